@@ -0,0 +1,299 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package align implements banded local alignment of a query sequence
+// against a reference window, for use in soft-clip rescue and indel
+// realignment.
+//
+// Alignments are scored with affine gap penalties following the
+// Smith-Waterman-Gotoh algorithm. On amd64 the dynamic programming
+// matrix is filled along anti-diagonals, whose cells are independent,
+// computing eight cells at once with SSE2 instructions. The portable
+// scalar implementation is used on other architectures, when the purego
+// build tag is set, and when the scores of an alignment may not fit in
+// 15 bits.
+package align
+
+import (
+	"errors"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+var ErrInvalidScoring = errors.New("align: invalid scoring")
+
+// Scoring is an alignment scoring scheme. Match is the score of an
+// aligned pair of identical bases and Mismatch is the penalty for an
+// aligned pair of differing bases. A gap of length n is penalised by
+// GapOpen + n*GapExtend. Only the bases A, C, G and T, in either case,
+// match; all other bases are mismatches.
+type Scoring struct {
+	Match     int
+	Mismatch  int
+	GapOpen   int
+	GapExtend int
+}
+
+// DefaultScoring is the scoring scheme used when none is specified.
+var DefaultScoring = Scoring{Match: 1, Mismatch: 4, GapOpen: 6, GapExtend: 1}
+
+// Options specifies the behaviour of an Aligner.
+type Options struct {
+	// Scoring is the alignment scoring scheme.
+	// If Scoring is the zero value,
+	// DefaultScoring is used.
+	Scoring Scoring
+
+	// Band is the number of diagonals of the
+	// alignment matrix searched beyond those
+	// placing the query wholly within the
+	// reference window, limiting the net
+	// length of gaps in an alignment. If Band
+	// is zero, the alignment is not banded.
+	Band int
+}
+
+// Alignment is a local alignment of a query to a reference window.
+type Alignment struct {
+	// Score is the score of the alignment.
+	Score int
+
+	// QueryStart and QueryEnd are the
+	// zero-based half-open interval of
+	// the query that is aligned, and
+	// RefStart and RefEnd are those of
+	// the reference window.
+	QueryStart, QueryEnd int
+	RefStart, RefEnd     int
+
+	// Cigar is the alignment of the query
+	// starting at RefStart, with unaligned
+	// query bases soft clipped.
+	Cigar sam.Cigar
+}
+
+// Aligner performs banded local alignments. An Aligner reuses its
+// working memory between alignments and is not safe for concurrent use.
+type Aligner struct {
+	scoring Scoring
+	band    int
+
+	// dir holds the traceback of the
+	// banded matrix in rows of stride
+	// cells for each anti-diagonal.
+	dir    []byte
+	hi     int
+	stride int
+
+	// Working rows of the kernels.
+	rows [7][]uint16
+	qc   []uint16
+	rc   []uint16
+	ints []int
+}
+
+// NewAligner returns a new Aligner using the given options.
+func NewAligner(opts Options) (*Aligner, error) {
+	s := opts.Scoring
+	if s == (Scoring{}) {
+		s = DefaultScoring
+	}
+	if s.Match <= 0 || s.Mismatch < 0 || s.GapOpen < 0 || s.GapExtend < 0 {
+		return nil, ErrInvalidScoring
+	}
+	band := opts.Band
+	if band < 0 {
+		band = 0
+	}
+	return &Aligner{scoring: s, band: band}, nil
+}
+
+// Align returns the best scoring local alignment of query to the
+// reference window ref. Ties between alignments are broken in favour of
+// the alignment with the fewest combined query and reference bases up
+// to its end, and then the fewest query bases. If no part of the query
+// aligns with a positive score, the returned Alignment has a zero Score
+// and a nil Cigar.
+func (a *Aligner) Align(query, ref []byte) Alignment {
+	return a.align(query, ref, a.fill)
+}
+
+// align returns the best scoring local alignment of query to ref using
+// the given matrix fill implementation.
+func (a *Aligner) align(query, ref []byte, fill func(q, r []byte, lo, hi int) (best, bi, bj int)) Alignment {
+	n, m := len(query), len(ref)
+	if n == 0 || m == 0 {
+		return Alignment{}
+	}
+
+	// Cells (i, j) of the matrix are held in the
+	// band when lo <= j-i <= hi.
+	lo, hi := -(n - 1), m-1
+	if a.band > 0 {
+		lo = minInt(0, m-n) - a.band
+		if lo < -(n - 1) {
+			lo = -(n - 1)
+		}
+		hi = maxInt(0, m-n) + a.band
+		if hi > m-1 {
+			hi = m - 1
+		}
+	}
+	// Each anti-diagonal holds at most (hi-lo)/2+1
+	// cells of the band, with room for the SSE2
+	// implementation to store eight cells at once.
+	a.hi = hi
+	a.stride = (hi-lo)/2 + 9
+	size := (n + m - 1) * a.stride
+	if cap(a.dir) < size {
+		a.dir = make([]byte, size)
+	}
+	a.dir = a.dir[:size]
+
+	best, bi, bj := fill(query, ref, lo, hi)
+	if best == 0 {
+		return Alignment{}
+	}
+	return a.traceback(best, bi, bj, n)
+}
+
+// Traceback codes held in the dir matrix. The low two bits of
+// a cell hold the source of the cell's score, and the remaining
+// bits whether the gap scores of the cell extend an existing gap.
+const (
+	fromNone = iota
+	fromDiag
+	fromUp   // Gap in the reference; consumes query.
+	fromLeft // Gap in the query; consumes reference.
+	fromMask = 0x3
+
+	extendUp   = 0x4
+	extendLeft = 0x8
+)
+
+// cell returns the index in the traceback matrix of the cell for query
+// position i and reference position j.
+func (a *Aligner) cell(i, j int) int {
+	d := i + j
+	return d*a.stride + i - a.base(d)
+}
+
+// base returns the offset of the first cell of anti-diagonal d in its
+// row of the traceback matrix, the query position of the cell on the
+// hi diagonal of the band rounded down.
+func (a *Aligner) base(d int) int {
+	// Rounded towards negative infinity.
+	if d < a.hi {
+		return -((a.hi - d + 1) / 2)
+	}
+	return (d - a.hi) / 2
+}
+
+// traceback returns the alignment with the given score ending at the
+// query and reference positions bi and bj.
+func (a *Aligner) traceback(score, bi, bj, n int) Alignment {
+	var (
+		rev   []sam.CigarOp
+		i, j  = bi, bj
+		state = fromDiag
+	)
+	push := func(t sam.CigarOpType) {
+		if k := len(rev) - 1; k >= 0 && rev[k].Type() == t {
+			rev[k] = sam.NewCigarOp(t, rev[k].Len()+1)
+			return
+		}
+		rev = append(rev, sam.NewCigarOp(t, 1))
+	}
+loop:
+	for i >= 0 && j >= 0 {
+		d := a.dir[a.cell(i, j)]
+		switch state {
+		case fromUp:
+			push(sam.CigarInsertion)
+			if d&extendUp == 0 {
+				state = fromDiag
+			}
+			i--
+		case fromLeft:
+			push(sam.CigarDeletion)
+			if d&extendLeft == 0 {
+				state = fromDiag
+			}
+			j--
+		default:
+			switch d & fromMask {
+			case fromNone:
+				break loop
+			case fromDiag:
+				push(sam.CigarMatch)
+				i--
+				j--
+			case fromUp:
+				state = fromUp
+			case fromLeft:
+				state = fromLeft
+			}
+		}
+	}
+
+	aln := Alignment{
+		Score:      score,
+		QueryStart: i + 1,
+		QueryEnd:   bi + 1,
+		RefStart:   j + 1,
+		RefEnd:     bj + 1,
+	}
+	if aln.QueryStart != 0 {
+		aln.Cigar = append(aln.Cigar, sam.NewCigarOp(sam.CigarSoftClipped, aln.QueryStart))
+	}
+	for k := len(rev) - 1; k >= 0; k-- {
+		aln.Cigar = append(aln.Cigar, rev[k])
+	}
+	if aln.QueryEnd != n {
+		aln.Cigar = append(aln.Cigar, sam.NewCigarOp(sam.CigarSoftClipped, n-aln.QueryEnd))
+	}
+	return aln
+}
+
+// Base codes used to compare query and reference bases. Bases other
+// than A, C, G and T are given codes that never compare equal.
+const (
+	queryOther = 4
+	refOther   = 5
+)
+
+var baseCode = func() [256]byte {
+	var c [256]byte
+	for i := range c {
+		c[i] = queryOther
+	}
+	for i, b := range []byte("ACGT") {
+		c[b] = byte(i)
+		c[b|0x20] = byte(i)
+	}
+	return c
+}()
+
+// refCode returns the comparison code of the reference base b.
+func refCode(b byte) byte {
+	c := baseCode[b]
+	if c == queryOther {
+		return refOther
+	}
+	return c
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,311 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+var testScoring = Scoring{Match: 2, Mismatch: 3, GapOpen: 4, GapExtend: 1}
+
+func TestAlign(t *testing.T) {
+	const ref = "ACGTTGCAACGGTACCATGA"
+	for _, test := range []struct {
+		name  string
+		query string
+		ref   string
+		band  int
+		want  Alignment
+	}{
+		{
+			name:  "exact",
+			query: "ACGTACGT",
+			ref:   "TTACGTACGTTT",
+			want:  Alignment{Score: 16, QueryStart: 0, QueryEnd: 8, RefStart: 2, RefEnd: 10, Cigar: cigar(t, "8M")},
+		},
+		{
+			name:  "clipped",
+			query: "GGGGACGTACGTCC",
+			ref:   "TTACGTACGTTT",
+			want:  Alignment{Score: 16, QueryStart: 4, QueryEnd: 12, RefStart: 2, RefEnd: 10, Cigar: cigar(t, "4S8M2S")},
+		},
+		{
+			name:  "lower case",
+			query: "acgtacgt",
+			ref:   "TTACGTACGTTT",
+			want:  Alignment{Score: 16, QueryStart: 0, QueryEnd: 8, RefStart: 2, RefEnd: 10, Cigar: cigar(t, "8M")},
+		},
+		{
+			name:  "mismatch",
+			query: "ACGTTGCAACTGTACCATGA",
+			ref:   ref,
+			want:  Alignment{Score: 35, QueryStart: 0, QueryEnd: 20, RefStart: 0, RefEnd: 20, Cigar: cigar(t, "20M")},
+		},
+		{
+			name:  "deletion",
+			query: ref[:10] + ref[12:],
+			ref:   ref,
+			want:  Alignment{Score: 30, QueryStart: 0, QueryEnd: 18, RefStart: 0, RefEnd: 20, Cigar: cigar(t, "10M2D8M")},
+		},
+		{
+			name:  "insertion",
+			query: ref[:10] + "TT" + ref[10:],
+			ref:   ref,
+			want:  Alignment{Score: 34, QueryStart: 0, QueryEnd: 22, RefStart: 0, RefEnd: 20, Cigar: cigar(t, "10M2I10M")},
+		},
+		{
+			name:  "insertion in band",
+			query: ref[:10] + "TTT" + ref[10:17],
+			ref:   ref,
+			band:  3,
+			want:  Alignment{Score: 27, QueryStart: 0, QueryEnd: 20, RefStart: 0, RefEnd: 17, Cigar: cigar(t, "10M3I7M")},
+		},
+		{
+			name:  "insertion outside band",
+			query: ref[:10] + "TTT" + ref[10:17],
+			ref:   ref,
+			band:  1,
+			want:  Alignment{Score: 20, QueryStart: 0, QueryEnd: 10, RefStart: 0, RefEnd: 10, Cigar: cigar(t, "10M10S")},
+		},
+		{
+			name:  "ambiguous",
+			query: "NNNN",
+			ref:   "NNNNACGT",
+			want:  Alignment{},
+		},
+		{
+			name:  "empty",
+			query: "",
+			ref:   ref,
+			want:  Alignment{},
+		},
+	} {
+		a, err := NewAligner(Options{Scoring: testScoring, Band: test.band})
+		if err != nil {
+			t.Fatalf("unexpected error creating aligner: %v", err)
+		}
+		got := a.Align([]byte(test.query), []byte(test.ref))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected alignment for %s:\ngot: %+v %v\nwant:%+v %v", test.name, got, got.Cigar, test.want, test.want.Cigar)
+		}
+		got = a.align([]byte(test.query), []byte(test.ref), a.fillScalar)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected scalar alignment for %s:\ngot: %+v %v\nwant:%+v %v", test.name, got, got.Cigar, test.want, test.want.Cigar)
+		}
+	}
+}
+
+func TestNewAligner(t *testing.T) {
+	for _, s := range []Scoring{
+		{Match: 0, Mismatch: 1},
+		{Match: 1, Mismatch: -1},
+		{Match: 1, GapOpen: -1},
+		{Match: 1, GapExtend: -1},
+	} {
+		_, err := NewAligner(Options{Scoring: s})
+		if err != ErrInvalidScoring {
+			t.Errorf("unexpected error for %+v: got:%v want:%v", s, err, ErrInvalidScoring)
+		}
+	}
+	a, err := NewAligner(Options{})
+	if err != nil {
+		t.Fatalf("unexpected error creating default aligner: %v", err)
+	}
+	if a.scoring != DefaultScoring {
+		t.Errorf("unexpected default scoring: %+v", a.scoring)
+	}
+}
+
+func TestAlignRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, s := range []Scoring{
+		DefaultScoring,
+		testScoring,
+		{Match: 1, Mismatch: 1, GapOpen: 0, GapExtend: 1},
+		{Match: 3, Mismatch: 2, GapOpen: 5, GapExtend: 2},
+		// Scores that do not fit in a lane.
+		{Match: 2000, Mismatch: 3000, GapOpen: 4000, GapExtend: 1000},
+	} {
+		for _, band := range []int{0, 1, 4, 16} {
+			a, err := NewAligner(Options{Scoring: s, Band: band})
+			if err != nil {
+				t.Fatalf("unexpected error creating aligner: %v", err)
+			}
+			for k := 0; k < 200; k++ {
+				ref := randomSeq(rnd, 1+rnd.Intn(80))
+				query := mutate(rnd, ref[rnd.Intn(len(ref)):])
+				if len(query) == 0 {
+					continue
+				}
+
+				got := a.Align(query, ref)
+				scalar := a.align(query, ref, a.fillScalar)
+				if !reflect.DeepEqual(got, scalar) {
+					t.Errorf("mismatched implementations for %+v band=%d query=%s ref=%s:\ngot: %+v %v\nscalar:%+v %v",
+						s, band, query, ref, got, got.Cigar, scalar, scalar.Cigar)
+				}
+
+				want := naiveScore(query, ref, s, band)
+				if got.Score != want {
+					t.Errorf("unexpected score for %+v band=%d query=%s ref=%s: got:%d want:%d",
+						s, band, query, ref, got.Score, want)
+				}
+				if got.Score == 0 {
+					continue
+				}
+				if !got.Cigar.IsValid(len(query)) {
+					t.Errorf("invalid cigar for query=%s ref=%s: %v", query, ref, got.Cigar)
+				}
+				if score := rescore(got, query, ref, s); score != got.Score {
+					t.Errorf("cigar score does not match alignment score for query=%s ref=%s %v: got:%d want:%d",
+						query, ref, got.Cigar, score, got.Score)
+				}
+			}
+		}
+	}
+}
+
+func cigar(t *testing.T, s string) sam.Cigar {
+	c, err := sam.ParseCigar([]byte(s))
+	if err != nil {
+		t.Fatalf("unexpected error parsing cigar: %v", err)
+	}
+	return c
+}
+
+func randomSeq(rnd *rand.Rand, n int) []byte {
+	s := make([]byte, n)
+	for i := range s {
+		if rnd.Intn(50) == 0 {
+			s[i] = 'N'
+			continue
+		}
+		s[i] = "ACGT"[rnd.Intn(4)]
+	}
+	return s
+}
+
+// mutate returns a copy of s with random substitutions, insertions
+// and deletions.
+func mutate(rnd *rand.Rand, s []byte) []byte {
+	var m []byte
+	for _, b := range s {
+		switch rnd.Intn(20) {
+		case 0:
+			m = append(m, "ACGT"[rnd.Intn(4)])
+		case 1:
+			m = append(m, b)
+			m = append(m, randomSeq(rnd, 1+rnd.Intn(4))...)
+		case 2:
+			// Delete b.
+		default:
+			m = append(m, b)
+		}
+	}
+	return m
+}
+
+// naiveScore returns the best local alignment score of query and ref
+// computed over the complete alignment matrix.
+func naiveScore(query, ref []byte, s Scoring, band int) int {
+	const minInf = -1 << 30
+	n, m := len(query), len(ref)
+	lo, hi := -n, m
+	if band > 0 {
+		lo = minInt(0, m-n) - band
+		hi = maxInt(0, m-n) + band
+	}
+	h := make([][]int, n+1)
+	e := make([][]int, n+1)
+	f := make([][]int, n+1)
+	for i := range h {
+		h[i] = make([]int, m+1)
+		e[i] = make([]int, m+1)
+		f[i] = make([]int, m+1)
+		for j := range e[i] {
+			e[i][j], f[i][j] = minInf, minInf
+		}
+	}
+	var best int
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			if j-i < lo || j-i > hi {
+				continue
+			}
+			e[i][j] = maxInt(h[i-1][j]-s.GapOpen-s.GapExtend, e[i-1][j]-s.GapExtend)
+			f[i][j] = maxInt(h[i][j-1]-s.GapOpen-s.GapExtend, f[i][j-1]-s.GapExtend)
+			sub := h[i-1][j-1] - s.Mismatch
+			if isMatch(query[i-1], ref[j-1]) {
+				sub = h[i-1][j-1] + s.Match
+			}
+			h[i][j] = maxInt(0, maxInt(sub, maxInt(e[i][j], f[i][j])))
+			best = maxInt(best, h[i][j])
+		}
+	}
+	return best
+}
+
+// rescore returns the score of the alignment described by aln.
+func rescore(aln Alignment, query, ref []byte, s Scoring) int {
+	var score int
+	i, j := aln.QueryStart, aln.RefStart
+	for _, co := range aln.Cigar {
+		switch n := co.Len(); co.Type() {
+		case sam.CigarMatch:
+			for k := 0; k < n; k++ {
+				if isMatch(query[i+k], ref[j+k]) {
+					score += s.Match
+				} else {
+					score -= s.Mismatch
+				}
+			}
+			i += n
+			j += n
+		case sam.CigarInsertion:
+			score -= s.GapOpen + n*s.GapExtend
+			i += n
+		case sam.CigarDeletion:
+			score -= s.GapOpen + n*s.GapExtend
+			j += n
+		}
+	}
+	if i != aln.QueryEnd || j != aln.RefEnd {
+		return -1
+	}
+	return score
+}
+
+func isMatch(q, r byte) bool {
+	q &^= 0x20
+	r &^= 0x20
+	return q == r && (q == 'A' || q == 'C' || q == 'G' || q == 'T')
+}
+
+func BenchmarkAlign(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	ref := randomSeq(rnd, 300)
+	query := mutate(rnd, ref[50:200])
+	a, err := NewAligner(Options{Band: 16})
+	if err != nil {
+		b.Fatalf("unexpected error creating aligner: %v", err)
+	}
+	for _, bench := range []struct {
+		name string
+		fill func(q, r []byte, lo, hi int) (best, bi, bj int)
+	}{
+		{name: "fill", fill: a.fill},
+		{name: "scalar", fill: a.fillScalar},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				a.align(query, ref, bench.fill)
+			}
+		})
+	}
+}
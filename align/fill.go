@@ -0,0 +1,103 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+// fillScalar fills the traceback matrix of a for the alignment of q to r
+// within the band lo <= j-i <= hi, one query base at a time, and returns
+// the best score and the query and reference positions of its cell.
+//
+// Scores are clamped at zero, including the scores of alignments ending
+// in a gap. Since a gap score can only decrease as the gap is extended,
+// a clamped gap score never contributes to a positive alignment score.
+// The SSE2 implementation depends on this to hold scores in unsigned
+// lanes.
+func (a *Aligner) fillScalar(q, r []byte, lo, hi int) (best, bi, bj int) {
+	s := a.scoring
+	goe, ge := s.GapOpen+s.GapExtend, s.GapExtend
+	n, m := len(q), len(r)
+
+	// Rows are indexed by reference position
+	// plus one so that column -1 is held.
+	stride := m + 2
+	if cap(a.ints) < 4*stride {
+		a.ints = make([]int, 4*stride)
+	}
+	a.ints = a.ints[:4*stride]
+	for i := range a.ints {
+		a.ints[i] = 0
+	}
+	hp, ep := a.ints[:stride], a.ints[stride:2*stride]
+	hc, ec := a.ints[2*stride:3*stride], a.ints[3*stride:]
+
+	for i := 0; i < n; i++ {
+		hp, hc = hc, hp
+		ep, ec = ec, ep
+		first, last := maxInt(0, i+lo), minInt(m-1, i+hi)
+		if first > last {
+			for j := range hc {
+				hc[j], ec[j] = 0, 0
+			}
+			continue
+		}
+		qb := baseCode[q[i]]
+		var hl, fl int
+		for j := first; j <= last; j++ {
+			e, extE := gap(hp[j+1], ep[j+1], goe, ge)
+			f, extF := gap(hl, fl, goe, ge)
+			var sub int
+			if qb == refCode(r[j]) {
+				sub = hp[j] + s.Match
+			} else {
+				sub = maxInt(0, hp[j]-s.Mismatch)
+			}
+			h := maxInt(sub, maxInt(e, f))
+
+			var d byte
+			switch {
+			case h == 0:
+				d = fromNone
+			case sub == h:
+				d = fromDiag
+			case e == h:
+				d = fromUp
+			default:
+				d = fromLeft
+			}
+			if extE {
+				d |= extendUp
+			}
+			if extF {
+				d |= extendLeft
+			}
+			a.dir[a.cell(i, j)] = d
+
+			hc[j+1], ec[j+1] = h, e
+			hl, fl = h, f
+			if h > best || (h == best && (i+j < bi+bj || (i+j == bi+bj && i < bi))) {
+				best, bi, bj = h, i, j
+			}
+		}
+
+		// Cells either side of the band are
+		// read as unaligned by the next row.
+		hc[first], ec[first] = 0, 0
+		if last+2 < stride {
+			hc[last+2], ec[last+2] = 0, 0
+		}
+	}
+	return best, bi, bj
+}
+
+// gap returns the score of a gap ending at a cell given the alignment
+// score h and gap score g of the preceding cell, and whether the gap
+// extends the gap of the preceding cell.
+func gap(h, g, open, extend int) (int, bool) {
+	o := maxInt(0, h-open)
+	e := maxInt(0, g-extend)
+	if e > o {
+		return e, true
+	}
+	return o, false
+}
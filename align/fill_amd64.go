@@ -0,0 +1,169 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build amd64 && !purego
+
+package align
+
+// This file contains the SSE2 implementation of the alignment matrix
+// fill. The portable implementation in fill_generic.go is used on other
+// architectures and when the purego build tag is set.
+//
+// Cells of an anti-diagonal depend only on the two preceding
+// anti-diagonals, so the cells of an anti-diagonal are computed eight at
+// a time, indexed by query position, in 16-bit lanes. Scores are held
+// unsigned and clamped at zero with saturating subtraction, and must not
+// exceed maxLane so that they may be compared as signed values.
+
+// maxLane is the largest score held in a lane.
+const maxLane = 0x7fff
+
+// kernelConsts holds the lane values used by fillDiagSSE2.
+type kernelConsts struct {
+	match    [8]uint16
+	mismatch [8]uint16
+	open     [8]uint16
+	extend   [8]uint16
+	left     [8]uint16
+	lanes    [8]uint16
+}
+
+// fillDiagSSE2 computes the n cells of an anti-diagonal starting at
+// the first query position of the anti-diagonal, storing the alignment
+// and gap scores of the cells in h, e and f, and their traceback codes
+// in dir. The scores of the cells above, to the left and diagonally
+// above are read from hu and eu, hu+1 and fl, and hd, and the base codes
+// of the cells from q and r. Lanes past n are stored as zero in h, e and
+// f, and as undefined codes in dir. The greatest score of the cells is
+// returned.
+//
+//go:noescape
+func fillDiagSSE2(h, e, f, hu, eu, fl, hd, q, r *uint16, dir *byte, n int, c *kernelConsts) (best uint16)
+
+// fill fills the traceback matrix of a for the alignment of q to r
+// within the band lo <= j-i <= hi and returns the best score and the
+// query and reference positions of its cell.
+func (a *Aligner) fill(q, r []byte, lo, hi int) (best, bi, bj int) {
+	s := a.scoring
+	if s.Match*(minInt(len(q), len(r))+1) > maxLane || s.Mismatch > maxLane || s.GapOpen+s.GapExtend > maxLane {
+		return a.fillScalar(q, r, lo, hi)
+	}
+	return a.fillSSE2(q, r, lo, hi)
+}
+
+// fillSSE2 is the SSE2 implementation of fill. The scores of q and r
+// under the scoring of a must not exceed maxLane.
+func (a *Aligner) fillSSE2(q, r []byte, lo, hi int) (best, bi, bj int) {
+	s := a.scoring
+	c := kernelConsts{
+		match:    splat(s.Match),
+		mismatch: splat(s.Mismatch),
+		open:     splat(s.GapOpen + s.GapExtend),
+		extend:   splat(s.GapExtend),
+		left:     splat(fromLeft),
+		lanes:    [8]uint16{0, 1, 2, 3, 4, 5, 6, 7},
+	}
+	n, m := len(q), len(r)
+
+	// Query codes are held in query order and
+	// reference codes in reverse order so that
+	// both advance with the query position along
+	// an anti-diagonal. Lanes past the end of the
+	// sequences pad loads of the last cells.
+	a.qc = resize(a.qc, n+16)
+	for i, b := range q {
+		a.qc[i] = uint16(baseCode[b])
+	}
+	a.rc = resize(a.rc, m+16)
+	for j, b := range r {
+		a.rc[m-1-j] = uint16(refCode(b))
+	}
+
+	// Scores of anti-diagonals d, d-1 and d-2
+	// are indexed by query position plus one so
+	// that row -1 is held. Cells outside the band
+	// on the preceding anti-diagonals must read
+	// as zero.
+	for k := range a.rows {
+		a.rows[k] = resize(a.rows[k], n+16)
+	}
+	var (
+		hc, hp, hpp = a.rows[0], a.rows[1], a.rows[2]
+		ec, ep      = a.rows[3], a.rows[4]
+		fc, fp      = a.rows[5], a.rows[6]
+	)
+	for d := 0; d <= n+m-2; d++ {
+		hpp, hp, hc = hp, hc, hpp
+		ep, ec = ec, ep
+		fp, fc = fc, fp
+		first, last := bounds(d, n, m, lo, hi)
+		if first > last {
+			for _, row := range [][]uint16{hc, ec, fc} {
+				for k := range row {
+					row[k] = 0
+				}
+			}
+			continue
+		}
+
+		v := int(fillDiagSSE2(
+			&hc[first+1], &ec[first+1], &fc[first+1],
+			&hp[first], &ep[first], &fp[first+1], &hpp[first],
+			&a.qc[first], &a.rc[first+m-1-d],
+			&a.dir[d*a.stride+first-a.base(d)],
+			last-first+1, &c,
+		))
+
+		// Cells either side of the band are read
+		// as unaligned by the next anti-diagonals.
+		hc[first], ec[first], fc[first] = 0, 0, 0
+		hc[last+2], ec[last+2], fc[last+2] = 0, 0, 0
+
+		// Cells of earlier anti-diagonals are
+		// preferred, so only a greater score on
+		// this anti-diagonal is a new best.
+		if v > best {
+			for i := first; ; i++ {
+				if int(hc[i+1]) == v {
+					best, bi, bj = v, i, d-i
+					break
+				}
+			}
+		}
+	}
+	return best, bi, bj
+}
+
+// bounds returns the first and last query positions of anti-diagonal d
+// of an n by m matrix banded by lo and hi. If the anti-diagonal holds
+// no cells in the band, first is greater than last.
+func bounds(d, n, m, lo, hi int) (first, last int) {
+	first = maxInt(0, d-m+1)
+	if d > hi {
+		first = maxInt(first, (d-hi+1)/2)
+	}
+	last = minInt(n-1, minInt(d, (d-lo)/2))
+	return first, last
+}
+
+// resize returns s with length n and all elements zero, reallocating
+// if the capacity of s is less than n.
+func resize(s []uint16, n int) []uint16 {
+	if cap(s) < n {
+		return make([]uint16, n)
+	}
+	s = s[:n]
+	for i := range s {
+		s[i] = 0
+	}
+	return s
+}
+
+// splat returns v in each lane.
+func splat(v int) (s [8]uint16) {
+	for i := range s {
+		s[i] = uint16(v)
+	}
+	return s
+}
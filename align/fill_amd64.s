@@ -0,0 +1,126 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// func fillDiagSSE2(h, e, f, hu, eu, fl, hd, q, r *uint16, dir *byte, n int, c *kernelConsts) (best uint16)
+TEXT ·fillDiagSSE2(SB), NOSPLIT, $0-98
+	MOVQ  c+88(FP), SI
+	MOVOU 0(SI), X12  // Match.
+	MOVOU 16(SI), X13 // Mismatch.
+	MOVOU 32(SI), X14 // Gap open.
+	MOVOU 48(SI), X15 // Gap extend.
+	MOVOU 64(SI), X10 // fromLeft.
+	MOVOU 80(SI), X11 // Lane indices.
+
+	MOVQ h+0(FP), AX
+	MOVQ e+8(FP), BX
+	MOVQ f+16(FP), DX
+	MOVQ hu+24(FP), R8
+	MOVQ eu+32(FP), R9
+	MOVQ fl+40(FP), R10
+	MOVQ hd+48(FP), R11
+	MOVQ q+56(FP), R12
+	MOVQ r+64(FP), R13
+	MOVQ dir+72(FP), DI
+	MOVQ n+80(FP), CX
+	XORQ SI, SI
+	PXOR X8, X8
+
+loop:
+	// Gap in the reference from the cell above;
+	// X0 holds e and X4 whether it is extended.
+	MOVOU   (R8)(SI*1), X0
+	MOVOU   (R9)(SI*1), X2
+	PSUBUSW X14, X0
+	PSUBUSW X15, X2
+	MOVO    X2, X4
+	PCMPGTW X0, X4
+	PMAXSW  X2, X0
+
+	// Gap in the query from the cell to the left;
+	// X1 holds f and X5 whether it is extended.
+	MOVOU   2(R8)(SI*1), X1
+	MOVOU   (R10)(SI*1), X3
+	PSUBUSW X14, X1
+	PSUBUSW X15, X3
+	MOVO    X3, X5
+	PCMPGTW X1, X5
+	PMAXSW  X3, X1
+
+	// Substitution from the cell diagonally above;
+	// X2 holds the score.
+	MOVOU   (R12)(SI*1), X6
+	MOVOU   (R13)(SI*1), X7
+	PCMPEQW X7, X6
+	MOVO    X12, X7
+	PAND    X6, X7
+	MOVOU   (R11)(SI*1), X2
+	PADDUSW X7, X2
+	PANDN   X13, X6
+	PSUBUSW X6, X2
+
+	// X3 holds h.
+	MOVO   X2, X3
+	PMAXSW X0, X3
+	PMAXSW X1, X3
+
+	// Clear lanes past the last cell.
+	CMPQ       CX, $8
+	JGE        store
+	MOVQ       CX, X6
+	PSHUFLW    $0, X6, X6
+	PUNPCKLQDQ X6, X6
+	PCMPGTW    X11, X6
+	PAND       X6, X3
+	PAND       X6, X0
+	PAND       X6, X1
+
+store:
+	MOVOU  X3, (AX)(SI*1)
+	MOVOU  X0, (BX)(SI*1)
+	MOVOU  X1, (DX)(SI*1)
+	PMAXSW X3, X8
+
+	// Traceback codes. The source of h is
+	// fromLeft (3), less one where h is e and
+	// less two where h is the substitution
+	// score, or fromNone where h is zero.
+	PCMPEQW X3, X2
+	PCMPEQW X3, X0
+	MOVO    X2, X9
+	PANDN   X0, X9
+	MOVO    X10, X7
+	PADDW   X9, X7
+	PADDW   X2, X7
+	PADDW   X2, X7
+	PXOR    X6, X6
+	PCMPEQW X3, X6
+	PANDN   X7, X6
+	PSRLW   $15, X4
+	PSLLW   $2, X4
+	PSRLW   $15, X5
+	PSLLW   $3, X5
+	POR     X4, X6
+	POR     X5, X6
+	PACKUSWB X6, X6
+	MOVQ    X6, (DI)
+
+	ADDQ $16, SI
+	ADDQ $8, DI
+	SUBQ $8, CX
+	JG   loop
+
+	// Reduce the greatest score across lanes.
+	PSHUFD  $0x4e, X8, X0
+	PMAXSW  X0, X8
+	PSHUFD  $0xb1, X8, X0
+	PMAXSW  X0, X8
+	PSHUFLW $0xb1, X8, X0
+	PMAXSW  X0, X8
+	MOVQ    X8, AX
+	MOVW    AX, best+96(FP)
+	RET
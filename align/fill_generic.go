@@ -0,0 +1,14 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !amd64 || purego
+
+package align
+
+// fill fills the traceback matrix of a for the alignment of q to r
+// within the band lo <= j-i <= hi and returns the best score and the
+// query and reference positions of its cell.
+func (a *Aligner) fill(q, r []byte, lo, hi int) (best, bi, bj int) {
+	return a.fillScalar(q, r, lo, hi)
+}
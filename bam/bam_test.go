@@ -294,6 +294,41 @@ func BenchmarkReadCoreOnly(b *testing.B) {
 	f.Close()
 }
 
+func BenchmarkUnmarshal(b *testing.B) {
+	br, err := NewReader(bytes.NewReader(bamHG00096_1000), *conc)
+	if err != nil {
+		b.Fatalf("NewReader failed: %v", err)
+	}
+	h := br.Header()
+	var recs [][]byte
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			b.Fatalf("Read failed: %v", err)
+		}
+		var buf bytes.Buffer
+		err = Marshal(r, &buf)
+		if err != nil {
+			b.Fatalf("Marshal failed: %v", err)
+		}
+		recs = append(recs, buf.Bytes())
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, buf := range recs {
+			r, err := Unmarshal(buf, h)
+			if err != nil {
+				b.Fatalf("Unmarshal failed: %v", err)
+			}
+			sam.PutInFreePool(r)
+		}
+	}
+}
+
 func BenchmarkWrite(b *testing.B) {
 	b.StopTimer()
 	br, err := NewReader(bytes.NewReader(bamHG00096_1000), *conc)
@@ -0,0 +1,73 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"io"
+
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+// BEDRegions returns the features of s as regions on the references of
+// h, for use with NewRegionIterator. Regions are returned in the
+// chromosome order of s and in start order within each chromosome.
+// Features on chromosomes not in h are omitted.
+func BEDRegions(s *bed.Set, h *sam.Header) []Region {
+	refs := make(map[string]*sam.Reference, len(h.Refs()))
+	for _, r := range h.Refs() {
+		refs[r.Name()] = r
+	}
+	var regions []Region
+	for _, c := range s.Chroms() {
+		ref, ok := refs[c]
+		if !ok {
+			continue
+		}
+		for _, f := range s.Tree(c).Features() {
+			regions = append(regions, Region{Ref: ref, Start: f.Start, End: f.End})
+		}
+	}
+	return regions
+}
+
+// ReadBEDRegions returns the features read from r as regions on the
+// references of h. Features on chromosomes not in h are omitted.
+func ReadBEDRegions(r *bed.Reader, h *sam.Header) ([]Region, error) {
+	var features []*bed.Feature
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, f)
+	}
+	return BEDRegions(bed.NewSet(features), h), nil
+}
+
+// QueryBED returns an Iterator to read from r the records that overlap
+// any of the features read from features, using idx to find the data
+// to read. Features on references not in the header of r are ignored.
+// Records are returned in file order and each record is returned at
+// most once.
+//
+//	it, err := bam.QueryBED(r, idx, bed.NewReader(f))
+//	if err != nil {
+//		return err
+//	}
+//	for it.Next() {
+//		fn(it.Record())
+//	}
+//	return it.Close()
+func QueryBED(r *Reader, idx *Index, features *bed.Reader) (*Iterator, error) {
+	regions, err := ReadBEDRegions(features, r.Header())
+	if err != nil {
+		return nil, err
+	}
+	return NewRegionIterator(r, idx, regions)
+}
@@ -0,0 +1,123 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestBEDRegions(t *testing.T) {
+	const features = "chr2\t100\t200\ta\n" +
+		"chr1\t0\t50\tb\n" +
+		"chr2\t150\t300\tc\n" +
+		"chrUn\t0\t10\td\n"
+	var refs []*sam.Reference
+	for _, name := range []string{"chr1", "chr2"} {
+		ref, err := sam.NewReference(name, "", "", 1000, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating reference: %v", err)
+		}
+		refs = append(refs, ref)
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	want := []Region{
+		{Ref: refs[1], Start: 100, End: 200},
+		{Ref: refs[1], Start: 150, End: 300},
+		{Ref: refs[0], Start: 0, End: 50},
+	}
+
+	s, err := bed.ReadSet(strings.NewReader(features))
+	if err != nil {
+		t.Fatalf("unexpected error reading set: %v", err)
+	}
+	if got := BEDRegions(s, h); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected regions:\ngot: %+v\nwant:%+v", got, want)
+	}
+	got, err := ReadBEDRegions(bed.NewReader(strings.NewReader(features)), h)
+	if err != nil {
+		t.Fatalf("unexpected error reading regions: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected read regions:\ngot: %+v\nwant:%+v", got, want)
+	}
+
+	_, err = ReadBEDRegions(bed.NewReader(strings.NewReader("chr1\tx\t10\n")), h)
+	if err == nil {
+		t.Error("expected error for invalid feature")
+	}
+}
+
+func TestQueryBED(t *testing.T) {
+	data, err := sortedBAM(20000, 1)
+	if err != nil {
+		t.Fatalf("failed to create BAM: %v", err)
+	}
+	recs, _, err := readAll(data)
+	if err != nil {
+		t.Fatalf("failed to read BAM: %v", err)
+	}
+	idx, err := indexBAM(data)
+	if err != nil {
+		t.Fatalf("failed to index BAM: %v", err)
+	}
+
+	const features = "chr2\t700000\t800000\n" +
+		"chr1\t40000\t90000\n" +
+		"chr1\t1000\t50000\n" +
+		"chrUn\t0\t1000000\n"
+	type key struct {
+		name string
+		pos  int
+	}
+	var want []key
+	for _, r := range recs {
+		for _, f := range []struct {
+			ref        string
+			start, end int
+		}{
+			{"chr1", 1000, 90000},
+			{"chr2", 700000, 800000},
+		} {
+			if r.Ref.Name() == f.ref && r.Pos < f.end && r.End() > f.start {
+				want = append(want, key{r.Name, r.Pos})
+				break
+			}
+		}
+	}
+	if len(want) == 0 {
+		t.Fatal("no records in test features")
+	}
+
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("failed to open BAM: %v", err)
+	}
+	defer br.Close()
+	it, err := QueryBED(br, idx, bed.NewReader(strings.NewReader(features)))
+	if err != nil {
+		t.Fatalf("unexpected error creating iterator: %v", err)
+	}
+	var got []key
+	for it.Next() {
+		r := it.Record()
+		got = append(got, key{r.Name, r.Pos})
+	}
+	err = it.Close()
+	if err != nil {
+		t.Fatalf("unexpected error iterating: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected records: got %d records want %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Cat writes the records of the BAM streams read from inputs to w as a
+// single BAM stream, in the manner of samtools cat. The header of the
+// first input is written and the headers and EOF blocks of the inputs
+// are dropped. Only the BGZF blocks holding the input headers are
+// decompressed; all following blocks are copied without recompression.
+//
+// The references of all inputs must have the same names and lengths in
+// the same order. All input headers are read and checked before any
+// records are written.
+func Cat(w io.Writer, inputs ...io.Reader) error {
+	_, err := cat(w, nil, inputs)
+	return err
+}
+
+// CatIndexed writes the records of the BAM streams read from inputs to
+// w as Cat does and returns the index of the written stream constructed
+// by MergeIndexes from idxs, the indexes of the inputs. Inputs must not
+// have records in their last header block, as is the case for BAM
+// streams written by Writer, and the written stream must be coordinate
+// sorted for the index to be valid.
+func CatIndexed(w io.Writer, idxs []*Index, inputs ...io.Reader) (*Index, error) {
+	if len(idxs) != len(inputs) {
+		return nil, errors.New("bam: index count does not match inputs")
+	}
+	offsets, err := cat(w, idxs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return MergeIndexes(idxs, offsets)
+}
+
+// cat performs the concatenation for Cat and CatIndexed, returning the
+// amount by which the compressed file offsets of each input are shifted
+// in the output. If idxs is not nil, inputs with records in their last
+// header block are rejected.
+func cat(w io.Writer, idxs []*Index, inputs []io.Reader) ([]int64, error) {
+	if len(inputs) == 0 {
+		return nil, errors.New("bam: no cat inputs")
+	}
+
+	// Each input contributes its recompressed
+	// header tail followed by the remainder
+	// of its stream, and the first input also
+	// contributes its header.
+	var (
+		first *sam.Header
+		parts = make([]io.Reader, 0, 2*len(inputs))
+		sizes = make([]int64, len(inputs))
+	)
+	for i, r := range inputs {
+		h, tail, size, err := readHeaderBlocks(r)
+		if err != nil {
+			return nil, err
+		}
+		if idxs != nil && len(tail) != 0 {
+			return nil, errors.New("bam: records in header block of indexed input")
+		}
+		if i == 0 {
+			first = h
+		} else {
+			if !sameRefs(first, h) {
+				return nil, errors.New("bam: incompatible headers")
+			}
+			h = nil
+		}
+		head, err := compressHead(h, tail)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, bytes.NewReader(head), r)
+		sizes[i] = size
+	}
+
+	offsets, err := bgzf.Cat(w, parts...)
+	if err != nil {
+		return nil, err
+	}
+	shifts := make([]int64, len(inputs))
+	for i, size := range sizes {
+		shifts[i] = offsets[2*i+1] - size
+	}
+	return shifts, nil
+}
+
+// sameRefs returns whether a and b have equivalent references in the
+// same order.
+func sameRefs(a, b *sam.Header) bool {
+	ra, rb := a.Refs(), b.Refs()
+	if len(ra) != len(rb) {
+		return false
+	}
+	for i, r := range ra {
+		if !r.EquivalentTo(rb[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestCat(t *testing.T) {
+	data := matePairs(t, 200)
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var (
+		idx    Index
+		want   []string
+		counts = make(map[string]int)
+	)
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("unexpected error adding record to index: %v", err)
+		}
+		want = append(want, r.String())
+		counts[r.Ref.Name()]++
+	}
+	refs := br.Header().Refs()
+
+	// Split the input by reference so that
+	// concatenation restores it.
+	shards := ReferenceShards(refs)
+	parts := make([]*bytes.Buffer, len(shards))
+	idxs, err := Split(br, &idx, shards, func(i int, _ Region) (io.Writer, error) {
+		parts[i] = &bytes.Buffer{}
+		return parts[i], nil
+	}, SplitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error splitting: %v", err)
+	}
+	inputs := func() []io.Reader {
+		r := make([]io.Reader, len(parts))
+		for i, p := range parts {
+			r[i] = bytes.NewReader(p.Bytes())
+		}
+		return r
+	}
+
+	var buf bytes.Buffer
+	err = Cat(&buf, inputs()...)
+	if err != nil {
+		t.Fatalf("unexpected error concatenating: %v", err)
+	}
+	got := readStrings(t, buf.Bytes())
+	if !equalStrings(got, want) {
+		t.Errorf("unexpected concatenated records: got:%d want:%d", len(got), len(want))
+	}
+
+	buf.Reset()
+	catIdx, err := CatIndexed(&buf, idxs, inputs()...)
+	if err != nil {
+		t.Fatalf("unexpected error concatenating: %v", err)
+	}
+	got = readStrings(t, buf.Bytes())
+	if !equalStrings(got, want) {
+		t.Errorf("unexpected concatenated records: got:%d want:%d", len(got), len(want))
+	}
+	cr, err := NewReader(bytes.NewReader(buf.Bytes()), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	for _, ref := range cr.Header().Refs() {
+		chunks, err := catIdx.Chunks(ref, 0, ref.Len())
+		if err != nil {
+			t.Fatalf("unexpected error getting chunks for %s: %v", ref.Name(), err)
+		}
+		it, err := NewIterator(cr, chunks)
+		if err != nil {
+			t.Fatalf("unexpected error creating iterator: %v", err)
+		}
+		var n int
+		for it.Next() {
+			if it.Record().Ref.Name() == ref.Name() {
+				n++
+			}
+		}
+		err = it.Close()
+		if err != nil {
+			t.Fatalf("unexpected error iterating: %v", err)
+		}
+		if n != counts[ref.Name()] {
+			t.Errorf("unexpected number of indexed records for %s: got:%d want:%d", ref.Name(), n, counts[ref.Name()])
+		}
+	}
+
+	other, err := sam.NewReference("chrX", "", "", 10, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{other})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	var bad bytes.Buffer
+	w, err := NewWriter(&bad, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	err = Cat(io.Discard, bytes.NewReader(data), &bad)
+	if err == nil {
+		t.Error("expected error for incompatible headers")
+	}
+}
+
+func readStrings(t *testing.T, data []byte) []string {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var recs []string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		recs = append(recs, rec.String())
+	}
+	return recs
+}
@@ -0,0 +1,297 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// DefaultCollateBuckets is the default number of temporary files used
+// by a Collator.
+const DefaultCollateBuckets = 64
+
+// CollateOptions specifies the behaviour of a Collator.
+type CollateOptions struct {
+	// Buckets is the number of temporary
+	// files records are distributed between.
+	// Each bucket is held in memory when it
+	// is grouped. If Buckets is less than one,
+	// DefaultCollateBuckets is used.
+	Buckets int
+
+	// TempDir is the directory temporary
+	// files are created in. If TempDir is
+	// empty, os.TempDir is used.
+	TempDir string
+
+	// Workers is the number of goroutines
+	// used to write the collated output of
+	// Collate. If Workers is less than one,
+	// runtime.GOMAXPROCS(0) is used.
+	Workers int
+}
+
+// Collator groups records by name without a full queryname sort, in
+// the manner of samtools collate.
+//
+// Records are added with Add and distributed between temporary BAM
+// files by a hash of their names. The first call to Read ends the
+// addition of records, and each temporary file is then read in turn
+// and its records returned grouped by name. Groups are returned in the
+// order of their first record within each temporary file, and records
+// within a group are returned first before last fragment and otherwise
+// in the order they were added.
+type Collator struct {
+	h    *sam.Header
+	opts CollateOptions
+
+	files   []*os.File
+	writers []*Writer
+
+	reading bool
+	bucket  int
+	recs    []*sam.Record
+	err     error
+}
+
+// NewCollator returns a Collator for records with the header h.
+func NewCollator(h *sam.Header, opts CollateOptions) (*Collator, error) {
+	if opts.Buckets < 1 {
+		opts.Buckets = DefaultCollateBuckets
+	}
+	if opts.TempDir == "" {
+		opts.TempDir = os.TempDir()
+	}
+	if opts.Workers < 1 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	h = h.Clone()
+	h.SortOrder = sam.Unsorted
+	h.GroupOrder = sam.GroupQuery
+	if h.Version == "" {
+		h.Version = sortVersion
+	}
+	err := setSubSort(h, Lexicographic)
+	if err != nil {
+		return nil, err
+	}
+	return &Collator{
+		h:       h,
+		opts:    opts,
+		files:   make([]*os.File, opts.Buckets),
+		writers: make([]*Writer, opts.Buckets),
+	}, nil
+}
+
+// Header returns the header of the collated records, with the sort
+// order set to unsorted and the group order set to query.
+func (c *Collator) Header() *sam.Header { return c.h }
+
+// Add adds r to the records to be collated.
+func (c *Collator) Add(r *sam.Record) error {
+	if c.reading {
+		return errors.New("bam: add to reading collator")
+	}
+	if c.err != nil {
+		return c.err
+	}
+	hash := fnv.New32a()
+	io.WriteString(hash, r.Name)
+	b := int(hash.Sum32() % uint32(len(c.writers)))
+	if c.writers[b] == nil {
+		f, err := os.CreateTemp(c.opts.TempDir, fmt.Sprintf("hts-collate-%d-*.bam", b))
+		if err != nil {
+			c.err = err
+			return err
+		}
+		c.files[b] = f
+		c.writers[b], c.err = NewWriterLevel(f, c.h, spillLevel, 1)
+		if c.err != nil {
+			return c.err
+		}
+	}
+	c.err = c.writers[b].Write(r)
+	return c.err
+}
+
+// Read returns the next record grouped by name. The first call to Read
+// ends the addition of records. Returned records use the references of
+// the header returned by Header.
+func (c *Collator) Read() (*sam.Record, error) {
+	if !c.reading {
+		c.reading = true
+		if c.err == nil {
+			c.err = c.flush()
+		}
+	}
+	for c.err == nil && len(c.recs) == 0 {
+		if c.bucket == len(c.files) {
+			return nil, io.EOF
+		}
+		c.recs, c.err = c.group(c.bucket)
+		c.bucket++
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	rec := c.recs[0]
+	c.recs[0] = nil
+	c.recs = c.recs[1:]
+	return rec, nil
+}
+
+// flush closes the temporary file writers.
+func (c *Collator) flush() error {
+	for i, w := range c.writers {
+		if w == nil {
+			continue
+		}
+		err := w.Close()
+		if err != nil {
+			return err
+		}
+		c.writers[i] = nil
+	}
+	return nil
+}
+
+// group returns the records of bucket b grouped by name.
+func (c *Collator) group(b int) ([]*sam.Record, error) {
+	f := c.files[b]
+	if f == nil {
+		return nil, nil
+	}
+	_, err := f.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	br, err := NewReader(f, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer br.Close()
+
+	var (
+		groups = make(map[string]int)
+		recs   []*sam.Record
+		order  []int
+	)
+	refs := c.h.Refs()
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		remapRefs(r, refs)
+		g, ok := groups[r.Name]
+		if !ok {
+			g = len(groups)
+			groups[r.Name] = g
+		}
+		recs = append(recs, r)
+		order = append(order, g)
+	}
+	idx := make([]int, len(recs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		gi, gj := order[idx[i]], order[idx[j]]
+		if gi != gj {
+			return gi < gj
+		}
+		return Lexicographic.lessByNameAndRead(recs[idx[i]], recs[idx[j]])
+	})
+	grouped := make([]*sam.Record, len(recs))
+	for i, j := range idx {
+		grouped[i] = recs[j]
+	}
+	return grouped, nil
+}
+
+// Close releases the resources held by the Collator and removes its
+// temporary files.
+func (c *Collator) Close() error {
+	var err error
+	for i, w := range c.writers {
+		if w == nil {
+			continue
+		}
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		c.writers[i] = nil
+	}
+	for i, f := range c.files {
+		if f == nil {
+			continue
+		}
+		f.Close()
+		if rerr := os.Remove(f.Name()); err == nil {
+			err = rerr
+		}
+		c.files[i] = nil
+	}
+	c.recs = nil
+	return err
+}
+
+// Collate writes the records read from src to dst as BAM with records
+// of the same name adjacent.
+func Collate(dst io.Writer, src *Reader, opts CollateOptions) error {
+	c, err := NewCollator(src.Header(), opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for {
+		r, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		err = c.Add(r)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := NewWriter(dst, c.Header(), c.opts.Workers)
+	if err != nil {
+		return err
+	}
+	for {
+		r, err := c.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Close()
+			return err
+		}
+		err = w.Write(r)
+		if err != nil {
+			w.Close()
+			return err
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
@@ -0,0 +1,132 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestCollate(t *testing.T) {
+	ref, err := sam.NewReference("chr1", "", "", 100000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{ref})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	h.Version = "1.6"
+	h.SortOrder = sam.Coordinate
+
+	// Mates are placed at random positions
+	// and written in coordinate order, with
+	// the last fragment of some pairs first.
+	rnd := rand.New(rand.NewSource(1))
+	const pairs = 500
+	var in []*sam.Record
+	for i := 0; i < pairs; i++ {
+		pos := []int{rnd.Intn(90000), rnd.Intn(90000)}
+		for j, flags := range []sam.Flags{sam.Paired | sam.Read1, sam.Paired | sam.Read2} {
+			r, err := sam.NewRecord(fmt.Sprintf("pair%d", i), ref, ref, pos[j], pos[1-j], 0, 60,
+				[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}, []byte("ACGT"), nil, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating record: %v", err)
+			}
+			r.Flags = flags
+			in = append(in, r)
+		}
+	}
+	for i := 1; i < len(in); i++ {
+		for j := i; j > 0 && in[j].Pos < in[j-1].Pos; j-- {
+			in[j], in[j-1] = in[j-1], in[j]
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, r := range in {
+		err = w.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	for _, buckets := range []int{1, 7, 0} {
+		br, err := NewReader(bytes.NewReader(buf.Bytes()), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		dir := t.TempDir()
+		var collated bytes.Buffer
+		err = Collate(&collated, br, CollateOptions{Buckets: buckets, TempDir: dir})
+		if err != nil {
+			t.Fatalf("unexpected error collating: %v", err)
+		}
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("unexpected error reading temporary directory: %v", err)
+		}
+		if len(files) != 0 {
+			t.Errorf("unexpected temporary files remaining: %d", len(files))
+		}
+
+		cr, err := NewReader(&collated, 1)
+		if err != nil {
+			t.Fatalf("unexpected error reading collated BAM: %v", err)
+		}
+		if cr.Header().SortOrder != sam.Unsorted || cr.Header().GroupOrder != sam.GroupQuery {
+			t.Errorf("unexpected collated header order: got:%v/%v want:%v/%v",
+				cr.Header().SortOrder, cr.Header().GroupOrder, sam.Unsorted, sam.GroupQuery)
+		}
+		var (
+			n    int
+			seen = make(map[string]bool)
+		)
+		for {
+			r1, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error reading collated record: %v", err)
+			}
+			r2, err := cr.Read()
+			if err != nil {
+				t.Fatalf("unexpected error reading collated mate of %v: %v", r1, err)
+			}
+			n += 2
+			if r1.Name != r2.Name {
+				t.Fatalf("mates not adjacent for %d buckets: %s and %s", buckets, r1.Name, r2.Name)
+			}
+			if r1.Flags&sam.Read1 == 0 || r2.Flags&sam.Read2 == 0 {
+				t.Errorf("unexpected fragment order for %s", r1.Name)
+			}
+			if r1.Pos != r2.MatePos {
+				t.Errorf("unexpected mate position for %s: got:%d want:%d", r1.Name, r2.MatePos, r1.Pos)
+			}
+			if seen[r1.Name] {
+				t.Errorf("name group split: %s", r1.Name)
+			}
+			seen[r1.Name] = true
+		}
+		if n != len(in) {
+			t.Errorf("unexpected number of collated records: got:%d want:%d", n, len(in))
+		}
+	}
+}
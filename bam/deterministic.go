@@ -0,0 +1,91 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+// NewDeterministicWriter returns a new Writer using the given SAM header
+// that writes byte-identical output for identical headers, records,
+// compression level and Go release, irrespective of the write
+// concurrency wc, the architecture and whether cgo is available.
+//
+// The returned Writer compresses with the bgzf.Stdlib backend rather
+// than libdeflate, writes BGZF members with a zero modification time
+// and no name or comment, and writes the aux fields of each record in
+// order of tag, with fields of the same tag kept in their order in the
+// record. Records passed to Write are not modified.
+//
+// The output of a deterministic Writer is not guaranteed to be
+// byte-identical to that of a Writer returned by NewWriter or
+// NewWriterLevel. Since w must be wrapped by the Writer to control
+// compression, w may not be a *bgzf.Writer.
+func NewDeterministicWriter(w io.Writer, h *sam.Header, level, wc int) (*Writer, error) {
+	if _, ok := w.(*bgzf.Writer); ok {
+		return nil, errors.New("bam: deterministic writer cannot use an existing bgzf.Writer")
+	}
+	bg, err := bgzf.NewWriterBackend(w, level, wc, bgzf.Stdlib)
+	if err != nil {
+		return nil, err
+	}
+	bg.Header.ModTime = time.Time{}
+	bg.Header.Name = ""
+	bg.Header.Comment = ""
+	bg.Header.OS = 0xff
+	bw := &Writer{
+		bg:            bg,
+		h:             h,
+		deterministic: true,
+	}
+
+	err = bw.writeHeader(h)
+	if err != nil {
+		return nil, err
+	}
+	bw.bg.Flush()
+	err = bw.bg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// canonicalAux returns r with its aux fields decoded and stably sorted
+// by tag. If r needs no change it is returned, otherwise a shallow copy
+// is returned and r is not modified.
+func canonicalAux(r *sam.Record) (*sam.Record, error) {
+	if r.RawAux == nil && sort.SliceIsSorted(r.AuxFields, func(i, j int) bool {
+		return lessTag(r.AuxFields[i].Tag(), r.AuxFields[j].Tag())
+	}) {
+		return r, nil
+	}
+	c := *r
+	err := c.DecodeAux()
+	if err != nil {
+		return nil, err
+	}
+	aux := make([]sam.Aux, len(c.AuxFields))
+	copy(aux, c.AuxFields)
+	sort.SliceStable(aux, func(i, j int) bool {
+		return lessTag(aux[i].Tag(), aux[j].Tag())
+	})
+	c.AuxFields = aux
+	return &c, nil
+}
+
+// lessTag returns whether a sorts before b.
+func lessTag(a, b sam.Tag) bool {
+	if a[0] != b[0] {
+		return a[0] < b[0]
+	}
+	return a[1] < b[1]
+}
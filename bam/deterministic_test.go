@@ -0,0 +1,96 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestDeterministicWriter(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	nm, _ := sam.NewAux(sam.NewTag("NM"), 1)
+	as, _ := sam.NewAux(sam.NewTag("AS"), 20)
+	rg, _ := sam.NewAux(sam.NewTag("RG"), "group")
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+	newRecords := func(aux ...sam.Aux) []*sam.Record {
+		var recs []*sam.Record
+		for i := 0; i < 100; i++ {
+			r, err := sam.NewRecord("r", chr1, nil, i, -1, 0, 60, cigar, []byte("ACGT"), nil, aux)
+			if err != nil {
+				t.Fatalf("unexpected error creating record: %v", err)
+			}
+			recs = append(recs, r)
+		}
+		return recs
+	}
+	write := func(recs []*sam.Record, wc int) []byte {
+		var buf bytes.Buffer
+		w, err := NewDeterministicWriter(&buf, h, 6, wc)
+		if err != nil {
+			t.Fatalf("unexpected error creating writer: %v", err)
+		}
+		for _, r := range recs {
+			err = w.Write(r)
+			if err != nil {
+				t.Fatalf("unexpected error writing record: %v", err)
+			}
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("unexpected error closing writer: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	shuffled := newRecords(rg, nm, as)
+	want := write(newRecords(as, nm, rg), 1)
+	got := write(shuffled, 4)
+	if !bytes.Equal(got, want) {
+		t.Error("unexpected difference between outputs with different aux order and concurrency")
+	}
+	if tag := shuffled[0].AuxFields[0].Tag(); tag != sam.NewTag("RG") {
+		t.Errorf("unexpected modification of written record: first tag %v", tag)
+	}
+
+	// Records with raw aux data are written
+	// identically to decoded records.
+	br, err := NewReader(bytes.NewReader(write(shuffled, 1)), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	br.LazyAux(true)
+	var lazy []*sam.Record
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		if r.RawAux == nil {
+			t.Fatal("expected raw aux data")
+		}
+		lazy = append(lazy, r)
+	}
+	br.Close()
+	if got := write(lazy, 2); !bytes.Equal(got, want) {
+		t.Error("unexpected difference between outputs with raw and decoded aux fields")
+	}
+
+	_, err = NewDeterministicWriter(bgzf.NewWriter(io.Discard, 1), h, 6, 1)
+	if err == nil {
+		t.Error("expected error for bgzf.Writer destination")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestEOFPolicy(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	const n = 10
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+	for i := 0; i < n; i++ {
+		r, err := sam.NewRecord("r", chr1, nil, i, -1, 0, 60, cigar, []byte("ACGT"), nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		err = w.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	complete := buf.Bytes()
+	// The BGZF magic EOF block is 28 bytes.
+	truncated := complete[:len(complete)-28]
+
+	for _, test := range []struct {
+		name     string
+		data     []byte
+		policy   EOFPolicy
+		err      error
+		warnings int
+	}{
+		{name: "complete ignore", data: complete, policy: IgnoreMissingEOF, err: io.EOF},
+		{name: "complete warn", data: complete, policy: WarnMissingEOF, err: io.EOF},
+		{name: "complete require", data: complete, policy: RequireEOF, err: io.EOF},
+		{name: "truncated ignore", data: truncated, policy: IgnoreMissingEOF, err: io.EOF},
+		{name: "truncated warn", data: truncated, policy: WarnMissingEOF, err: io.EOF, warnings: 1},
+		{name: "truncated require", data: truncated, policy: RequireEOF, err: ErrNoEOFBlock},
+	} {
+		for _, rd := range []int{1, 4} {
+			br, err := NewReader(bytes.NewReader(test.data), rd)
+			if err != nil {
+				t.Fatalf("unexpected error creating reader for %s: %v", test.name, err)
+			}
+			br.SetEOFPolicy(test.policy)
+			var got int
+			for {
+				_, err = br.Read()
+				if err != nil {
+					break
+				}
+				got++
+			}
+			if got != n {
+				t.Errorf("unexpected number of records for %s with rd=%d: got:%d want:%d", test.name, rd, got, n)
+			}
+			if err != test.err {
+				t.Errorf("unexpected error for %s with rd=%d: got:%v want:%v", test.name, rd, err, test.err)
+			}
+			// Reading again at the end of the stream
+			// must not record further warnings.
+			_, err = br.Read()
+			if err != test.err {
+				t.Errorf("unexpected error on repeated read for %s with rd=%d: got:%v want:%v", test.name, rd, err, test.err)
+			}
+			if len(br.Warnings()) != test.warnings {
+				t.Errorf("unexpected warnings for %s with rd=%d: got:%v", test.name, rd, br.Warnings())
+			}
+			br.Close()
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+// ErrNoRecordBoundary is returned when no BAM record boundary can be
+// found after a probed file position.
+var ErrNoRecordBoundary = errors.New("bam: no record boundary found")
+
+// ApproximateOffset returns the virtual offset of a record in the
+// coordinate-sorted BAM data held in ra that precedes all records
+// aligned to ref at or after pos. Reading from the returned offset will
+// yield all records at positions not less than pos on ref. The search
+// bisects the compressed file, locating BGZF blocks and record
+// boundaries heuristically, and so does not require a BAI or CSI index.
+// It is intended as a fallback when an index is not available; the
+// returned offset may be well before the first matching record.
+//
+// The size parameter is the size of the BAM data in bytes. If ref is
+// nil, the returned offset precedes the unplaced records at the end of
+// the file.
+func ApproximateOffset(ra io.ReaderAt, size int64, ref *sam.Reference, pos int) (bgzf.Offset, error) {
+	br, err := NewReader(io.NewSectionReader(ra, 0, size), 1)
+	if err != nil {
+		return bgzf.Offset{}, err
+	}
+	first := br.LastChunk().End
+	nRefs := len(br.Header().Refs())
+	br.Close()
+
+	target := key{refID: -1, pos: pos}
+	if ref != nil {
+		target.refID = ref.ID()
+	}
+
+	best := first
+	lo, hi := first.File, size
+	var buf []byte
+	for hi-lo > bgzf.MaxBlockSize {
+		mid := lo + (hi-lo)/2
+		off, k, err := probeRecord(ra, size, mid, nRefs, &buf)
+		if err == ErrNoRecordBoundary || (err == nil && off.File >= hi) {
+			hi = mid
+			continue
+		}
+		if err != nil {
+			return bgzf.Offset{}, err
+		}
+		if k.less(target) {
+			best = off
+			lo = off.File
+		} else {
+			hi = mid
+		}
+	}
+	return best, nil
+}
+
+// key is a coordinate sort key.
+type key struct {
+	refID, pos int
+}
+
+func (k key) less(o key) bool {
+	// Unplaced records sort after all placed records.
+	switch {
+	case k.refID == -1 && o.refID != -1:
+		return false
+	case k.refID != -1 && o.refID == -1:
+		return true
+	}
+	return k.refID < o.refID || (k.refID == o.refID && k.pos < o.pos)
+}
+
+// probeRecord finds the first BGZF block starting at or after off that
+// contains the start of a BAM record and returns the virtual offset and
+// sort key of that record.
+func probeRecord(ra io.ReaderAt, size, off int64, nRefs int, buf *[]byte) (bgzf.Offset, key, error) {
+	for off < size {
+		start, err := nextBlockStart(ra, size, off)
+		if err != nil {
+			return bgzf.Offset{}, key{}, err
+		}
+		bg, err := bgzf.NewReader(io.NewSectionReader(ra, start, size-start), 1)
+		if err != nil {
+			// A false positive match on the block magic.
+			off = start + 1
+			continue
+		}
+		bg.Blocked = true
+		blockLen := bg.BlockLen()
+		// Read the first block and enough of the following data to
+		// validate a record spanning the block boundary.
+		resizeScratch(buf, blockLen+2*bgzf.MaxBlockSize)
+		n, _ := io.ReadFull(bg, (*buf)[:blockLen])
+		if n == blockLen {
+			bg.Blocked = false
+			m, _ := io.ReadFull(bg, (*buf)[n:])
+			n += m
+		}
+		bg.Close()
+		data := (*buf)[:n]
+		for p := 0; p < blockLen; p++ {
+			k, ok := validRecordAt(data, p, nRefs)
+			if ok {
+				return bgzf.Offset{File: start, Block: uint16(p)}, k, nil
+			}
+		}
+		off = start + 1
+	}
+	return bgzf.Offset{}, key{}, ErrNoRecordBoundary
+}
+
+var blockMagic = []byte{0x1f, 0x8b, 0x08, 0x04}
+
+// nextBlockStart returns the file offset of the first plausible BGZF
+// block header at or after off.
+func nextBlockStart(ra io.ReaderAt, size, off int64) (int64, error) {
+	var buf [4096]byte
+	for off < size {
+		n, err := ra.ReadAt(buf[:], off)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if n < 18 {
+			break
+		}
+		for i := 0; i+18 <= n; i++ {
+			b := buf[i:]
+			if bytes.Equal(b[:4], blockMagic) && b[12] == 'B' && b[13] == 'C' && b[14] == 2 && b[15] == 0 {
+				return off + int64(i), nil
+			}
+		}
+		off += int64(n - 17)
+	}
+	return 0, ErrNoRecordBoundary
+}
+
+// validRecordAt returns whether a plausible BAM record starts at
+// data[p:], and if so the record's sort key. The record following
+// a plausible record must also be plausible if it is within data.
+func validRecordAt(data []byte, p, nRefs int) (key, bool) {
+	k, next, ok := plausibleRecord(data, p, nRefs)
+	if !ok {
+		return key{}, false
+	}
+	if next+4 <= len(data) {
+		nk, _, ok := plausibleRecord(data, next, nRefs)
+		if !ok && next+36 <= len(data) {
+			return key{}, false
+		}
+		if ok && nk.less(k) {
+			return key{}, false
+		}
+	}
+	return k, true
+}
+
+// plausibleRecord performs sanity checks on the fixed length fields
+// and read name of a BAM record starting at data[p:].
+func plausibleRecord(data []byte, p, nRefs int) (k key, next int, ok bool) {
+	if p+36 > len(data) {
+		return key{}, 0, false
+	}
+	b := data[p:]
+	size := int(int32(binary.LittleEndian.Uint32(b)))
+	if size < 32 || size > maxBAMRecordSize {
+		return key{}, 0, false
+	}
+	refID := int(int32(binary.LittleEndian.Uint32(b[4:])))
+	pos := int(int32(binary.LittleEndian.Uint32(b[8:])))
+	nLen := int(b[12])
+	nCigar := int(binary.LittleEndian.Uint16(b[16:]))
+	lSeq := int(int32(binary.LittleEndian.Uint32(b[20:])))
+	nextRefID := int(int32(binary.LittleEndian.Uint32(b[24:])))
+	nextPos := int(int32(binary.LittleEndian.Uint32(b[28:])))
+	switch {
+	case refID < -1 || refID >= nRefs,
+		nextRefID < -1 || nextRefID >= nRefs,
+		pos < -1, nextPos < -1,
+		nLen < 2, lSeq < 0,
+		32+nLen+4*nCigar+(lSeq+1)/2+lSeq > size:
+		return key{}, 0, false
+	}
+	if p+36+nLen <= len(data) {
+		name := b[36 : 36+nLen]
+		if name[nLen-1] != 0 {
+			return key{}, 0, false
+		}
+		for _, c := range name[:nLen-1] {
+			if c < '!' || c > '~' || c == '@' {
+				return key{}, 0, false
+			}
+		}
+	}
+	return key{refID: refID, pos: pos}, p + 4 + size, true
+}
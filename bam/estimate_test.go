@@ -0,0 +1,132 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestApproximateOffset(t *testing.T) {
+	// Build a larger coordinate sorted BAM from the test data so that
+	// the bisection has several blocks to work with.
+	br, err := NewReader(bytes.NewReader(bamHG00096_1000), 1)
+	if err != nil {
+		t.Fatalf("failed to open BAM: %v", err)
+	}
+	var recs []*sam.Record
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read BAM record: %v", err)
+		}
+		recs = append(recs, r)
+	}
+	br.Close()
+
+	var buf bytes.Buffer
+	bw, err := NewWriter(&buf, br.Header(), 1)
+	if err != nil {
+		t.Fatalf("failed to create BAM writer: %v", err)
+	}
+	var placed []*sam.Record
+	for _, r := range recs {
+		if r.Ref == nil {
+			continue
+		}
+		placed = append(placed, r)
+	}
+	for rep := 0; rep < 20; rep++ {
+		for _, r := range placed {
+			err = bw.Write(r)
+			if err != nil {
+				t.Fatalf("failed to write BAM record: %v", err)
+			}
+		}
+		for i := range placed {
+			r := *placed[i]
+			r.Pos += 1e6
+			placed[i] = &r
+		}
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("failed to close BAM writer: %v", err)
+	}
+	data := buf.Bytes()
+
+	for _, target := range []struct {
+		ref *sam.Reference
+		pos int
+	}{
+		{ref: placed[0].Ref, pos: 0},
+		{ref: placed[0].Ref, pos: 5e6},
+		{ref: placed[0].Ref, pos: 13e6},
+		{ref: placed[0].Ref, pos: 1e9},
+	} {
+		want := 0
+		all, err := readAllFrom(data, nil)
+		if err != nil {
+			t.Fatalf("failed to read BAM: %v", err)
+		}
+		for _, r := range all {
+			if r.Pos >= target.pos {
+				want++
+			}
+		}
+		off, err := ApproximateOffset(bytes.NewReader(data), int64(len(data)), target.ref, target.pos)
+		if err != nil {
+			t.Fatalf("unexpected error for %d: %v", target.pos, err)
+		}
+		got, err := readAllFrom(data, &off)
+		if err != nil {
+			t.Fatalf("failed to read BAM from %+v: %v", off, err)
+		}
+		n := 0
+		for _, r := range got {
+			if r.Pos >= target.pos {
+				n++
+			}
+		}
+		if n != want {
+			t.Errorf("unexpected number of records at or after %d: got:%d want:%d", target.pos, n, want)
+		}
+		if target.pos > 0 && len(got) == len(all) && len(data) > 1<<18 {
+			t.Errorf("no progress made for target %d", target.pos)
+		}
+	}
+}
+
+func readAllFrom(data []byte, off *bgzf.Offset) ([]*sam.Record, error) {
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		return nil, err
+	}
+	defer br.Close()
+	if off != nil {
+		err = br.Seek(*off)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var recs []*sam.Record
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, r)
+	}
+}
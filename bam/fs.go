@@ -0,0 +1,54 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"io/fs"
+
+	"github.com/Schaudge/hts/internal"
+)
+
+// OpenFS returns a Reader reading the BAM file with the given name from
+// fsys, setting the read concurrency to rd. The file is closed when the
+// Reader is closed. The Reader may seek if the file implements
+// io.Seeker, or io.ReaderAt and reports its size.
+func OpenFS(fsys fs.FS, name string, rd int) (*Reader, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	br, err := NewReader(internal.ReadSeeker(f), rd)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	br.closer = f
+	return br, nil
+}
+
+// ReadIndexFS reads the BAI Index with the given name from fsys.
+func ReadIndexFS(fsys fs.FS, name string) (*Index, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadIndex(f)
+}
+
+// OpenIndexedFS returns a Reader reading the BAM file with the given
+// name from fsys as OpenFS does, and its BAI Index read from the file
+// with ".bai" appended to name.
+func OpenIndexedFS(fsys fs.FS, name string, rd int) (*Reader, *Index, error) {
+	idx, err := ReadIndexFS(fsys, name+".bai")
+	if err != nil {
+		return nil, nil, err
+	}
+	br, err := OpenFS(fsys, name, rd)
+	if err != nil {
+		return nil, nil, err
+	}
+	return br, idx, nil
+}
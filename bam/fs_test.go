@@ -0,0 +1,114 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOpenIndexedFS(t *testing.T) {
+	data := matePairs(t, 50)
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var (
+		idx   Index
+		total int
+	)
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("unexpected error indexing record: %v", err)
+		}
+		total++
+	}
+	var bai bytes.Buffer
+	err = WriteIndex(&bai, &idx)
+	if err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"data/sample.bam":     {Data: data},
+		"data/sample.bam.bai": {Data: bai.Bytes()},
+	}
+
+	r, fidx, err := OpenIndexedFS(fsys, "data/sample.bam", 1)
+	if err != nil {
+		t.Fatalf("unexpected error opening BAM: %v", err)
+	}
+	var n int
+	for _, ref := range r.Header().Refs() {
+		chunks, err := fidx.Chunks(ref, 0, ref.Len())
+		if err != nil {
+			t.Fatalf("unexpected error getting chunks: %v", err)
+		}
+		it, err := NewIterator(r, chunks)
+		if err != nil {
+			t.Fatalf("unexpected error creating iterator: %v", err)
+		}
+		for it.Next() {
+			if it.Record().Ref.Name() == ref.Name() {
+				n++
+			}
+		}
+		err = it.Close()
+		if err != nil {
+			t.Fatalf("unexpected error iterating: %v", err)
+		}
+	}
+	if n != total {
+		t.Errorf("unexpected number of indexed records: got:%d want:%d", n, total)
+	}
+	err = r.Close()
+	if err != nil {
+		t.Errorf("unexpected error closing reader: %v", err)
+	}
+
+	// Files without io.Seeker or io.ReaderAt
+	// may be read sequentially.
+	r, err = OpenFS(streamFS{fsys}, "data/sample.bam", 1)
+	if err != nil {
+		t.Fatalf("unexpected error opening BAM: %v", err)
+	}
+	for n = 0; ; n++ {
+		_, err = r.Read()
+		if err != nil {
+			break
+		}
+	}
+	if err != io.EOF || n != total {
+		t.Errorf("unexpected sequential read: got:%d records with error %v", n, err)
+	}
+	r.Close()
+
+	_, _, err = OpenIndexedFS(fsys, "data/missing.bam", 1)
+	if err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+// streamFS hides the io.ReaderAt and io.Seeker methods of the files
+// of an fs.FS, as is the case for compressed zip archive members.
+type streamFS struct{ fs.FS }
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
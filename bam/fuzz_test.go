@@ -0,0 +1,160 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// fuzzHeader returns a header and records used to seed fuzz targets.
+func fuzzHeader(f *testing.F) (*sam.Header, []*sam.Record) {
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	chr2, _ := sam.NewReference("chr2", "", "", 1000, nil, nil)
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1, chr2})
+	if err != nil {
+		f.Fatalf("unexpected error creating header: %v", err)
+	}
+	nm, _ := sam.NewAux(sam.NewTag("NM"), 1)
+	rg, _ := sam.NewAux(sam.NewTag("RG"), "group")
+	xb, _ := sam.NewAux(sam.NewTag("XB"), []int16{1, -2, 3})
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 3), sam.NewCigarOp(sam.CigarInsertion, 1)}
+	var recs []*sam.Record
+	for i, ref := range []*sam.Reference{chr1, chr2, nil} {
+		var (
+			pos = -1
+			c   []sam.CigarOp
+		)
+		if ref != nil {
+			pos, c = 10*i, cigar
+		}
+		r, err := sam.NewRecord("r", ref, chr1, pos, 20, 0, 60, c, []byte("ACGT"), []byte{30, 31, 32, 33}, []sam.Aux{nm, rg, xb})
+		if err != nil {
+			f.Fatalf("unexpected error creating record: %v", err)
+		}
+		if ref == nil {
+			r.Flags = sam.Unmapped
+		}
+		recs = append(recs, r)
+	}
+	return h, recs
+}
+
+func FuzzBAMRecord(f *testing.F) {
+	h, recs := fuzzHeader(f)
+	for _, r := range recs {
+		b, err := MarshalRecord(r, nil)
+		if err != nil {
+			f.Fatalf("unexpected error marshaling record: %v", err)
+		}
+		body := b[lenFieldSize:]
+		f.Add(body)
+
+		// Records with malformed aux data.
+		aux := len(body) - encodedAuxLen(body)
+		for _, bad := range [][]byte{
+			[]byte("XBBi\x05\x00\x00\x00\x01\x00\x00\x00"), // Truncated array.
+			[]byte("XBBZ\x01\x00\x00\x00A"),                // Invalid array type.
+			[]byte("XX?\x00"),                              // Unknown type.
+			[]byte("XZZunterminated"),                      // Missing terminal zero.
+			[]byte("XIi\x01\x00"),                          // Truncated integer.
+		} {
+			f.Add(append(body[:aux:aux], bad...))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		b := make([]byte, lenFieldSize+len(data))
+		binary.LittleEndian.PutUint32(b, uint32(len(data)))
+		copy(b[lenFieldSize:], data)
+		r, err := Unmarshal(b, h)
+		if err != nil {
+			return
+		}
+		want := r.String()
+		b, err = MarshalRecord(r, nil)
+		if err != nil {
+			return
+		}
+		got, err := Unmarshal(b, h)
+		if err != nil {
+			t.Fatalf("failed to unmarshal marshaled record: %v", err)
+		}
+		if got.String() != want {
+			t.Errorf("unexpected round trip:\ngot: %v\nwant:%v", got, want)
+		}
+	})
+}
+
+func FuzzBAIRead(f *testing.F) {
+	h, recs := fuzzHeader(f)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		f.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, r := range recs {
+		err = w.Write(r)
+		if err != nil {
+			f.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		f.Fatalf("unexpected error closing writer: %v", err)
+	}
+	br, err := NewReader(&buf, 1)
+	if err != nil {
+		f.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var idx Index
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Fatalf("unexpected error reading record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			f.Fatalf("unexpected error indexing record: %v", err)
+		}
+	}
+	br.Close()
+	buf.Reset()
+	err = WriteIndex(&buf, &idx)
+	if err != nil {
+		f.Fatalf("unexpected error writing index: %v", err)
+	}
+	valid := buf.Bytes()
+	f.Add(valid)
+	f.Add(valid[:len(valid)/2])
+	f.Add([]byte("BAI\x01\x00\x00\x00\x00"))
+	f.Add([]byte("BAI\x01\xff\xff\xff\xff"))                                 // Negative reference count.
+	f.Add([]byte("BAI\x01\x01\x00\x00\x00\xff\xff\xff\xff"))                 // Negative bin count.
+	f.Add([]byte("BAI\x01\x01\x00\x00\x00\x00\x00\x00\x00\xff\xff\xff\x7f")) // Huge interval count.
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		idx, err := ReadIndex(bytes.NewReader(data))
+		if err != nil || idx == nil {
+			return
+		}
+		for _, ref := range h.Refs() {
+			if ref.ID() >= idx.NumRefs() {
+				break
+			}
+			idx.Chunks(ref, 0, ref.Len())
+		}
+		err = WriteIndex(io.Discard, idx)
+		if err != nil {
+			t.Errorf("failed to write read index: %v", err)
+		}
+	})
+}
@@ -25,6 +25,12 @@ type Index struct {
 	// If MergeStrategy is nil, index.MergeStrategy
 	// is used.
 	MergeStrategy index.MergeStrategy
+
+	// CountBins specifies whether Add records
+	// per-bin record counts for use by
+	// EstimateRecordCount. Bin counts are not
+	// written by WriteIndex.
+	CountBins bool
 }
 
 // NumRefs returns the number of references in the index.
@@ -52,6 +58,7 @@ func (i *Index) Unmapped() (n uint64, ok bool) {
 
 // Add records the SAM record as having being located at the given chunk.
 func (i *Index) Add(r *sam.Record, c bgzf.Chunk) error {
+	i.idx.CountBins = i.CountBins
 	return i.idx.Add(r, uint32(r.Bin()), c, isPlaced(r), isMapped(r))
 }
 
@@ -75,11 +82,46 @@ func (i *Index) Chunks(r *sam.Reference, beg, end int) ([]bgzf.Chunk, error) {
 	return i.MergeStrategy(chunks), nil
 }
 
+// EstimateRecordCount returns an estimate of the number of records
+// overlapping the interval [beg,end) on the given reference, calculated
+// from the per-bin record counts collected when CountBins is true.
+// No records are read to obtain the estimate.
+func (i *Index) EstimateRecordCount(r *sam.Reference, beg, end int) (uint64, error) {
+	return i.idx.EstimateRecordCount(r.ID(), beg, end)
+}
+
 // MergeChunks applies the given MergeStrategy to all bins in the Index.
 func (i *Index) MergeChunks(s index.MergeStrategy) {
 	i.idx.MergeChunks(s)
 }
 
+// MergeIndexes returns a BAI index for the BAM file formed by BGZF
+// concatenation of the files indexed by idxs, without reading the
+// concatenated file. The value of offsets[i] is added to the compressed
+// file offsets held by idxs[i]; when whole files are concatenated it is
+// the byte offset at which the i-th file begins in the concatenated file.
+// If the header blocks of a file are removed before concatenation, their
+// compressed length must be subtracted from its offset. Indexes must be
+// given in concatenation order and the resulting file must be coordinate
+// sorted. The MergeStrategy of the first index is retained.
+func MergeIndexes(idxs []*Index, offsets []int64) (*Index, error) {
+	in := make([]*internal.Index, len(idxs))
+	for i, idx := range idxs {
+		if idx != nil {
+			in[i] = &idx.idx
+		}
+	}
+	m, err := internal.Merge(in, offsets)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{idx: m}
+	if len(idxs) != 0 && idxs[0] != nil {
+		idx.MergeStrategy = idxs[0].MergeStrategy
+	}
+	return idx, nil
+}
+
 var baiMagic = [4]byte{'B', 'A', 'I', 0x1}
 
 // ReadIndex reads the BAI Index from the given io.Reader.
@@ -0,0 +1,371 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Schaudge/hts/bgzf/index"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestEstimateRecordCount(t *testing.T) {
+	br, err := NewReader(bytes.NewReader(bamHG00096_1000), 1)
+	if err != nil {
+		t.Fatalf("failed to open BAM: %v", err)
+	}
+	defer br.Close()
+
+	var (
+		plain   Index
+		counted = Index{CountBins: true}
+		n       = make(map[int]uint64)
+	)
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read BAM record: %v", err)
+		}
+		for _, idx := range []*Index{&plain, &counted} {
+			err = idx.Add(r, br.LastChunk())
+			if err != nil {
+				t.Fatalf("failed to add record to index: %v", err)
+			}
+		}
+		if isPlaced(r) {
+			n[r.Ref.ID()]++
+		}
+	}
+
+	refs := br.Header().Refs()
+	for id, want := range n {
+		ref := refs[id]
+		_, err = plain.EstimateRecordCount(ref, 0, ref.Len())
+		if err != index.ErrNoBinCounts {
+			t.Errorf("unexpected error without bin counts: got:%v want:%v", err, index.ErrNoBinCounts)
+		}
+
+		// A query spanning the whole indexable range must
+		// count every placed record exactly.
+		got, err := counted.EstimateRecordCount(ref, 0, 1<<29)
+		if err != nil {
+			t.Fatalf("unexpected error estimating count: %v", err)
+		}
+		if got != want {
+			t.Errorf("unexpected count for %s: got:%d want:%d", ref.Name(), got, want)
+		}
+		half, err := counted.EstimateRecordCount(ref, 0, 1<<28)
+		if err != nil {
+			t.Fatalf("unexpected error estimating count: %v", err)
+		}
+		if half > got {
+			t.Errorf("estimate for sub-interval exceeds total for %s: %d > %d", ref.Name(), half, got)
+		}
+	}
+}
+
+func TestMergeIndexes(t *testing.T) {
+	recs, h, err := readAll(bamHG00096_1000)
+	if err != nil {
+		t.Fatalf("failed to read BAM: %v", err)
+	}
+
+	// Split the records into shards and index each
+	// shard independently.
+	var (
+		cat     []byte
+		shards  [][]byte
+		idxs    []*Index
+		offsets []int64
+	)
+	for _, shard := range [][]*sam.Record{recs[:300], recs[300:301], recs[301:]} {
+		var buf bytes.Buffer
+		bw, err := NewWriter(&buf, h, 1)
+		if err != nil {
+			t.Fatalf("failed to create BAM writer: %v", err)
+		}
+		for _, r := range shard {
+			err = bw.Write(r)
+			if err != nil {
+				t.Fatalf("failed to write BAM record: %v", err)
+			}
+		}
+		err = bw.Close()
+		if err != nil {
+			t.Fatalf("failed to close BAM writer: %v", err)
+		}
+		idx, err := indexBAM(buf.Bytes())
+		if err != nil {
+			t.Fatalf("failed to index shard: %v", err)
+		}
+		shards = append(shards, buf.Bytes())
+		idxs = append(idxs, idx)
+		offsets = append(offsets, int64(len(cat)))
+		cat = append(cat, buf.Bytes()...)
+	}
+	merged, err := MergeIndexes(idxs, offsets)
+	if err != nil {
+		t.Fatalf("failed to merge indexes: %v", err)
+	}
+	var ibuf bytes.Buffer
+	err = WriteIndex(&ibuf, merged)
+	if err != nil {
+		t.Fatalf("failed to write merged index: %v", err)
+	}
+	merged, err = ReadIndex(&ibuf)
+	if err != nil {
+		t.Fatalf("failed to read merged index: %v", err)
+	}
+
+	var n uint64
+	for _, r := range recs {
+		if !isPlaced(r) {
+			n++
+		}
+	}
+	if got, _ := merged.Unmapped(); got != n {
+		t.Errorf("unexpected unplaced count: got:%d want:%d", got, n)
+	}
+
+	for _, ref := range h.Refs() {
+		for _, q := range []struct{ beg, end int }{
+			{0, ref.Len()},
+			{ref.Len() / 4, ref.Len() / 2},
+			{1e6, 2e6},
+		} {
+			// The records found using the merged index must be
+			// the records found using each shard's own index.
+			var want []string
+			for i, data := range shards {
+				names, err := query(data, idxs[i], ref, q.beg, q.end)
+				if err != nil {
+					t.Fatalf("failed to query shard %d: %v", i, err)
+				}
+				want = append(want, names...)
+			}
+			got, err := query(cat, merged, ref, q.beg, q.end)
+			if err != nil {
+				t.Fatalf("failed to query merged index: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("unexpected records for %s:%d-%d: got:%d records want:%d records", ref.Name(), q.beg, q.end, len(got), len(want))
+			}
+		}
+	}
+}
+
+// query returns the names of the records in the BAM data that
+// overlap [beg,end) on ref, found using idx.
+func query(data []byte, idx *Index, ref *sam.Reference, beg, end int) ([]string, error) {
+	chunks, err := idx.Chunks(ref, beg, end)
+	if err == index.ErrNoReference || err == index.ErrInvalid {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		return nil, err
+	}
+	defer br.Close()
+	it, err := NewIterator(br, chunks)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for it.Next() {
+		r := it.Record()
+		if r.Ref.ID() == ref.ID() && r.Pos < end && r.End() > beg {
+			names = append(names, r.Name)
+		}
+	}
+	return names, it.Close()
+}
+
+func readAll(data []byte) ([]*sam.Record, *sam.Header, error) {
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer br.Close()
+	var recs []*sam.Record
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			return recs, br.Header(), nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		recs = append(recs, r)
+	}
+}
+
+func indexBAM(data []byte) (*Index, error) {
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		return nil, err
+	}
+	defer br.Close()
+	var idx Index
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			return &idx, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sortedBAM returns a coordinate sorted BAM holding n mapped records
+// with random positions on two references.
+func sortedBAM(n int, seed int64) ([]byte, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	var refs []*sam.Reference
+	for _, name := range []string{"chr1", "chr2"} {
+		ref, err := sam.NewReference(name, "", "", 1<<20, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		return nil, err
+	}
+	h.SortOrder = sam.Coordinate
+
+	recs := make([]*sam.Record, n)
+	for i := range recs {
+		ref := refs[rnd.Intn(len(refs))]
+		pos := rnd.Intn(ref.Len() - 1000)
+		l := 50 + rnd.Intn(100)
+		seq := bytes.Repeat([]byte{'A'}, l)
+		qual := bytes.Repeat([]byte{30}, l)
+		recs[i], err = sam.NewRecord(fmt.Sprintf("r%d", i), ref, nil, pos, -1, 0, 60,
+			[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, l)}, seq, qual, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].LessByCoordinate(recs[j]) })
+
+	var buf bytes.Buffer
+	bw, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range recs {
+		err = bw.Write(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = bw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func TestRegionIterator(t *testing.T) {
+	data, err := sortedBAM(20000, 1)
+	if err != nil {
+		t.Fatalf("failed to create BAM: %v", err)
+	}
+	recs, h, err := readAll(data)
+	if err != nil {
+		t.Fatalf("failed to read BAM: %v", err)
+	}
+	idx, err := indexBAM(data)
+	if err != nil {
+		t.Fatalf("failed to index BAM: %v", err)
+	}
+
+	// Include overlapping and adjacent regions on
+	// the same reference.
+	refs := h.Refs()
+	regions := []Region{
+		{Ref: refs[0], Start: 1000, End: 50000},
+		{Ref: refs[0], Start: 40000, End: 90000},
+		{Ref: refs[0], Start: 500000, End: 501000},
+		{Ref: refs[1], Start: 700000, End: 800000},
+		{Ref: refs[1], Start: 800000, End: 800001},
+		{Ref: refs[0], Start: 200000, End: 300000},
+	}
+
+	type key struct {
+		name string
+		pos  int
+	}
+	var want []key
+	for _, r := range recs {
+		for _, g := range regions {
+			if r.Ref.ID() == g.Ref.ID() && r.Pos < g.End && r.End() > g.Start {
+				want = append(want, key{r.Name, r.Pos})
+				break
+			}
+		}
+	}
+	if len(want) == 0 {
+		t.Fatal("no records in test regions")
+	}
+
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("failed to open BAM: %v", err)
+	}
+	defer br.Close()
+	for i := range regions {
+		regions[i].Ref = br.Header().Refs()[regions[i].Ref.ID()]
+	}
+	it, err := NewRegionIterator(br, idx, regions)
+	if err != nil {
+		t.Fatalf("unexpected error creating iterator: %v", err)
+	}
+	var got []key
+	for it.Next() {
+		r := it.Record()
+		got = append(got, key{r.Name, r.Pos})
+	}
+	err = it.Close()
+	if err != nil {
+		t.Fatalf("unexpected error iterating: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected records: got %d records want %d", len(got), len(want))
+	}
+
+	chunks, err := idx.RegionChunks(regions)
+	if err != nil {
+		t.Fatalf("unexpected error getting chunks: %v", err)
+	}
+	for i := 1; i < len(chunks); i++ {
+		if !chunks[i-1].End.Less(chunks[i].Begin) {
+			t.Errorf("chunks not sorted and disjoint: %+v then %+v", chunks[i-1], chunks[i])
+		}
+	}
+
+	_, err = idx.RegionChunks([]Region{{Start: 0, End: 10}})
+	if err == nil {
+		t.Error("expected error for region without reference")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package bam
+
+import (
+	"io"
+	"iter"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// All returns an iterator over the records remaining in the BAM
+// stream. A read error other than io.EOF is yielded with a nil record
+// and ends the iteration.
+func (br *Reader) All() iter.Seq2[*sam.Record, error] {
+	return func(yield func(*sam.Record, error) bool) {
+		for {
+			rec, err := br.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(rec, nil) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the records remaining in the chunks of
+// the Iterator. An error is yielded with a nil record and ends the
+// iteration. All does not close the Iterator.
+func (i *Iterator) All() iter.Seq2[*sam.Record, error] {
+	return func(yield func(*sam.Record, error) bool) {
+		for i.Next() {
+			if !yield(i.Record(), nil) {
+				return
+			}
+		}
+		if err := i.Error(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
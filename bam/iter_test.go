@@ -0,0 +1,56 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package bam
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReaderAll(t *testing.T) {
+	data := matePairs(t, 50)
+	want := readStrings(t, data)
+
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var got []string
+	for r, err := range br.All() {
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		got = append(got, r.String())
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("unexpected records: got:%d want:%d", len(got), len(want))
+	}
+
+	// Stopping an iteration leaves the
+	// remaining records to be read.
+	br, err = NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var n int
+	for _, err := range br.All() {
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		n++
+		if n == 10 {
+			break
+		}
+	}
+	r, err := br.Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading record: %v", err)
+	}
+	if r.String() != want[10] {
+		t.Errorf("unexpected record after stopped iteration: got:%v want:%v", r, want[10])
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestLazyAux(t *testing.T) {
+	ref, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{ref})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	co := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+	for i := 0; i < 10; i++ {
+		var aux []sam.Aux
+		if i%3 != 0 {
+			for _, f := range []struct {
+				tag string
+				v   interface{}
+			}{{"NM", i}, {"RG", "grp"}, {"XB", []uint16{1, 2}}} {
+				a, err := sam.NewAux(sam.NewTag(f.tag), f.v)
+				if err != nil {
+					t.Fatalf("unexpected error creating aux: %v", err)
+				}
+				aux = append(aux, a)
+			}
+		}
+		r, err := sam.NewRecord("r", ref, nil, i, -1, 0, 60, co, []byte("ACGT"), nil, aux)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		err = w.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	read := func(lazy bool) []*sam.Record {
+		br, err := NewReader(bytes.NewReader(buf.Bytes()), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		defer br.Close()
+		br.LazyAux(lazy)
+		var recs []*sam.Record
+		for {
+			r, err := br.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error reading record: %v", err)
+			}
+			recs = append(recs, r)
+		}
+		return recs
+	}
+	eager := read(false)
+	lazy := read(true)
+	if len(lazy) != len(eager) {
+		t.Fatalf("unexpected number of records: got:%d want:%d", len(lazy), len(eager))
+	}
+	for i, r := range lazy {
+		if r.AuxFields != nil {
+			t.Errorf("unexpected decoded aux fields for record %d", i)
+		}
+		if (r.RawAux == nil) != (eager[i].AuxFields == nil) {
+			t.Errorf("unexpected raw aux state for record %d", i)
+		}
+		got, err := r.MarshalSAM(sam.FlagDecimal)
+		if err != nil {
+			t.Fatalf("unexpected error marshaling record: %v", err)
+		}
+		want, _ := eager[i].MarshalSAM(sam.FlagDecimal)
+		if !bytes.Equal(got, want) {
+			t.Errorf("unexpected SAM for record %d:\ngot: %s\nwant:%s", i, got, want)
+		}
+		var gotBAM, wantBAM bytes.Buffer
+		err = Marshal(r, &gotBAM)
+		if err != nil {
+			t.Fatalf("unexpected error marshaling record: %v", err)
+		}
+		Marshal(eager[i], &wantBAM)
+		if !bytes.Equal(gotBAM.Bytes(), wantBAM.Bytes()) {
+			t.Errorf("unexpected BAM encoding for record %d", i)
+		}
+		if !bytes.Equal(r.AuxTag(sam.NewTag("RG")), eager[i].AuxFields.Get(sam.NewTag("RG"))) {
+			t.Errorf("unexpected RG for record %d", i)
+		}
+		err = r.DecodeAux()
+		if err != nil {
+			t.Fatalf("unexpected error decoding aux: %v", err)
+		}
+		if !r.AuxFields.Equal(eager[i].AuxFields) {
+			t.Errorf("unexpected decoded aux for record %d: got:%v want:%v", i, r.AuxFields, eager[i].AuxFields)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestMarshalRecord(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	chr2, _ := sam.NewReference("chr2", "", "", 1000, nil, nil)
+	src, err := sam.NewHeader(nil, []*sam.Reference{chr1, chr2})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	aux, _ := sam.NewAux(sam.NewTag("NM"), 1)
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+	r, err := sam.NewRecord("r0", chr1, chr2, 10, 20, 0, 60, cigar, []byte("ACGT"), []byte{30, 31, 32, 33}, []sam.Aux{aux})
+	if err != nil {
+		t.Fatalf("unexpected error creating record: %v", err)
+	}
+	r.Flags = sam.Paired | sam.Read1
+
+	b, err := MarshalRecord(r, nil)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling record: %v", err)
+	}
+	got, err := Unmarshal(b, src)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshaling record: %v", err)
+	}
+	if got.String() != r.String() {
+		t.Errorf("unexpected round trip record:\ngot: %v\nwant:%v", got, r)
+	}
+
+	// Records may be encoded for a header with
+	// references in a different order.
+	dst2, _ := sam.NewReference("chr2", "", "", 1000, nil, nil)
+	dst1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	dst, err := sam.NewHeader(nil, []*sam.Reference{dst2, dst1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	b, err = MarshalRecord(r, map[*sam.Reference]int{chr1: 1, chr2: 0})
+	if err != nil {
+		t.Fatalf("unexpected error marshaling record: %v", err)
+	}
+	got, err = Unmarshal(b, dst)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshaling record: %v", err)
+	}
+	if got.Ref != dst1 || got.MateRef != dst2 {
+		t.Errorf("unexpected references: got:%v/%v want:%v/%v", got.Ref.Name(), got.MateRef.Name(), dst1.Name(), dst2.Name())
+	}
+	if got.String() != r.String() {
+		t.Errorf("unexpected remapped record:\ngot: %v\nwant:%v", got, r)
+	}
+
+	_, err = MarshalRecord(r, map[*sam.Reference]int{chr1: 0})
+	if err == nil {
+		t.Error("expected error for missing reference ID")
+	}
+}
+
+func TestRecordSize(t *testing.T) {
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	_, err = sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 3), sam.NewCigarOp(sam.CigarInsertion, 2)}
+	nm, _ := sam.NewAux(sam.NewTag("NM"), 1)
+	rg, _ := sam.NewAux(sam.NewTag("RG"), "group")
+	for _, test := range []struct {
+		cigar     []sam.CigarOp
+		seq, qual []byte
+		aux       []sam.Aux
+		rawAux    []byte
+	}{
+		{},
+		{cigar: cigar, seq: []byte("ACGTA"), qual: []byte{30, 30, 30, 30, 30}},
+		{cigar: cigar, seq: []byte("ACGTA"), aux: []sam.Aux{nm, rg}},
+		{cigar: cigar, seq: []byte("ACGTA"), aux: []sam.Aux{rg}, rawAux: []byte("NMC\x01")},
+	} {
+		var r *sam.Record
+		if test.seq == nil {
+			// sam.NewRecord rejects records
+			// without a sequence.
+			r = &sam.Record{Name: "read", Ref: chr1, Pos: 10, MatePos: -1, MapQ: 60, AuxFields: test.aux}
+		} else {
+			r, err = sam.NewRecord("read", chr1, nil, 10, -1, 0, 60, test.cigar, test.seq, test.qual, test.aux)
+			if err != nil {
+				t.Fatalf("unexpected error creating record: %v", err)
+			}
+		}
+		r.RawAux = test.rawAux
+		b, err := MarshalRecord(r, nil)
+		if err != nil {
+			t.Fatalf("unexpected error marshaling record: %v", err)
+		}
+		if got := RecordSize(r); got != len(b) {
+			t.Errorf("unexpected record size for %v: got:%d want:%d", r, got, len(b))
+		}
+	}
+
+	big, _ := sam.NewAux(sam.NewTag("XX"), strings.Repeat("A", maxBAMRecordSize))
+	r := &sam.Record{Name: "read", Ref: chr1, Pos: 10, MatePos: -1, MapQ: 60, AuxFields: []sam.Aux{big}}
+	if RecordSize(r) <= maxBAMRecordSize {
+		t.Errorf("unexpected size of large record: %d", RecordSize(r))
+	}
+	_, err = MarshalRecord(r, nil)
+	if err == nil {
+		t.Error("expected error marshaling large record")
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"container/list"
+	"errors"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// ErrNoMate is returned by MateFetcher.Mate when the mate of a record
+// cannot be found.
+var ErrNoMate = errors.New("bam: mate not found")
+
+// DefaultMateCache is the default number of records held by a
+// MateFetcher.
+const DefaultMateCache = 4096
+
+// MateFetcher retrieves the mates of paired records from an indexed
+// BAM. Mates are located by querying the index at the mate position
+// of a record, or by read name if a NameIndex is provided. Records
+// read during a query are retained in a least recently used cache, so
+// fetching the mates of records from the same region, as in batched
+// use, requires few index queries.
+//
+// A MateFetcher moves the position of its Reader, so records being
+// processed must not be read from the same Reader.
+type MateFetcher struct {
+	r     *Reader
+	idx   *Index
+	names *NameIndex
+
+	cap   int
+	lru   *list.List
+	cache map[mateKey]*list.Element
+}
+
+// mateKey identifies a primary record of a read pair.
+type mateKey struct {
+	name string
+	read sam.Flags
+	ref  int
+	pos  int
+}
+
+// NewMateFetcher returns a MateFetcher reading mates from r using the
+// index idx and, if not nil, the name index names. The cache holds up
+// to n records; if n is less than one, DefaultMateCache is used.
+func NewMateFetcher(r *Reader, idx *Index, names *NameIndex, n int) *MateFetcher {
+	if n < 1 {
+		n = DefaultMateCache
+	}
+	return &MateFetcher{
+		r:     r,
+		idx:   idx,
+		names: names,
+		cap:   n,
+		lru:   list.New(),
+		cache: make(map[mateKey]*list.Element),
+	}
+}
+
+// keyOf returns the cache key of a primary paired record.
+func keyOf(r *sam.Record) mateKey {
+	return mateKey{name: r.Name, read: r.Flags & (sam.Read1 | sam.Read2), ref: r.Ref.ID(), pos: r.Pos}
+}
+
+// mateKeyOf returns the cache key of the mate of r.
+func mateKeyOf(r *sam.Record) mateKey {
+	return mateKey{name: r.Name, read: (r.Flags & (sam.Read1 | sam.Read2)) ^ (sam.Read1 | sam.Read2), ref: r.MateRef.ID(), pos: r.MatePos}
+}
+
+func isPrimaryPaired(r *sam.Record) bool {
+	return r.Flags&(sam.Paired|sam.Secondary|sam.Supplementary) == sam.Paired
+}
+
+// Mate returns the primary alignment record of the mate of rec. If rec
+// is not paired or its mate is not placed, or no mate is found, Mate
+// returns ErrNoMate. Without a NameIndex, the mates of records in pairs
+// with both reads unmapped cannot be found.
+func (f *MateFetcher) Mate(rec *sam.Record) (*sam.Record, error) {
+	if rec.Flags&sam.Paired == 0 || rec.MateRef == nil || rec.MatePos < 0 {
+		return nil, ErrNoMate
+	}
+	want := mateKeyOf(rec)
+	if e, ok := f.cache[want]; ok {
+		f.lru.MoveToFront(e)
+		return e.Value.(*sam.Record), nil
+	}
+
+	var (
+		mate *sam.Record
+		err  error
+	)
+	switch {
+	case f.names != nil:
+		mate, err = f.fetchByName(want)
+	case rec.Flags&(sam.Unmapped|sam.MateUnmapped) == sam.Unmapped|sam.MateUnmapped:
+		// Pairs with both reads unmapped are
+		// not binned by the index.
+		return nil, ErrNoMate
+	default:
+		mate, err = f.fetchAt(want, rec.MateRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mate == nil {
+		return nil, ErrNoMate
+	}
+	return mate, nil
+}
+
+// fetchAt caches the primary paired records starting at the position
+// of want on ref, returning the record matching want if it is found.
+func (f *MateFetcher) fetchAt(want mateKey, ref *sam.Reference) (*sam.Record, error) {
+	chunks, err := f.idx.Chunks(ref, want.pos, want.pos+1)
+	if err != nil {
+		return nil, err
+	}
+	it, err := NewIterator(f.r, chunks)
+	if err != nil {
+		return nil, err
+	}
+	var mate *sam.Record
+	for it.Next() {
+		r := it.Record()
+		if r.Ref != ref || r.Pos > want.pos {
+			break
+		}
+		if r.Pos == want.pos && isPrimaryPaired(r) {
+			f.add(r)
+			if keyOf(r) == want {
+				mate = r
+			}
+		}
+	}
+	return mate, it.Close()
+}
+
+// fetchByName caches the primary paired records with the name of want,
+// returning the record matching want if it is found.
+func (f *MateFetcher) fetchByName(want mateKey) (*sam.Record, error) {
+	recs, err := FetchByName(f.r, f.names, want.name)
+	if err != nil {
+		return nil, err
+	}
+	var mate *sam.Record
+	for _, r := range recs {
+		if isPrimaryPaired(r) {
+			f.add(r)
+			if keyOf(r) == want {
+				mate = r
+			}
+		}
+	}
+	return mate, nil
+}
+
+// add adds r to the cache, evicting the least recently used record if
+// the cache is full.
+func (f *MateFetcher) add(r *sam.Record) {
+	k := keyOf(r)
+	if e, ok := f.cache[k]; ok {
+		f.lru.MoveToFront(e)
+		return
+	}
+	if f.lru.Len() >= f.cap {
+		e := f.lru.Back()
+		f.lru.Remove(e)
+		delete(f.cache, keyOf(e.Value.(*sam.Record)))
+	}
+	f.cache[k] = f.lru.PushFront(r)
+}
@@ -0,0 +1,174 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// matePairs returns a coordinate sorted BAM holding n read pairs on
+// two references, with a supplementary alignment for each first read.
+func matePairs(t *testing.T, n int) []byte {
+	t.Helper()
+	chr1, _ := sam.NewReference("chr1", "", "", 1e6, nil, nil)
+	chr2, _ := sam.NewReference("chr2", "", "", 1e6, nil, nil)
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1, chr2})
+	if err != nil {
+		t.Fatalf("failed to make header: %v", err)
+	}
+	h.SortOrder = sam.Coordinate
+	co := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 10)}
+	seq := []byte("ACGTACGTAC")
+	qual := bytes.Repeat([]byte{30}, len(seq))
+
+	var recs [2][]*sam.Record
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("pair%d", i)
+		// Pairs share positions in groups of three,
+		// and every fifth pair is split between
+		// references.
+		pos, matePos := 100*(i/3), 100*(i/3)+300
+		ref, mateRef := chr1, chr1
+		if i%5 == 0 {
+			mateRef, matePos = chr2, 100*(i/3)
+		}
+		r1, err := sam.NewRecord(name, ref, mateRef, pos, matePos, 0, 60, co, seq, qual, nil)
+		if err != nil {
+			t.Fatalf("failed to make record: %v", err)
+		}
+		r1.Flags = sam.Paired | sam.Read1 | sam.MateReverse
+		r2, err := sam.NewRecord(name, mateRef, ref, matePos, pos, 0, 60, co, seq, qual, nil)
+		if err != nil {
+			t.Fatalf("failed to make record: %v", err)
+		}
+		r2.Flags = sam.Paired | sam.Read2 | sam.Reverse
+		sup, err := sam.NewRecord(name, mateRef, ref, matePos, pos, 0, 60, co, seq, qual, nil)
+		if err != nil {
+			t.Fatalf("failed to make record: %v", err)
+		}
+		sup.Flags = sam.Paired | sam.Read1 | sam.Supplementary
+		for _, r := range []*sam.Record{r1, r2, sup} {
+			recs[r.Ref.ID()] = append(recs[r.Ref.ID()], r)
+		}
+	}
+
+	var buf bytes.Buffer
+	bw, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("failed to make writer: %v", err)
+	}
+	for _, rr := range recs {
+		sortByPos(rr)
+		for _, r := range rr {
+			err = bw.Write(r)
+			if err != nil {
+				t.Fatalf("failed to write record: %v", err)
+			}
+		}
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func sortByPos(recs []*sam.Record) {
+	for i := 1; i < len(recs); i++ {
+		for j := i; j > 0 && recs[j].Pos < recs[j-1].Pos; j-- {
+			recs[j], recs[j-1] = recs[j-1], recs[j]
+		}
+	}
+}
+
+func TestMateFetcher(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "synthetic", data: matePairs(t, 50)},
+		{name: "HG00096", data: bamHG00096_1000},
+	} {
+		br, err := NewReader(bytes.NewReader(test.data), 1)
+		if err != nil {
+			t.Fatalf("failed to open BAM: %v", err)
+		}
+
+		var (
+			idx   Index
+			names = NewNameIndex()
+			recs  []*sam.Record
+			pairs = make(map[string]int)
+		)
+		for {
+			r, err := br.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("failed to read BAM record: %v", err)
+			}
+			err = idx.Add(r, br.LastChunk())
+			if err != nil {
+				t.Fatalf("failed to add record to index: %v", err)
+			}
+			err = names.Add(r, br.LastChunk())
+			if err != nil {
+				t.Fatalf("failed to add record to name index: %v", err)
+			}
+			if isPrimaryPaired(r) {
+				recs = append(recs, r)
+				pairs[r.Name]++
+			}
+		}
+
+		for _, lookup := range []struct {
+			name  string
+			names *NameIndex
+			n     int
+		}{
+			{name: "index", n: 4},
+			{name: "names", names: names, n: 1},
+		} {
+			f := NewMateFetcher(br, &idx, lookup.names, lookup.n)
+			var found int
+			for _, r := range recs {
+				m, err := f.Mate(r)
+				unplaced := lookup.names == nil && r.Flags&(sam.Unmapped|sam.MateUnmapped) == sam.Unmapped|sam.MateUnmapped
+				if pairs[r.Name] != 2 || r.MateRef == nil || unplaced {
+					if err != ErrNoMate {
+						t.Errorf("%s %s: unexpected result for mateless %s: %v %v", test.name, lookup.name, r.Name, m, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%s %s: unexpected error fetching mate of %s: %v", test.name, lookup.name, r.Name, err)
+				}
+				found++
+				if m.Name != r.Name || m.Pos != r.MatePos || m.Ref != r.MateRef || m.Flags&(sam.Read1|sam.Read2) == r.Flags&(sam.Read1|sam.Read2) || !isPrimaryPaired(m) {
+					t.Errorf("%s %s: unexpected mate for %s %v at %d: got:%s %v at %d", test.name, lookup.name, r.Name, r.Flags, r.Pos, m.Name, m.Flags, m.Pos)
+				}
+				if len(f.cache) > lookup.n || f.lru.Len() != len(f.cache) {
+					t.Errorf("%s %s: unexpected cache size: %d %d", test.name, lookup.name, len(f.cache), f.lru.Len())
+				}
+			}
+			if found == 0 && (test.name == "synthetic" || lookup.names != nil) {
+				t.Errorf("%s %s: no mates found", test.name, lookup.name)
+			}
+		}
+
+		unpaired := *recs[0]
+		unpaired.Flags &^= sam.Paired
+		if _, err := NewMateFetcher(br, &idx, nil, 0).Mate(&unpaired); err != ErrNoMate {
+			t.Errorf("%s: unexpected error for unpaired record: %v", test.name, err)
+		}
+		br.Close()
+	}
+}
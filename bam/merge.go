@@ -0,0 +1,227 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+var rgTag = sam.NewTag("RG")
+
+// Merge writes the records of the src Readers to w as BAM, in the manner
+// of samtools merge. The header written is the merge of the src headers
+// constructed by sam.MergeHeaders, with the read groups of all sources.
+//
+// The src headers must have the same sort order. Coordinate sorted
+// sources are merged by reference ID in the merged header, position and
+// strand, and queryname sorted sources by name and then first before
+// last fragment. Names are compared in the order declared by the SS
+// sub-sort field of the headers, or lexically if none is declared.
+// Records that compare equal are written in the order of their sources.
+// Sources of other sort orders are concatenated. The references of
+// coordinate sorted sources must be in the same relative order in each
+// header.
+//
+// The src Readers are not closed by Merge.
+func Merge(w io.Writer, src ...*Reader) error {
+	return MergeTagged(w, nil, src...)
+}
+
+// MergeTagged writes the records of the src Readers to w as Merge does.
+// If ids is not nil, it holds a read group ID for each source. The RG
+// aux field of each record is set to the ID of its source, replacing
+// any existing read group, and read groups with the IDs are added to
+// the header if not already present.
+func MergeTagged(w io.Writer, ids []string, src ...*Reader) error {
+	if len(src) == 0 {
+		return errors.New("bam: no merge sources")
+	}
+	if ids != nil && len(ids) != len(src) {
+		return errors.New("bam: read group ID count does not match sources")
+	}
+
+	headers := make([]*sam.Header, len(src))
+	so := src[0].Header().SortOrder
+	for i, r := range src {
+		headers[i] = r.Header()
+		if headers[i].SortOrder != so {
+			return errors.New("bam: sort order mismatch")
+		}
+	}
+	h, links, err := sam.MergeHeaders(headers)
+	if err != nil {
+		return err
+	}
+	if links == nil {
+		h = h.Clone()
+		links = [][]*sam.Reference{h.Refs()}
+	}
+	h.SortOrder = so
+	for _, sh := range headers[1:] {
+		err = addReadGroups(h, sh.RGs())
+		if err != nil {
+			return err
+		}
+	}
+	var tags []sam.Aux
+	if ids != nil {
+		tags = make([]sam.Aux, len(ids))
+		for i, id := range ids {
+			tags[i], err = sam.NewAux(rgTag, id)
+			if err != nil {
+				return err
+			}
+			rg, err := sam.NewReadGroup(id, "", "", "", "", "", "", "", "", "", time.Time{}, 0)
+			if err != nil {
+				return err
+			}
+			err = addReadGroups(h, []*sam.ReadGroup{rg})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var less func(a, b *sam.Record) bool
+	switch so {
+	case sam.Coordinate:
+		less = lessByCoordinate
+		for _, l := range links {
+			for i := 1; i < len(l); i++ {
+				if l[i].ID() < l[i-1].ID() {
+					return errors.New("bam: inconsistent reference order")
+				}
+			}
+		}
+	case sam.QueryName:
+		order, declared, err := nameOrder(headers)
+		if err != nil {
+			return err
+		}
+		if declared {
+			err = setSubSort(h, order)
+			if err != nil {
+				return err
+			}
+		}
+		less = order.lessByNameAndRead
+	}
+
+	var runs sortRuns
+	for i, r := range src {
+		i, r := i, r
+		next := func() (*sam.Record, error) {
+			rec, err := r.Read()
+			if err != nil {
+				return nil, err
+			}
+			if id := rec.Ref.ID(); id >= 0 {
+				rec.Ref = links[i][id]
+			}
+			if id := rec.MateRef.ID(); id >= 0 {
+				rec.MateRef = links[i][id]
+			}
+			if tags != nil {
+				err = samutil.PutAux(rec, tags[i])
+				if err != nil {
+					return nil, err
+				}
+			}
+			return rec, nil
+		}
+		head, err := next()
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		runs.runs = append(runs.runs, &sortRun{id: i, head: head, next: next})
+	}
+	if less != nil {
+		runs.less = less
+		heap.Init(&runs)
+	}
+
+	bw, err := NewWriter(w, h, runtime.GOMAXPROCS(0))
+	if err != nil {
+		return err
+	}
+	for len(runs.runs) != 0 {
+		run := runs.runs[0]
+		err = bw.Write(run.head)
+		if err != nil {
+			bw.Close()
+			return err
+		}
+		run.head, err = run.next()
+		switch {
+		case err == io.EOF:
+			if less != nil {
+				heap.Pop(&runs)
+			} else {
+				runs.runs = runs.runs[1:]
+			}
+		case err != nil:
+			bw.Close()
+			return err
+		case less != nil:
+			heap.Fix(&runs, 0)
+		}
+	}
+	return bw.Close()
+}
+
+// nameOrder returns the name order of the queryname sorted headers and
+// whether any header declares it, returning an error if the headers
+// declare different orders.
+func nameOrder(headers []*sam.Header) (NameOrder, bool, error) {
+	var (
+		order    NameOrder
+		declared bool
+	)
+	for _, h := range headers {
+		o, ok := HeaderNameOrder(h)
+		if !ok {
+			continue
+		}
+		if declared && o != order {
+			return 0, false, errors.New("bam: name order mismatch")
+		}
+		order, declared = o, true
+	}
+	return order, declared, nil
+}
+
+// addReadGroups adds clones of the read groups in rgs to h, ignoring
+// read groups with IDs already in h.
+func addReadGroups(h *sam.Header, rgs []*sam.ReadGroup) error {
+	for _, rg := range rgs {
+		if hasReadGroup(h, rg.Name()) {
+			continue
+		}
+		err := h.AddReadGroup(rg.Clone())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasReadGroup(h *sam.Header, id string) bool {
+	for _, rg := range h.RGs() {
+		if rg.Name() == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestMerge(t *testing.T) {
+	newRefs := func(names ...string) []*sam.Reference {
+		var refs []*sam.Reference
+		for _, n := range names {
+			ref, err := sam.NewReference(n, "", "", 1000, nil, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating reference: %v", err)
+			}
+			refs = append(refs, ref)
+		}
+		return refs
+	}
+	type rec struct {
+		name string
+		ref  int
+		pos  int
+	}
+	bamData := func(refNames []string, so sam.SortOrder, recs []rec) []byte {
+		refs := newRefs(refNames...)
+		h, err := sam.NewHeader(nil, refs)
+		if err != nil {
+			t.Fatalf("unexpected error creating header: %v", err)
+		}
+		h.Version = "1.6"
+		h.SortOrder = so
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, h, 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating writer: %v", err)
+		}
+		for _, r := range recs {
+			var ref *sam.Reference
+			if r.ref >= 0 {
+				ref = refs[r.ref]
+			}
+			cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+			if ref == nil {
+				cigar = []sam.CigarOp{sam.NewCigarOp(sam.CigarSoftClipped, 4)}
+			}
+			sr, err := sam.NewRecord(r.name, ref, nil, r.pos, -1, 0, 60, cigar, []byte("ACGT"), nil, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating record: %v", err)
+			}
+			if ref == nil {
+				sr.Flags = sam.Unmapped
+			}
+			err = w.Write(sr)
+			if err != nil {
+				t.Fatalf("unexpected error writing record: %v", err)
+			}
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("unexpected error closing writer: %v", err)
+		}
+		return buf.Bytes()
+	}
+	readers := func(data ...[]byte) []*Reader {
+		var src []*Reader
+		for _, d := range data {
+			r, err := NewReader(bytes.NewReader(d), 1)
+			if err != nil {
+				t.Fatalf("unexpected error creating reader: %v", err)
+			}
+			src = append(src, r)
+		}
+		return src
+	}
+	read := func(data []byte) (*sam.Header, []*sam.Record) {
+		r, err := NewReader(bytes.NewReader(data), 1)
+		if err != nil {
+			t.Fatalf("unexpected error reading merged BAM: %v", err)
+		}
+		var recs []*sam.Record
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error reading merged record: %v", err)
+			}
+			recs = append(recs, rec)
+		}
+		return r.Header(), recs
+	}
+
+	a := bamData([]string{"chr1", "chr2"}, sam.Coordinate, []rec{
+		{"a1", 0, 10}, {"a2", 1, 5}, {"a3", 1, 20}, {"a4", -1, -1},
+	})
+	b := bamData([]string{"chr2", "chr3"}, sam.Coordinate, []rec{
+		{"b1", 0, 5}, {"b2", 0, 15}, {"b3", 1, 1}, {"b4", -1, -1},
+	})
+
+	var buf bytes.Buffer
+	err := MergeTagged(&buf, []string{"A", "B"}, readers(a, b)...)
+	if err != nil {
+		t.Fatalf("unexpected error merging: %v", err)
+	}
+	h, recs := read(buf.Bytes())
+	if h.SortOrder != sam.Coordinate {
+		t.Errorf("unexpected sort order: got:%v want:%v", h.SortOrder, sam.Coordinate)
+	}
+	var refNames []string
+	for _, r := range h.Refs() {
+		refNames = append(refNames, r.Name())
+	}
+	if got, want := refNames, []string{"chr1", "chr2", "chr3"}; !equalStrings(got, want) {
+		t.Errorf("unexpected references: got:%v want:%v", got, want)
+	}
+	if len(h.RGs()) != 2 || !hasReadGroup(h, "A") || !hasReadGroup(h, "B") {
+		t.Errorf("unexpected read groups: %v", h.RGs())
+	}
+	var got, gotRG []string
+	for _, r := range recs {
+		got = append(got, r.Name)
+		rg, _ := r.AuxFields.Get(rgTag).Value().(string)
+		gotRG = append(gotRG, rg)
+	}
+	if want := []string{"a1", "a2", "b1", "b2", "a3", "b3", "a4", "b4"}; !equalStrings(got, want) {
+		t.Errorf("unexpected merge order: got:%v want:%v", got, want)
+	}
+	if want := []string{"A", "A", "B", "B", "A", "B", "A", "B"}; !equalStrings(gotRG, want) {
+		t.Errorf("unexpected read groups: got:%v want:%v", gotRG, want)
+	}
+
+	q1 := bamData([]string{"chr1"}, sam.QueryName, []rec{{"x", 0, 1}, {"z", 0, 1}})
+	q2 := bamData([]string{"chr1"}, sam.QueryName, []rec{{"y", 0, 1}, {"z", 0, 2}})
+	buf.Reset()
+	err = Merge(&buf, readers(q1, q2)...)
+	if err != nil {
+		t.Fatalf("unexpected error merging: %v", err)
+	}
+	_, recs = read(buf.Bytes())
+	got = got[:0]
+	for _, r := range recs {
+		got = append(got, r.Name)
+		if r.AuxFields.Get(rgTag) != nil {
+			t.Errorf("unexpected read group tag: %v", r)
+		}
+	}
+	if want := []string{"x", "y", "z", "z"}; !equalStrings(got, want) {
+		t.Errorf("unexpected merge order: got:%v want:%v", got, want)
+	}
+	if recs[2].Pos != 1 || recs[3].Pos != 2 {
+		t.Errorf("unexpected tie order: got positions %d and %d", recs[2].Pos, recs[3].Pos)
+	}
+
+	// Natural name order is taken from the
+	// SS header field.
+	natural := func(data []byte) []byte {
+		r, err := NewReader(bytes.NewReader(data), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		h := r.Header().Clone()
+		err = setSubSort(h, Natural)
+		if err != nil {
+			t.Fatalf("unexpected error setting sub-sort: %v", err)
+		}
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, h, 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating writer: %v", err)
+		}
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error reading record: %v", err)
+			}
+			rec.Ref = h.Refs()[rec.Ref.ID()]
+			err = w.Write(rec)
+			if err != nil {
+				t.Fatalf("unexpected error writing record: %v", err)
+			}
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("unexpected error closing writer: %v", err)
+		}
+		return buf.Bytes()
+	}
+	n1 := natural(bamData([]string{"chr1"}, sam.QueryName, []rec{{"r9", 0, 1}, {"r100", 0, 1}}))
+	n2 := natural(bamData([]string{"chr1"}, sam.QueryName, []rec{{"r10", 0, 1}}))
+	buf.Reset()
+	err = Merge(&buf, readers(n1, n2)...)
+	if err != nil {
+		t.Fatalf("unexpected error merging: %v", err)
+	}
+	h, recs = read(buf.Bytes())
+	if order, ok := HeaderNameOrder(h); !ok || order != Natural {
+		t.Errorf("unexpected merged name order: got:%v,%t want:%v,true", order, ok, Natural)
+	}
+	got = got[:0]
+	for _, r := range recs {
+		got = append(got, r.Name)
+	}
+	if want := []string{"r9", "r10", "r100"}; !equalStrings(got, want) {
+		t.Errorf("unexpected natural merge order: got:%v want:%v", got, want)
+	}
+	err = Merge(io.Discard, readers(n1, q1)...)
+	if err != nil {
+		t.Errorf("unexpected error merging with undeclared name order: %v", err)
+	}
+
+	c := bamData([]string{"chr2", "chr1"}, sam.Coordinate, []rec{{"c1", 0, 1}})
+	err = Merge(io.Discard, readers(a, c)...)
+	if err == nil {
+		t.Error("expected error for inconsistent reference order")
+	}
+	err = Merge(io.Discard, readers(a, q1)...)
+	if err == nil {
+		t.Error("expected error for sort order mismatch")
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -41,7 +41,9 @@ type reader struct {
 // function. The header sort order fields must agree.
 //
 // Sort order is determined using the following rules:
-//  - for sam.QueryName the LessByName sam.Record method is used.
+//  - for sam.QueryName names are compared in the order declared by the
+//    SS header field, or by the LessByName sam.Record method if none is
+//    declared.
 //  - for sam.Coordinate the LessByCoordinate sam.Record method is used.
 //  - for sam.Unsorted the reader streams are concatenated.
 //  - for sam.Unknown the provided less function is used - if nil
@@ -81,7 +83,14 @@ func NewMerger(less func(a, b *sam.Record) bool, src ...*Reader) (*Merger, error
 		m.less = less
 	case sam.Unsorted:
 	case sam.QueryName:
+		order, _, err := nameOrder(headers)
+		if err != nil {
+			return nil, err
+		}
 		m.less = (*sam.Record).LessByName
+		if order == Natural {
+			m.less = func(a, b *sam.Record) bool { return NaturalLess(a.Name, b.Name) }
+		}
 	case sam.Coordinate:
 		m.less = (*sam.Record).LessByCoordinate
 	}
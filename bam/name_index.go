@@ -0,0 +1,225 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+// NameIndex is a read name sidecar index. It maps each read name (QNAME)
+// to the virtual offsets of all alignment records carrying that name,
+// allowing retrieval of both mates and any secondary and supplementary
+// alignments of a read without scanning the BAM or producing a
+// queryname-sorted copy.
+type NameIndex struct {
+	names map[string][]bgzf.Offset
+}
+
+// NewNameIndex returns an empty NameIndex.
+func NewNameIndex() *NameIndex {
+	return &NameIndex{names: make(map[string][]bgzf.Offset)}
+}
+
+// Add records the SAM record as having being located at the given chunk.
+// Records may be added in any order.
+func (i *NameIndex) Add(r *sam.Record, c bgzf.Chunk) error {
+	if len(r.Name) == 0 || len(r.Name) > 254 {
+		return errors.New("bam: name absent or too long")
+	}
+	if i.names == nil {
+		i.names = make(map[string][]bgzf.Offset)
+	}
+	// Keep offsets in file order so that
+	// Offsets does not alter the index.
+	offs := i.names[r.Name]
+	v := vOffset(c.Begin)
+	k := len(offs)
+	if k != 0 && v < vOffset(offs[k-1]) {
+		k = sort.Search(len(offs), func(j int) bool { return vOffset(offs[j]) > v })
+	}
+	offs = append(offs, bgzf.Offset{})
+	copy(offs[k+1:], offs[k:])
+	offs[k] = c.Begin
+	i.names[r.Name] = offs
+	return nil
+}
+
+// Len returns the number of distinct read names in the index.
+func (i *NameIndex) Len() int {
+	return len(i.names)
+}
+
+// Offsets returns the virtual offsets of the records with the given
+// name, in file order. The returned slice should not be altered.
+func (i *NameIndex) Offsets(name string) []bgzf.Offset {
+	return i.names[name]
+}
+
+// Names returns the read names held by the index in lexical order.
+func (i *NameIndex) Names() []string {
+	names := make([]string, 0, len(i.names))
+	for n := range i.names {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FetchByName returns all records in r with the given name using the
+// NameIndex idx. The records are returned in file order. FetchByName
+// leaves the Reader positioned after the last record read and clears
+// any chunk set with SetChunk.
+func FetchByName(r *Reader, idx *NameIndex, name string) ([]*sam.Record, error) {
+	offs := idx.Offsets(name)
+	if len(offs) == 0 {
+		return nil, nil
+	}
+	err := r.SetChunk(nil)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]*sam.Record, 0, len(offs))
+	for _, o := range offs {
+		err = r.Seek(o)
+		if err != nil {
+			return nil, err
+		}
+		rec, err := r.Read()
+		if err != nil {
+			return nil, fmt.Errorf("bam: failed to read record at %+v: %v", o, err)
+		}
+		if rec.Name != name {
+			return nil, fmt.Errorf("bam: name index mismatch at %+v: got %q want %q", o, rec.Name, name)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+var bniMagic = [4]byte{'B', 'N', 'I', 0x1}
+
+// ReadNameIndex reads a NameIndex from the given io.Reader.
+func ReadNameIndex(r io.Reader) (*NameIndex, error) {
+	var magic [4]byte
+	err := binary.Read(r, binary.LittleEndian, &magic)
+	if err != nil {
+		return nil, err
+	}
+	if magic != bniMagic {
+		return nil, errors.New("bam: name index magic number mismatch")
+	}
+	var n int32
+	err = binary.Read(r, binary.LittleEndian, &n)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, errors.New("bam: invalid name count")
+	}
+	// Limit the initial allocations so that corrupt
+	// counts fail on reading rather than on allocation.
+	c := n
+	if c > 1<<16 {
+		c = 1 << 16
+	}
+	idx := &NameIndex{names: make(map[string][]bgzf.Offset, c)}
+	var (
+		name []byte
+		lens [5]byte
+	)
+	for k := 0; k < int(n); k++ {
+		_, err = io.ReadFull(r, lens[:])
+		if err != nil {
+			return nil, fmt.Errorf("bam: failed to read name index entry: %v", err)
+		}
+		nLen := int(lens[0])
+		nOff := int32(binary.LittleEndian.Uint32(lens[1:]))
+		if nLen == 0 || nOff <= 0 {
+			return nil, errors.New("bam: malformed name index entry")
+		}
+		if cap(name) < nLen {
+			name = make([]byte, nLen)
+		}
+		name = name[:nLen]
+		_, err = io.ReadFull(r, name)
+		if err != nil {
+			return nil, fmt.Errorf("bam: failed to read name index name: %v", err)
+		}
+		c := nOff
+		if c > 1<<10 {
+			c = 1 << 10
+		}
+		offs := make([]bgzf.Offset, 0, c)
+		var buf [8]byte
+		for j := int32(0); j < nOff; j++ {
+			_, err = io.ReadFull(r, buf[:])
+			if err != nil {
+				return nil, fmt.Errorf("bam: failed to read name index virtual offset: %v", err)
+			}
+			offs = append(offs, makeOffset(binary.LittleEndian.Uint64(buf[:])))
+		}
+		if !sort.IsSorted(byVirtualOffset(offs)) {
+			sort.Sort(byVirtualOffset(offs))
+		}
+		idx.names[string(name)] = offs
+	}
+	return idx, nil
+}
+
+// WriteNameIndex writes the NameIndex to the given io.Writer. Names are
+// written in lexical order and offsets in file order so the output is
+// deterministic for a given set of records.
+func WriteNameIndex(w io.Writer, idx *NameIndex) error {
+	err := binary.Write(w, binary.LittleEndian, bniMagic)
+	if err != nil {
+		return err
+	}
+	names := idx.Names()
+	err = binary.Write(w, binary.LittleEndian, int32(len(names)))
+	if err != nil {
+		return err
+	}
+	var lens [5]byte
+	for _, n := range names {
+		offs := idx.Offsets(n)
+		lens[0] = byte(len(n))
+		binary.LittleEndian.PutUint32(lens[1:], uint32(len(offs)))
+		_, err = w.Write(lens[:])
+		if err != nil {
+			return fmt.Errorf("bam: failed to write name index entry: %v", err)
+		}
+		_, err = io.WriteString(w, n)
+		if err != nil {
+			return fmt.Errorf("bam: failed to write name index name: %v", err)
+		}
+		for _, o := range offs {
+			err = binary.Write(w, binary.LittleEndian, uint64(vOffset(o)))
+			if err != nil {
+				return fmt.Errorf("bam: failed to write name index virtual offset: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func makeOffset(vOff uint64) bgzf.Offset {
+	return bgzf.Offset{
+		File:  int64(vOff >> 16),
+		Block: uint16(vOff),
+	}
+}
+
+type byVirtualOffset []bgzf.Offset
+
+func (o byVirtualOffset) Len() int           { return len(o) }
+func (o byVirtualOffset) Less(i, j int) bool { return vOffset(o[i]) < vOffset(o[j]) }
+func (o byVirtualOffset) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
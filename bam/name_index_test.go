@@ -0,0 +1,114 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestNameIndex(t *testing.T) {
+	br, err := NewReader(bytes.NewReader(bamHG00096_1000), 1)
+	if err != nil {
+		t.Fatalf("failed to open BAM: %v", err)
+	}
+	defer br.Close()
+
+	idx := NewNameIndex()
+	counts := make(map[string]int)
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read BAM record: %v", err)
+		}
+		counts[r.Name]++
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("failed to add record to name index: %v", err)
+		}
+	}
+	if idx.Len() != len(counts) {
+		t.Errorf("unexpected number of names: got:%d want:%d", idx.Len(), len(counts))
+	}
+
+	var buf bytes.Buffer
+	err = WriteNameIndex(&buf, idx)
+	if err != nil {
+		t.Fatalf("failed to write name index: %v", err)
+	}
+	got, err := ReadNameIndex(&buf)
+	if err != nil {
+		t.Fatalf("failed to read name index: %v", err)
+	}
+	if !reflect.DeepEqual(got, idx) {
+		t.Error("name index round trip mismatch")
+	}
+
+	for name, n := range counts {
+		recs, err := FetchByName(br, got, name)
+		if err != nil {
+			t.Fatalf("failed to fetch %q: %v", name, err)
+		}
+		if len(recs) != n {
+			t.Errorf("unexpected number of records for %q: got:%d want:%d", name, len(recs), n)
+		}
+	}
+
+	recs, err := FetchByName(br, got, "no-such-read")
+	if err != nil || recs != nil {
+		t.Errorf("unexpected result for missing name: %v %v", recs, err)
+	}
+}
+
+func TestNameIndexAddOrder(t *testing.T) {
+	idx := NewNameIndex()
+	r := &sam.Record{Name: "r"}
+	var want []bgzf.Offset
+	for _, f := range []int64{30, 10, 40, 20, 20, 0} {
+		err := idx.Add(r, bgzf.Chunk{Begin: bgzf.Offset{File: f}})
+		if err != nil {
+			t.Fatalf("failed to add record to name index: %v", err)
+		}
+	}
+	for _, f := range []int64{0, 10, 20, 20, 30, 40} {
+		want = append(want, bgzf.Offset{File: f})
+	}
+	if got := idx.Offsets("r"); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected offsets: got:%v want:%v", got, want)
+	}
+}
+
+func TestReadNameIndexCorrupt(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "name count",
+			data: append(append([]byte{}, bniMagic[:]...), 0xff, 0xff, 0xff, 0x7f),
+		},
+		{
+			name: "offset count",
+			data: append(append([]byte{}, bniMagic[:]...),
+				1, 0, 0, 0, // One name.
+				1, 0xff, 0xff, 0xff, 0x7f, // Name length and offset count.
+				'r',
+			),
+		},
+	} {
+		_, err := ReadNameIndex(bytes.NewReader(test.data))
+		if err == nil {
+			t.Errorf("expected error for corrupt %s", test.name)
+		}
+	}
+}
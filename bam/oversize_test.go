@@ -0,0 +1,126 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestRecordSizeLimit(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	big, _ := sam.NewAux(sam.NewTag("XX"), strings.Repeat("A", 1000))
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	err = w.SetMaxRecordSize(500)
+	if err != nil {
+		t.Fatalf("unexpected error setting writer limit: %v", err)
+	}
+	for i, name := range []string{"r0", "long", "r2"} {
+		var aux []sam.Aux
+		if name == "long" {
+			aux = []sam.Aux{big}
+		}
+		r, err := sam.NewRecord(name, chr1, nil, i, -1, 0, 60, cigar, []byte("ACGT"), nil, aux)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		err = w.Write(r)
+		if name == "long" {
+			if err == nil {
+				t.Fatal("expected error writing record over limit")
+			}
+			err = w.SetMaxRecordSize(0)
+			if err != nil {
+				t.Fatalf("unexpected error resetting writer limit: %v", err)
+			}
+			err = w.Write(r)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	read := func(limit int, skip bool) (names []string, r *Reader, err error) {
+		r, err = NewReader(bytes.NewReader(buf.Bytes()), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		err = r.SetMaxRecordSize(limit)
+		if err != nil {
+			t.Fatalf("unexpected error setting reader limit: %v", err)
+		}
+		r.SkipOversize(skip)
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				return names, r, nil
+			}
+			if err != nil {
+				return names, r, err
+			}
+			names = append(names, rec.Name)
+		}
+	}
+
+	names, r, err := read(0, false)
+	if err != nil {
+		t.Errorf("unexpected error reading with default limit: %v", err)
+	}
+	if got := strings.Join(names, " "); got != "r0 long r2" {
+		t.Errorf("unexpected records with default limit: %s", got)
+	}
+	r.Close()
+
+	names, r, err = read(500, false)
+	e, ok := err.(*RecordSizeError)
+	if !ok {
+		t.Fatalf("unexpected error reading over limit: %v", err)
+	}
+	if e.Name != "long" || e.Limit != 500 || e.Size <= 1000 {
+		t.Errorf("unexpected record size error: %+v", e)
+	}
+	if strings.Join(names, " ") != "r0" {
+		t.Errorf("unexpected records before error: %v", names)
+	}
+	r.Close()
+
+	names, r, err = read(500, true)
+	if err != nil {
+		t.Errorf("unexpected error reading with skip: %v", err)
+	}
+	if got := strings.Join(names, " "); got != "r0 r2" {
+		t.Errorf("unexpected records with skip: %s", got)
+	}
+	warnings := r.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if we, ok := warnings[0].(*RecordSizeError); !ok || *we != *e {
+		t.Errorf("unexpected warning: got:%v want:%v", warnings[0], e)
+	}
+	r.Close()
+
+	var br Reader
+	if br.SetMaxRecordSize(-1) == nil {
+		t.Error("expected error for invalid limit")
+	}
+}
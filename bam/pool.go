@@ -1,15 +1,54 @@
 package bam
 
 import (
+	"math/bits"
 	"sync"
-	"reflect"
 	"unsafe"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// Scratch buffers are pooled in power of two size classes from
+// 1<<minScratchShift to 1<<maxScratchShift bytes, so that a buffer
+// grown for a long record is only reused for records of similar size.
+// Buffers with capacity beyond the largest class are not retained.
+const (
+	minScratchShift = 8
+	maxScratchShift = 22
 )
 
-var bufPool = sync.Pool{
-	New: func() interface{} {
-		return []byte{}
-	},
+// scratchPools holds the pooled buffers of each size class. The
+// buffers in scratchPools[i] have capacity at least 1<<(i+minScratchShift).
+var scratchPools [maxScratchShift - minScratchShift + 1]sync.Pool
+
+// getScratch returns a buffer of length zero and capacity at least n,
+// from the pool if one is available.
+func getScratch(n int) *[]byte {
+	shift := minScratchShift
+	if n > 1<<minScratchShift {
+		shift = bits.Len(uint(n - 1))
+	}
+	if shift > maxScratchShift {
+		buf := make([]byte, 0, n)
+		return &buf
+	}
+	if buf, ok := scratchPools[shift-minScratchShift].Get().(*[]byte); ok {
+		*buf = (*buf)[:0]
+		return buf
+	}
+	buf := make([]byte, 0, 1<<shift)
+	return &buf
+}
+
+// putScratch returns buf to the pool of the largest size class its
+// capacity satisfies. Buffers smaller than the smallest class or larger
+// than the largest class are dropped.
+func putScratch(buf *[]byte) {
+	c := cap(*buf)
+	if c < 1<<minScratchShift || c > 1<<maxScratchShift {
+		return
+	}
+	scratchPools[bits.Len(uint(c))-1-minScratchShift].Put(buf)
 }
 
 func resizeScratch(buf *[]byte, n int) {
@@ -23,7 +62,7 @@ func resizeScratch(buf *[]byte, n int) {
 	}
 }
 
-const sizeofSliceHeader = int(unsafe.Sizeof(reflect.SliceHeader{}))
+const sizeofSliceHeader = int(unsafe.Sizeof(sam.Aux(nil)))
 
 // Round "off" up so that it is a multiple of 8. Used when storing a pointer in
 // []byte.  8-byte alignment is sufficient for all CPUs we care about.
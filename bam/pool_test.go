@@ -0,0 +1,42 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import "testing"
+
+func TestScratchPool(t *testing.T) {
+	for _, n := range []int{0, 1, 255, 256, 257, 1000, 1 << 20, 1<<maxScratchShift + 1} {
+		buf := getScratch(n)
+		if len(*buf) != 0 || cap(*buf) < n {
+			t.Errorf("unexpected buffer for size %d: len=%d cap=%d", n, len(*buf), cap(*buf))
+		}
+		putScratch(buf)
+	}
+
+	// Large buffers must not be handed out
+	// for small requests.
+	for i := 0; i < 16; i++ {
+		big := make([]byte, 0, 1<<20)
+		putScratch(&big)
+	}
+	for _, n := range []int{10, 300, 5000} {
+		for i := 0; i < 16; i++ {
+			buf := getScratch(n)
+			if cap(*buf) >= 4*n && cap(*buf) > 1<<(minScratchShift+1) {
+				t.Errorf("unexpected capacity for size %d: %d", n, cap(*buf))
+			}
+		}
+	}
+
+	// Buffers beyond the largest class
+	// are not retained.
+	huge := make([]byte, 0, 1<<maxScratchShift+1)
+	putScratch(&huge)
+	for i := 0; i < 16; i++ {
+		if buf := getScratch(1 << maxScratchShift); buf == &huge {
+			t.Error("unexpected retention of oversized buffer")
+		}
+	}
+}
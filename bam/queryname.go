@@ -0,0 +1,128 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"strings"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// NameOrder is an ordering of read names in queryname sorted data.
+type NameOrder int
+
+const (
+	// Lexicographic orders names bytewise,
+	// as Picard SortSam.
+	Lexicographic NameOrder = iota
+
+	// Natural orders names with runs of
+	// digits compared by numeric value, as
+	// samtools sort.
+	Natural
+)
+
+// String returns the name of the order as used in the sub-sort field
+// of a SAM header.
+func (o NameOrder) String() string {
+	switch o {
+	case Lexicographic:
+		return "lexicographical"
+	case Natural:
+		return "natural"
+	}
+	return "unknown"
+}
+
+// Less returns whether the name a sorts before b in the order o.
+func (o NameOrder) Less(a, b string) bool {
+	if o == Natural {
+		return NaturalLess(a, b)
+	}
+	return a < b
+}
+
+// lessByNameAndRead returns whether a sorts before b by name and then
+// first before last fragment.
+func (o NameOrder) lessByNameAndRead(a, b *sam.Record) bool {
+	if a.Name != b.Name {
+		return o.Less(a.Name, b.Name)
+	}
+	return a.Flags&(sam.Read1|sam.Read2) < b.Flags&(sam.Read1|sam.Read2)
+}
+
+var subSortTag = sam.NewTag("SS")
+
+// HeaderNameOrder returns the name order declared by the SS sub-sort
+// field of h, and whether h is queryname sorted with a recognised name
+// order.
+func HeaderNameOrder(h *sam.Header) (NameOrder, bool) {
+	if h.SortOrder != sam.QueryName {
+		return Lexicographic, false
+	}
+	ss := h.Get(subSortTag)
+	if !strings.HasPrefix(ss, "queryname:") {
+		return Lexicographic, false
+	}
+	sub := strings.TrimPrefix(ss, "queryname:")
+	if i := strings.IndexByte(sub, ':'); i >= 0 {
+		sub = sub[:i]
+	}
+	switch sub {
+	case Lexicographic.String():
+		return Lexicographic, true
+	case Natural.String():
+		return Natural, true
+	}
+	return Lexicographic, false
+}
+
+// setSubSort sets the SS sub-sort field of h for its sort order,
+// removing the field if h is not queryname sorted.
+func setSubSort(h *sam.Header, o NameOrder) error {
+	if h.SortOrder != sam.QueryName {
+		return h.Set(subSortTag, "")
+	}
+	return h.Set(subSortTag, "queryname:"+o.String())
+}
+
+// NaturalLess returns whether the name a sorts before b when runs of
+// digits are compared by numeric value, the queryname order used by
+// samtools sort.
+func NaturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			for i < len(a) && a[i] == '0' {
+				i++
+			}
+			for j < len(b) && b[j] == '0' {
+				j++
+			}
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			if i-si != j-sj {
+				return i-si < j-sj
+			}
+			if c := strings.Compare(a[si:i], b[sj:j]); c != 0 {
+				return c < 0
+			}
+			continue
+		}
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+	return len(a)-i < len(b)-j
+}
+
+func isDigit(b byte) bool { return '0' <= b && b <= '9' }
@@ -0,0 +1,73 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestNaturalLess(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want bool
+	}{
+		{"r9", "r10", true},
+		{"r10", "r9", false},
+		{"r010", "r9", false},
+		{"a", "b", true},
+		{"r1", "r1", false},
+		{"r1", "r1a", true},
+		{"r1:2", "r1:10", true},
+		{"r1:10", "r2:2", true},
+	} {
+		if got := NaturalLess(test.a, test.b); got != test.want {
+			t.Errorf("unexpected result for NaturalLess(%q, %q): got:%t want:%t", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestHeaderNameOrder(t *testing.T) {
+	for _, test := range []struct {
+		so       sam.SortOrder
+		ss       string
+		want     NameOrder
+		declared bool
+	}{
+		{so: sam.QueryName, ss: "queryname:natural", want: Natural, declared: true},
+		{so: sam.QueryName, ss: "queryname:lexicographical", want: Lexicographic, declared: true},
+		{so: sam.QueryName, ss: "queryname:natural:extra", want: Natural, declared: true},
+		{so: sam.QueryName, ss: "queryname:other", want: Lexicographic},
+		{so: sam.QueryName, want: Lexicographic},
+		{so: sam.Coordinate, ss: "queryname:natural", want: Lexicographic},
+	} {
+		h, err := sam.NewHeader(nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating header: %v", err)
+		}
+		h.SortOrder = test.so
+		if test.ss != "" {
+			err = h.Set(subSortTag, test.ss)
+			if err != nil {
+				t.Fatalf("unexpected error setting SS: %v", err)
+			}
+		}
+		got, declared := HeaderNameOrder(h)
+		if got != test.want || declared != test.declared {
+			t.Errorf("unexpected name order for %v %q: got:%v,%t want:%v,%t", test.so, test.ss, got, declared, test.want, test.declared)
+		}
+		if test.declared {
+			h.Set(subSortTag, "")
+			err = setSubSort(h, test.want)
+			if err != nil {
+				t.Fatalf("unexpected error setting sub-sort: %v", err)
+			}
+			if got, _ := HeaderNameOrder(h); got != test.want {
+				t.Errorf("unexpected name order after setting sub-sort: got:%v want:%v", got, test.want)
+			}
+		}
+	}
+}
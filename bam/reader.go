@@ -5,11 +5,11 @@
 package bam
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
-	"reflect"
 	"unsafe"
 
 	"github.com/Schaudge/hts/bgzf"
@@ -31,15 +31,67 @@ type Reader struct {
 	// a read of the BAM input.
 	omit int
 
+	// lazyAux specifies that aux fields
+	// are left undecoded in RawAux.
+	lazyAux bool
+
+	// pool is the pool records are
+	// obtained from. If pool is nil the
+	// global sam free pool is used.
+	pool *sam.RecordPool
+
+	// recs holds records obtained from
+	// the pool in a batch and not yet
+	// returned by Read.
+	recs []*sam.Record
+
+	// stats, if not nil, counts the
+	// records read.
+	stats *Stats
+
+	// lastBin is the index bin stored in
+	// the last record read.
+	lastBin uint16
+
+	// maxRecordSize is the limit on the size
+	// of records read. If zero,
+	// DefaultMaxRecordSize is used.
+	// skipOversize specifies that records
+	// over the limit are skipped.
+	maxRecordSize int
+	skipOversize  bool
+
+	// eofPolicy is the handling of a missing
+	// BGZF EOF block, and warnings holds the
+	// warnings recorded during reading.
+	eofPolicy EOFPolicy
+	warnings  []error
+
 	lastChunk bgzf.Chunk
 
+	// closer is closed when the Reader
+	// is closed, if not nil.
+	closer io.Closer
+
 	// sizeBuf and sizeStorage are used to read the block size of each record
 	// without having to allocate new storage and a slice everytime.
 	sizeStorage [4]byte
 	sizeBuf     []byte
 }
 
-const maxBAMRecordSize = 0xffffff
+const (
+	// DefaultMaxRecordSize is the default limit on
+	// the encoded size of a BAM record read or
+	// written, excluding the block size field.
+	DefaultMaxRecordSize = 0xffffff
+
+	// MaxRecordSize is the largest record size
+	// that can be held in the block size field
+	// of a BAM record.
+	MaxRecordSize = 1<<31 - 1
+
+	maxBAMRecordSize = DefaultMaxRecordSize
+)
 
 // NewReader returns a new Reader using the given io.Reader
 // and setting the read concurrency to rd. If rd is zero
@@ -105,6 +157,50 @@ func (br *Reader) Omit(o int) {
 	br.omit = o
 }
 
+// LazyAux specifies whether the aux fields of records returned by Read
+// are left undecoded in the RawAux field of the record, to be searched
+// when a tag is requested with a sam.Record method, rather than parsed
+// into AuxFields. Code that examines AuxFields directly must call the
+// DecodeAux method of records read in lazy mode first.
+func (br *Reader) LazyAux(lazy bool) {
+	br.lazyAux = lazy
+}
+
+// SetPool sets the pool that records returned by Read are obtained
+// from. If p is nil, the global pool used by sam.GetFromFreePool is
+// used. Records may be returned to p with its Put method once they are
+// no longer referenced.
+func (br *Reader) SetPool(p *sam.RecordPool) {
+	br.release()
+	br.pool = p
+}
+
+// record returns an empty record from the Reader's pool, getting
+// records from the pool in batches to reduce contention between
+// concurrent Readers.
+func (br *Reader) record() *sam.Record {
+	if len(br.recs) == 0 {
+		if cap(br.recs) == 0 {
+			br.recs = make([]*sam.Record, sam.PoolBatchSize)
+		}
+		br.recs = br.recs[:cap(br.recs)]
+		br.pool.GetBatch(br.recs)
+	}
+	rec := br.recs[len(br.recs)-1]
+	br.recs[len(br.recs)-1] = nil
+	br.recs = br.recs[:len(br.recs)-1]
+	return rec
+}
+
+// release returns the unused records held by the Reader to its pool.
+func (br *Reader) release() {
+	br.pool.PutBatch(br.recs)
+	for i := range br.recs {
+		br.recs[i] = nil
+	}
+	br.recs = br.recs[:0]
+}
+
 // None, AuxTags and AllVariableLengthData are values taken
 // by the Reader Omit method.
 const (
@@ -120,25 +216,164 @@ const (
 // prior to the Read call and will not contain the auxiliary tag data
 // is Omit(AuxTags) has been called.
 func (br *Reader) Read() (*sam.Record, error) {
-	if br.c != nil && vOffset(br.r.LastChunk().End) >= vOffset(br.c.End) {
-		return nil, io.EOF
-	}
-	// Use a pool of buffer's to share buffers between concurrent clients
-	// and hence reduce the number of allocations required.
-	buf := bufPool.Get().([]byte)
-	if err := readAlignment(br, &buf); err != nil {
-		bufPool.Put(buf)
+	var (
+		buf *[]byte
+		err error
+	)
+	for {
+		if br.c != nil && vOffset(br.r.LastChunk().End) >= vOffset(br.c.End) {
+			return nil, io.EOF
+		}
+		buf, err = readAlignment(br)
+		if err != errSkipped {
+			break
+		}
+	}
+	if err != nil {
+		if err == io.EOF && br.c == nil {
+			err = br.checkEOF()
+		}
 		return nil, err
 	}
-	rec, err := unmarshal(buf, br.h, br.omit)
-	bufPool.Put(buf)
-	return rec, err
+	if len(*buf) >= 12 {
+		br.lastBin = binary.LittleEndian.Uint16((*buf)[10:])
+	}
+	rec := br.record()
+	if br.stats != nil {
+		br.stats.addPoolGet(cap(rec.Scratch) != 0)
+		br.stats.addRecord(lenFieldSize+len(*buf), encodedAuxLen(*buf))
+	}
+	r, err := unmarshal(rec, *buf, br.h, br.omit, br.lazyAux)
+	putScratch(buf)
+	if err != nil {
+		br.pool.Put(rec)
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetMaxRecordSize sets the limit on the encoded size of records read
+// by the Reader to n bytes. If n is zero, DefaultMaxRecordSize is used.
+// The limit may be raised to MaxRecordSize to read records with very
+// large aux fields, such as those of ultra-long reads, at the cost of
+// allocating buffers of up to the limit for corrupt input.
+func (br *Reader) SetMaxRecordSize(n int) error {
+	if n < 0 || n > MaxRecordSize {
+		return fmt.Errorf("bam: invalid record size limit: %d", n)
+	}
+	br.maxRecordSize = n
+	return nil
+}
+
+// SkipOversize specifies whether records larger than the Reader's size
+// limit are skipped by Read, rather than returning a *RecordSizeError.
+// Skipped records are recorded as *RecordSizeError warnings.
+func (br *Reader) SkipOversize(skip bool) {
+	br.skipOversize = skip
+}
+
+// RecordSizeError is the error returned by Read for a record larger
+// than the Reader's size limit.
+type RecordSizeError struct {
+	// Name is the name of the read.
+	Name string
+
+	// Size is the size of the record
+	// and Limit is the size limit.
+	Size, Limit int
+
+	// Offset is the virtual offset
+	// of the record in the BAM stream.
+	Offset bgzf.Offset
+}
+
+func (e *RecordSizeError) Error() string {
+	return fmt.Sprintf("bam: record %q at offset %d:%d too large: %d bytes exceeds limit of %d",
+		e.Name, e.Offset.File, e.Offset.Block, e.Size, e.Limit)
+}
+
+// ErrNoEOFBlock is the error returned by Read at the end of a BAM
+// stream without a BGZF EOF block when the Reader's EOF policy is
+// RequireEOF, and the warning recorded when the policy is WarnMissingEOF.
+var ErrNoEOFBlock = errors.New("bam: missing EOF block")
+
+// EOFPolicy specifies how a Reader handles a BAM stream that ends
+// without the BGZF EOF block required by the SAM specification. Such
+// files may be truncated, but are also written by some legacy tools.
+type EOFPolicy int
+
+const (
+	// IgnoreMissingEOF does not check for
+	// the EOF block.
+	IgnoreMissingEOF EOFPolicy = iota
+
+	// WarnMissingEOF records ErrNoEOFBlock
+	// as a warning when the end of the
+	// stream is reached without an EOF
+	// block, and Read returns io.EOF.
+	WarnMissingEOF
+
+	// RequireEOF returns ErrNoEOFBlock from
+	// Read in place of io.EOF when the end
+	// of the stream is reached without an
+	// EOF block.
+	RequireEOF
+)
+
+// SetEOFPolicy sets how the Reader handles a missing BGZF EOF block.
+// The check is made when Read reaches the end of the stream outside of
+// an iteration over chunks. The default policy is IgnoreMissingEOF.
+func (br *Reader) SetEOFPolicy(p EOFPolicy) {
+	br.eofPolicy = p
+}
+
+// Warnings returns the warnings recorded by the Reader, such as
+// ErrNoEOFBlock under the WarnMissingEOF policy.
+func (br *Reader) Warnings() []error {
+	return br.warnings
+}
+
+// checkEOF returns the error for the end of the stream according to
+// the Reader's EOF policy, recording a warning if needed.
+func (br *Reader) checkEOF() error {
+	if br.eofPolicy == IgnoreMissingEOF || br.r.EOFBlock() {
+		return io.EOF
+	}
+	if br.eofPolicy == RequireEOF {
+		return ErrNoEOFBlock
+	}
+	for _, w := range br.warnings {
+		if w == ErrNoEOFBlock {
+			return io.EOF
+		}
+	}
+	br.warnings = append(br.warnings, ErrNoEOFBlock)
+	return io.EOF
+}
+
+// SetStats sets the Stats counting the records read by the Reader. If
+// s is nil, records are not counted.
+func (br *Reader) SetStats(s *Stats) {
+	br.stats = s
+}
+
+// Unmarshal returns the record serialized in b by Marshal or
+// MarshalRecord, resolving reference IDs using h.
+func Unmarshal(b []byte, h *sam.Header) (*sam.Record, error) {
+	if len(b) < 4 {
+		return nil, errors.New("bam: record too short")
+	}
+	if int(binary.LittleEndian.Uint32(b)) != len(b)-4 {
+		return nil, errors.New("bam: record length mismatch")
+	}
+	return unmarshal(sam.GetFromFreePool(), b[4:], h, 0, false)
 }
 
 // Unmarshal a serialized record.  Parameter omit is the value of Reader.Omit().
-// Most callers should pass zero as omit.
-func unmarshal(b []byte, header *sam.Header, omit int) (*sam.Record, error) {
-	rec := sam.GetFromFreePool()
+// Most callers should pass zero as omit. The record is decoded into rec,
+// which must be empty. If lazyAux is true, the aux fields are left in
+// rec.RawAux.
+func unmarshal(rec *sam.Record, b []byte, header *sam.Header, omit int, lazyAux bool) (*sam.Record, error) {
 	if len(b) < 32 {
 		return nil, errors.New("bam: record too short")
 	}
@@ -166,9 +401,13 @@ func unmarshal(b []byte, header *sam.Header, omit int) (*sam.Record, error) {
 	if len(b) < bAuxOffset {
 		return nil, fmt.Errorf("Corrupt BAM aux record: len(b)=%d, auxoffset=%d", len(b), bAuxOffset)
 	}
-	nAuxFields, err := countAuxFields(b[bAuxOffset:])
-	if err != nil {
-		return nil, err
+	var nAuxFields int
+	if !lazyAux {
+		var err error
+		nAuxFields, err = countAuxFields(b[bAuxOffset:])
+		if err != nil {
+			return nil, err
+		}
 	}
 	shadowSize := auxOffset + (nAuxFields * sizeofSliceHeader)
 
@@ -181,30 +420,20 @@ func unmarshal(b []byte, header *sam.Header, omit int) (*sam.Record, error) {
 	shadowBuf := rec.Scratch
 	copy(shadowBuf, b[pos:])
 
-	bufHdr := (*reflect.SliceHeader)(unsafe.Pointer(&shadowBuf))
-
 	// Note that rec.Name now points to the shadow buffer
-	hdr := (*reflect.StringHeader)(unsafe.Pointer(&rec.Name))
-	hdr.Data = uintptr(unsafe.Pointer(bufHdr.Data))
-	hdr.Len = nLen - 1 // drop trailing '\0'
+	if nLen > 1 {
+		rec.Name = unsafe.String(&shadowBuf[0], nLen-1) // drop trailing '\0'
+	}
 	shadowOffset += nLen
 
-	var sliceHdr *reflect.SliceHeader
-
 	if nCigar > 0 {
 		for i := 0; i < nCigar; i++ {
 			*(*uint32)(unsafe.Pointer(&shadowBuf[cigarOffset+(i*4)])) = binary.LittleEndian.Uint32(shadowBuf[shadowOffset+(i*4):])
 		}
-		sliceHdr = (*reflect.SliceHeader)(unsafe.Pointer(&rec.Cigar))
-		sliceHdr.Data = bufHdr.Data + uintptr(cigarOffset)
-		sliceHdr.Len = nCigar
-		sliceHdr.Cap = sliceHdr.Len
+		rec.Cigar = unsafe.Slice((*sam.CigarOp)(unsafe.Pointer(&shadowBuf[cigarOffset])), nCigar)
 		shadowOffset += nCigar * 4
 	} else {
-		sliceHdr = (*reflect.SliceHeader)(unsafe.Pointer(&rec.Cigar))
-		sliceHdr.Data = uintptr(0)
-		sliceHdr.Len = 0
-		sliceHdr.Cap = 0
+		rec.Cigar = nil
 	}
 
 	if omit >= AllVariableLengthData {
@@ -213,34 +442,31 @@ func unmarshal(b []byte, header *sam.Header, omit int) (*sam.Record, error) {
 
 	rec.Seq.Length = lSeq
 
-	sliceHdr = (*reflect.SliceHeader)(unsafe.Pointer(&rec.Seq.Seq))
-	sliceHdr.Data = uintptr(unsafe.Pointer(bufHdr.Data + uintptr(shadowOffset)))
-	sliceHdr.Len = nDoubletBytes
-	sliceHdr.Cap = sliceHdr.Len
+	rec.Seq.Seq = unsafe.Slice((*sam.Doublet)(unsafe.SliceData(shadowBuf[shadowOffset:])), nDoubletBytes)
 	shadowOffset += nDoubletBytes
 
 	if omit >= AuxTags {
 		goto done
 	}
 
-	sliceHdr = (*reflect.SliceHeader)(unsafe.Pointer(&rec.Qual))
-	sliceHdr.Data = uintptr(unsafe.Pointer(bufHdr.Data + uintptr(shadowOffset)))
-	sliceHdr.Len = lSeq
-	sliceHdr.Cap = sliceHdr.Len
-
+	rec.Qual = shadowBuf[shadowOffset : shadowOffset+lSeq : shadowOffset+lSeq]
 	shadowOffset += lSeq
 
-	if nAuxFields > 0 {
+	if lazyAux {
+		if shadowOffset < blen {
+			rec.RawAux = shadowBuf[shadowOffset:blen:blen]
+		}
+	} else if nAuxFields > 0 {
 		// Clear the array before updating rec.AuxFields. GC will be
 		// confused otherwise.
 		for i := auxOffset; i < auxOffset+nAuxFields*sizeofSliceHeader; i++ {
 			shadowBuf[i] = 0
 		}
-		sliceHdr = (*reflect.SliceHeader)(unsafe.Pointer(&rec.AuxFields))
-		sliceHdr.Data = uintptr(unsafe.Pointer(bufHdr.Data + uintptr(auxOffset)))
-		sliceHdr.Len = nAuxFields
-		sliceHdr.Cap = sliceHdr.Len
-		parseAux(shadowBuf[shadowOffset:blen], rec.AuxFields)
+		rec.AuxFields = unsafe.Slice((*sam.Aux)(unsafe.Pointer(&shadowBuf[auxOffset])), nAuxFields)
+		err := parseAux(shadowBuf[shadowOffset:blen], rec.AuxFields)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 done:
@@ -288,6 +514,15 @@ func (br *Reader) SetChunk(c *bgzf.Chunk) error {
 	return nil
 }
 
+// LastBin returns the BAM index bin stored in the encoding of the
+// record returned by the last Read operation, which may differ from the
+// bin computed by the record's Bin method if the BAM data is invalid.
+// The value returned is only valid if the last Read operation returned
+// a nil error.
+func (br *Reader) LastBin() int {
+	return int(br.lastBin)
+}
+
 // LastChunk returns the bgzf.Chunk corresponding to the last Read operation.
 // The bgzf.Chunk returned is only valid if the last Read operation returned a
 // nil error.
@@ -297,7 +532,15 @@ func (br *Reader) LastChunk() bgzf.Chunk {
 
 // Close closes the Reader.
 func (br *Reader) Close() error {
-	return br.r.Close()
+	br.release()
+	err := br.r.Close()
+	if br.closer != nil {
+		cerr := br.closer.Close()
+		if err == nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
 // Iterator wraps a Reader to provide a convenient loop interface for reading BAM data.
@@ -308,6 +551,10 @@ type Iterator struct {
 
 	chunks []bgzf.Chunk
 
+	// filter, if not nil, specifies the
+	// records returned by the Iterator.
+	filter func(*sam.Record) bool
+
 	rec *sam.Record
 	err error
 }
@@ -345,16 +592,21 @@ func NewIterator(r *Reader, chunks []bgzf.Chunk) (*Iterator, error) {
 // input or an error. After Next returns false, the Error method will return any error that
 // occurred during iteration, except that if it was io.EOF, Error will return nil.
 func (i *Iterator) Next() bool {
-	if i.err != nil {
-		return false
-	}
-	i.rec, i.err = i.r.Read()
-	if len(i.chunks) != 0 && i.err == io.EOF {
-		i.err = i.r.SetChunk(&i.chunks[0])
-		i.chunks = i.chunks[1:]
-		return i.Next()
+	for {
+		if i.err != nil {
+			return false
+		}
+		i.rec, i.err = i.r.Read()
+		if len(i.chunks) != 0 && i.err == io.EOF {
+			i.err = i.r.SetChunk(&i.chunks[0])
+			i.chunks = i.chunks[1:]
+			continue
+		}
+		if i.err == nil && i.filter != nil && !i.filter(i.rec) {
+			continue
+		}
+		return i.err == nil
 	}
-	return i.err == nil
 }
 
 // Error returns the first non-EOF error that was encountered by the Iterator.
@@ -387,121 +639,167 @@ var jumps = [256]int{
 
 var errCorruptAuxField = errors.New("Corrupt aux field")
 
+// nextAuxField returns the length of the first aux field in aux,
+// excluding the terminal zero of Z and H fields, and the number of
+// bytes of aux it occupies.
+func nextAuxField(aux []byte) (length, size int, err error) {
+	if len(aux) < 4 {
+		return 0, 0, errCorruptAuxField
+	}
+	switch j := jumps[aux[2]]; {
+	case j > 0:
+		size = j + 3
+		length = size
+	case aux[2] == 'Z', aux[2] == 'H':
+		i := bytes.IndexByte(aux[3:], 0)
+		if i < 0 {
+			return 0, 0, errCorruptAuxField
+		}
+		length = 3 + i
+		size = length + 1
+	case aux[2] == 'B':
+		if len(aux) < 8 {
+			return 0, 0, errCorruptAuxField
+		}
+		switch aux[3] {
+		case 'c', 'C', 's', 'S', 'i', 'I', 'f':
+		default:
+			return 0, 0, errCorruptAuxField
+		}
+		n := int64(binary.LittleEndian.Uint32(aux[4:8]))*int64(jumps[aux[3]]) + 8
+		if n > int64(len(aux)) {
+			return 0, 0, errCorruptAuxField
+		}
+		size = int(n)
+		length = size
+	default:
+		return 0, 0, errCorruptAuxField
+	}
+	if size > len(aux) {
+		return 0, 0, errCorruptAuxField
+	}
+	return length, size, nil
+}
+
 // countAuxFields examines the data of a SAM record's OPT field to determine
 // the number of auxFields there are.
 func countAuxFields(aux []byte) (int, error) {
 	naux := 0
 	for i := 0; i+2 < len(aux); {
-		t := aux[i+2]
-		switch j := jumps[t]; {
-		case j > 0:
-			j += 3
-			i += j
-			naux++
-		case j < 0:
-			switch t {
-			case 'Z', 'H':
-				var (
-					j int
-					v byte
-				)
-				for j, v = range aux[i:] {
-					if v == 0 { // C string termination
-						break // Truncate terminal zero.
-					}
-				}
-				i += j + 1
-				naux++
-			case 'B':
-				if len(aux) < i+8 {
-					return -1, errCorruptAuxField
-				}
-				length := binary.LittleEndian.Uint32(aux[i+4 : i+8])
-				j = int(length)*jumps[aux[i+3]] + int(unsafe.Sizeof(length)) + 4
-				i += j
-				naux++
-			}
-		default:
-			return -1, errCorruptAuxField
+		_, n, err := nextAuxField(aux[i:])
+		if err != nil {
+			return -1, err
 		}
+		i += n
+		naux++
 	}
 	return naux, nil
 }
 
 // parseAux examines the data of a SAM record's OPT fields,
-// returning a slice of sam.Aux that are backed by the original data.
-func parseAux(aux []byte, aa []sam.Aux) {
+// filling aa with sam.Aux that are backed by the original data.
+// The length of aa must be the count returned by countAuxFields.
+func parseAux(aux []byte, aa []sam.Aux) error {
 	naa := 0
-	/*	var sliceHdr *reflect.SliceHeader
-		auxSlice := (*reflect.SliceHeader)(unsafe.Pointer(&aux))*/
 	for i := 0; i+2 < len(aux); {
-		t := aux[i+2]
-		switch j := jumps[t]; {
-		case j > 0:
-			j += 3
-			aa[naa] = sam.Aux(aux[i : i+j : i+j])
-			naa++
-			i += j
-		case j < 0:
-			switch t {
-			case 'Z', 'H':
-				var (
-					j int
-					v byte
-				)
-				for j, v = range aux[i:] {
-					if v == 0 { // C string termination
-						break // Truncate terminal zero.
-					}
-				}
-				aa[naa] = sam.Aux(aux[i : i+j : i+j])
-				naa++
-				i += j + 1
-			case 'B':
-				length := binary.LittleEndian.Uint32(aux[i+4 : i+8])
-				j = int(length)*jumps[aux[i+3]] + int(unsafe.Sizeof(length)) + 4
-				aa[naa] = sam.Aux(aux[i : i+j : i+j])
-				naa++
-				i += j
-			}
-		default:
-			panic(fmt.Sprintf("bam: unrecognised optional field type: %q", t))
+		l, n, err := nextAuxField(aux[i:])
+		if err != nil {
+			return err
+		}
+		if naa == len(aa) {
+			return errCorruptAuxField
 		}
+		aa[naa] = sam.Aux(aux[i : i+l : i+l])
+		naa++
+		i += n
 	}
+	return nil
 }
 
 // readAlignment reads the alignment record from the Reader's underlying
-// bgzf.Reader into the supplied bytes.Buffer and updates the Reader's lastChunk
-// field.
-func readAlignment(br *Reader, buf *[]byte) error {
+// bgzf.Reader into a pooled scratch buffer and updates the Reader's lastChunk
+// field. The buffer should be returned with putScratch after use.
+func readAlignment(br *Reader) (*[]byte, error) {
 	n, err := io.ReadFull(br.r, br.sizeBuf)
 	// br.r.Chunk() is only valid after the call the Read(), so this
 	// must come after the first read in the record.
+	off := br.r.LastChunk().Begin
 	tx := br.r.Begin()
 	defer func() {
 		br.lastChunk = tx.End()
 	}()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if n != 4 {
-		return errors.New("bam: invalid record: short block size")
+		return nil, errors.New("bam: invalid record: short block size")
 	}
 	size := int(binary.LittleEndian.Uint32(br.sizeBuf))
-	if size > maxBAMRecordSize {
-		return errors.New("bam: record too large")
+	if size > MaxRecordSize {
+		return nil, errors.New("bam: invalid record: block size out of range")
+	}
+	limit := br.maxRecordSize
+	if limit == 0 {
+		limit = DefaultMaxRecordSize
+	}
+	if size > limit {
+		return nil, br.oversize(size, limit, off)
 	}
-	resizeScratch(buf, size)
+	buf := getScratch(size)
+	*buf = (*buf)[:size]
 	nn, err := io.ReadFull(br.r, *buf)
 	if err != nil {
-		return err
+		putScratch(buf)
+		return nil, err
 	}
 	if nn != size {
+		putScratch(buf)
+		return nil, errors.New("bam: truncated record")
+	}
+	return buf, nil
+}
+
+// oversize handles a record of the given size that is larger than
+// limit, whose block size field has been read from off. The name of
+// the read is read from the record and a *RecordSizeError is returned,
+// unless the Reader skips oversize records, in which case the remainder
+// of the record is discarded, the error is recorded as a warning and
+// errSkipped is returned.
+func (br *Reader) oversize(size, limit int, off bgzf.Offset) error {
+	var fixed [32]byte
+	if size < len(fixed) {
+		return errors.New("bam: record too short")
+	}
+	_, err := io.ReadFull(br.r, fixed[:])
+	if err != nil {
 		return errors.New("bam: truncated record")
 	}
-	return nil
+	if size < len(fixed)+int(fixed[8]) {
+		return errors.New("bam: record too short")
+	}
+	name := make([]byte, fixed[8])
+	_, err = io.ReadFull(br.r, name)
+	if err != nil {
+		return errors.New("bam: truncated record")
+	}
+	if len(name) != 0 {
+		name = name[:len(name)-1] // drop trailing '\0'
+	}
+	e := &RecordSizeError{Name: string(name), Size: size, Limit: limit, Offset: off}
+	if !br.skipOversize {
+		return e
+	}
+	_, err = io.CopyN(io.Discard, br.r, int64(size-len(fixed)-int(fixed[8])))
+	if err != nil {
+		return errors.New("bam: truncated record")
+	}
+	br.warnings = append(br.warnings, e)
+	return errSkipped
 }
 
+// errSkipped is returned by readAlignment when a record is skipped.
+var errSkipped = errors.New("bam: record skipped")
+
 // buildAux constructs a single byte slice that represents a slice of sam.Aux.
 // *buf should be an empty slice on call, and it is filled with the result on
 // return.
@@ -0,0 +1,105 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/index"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Region is a genomic interval on a reference sequence. Start and End
+// are zero-based half-open coordinates.
+type Region struct {
+	Ref        *sam.Reference
+	Start, End int
+}
+
+// RegionChunks returns a []bgzf.Chunk that covers all the given
+// genomic regions. The returned chunks are sorted and do not overlap,
+// so each record in the file is read at most once when the chunks are
+// iterated over. Regions on references without indexed data are
+// ignored. As with Chunks, records that do not overlap any region may
+// be included in the returned chunks.
+func (i *Index) RegionChunks(regions []Region) ([]bgzf.Chunk, error) {
+	var chunks []bgzf.Chunk
+	for _, r := range regions {
+		if r.Ref == nil {
+			return nil, errors.New("bam: region with nil reference")
+		}
+		c, err := i.idx.Chunks(r.Ref.ID(), r.Start, r.End)
+		switch err {
+		case nil:
+		case index.ErrNoReference, index.ErrInvalid:
+			continue
+		default:
+			return nil, err
+		}
+		chunks = append(chunks, c...)
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Begin.Less(chunks[j].Begin)
+	})
+	chunks = index.Adjacent(chunks)
+	if i.MergeStrategy != nil {
+		chunks = i.MergeStrategy(chunks)
+	}
+	return chunks, nil
+}
+
+// NewRegionIterator returns an Iterator to read from r the records that
+// overlap at least one of the given genomic regions, using idx to
+// find the data to read. Records are returned in file order and each
+// record is returned at most once, even when it overlaps more than one
+// region.
+func NewRegionIterator(r *Reader, idx *Index, regions []Region) (*Iterator, error) {
+	chunks, err := idx.RegionChunks(regions)
+	if err != nil {
+		return nil, err
+	}
+	it, err := NewIterator(r, chunks)
+	if err != nil {
+		return nil, err
+	}
+	it.filter = newRegionSet(regions).overlaps
+	return it, nil
+}
+
+// regionSet is a set of disjoint sorted intervals for each reference.
+type regionSet map[int][]Region
+
+func newRegionSet(regions []Region) regionSet {
+	s := make(regionSet)
+	for _, r := range regions {
+		id := r.Ref.ID()
+		s[id] = append(s[id], r)
+	}
+	for id, rs := range s {
+		sort.Slice(rs, func(i, j int) bool { return rs[i].Start < rs[j].Start })
+		merged := rs[:1]
+		for _, r := range rs[1:] {
+			last := &merged[len(merged)-1]
+			if r.Start <= last.End {
+				if r.End > last.End {
+					last.End = r.End
+				}
+				continue
+			}
+			merged = append(merged, r)
+		}
+		s[id] = merged
+	}
+	return s
+}
+
+// overlaps returns whether rec overlaps any interval in the set.
+func (s regionSet) overlaps(rec *sam.Record) bool {
+	rs := s[rec.Ref.ID()]
+	i := sort.Search(len(rs), func(i int) bool { return rs[i].End > rec.Pos })
+	return i < len(rs) && rs[i].Start < rec.End()
+}
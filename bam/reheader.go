@@ -0,0 +1,164 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Reheader writes the BAM stream read from src to dst with its header
+// replaced by h. Only the BGZF blocks holding the original header are
+// decompressed; record data in the last of these blocks is recompressed
+// and all following blocks are copied without recompression.
+//
+// The references of h must have the same number and lengths as those of
+// the original header since records refer to references by ID, though
+// they may be renamed.
+func Reheader(dst io.Writer, src io.Reader, h *sam.Header) error {
+	orig, tail, _, err := readHeaderBlocks(src)
+	if err != nil {
+		return err
+	}
+	oldRefs := orig.Refs()
+	newRefs := h.Refs()
+	if len(oldRefs) != len(newRefs) {
+		return errors.New("bam: reference count mismatch")
+	}
+	for i, ref := range oldRefs {
+		if ref.Len() != newRefs[i].Len() {
+			return errors.New("bam: reference length mismatch")
+		}
+	}
+	head, err := compressHead(h, tail)
+	if err != nil {
+		return err
+	}
+	_, err = bgzf.Cat(dst, bytes.NewReader(head), src)
+	return err
+}
+
+// readHeaderBlocks reads the BGZF blocks of src holding the BAM header,
+// and returns the header, the record data following the header in the
+// last of the blocks and the compressed length of the blocks. src is
+// left at the start of the following block.
+func readHeaderBlocks(src io.Reader) (h *sam.Header, tail []byte, size int64, err error) {
+	var (
+		buf bytes.Buffer
+		n   int
+	)
+	it := bgzf.NewBlockIterator(src)
+	for {
+		if !it.Next() {
+			if err := it.Error(); err != nil {
+				return nil, nil, 0, err
+			}
+			return nil, nil, 0, errors.New("bam: truncated header")
+		}
+		data, err := it.Data()
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		buf.Write(data)
+		var ok bool
+		n, ok, err = headerLen(buf.Bytes())
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if ok {
+			break
+		}
+	}
+	h, err = sam.NewHeader(nil, nil)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	err = h.DecodeBinary(bytes.NewReader(buf.Bytes()[:n]))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	b := it.Block()
+	return h, buf.Bytes()[n:], b.Offset + int64(b.Size), nil
+}
+
+// compressHead returns the BGZF compressed binary encoding of h, if h
+// is not nil, followed by the record data in tail. The header is written
+// to blocks of its own so that subsequent reheading of the output only
+// needs to decompress the header.
+func compressHead(h *sam.Header, tail []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	bg := bgzf.NewWriter(&buf, 1)
+	if h != nil {
+		err := h.EncodeBinary(bg)
+		if err != nil {
+			bg.Close()
+			return nil, err
+		}
+		err = bg.Flush()
+		if err != nil {
+			bg.Close()
+			return nil, err
+		}
+	}
+	_, err := bg.Write(tail)
+	if err != nil {
+		bg.Close()
+		return nil, err
+	}
+	err = bg.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// headerLen returns the length of the binary BAM header at the start of
+// b and whether b holds the complete header.
+func headerLen(b []byte) (n int, ok bool, err error) {
+	next := func(size int) bool {
+		if len(b) < n+size {
+			return false
+		}
+		n += size
+		return true
+	}
+	length := func() (int, bool, error) {
+		if !next(4) {
+			return 0, false, nil
+		}
+		l := int32(binary.LittleEndian.Uint32(b[n-4:]))
+		if l < 0 {
+			return 0, false, errors.New("bam: invalid header length")
+		}
+		return int(l), true, nil
+	}
+
+	if !next(4) {
+		return 0, false, nil
+	}
+	if string(b[:4]) != "BAM\x01" {
+		return 0, false, errors.New("bam: magic number mismatch")
+	}
+	lText, ok, err := length()
+	if !ok || !next(lText) {
+		return 0, false, err
+	}
+	nRef, ok, err := length()
+	if !ok {
+		return 0, false, err
+	}
+	for i := 0; i < nRef; i++ {
+		lName, ok, err := length()
+		if !ok || !next(lName+4) {
+			return 0, false, err
+		}
+	}
+	return n, true, nil
+}
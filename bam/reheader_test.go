@@ -0,0 +1,99 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestReheader(t *testing.T) {
+	data := matePairs(t, 500)
+	records := func(data []byte) (*sam.Header, []string) {
+		r, err := NewReader(bytes.NewReader(data), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		var recs []string
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error reading record: %v", err)
+			}
+			recs = append(recs, rec.String())
+		}
+		return r.Header(), recs
+	}
+	h, want := records(data)
+
+	it := bgzf.NewBlockIterator(bytes.NewReader(data))
+	if !it.Next() {
+		t.Fatalf("unexpected error reading first block: %v", it.Error())
+	}
+	first := it.Block().Size
+
+	// A long comment forces the new header to
+	// span several blocks.
+	long := strings.Repeat("x", 3*bgzf.BlockSize)
+	for _, comments := range [][]string{{"reheadered"}, {long}} {
+		nh := h.Clone()
+		nh.Comments = comments
+		var buf bytes.Buffer
+		err := Reheader(&buf, bytes.NewReader(data), nh)
+		if err != nil {
+			t.Fatalf("unexpected error reheading: %v", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), data[first:]) {
+			t.Error("record blocks after the header block not copied verbatim")
+		}
+		gh, got := records(buf.Bytes())
+		if len(gh.Comments) != 1 || gh.Comments[0] != comments[0] {
+			t.Errorf("unexpected comments: got:%d comments", len(gh.Comments))
+		}
+		if !equalStrings(got, want) {
+			t.Errorf("unexpected records after reheading: got:%d want:%d", len(got), len(want))
+		}
+
+		// Reheading a header spanning several
+		// blocks must restore the records.
+		var back bytes.Buffer
+		err = Reheader(&back, bytes.NewReader(buf.Bytes()), h)
+		if err != nil {
+			t.Fatalf("unexpected error reheading: %v", err)
+		}
+		gh, got = records(back.Bytes())
+		if len(gh.Comments) != 0 {
+			t.Errorf("unexpected comments: got:%d comments", len(gh.Comments))
+		}
+		if !equalStrings(got, want) {
+			t.Errorf("unexpected records after restoring header: got:%d want:%d", len(got), len(want))
+		}
+	}
+
+	ref, err := sam.NewReference("chr1", "", "", 10, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	bad, err := sam.NewHeader(nil, []*sam.Reference{ref})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	err = Reheader(io.Discard, bytes.NewReader(data), bad)
+	if err == nil {
+		t.Error("expected error for reference mismatch")
+	}
+	err = Reheader(io.Discard, bytes.NewReader(data[:first/2]), h)
+	if err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/Schaudge/hts/bgzf/index"
+	"github.com/Schaudge/hts/internal"
+	"github.com/Schaudge/hts/sam"
+)
+
+// WindowShards returns regions of width bases tiling each of the given
+// references. The last region of each reference may be shorter.
+func WindowShards(refs []*sam.Reference, width int) []Region {
+	if width < 1 {
+		panic("bam: invalid shard width")
+	}
+	var shards []Region
+	for _, ref := range refs {
+		for start := 0; start < ref.Len(); start += width {
+			end := start + width
+			if end > ref.Len() {
+				end = ref.Len()
+			}
+			shards = append(shards, Region{Ref: ref, Start: start, End: end})
+		}
+	}
+	return shards
+}
+
+// IndexShards returns regions tiling each of the given references that
+// have indexed data, with each region holding approximately size bytes
+// of compressed data as estimated from the linear index of idx. Region
+// boundaries fall on the 16kb tiles of the linear index. References
+// without indexed data are omitted.
+func IndexShards(idx *Index, refs []*sam.Reference, size int64) []Region {
+	if size < 1 {
+		panic("bam: invalid shard size")
+	}
+	var shards []Region
+	for _, ref := range refs {
+		id := ref.ID()
+		if id < 0 || id >= len(idx.idx.Refs) {
+			continue
+		}
+		intervals := idx.idx.Refs[id].Intervals
+		if len(intervals) == 0 {
+			continue
+		}
+		start := 0
+		base := intervals[0].File
+		for t, off := range intervals[1:] {
+			pos := (t + 1) * internal.TileWidth
+			if pos >= ref.Len() {
+				break
+			}
+			// Empty tiles may hold zero offsets,
+			// which never start a shard.
+			if off.File-base < size {
+				continue
+			}
+			shards = append(shards, Region{Ref: ref, Start: start, End: pos})
+			start, base = pos, off.File
+		}
+		shards = append(shards, Region{Ref: ref, Start: start, End: ref.Len()})
+	}
+	return shards
+}
+
+// ShardOptions specifies how shards are processed by MapShards and
+// MapReduce.
+type ShardOptions struct {
+	// Open returns a new Reader for the
+	// indexed BAM data. Each worker opens
+	// one Reader, which is closed when the
+	// worker finishes. Open must not be nil.
+	Open func() (*Reader, error)
+
+	// Index is the index of the BAM data.
+	// Index must not be nil.
+	Index *Index
+
+	// Workers is the number of shards
+	// processed concurrently. If Workers is
+	// less than one, runtime.GOMAXPROCS(0)
+	// is used.
+	Workers int
+}
+
+// MapShards calls fn for each of the given shards with an Iterator over
+// the records starting within the shard, so that when the shards tile
+// the genome each placed record is seen by exactly one call. Unplaced
+// records are not read. Up to opts.Workers calls are made concurrently,
+// and the results are returned in shard order.
+//
+// The context passed to fn is cancelled when any call returns an error
+// or ctx is done, and the first error is returned. The Iterator is
+// closed when fn returns.
+func MapShards[T any](ctx context.Context, shards []Region, opts ShardOptions, fn func(context.Context, *Iterator) (T, error)) ([]T, error) {
+	if opts.Open == nil || opts.Index == nil {
+		return nil, errors.New("bam: missing shard reader or index")
+	}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(shards) {
+		workers = len(shards)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		results = make([]T, len(shards))
+		next    = make(chan int)
+
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := opts.Open()
+			if err != nil {
+				fail(err)
+				for range next {
+				}
+				return
+			}
+			defer r.Close()
+			for i := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				results[i], err = mapShard(ctx, r, opts.Index, shards[i], fn)
+				if err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+loop:
+	for i := range shards {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// mapShard calls fn with an Iterator over the records of r starting
+// within shard.
+func mapShard[T any](ctx context.Context, r *Reader, idx *Index, shard Region, fn func(context.Context, *Iterator) (T, error)) (T, error) {
+	var zero T
+	if shard.Ref == nil {
+		return zero, errors.New("bam: region with nil reference")
+	}
+	chunks, err := idx.Chunks(shard.Ref, shard.Start, shard.End)
+	switch err {
+	case nil:
+	case index.ErrNoReference, index.ErrInvalid:
+		chunks = nil
+	default:
+		return zero, err
+	}
+	it, err := NewIterator(r, chunks)
+	if err != nil {
+		return zero, err
+	}
+	// Each worker's Reader has its own header,
+	// so references are compared by ID.
+	id := shard.Ref.ID()
+	it.filter = func(rec *sam.Record) bool {
+		return rec.Ref.ID() == id && shard.Start <= rec.Pos && rec.Pos < shard.End
+	}
+	v, err := fn(ctx, it)
+	cerr := it.Close()
+	if err == nil {
+		err = cerr
+	}
+	return v, err
+}
+
+// MapReduce calls fn for each of the given shards as described for
+// MapShards, and returns the result of combining the shard results in
+// shard order with merge, starting from the zero value of T.
+func MapReduce[T any](ctx context.Context, shards []Region, opts ShardOptions, fn func(context.Context, *Iterator) (T, error), merge func(a, b T) T) (T, error) {
+	var acc T
+	results, err := MapShards(ctx, shards, opts, fn)
+	if err != nil {
+		return acc, err
+	}
+	for _, v := range results {
+		acc = merge(acc, v)
+	}
+	return acc, nil
+}
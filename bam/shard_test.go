@@ -0,0 +1,110 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestMapShards(t *testing.T) {
+	data := matePairs(t, 50)
+	open := func() (*Reader, error) { return NewReader(bytes.NewReader(data), 1) }
+
+	br, err := open()
+	if err != nil {
+		t.Fatalf("failed to open BAM: %v", err)
+	}
+	var (
+		idx  Index
+		want = make(map[string]int)
+	)
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read BAM record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("failed to add record to index: %v", err)
+		}
+		want[r.String()]++
+	}
+	refs := br.Header().Refs()
+	br.Close()
+
+	for _, test := range []struct {
+		name   string
+		shards []Region
+	}{
+		{name: "windows", shards: WindowShards(refs, 150)},
+		{name: "index", shards: IndexShards(&idx, refs, 1<<20)},
+	} {
+		for _, workers := range []int{0, 1, 3} {
+			opts := ShardOptions{Open: open, Index: &idx, Workers: workers}
+			counts, err := MapShards(context.Background(), test.shards, opts, func(_ context.Context, it *Iterator) (map[string]int, error) {
+				m := make(map[string]int)
+				for it.Next() {
+					m[it.Record().String()]++
+				}
+				return m, it.Error()
+			})
+			if err != nil {
+				t.Fatalf("%s %d: unexpected error: %v", test.name, workers, err)
+			}
+			if len(counts) != len(test.shards) {
+				t.Fatalf("%s %d: unexpected number of results: got:%d want:%d", test.name, workers, len(counts), len(test.shards))
+			}
+			got := make(map[string]int)
+			for _, m := range counts {
+				for k, n := range m {
+					got[k] += n
+				}
+			}
+			if len(got) != len(want) {
+				t.Errorf("%s %d: unexpected number of records: got:%d want:%d", test.name, workers, len(got), len(want))
+			}
+			for k, n := range want {
+				if got[k] != n {
+					t.Errorf("%s %d: unexpected count for %s: got:%d want:%d", test.name, workers, k, got[k], n)
+				}
+			}
+
+			n, err := MapReduce(context.Background(), test.shards, opts, func(_ context.Context, it *Iterator) (int, error) {
+				var n int
+				for it.Next() {
+					n++
+				}
+				return n, it.Error()
+			}, func(a, b int) int { return a + b })
+			if err != nil {
+				t.Fatalf("%s %d: unexpected error: %v", test.name, workers, err)
+			}
+			if n != 150 {
+				t.Errorf("%s %d: unexpected total: got:%d want:150", test.name, workers, n)
+			}
+		}
+	}
+	if got := len(IndexShards(&idx, refs, 1<<20)); got != len(refs) {
+		t.Errorf("unexpected number of index shards: got:%d want:%d", got, len(refs))
+	}
+
+	errShard := errors.New("shard failed")
+	_, err = MapShards(context.Background(), WindowShards(refs, 150), ShardOptions{Open: open, Index: &idx, Workers: 2}, func(ctx context.Context, it *Iterator) (int, error) {
+		if it.Next() && it.Record().Pos >= 900 {
+			return 0, errShard
+		}
+		return 0, ctx.Err()
+	})
+	if err != errShard {
+		t.Errorf("unexpected error: got:%v want:%v", err, errShard)
+	}
+}
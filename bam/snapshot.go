@@ -0,0 +1,162 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/Schaudge/hts/bgzf"
+)
+
+// snapshotVersion is the version of the binary encoding of a Snapshot.
+const snapshotVersion = 1
+
+// Snapshot is the position of a Reader or Iterator in a BAM stream. A
+// Snapshot may be taken between reads and used to resume reading from
+// the same position with a new Reader on the same data, allowing long
+// scans to be checkpointed and their remaining work to be handed to
+// other processes.
+type Snapshot struct {
+	// Offset is the virtual offset of
+	// the next record to be read.
+	Offset bgzf.Offset
+
+	// Bounded specifies that reading is
+	// limited to Chunks. If Bounded is
+	// false, reading continues from Offset
+	// to the end of the stream.
+	Bounded bool
+
+	// Chunks holds the remaining chunks
+	// to be read if Bounded is true. The
+	// first chunk begins at Offset.
+	Chunks []bgzf.Chunk
+}
+
+// Snapshot returns the position of the Reader. The Snapshot is only
+// valid if the last Read operation returned a nil error.
+func (br *Reader) Snapshot() Snapshot {
+	s := Snapshot{Offset: br.lastChunk.End}
+	if br.c != nil {
+		s.Bounded = true
+		if s.Offset.Less(br.c.End) {
+			s.Chunks = []bgzf.Chunk{{Begin: s.Offset, End: br.c.End}}
+		}
+	}
+	return s
+}
+
+// Resume positions the Reader at the Snapshot s, taken from a Reader
+// on the same BAM data. Resume returns an error if s holds more than
+// one chunk; such a Snapshot is resumed with ResumeIterator.
+func (br *Reader) Resume(s Snapshot) error {
+	if !s.Bounded {
+		err := br.Seek(s.Offset)
+		if err != nil {
+			return err
+		}
+		return br.SetChunk(nil)
+	}
+	switch len(s.Chunks) {
+	case 0:
+		// Leave the Reader at the end of
+		// an empty chunk.
+		return br.SetChunk(&bgzf.Chunk{Begin: s.Offset, End: s.Offset})
+	case 1:
+		return br.SetChunk(&s.Chunks[0])
+	}
+	return errors.New("bam: cannot resume reader from snapshot with multiple chunks")
+}
+
+// Snapshot returns the position of the Iterator, including the chunks
+// it has not yet read. The record filter of the Iterator is not held
+// by the Snapshot.
+func (i *Iterator) Snapshot() Snapshot {
+	if i.err == io.EOF {
+		return Snapshot{Offset: i.r.lastChunk.End, Bounded: true}
+	}
+	s := i.r.Snapshot()
+	s.Chunks = append(s.Chunks, i.chunks...)
+	if len(s.Chunks) != 0 {
+		s.Offset = s.Chunks[0].Begin
+	}
+	return s
+}
+
+// ResumeIterator returns an Iterator reading from r at the Snapshot s,
+// taken from a Reader or Iterator on the same BAM data.
+func ResumeIterator(r *Reader, s Snapshot) (*Iterator, error) {
+	if s.Bounded {
+		return NewIterator(r, s.Chunks)
+	}
+	err := r.Resume(s)
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator{r: r}, nil
+}
+
+// MarshalBinary returns a binary encoding of s.
+func (s Snapshot) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 2+binary.MaxVarintLen64*(2+2*len(s.Chunks)))
+	var bounded byte
+	if s.Bounded {
+		bounded = 1
+	}
+	b = append(b, snapshotVersion, bounded)
+	b = binary.AppendUvarint(b, s.Offset.Virtual())
+	b = binary.AppendUvarint(b, uint64(len(s.Chunks)))
+	for _, c := range s.Chunks {
+		b = binary.AppendUvarint(b, c.Begin.Virtual())
+		b = binary.AppendUvarint(b, c.End.Virtual())
+	}
+	return b, nil
+}
+
+// UnmarshalBinary sets s to the Snapshot encoded in b by MarshalBinary.
+func (s *Snapshot) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 || b[0] != snapshotVersion || b[1] > 1 {
+		return errors.New("bam: invalid snapshot encoding")
+	}
+	d := uvarintDecoder{b: b[2:]}
+	var t Snapshot
+	t.Bounded = b[1] == 1
+	t.Offset = makeOffset(d.next())
+	n := d.next()
+	if n > uint64(len(d.b)/2) {
+		return errors.New("bam: invalid snapshot encoding")
+	}
+	if n != 0 {
+		t.Chunks = make([]bgzf.Chunk, n)
+	}
+	for i := range t.Chunks {
+		t.Chunks[i] = bgzf.Chunk{Begin: makeOffset(d.next()), End: makeOffset(d.next())}
+	}
+	if d.err || len(d.b) != 0 {
+		return errors.New("bam: invalid snapshot encoding")
+	}
+	*s = t
+	return nil
+}
+
+// uvarintDecoder decodes a sequence of uvarints, recording whether
+// any was invalid.
+type uvarintDecoder struct {
+	b   []byte
+	err bool
+}
+
+func (d *uvarintDecoder) next() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = true
+		d.b = nil
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
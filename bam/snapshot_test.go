@@ -0,0 +1,167 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/bgzf"
+)
+
+func TestReaderSnapshot(t *testing.T) {
+	data := matePairs(t, 500)
+	want := readStrings(t, data)
+
+	for _, k := range []int{0, 1, len(want) / 2, len(want)} {
+		br, err := NewReader(bytes.NewReader(data), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		for i := 0; i < k; i++ {
+			_, err = br.Read()
+			if err != nil {
+				t.Fatalf("unexpected error reading record: %v", err)
+			}
+		}
+		s := roundTripSnapshot(t, br.Snapshot())
+		if s.Bounded {
+			t.Errorf("unexpected bounded snapshot of unbounded reader")
+		}
+
+		rr, err := NewReader(bytes.NewReader(data), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		err = rr.Resume(s)
+		if err != nil {
+			t.Fatalf("unexpected error resuming reader: %v", err)
+		}
+		var got []string
+		for {
+			r, err := rr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error reading resumed record: %v", err)
+			}
+			got = append(got, r.String())
+		}
+		if !equalStrings(got, want[k:]) {
+			t.Errorf("unexpected records after resuming at %d: got:%d want:%d", k, len(got), len(want)-k)
+		}
+	}
+}
+
+func TestIteratorSnapshot(t *testing.T) {
+	data := matePairs(t, 500)
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var idx Index
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("unexpected error adding record to index: %v", err)
+		}
+	}
+	refs := br.Header().Refs()
+	var chunks []bgzf.Chunk
+	for _, ref := range refs {
+		c, err := idx.Chunks(ref, 0, ref.Len())
+		if err != nil {
+			t.Fatalf("unexpected error getting chunks for %s: %v", ref.Name(), err)
+		}
+		chunks = append(chunks, c...)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("unexpected number of chunks: %d", len(chunks))
+	}
+
+	var want []string
+	it, err := NewIterator(br, chunks)
+	if err != nil {
+		t.Fatalf("unexpected error creating iterator: %v", err)
+	}
+	for it.Next() {
+		want = append(want, it.Record().String())
+	}
+	err = it.Close()
+	if err != nil {
+		t.Fatalf("unexpected error iterating: %v", err)
+	}
+
+	for _, k := range []int{0, 1, len(want) / 2, len(want)} {
+		br, err := NewReader(bytes.NewReader(data), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		it, err := NewIterator(br, chunks)
+		if err != nil {
+			t.Fatalf("unexpected error creating iterator: %v", err)
+		}
+		for i := 0; i < k; i++ {
+			if !it.Next() {
+				t.Fatalf("unexpected end of iteration: %v", it.Error())
+			}
+		}
+		s := roundTripSnapshot(t, it.Snapshot())
+		if !s.Bounded {
+			t.Errorf("unexpected unbounded snapshot of iterator")
+		}
+
+		rr, err := NewReader(bytes.NewReader(data), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		ri, err := ResumeIterator(rr, s)
+		if err != nil {
+			t.Fatalf("unexpected error resuming iterator: %v", err)
+		}
+		var got []string
+		for ri.Next() {
+			got = append(got, ri.Record().String())
+		}
+		err = ri.Close()
+		if err != nil {
+			t.Fatalf("unexpected error iterating: %v", err)
+		}
+		if !equalStrings(got, want[k:]) {
+			t.Errorf("unexpected records after resuming at %d: got:%d want:%d", k, len(got), len(want)-k)
+		}
+	}
+}
+
+func roundTripSnapshot(t *testing.T, s Snapshot) Snapshot {
+	t.Helper()
+	b, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error marshaling snapshot: %v", err)
+	}
+	var got Snapshot
+	err = got.UnmarshalBinary(b)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshaling snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Fatalf("unexpected snapshot after round trip: got:%+v want:%+v", got, s)
+	}
+	err = got.UnmarshalBinary(b[:len(b)-1])
+	if err == nil {
+		t.Error("expected error unmarshaling truncated snapshot")
+	}
+	return got
+}
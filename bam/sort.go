@@ -0,0 +1,521 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// DefaultSortMemory is the default approximate memory budget of a
+// Sorter in bytes.
+const DefaultSortMemory = 768 << 20
+
+// spillLevel is the compression level of temporary files.
+const spillLevel = 1
+
+// sortVersion is the SAM version given to sorted headers without a
+// version.
+const sortVersion = "1.6"
+
+// SortOptions specifies the behaviour of a Sorter.
+type SortOptions struct {
+	// Order is the sort order, either
+	// sam.Coordinate or sam.QueryName.
+	Order sam.SortOrder
+
+	// NameOrder is the order of names when
+	// sorting by queryname.
+	NameOrder NameOrder
+
+	// Memory is the approximate number of
+	// bytes of records held in memory before
+	// they are sorted and written to a
+	// temporary file. If Memory is zero,
+	// DefaultSortMemory is used.
+	Memory int64
+
+	// TempDir is the directory temporary
+	// files are created in. If TempDir is
+	// empty, os.TempDir is used.
+	TempDir string
+
+	// Workers is the number of goroutines
+	// used to sort records in memory and to
+	// compress temporary files. If Workers
+	// is less than one, runtime.GOMAXPROCS(0)
+	// is used.
+	Workers int
+
+	// CompactNames specifies that names of
+	// records held in memory when sorting by
+	// queryname are held compactly, with the
+	// instrument, run and flowcell fields
+	// shared between records. CompactNames
+	// is ignored for coordinate order.
+	CompactNames bool
+}
+
+// Sorter sorts records in coordinate or queryname order using bounded
+// memory, in the manner of samtools sort.
+//
+// Records are added with Add. When the buffered records exceed the
+// memory budget, they are sorted and written to a temporary BAM file
+// with fast compression. The first call to Read ends the addition of
+// records and begins a merge of the buffered records and temporary
+// files, returning records in sorted order. Records that compare equal
+// are returned in the order they were added.
+//
+// Coordinate order sorts records by reference ID, with unplaced records
+// last, then position and then strand. Queryname order sorts records by
+// name in the requested NameOrder, then first before last fragment.
+type Sorter struct {
+	h    *sam.Header
+	opts SortOptions
+	less func(a, b *sam.Record) bool
+
+	// names holds the name prefixes of
+	// buffered records if their names are
+	// compact, and bufLess orders buffered
+	// records.
+	names   *nameDict
+	bufLess func(a, b *sam.Record) bool
+
+	buf   []*sam.Record
+	size  int64
+	files []string
+
+	merging bool
+	runs    sortRuns
+	readers []*Reader
+	open    []*os.File
+	err     error
+}
+
+// NewSorter returns a Sorter for records with the header h.
+func NewSorter(h *sam.Header, opts SortOptions) (*Sorter, error) {
+	var less func(a, b *sam.Record) bool
+	switch opts.Order {
+	case sam.Coordinate:
+		less = lessByCoordinate
+	case sam.QueryName:
+		if opts.NameOrder != Lexicographic && opts.NameOrder != Natural {
+			return nil, errors.New("bam: invalid name order")
+		}
+		less = opts.NameOrder.lessByNameAndRead
+	default:
+		return nil, errors.New("bam: invalid sort order")
+	}
+	if opts.Memory == 0 {
+		opts.Memory = DefaultSortMemory
+	}
+	if opts.TempDir == "" {
+		opts.TempDir = os.TempDir()
+	}
+	if opts.Workers < 1 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	h = h.Clone()
+	h.SortOrder = opts.Order
+	if h.Version == "" {
+		// The sort order is only written
+		// in a header with a version.
+		h.Version = sortVersion
+	}
+	err := setSubSort(h, opts.NameOrder)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sorter{h: h, opts: opts, less: less, bufLess: less}
+	if opts.Order == sam.QueryName && opts.CompactNames {
+		s.names = newNameDict()
+		s.bufLess = s.names.lessByNameAndRead(opts.NameOrder)
+	}
+	return s, nil
+}
+
+// Header returns the header of the sorted records, with the sort order
+// and, for queryname order, the SS sub-sort field set.
+func (s *Sorter) Header() *sam.Header { return s.h }
+
+// Add adds r to the records to be sorted. The record must use
+// references of the header provided to NewSorter and must not be
+// modified after it is added. If the names of records are held
+// compactly, the name of r is altered until r is returned by Read or
+// written to a temporary file.
+func (s *Sorter) Add(r *sam.Record) error {
+	if s.merging {
+		return errors.New("bam: add to merging sorter")
+	}
+	if s.err != nil {
+		return s.err
+	}
+	if s.names != nil {
+		s.names.packRecord(r)
+	}
+	s.buf = append(s.buf, r)
+	s.size += recordSize(r)
+	if s.size >= s.opts.Memory {
+		s.err = s.spill()
+	}
+	return s.err
+}
+
+// recordSize returns the approximate memory used by r.
+func recordSize(r *sam.Record) int64 {
+	const overhead = 200
+	if r.Scratch != nil {
+		// Records read by a Reader hold their
+		// variable length data in Scratch.
+		n := overhead + cap(r.Scratch)
+		if !nameInScratch(r) {
+			n += len(r.Name)
+		}
+		return int64(n)
+	}
+	n := overhead + len(r.Name) + len(r.Cigar)<<2 + len(r.Seq.Seq) + len(r.Qual) + len(r.RawAux)
+	for _, a := range r.AuxFields {
+		n += len(a) + 24
+	}
+	return int64(n)
+}
+
+// spill sorts the buffered records and writes them to a temporary
+// file.
+func (s *Sorter) spill() error {
+	s.sortBuffer()
+	f, err := os.CreateTemp(s.opts.TempDir, "hts-sort-*.bam")
+	if err != nil {
+		return err
+	}
+	s.files = append(s.files, f.Name())
+	w, err := NewWriterLevel(f, s.h, spillLevel, s.opts.Workers)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	for _, r := range s.buf {
+		s.unpackName(r)
+		err = w.Write(r)
+		if err != nil {
+			w.Close()
+			f.Close()
+			return err
+		}
+	}
+	err = w.Close()
+	cerr := f.Close()
+	if err == nil {
+		err = cerr
+	}
+	for i := range s.buf {
+		s.buf[i] = nil
+	}
+	s.buf = s.buf[:0]
+	s.size = 0
+	return err
+}
+
+// unpackName restores the full name of the buffered record r if the
+// names of records are held compactly.
+func (s *Sorter) unpackName(r *sam.Record) {
+	if s.names != nil {
+		r.Name = s.names.unpack(r.Name)
+	}
+}
+
+// sortBuffer sorts the buffered records, sorting parts of the buffer
+// concurrently and merging the sorted parts.
+func (s *Sorter) sortBuffer() {
+	if len(s.buf) == 0 {
+		return
+	}
+	n := s.opts.Workers
+	if n > len(s.buf)/1024 {
+		n = len(s.buf)/1024 + 1
+	}
+	parts := make([][]*sam.Record, n)
+	var wg sync.WaitGroup
+	for i := range parts {
+		parts[i] = s.buf[i*len(s.buf)/n : (i+1)*len(s.buf)/n]
+		wg.Add(1)
+		go func(p []*sam.Record) {
+			defer wg.Done()
+			sort.SliceStable(p, func(i, j int) bool { return s.bufLess(p[i], p[j]) })
+		}(parts[i])
+	}
+	wg.Wait()
+
+	// Merge adjacent pairs of parts until one
+	// remains, alternating between the buffer
+	// and scratch space.
+	src, dst := s.buf, make([]*sam.Record, len(s.buf))
+	for len(parts) > 1 {
+		var (
+			merged [][]*sam.Record
+			off    int
+		)
+		for i := 0; i < len(parts); i += 2 {
+			if i+1 == len(parts) {
+				copy(dst[off:], parts[i])
+				merged = append(merged, dst[off:off+len(parts[i])])
+				break
+			}
+			a, b := parts[i], parts[i+1]
+			out := dst[off : off+len(a)+len(b)]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mergeRecords(out, a, b, s.bufLess)
+			}()
+			merged = append(merged, out)
+			off += len(out)
+		}
+		wg.Wait()
+		parts = merged
+		src, dst = dst, src
+	}
+	if &parts[0][0] != &s.buf[0] {
+		copy(s.buf, parts[0])
+	}
+}
+
+// mergeRecords merges the sorted records of a and b into dst, taking
+// records from a first when they compare equal.
+func mergeRecords(dst, a, b []*sam.Record, less func(a, b *sam.Record) bool) {
+	i, j := 0, 0
+	for k := range dst {
+		if j == len(b) || (i < len(a) && !less(b[j], a[i])) {
+			dst[k] = a[i]
+			i++
+		} else {
+			dst[k] = b[j]
+			j++
+		}
+	}
+}
+
+// Read returns the next record in sorted order. The first call to Read
+// ends the addition of records. Returned records use the references of
+// the header returned by Header.
+func (s *Sorter) Read() (*sam.Record, error) {
+	if !s.merging {
+		s.merging = true
+		if s.err == nil {
+			s.err = s.startMerge()
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(s.runs.runs) == 0 {
+		return nil, io.EOF
+	}
+	run := s.runs.runs[0]
+	rec := run.head
+
+	// Use the references of the sorter header
+	// rather than those of the input or of the
+	// temporary files.
+	remapRefs(rec, s.h.Refs())
+
+	next, err := run.next()
+	switch err {
+	case nil:
+		run.head = next
+		heap.Fix(&s.runs, 0)
+	case io.EOF:
+		heap.Pop(&s.runs)
+	default:
+		s.err = err
+	}
+	return rec, nil
+}
+
+// startMerge prepares the merge of the buffered records and the
+// temporary files.
+func (s *Sorter) startMerge() error {
+	s.sortBuffer()
+	for _, name := range s.files {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		s.open = append(s.open, f)
+		r, err := NewReader(f, 1)
+		if err != nil {
+			return err
+		}
+		s.readers = append(s.readers, r)
+		next := func() (*sam.Record, error) {
+			return r.Read()
+		}
+		s.addRun(next)
+	}
+	if s.err != nil {
+		return s.err
+	}
+	buf := s.buf
+	s.buf = nil
+	s.addRun(func() (*sam.Record, error) {
+		if len(buf) == 0 {
+			return nil, io.EOF
+		}
+		rec := buf[0]
+		buf = buf[1:]
+		s.unpackName(rec)
+		return rec, nil
+	})
+	if s.err != nil {
+		return s.err
+	}
+	s.runs.less = s.less
+	heap.Init(&s.runs)
+	return nil
+}
+
+// addRun adds a run of sorted records with the given source to the
+// merge if the run is not empty.
+func (s *Sorter) addRun(next func() (*sam.Record, error)) {
+	head, err := next()
+	switch err {
+	case nil:
+		s.runs.runs = append(s.runs.runs, &sortRun{id: len(s.runs.runs), head: head, next: next})
+	case io.EOF:
+	default:
+		s.err = err
+	}
+}
+
+// Close releases the resources held by the Sorter and removes its
+// temporary files.
+func (s *Sorter) Close() error {
+	var err error
+	for _, r := range s.readers {
+		if cerr := r.Close(); err == nil {
+			err = cerr
+		}
+	}
+	for _, f := range s.open {
+		f.Close()
+	}
+	for _, name := range s.files {
+		if rerr := os.Remove(name); err == nil {
+			err = rerr
+		}
+	}
+	s.readers, s.open, s.files, s.buf = nil, nil, nil, nil
+	s.runs.runs = nil
+	return err
+}
+
+// Sort writes the records read from src to dst as BAM in the sort
+// order specified by opts.
+func Sort(dst io.Writer, src *Reader, opts SortOptions) error {
+	s, err := NewSorter(src.Header(), opts)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	for {
+		r, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		err = s.Add(r)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := NewWriter(dst, s.Header(), s.opts.Workers)
+	if err != nil {
+		return err
+	}
+	for {
+		r, err := s.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Close()
+			return err
+		}
+		err = w.Write(r)
+		if err != nil {
+			w.Close()
+			return err
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return s.Close()
+}
+
+// remapRefs sets the references of r to those with the same IDs in
+// refs.
+func remapRefs(r *sam.Record, refs []*sam.Reference) {
+	if id := r.Ref.ID(); id >= 0 {
+		r.Ref = refs[id]
+	}
+	if id := r.MateRef.ID(); id >= 0 {
+		r.MateRef = refs[id]
+	}
+}
+
+// lessByCoordinate returns whether a sorts before b by reference ID,
+// with unplaced records last, position and strand.
+func lessByCoordinate(a, b *sam.Record) bool {
+	ai, bi := uint32(a.Ref.ID()), uint32(b.Ref.ID())
+	if ai != bi {
+		return ai < bi
+	}
+	if a.Pos != b.Pos {
+		return a.Pos < b.Pos
+	}
+	return a.Flags&sam.Reverse < b.Flags&sam.Reverse
+}
+
+// sortRun is a source of sorted records in a merge.
+type sortRun struct {
+	id   int
+	head *sam.Record
+	next func() (*sam.Record, error)
+}
+
+// sortRuns is a heap of runs ordered by their head records, and then
+// by run ID so that equal records are returned in the order they were
+// added.
+type sortRuns struct {
+	runs []*sortRun
+	less func(a, b *sam.Record) bool
+}
+
+func (h *sortRuns) Len() int { return len(h.runs) }
+func (h *sortRuns) Less(i, j int) bool {
+	a, b := h.runs[i], h.runs[j]
+	if h.less(a.head, b.head) {
+		return true
+	}
+	return a.id < b.id && !h.less(b.head, a.head)
+}
+func (h *sortRuns) Swap(i, j int)      { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *sortRuns) Push(x interface{}) { h.runs = append(h.runs, x.(*sortRun)) }
+func (h *sortRuns) Pop() interface{} {
+	r := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return r
+}
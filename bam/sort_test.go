@@ -0,0 +1,442 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestSorter(t *testing.T) {
+	var refs []*sam.Reference
+	for i := 0; i < 3; i++ {
+		ref, err := sam.NewReference(fmt.Sprintf("chr%d", i+1), "", "", 10000, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating reference: %v", err)
+		}
+		refs = append(refs, ref)
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	var in []*sam.Record
+	for i := 0; i < 5000; i++ {
+		var (
+			ref   *sam.Reference
+			pos   = -1
+			cigar = []sam.CigarOp{sam.NewCigarOp(sam.CigarSoftClipped, 4)}
+			flags = sam.Paired | sam.Read1
+		)
+		if i%2 == 1 {
+			flags = sam.Paired | sam.Read2
+		}
+		if rnd.Intn(20) != 0 {
+			ref = refs[rnd.Intn(len(refs))]
+			pos = rnd.Intn(100)
+			cigar = []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+			if rnd.Intn(2) == 0 {
+				flags |= sam.Reverse
+			}
+		} else {
+			flags |= sam.Unmapped
+		}
+		// Records without quality scores check
+		// that absent scores are filled when
+		// written to temporary files.
+		var qual []byte
+		if i%3 != 0 {
+			qual = []byte{30, 30, 30, 30}
+		}
+		r, err := sam.NewRecord(fmt.Sprintf("r%d", i/2), ref, nil, pos, -1, 0, 60, cigar, []byte("ACGT"), qual, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		r.Flags = flags
+		in = append(in, r)
+	}
+
+	for _, test := range []struct {
+		order     sam.SortOrder
+		nameOrder NameOrder
+		less      func(a, b *sam.Record) bool
+		ss        string
+	}{
+		{order: sam.Coordinate, less: lessByCoordinate},
+		{order: sam.QueryName, less: Lexicographic.lessByNameAndRead, ss: "queryname:lexicographical"},
+		{order: sam.QueryName, nameOrder: Natural, less: Natural.lessByNameAndRead, ss: "queryname:natural"},
+	} {
+		for _, memory := range []int64{1 << 16, 1 << 30} {
+			dir := t.TempDir()
+			s, err := NewSorter(h, SortOptions{Order: test.order, NameOrder: test.nameOrder, Memory: memory, TempDir: dir, Workers: 3})
+			if err != nil {
+				t.Fatalf("unexpected error creating sorter: %v", err)
+			}
+			if s.Header().SortOrder != test.order {
+				t.Errorf("unexpected header sort order: got:%v want:%v", s.Header().SortOrder, test.order)
+			}
+			if ss := s.Header().Get(subSortTag); ss != test.ss {
+				t.Errorf("unexpected header sub-sort order: got:%q want:%q", ss, test.ss)
+			}
+			for _, r := range in {
+				err = s.Add(r)
+				if err != nil {
+					t.Fatalf("unexpected error adding record: %v", err)
+				}
+			}
+			spilled := len(s.files) != 0
+			if spilled != (memory < 1<<30) {
+				t.Errorf("unexpected spill state for memory %d: got:%t", memory, spilled)
+			}
+
+			var got []*sam.Record
+			for {
+				r, err := s.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("unexpected error reading record: %v", err)
+				}
+				got = append(got, r)
+			}
+			if len(got) != len(in) {
+				t.Fatalf("unexpected number of records for %v memory %d: got:%d want:%d", test.order, memory, len(got), len(in))
+			}
+			for i := 1; i < len(got); i++ {
+				if test.less(got[i], got[i-1]) {
+					t.Fatalf("records out of %v order at %d: %v before %v", test.order, i, got[i-1], got[i])
+				}
+			}
+			for _, r := range got {
+				if r.Ref != nil && r.Ref != s.Header().Refs()[r.Ref.ID()] {
+					t.Fatalf("record reference not from sorter header: %v", r)
+				}
+			}
+
+			err = s.Close()
+			if err != nil {
+				t.Fatalf("unexpected error closing sorter: %v", err)
+			}
+			files, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("unexpected error reading temporary directory: %v", err)
+			}
+			if len(files) != 0 {
+				t.Errorf("unexpected temporary files remaining: %d", len(files))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, r := range in {
+		err = w.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	data := buf.Bytes()
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var sorted bytes.Buffer
+	err = Sort(&sorted, br, SortOptions{Order: sam.Coordinate, Memory: 1 << 16, TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("unexpected error sorting: %v", err)
+	}
+	sr, err := NewReader(&sorted, 1)
+	if err != nil {
+		t.Fatalf("unexpected error reading sorted BAM: %v", err)
+	}
+	if sr.Header().SortOrder != sam.Coordinate {
+		t.Errorf("unexpected sorted BAM sort order: got:%v want:%v", sr.Header().SortOrder, sam.Coordinate)
+	}
+	var (
+		n    int
+		last *sam.Record
+	)
+	for {
+		r, err := sr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading sorted record: %v", err)
+		}
+		if last != nil && lessByCoordinate(r, last) {
+			t.Fatalf("records out of order: %v before %v", last, r)
+		}
+		last = r
+		n++
+	}
+	if n != len(in) {
+		t.Errorf("unexpected number of sorted records: got:%d want:%d", n, len(in))
+	}
+
+	// Natural queryname order must be recognised
+	// from the header by a Verifier.
+	br, err = NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	sorted.Reset()
+	err = Sort(&sorted, br, SortOptions{Order: sam.QueryName, NameOrder: Natural, TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("unexpected error sorting: %v", err)
+	}
+	sr, err = NewReader(&sorted, 1)
+	if err != nil {
+		t.Fatalf("unexpected error reading sorted BAM: %v", err)
+	}
+	v := NewVerifier(sr, nil)
+	for n = 0; ; n++ {
+		_, err = v.Read()
+		if err != nil {
+			break
+		}
+	}
+	if err != io.EOF || n != len(in) {
+		t.Errorf("unexpected verification of natural order: got:%d records with error %v", n, err)
+	}
+
+	_, err = NewSorter(h, SortOptions{Order: sam.Unsorted})
+	if err == nil {
+		t.Error("expected error for unsorted order")
+	}
+}
+
+func TestSorterCompactNames(t *testing.T) {
+	h, err := sam.NewHeader(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	var names []string
+	for i := 0; i < 2000; i++ {
+		var name string
+		switch rnd.Intn(4) {
+		case 0:
+			// Names without an instrument, run
+			// and flowcell prefix.
+			name = fmt.Sprintf("r%d", rnd.Intn(500))
+		default:
+			name = fmt.Sprintf("A0%d:%d:HXY%dDSX:%d:%d:%d:%d", rnd.Intn(3), rnd.Intn(12), rnd.Intn(2), rnd.Intn(4)+1, rnd.Intn(2000), rnd.Intn(30000), rnd.Intn(30000))
+		}
+		names = append(names, name, name)
+	}
+
+	for _, nameOrder := range []NameOrder{Lexicographic, Natural} {
+		for _, memory := range []int64{1 << 14, 1 << 30} {
+			s, err := NewSorter(h, SortOptions{Order: sam.QueryName, NameOrder: nameOrder, Memory: memory, TempDir: t.TempDir(), Workers: 2, CompactNames: true})
+			if err != nil {
+				t.Fatalf("unexpected error creating sorter: %v", err)
+			}
+			for i, name := range names {
+				r, err := sam.NewRecord(name, nil, nil, -1, -1, 0, 0, nil, []byte("ACGT"), nil, nil)
+				if err != nil {
+					t.Fatalf("unexpected error creating record: %v", err)
+				}
+				r.Flags = sam.Paired | sam.Unmapped | sam.Read1
+				if i%2 == 1 {
+					r.Flags = sam.Paired | sam.Unmapped | sam.Read2
+				}
+				err = s.Add(r)
+				if err != nil {
+					t.Fatalf("unexpected error adding record: %v", err)
+				}
+			}
+
+			var got []*sam.Record
+			for {
+				r, err := s.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("unexpected error reading record: %v", err)
+				}
+				got = append(got, r)
+			}
+			if len(got) != len(names) {
+				t.Fatalf("unexpected number of records for %v memory %d: got:%d want:%d", nameOrder, memory, len(got), len(names))
+			}
+			want := append([]string(nil), names...)
+			sort.Slice(want, func(i, j int) bool { return nameOrder.Less(want[i], want[j]) })
+			for i, r := range got {
+				if r.Name != want[i] {
+					t.Fatalf("unexpected name for %v memory %d at %d: got:%q want:%q", nameOrder, memory, i, r.Name, want[i])
+				}
+				if i > 0 && nameOrder.lessByNameAndRead(r, got[i-1]) {
+					t.Fatalf("records out of %v order at %d: %v before %v", nameOrder, i, got[i-1], r)
+				}
+			}
+			err = s.Close()
+			if err != nil {
+				t.Fatalf("unexpected error closing sorter: %v", err)
+			}
+		}
+	}
+}
+
+func TestSorterCompactNamesInScratch(t *testing.T) {
+	h, err := sam.NewHeader(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	const n = 1000
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	var names []string
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("A00%d:%d:HXYDSX:%d:%d:%d:%d", rnd.Intn(3), rnd.Intn(4), rnd.Intn(4)+1, rnd.Intn(2000), rnd.Intn(30000), rnd.Intn(30000))
+		if i%10 == 0 {
+			// Names without a prefix are one
+			// byte longer when compact.
+			name = fmt.Sprintf("r%d", rnd.Intn(500))
+		}
+		names = append(names, name)
+		r, err := sam.NewRecord(name, nil, nil, -1, -1, 0, 0, nil, []byte("ACGTACGTAC"), nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		r.Flags = sam.Unmapped
+		err = w.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	br, err := NewReader(&buf, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var in []*sam.Record
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		in = append(in, r)
+	}
+
+	s, err := NewSorter(br.Header(), SortOptions{Order: sam.QueryName, NameOrder: Natural, TempDir: t.TempDir(), CompactNames: true})
+	if err != nil {
+		t.Fatalf("unexpected error creating sorter: %v", err)
+	}
+	var (
+		before, after runtime.MemStats
+		size          int64
+	)
+	runtime.ReadMemStats(&before)
+	for _, r := range in {
+		err = s.Add(r)
+		if err != nil {
+			t.Fatalf("unexpected error adding record: %v", err)
+		}
+	}
+	runtime.ReadMemStats(&after)
+
+	// Compact names are written over the full
+	// names held in Scratch, so adding records
+	// allocates only the buffer and prefixes.
+	if allocs := after.Mallocs - before.Mallocs; allocs > n/10 {
+		t.Errorf("unexpected number of allocations adding records: got:%d want:<=%d", allocs, n/10)
+	}
+	for i, r := range in {
+		if !nameInScratch(r) {
+			t.Errorf("compact name of record %d not held in scratch", i)
+		}
+		size += 200 + int64(cap(r.Scratch))
+	}
+	if s.size != size {
+		t.Errorf("unexpected buffered size: got:%d want:%d", s.size, size)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return NaturalLess(names[i], names[j]) })
+	for i := 0; ; i++ {
+		r, err := s.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		if r.Name != names[i] {
+			t.Fatalf("unexpected name at %d: got:%q want:%q", i, r.Name, names[i])
+		}
+	}
+	err = s.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing sorter: %v", err)
+	}
+}
+
+func TestNameDictLess(t *testing.T) {
+	names := []string{
+		"r1",
+		"r10",
+		"A1:1:X:5:1:1:1",
+		"A01:1:X:5:1:1:1",
+		"A01:1:X:10:1:1:1",
+		"A1:1:X:1",
+		"A1:1:X",
+		"A1:1:X:",
+		"A1:1:Y:1",
+		"A1:1",
+		"A1:1:X:1:1",
+		"B",
+	}
+	for _, o := range []NameOrder{Lexicographic, Natural} {
+		d := newNameDict()
+		less := d.lessByNameAndRead(o)
+		var recs []*sam.Record
+		for _, name := range names {
+			recs = append(recs,
+				&sam.Record{Name: d.pack(name)},
+				// Names held in full, as they
+				// are when the dictionary is full.
+				&sam.Record{Name: "\x00" + name},
+			)
+		}
+		for _, a := range recs {
+			for _, b := range recs {
+				an, bn := d.unpack(a.Name), d.unpack(b.Name)
+				want := an != bn && o.Less(an, bn)
+				if got := less(a, b); got != want {
+					t.Errorf("unexpected %v order of %q and %q: got:%t want:%t", o, an, bn, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"encoding/binary"
+	"strings"
+	"unsafe"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// maxNamePrefixes is the maximum number of name prefixes held by a
+// nameDict. Names with prefixes beyond the limit are held in full.
+const maxNamePrefixes = 1 << 16
+
+// namePrefixFields is the number of colon separated fields of a read
+// name held in a nameDict, the instrument, run and flowcell fields of
+// Illumina read names.
+const namePrefixFields = 3
+
+// nameDict is a dictionary of read name prefixes used to hold the names
+// of buffered records compactly. A compact name is the uvarint encoded
+// index of its prefix in the dictionary followed by the remainder of
+// the name. The zero index is the empty prefix.
+type nameDict struct {
+	prefixes []string
+	index    map[string]uint64
+}
+
+func newNameDict() *nameDict {
+	return &nameDict{prefixes: []string{""}, index: map[string]uint64{"": 0}}
+}
+
+// splitName returns the prefix of name held in a nameDict and the
+// remainder of the name. The prefix ends with a colon so that runs of
+// digits do not span the prefix and the remainder.
+func splitName(name string) (prefix, suffix string) {
+	var n int
+	for i := 0; i < len(name); i++ {
+		if name[i] != ':' {
+			continue
+		}
+		n++
+		if n == namePrefixFields {
+			return name[:i+1], name[i+1:]
+		}
+	}
+	return "", name
+}
+
+// compact returns the prefix index of name and the remainder of the
+// name following the prefix, adding the prefix to the dictionary if it
+// is not held.
+func (d *nameDict) compact(name string) (uint64, string) {
+	prefix, suffix := splitName(name)
+	id, ok := d.index[prefix]
+	if !ok {
+		if len(d.prefixes) == maxNamePrefixes {
+			return 0, name
+		}
+		id = uint64(len(d.prefixes))
+		prefix = strings.Clone(prefix)
+		d.prefixes = append(d.prefixes, prefix)
+		d.index[prefix] = id
+	}
+	return id, suffix
+}
+
+// pack returns the compact form of name.
+func (d *nameDict) pack(name string) string {
+	id, suffix := d.compact(name)
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], id)
+	var sb strings.Builder
+	sb.Grow(n + len(suffix))
+	sb.Write(b[:n])
+	sb.WriteString(suffix)
+	return sb.String()
+}
+
+// packRecord replaces the name of r with its compact form. The name of
+// a record read by a Reader is held at the start of r.Scratch followed
+// by its NUL terminator, and is overwritten by the compact name so that
+// the full name is not retained alongside it.
+func (d *nameDict) packRecord(r *sam.Record) {
+	id, suffix := d.compact(r.Name)
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], id)
+	held := len(r.Name)
+	if held < len(r.Scratch) && r.Scratch[held] == 0 {
+		held++
+	}
+	if !nameInScratch(r) || n+len(suffix) > held {
+		r.Name = d.pack(r.Name)
+		return
+	}
+	// The suffix may overlap the index when
+	// the name is held in full, so it is
+	// moved before the index is written.
+	copy(r.Scratch[n:], suffix)
+	copy(r.Scratch, b[:n])
+	r.Name = unsafe.String(&r.Scratch[0], n+len(suffix))
+}
+
+// nameInScratch returns whether the name of r is held at the start of
+// r.Scratch.
+func nameInScratch(r *sam.Record) bool {
+	return len(r.Name) != 0 && len(r.Scratch) != 0 && unsafe.StringData(r.Name) == &r.Scratch[0]
+}
+
+// split returns the prefix index and remainder of the compact name c.
+func (d *nameDict) split(c string) (uint64, string) {
+	var (
+		id uint64
+		n  int
+	)
+	for s := uint(0); n < len(c); s += 7 {
+		b := c[n]
+		n++
+		id |= uint64(b&0x7f) << s
+		if b < 0x80 {
+			break
+		}
+	}
+	return id, c[n:]
+}
+
+// unpack returns the full name held in the compact name c.
+func (d *nameDict) unpack(c string) string {
+	id, suffix := d.split(c)
+	if id == 0 {
+		return suffix
+	}
+	return d.prefixes[id] + suffix
+}
+
+// parts returns the prefix and remainder of the full name held in the
+// compact name c. Names held in full are split as they would be if
+// their prefix were held.
+func (d *nameDict) parts(c string) (prefix, suffix string) {
+	id, suffix := d.split(c)
+	if id == 0 {
+		return splitName(suffix)
+	}
+	return d.prefixes[id], suffix
+}
+
+// lessByNameAndRead returns whether the record a sorts before b in the
+// order o by name and then first before last fragment, where the names
+// of a and b are compact names of d.
+func (d *nameDict) lessByNameAndRead(o NameOrder) func(a, b *sam.Record) bool {
+	return func(a, b *sam.Record) bool {
+		if a.Name != b.Name {
+			// Non-empty prefixes hold exactly
+			// namePrefixFields colons and end
+			// with a colon, so no prefix is the
+			// start of another name, and runs
+			// of digits do not span the prefix
+			// and the remainder. The order of
+			// names with different prefixes is
+			// then the order of their prefixes,
+			// unless the prefixes are equal in
+			// natural order.
+			ap, as := d.parts(a.Name)
+			bp, bs := d.parts(b.Name)
+			switch {
+			case ap == bp:
+			case ap == "":
+				return o.Less(as, bp)
+			case bp == "":
+				return o.Less(ap, bs)
+			case o.Less(ap, bp):
+				return true
+			case o.Less(bp, ap):
+				return false
+			}
+			return o.Less(as, bs)
+		}
+		return a.Flags&(sam.Read1|sam.Read2) < b.Flags&(sam.Read1|sam.Read2)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"errors"
+	"io"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/index"
+	"github.com/Schaudge/hts/sam"
+)
+
+// ReferenceShards returns a region covering the whole of each of the
+// given references.
+func ReferenceShards(refs []*sam.Reference) []Region {
+	shards := make([]Region, len(refs))
+	for i, ref := range refs {
+		shards[i] = Region{Ref: ref, Start: 0, End: ref.Len()}
+	}
+	return shards
+}
+
+// Overlap is the policy for records overlapping shard boundaries.
+type Overlap int
+
+const (
+	// StartOnly writes each record to the
+	// shard containing its start position,
+	// so that when the shards tile the genome
+	// each placed record is written once.
+	StartOnly Overlap = iota
+
+	// Duplicate writes each record to every
+	// shard it overlaps.
+	Duplicate
+)
+
+// SplitOptions specifies the behaviour of Split.
+type SplitOptions struct {
+	// Overlap is the policy for records
+	// overlapping shard boundaries.
+	Overlap Overlap
+
+	// SubsetHeader specifies that the header
+	// of each shard holds only the reference
+	// of the shard. Mate references of
+	// records with mates on other references
+	// are cleared.
+	SubsetHeader bool
+
+	// Workers is the number of goroutines
+	// used to compress each shard. If Workers
+	// is less than one, one goroutine is used.
+	Workers int
+}
+
+// Split writes the records of r within each of the given shards as BAM
+// to the io.Writer returned by create for the shard, and returns an
+// Index for each shard. Shards are read using idx, and unplaced records
+// are not written. The io.Writers are not closed by Split.
+func Split(r *Reader, idx *Index, shards []Region, create func(i int, shard Region) (io.Writer, error), opts SplitOptions) ([]*Index, error) {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	idxs := make([]*Index, len(shards))
+	for i, shard := range shards {
+		if shard.Ref == nil {
+			return nil, errors.New("bam: region with nil reference")
+		}
+		w, err := create(i, shard)
+		if err != nil {
+			return nil, err
+		}
+		idxs[i], err = splitShard(w, r, idx, shard, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return idxs, nil
+}
+
+// splitShard writes the records of r within shard to w and returns the
+// index of the written data.
+func splitShard(w io.Writer, r *Reader, idx *Index, shard Region, opts SplitOptions) (*Index, error) {
+	h := r.Header().Clone()
+	ref := h.Refs()[shard.Ref.ID()]
+	if opts.SubsetHeader {
+		refs := h.Refs()
+		for i := len(refs) - 1; i >= 0; i-- {
+			if refs[i] == ref {
+				continue
+			}
+			err := h.RemoveReference(refs[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	chunks, err := idx.Chunks(shard.Ref, shard.Start, shard.End)
+	switch err {
+	case nil:
+	case index.ErrNoReference, index.ErrInvalid:
+		chunks = nil
+	default:
+		return nil, err
+	}
+	it, err := NewIterator(r, chunks)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	id := shard.Ref.ID()
+	if opts.Overlap == Duplicate {
+		it.filter = newRegionSet([]Region{shard}).overlaps
+	} else {
+		it.filter = func(rec *sam.Record) bool {
+			return rec.Ref.ID() == id && shard.Start <= rec.Pos && rec.Pos < shard.End
+		}
+	}
+
+	bw, err := NewWriter(w, h, opts.Workers)
+	if err != nil {
+		return nil, err
+	}
+	var out Index
+	begin, err := bw.bg.VirtualOffset(false)
+	if err != nil {
+		bw.Close()
+		return nil, err
+	}
+	for it.Next() {
+		rec := it.Record()
+		rec.Ref = ref
+		if rec.MateRef.ID() == id {
+			rec.MateRef = ref
+		} else if opts.SubsetHeader && rec.MateRef != nil {
+			rec.MateRef, rec.MatePos = nil, -1
+		} else if rec.MateRef != nil {
+			rec.MateRef = h.Refs()[rec.MateRef.ID()]
+		}
+		err = bw.Write(rec)
+		if err != nil {
+			bw.Close()
+			return nil, err
+		}
+		end, err := bw.bg.VirtualOffset(false)
+		if err != nil {
+			bw.Close()
+			return nil, err
+		}
+		err = out.Add(rec, bgzf.Chunk{Begin: begin, End: end})
+		if err != nil {
+			bw.Close()
+			return nil, err
+		}
+		begin = end
+	}
+	if err := it.Error(); err != nil {
+		bw.Close()
+		return nil, err
+	}
+	err = bw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,156 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	data := matePairs(t, 50)
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("failed to open BAM: %v", err)
+	}
+	var (
+		idx   Index
+		total int
+	)
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read BAM record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("failed to add record to index: %v", err)
+		}
+		total++
+	}
+	refs := br.Header().Refs()
+
+	for _, test := range []struct {
+		name   string
+		shards []Region
+	}{
+		{name: "references", shards: ReferenceShards(refs)},
+		{name: "regions", shards: []Region{
+			{Ref: refs[0], Start: 0, End: 255},
+			{Ref: refs[0], Start: 255, End: 1005},
+			{Ref: refs[0], Start: 1005, End: refs[0].Len()},
+			{Ref: refs[1], Start: 0, End: refs[1].Len()},
+		}},
+	} {
+		for _, opts := range []SplitOptions{
+			{Overlap: StartOnly},
+			{Overlap: Duplicate},
+			{Overlap: StartOnly, SubsetHeader: true, Workers: 2},
+		} {
+			outs := make([]*bytes.Buffer, len(test.shards))
+			idxs, err := Split(br, &idx, test.shards, func(i int, _ Region) (io.Writer, error) {
+				outs[i] = &bytes.Buffer{}
+				return outs[i], nil
+			}, opts)
+			if err != nil {
+				t.Fatalf("unexpected error splitting %s: %v", test.name, err)
+			}
+			if len(idxs) != len(test.shards) {
+				t.Fatalf("unexpected number of indexes for %s: got:%d want:%d", test.name, len(idxs), len(test.shards))
+			}
+
+			var n int
+			for i, shard := range test.shards {
+				sr, err := NewReader(bytes.NewReader(outs[i].Bytes()), 1)
+				if err != nil {
+					t.Fatalf("unexpected error reading shard %d of %s: %v", i, test.name, err)
+				}
+				h := sr.Header()
+				if opts.SubsetHeader && (len(h.Refs()) != 1 || h.Refs()[0].Name() != shard.Ref.Name()) {
+					t.Errorf("unexpected subset header references for shard %d of %s: %v", i, test.name, h.Refs())
+				}
+				if !opts.SubsetHeader && len(h.Refs()) != len(refs) {
+					t.Errorf("unexpected header references for shard %d of %s: %v", i, test.name, h.Refs())
+				}
+				var m int
+				for {
+					r, err := sr.Read()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Fatalf("unexpected error reading shard %d of %s: %v", i, test.name, err)
+					}
+					if r.Ref.Name() != shard.Ref.Name() {
+						t.Errorf("unexpected reference in shard %d of %s: %v", i, test.name, r)
+					}
+					switch opts.Overlap {
+					case StartOnly:
+						if r.Pos < shard.Start || shard.End <= r.Pos {
+							t.Errorf("record outside shard %d of %s: %v", i, test.name, r)
+						}
+					case Duplicate:
+						if r.End() <= shard.Start || shard.End <= r.Pos {
+							t.Errorf("record not overlapping shard %d of %s: %v", i, test.name, r)
+						}
+					}
+					if opts.SubsetHeader && r.MateRef != nil && r.MateRef.Name() != shard.Ref.Name() {
+						t.Errorf("unexpected mate reference in subset shard %d of %s: %v", i, test.name, r)
+					}
+					m++
+				}
+
+				// The emitted index must find all
+				// records of the shard.
+				sr, err = NewReader(bytes.NewReader(outs[i].Bytes()), 1)
+				if err != nil {
+					t.Fatalf("unexpected error reading shard %d of %s: %v", i, test.name, err)
+				}
+				ref := sr.Header().Refs()[0]
+				if !opts.SubsetHeader {
+					ref = sr.Header().Refs()[shard.Ref.ID()]
+				}
+				var bai bytes.Buffer
+				err = WriteIndex(&bai, idxs[i])
+				if err != nil {
+					t.Fatalf("unexpected error writing index of shard %d of %s: %v", i, test.name, err)
+				}
+				shardIdx, err := ReadIndex(&bai)
+				if err != nil {
+					t.Fatalf("unexpected error reading index of shard %d of %s: %v", i, test.name, err)
+				}
+				var got int
+				chunks, err := shardIdx.Chunks(ref, 0, ref.Len())
+				if err == nil {
+					it, err := NewIterator(sr, chunks)
+					if err != nil {
+						t.Fatalf("unexpected error creating iterator: %v", err)
+					}
+					for it.Next() {
+						got++
+					}
+					err = it.Close()
+					if err != nil {
+						t.Fatalf("unexpected error iterating shard %d of %s: %v", i, test.name, err)
+					}
+				}
+				if got != m {
+					t.Errorf("unexpected number of indexed records in shard %d of %s: got:%d want:%d", i, test.name, got, m)
+				}
+				n += m
+			}
+			switch {
+			case opts.Overlap == StartOnly && n != total:
+				t.Errorf("unexpected number of records in %s shards: got:%d want:%d", test.name, n, total)
+			case opts.Overlap == Duplicate && n < total:
+				t.Errorf("unexpected number of records in %s shards: got:%d want at least:%d", test.name, n, total)
+			}
+		}
+	}
+}
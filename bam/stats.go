@@ -0,0 +1,186 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// Stats accumulates counts of the records decoded by Readers or
+// encoded by Writers it is set on. A Stats may be shared by several
+// Readers or Writers to count the records of a pipeline stage, and is
+// safe for concurrent use.
+//
+// Stats implements the expvar.Var interface, so it may be published
+// with expvar.Publish. Its counters are monotonic and so are suitable
+// for export as Prometheus counters.
+type Stats struct {
+	start time.Time
+
+	records  atomic.Int64
+	bytes    atomic.Int64
+	auxBytes atomic.Int64
+	poolGets atomic.Int64
+	poolHits atomic.Int64
+}
+
+// NewStats returns a new Stats with rates measured from the time of
+// the call.
+func NewStats() *Stats {
+	return &Stats{start: time.Now()}
+}
+
+// Counts is a snapshot of the counts held by a Stats.
+type Counts struct {
+	// Records is the number of records
+	// decoded or encoded.
+	Records int64
+
+	// Bytes is the number of bytes in the
+	// BAM encoding of the records,
+	// including their block size fields.
+	Bytes int64
+
+	// AuxBytes is the number of bytes in
+	// the BAM encoding of the aux fields of
+	// the records.
+	AuxBytes int64
+
+	// PoolGets is the number of records
+	// obtained from a record pool by
+	// Readers, and PoolHits is the number
+	// of those records holding storage from
+	// an earlier use that could be reused.
+	PoolGets int64
+	PoolHits int64
+
+	// Elapsed is the time since the Stats
+	// was created.
+	Elapsed time.Duration
+}
+
+// RecordsPerSecond returns the mean rate of records.
+func (c Counts) RecordsPerSecond() float64 {
+	return perSecond(c.Records, c.Elapsed)
+}
+
+// BytesPerSecond returns the mean rate of encoded bytes.
+func (c Counts) BytesPerSecond() float64 {
+	return perSecond(c.Bytes, c.Elapsed)
+}
+
+// MeanRecordSize returns the mean size of the BAM encoding of the
+// records, or zero if no record was counted.
+func (c Counts) MeanRecordSize() float64 {
+	if c.Records == 0 {
+		return 0
+	}
+	return float64(c.Bytes) / float64(c.Records)
+}
+
+// AuxRatio returns the fraction of encoded bytes held by aux fields,
+// or zero if no record was counted.
+func (c Counts) AuxRatio() float64 {
+	if c.Bytes == 0 {
+		return 0
+	}
+	return float64(c.AuxBytes) / float64(c.Bytes)
+}
+
+// PoolHitRate returns the fraction of records obtained from a pool
+// that held reusable storage, or zero if no record was obtained from
+// a pool.
+func (c Counts) PoolHitRate() float64 {
+	if c.PoolGets == 0 {
+		return 0
+	}
+	return float64(c.PoolHits) / float64(c.PoolGets)
+}
+
+func perSecond(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// Counts returns the current counts held by s.
+func (s *Stats) Counts() Counts {
+	return Counts{
+		Records:  s.records.Load(),
+		Bytes:    s.bytes.Load(),
+		AuxBytes: s.auxBytes.Load(),
+		PoolGets: s.poolGets.Load(),
+		PoolHits: s.poolHits.Load(),
+		Elapsed:  time.Since(s.start),
+	}
+}
+
+// String returns a JSON encoding of the counts and rates held by s.
+func (s *Stats) String() string {
+	c := s.Counts()
+	b, _ := json.Marshal(struct {
+		Records          int64   `json:"records"`
+		Bytes            int64   `json:"bytes"`
+		AuxBytes         int64   `json:"aux_bytes"`
+		PoolGets         int64   `json:"pool_gets"`
+		PoolHits         int64   `json:"pool_hits"`
+		ElapsedSeconds   float64 `json:"elapsed_seconds"`
+		RecordsPerSecond float64 `json:"records_per_second"`
+		BytesPerSecond   float64 `json:"bytes_per_second"`
+		MeanRecordSize   float64 `json:"mean_record_size"`
+		AuxRatio         float64 `json:"aux_ratio"`
+		PoolHitRate      float64 `json:"pool_hit_rate"`
+	}{
+		Records:          c.Records,
+		Bytes:            c.Bytes,
+		AuxBytes:         c.AuxBytes,
+		PoolGets:         c.PoolGets,
+		PoolHits:         c.PoolHits,
+		ElapsedSeconds:   c.Elapsed.Seconds(),
+		RecordsPerSecond: c.RecordsPerSecond(),
+		BytesPerSecond:   c.BytesPerSecond(),
+		MeanRecordSize:   c.MeanRecordSize(),
+		AuxRatio:         c.AuxRatio(),
+		PoolHitRate:      c.PoolHitRate(),
+	})
+	return string(b)
+}
+
+// addRecord counts a record with the given BAM encoded size and aux
+// size.
+func (s *Stats) addRecord(size, aux int) {
+	s.records.Add(1)
+	s.bytes.Add(int64(size))
+	s.auxBytes.Add(int64(aux))
+}
+
+// addPoolGet counts a record obtained from a pool.
+func (s *Stats) addPoolGet(hit bool) {
+	s.poolGets.Add(1)
+	if hit {
+		s.poolHits.Add(1)
+	}
+}
+
+// encodedAuxLen returns the length of the aux fields of the BAM
+// encoded record b, which excludes the block size field, or zero if b
+// is too short.
+func encodedAuxLen(b []byte) int {
+	if len(b) < 32 {
+		return 0
+	}
+	nLen := int(b[8])
+	nCigar := int(binary.LittleEndian.Uint16(b[12:]))
+	lSeq := int(binary.LittleEndian.Uint32(b[16:]))
+	n := len(b) - (32 + nLen + nCigar*4 + (lSeq+1)>>1 + lSeq)
+	if n < 0 {
+		return 0
+	}
+	return n
+}
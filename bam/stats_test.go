@@ -0,0 +1,91 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+var _ expvar.Var = (*Stats)(nil)
+
+func TestStats(t *testing.T) {
+	data := matePairs(t, 50)
+	br, err := NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	rs := NewStats()
+	br.SetStats(rs)
+
+	var out bytes.Buffer
+	bw, err := NewWriter(&out, br.Header(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	ws := NewStats()
+	bw.SetStats(ws)
+
+	rg, _ := sam.NewAux(sam.NewTag("RG"), "group")
+	var n, readSize, size, aux int
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		readSize += RecordSize(r)
+		r.AuxFields = append(r.AuxFields, rg)
+		err = bw.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+		n++
+		size += RecordSize(r)
+		aux += auxLen(r)
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	rc := rs.Counts()
+	if rc.Records != int64(n) {
+		t.Errorf("unexpected number of records read: got:%d want:%d", rc.Records, n)
+	}
+	if rc.Bytes != int64(readSize) {
+		t.Errorf("unexpected number of bytes read: got:%d want:%d", rc.Bytes, readSize)
+	}
+	if rc.AuxBytes != 0 || rc.AuxRatio() != 0 {
+		t.Errorf("unexpected aux bytes read: got:%d ratio %v", rc.AuxBytes, rc.AuxRatio())
+	}
+	if rc.PoolGets != int64(n) {
+		t.Errorf("unexpected number of pool gets: got:%d want:%d", rc.PoolGets, n)
+	}
+
+	wc := ws.Counts()
+	if wc.Records != int64(n) || wc.Bytes != int64(size) || wc.AuxBytes != int64(aux) {
+		t.Errorf("unexpected write counts: got:%+v want records:%d bytes:%d aux:%d", wc, n, size, aux)
+	}
+	if got, want := wc.MeanRecordSize(), float64(size)/float64(n); got != want {
+		t.Errorf("unexpected mean record size: got:%v want:%v", got, want)
+	}
+
+	var v map[string]float64
+	err = json.Unmarshal([]byte(ws.String()), &v)
+	if err != nil {
+		t.Fatalf("unexpected error decoding stats: %v", err)
+	}
+	if v["records"] != float64(n) {
+		t.Errorf("unexpected records in stats: got:%v want:%d", v["records"], n)
+	}
+}
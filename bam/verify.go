@@ -0,0 +1,98 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"fmt"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+// OrderError is returned by a Verifier when a record is out of the sort
+// order declared by the header.
+type OrderError struct {
+	Order sam.SortOrder
+
+	// Prev and Rec are the out of order
+	// records, and PrevChunk and Chunk are
+	// their locations in the BAM stream.
+	Prev, Rec        *sam.Record
+	PrevChunk, Chunk bgzf.Chunk
+}
+
+func (e *OrderError) Error() string {
+	return fmt.Sprintf("bam: record %q at %#x out of %v order after %q at %#x",
+		e.Rec.Name, e.Chunk.Begin.Virtual(), e.Order, e.Prev.Name, e.PrevChunk.Begin.Virtual())
+}
+
+// Verifier is a Reader wrapper that checks that records are in the
+// sort order declared by the header of the Reader. Coordinate sorted
+// records must be in order of reference ID, with unplaced records last,
+// and position. Queryname sorted records must be in order of name.
+// Records of other sort orders are not checked.
+type Verifier struct {
+	r    *Reader
+	less func(a, b *sam.Record) bool
+
+	last      *sam.Record
+	lastChunk bgzf.Chunk
+	err       error
+}
+
+// NewVerifier returns a Verifier reading from r. Queryname sorted names
+// are compared with nameLess or, if nameLess is nil, in the order
+// declared by the SS sub-sort field of the header, defaulting to
+// lexical order.
+func NewVerifier(r *Reader, nameLess func(a, b string) bool) *Verifier {
+	v := &Verifier{r: r}
+	switch r.Header().SortOrder {
+	case sam.Coordinate:
+		v.less = func(a, b *sam.Record) bool {
+			ai, bi := uint32(a.Ref.ID()), uint32(b.Ref.ID())
+			if ai != bi {
+				return ai < bi
+			}
+			return a.Pos < b.Pos
+		}
+	case sam.QueryName:
+		if nameLess == nil {
+			order, _ := HeaderNameOrder(r.Header())
+			nameLess = order.Less
+		}
+		v.less = func(a, b *sam.Record) bool { return nameLess(a.Name, b.Name) }
+	}
+	return v
+}
+
+// Read returns the next record from the underlying Reader. If the
+// record is out of order, Read returns a nil record and an *OrderError,
+// and all subsequent calls to Read return the same error.
+func (v *Verifier) Read() (*sam.Record, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	rec, err := v.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	chunk := v.r.LastChunk()
+	if v.less != nil && v.last != nil && v.less(rec, v.last) {
+		v.err = &OrderError{
+			Order:     v.r.Header().SortOrder,
+			Prev:      v.last,
+			Rec:       rec,
+			PrevChunk: v.lastChunk,
+			Chunk:     chunk,
+		}
+		return nil, v.err
+	}
+	v.last, v.lastChunk = rec, chunk
+	return rec, nil
+}
+
+// LastChunk returns the bgzf.Chunk corresponding to the last Read
+// operation that returned a record.
+func (v *Verifier) LastChunk() bgzf.Chunk { return v.lastChunk }
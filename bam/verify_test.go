@@ -0,0 +1,144 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestVerifier(t *testing.T) {
+	var refs []*sam.Reference
+	for _, name := range []string{"chr1", "chr2"} {
+		ref, err := sam.NewReference(name, "", "", 1000, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating reference: %v", err)
+		}
+		refs = append(refs, ref)
+	}
+	header, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	type rec struct {
+		name string
+		ref  int
+		pos  int
+	}
+	bamData := func(so sam.SortOrder, recs []rec) []byte {
+		h := header.Clone()
+		h.Version = "1.6"
+		h.SortOrder = so
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, h, 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating writer: %v", err)
+		}
+		for _, r := range recs {
+			var ref *sam.Reference
+			if r.ref >= 0 {
+				ref = h.Refs()[r.ref]
+			}
+			sr, err := sam.NewRecord(r.name, ref, nil, r.pos, -1, 0, 60, []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}, []byte("ACGT"), nil, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating record: %v", err)
+			}
+			err = w.Write(sr)
+			if err != nil {
+				t.Fatalf("unexpected error writing record: %v", err)
+			}
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("unexpected error closing writer: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	for _, test := range []struct {
+		name     string
+		order    sam.SortOrder
+		nameLess func(a, b string) bool
+		recs     []rec
+		wantBad  string
+	}{
+		{
+			name:  "coordinate",
+			order: sam.Coordinate,
+			recs:  []rec{{"a", 0, 5}, {"b", 0, 5}, {"c", 0, 9}, {"d", 1, 0}, {"e", -1, -1}},
+		},
+		{
+			name:    "coordinate position",
+			order:   sam.Coordinate,
+			recs:    []rec{{"a", 0, 5}, {"b", 0, 4}},
+			wantBad: "b",
+		},
+		{
+			name:    "coordinate unplaced",
+			order:   sam.Coordinate,
+			recs:    []rec{{"a", 0, 5}, {"b", -1, -1}, {"c", 1, 1}},
+			wantBad: "c",
+		},
+		{
+			name:  "queryname lexical",
+			order: sam.QueryName,
+			recs:  []rec{{"r10", 0, 1}, {"r10", 0, 0}, {"r9", 0, 0}},
+		},
+		{
+			name:     "queryname natural",
+			order:    sam.QueryName,
+			nameLess: NaturalLess,
+			recs:     []rec{{"r9", 0, 1}, {"r10", 0, 0}, {"r9", 0, 0}},
+			wantBad:  "r9",
+		},
+		{
+			name:  "unsorted",
+			order: sam.Unsorted,
+			recs:  []rec{{"b", 1, 5}, {"a", 0, 1}},
+		},
+	} {
+		br, err := NewReader(bytes.NewReader(bamData(test.order, test.recs)), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		v := NewVerifier(br, test.nameLess)
+		var n int
+		for {
+			_, err = v.Read()
+			if err != nil {
+				break
+			}
+			n++
+		}
+		if test.wantBad == "" {
+			if err != io.EOF {
+				t.Errorf("unexpected error for %s: %v", test.name, err)
+			}
+			if n != len(test.recs) {
+				t.Errorf("unexpected number of records for %s: got:%d want:%d", test.name, n, len(test.recs))
+			}
+			continue
+		}
+		var oe *OrderError
+		if !errors.As(err, &oe) {
+			t.Errorf("expected order error for %s: got:%v", test.name, err)
+			continue
+		}
+		if oe.Rec.Name != test.wantBad || oe.Prev.Name != test.recs[n-1].name {
+			t.Errorf("unexpected out of order records for %s: got:%s after %s want:%s after %s",
+				test.name, oe.Rec.Name, oe.Prev.Name, test.wantBad, test.recs[n-1].name)
+		}
+		if !oe.PrevChunk.Begin.Less(oe.Chunk.Begin) {
+			t.Errorf("unexpected chunk order for %s: %v not before %v", test.name, oe.PrevChunk, oe.Chunk)
+		}
+		if _, err2 := v.Read(); err2 != err {
+			t.Errorf("expected sticky error for %s: got:%v", test.name, err2)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/Schaudge/hts/bgzf"
@@ -21,6 +22,20 @@ type Writer struct {
 
 	bg  *bgzf.Writer
 	buf bytes.Buffer
+
+	// stats, if not nil, counts the
+	// records written.
+	stats *Stats
+
+	// deterministic specifies that aux
+	// fields are written in canonical
+	// order.
+	deterministic bool
+
+	// maxRecordSize is the limit on the
+	// size of records written. If zero,
+	// DefaultMaxRecordSize is used.
+	maxRecordSize int
 }
 
 // NewWriter returns a new Writer using the given SAM header. Write
@@ -83,6 +98,51 @@ func MarshalHeader(header *sam.Header) ([]byte, error) {
 
 // Marshal serializes the record into "buf".
 func Marshal(r *sam.Record, buf *bytes.Buffer) error {
+	return marshal(r, buf, r.Ref.ID(), r.MateRef.ID(), maxBAMRecordSize)
+}
+
+// MarshalRecord returns the BAM encoding of r, as read by Unmarshal.
+// If refIDs is not nil, the IDs of the reference and mate reference of
+// r are taken from refIDs rather than from the references themselves,
+// allowing records to be encoded for a header other than the one
+// holding their references. It is an error for a reference of r to be
+// absent from a non-nil refIDs.
+func MarshalRecord(r *sam.Record, refIDs map[*sam.Reference]int) ([]byte, error) {
+	refID, mateRefID := r.Ref.ID(), r.MateRef.ID()
+	if refIDs != nil {
+		var err error
+		refID, err = lookupRefID(r.Ref, refIDs)
+		if err != nil {
+			return nil, err
+		}
+		mateRefID, err = lookupRefID(r.MateRef, refIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	err := marshal(r, &buf, refID, mateRefID, maxBAMRecordSize)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupRefID returns the ID of ref in refIDs, or -1 if ref is nil.
+func lookupRefID(ref *sam.Reference, refIDs map[*sam.Reference]int) (int, error) {
+	if ref == nil {
+		return -1, nil
+	}
+	id, ok := refIDs[ref]
+	if !ok {
+		return -1, fmt.Errorf("bam: reference %q has no ID", ref.Name())
+	}
+	return id, nil
+}
+
+// marshal serializes r into buf with the given reference and mate
+// reference IDs, returning an error if the record is larger than limit.
+func marshal(r *sam.Record, buf *bytes.Buffer, refID, mateRefID, limit int) error {
 	if len(r.Name) == 0 || len(r.Name) > 254 {
 		return errors.New("bam: name absent or too long")
 	}
@@ -90,22 +150,21 @@ func Marshal(r *sam.Record, buf *bytes.Buffer) error {
 		return errors.New("bam: sequence/quality length mismatch")
 	}
 
-	scratch := bufPool.Get().([]byte)
-	resizeScratch(&scratch, 0)
-	buildAux(r.AuxFields, &scratch)
-	tags := scratch
+	recLen := recordLen(r)
+	if recLen > limit {
+		return fmt.Errorf("bam: record %q too large: %d bytes exceeds limit of %d", r.Name, recLen, limit)
+	}
+
+	scratch := getScratch(auxLen(r))
+	*scratch = append(*scratch, r.RawAux...)
+	buildAux(r.AuxFields, scratch)
+	tags := *scratch
 	wb := errWriter{w: buf}
 	bin := binaryWriter{w: &wb}
-	recLen := bamFixedRemainder +
-		len(r.Name) + 1 + // Null terminated.
-		len(r.Cigar)<<2 + // CigarOps are 4 bytes.
-		len(r.Seq.Seq) +
-		len(r.Qual) +
-		len(tags)
 
 	// Write record header data.
 	bin.writeInt32(int32(recLen))
-	bin.writeInt32(int32(r.Ref.ID()))
+	bin.writeInt32(int32(refID))
 	bin.writeInt32(int32(r.Pos))
 	bin.writeUint8(byte(len(r.Name) + 1))
 	bin.writeUint8(r.MapQ)
@@ -113,7 +172,7 @@ func Marshal(r *sam.Record, buf *bytes.Buffer) error {
 	bin.writeUint16(uint16(len(r.Cigar)))
 	bin.writeUint16(uint16(r.Flags))
 	bin.writeInt32(int32(r.Seq.Length))
-	bin.writeInt32(int32(r.MateRef.ID()))
+	bin.writeInt32(int32(mateRefID))
 	bin.writeInt32(int32(r.MatePos))
 	bin.writeInt32(int32(r.TempLen))
 
@@ -130,20 +189,87 @@ func Marshal(r *sam.Record, buf *bytes.Buffer) error {
 		}
 	}
 	wb.Write(tags)
-	bufPool.Put(scratch)
+	putScratch(scratch)
 	return wb.err
 }
 
+// RecordSize returns the number of bytes in the BAM encoding of r
+// written by Marshal, including the leading block size, without
+// encoding the record. Marshal rejects records with a block size, the
+// RecordSize less the four bytes of the block size field, of more than
+// 0xffffff bytes since a Reader cannot read them.
+func RecordSize(r *sam.Record) int {
+	return lenFieldSize + recordLen(r)
+}
+
+// recordLen returns the block size of the BAM encoding of r, the
+// length of the encoding following its block size field.
+func recordLen(r *sam.Record) int {
+	return bamFixedRemainder +
+		len(r.Name) + 1 + // Null terminated.
+		len(r.Cigar)<<2 + // CigarOps are 4 bytes.
+		len(r.Seq.Seq) +
+		r.Seq.Length + // Quality scores are 0xff filled if absent.
+		auxLen(r)
+}
+
+// auxLen returns the length of the BAM encoding of the aux fields of
+// r.
+func auxLen(r *sam.Record) int {
+	n := len(r.RawAux)
+	for _, a := range r.AuxFields {
+		n += len(a)
+		switch a.Type() {
+		case 'Z', 'H':
+			n++ // Null terminated.
+		}
+	}
+	return n
+}
+
 // Write writes r to the BAM stream.
 func (bw *Writer) Write(r *sam.Record) error {
 	bw.buf.Reset()
-	if err := Marshal(r, &bw.buf); err != nil {
+	if bw.deterministic {
+		var err error
+		r, err = canonicalAux(r)
+		if err != nil {
+			return err
+		}
+	}
+	limit := bw.maxRecordSize
+	if limit == 0 {
+		limit = DefaultMaxRecordSize
+	}
+	if err := marshal(r, &bw.buf, r.Ref.ID(), r.MateRef.ID(), limit); err != nil {
 		return err
 	}
 	_, err := bw.bg.Write(bw.buf.Bytes())
+	if err == nil && bw.stats != nil {
+		bw.stats.addRecord(bw.buf.Len(), auxLen(r))
+	}
 	return err
 }
 
+// SetMaxRecordSize sets the limit on the encoded size of records
+// written by the Writer to n bytes. If n is zero, DefaultMaxRecordSize
+// is used. Records larger than DefaultMaxRecordSize are permitted by
+// the SAM specification, but may not be readable by other tools or by
+// Readers with the default limit.
+func (bw *Writer) SetMaxRecordSize(n int) error {
+	if n < 0 || n > MaxRecordSize {
+		return fmt.Errorf("bam: invalid record size limit: %d", n)
+	}
+	bw.maxRecordSize = n
+	return nil
+}
+
+// SetStats sets the Stats counting the records written by the Writer.
+// If s is nil, records are not counted.
+func (bw *Writer) SetStats(s *Stats) {
+	bw.stats = s
+}
+
 func writeCigarOps(bin *binaryWriter, co []sam.CigarOp) {
 	for _, o := range co {
 		bin.writeUint32(uint32(o))
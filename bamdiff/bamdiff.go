@@ -0,0 +1,527 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bamdiff implements record level comparison of two SAM or BAM
+// streams, for regression testing of the pipelines that produce them.
+//
+// Records of the two streams are matched by name and by the first and
+// last segment, secondary and supplementary flags. Secondary and
+// supplementary records are additionally matched by reference and
+// position unless those fields are ignored. Matched records are
+// compared field by field, and records without a match in the other
+// stream are reported as only present in one stream.
+package bamdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Fields is a set of record fields.
+type Fields uint16
+
+const (
+	Flags Fields = 1 << iota
+	Ref
+	Pos
+	MapQ
+	Cigar
+	MateRef
+	MatePos
+	TempLen
+	Seq
+	Qual
+	Aux
+
+	AllFields = Flags | Ref | Pos | MapQ | Cigar | MateRef | MatePos | TempLen | Seq | Qual | Aux
+)
+
+// fieldNames are the SAM column names of the fields, used by String
+// and ParseFields.
+var fieldNames = []struct {
+	field Fields
+	name  string
+}{
+	{Flags, "FLAG"},
+	{Ref, "RNAME"},
+	{Pos, "POS"},
+	{MapQ, "MAPQ"},
+	{Cigar, "CIGAR"},
+	{MateRef, "RNEXT"},
+	{MatePos, "PNEXT"},
+	{TempLen, "TLEN"},
+	{Seq, "SEQ"},
+	{Qual, "QUAL"},
+	{Aux, "AUX"},
+}
+
+// Names returns the SAM column names of the fields in f, with AUX
+// naming the aux fields.
+func (f Fields) Names() []string {
+	var names []string
+	for _, n := range fieldNames {
+		if f&n.field != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+// String returns a comma separated list of the SAM column names of the
+// fields in f.
+func (f Fields) String() string {
+	return strings.Join(f.Names(), ",")
+}
+
+// ParseFields returns the fields named in the comma separated list s,
+// using the names returned by Fields.String.
+func ParseFields(s string) (Fields, error) {
+	var f Fields
+	if s == "" {
+		return f, nil
+	}
+outer:
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		for _, n := range fieldNames {
+			if n.name == name {
+				f |= n.field
+				continue outer
+			}
+		}
+		return 0, fmt.Errorf("bamdiff: unknown field %q", name)
+	}
+	return f, nil
+}
+
+// Options specifies the behaviour of a comparison.
+type Options struct {
+	// Ignore is the set of fields that are
+	// not compared.
+	Ignore Fields
+
+	// IgnoreTags lists aux tags that are not
+	// compared. IgnoreTags has no effect if
+	// Ignore includes Aux.
+	IgnoreTags []sam.Tag
+
+	// ByName specifies that the streams are
+	// read alternately rather than in step
+	// by the sort order of their headers.
+	// Records are matched irrespective of
+	// order in both cases, but reading in
+	// step keeps only the records between
+	// the positions of the two streams in
+	// memory when the streams are coordinate
+	// or queryname sorted. ByName must be
+	// used when the streams differ in order.
+	ByName bool
+}
+
+// Source is a source of records to compare. It is satisfied by
+// *bam.Reader and *sam.Reader.
+type Source interface {
+	Header() *sam.Header
+	Read() (*sam.Record, error)
+}
+
+// Kind is a kind of difference.
+type Kind int
+
+const (
+	// Changed is a record present in both
+	// streams with differing fields.
+	Changed Kind = iota
+
+	// OnlyA and OnlyB are records only
+	// present in the first or second
+	// stream.
+	OnlyA
+	OnlyB
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case Changed:
+		return "changed"
+	case OnlyA:
+		return "only_a"
+	case OnlyB:
+		return "only_b"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Difference is a difference between two streams.
+type Difference struct {
+	Kind Kind
+	Name string
+
+	// A and B are the records from the first
+	// and second streams. B is nil for OnlyA
+	// and A is nil for OnlyB differences.
+	A, B *sam.Record
+
+	// Fields and Tags are the differing
+	// fields and aux tags of Changed
+	// differences.
+	Fields Fields
+	Tags   []sam.Tag
+}
+
+// MarshalJSON implements the json.Marshaler interface. Records are
+// represented by their SAM encoding.
+func (d Difference) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Kind   Kind     `json:"kind"`
+		Name   string   `json:"name"`
+		Fields []string `json:"fields,omitempty"`
+		Tags   []string `json:"tags,omitempty"`
+		A      string   `json:"a,omitempty"`
+		B      string   `json:"b,omitempty"`
+	}{
+		Kind:   d.Kind,
+		Name:   d.Name,
+		Fields: d.Fields.Names(),
+	}
+	for _, t := range d.Tags {
+		v.Tags = append(v.Tags, t.String())
+	}
+	var err error
+	v.A, err = samLine(d.A)
+	if err != nil {
+		return nil, err
+	}
+	v.B, err = samLine(d.B)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func samLine(r *sam.Record) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	b, err := r.MarshalText()
+	return string(b), err
+}
+
+// Summary holds the counts of a comparison.
+type Summary struct {
+	// A and B are the number of records
+	// read from each stream.
+	A int64 `json:"a"`
+	B int64 `json:"b"`
+
+	// Identical and Changed are the number
+	// of matched records without and with
+	// differences, and OnlyA and OnlyB are
+	// the number of unmatched records of
+	// each stream.
+	Identical int64 `json:"identical"`
+	Changed   int64 `json:"changed"`
+	OnlyA     int64 `json:"only_a"`
+	OnlyB     int64 `json:"only_b"`
+}
+
+// Differences returns the total number of differences.
+func (s Summary) Differences() int64 {
+	return s.Changed + s.OnlyA + s.OnlyB
+}
+
+// Compare returns the fields and aux tags that differ between a and b,
+// ignoring those specified by opts. Raw aux fields of a and b are
+// decoded. References are compared with sam.Reference.EquivalentTo, so
+// a and b may be read from different files.
+func Compare(a, b *sam.Record, opts Options) (Fields, []sam.Tag, error) {
+	var f Fields
+	if a.Flags != b.Flags {
+		f |= Flags
+	}
+	if !a.Ref.EquivalentTo(b.Ref) {
+		f |= Ref
+	}
+	if a.Pos != b.Pos {
+		f |= Pos
+	}
+	if a.MapQ != b.MapQ {
+		f |= MapQ
+	}
+	if !equalCigar(a.Cigar, b.Cigar) {
+		f |= Cigar
+	}
+	if !a.MateRef.EquivalentTo(b.MateRef) {
+		f |= MateRef
+	}
+	if a.MatePos != b.MatePos {
+		f |= MatePos
+	}
+	if a.TempLen != b.TempLen {
+		f |= TempLen
+	}
+	if a.Seq.Length != b.Seq.Length || !bytes.Equal(a.Seq.Expand(), b.Seq.Expand()) {
+		f |= Seq
+	}
+	if !bytes.Equal(a.Qual, b.Qual) {
+		f |= Qual
+	}
+	f &^= opts.Ignore
+	if opts.Ignore&Aux != 0 {
+		return f, nil, nil
+	}
+	tags, err := compareAux(a, b, opts.IgnoreTags)
+	if len(tags) != 0 {
+		f |= Aux
+	}
+	return f, tags, err
+}
+
+func equalCigar(a, b sam.Cigar) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// compareAux returns the sorted aux tags of a and b that are present in
+// only one or that have different values, ignoring the tags in ignore.
+// Values are compared by their text representation so that integer
+// values of different BAM types compare equal.
+func compareAux(a, b *sam.Record, ignore []sam.Tag) ([]sam.Tag, error) {
+	err := a.DecodeAux()
+	if err != nil {
+		return nil, err
+	}
+	err = b.DecodeAux()
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[sam.Tag]bool, len(ignore))
+	for _, t := range ignore {
+		skip[t] = true
+	}
+	values := make(map[sam.Tag]string, len(a.AuxFields))
+	for _, aux := range a.AuxFields {
+		if !skip[aux.Tag()] {
+			values[aux.Tag()] = aux.String()
+		}
+	}
+	var tags []sam.Tag
+	for _, aux := range b.AuxFields {
+		t := aux.Tag()
+		if skip[t] {
+			continue
+		}
+		v, ok := values[t]
+		if !ok || v != aux.String() {
+			tags = append(tags, t)
+		}
+		delete(values, t)
+	}
+	for t := range values {
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].String() < tags[j].String() })
+	return tags, nil
+}
+
+// Diff compares the records read from a and b, calling fn with each
+// difference found. Changed differences are reported as records are
+// matched and unmatched records are reported after both streams are
+// exhausted, those of a first, in stream order. If fn returns a non-nil
+// error, Diff stops and returns that error.
+func Diff(a, b Source, opts Options, fn func(Difference) error) (Summary, error) {
+	d := differ{opts: opts, fn: fn}
+	for i := range d.pending {
+		d.pending[i] = make(map[key][]entry)
+	}
+	var less func(x, y *sam.Record) bool
+	if !opts.ByName {
+		ao, bo := a.Header().SortOrder, b.Header().SortOrder
+		if ao != bo {
+			return d.sum, fmt.Errorf("bamdiff: sort orders differ: %v and %v", ao, bo)
+		}
+		switch ao {
+		case sam.Coordinate:
+			less = func(x, y *sam.Record) bool {
+				xi, yi := uint32(x.Ref.ID()), uint32(y.Ref.ID())
+				if xi != yi {
+					return xi < yi
+				}
+				return x.Pos < y.Pos
+			}
+		case sam.QueryName:
+			order, _ := bam.HeaderNameOrder(a.Header())
+			less = func(x, y *sam.Record) bool { return order.Less(x.Name, y.Name) }
+		}
+	}
+
+	ra, err := next(a)
+	if err != nil {
+		return d.sum, err
+	}
+	rb, err := next(b)
+	if err != nil {
+		return d.sum, err
+	}
+	for ra != nil || rb != nil {
+		var takeA bool
+		switch {
+		case rb == nil:
+			takeA = true
+		case ra == nil:
+			takeA = false
+		case less != nil:
+			takeA = !less(rb, ra)
+		default:
+			takeA = d.sum.A <= d.sum.B
+		}
+		if takeA {
+			err = d.add(0, ra)
+			if err == nil {
+				ra, err = next(a)
+			}
+		} else {
+			err = d.add(1, rb)
+			if err == nil {
+				rb, err = next(b)
+			}
+		}
+		if err != nil {
+			return d.sum, err
+		}
+	}
+	return d.sum, d.flush()
+}
+
+// next returns the next record from s, or nil at the end of the stream.
+func next(s Source) (*sam.Record, error) {
+	r, err := s.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	return r, err
+}
+
+// key identifies the records that are matched between streams.
+type key struct {
+	name    string
+	segment sam.Flags
+	ref     string
+	pos     int
+}
+
+// entry is a record awaiting a match, with its index in its stream.
+type entry struct {
+	rec   *sam.Record
+	index int64
+}
+
+const segmentFlags = sam.Read1 | sam.Read2 | sam.Secondary | sam.Supplementary
+
+// differ holds the state of a comparison.
+type differ struct {
+	opts Options
+	fn   func(Difference) error
+
+	// pending holds unmatched records of
+	// each stream.
+	pending [2]map[key][]entry
+	sum     Summary
+}
+
+// add adds r from the stream indexed by side, matching it against the
+// pending records of the other stream.
+func (d *differ) add(side int, r *sam.Record) error {
+	k := key{name: r.Name, segment: r.Flags & segmentFlags, pos: -1}
+	if r.Flags&(sam.Secondary|sam.Supplementary) != 0 {
+		if d.opts.Ignore&Ref == 0 {
+			k.ref = r.Ref.Name()
+		}
+		if d.opts.Ignore&Pos == 0 {
+			k.pos = r.Pos
+		}
+	}
+	e := entry{rec: r}
+	if side == 0 {
+		e.index = d.sum.A
+		d.sum.A++
+	} else {
+		e.index = d.sum.B
+		d.sum.B++
+	}
+
+	other := d.pending[1-side]
+	matches := other[k]
+	if len(matches) == 0 {
+		d.pending[side][k] = append(d.pending[side][k], e)
+		return nil
+	}
+	m := matches[0]
+	if len(matches) == 1 {
+		delete(other, k)
+	} else {
+		other[k] = matches[1:]
+	}
+	a, b := m.rec, r
+	if side == 0 {
+		a, b = b, a
+	}
+	f, tags, err := Compare(a, b, d.opts)
+	if err != nil {
+		return err
+	}
+	if f == 0 {
+		d.sum.Identical++
+		return nil
+	}
+	d.sum.Changed++
+	return d.fn(Difference{Kind: Changed, Name: r.Name, A: a, B: b, Fields: f, Tags: tags})
+}
+
+// flush reports the records remaining unmatched.
+func (d *differ) flush() error {
+	for side, kind := range []Kind{OnlyA, OnlyB} {
+		var unmatched []entry
+		for _, entries := range d.pending[side] {
+			unmatched = append(unmatched, entries...)
+		}
+		sort.Slice(unmatched, func(i, j int) bool { return unmatched[i].index < unmatched[j].index })
+		for _, e := range unmatched {
+			diff := Difference{Kind: kind, Name: e.rec.Name}
+			if kind == OnlyA {
+				d.sum.OnlyA++
+				diff.A = e.rec
+			} else {
+				d.sum.OnlyB++
+				diff.B = e.rec
+			}
+			err := d.fn(diff)
+			if err != nil {
+				return err
+			}
+		}
+		d.pending[side] = nil
+	}
+	return nil
+}
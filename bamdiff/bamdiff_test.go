@@ -0,0 +1,197 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bamdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+func newReader(t *testing.T, b *htstestutil.BAMBuilder) *bam.Reader {
+	t.Helper()
+	data, err := b.Build()
+	if err != nil {
+		t.Fatalf("unexpected error building BAM: %v", err)
+	}
+	br, err := bam.NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	return br
+}
+
+func diffBuilders(t *testing.T, a, b *htstestutil.BAMBuilder, opts Options) ([]Difference, Summary) {
+	t.Helper()
+	ra := newReader(t, a)
+	defer ra.Close()
+	rb := newReader(t, b)
+	defer rb.Close()
+	var diffs []Difference
+	sum, err := Diff(ra, rb, opts, func(d Difference) error {
+		diffs = append(diffs, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error comparing: %v", err)
+	}
+	return diffs, sum
+}
+
+func base(order sam.SortOrder) *htstestutil.BAMBuilder {
+	return htstestutil.NewBAMBuilder().Ref("chr1", 1000).SortOrder(order)
+}
+
+func TestDiff(t *testing.T) {
+	a := base(sam.Coordinate)
+	a.Record("r0", "chr1", 10, "4M", "ACGT").MapQ(60).Tag("NM", 0).
+		Record("r1", "chr1", 20, "4M", "ACGT").MapQ(60).Tag("NM", 1).Tag("XS", 10).
+		Record("r2", "chr1", 30, "4M", "ACGT").MapQ(60).
+		Record("r3", "chr1", 40, "4M", "ACGT").MapQ(60)
+	b := base(sam.Coordinate)
+	b.Record("r0", "chr1", 10, "4M", "ACGT").MapQ(60).Tag("NM", 0).
+		Record("r2", "chr1", 25, "2M1I1M", "ACGT").MapQ(60).
+		Record("r1", "chr1", 30, "4M", "ACGT").MapQ(30).Tag("NM", 2).
+		Record("r4", "chr1", 50, "4M", "ACGT").MapQ(60)
+
+	diffs, sum := diffBuilders(t, a, b, Options{})
+	wantSum := Summary{A: 4, B: 4, Identical: 1, Changed: 2, OnlyA: 1, OnlyB: 1}
+	if sum != wantSum {
+		t.Errorf("unexpected summary: got:%+v want:%+v", sum, wantSum)
+	}
+	type result struct {
+		kind   Kind
+		name   string
+		fields Fields
+		tags   []sam.Tag
+	}
+	want := []result{
+		{kind: Changed, name: "r2", fields: Pos | Cigar},
+		{kind: Changed, name: "r1", fields: Pos | MapQ | Aux, tags: []sam.Tag{sam.NewTag("NM"), sam.NewTag("XS")}},
+		{kind: OnlyA, name: "r3"},
+		{kind: OnlyB, name: "r4"},
+	}
+	var got []result
+	for _, d := range diffs {
+		got = append(got, result{kind: d.Kind, name: d.Name, fields: d.Fields, tags: d.Tags})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected differences:\ngot: %+v\nwant:%+v", got, want)
+	}
+
+	diffs, sum = diffBuilders(t, a, b, Options{Ignore: Pos | MapQ, IgnoreTags: []sam.Tag{sam.NewTag("XS")}})
+	if sum.Changed != 2 || len(diffs) != 4 {
+		t.Fatalf("unexpected summary with ignored fields: %+v", sum)
+	}
+	if diffs[0].Fields != Cigar {
+		t.Errorf("unexpected fields for r2: got:%v want:%v", diffs[0].Fields, Cigar)
+	}
+	if diffs[1].Fields != Aux || !reflect.DeepEqual(diffs[1].Tags, []sam.Tag{sam.NewTag("NM")}) {
+		t.Errorf("unexpected fields for r1: got:%v %v want:AUX [NM]", diffs[1].Fields, diffs[1].Tags)
+	}
+}
+
+func TestDiffByName(t *testing.T) {
+	a := base(sam.Coordinate)
+	a.Record("r0", "chr1", 10, "4M", "ACGT").Flags(sam.Paired|sam.Read1).
+		Record("r0", "chr1", 20, "4M", "ACGT").Flags(sam.Paired|sam.Read2).
+		Record("r1", "chr1", 30, "4M", "ACGT")
+	b := base(sam.QueryName)
+	b.Record("r0", "chr1", 20, "4M", "ACGT").Flags(sam.Paired|sam.Read2).
+		Record("r0", "chr1", 10, "4M", "ACGT").Flags(sam.Paired|sam.Read1).
+		Record("r1", "chr1", 30, "4M", "ACGA")
+
+	ra := newReader(t, a)
+	defer ra.Close()
+	rb := newReader(t, b)
+	defer rb.Close()
+	_, err := Diff(ra, rb, Options{}, func(Difference) error { return nil })
+	if err == nil {
+		t.Error("expected error for differing sort orders")
+	}
+
+	diffs, sum := diffBuilders(t, a, b, Options{ByName: true})
+	if sum.Identical != 2 || sum.Changed != 1 || sum.Differences() != 1 {
+		t.Errorf("unexpected summary: %+v", sum)
+	}
+	if len(diffs) != 1 || diffs[0].Name != "r1" || diffs[0].Fields != Seq {
+		t.Errorf("unexpected differences: %+v", diffs)
+	}
+}
+
+func TestDiffReferenceLength(t *testing.T) {
+	a := htstestutil.NewBAMBuilder().Ref("chr1", 1000).Ref("chr2", 500).SortOrder(sam.Coordinate)
+	a.Record("r0", "chr1", 10, "4M", "ACGT").
+		Record("r1", "chr2", 10, "4M", "ACGT")
+	b := htstestutil.NewBAMBuilder().Ref("chr1", 1000).Ref("chr2", 600).SortOrder(sam.Coordinate)
+	b.Record("r0", "chr1", 10, "4M", "ACGT").
+		Record("r1", "chr2", 10, "4M", "ACGT")
+
+	diffs, sum := diffBuilders(t, a, b, Options{})
+	if sum.Identical != 1 || sum.Changed != 1 {
+		t.Errorf("unexpected summary: %+v", sum)
+	}
+	if len(diffs) != 1 || diffs[0].Name != "r1" || diffs[0].Fields != Ref {
+		t.Errorf("unexpected differences: %+v", diffs)
+	}
+}
+
+func TestDiffStop(t *testing.T) {
+	a := base(sam.Coordinate)
+	a.Record("r0", "chr1", 10, "4M", "ACGT").
+		Record("r1", "chr1", 20, "4M", "ACGT")
+	b := base(sam.Coordinate)
+	ra := newReader(t, a)
+	defer ra.Close()
+	rb := newReader(t, b)
+	defer rb.Close()
+	errStop := errors.New("stop")
+	var n int
+	_, err := Diff(ra, rb, Options{}, func(Difference) error {
+		n++
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("unexpected error: got:%v want:%v", err, errStop)
+	}
+	if n != 1 {
+		t.Errorf("unexpected number of calls: got:%d want:1", n)
+	}
+}
+
+func TestDifferenceJSON(t *testing.T) {
+	d := Difference{Kind: Changed, Name: "r0", Fields: Pos | Aux, Tags: []sam.Tag{sam.NewTag("NM")}}
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling: %v", err)
+	}
+	want := `{"kind":"changed","name":"r0","fields":["POS","AUX"],"tags":["NM"]}`
+	if string(b) != want {
+		t.Errorf("unexpected JSON: got:%s want:%s", b, want)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	f, err := ParseFields("mapq, QUAL,AUX")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f != MapQ|Qual|Aux {
+		t.Errorf("unexpected fields: got:%v want:%v", f, MapQ|Qual|Aux)
+	}
+	if got, err := ParseFields(AllFields.String()); err != nil || got != AllFields {
+		t.Errorf("failed to round trip all fields: got:%v err:%v", got, err)
+	}
+	_, err = ParseFields("NOPE")
+	if err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
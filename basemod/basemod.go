@@ -0,0 +1,228 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package basemod implements parsing of base modification calls held
+// in MM and ML aux tags and their aggregation into per-site
+// modification frequencies in bedMethyl format.
+package basemod
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrMLLength = errors.New("basemod: ML tag length does not match MM tag")
+)
+
+var (
+	mmTag    = sam.NewTag("MM")
+	mlTag    = sam.NewTag("ML")
+	oldMMTag = sam.NewTag("Mm")
+	oldMLTag = sam.NewTag("Ml")
+)
+
+// Mode is the interpretation of bases without modification calls.
+type Mode byte
+
+const (
+	// Implicit indicates that bases without
+	// calls are unmodified.
+	Implicit Mode = '.'
+
+	// Explicit indicates that the state of
+	// bases without calls is unknown.
+	Explicit Mode = '?'
+)
+
+// Modification holds the calls for a base modification of a record.
+type Modification struct {
+	// Base is the canonical base, or N
+	// for any base, in the orientation of
+	// the sequenced read.
+	Base byte
+
+	// Strand is '+' if the modification is
+	// on the sequenced strand and '-' if it
+	// is on the complementary strand.
+	Strand byte
+
+	// Code is the modification code, a
+	// single letter or a ChEBI number.
+	Code string
+
+	Mode Mode
+
+	// Sites holds the modification calls
+	// in the order of the sequenced read.
+	Sites []Site
+}
+
+// Site is a modification call.
+type Site struct {
+	// Pos is the position of the base
+	// in the record sequence.
+	Pos int
+
+	// Prob is the likelihood of the
+	// modification, with the value p
+	// representing probabilities in the
+	// range [p/256, (p+1)/256). Prob is
+	// 255 if the record has no ML tag.
+	Prob byte
+}
+
+// Parse returns the modifications described by the MM and ML aux tags
+// of r, or by the Mm and Ml tags if MM is absent. Parse returns nil and
+// no error if r has no MM tag.
+func Parse(r *sam.Record) ([]Modification, error) {
+	mm := r.AuxTag(mmTag)
+	ml := r.AuxTag(mlTag)
+	if mm == nil {
+		mm = r.AuxTag(oldMMTag)
+		ml = r.AuxTag(oldMLTag)
+	}
+	if mm == nil {
+		return nil, nil
+	}
+	mmv, ok := mm.Value().(string)
+	if !ok {
+		return nil, errors.New("basemod: MM tag is not a string")
+	}
+	var mlv []byte
+	if ml != nil {
+		mlv, ok = ml.Value().([]uint8)
+		if !ok {
+			return nil, errors.New("basemod: ML tag is not a uint8 array")
+		}
+	}
+	return ParseTags(mmv, mlv, r.Seq.Expand(), r.Flags&sam.Reverse != 0)
+}
+
+// ParseTags returns the modifications described by the MM and ML tag
+// values mm and ml for a record with the sequence seq. If reverse is
+// true, seq is the reverse complement of the sequenced read. If ml is
+// nil, all calls have a Prob of 255.
+func ParseTags(mm string, ml []byte, seq []byte, reverse bool) ([]Modification, error) {
+	read := seq
+	if reverse {
+		read = make([]byte, len(seq))
+		for i, b := range seq {
+			read[len(seq)-1-i] = samutil.Complement(b)
+		}
+	}
+
+	var (
+		mods []Modification
+		nml  int
+	)
+	for _, entry := range strings.Split(strings.TrimSuffix(mm, ";"), ";") {
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, ",")
+		head := fields[0]
+		if len(head) < 3 || strings.IndexByte("ACGTUN", head[0]) < 0 || (head[1] != '+' && head[1] != '-') {
+			return nil, fmt.Errorf("basemod: invalid MM entry: %q", entry)
+		}
+		base, strand := head[0], head[1]
+		codes := head[2:]
+		mode := Implicit
+		if c := codes[len(codes)-1]; c == '.' || c == '?' {
+			mode = Mode(c)
+			codes = codes[:len(codes)-1]
+		}
+		var codeList []string
+		switch {
+		case codes == "":
+			return nil, fmt.Errorf("basemod: missing modification code in MM entry: %q", entry)
+		case isDigits(codes):
+			codeList = []string{codes}
+		default:
+			for i := 0; i < len(codes); i++ {
+				c := codes[i]
+				if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+					return nil, fmt.Errorf("basemod: invalid modification code in MM entry: %q", entry)
+				}
+				codeList = append(codeList, codes[i:i+1])
+			}
+		}
+
+		var pos []int
+		i := -1
+		for _, f := range fields[1:] {
+			skip, err := strconv.Atoi(f)
+			if err != nil || skip < 0 {
+				return nil, fmt.Errorf("basemod: invalid MM skip count in entry: %q", entry)
+			}
+			for n := skip; ; {
+				i++
+				for i < len(read) && !matches(base, read[i]) {
+					i++
+				}
+				if i >= len(read) {
+					return nil, fmt.Errorf("basemod: MM entry beyond end of sequence: %q", entry)
+				}
+				if n == 0 {
+					break
+				}
+				n--
+			}
+			p := i
+			if reverse {
+				p = len(read) - 1 - i
+			}
+			pos = append(pos, p)
+		}
+
+		first := len(mods)
+		for _, c := range codeList {
+			mods = append(mods, Modification{Base: base, Strand: strand, Code: c, Mode: mode, Sites: make([]Site, len(pos))})
+		}
+		for j, p := range pos {
+			for k := range codeList {
+				prob := byte(255)
+				if ml != nil {
+					if nml >= len(ml) {
+						return nil, ErrMLLength
+					}
+					prob = ml[nml]
+					nml++
+				}
+				mods[first+k].Sites[j] = Site{Pos: p, Prob: prob}
+			}
+		}
+	}
+	if ml != nil && nml != len(ml) {
+		return nil, ErrMLLength
+	}
+	return mods, nil
+}
+
+// matches returns whether the read base b is an instance of the MM
+// base.
+func matches(base, b byte) bool {
+	b &^= 0x20
+	switch base {
+	case 'N':
+		return true
+	case 'U':
+		return b == 'T' || b == 'U'
+	}
+	return b == base
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || '9' < s[i] {
+			return false
+		}
+	}
+	return true
+}
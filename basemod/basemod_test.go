@@ -0,0 +1,105 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package basemod
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestParseTags(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		mm      string
+		ml      []byte
+		seq     string
+		reverse bool
+		want    []Modification
+	}{
+		{
+			name: "forward", mm: "C+m,0,2;", ml: []byte{200, 10}, seq: "ACGCCGTCG",
+			want: []Modification{
+				{Base: 'C', Strand: '+', Code: "m", Mode: Implicit, Sites: []Site{{Pos: 1, Prob: 200}, {Pos: 7, Prob: 10}}},
+			},
+		},
+		{
+			name: "multiple codes", mm: "C+mh?,1;A+a.,0;", ml: []byte{100, 50, 7}, seq: "ACGCCGTCG",
+			want: []Modification{
+				{Base: 'C', Strand: '+', Code: "m", Mode: Explicit, Sites: []Site{{Pos: 3, Prob: 100}}},
+				{Base: 'C', Strand: '+', Code: "h", Mode: Explicit, Sites: []Site{{Pos: 3, Prob: 50}}},
+				{Base: 'A', Strand: '+', Code: "a", Mode: Implicit, Sites: []Site{{Pos: 0, Prob: 7}}},
+			},
+		},
+		{
+			name: "reverse", mm: "C+m,1", seq: "CGAAG", reverse: true,
+			want: []Modification{
+				{Base: 'C', Strand: '+', Code: "m", Mode: Implicit, Sites: []Site{{Pos: 1, Prob: 255}}},
+			},
+		},
+		{
+			name: "chebi", mm: "N-76792,2;C+m;", ml: []byte{3}, seq: "ACGT",
+			want: []Modification{
+				{Base: 'N', Strand: '-', Code: "76792", Mode: Implicit, Sites: []Site{{Pos: 2, Prob: 3}}},
+				{Base: 'C', Strand: '+', Code: "m", Mode: Implicit, Sites: []Site{}},
+			},
+		},
+	} {
+		got, err := ParseTags(test.mm, test.ml, []byte(test.seq), test.reverse)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: unexpected modifications:\ngot: %+v\nwant:%+v", test.name, got, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		mm string
+		ml []byte
+	}{
+		{mm: "C+m,5"},
+		{mm: "X+m,0"},
+		{mm: "C*m,0"},
+		{mm: "C+,0"},
+		{mm: "C+m,x"},
+		{mm: "C+m,0", ml: []byte{1, 2}},
+		{mm: "C+mh,0", ml: []byte{1}},
+	} {
+		if _, err := ParseTags(test.mm, test.ml, []byte("ACGCCGTCG"), false); err == nil {
+			t.Errorf("expected error for %q %v", test.mm, test.ml)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	var aux []sam.Aux
+	for _, s := range []string{"MM:Z:C+m,1;", "ML:B:C,42"} {
+		a, err := sam.ParseAux([]byte(s))
+		if err != nil {
+			t.Fatalf("unexpected error parsing aux: %v", err)
+		}
+		aux = append(aux, a)
+	}
+	r, err := sam.NewRecord("r", nil, nil, -1, -1, 0, 0, nil, []byte("CACG"), nil, aux)
+	if err != nil {
+		t.Fatalf("unexpected error making record: %v", err)
+	}
+	got, err := Parse(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Modification{{Base: 'C', Strand: '+', Code: "m", Mode: Implicit, Sites: []Site{{Pos: 2, Prob: 42}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected modifications:\ngot: %+v\nwant:%+v", got, want)
+	}
+
+	r.AuxFields = nil
+	got, err = Parse(r)
+	if got != nil || err != nil {
+		t.Errorf("unexpected result for record without MM tag: %v %v", got, err)
+	}
+}
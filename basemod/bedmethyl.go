@@ -0,0 +1,391 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package basemod
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+// DefaultExclude is the default set of flags of records excluded from
+// aggregation.
+const DefaultExclude = sam.Unmapped | sam.Secondary | sam.Supplementary | sam.QCFail | sam.Duplicate
+
+// Options specifies the behaviour of an Aggregator.
+type Options struct {
+	// Motif, if not empty, restricts sites
+	// to reference positions of the base at
+	// Offset in instances of the motif on
+	// either strand, for example "CG" with
+	// an Offset of zero for CpG sites.
+	Motif  string
+	Offset int
+
+	// CombineStrands specifies that calls
+	// on the negative strand are counted at
+	// the positive strand site of the same
+	// motif instance. CombineStrands requires
+	// a Motif that is its own reverse
+	// complement.
+	CombineStrands bool
+
+	// MinCoverage is the minimum number of
+	// calls at a written site.
+	MinCoverage int
+
+	// MinProb is the minimum probability of
+	// a counted call. Calls of the canonical
+	// base or a modification with a lower
+	// probability are not counted.
+	MinProb float64
+
+	// MinMapQ is the minimum mapping quality
+	// of included records.
+	MinMapQ byte
+
+	// Exclude specifies flags of records
+	// that are excluded. If Exclude is zero,
+	// DefaultExclude is used.
+	Exclude sam.Flags
+}
+
+// Counts holds the calls at a site for a modification.
+type Counts struct {
+	// Modified is the number of calls of
+	// the modification, Canonical is the
+	// number of calls of the unmodified base
+	// and Other is the number of calls of
+	// other modifications of the base.
+	Modified, Canonical, Other int
+}
+
+// Coverage returns the total number of calls.
+func (c Counts) Coverage() int { return c.Modified + c.Canonical + c.Other }
+
+// Fraction returns the fraction of calls of the modification.
+func (c Counts) Fraction() float64 {
+	if c.Coverage() == 0 {
+		return 0
+	}
+	return float64(c.Modified) / float64(c.Coverage())
+}
+
+// Frequency is the modification frequency at a reference site.
+type Frequency struct {
+	Ref string
+	Pos int
+
+	// Strand is '+' or '-', or '.' when
+	// strands are combined.
+	Strand byte
+
+	Code string
+	Counts
+}
+
+// Aggregator counts modification calls of records at reference sites.
+type Aggregator struct {
+	ref  sam.ReferenceProvider
+	opts Options
+
+	motif, rcMotif []byte
+
+	// name and seq are the name and sequence
+	// of the reference most recently used for
+	// motif matching.
+	name string
+	seq  []byte
+
+	names map[int]string
+	sites map[siteKey]*Counts
+}
+
+type siteKey struct {
+	ref    int
+	pos    int
+	strand byte
+	code   string
+}
+
+// NewAggregator returns a new Aggregator. If a motif is specified in
+// opts, reference sequences are read from ref.
+func NewAggregator(ref sam.ReferenceProvider, opts Options) (*Aggregator, error) {
+	if opts.Exclude == 0 {
+		opts.Exclude = DefaultExclude
+	}
+	a := &Aggregator{
+		ref:   ref,
+		opts:  opts,
+		names: make(map[int]string),
+		sites: make(map[siteKey]*Counts),
+	}
+	if opts.Motif != "" {
+		if ref == nil {
+			return nil, errors.New("basemod: motif requires a reference")
+		}
+		if opts.Offset < 0 || opts.Offset >= len(opts.Motif) {
+			return nil, errors.New("basemod: motif offset out of range")
+		}
+		a.motif = bytes.ToUpper([]byte(opts.Motif))
+		a.rcMotif = make([]byte, len(a.motif))
+		for i, b := range a.motif {
+			a.rcMotif[len(a.motif)-1-i] = samutil.Complement(b)
+		}
+	}
+	if opts.CombineStrands && (a.motif == nil || !bytes.Equal(a.motif, a.rcMotif)) {
+		return nil, errors.New("basemod: combining strands requires a palindromic motif")
+	}
+	return a, nil
+}
+
+// group holds the calls of the modifications of a base on a strand.
+type group struct {
+	base, strand byte
+	codes        []string
+	implicit     bool
+
+	// probs holds the call probabilities of
+	// each code indexed by sequence position.
+	probs map[int][]byte
+}
+
+// Add adds the modification calls of r.
+func (a *Aggregator) Add(r *sam.Record) error {
+	if r.Flags&a.opts.Exclude != 0 || r.MapQ < a.opts.MinMapQ || r.Ref == nil {
+		return nil
+	}
+	mods, err := Parse(r)
+	if err != nil || len(mods) == 0 {
+		return err
+	}
+
+	var groups []*group
+	for _, m := range mods {
+		var g *group
+		for _, c := range groups {
+			if c.base == m.Base && c.strand == m.Strand {
+				g = c
+				break
+			}
+		}
+		if g == nil {
+			g = &group{base: m.Base, strand: m.Strand, implicit: true, probs: make(map[int][]byte)}
+			groups = append(groups, g)
+		}
+		g.implicit = g.implicit && m.Mode == Implicit
+		k := len(g.codes)
+		g.codes = append(g.codes, m.Code)
+		for _, s := range m.Sites {
+			p := g.probs[s.Pos]
+			for len(p) <= k {
+				p = append(p, 0)
+			}
+			p[k] = s.Prob
+			g.probs[s.Pos] = p
+		}
+	}
+
+	seq := r.Seq.Expand()
+	refPos := alignedPositions(r)
+	rev := r.Flags&sam.Reverse != 0
+	for _, g := range groups {
+		plus := rev == (g.strand == '-')
+		for p, rp := range refPos {
+			if rp < 0 {
+				continue
+			}
+			probs, ok := g.probs[p]
+			if !ok {
+				b := seq[p]
+				if rev {
+					b = samutil.Complement(b)
+				}
+				if !g.implicit || !matches(g.base, b) {
+					continue
+				}
+			}
+			pos, strand, ok, err := a.site(r.Ref, rp, plus)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			// Call the canonical base or the
+			// most likely modification.
+			best, bestProb := -1, 255
+			for _, q := range probs {
+				bestProb -= int(q)
+			}
+			if bestProb < 0 {
+				bestProb = 0
+			}
+			for k, q := range probs {
+				if int(q) > bestProb {
+					best, bestProb = k, int(q)
+				}
+			}
+			if (float64(bestProb)+0.5)/256 < a.opts.MinProb {
+				continue
+			}
+			a.names[r.Ref.ID()] = r.Ref.Name()
+			for k, code := range g.codes {
+				key := siteKey{ref: r.Ref.ID(), pos: pos, strand: strand, code: code}
+				c, ok := a.sites[key]
+				if !ok {
+					c = &Counts{}
+					a.sites[key] = c
+				}
+				switch best {
+				case k:
+					c.Modified++
+				case -1:
+					c.Canonical++
+				default:
+					c.Other++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// alignedPositions returns the reference position aligned to each base
+// of the sequence of r, or -1 for bases not aligned to the reference.
+func alignedPositions(r *sam.Record) []int {
+	pos := make([]int, r.Seq.Length)
+	for i := range pos {
+		pos[i] = -1
+	}
+	q, rp := 0, r.Pos
+	for _, co := range r.Cigar {
+		n := co.Len()
+		con := co.Type().Consumes()
+		if con.Query != 0 && con.Reference != 0 {
+			for i := 0; i < n && q+i < len(pos); i++ {
+				pos[q+i] = rp + i
+			}
+		}
+		q += n * con.Query
+		rp += n * con.Reference
+	}
+	return pos
+}
+
+// site returns the reported position and strand of a call at the
+// reference position pos on the given strand, and whether the call is
+// at a motif site.
+func (a *Aggregator) site(ref *sam.Reference, pos int, plus bool) (int, byte, bool, error) {
+	strand := byte('-')
+	switch {
+	case a.opts.CombineStrands:
+		strand = '.'
+	case plus:
+		strand = '+'
+	}
+	if a.motif == nil {
+		return pos, strand, true, nil
+	}
+	if a.name != ref.Name() || a.seq == nil {
+		seq, err := a.ref.GetSequence(ref.Name(), 0, ref.Len())
+		if err != nil {
+			return 0, 0, false, err
+		}
+		a.name, a.seq = ref.Name(), bytes.ToUpper(seq)
+	}
+	n := len(a.motif)
+	var start int
+	if plus {
+		start = pos - a.opts.Offset
+		if start < 0 || start+n > len(a.seq) || !bytes.Equal(a.seq[start:start+n], a.motif) {
+			return 0, 0, false, nil
+		}
+		return pos, strand, true, nil
+	}
+	start = pos - (n - 1 - a.opts.Offset)
+	if start < 0 || start+n > len(a.seq) || !bytes.Equal(a.seq[start:start+n], a.rcMotif) {
+		return 0, 0, false, nil
+	}
+	if a.opts.CombineStrands {
+		return start + a.opts.Offset, strand, true, nil
+	}
+	return pos, strand, true, nil
+}
+
+// Merge adds the counts of o to a.
+func (a *Aggregator) Merge(o *Aggregator) {
+	for id, name := range o.names {
+		a.names[id] = name
+	}
+	for k, c := range o.sites {
+		d, ok := a.sites[k]
+		if !ok {
+			d = &Counts{}
+			a.sites[k] = d
+		}
+		d.Modified += c.Modified
+		d.Canonical += c.Canonical
+		d.Other += c.Other
+	}
+}
+
+// Frequencies returns the modification frequencies of sites with at
+// least the minimum coverage, sorted by reference, position, strand
+// and modification code.
+func (a *Aggregator) Frequencies() []Frequency {
+	keys := make([]siteKey, 0, len(a.sites))
+	for k, c := range a.sites {
+		if c.Coverage() >= a.opts.MinCoverage && c.Coverage() != 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch {
+		case a.ref != b.ref:
+			return a.ref < b.ref
+		case a.pos != b.pos:
+			return a.pos < b.pos
+		case a.strand != b.strand:
+			return a.strand < b.strand
+		}
+		return a.code < b.code
+	})
+	freqs := make([]Frequency, len(keys))
+	for i, k := range keys {
+		freqs[i] = Frequency{Ref: a.names[k.ref], Pos: k.pos, Strand: k.strand, Code: k.code, Counts: *a.sites[k]}
+	}
+	return freqs
+}
+
+// WriteTo writes the modification frequencies to w in the eleven column
+// bedMethyl format. The name column holds the modification code, and
+// the score column the coverage capped at 1000.
+func (a *Aggregator) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	for _, f := range a.Frequencies() {
+		cov := f.Coverage()
+		score := cov
+		if score > 1000 {
+			score = 1000
+		}
+		c, err := fmt.Fprintf(bw, "%s\t%d\t%d\t%s\t%d\t%c\t%d\t%d\t255,0,0\t%d\t%.2f\n",
+			f.Ref, f.Pos, f.Pos+1, f.Code, score, f.Strand, f.Pos, f.Pos+1, cov, 100*f.Fraction())
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
@@ -0,0 +1,128 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package basemod
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/reference"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestAggregator(t *testing.T) {
+	ref := reference.NewMemory()
+	err := ref.Add("chr1", []byte("ACGTTACGAA"))
+	if err != nil {
+		t.Fatalf("unexpected error adding reference: %v", err)
+	}
+	builder := htstestutil.NewBAMBuilder().Ref("chr1", 10)
+	// The unlisted C at 6 is implicitly canonical.
+	builder.Record("r", "chr1", 0, "10M", "ACGTTACGAA").Tag("MM", "C+m.,0;").Tag("ML", []uint8{230}).
+		// Read Cs are the Gs at 7 and 2.
+		Record("r", "chr1", 0, "10M", "ACGTTACGAA").Flags(sam.Reverse).Tag("MM", "C+m,0,0;").Tag("ML", []uint8{240, 240}).
+		// The C at 0 is not in a CpG.
+		Record("r", "chr1", 0, "10M", "CCGTTACGAA").Tag("MM", "C+m,0,0,0;").Tag("ML", []uint8{255, 255, 255}).
+		Record("r", "chr1", 0, "10M", "ACGTTACGAA").Flags(sam.Duplicate).Tag("MM", "C+m,0,0;").Tag("ML", []uint8{255, 255}).
+		Record("r", "chr1", 3, "3M", "TTA").Tag("MM", "C+m;")
+	h, err := builder.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	recs, err := builder.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+
+	for _, test := range []struct {
+		name string
+		opts Options
+		want []Frequency
+	}{
+		{
+			name: "all",
+			opts: Options{},
+			want: []Frequency{
+				{Ref: "chr1", Pos: 0, Strand: '+', Code: "m", Counts: Counts{Modified: 1}},
+				{Ref: "chr1", Pos: 1, Strand: '+', Code: "m", Counts: Counts{Modified: 2}},
+				{Ref: "chr1", Pos: 2, Strand: '-', Code: "m", Counts: Counts{Modified: 1}},
+				{Ref: "chr1", Pos: 6, Strand: '+', Code: "m", Counts: Counts{Modified: 1, Canonical: 1}},
+				{Ref: "chr1", Pos: 7, Strand: '-', Code: "m", Counts: Counts{Modified: 1}},
+			},
+		},
+		{
+			name: "cpg",
+			opts: Options{Motif: "CG"},
+			want: []Frequency{
+				{Ref: "chr1", Pos: 1, Strand: '+', Code: "m", Counts: Counts{Modified: 2}},
+				{Ref: "chr1", Pos: 2, Strand: '-', Code: "m", Counts: Counts{Modified: 1}},
+				{Ref: "chr1", Pos: 6, Strand: '+', Code: "m", Counts: Counts{Modified: 1, Canonical: 1}},
+				{Ref: "chr1", Pos: 7, Strand: '-', Code: "m", Counts: Counts{Modified: 1}},
+			},
+		},
+		{
+			name: "combined",
+			opts: Options{Motif: "CG", CombineStrands: true, MinCoverage: 3},
+			want: []Frequency{
+				{Ref: "chr1", Pos: 1, Strand: '.', Code: "m", Counts: Counts{Modified: 3}},
+				{Ref: "chr1", Pos: 6, Strand: '.', Code: "m", Counts: Counts{Modified: 2, Canonical: 1}},
+			},
+		},
+		{
+			name: "min prob",
+			opts: Options{Motif: "CG", MinProb: 0.95},
+			want: []Frequency{
+				{Ref: "chr1", Pos: 1, Strand: '+', Code: "m", Counts: Counts{Modified: 1}},
+				{Ref: "chr1", Pos: 6, Strand: '+', Code: "m", Counts: Counts{Modified: 1, Canonical: 1}},
+			},
+		},
+	} {
+		a, err := NewAggregator(ref, test.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error making aggregator: %v", test.name, err)
+		}
+		b, _ := NewAggregator(ref, test.opts)
+		for i, r := range recs {
+			agg := a
+			if i%2 != 0 {
+				agg = b
+			}
+			err := agg.Add(r)
+			if err != nil {
+				t.Fatalf("%s: unexpected error adding record: %v", test.name, err)
+			}
+		}
+		a.Merge(b)
+		got := a.Frequencies()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: unexpected frequencies:\ngot: %+v\nwant:%+v", test.name, got, test.want)
+		}
+
+		if test.name == "combined" {
+			var buf bytes.Buffer
+			_, err = a.WriteTo(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error writing bedMethyl: %v", err)
+			}
+			const want = "chr1\t1\t2\tm\t3\t.\t1\t2\t255,0,0\t3\t100.00\n" +
+				"chr1\t6\t7\tm\t3\t.\t6\t7\t255,0,0\t3\t66.67\n"
+			if buf.String() != want {
+				t.Errorf("unexpected bedMethyl:\ngot:\n%s\nwant:\n%s", &buf, want)
+			}
+		}
+	}
+
+	for _, opts := range []Options{
+		{Motif: "CG", Offset: 2},
+		{Motif: "CA", CombineStrands: true},
+		{CombineStrands: true},
+	} {
+		if _, err := NewAggregator(ref, opts); err == nil {
+			t.Errorf("expected error for options %+v", opts)
+		}
+	}
+}
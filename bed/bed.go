@@ -0,0 +1,305 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bed implements BED format reading and writing, and overlap
+// queries over sets of BED features.
+//
+// The BED format is described in the BED specification.
+//
+// https://samtools.github.io/hts-specs/BEDv1.pdf
+package bed
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFields = errors.New("bed: invalid number of fields")
+	ErrBlocks = errors.New("bed: inconsistent block fields")
+)
+
+// Feature is a BED feature. Start, End, ThickStart and ThickEnd are
+// zero-based half-open coordinates. Only the first Fields columns of
+// the feature are meaningful; the remaining standard fields are left
+// as their zero values.
+type Feature struct {
+	Chrom      string
+	Start, End int
+
+	Name       string
+	Score      int
+	Strand     byte
+	ThickStart int
+	ThickEnd   int
+	ItemRGB    string
+
+	// BlockSizes and BlockStarts are the sizes
+	// of the feature's blocks and their starts
+	// relative to Start.
+	BlockSizes  []int
+	BlockStarts []int
+
+	// Fields is the number of standard BED fields
+	// held by the feature, from 3 to 12.
+	Fields int
+
+	// Extra holds any non-standard fields that
+	// follow the standard fields.
+	Extra []string
+}
+
+// Len returns the length of the feature.
+func (f *Feature) Len() int { return f.End - f.Start }
+
+// UnmarshalText parses a single BED line into f. Fields are separated
+// by tabs or, if the line has no tabs, by runs of white space.
+//
+// The greatest number of standard fields, 3, 4, 5, 6, 8, 9 or 12, that
+// the line can hold is parsed and any remaining fields are held in
+// Extra. If the seventh and later fields are not valid standard
+// fields, as in BED6+4 formats such as narrowPeak, they are all held
+// in Extra.
+func (f *Feature) UnmarshalText(b []byte) error {
+	var fields []string
+	if bytes.IndexByte(b, '\t') >= 0 {
+		fields = strings.Split(string(b), "\t")
+	} else {
+		fields = strings.Fields(string(b))
+	}
+	if len(fields) < 3 {
+		return ErrFields
+	}
+	var n int
+	for _, c := range []int{12, 9, 8, 6, 5, 4, 3} {
+		if c <= len(fields) {
+			n = c
+			break
+		}
+	}
+	err := f.parse(fields, n)
+	if err != nil && n > 6 {
+		if f.parse(fields, 6) == nil {
+			err = nil
+		}
+	}
+	return err
+}
+
+// parse parses the first n fields of a BED line as standard fields
+// and holds the remaining fields in Extra.
+func (f *Feature) parse(fields []string, n int) error {
+	*f = Feature{Chrom: fields[0], Fields: n}
+	var err error
+	f.Start, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return fmt.Errorf("bed: invalid start: %v", err)
+	}
+	f.End, err = strconv.Atoi(fields[2])
+	if err != nil {
+		return fmt.Errorf("bed: invalid end: %v", err)
+	}
+	if f.Start < 0 || f.End < f.Start {
+		return fmt.Errorf("bed: invalid interval [%d,%d)", f.Start, f.End)
+	}
+	if n >= 4 {
+		f.Name = fields[3]
+	}
+	if n >= 5 && fields[4] != "." {
+		f.Score, err = strconv.Atoi(fields[4])
+		if err != nil {
+			return fmt.Errorf("bed: invalid score: %v", err)
+		}
+	}
+	if n >= 6 {
+		s := fields[5]
+		if s != "+" && s != "-" && s != "." {
+			return fmt.Errorf("bed: invalid strand: %q", s)
+		}
+		f.Strand = s[0]
+	}
+	if n >= 8 {
+		f.ThickStart, err = strconv.Atoi(fields[6])
+		if err != nil {
+			return fmt.Errorf("bed: invalid thick start: %v", err)
+		}
+		f.ThickEnd, err = strconv.Atoi(fields[7])
+		if err != nil {
+			return fmt.Errorf("bed: invalid thick end: %v", err)
+		}
+	}
+	if n >= 9 {
+		f.ItemRGB = fields[8]
+	}
+	if n == 12 {
+		count, err := strconv.Atoi(fields[9])
+		if err != nil {
+			return fmt.Errorf("bed: invalid block count: %v", err)
+		}
+		f.BlockSizes, err = parseList(fields[10])
+		if err != nil {
+			return fmt.Errorf("bed: invalid block sizes: %v", err)
+		}
+		f.BlockStarts, err = parseList(fields[11])
+		if err != nil {
+			return fmt.Errorf("bed: invalid block starts: %v", err)
+		}
+		if len(f.BlockSizes) != count || len(f.BlockStarts) != count {
+			return ErrBlocks
+		}
+	}
+	if len(fields) > n {
+		f.Extra = fields[n:]
+	}
+	return nil
+}
+
+// parseList parses a comma-separated list of integers. A trailing
+// comma is permitted.
+func parseList(s string) ([]int, error) {
+	s = strings.TrimSuffix(s, ",")
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	v := make([]int, len(parts))
+	for i, p := range parts {
+		var err error
+		v[i], err = strconv.Atoi(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// MarshalText returns the BED text representation of f holding f.Fields
+// standard fields followed by any extra fields. If f.Fields is zero, 3
+// standard fields are written.
+func (f *Feature) MarshalText() ([]byte, error) {
+	n := f.Fields
+	if n == 0 {
+		n = 3
+	}
+	if n < 3 || n == 7 || n == 10 || n == 11 || n > 12 {
+		return nil, ErrFields
+	}
+	if n == 12 && len(f.BlockSizes) != len(f.BlockStarts) {
+		return nil, ErrBlocks
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\t%d\t%d", f.Chrom, f.Start, f.End)
+	if n >= 4 {
+		fmt.Fprintf(&buf, "\t%s", f.Name)
+	}
+	if n >= 5 {
+		fmt.Fprintf(&buf, "\t%d", f.Score)
+	}
+	if n >= 6 {
+		strand := f.Strand
+		if strand == 0 {
+			strand = '.'
+		}
+		fmt.Fprintf(&buf, "\t%c", strand)
+	}
+	if n >= 8 {
+		fmt.Fprintf(&buf, "\t%d\t%d", f.ThickStart, f.ThickEnd)
+	}
+	if n >= 9 {
+		rgb := f.ItemRGB
+		if rgb == "" {
+			rgb = "0"
+		}
+		fmt.Fprintf(&buf, "\t%s", rgb)
+	}
+	if n == 12 {
+		fmt.Fprintf(&buf, "\t%d\t%s\t%s", len(f.BlockSizes), formatList(f.BlockSizes), formatList(f.BlockStarts))
+	}
+	for _, e := range f.Extra {
+		fmt.Fprintf(&buf, "\t%s", e)
+	}
+	return buf.Bytes(), nil
+}
+
+func formatList(v []int) string {
+	var sb strings.Builder
+	for _, e := range v {
+		sb.WriteString(strconv.Itoa(e))
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+// Reader implements BED format reading.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a new Reader, reading from the given io.Reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next Feature in the BED stream. Blank lines,
+// comment lines and track and browser lines are skipped.
+func (r *Reader) Read() (*Feature, error) {
+	for {
+		b, err := r.r.ReadBytes('\n')
+		if len(b) == 0 {
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		r.line++
+		b = bytes.TrimSuffix(b, []byte{'\n'})
+		b = bytes.TrimSuffix(b, []byte{'\r'})
+		if isHeader(b) {
+			continue
+		}
+		var f Feature
+		err = f.UnmarshalText(b)
+		if err != nil {
+			return nil, fmt.Errorf("%v at line %d", err, r.line)
+		}
+		return &f, nil
+	}
+}
+
+// isHeader returns whether b is a BED line that does not hold a feature.
+func isHeader(b []byte) bool {
+	return len(bytes.TrimSpace(b)) == 0 ||
+		b[0] == '#' ||
+		bytes.HasPrefix(b, []byte("track")) ||
+		bytes.HasPrefix(b, []byte("browser"))
+}
+
+// Writer implements BED format writing.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer to the given io.Writer.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes f to the BED stream.
+func (w *Writer) Write(f *Feature) error {
+	b, err := f.MarshalText()
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(b, '\n'))
+	return err
+}
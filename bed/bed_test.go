@@ -0,0 +1,245 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bed
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFeature(t *testing.T) {
+	for _, test := range []struct {
+		line string
+		want Feature
+		text string
+	}{
+		{
+			line: "chr1\t10\t20",
+			want: Feature{Chrom: "chr1", Start: 10, End: 20, Fields: 3},
+		},
+		{
+			line: "chr1 10 20 a",
+			want: Feature{Chrom: "chr1", Start: 10, End: 20, Name: "a", Fields: 4},
+			text: "chr1\t10\t20\ta",
+		},
+		{
+			line: "chr1\t10\t20\ta\t.\t-",
+			want: Feature{Chrom: "chr1", Start: 10, End: 20, Name: "a", Strand: '-', Fields: 6},
+			text: "chr1\t10\t20\ta\t0\t-",
+		},
+		{
+			line: "chr1\t10\t20\ta\t5\t+\t12\t18\t255,0,0",
+			want: Feature{Chrom: "chr1", Start: 10, End: 20, Name: "a", Score: 5, Strand: '+', ThickStart: 12, ThickEnd: 18, ItemRGB: "255,0,0", Fields: 9},
+		},
+		{
+			line: "chr1\t10\t100\ta\t5\t+\t12\t18\t0\t2\t5,10,\t0,80,",
+			want: Feature{
+				Chrom: "chr1", Start: 10, End: 100, Name: "a", Score: 5, Strand: '+',
+				ThickStart: 12, ThickEnd: 18, ItemRGB: "0",
+				BlockSizes: []int{5, 10}, BlockStarts: []int{0, 80},
+				Fields: 12,
+			},
+		},
+		{
+			line: "chr1\t10\t100\ta\t5\t+\t12\t18\t0\t1\t90\t0\tx\ty",
+			want: Feature{
+				Chrom: "chr1", Start: 10, End: 100, Name: "a", Score: 5, Strand: '+',
+				ThickStart: 12, ThickEnd: 18, ItemRGB: "0",
+				BlockSizes: []int{90}, BlockStarts: []int{0},
+				Fields: 12, Extra: []string{"x", "y"},
+			},
+			text: "chr1\t10\t100\ta\t5\t+\t12\t18\t0\t1\t90,\t0,\tx\ty",
+		},
+		{
+			// narrowPeak is BED6+4.
+			line: "chr1\t10\t20\tpeak\t900\t.\t18.2\t-1\t4.5\t5",
+			want: Feature{
+				Chrom: "chr1", Start: 10, End: 20, Name: "peak", Score: 900, Strand: '.',
+				Fields: 6, Extra: []string{"18.2", "-1", "4.5", "5"},
+			},
+		},
+		{
+			line: "chr1\t10\t20\ta\t5\t+\tx",
+			want: Feature{Chrom: "chr1", Start: 10, End: 20, Name: "a", Score: 5, Strand: '+', Fields: 6, Extra: []string{"x"}},
+		},
+	} {
+		var f Feature
+		err := f.UnmarshalText([]byte(test.line))
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(f, test.want) {
+			t.Errorf("unexpected feature for %q:\ngot: %+v\nwant:%+v", test.line, f, test.want)
+		}
+		text, err := f.MarshalText()
+		if err != nil {
+			t.Errorf("unexpected error marshaling %q: %v", test.line, err)
+			continue
+		}
+		want := test.text
+		if want == "" {
+			want = test.line
+		}
+		if string(text) != want {
+			t.Errorf("unexpected text:\ngot: %q\nwant:%q", text, want)
+		}
+	}
+}
+
+func TestFeatureErrors(t *testing.T) {
+	for _, line := range []string{
+		"chr1\t10",
+		"chr1\tx\t20",
+		"chr1\t10\ty",
+		"chr1\t20\t10",
+		"chr1\t-1\t10",
+		"chr1\t10\t20\ta\tx",
+		"chr1\t10\t20\ta\t0\tx",
+	} {
+		var f Feature
+		err := f.UnmarshalText([]byte(line))
+		if err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+	for _, f := range []Feature{
+		{Chrom: "chr1", End: 1, Fields: 7},
+		{Chrom: "chr1", End: 1, Fields: 12, BlockSizes: []int{1}},
+	} {
+		_, err := f.MarshalText()
+		if err == nil {
+			t.Errorf("expected error for %+v", f)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	const bed = "browser position chr1:1-100\n" +
+		"track name=test\n" +
+		"# comment\n" +
+		"chr1\t0\t10\ta\n" +
+		"\n" +
+		"chr2\t5\t15\tb\r\n" +
+		"chr1\t20\t30\tc"
+	r := NewReader(strings.NewReader(bed))
+	var names []string
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+		names = append(names, f.Name)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected features: got:%v want:%v", names, want)
+	}
+
+	r = NewReader(strings.NewReader("chr1\t0\t10\nchr1\t10\n"))
+	_, err := r.Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading first line: %v", err)
+	}
+	_, err = r.Read()
+	if err == nil || !strings.HasSuffix(err.Error(), "at line 2") {
+		t.Errorf("unexpected error for invalid line: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	err = w.Write(&Feature{Chrom: "chr1", Start: 1, End: 2, Name: "x", Fields: 4})
+	if err != nil {
+		t.Fatalf("unexpected error writing: %v", err)
+	}
+	if buf.String() != "chr1\t1\t2\tx\n" {
+		t.Errorf("unexpected output: %q", &buf)
+	}
+}
+
+func TestTree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 10, 1000} {
+		features := make([]*Feature, n)
+		for i := range features {
+			start := rnd.Intn(10000)
+			features[i] = &Feature{Chrom: "chr1", Start: start, End: start + rnd.Intn(500)}
+		}
+		all := append([]*Feature(nil), features...)
+		tree := NewTree(features)
+		if tree.Len() != n {
+			t.Errorf("unexpected tree length: got:%d want:%d", tree.Len(), n)
+		}
+		for i := 0; i < 200; i++ {
+			beg := rnd.Intn(11000) - 500
+			end := beg + rnd.Intn(1000)
+			want := make(map[*Feature]bool)
+			for _, f := range all {
+				if f.Start < end && f.End > beg {
+					want[f] = true
+				}
+			}
+			got := tree.Overlapping(beg, end)
+			if len(got) != len(want) {
+				t.Errorf("unexpected number of overlaps for [%d,%d): got:%d want:%d", beg, end, len(got), len(want))
+			}
+			for j, f := range got {
+				if !want[f] {
+					t.Errorf("unexpected overlap for [%d,%d): %+v", beg, end, f)
+				}
+				if j != 0 && got[j-1].Start > f.Start {
+					t.Errorf("overlaps not in start order for [%d,%d)", beg, end)
+				}
+			}
+			if tree.Overlaps(beg, end) != (len(want) != 0) {
+				t.Errorf("unexpected overlap state for [%d,%d)", beg, end)
+			}
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	const bed = "chr2\t100\t200\ta\n" +
+		"chr1\t0\t50\tb\n" +
+		"chr2\t150\t300\tc\n" +
+		"chrUn\t0\t10\td\n"
+	s, err := ReadSet(strings.NewReader(bed))
+	if err != nil {
+		t.Fatalf("unexpected error reading set: %v", err)
+	}
+	if want := []string{"chr2", "chr1", "chrUn"}; !reflect.DeepEqual(s.Chroms(), want) {
+		t.Errorf("unexpected chromosomes: got:%v want:%v", s.Chroms(), want)
+	}
+	var names []string
+	for _, f := range s.Overlapping("chr2", 160, 170) {
+		names = append(names, f.Name)
+	}
+	if want := []string{"a", "c"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected overlaps: got:%v want:%v", names, want)
+	}
+	if s.Overlaps("chr1", 50, 60) {
+		t.Error("unexpected overlap at feature end")
+	}
+	if s.Overlaps("chr3", 0, 100) || s.Overlapping("chr3", 0, 100) != nil {
+		t.Error("unexpected overlap on missing chromosome")
+	}
+	if s.Tree("chr3") != nil || s.Tree("chr2").Len() != 2 {
+		t.Error("unexpected trees")
+	}
+
+	var starts []int
+	for _, f := range s.Tree("chr2").Features() {
+		starts = append(starts, f.Start)
+	}
+	if want := []int{100, 150}; !reflect.DeepEqual(starts, want) {
+		t.Errorf("unexpected feature starts: got:%v want:%v", starts, want)
+	}
+}
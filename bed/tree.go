@@ -0,0 +1,164 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bed
+
+import (
+	"io"
+	"sort"
+)
+
+// Tree is a static interval tree holding the features of a single
+// chromosome. Overlap queries take O(log n + k) time for k results.
+//
+// The tree is an implicit balanced binary tree over the features sorted
+// by start position, with each node augmented by the greatest end
+// position within its subtree.
+type Tree struct {
+	features []*Feature
+	maxEnd   []int
+}
+
+// NewTree returns a Tree holding the given features. The features are
+// not checked to be on the same chromosome. The order of the provided
+// slice is altered.
+func NewTree(features []*Feature) *Tree {
+	sort.SliceStable(features, func(i, j int) bool {
+		return features[i].Start < features[j].Start
+	})
+	t := &Tree{features: features, maxEnd: make([]int, len(features))}
+	t.augment(0, len(features))
+	return t
+}
+
+// augment fills maxEnd for the subtree rooted at the midpoint of
+// [lo,hi) and returns the greatest end in the subtree.
+func (t *Tree) augment(lo, hi int) int {
+	if lo >= hi {
+		return -1
+	}
+	mid := int(uint(lo+hi) >> 1)
+	m := t.features[mid].End
+	if e := t.augment(lo, mid); e > m {
+		m = e
+	}
+	if e := t.augment(mid+1, hi); e > m {
+		m = e
+	}
+	t.maxEnd[mid] = m
+	return m
+}
+
+// Features returns the features held by the Tree in start order. The
+// returned slice must not be modified.
+func (t *Tree) Features() []*Feature { return t.features }
+
+// Len returns the number of features held by the Tree.
+func (t *Tree) Len() int { return len(t.features) }
+
+// Overlapping returns the features that overlap the zero-based
+// half-open interval [beg,end), in start order.
+func (t *Tree) Overlapping(beg, end int) []*Feature {
+	var found []*Feature
+	t.do(0, len(t.features), beg, end, func(f *Feature) bool {
+		found = append(found, f)
+		return false
+	})
+	return found
+}
+
+// Overlaps returns whether any feature overlaps the zero-based
+// half-open interval [beg,end).
+func (t *Tree) Overlaps(beg, end int) bool {
+	return t.do(0, len(t.features), beg, end, func(*Feature) bool { return true })
+}
+
+// do calls fn on each feature in the subtree rooted at the midpoint of
+// [lo,hi) that overlaps [beg,end), in start order, until fn returns
+// true. It returns whether fn returned true.
+func (t *Tree) do(lo, hi, beg, end int, fn func(*Feature) bool) bool {
+	if lo >= hi || beg >= end {
+		return false
+	}
+	mid := int(uint(lo+hi) >> 1)
+	if t.maxEnd[mid] <= beg {
+		return false
+	}
+	if t.do(lo, mid, beg, end, fn) {
+		return true
+	}
+	f := t.features[mid]
+	if f.Start >= end {
+		return false
+	}
+	if f.End > beg && fn(f) {
+		return true
+	}
+	return t.do(mid+1, hi, beg, end, fn)
+}
+
+// Set is a collection of BED features held in per-chromosome
+// interval trees.
+type Set struct {
+	chroms []string
+	trees  map[string]*Tree
+}
+
+// NewSet returns a Set holding the given features. Chromosomes are
+// retained in the order they first appear in features.
+func NewSet(features []*Feature) *Set {
+	s := &Set{trees: make(map[string]*Tree)}
+	byChrom := make(map[string][]*Feature)
+	for _, f := range features {
+		if _, ok := byChrom[f.Chrom]; !ok {
+			s.chroms = append(s.chroms, f.Chrom)
+		}
+		byChrom[f.Chrom] = append(byChrom[f.Chrom], f)
+	}
+	for c, fs := range byChrom {
+		s.trees[c] = NewTree(fs)
+	}
+	return s
+}
+
+// ReadSet returns a Set holding all the features read from r.
+func ReadSet(r io.Reader) (*Set, error) {
+	br := NewReader(r)
+	var features []*Feature
+	for {
+		f, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, f)
+	}
+	return NewSet(features), nil
+}
+
+// Chroms returns the names of the chromosomes with features in the Set.
+func (s *Set) Chroms() []string { return s.chroms }
+
+// Tree returns the interval tree for the named chromosome, or nil if the
+// Set holds no features on the chromosome.
+func (s *Set) Tree(chrom string) *Tree { return s.trees[chrom] }
+
+// Overlapping returns the features on the named chromosome that overlap
+// the zero-based half-open interval [beg,end), in start order.
+func (s *Set) Overlapping(chrom string, beg, end int) []*Feature {
+	t, ok := s.trees[chrom]
+	if !ok {
+		return nil
+	}
+	return t.Overlapping(beg, end)
+}
+
+// Overlaps returns whether any feature on the named chromosome overlaps
+// the zero-based half-open interval [beg,end).
+func (s *Set) Overlaps(chrom string, beg, end int) bool {
+	t, ok := s.trees[chrom]
+	return ok && t.Overlaps(beg, end)
+}
@@ -0,0 +1,229 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/Schaudge/grailbase/compress/libdeflate"
+	kflate "github.com/klauspost/compress/flate"
+	kgzip "github.com/klauspost/compress/gzip"
+)
+
+// Backend specifies the DEFLATE implementation used to compress
+// and decompress BGZF blocks.
+type Backend int
+
+const (
+	// Libdeflate uses the libdeflate library. When cgo is not
+	// available this falls back to compress/flate. Libdeflate
+	// is the default backend.
+	Libdeflate Backend = iota
+
+	// Stdlib uses the Go standard library compress/flate package.
+	Stdlib
+
+	// Klauspost uses the github.com/klauspost/compress/flate package.
+	Klauspost
+)
+
+func (b Backend) String() string {
+	switch b {
+	case Libdeflate:
+		return "libdeflate"
+	case Stdlib:
+		return "stdlib"
+	case Klauspost:
+		return "klauspost"
+	}
+	return fmt.Sprintf("Backend(%d)", int(b))
+}
+
+func (b Backend) valid() bool {
+	return Libdeflate <= b && b <= Klauspost
+}
+
+// memberWriter is a gzip member writer.
+type memberWriter interface {
+	io.WriteCloser
+	Reset(io.Writer)
+	setHeader(gzip.Header)
+}
+
+// newMemberWriter returns a gzip member writer using the given backend
+// and compression level writing to w.
+func newMemberWriter(b Backend, w io.Writer, level int) (memberWriter, error) {
+	switch b {
+	case Libdeflate:
+		ld, err := libdeflate.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, err
+		}
+		return libdeflateWriter{ld}, nil
+	case Stdlib:
+		gz, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, err
+		}
+		return stdlibWriter{gz}, nil
+	case Klauspost:
+		gz, err := kgzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, err
+		}
+		return klauspostWriter{gz}, nil
+	}
+	return nil, fmt.Errorf("bgzf: invalid backend: %v", b)
+}
+
+// writerPools holds idle gzip member writers for each backend and
+// compression level, so that their compression state, including the
+// hash tables of flate writers, is reused by later Writers rather
+// than reallocated.
+var writerPools [Klauspost + 1][gzip.BestCompression - gzip.DefaultCompression + 1]sync.Pool
+
+// getMemberWriter returns a gzip member writer using the given backend
+// and compression level writing to w, reusing a pooled writer if one
+// is available.
+func getMemberWriter(b Backend, w io.Writer, level int) (memberWriter, error) {
+	if b.valid() && gzip.DefaultCompression <= level && level <= gzip.BestCompression {
+		if mw, ok := writerPools[b][level-gzip.DefaultCompression].Get().(memberWriter); ok {
+			mw.Reset(w)
+			return mw, nil
+		}
+	}
+	return newMemberWriter(b, w, level)
+}
+
+// putMemberWriter returns mw, created by getMemberWriter with the given
+// backend and level, to its pool.
+func putMemberWriter(b Backend, level int, mw memberWriter) {
+	if !b.valid() || level < gzip.DefaultCompression || gzip.BestCompression < level {
+		return
+	}
+	mw.Reset(io.Discard)
+	writerPools[b][level-gzip.DefaultCompression].Put(mw)
+}
+
+type libdeflateWriter struct{ *libdeflate.Writer }
+
+func (w libdeflateWriter) setHeader(h gzip.Header) { w.Header = h }
+
+type stdlibWriter struct{ *gzip.Writer }
+
+func (w stdlibWriter) setHeader(h gzip.Header) { w.Header = h }
+
+type klauspostWriter struct{ *kgzip.Writer }
+
+func (w klauspostWriter) setHeader(h gzip.Header) {
+	w.Header = kgzip.Header{
+		Comment: h.Comment,
+		Extra:   h.Extra,
+		ModTime: h.ModTime,
+		Name:    h.Name,
+		OS:      h.OS,
+	}
+}
+
+// inflate decompresses in into out using the given backend.
+func inflate(b Backend, out, in []byte) (int, error) {
+	switch b {
+	case Libdeflate:
+		dd, err := getLibdeflate()
+		if err != nil {
+			return 0, err
+		}
+		defer libdeflatePool.Put(dd)
+		return dd.Decompress(out, in)
+	case Stdlib:
+		return inflateFlate(&stdlibPool, in, out, func(r io.Reader) io.ReadCloser { return flate.NewReader(r) })
+	case Klauspost:
+		return inflateFlate(&klauspostPool, in, out, func(r io.Reader) io.ReadCloser { return kflate.NewReader(r) })
+	}
+	return 0, fmt.Errorf("bgzf: invalid backend: %v", b)
+}
+
+// libdeflatePool holds initialised libdeflate decompressors. The C
+// workspace of a decompressor is released by a finalizer when the
+// decompressor is dropped from the pool.
+var libdeflatePool sync.Pool
+
+func getLibdeflate() (*libdeflate.Decompressor, error) {
+	if dd, ok := libdeflatePool.Get().(*libdeflate.Decompressor); ok {
+		return dd, nil
+	}
+	dd := new(libdeflate.Decompressor)
+	err := dd.Init()
+	if err != nil {
+		return nil, err
+	}
+	runtime.SetFinalizer(dd, (*libdeflate.Decompressor).Cleanup)
+	return dd, nil
+}
+
+// pooledFlate is a reusable flate decompressor reading from src.
+type pooledFlate struct {
+	src bytes.Reader
+	r   io.ReadCloser
+}
+
+var stdlibPool, klauspostPool sync.Pool
+
+// inflateFlate decompresses in into out using a flate decompressor
+// from the given pool, creating one with newReader if none is available.
+func inflateFlate(pool *sync.Pool, in, out []byte, newReader func(io.Reader) io.ReadCloser) (int, error) {
+	f, ok := pool.Get().(*pooledFlate)
+	if ok {
+		f.src.Reset(in)
+		err := f.r.(flate.Resetter).Reset(&f.src, nil)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		f = &pooledFlate{}
+		f.src.Reset(in)
+		f.r = newReader(&f.src)
+	}
+	n, err := readToEOF(f.r, out)
+	f.src.Reset(nil)
+	pool.Put(f)
+	return n, err
+}
+
+// readToEOF reads the complete DEFLATE stream from r into out.
+func readToEOF(r io.Reader, out []byte) (int, error) {
+	var (
+		n   int
+		err error
+	)
+	for err == nil && n < len(out) {
+		var _n int
+		_n, err = r.Read(out[n:])
+		n += _n
+	}
+	switch {
+	case err == io.EOF:
+		return n, nil
+	case err != nil:
+		return n, err
+	}
+	// Ensure the stream does not extend beyond the
+	// capacity of out.
+	var b [1]byte
+	_, err = r.Read(b[:])
+	switch err {
+	case nil:
+		return n, io.ErrShortBuffer
+	case io.EOF:
+		return n, nil
+	}
+	return n, err
+}
@@ -210,6 +210,45 @@ func TestRoundTrip(t *testing.T) {
 	}
 }
 
+// TestRoundTripBackends tests that data compressed by each DEFLATE
+// backend can be decompressed by every backend.
+func TestRoundTripBackends(t *testing.T) {
+	payload := bytes.Repeat([]byte("ACGTTGCAACGGCCTTAAGG\n"), 3*BlockSize/21)
+	backends := []Backend{Libdeflate, Stdlib, Klauspost}
+	for _, wb := range backends {
+		buf := new(bytes.Buffer)
+		w, err := NewWriterBackend(buf, gzip.DefaultCompression, *conc, wb)
+		if err != nil {
+			t.Fatalf("NewWriterBackend(%v): %v", wb, err)
+		}
+		if _, err := w.Write(payload); err != nil {
+			t.Fatalf("Write(%v): %v", wb, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Writer.Close(%v): %v", wb, err)
+		}
+		for _, rb := range backends {
+			r, err := NewReaderBackend(bytes.NewReader(buf.Bytes()), *conc, rb)
+			if err != nil {
+				t.Fatalf("NewReaderBackend(%v): %v", rb, err)
+			}
+			b, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll(%v->%v): %v", wb, rb, err)
+			}
+			if !bytes.Equal(b, payload) {
+				t.Errorf("payload mismatch for %v->%v", wb, rb)
+			}
+			if err := r.Close(); err != nil {
+				t.Errorf("Reader.Close(%v): %v", rb, err)
+			}
+		}
+	}
+	if _, err := NewWriterBackend(new(bytes.Buffer), gzip.DefaultCompression, *conc, Backend(-1)); err == nil {
+		t.Error("expected error for invalid backend")
+	}
+}
+
 // TestRoundTripMulti tests that bgzipping and then bgunzipping is the identity
 // function for a multiple member bgzf.
 func TestRoundTripMulti(t *testing.T) {
@@ -971,6 +1010,282 @@ func TestCache(t *testing.T) {
 	}
 }
 
+func TestSharedLRU(t *testing.T) {
+	const blocks = 10
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1)
+	var offsets []Offset
+	for i := 0; i < blocks; i++ {
+		offsets = append(offsets, Offset{File: int64(buf.Len())})
+		_, err := fmt.Fprintf(w, "payload%02d", i)
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		err = w.Flush()
+		if err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+		err = w.Wait()
+		if err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	c := cache.NewSharedLRU(4 * MaxBlockSize)
+	var readers []*Reader
+	for i := 0; i < 3; i++ {
+		r, err := NewReader(bytes.NewReader(buf.Bytes()), 1)
+		if err != nil {
+			t.Fatalf("NewReader: %v", err)
+		}
+		r.SetCache(c.View())
+		readers = append(readers, r)
+	}
+	p := make([]byte, len("payload00"))
+	for pass := 0; pass < 2; pass++ {
+		for _, r := range readers {
+			for _, i := range []int{0, 1, 0, 2, 1} {
+				err := r.Seek(offsets[i])
+				if err != nil {
+					t.Fatalf("Seek: %v", err)
+				}
+				_, err = io.ReadFull(r, p)
+				if err != nil {
+					t.Fatalf("ReadFull: %v", err)
+				}
+				if want := fmt.Sprintf("payload%02d", i); string(p) != want {
+					t.Errorf("unexpected payload: got:%q want:%q", p, want)
+				}
+			}
+		}
+	}
+	for _, r := range readers {
+		r.Close()
+	}
+	if c.Size() > c.Cap() {
+		t.Errorf("cache size exceeds capacity: %d > %d", c.Size(), c.Cap())
+	}
+	stats := c.Stats()
+	if stats.Gets == stats.Misses {
+		t.Error("expected cache hits")
+	}
+	if stats.Evictions == 0 {
+		t.Error("expected cache evictions")
+	}
+}
+
+func TestWriterVirtualOffset(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	var (
+		offsets []Offset
+		records []string
+	)
+	for i := 0; i < 5000; i++ {
+		rec := fmt.Sprintf("record %d\n", i)
+		off, err := w.VirtualOffset(i%1000 == 0)
+		if err != nil {
+			t.Fatalf("VirtualOffset: %v", err)
+		}
+		if i%1000 == 0 && off.Block != 0 {
+			t.Errorf("expected block boundary at record %d: got:%+v", i, off)
+		}
+		_, err = w.Write([]byte(rec))
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		offsets = append(offsets, off)
+		records = append(records, rec)
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err = w.VirtualOffset(false); err != ErrClosed {
+		t.Errorf("unexpected error after close: got:%v want:%v", err, ErrClosed)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), *conc)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer r.Close()
+	for i := 0; i < len(records); i += 37 {
+		err = r.Seek(offsets[i])
+		if err != nil {
+			t.Fatalf("Seek: %v", err)
+		}
+		p := make([]byte, len(records[i]))
+		_, err = io.ReadFull(r, p)
+		if err != nil {
+			t.Fatalf("ReadFull: %v", err)
+		}
+		if string(p) != records[i] {
+			t.Errorf("unexpected record at %+v: got:%q want:%q", offsets[i], p, records[i])
+		}
+	}
+}
+
+func TestWriterBlockSize(t *testing.T) {
+	payload := bytes.Repeat([]byte("ACGTTGCAACGGCCTTAAGG\n"), 1000)
+	for _, size := range []int{1, 100, 4096, BlockSize} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, *conc)
+		err := w.SetBlockSize(size)
+		if err != nil {
+			t.Fatalf("SetBlockSize(%d): %v", size, err)
+		}
+		_, err = w.Write(payload)
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		var n int
+		it := NewBlockIterator(bytes.NewReader(buf.Bytes()))
+		for it.Next() {
+			b := it.Block()
+			if int(b.ISize) > size {
+				t.Errorf("block exceeds size limit %d: %d", size, b.ISize)
+			}
+			if !b.IsEOF() {
+				n++
+			}
+		}
+		if want := (len(payload) + size - 1) / size; n != want {
+			t.Errorf("unexpected number of blocks for size %d: got:%d want:%d", size, n, want)
+		}
+
+		r, err := NewReader(bytes.NewReader(buf.Bytes()), *conc)
+		if err != nil {
+			t.Fatalf("NewReader: %v", err)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		r.Close()
+		if !bytes.Equal(got, payload) {
+			t.Errorf("unexpected data for block size %d", size)
+		}
+	}
+	w := NewWriter(ioutil.Discard, *conc)
+	for _, size := range []int{0, BlockSize + 1} {
+		if err := w.SetBlockSize(size); err == nil {
+			t.Errorf("expected error for block size %d", size)
+		}
+	}
+	w.Close()
+}
+
+func TestWriterReadFrom(t *testing.T) {
+	payload := bytes.Repeat([]byte("ACGTTGCAACGGCCTTAAGG\n"), 10000)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	_, err := w.Write([]byte("header\n"))
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// Hide the bytes.Reader's WriteTo method so that
+	// io.Copy uses the Writer's ReadFrom method.
+	n, err := io.Copy(w, struct{ io.Reader }{bytes.NewReader(payload)})
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if n != int64(len(payload)) {
+		t.Errorf("unexpected number of bytes read: got:%d want:%d", n, len(payload))
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var blocks int
+	it := NewBlockIterator(bytes.NewReader(buf.Bytes()))
+	for it.Next() {
+		b := it.Block()
+		if !b.IsEOF() && int(b.ISize) != BlockSize {
+			blocks++
+		}
+	}
+	if blocks != 1 {
+		t.Errorf("unexpected number of partially filled blocks: got:%d want:1", blocks)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), *conc)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer r.Close()
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if want := append([]byte("header\n"), payload...); !bytes.Equal(got, want) {
+		t.Error("unexpected data read after ReadFrom")
+	}
+
+	if _, err = w.ReadFrom(bytes.NewReader(payload)); err != ErrClosed {
+		t.Errorf("unexpected error after Close: got:%v want:%v", err, ErrClosed)
+	}
+}
+
+func TestReaderProgress(t *testing.T) {
+	payload := bytes.Repeat([]byte("ACGTTGCAACGGCCTTAAGG\n"), 20000)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	_, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), *conc)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer r.Close()
+	var (
+		total    int64
+		lastComp int64
+		p        = make([]byte, 1000)
+	)
+	for {
+		n, err := r.Read(p)
+		total += int64(n)
+		comp, uncomp := r.Progress()
+		if uncomp != total {
+			t.Fatalf("unexpected uncompressed progress: got:%d want:%d", uncomp, total)
+		}
+		if comp < lastComp || comp >= int64(buf.Len()) {
+			t.Fatalf("unexpected compressed progress: got:%d previous:%d file size:%d", comp, lastComp, buf.Len())
+		}
+		lastComp = comp
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+	}
+	if total != int64(len(payload)) {
+		t.Errorf("unexpected total read: got:%d want:%d", total, len(payload))
+	}
+	if lastComp == 0 {
+		t.Error("compressed progress did not advance")
+	}
+}
+
 func TestBlocked(t *testing.T) {
 	const (
 		infix  = "payload"
@@ -1148,6 +1463,46 @@ func TestWriteByteCount(t *testing.T) {
 	}
 }
 
+func TestWriterReuse(t *testing.T) {
+	data := bytes.Repeat([]byte("reused compressor state "), 10000)
+	for _, backend := range []Backend{Libdeflate, Stdlib, Klauspost} {
+		for _, level := range []int{gzip.DefaultCompression, gzip.BestSpeed, gzip.BestCompression} {
+			var want []byte
+			for i := 0; i < 3; i++ {
+				var buf bytes.Buffer
+				bg, err := NewWriterBackend(&buf, level, 2, backend)
+				if err != nil {
+					t.Fatalf("unexpected error creating writer: %v", err)
+				}
+				_, err = bg.Write(data)
+				if err != nil {
+					t.Fatalf("unexpected error writing: %v", err)
+				}
+				err = bg.Close()
+				if err != nil {
+					t.Fatalf("unexpected error closing writer: %v", err)
+				}
+				if i == 0 {
+					want = buf.Bytes()
+				} else if !bytes.Equal(buf.Bytes(), want) {
+					t.Errorf("unexpected output from reused %v writer at level %d", backend, level)
+				}
+				r, err := NewReader(&buf, 1)
+				if err != nil {
+					t.Fatalf("unexpected error creating reader: %v", err)
+				}
+				got, err := ioutil.ReadAll(r)
+				if err != nil {
+					t.Fatalf("unexpected error reading: %v", err)
+				}
+				if !bytes.Equal(got, data) {
+					t.Errorf("unexpected round trip from %v writer at level %d", backend, level)
+				}
+			}
+		}
+	}
+}
+
 func BenchmarkWrite(b *testing.B) {
 	bg := NewWriter(ioutil.Discard, *conc)
 	block := bytes.Repeat([]byte("repeated"), 50)
@@ -1159,6 +1514,81 @@ func BenchmarkWrite(b *testing.B) {
 	}
 }
 
+// benchData returns n bytes of record-like data with the mix of
+// repetition and entropy found in alignment data.
+func benchData(n int) []byte {
+	const bases = "ACGT"
+	data := make([]byte, 0, n)
+	x := uint32(1)
+	for len(data) < n {
+		data = append(data, "read_name:1234:5678\x00"...)
+		for i := 0; i < 150; i++ {
+			x ^= x << 13
+			x ^= x >> 17
+			x ^= x << 5
+			data = append(data, bases[x&3])
+		}
+		for i := 0; i < 150; i++ {
+			data = append(data, byte(30+x>>(i%29)&7))
+		}
+	}
+	return data[:n]
+}
+
+// BenchmarkWriterSmall measures the cost of writing many small BGZF
+// streams, as done when writing shards or temporary sort files, where
+// compressor construction dominates.
+func BenchmarkWriterSmall(b *testing.B) {
+	data := benchData(BlockSize)
+	for _, backend := range []Backend{Libdeflate, Stdlib, Klauspost} {
+		b.Run(backend.String(), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bg, err := NewWriterBackend(ioutil.Discard, gzip.DefaultCompression, *conc, backend)
+				if err != nil {
+					b.Fatalf("unexpected error creating writer: %v", err)
+				}
+				bg.Write(data)
+				err = bg.Close()
+				if err != nil {
+					b.Fatalf("unexpected error closing writer: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWriterLarge measures the cost of writing a large BGZF
+// stream in blocks of record-like data.
+func BenchmarkWriterLarge(b *testing.B) {
+	data := benchData(64 << 20)
+	for _, backend := range []Backend{Libdeflate, Stdlib, Klauspost} {
+		b.Run(backend.String(), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bg, err := NewWriterBackend(ioutil.Discard, gzip.DefaultCompression, *conc, backend)
+				if err != nil {
+					b.Fatalf("unexpected error creating writer: %v", err)
+				}
+				for p := data; len(p) != 0; {
+					n := 4 << 10
+					if n > len(p) {
+						n = len(p)
+					}
+					bg.Write(p[:n])
+					p = p[n:]
+				}
+				err = bg.Close()
+				if err != nil {
+					b.Fatalf("unexpected error closing writer: %v", err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkRead(b *testing.B) {
 	if *file == "" {
 		b.Skip("no bgzf file specified")
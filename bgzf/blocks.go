@@ -0,0 +1,180 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// ErrChecksum is returned when the decompressed data of a block does not
+// match the CRC32 recorded in the block's gzip footer.
+var ErrChecksum = errors.New("bgzf: checksum mismatch")
+
+// BlockInfo describes a BGZF block.
+type BlockInfo struct {
+	// Offset is the compressed file offset
+	// of the start of the block.
+	Offset int64
+
+	// Size is the compressed size of the block,
+	// including the gzip header and footer.
+	Size int
+
+	// ISize is the uncompressed size of the block
+	// data recorded in the gzip footer.
+	ISize uint32
+
+	// CRC32 is the checksum of the uncompressed
+	// block data recorded in the gzip footer.
+	CRC32 uint32
+}
+
+// IsEOF returns whether the block is a zero-length block such as the
+// BGZF magic EOF marker block.
+func (b BlockInfo) IsEOF() bool { return b.ISize == 0 }
+
+// BlockIterator iterates over the blocks of a BGZF stream, reporting block
+// metadata from the gzip headers and footers. Block data is only
+// decompressed on request.
+type BlockIterator struct {
+	r       io.Reader
+	backend Backend
+
+	buf  []byte
+	off  int64
+	info BlockInfo
+
+	data    []byte
+	inflate bool
+	dataErr error
+
+	err error
+}
+
+// NewBlockIterator returns a BlockIterator reading BGZF blocks from r.
+func NewBlockIterator(r io.Reader) *BlockIterator {
+	return &BlockIterator{r: r, buf: make([]byte, MaxBlockSize)}
+}
+
+// Next advances the iterator to the next block. It returns false when
+// the iteration stops, either by reaching the end of the stream or an
+// error.
+func (i *BlockIterator) Next() bool {
+	if i.err != nil {
+		return false
+	}
+	size, isize, err := readBlockSizes(i.r, i.buf)
+	if err != nil {
+		i.err = err
+		return false
+	}
+	i.info = BlockInfo{
+		Offset: i.off,
+		Size:   size,
+		ISize:  isize,
+		CRC32:  binary.LittleEndian.Uint32(i.buf[size-8 : size-4]),
+	}
+	i.off += int64(size)
+	i.inflate = false
+	return true
+}
+
+// Block returns the metadata of the current block.
+func (i *BlockIterator) Block() BlockInfo { return i.info }
+
+// Raw returns the complete compressed bytes of the current block. The
+// returned slice is only valid until the next call to Next.
+func (i *BlockIterator) Raw() []byte { return i.buf[:i.info.Size] }
+
+// Data decompresses and returns the data held by the current block,
+// checking the uncompressed size and CRC32 recorded in the block footer.
+// The returned slice is only valid until the next call to Next.
+func (i *BlockIterator) Data() ([]byte, error) {
+	if i.inflate {
+		return i.data, i.dataErr
+	}
+	i.inflate = true
+	if cap(i.data) < MaxBlockSize {
+		i.data = make([]byte, MaxBlockSize)
+	}
+	i.data = i.data[:cap(i.data)]
+	var n int
+	if i.info.ISize != 0 {
+		xlen := int(binary.LittleEndian.Uint16(i.buf[10:12]))
+		n, i.dataErr = inflate(i.backend, i.data, i.buf[12+xlen:i.info.Size-8])
+		if i.dataErr != nil {
+			i.data = i.data[:0]
+			return nil, i.dataErr
+		}
+	}
+	i.data = i.data[:n]
+	switch {
+	case uint32(n) != i.info.ISize:
+		i.dataErr = ErrBlockSizeMismatch
+	case crc32.ChecksumIEEE(i.data) != i.info.CRC32:
+		i.dataErr = ErrChecksum
+	}
+	return i.data, i.dataErr
+}
+
+// Error returns the first non-EOF error that was encountered by the
+// BlockIterator.
+func (i *BlockIterator) Error() error {
+	if i.err == io.EOF {
+		return nil
+	}
+	return i.err
+}
+
+// readBlockSizes reads a complete BGZF block from r into buf and returns
+// the compressed size of the block and the uncompressed size recorded in
+// its gzip footer.
+func readBlockSizes(r io.Reader, buf []byte) (size int, isize uint32, err error) {
+	const fixedHeader = 12
+	n, err := io.ReadFull(r, buf[:fixedHeader])
+	if err != nil {
+		if err == io.ErrUnexpectedEOF || (err == io.EOF && n != 0) {
+			return 0, 0, ErrCorrupt
+		}
+		return 0, 0, err
+	}
+	if buf[0] != 0x1f || buf[1] != 0x8b || buf[2] != 8 || buf[3]&0x4 == 0 {
+		return 0, 0, ErrCorrupt
+	}
+	xlen := int(binary.LittleEndian.Uint16(buf[10:12]))
+	_, err = io.ReadFull(r, buf[fixedHeader:fixedHeader+xlen])
+	if err != nil {
+		return 0, 0, ErrCorrupt
+	}
+	extra := buf[fixedHeader : fixedHeader+xlen]
+	size = -1
+	for len(extra) >= 4 {
+		slen := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+slen {
+			break
+		}
+		if bytes.Equal(extra[:2], bgzfExtraPrefix[:2]) && slen == 2 {
+			size = int(binary.LittleEndian.Uint16(extra[4:6])) + 1
+			break
+		}
+		extra = extra[4+slen:]
+	}
+	if size < 0 {
+		return 0, 0, ErrNoBlockSize
+	}
+	if size < fixedHeader+xlen+8 || size > len(buf) {
+		return 0, 0, ErrCorrupt
+	}
+	_, err = io.ReadFull(r, buf[fixedHeader+xlen:size])
+	if err != nil {
+		return 0, 0, ErrCorrupt
+	}
+	isize = binary.LittleEndian.Uint32(buf[size-4 : size])
+	return size, isize, nil
+}
@@ -0,0 +1,82 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/Schaudge/hts/bgzf"
+)
+
+func TestBlockIterator(t *testing.T) {
+	payload := bytes.Repeat([]byte("ACGTTGCAACGGCCTTAAGG\n"), 3*BlockSize/21)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	_, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var (
+		data  []byte
+		off   int64
+		n     int
+		nEOF  int
+		inner = buf.Bytes()
+	)
+	it := NewBlockIterator(bytes.NewReader(inner))
+	for it.Next() {
+		b := it.Block()
+		if b.Offset != off {
+			t.Errorf("unexpected block offset: got:%d want:%d", b.Offset, off)
+		}
+		if !bytes.Equal(it.Raw(), inner[off:off+int64(b.Size)]) {
+			t.Errorf("unexpected raw block at %d", off)
+		}
+		off += int64(b.Size)
+		if b.IsEOF() {
+			nEOF++
+		}
+		d, err := it.Data()
+		if err != nil {
+			t.Fatalf("Data: %v", err)
+		}
+		if len(d) != int(b.ISize) {
+			t.Errorf("unexpected data length: got:%d want:%d", len(d), b.ISize)
+		}
+		data = append(data, d...)
+		n++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("unexpected iteration error: %v", err)
+	}
+	if off != int64(len(inner)) {
+		t.Errorf("iteration did not consume stream: got:%d want:%d", off, len(inner))
+	}
+	if n != 4 || nEOF != 1 {
+		t.Errorf("unexpected block counts: got:%d/%d want:4/1", n, nEOF)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Error("unexpected decompressed data")
+	}
+
+	// Corrupt the CRC of the first block.
+	corrupt := append([]byte(nil), inner...)
+	it = NewBlockIterator(bytes.NewReader(corrupt))
+	it.Next()
+	corrupt[it.Block().Size-8] ^= 0xff
+	it = NewBlockIterator(bytes.NewReader(corrupt))
+	if !it.Next() {
+		t.Fatalf("unexpected iteration failure: %v", it.Error())
+	}
+	if _, err = it.Data(); err != ErrChecksum {
+		t.Errorf("unexpected error for corrupt block: got:%v want:%v", err, ErrChecksum)
+	}
+}
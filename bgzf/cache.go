@@ -8,8 +8,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"io"
-
-	"github.com/Schaudge/grailbase/compress/libdeflate"
+	"sync"
 )
 
 // Cache is a Block caching type. Basic cache implementations are provided
@@ -78,8 +77,9 @@ type Block interface {
 	// return the new offset.
 	seek(offset int64) error
 
-	// readBuf uncompresses the given input data.
-	readBuf(in []byte, dd libdeflate.Decompressor) error
+	// readBuf uncompresses the given input data
+	// using the specified DEFLATE backend.
+	readBuf(in []byte, be Backend) error
 
 	// len returns the number of remaining
 	// bytes that can be read from the Block.
@@ -116,10 +116,38 @@ type block struct {
 	data [MaxBlockSize]byte
 }
 
+// blockPool holds released blocks for reuse, avoiding the allocation
+// of a new block for each member read by a concurrent Reader.
+var blockPool = sync.Pool{New: func() interface{} { return new(block) }}
+
+// newBlock returns a block owned by r, reusing a released block if one
+// is available.
+func newBlock(r *Reader) *block {
+	b := blockPool.Get().(*block)
+	b.setOwner(r)
+	return b
+}
+
+// releaseBlock makes b available for reuse if it is a *block.
+// The caller must ensure b is not referenced elsewhere.
+func releaseBlock(b Block) {
+	blk, ok := b.(*block)
+	if !ok {
+		return
+	}
+	blk.owner = nil
+	blk.buf = nil
+	blockPool.Put(blk)
+}
+
 func (b *block) Base() int64 { return b.base }
 
 func (b *block) Used() bool { return b.used }
 
+// Size returns the number of bytes of memory retained
+// by the Block's data buffer.
+func (b *block) Size() int { return len(b.data) }
+
 func (b *block) Read(p []byte) (int, error) {
 	n, err := b.buf.Read(p)
 	b.offset.Block += uint16(n)
@@ -129,10 +157,10 @@ func (b *block) Read(p []byte) (int, error) {
 	return n, err
 }
 
-func (b *block) readBuf(inData []byte, dd libdeflate.Decompressor) error {
+func (b *block) readBuf(inData []byte, be Backend) error {
 	o := b.owner
 	b.owner = nil
-	n, err := dd.Decompress(b.data[:], inData)
+	n, err := inflate(be, b.data[:], inData)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,215 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"sync"
+
+	"github.com/Schaudge/hts/bgzf"
+)
+
+// Sizer is implemented by Blocks that can report the number of bytes
+// of memory they retain. Blocks that do not implement Sizer are
+// assumed to retain bgzf.MaxBlockSize bytes.
+type Sizer interface {
+	Size() int
+}
+
+func sizeOf(b bgzf.Block) int64 {
+	if s, ok := b.(Sizer); ok {
+		return int64(s.Size())
+	}
+	return bgzf.MaxBlockSize
+}
+
+// SharedLRU is a least recently used block cache bounded by the total
+// size of the Blocks it holds rather than by their number. A single
+// SharedLRU may be shared by any number of bgzf.Readers, each using
+// its own view of the cache obtained from the View method, so that the
+// memory used for caching by all the Readers is bounded by one limit.
+type SharedLRU struct {
+	mu    sync.Mutex
+	root  sharedNode
+	table map[sharedKey]*sharedNode
+	size  int64
+	cap   int64
+	views int
+	stats Stats
+}
+
+type sharedKey struct {
+	view int
+	base int64
+}
+
+type sharedNode struct {
+	key  sharedKey
+	b    bgzf.Block
+	size int64
+
+	next, prev *sharedNode
+}
+
+// NewSharedLRU returns a SharedLRU holding at most n bytes of Blocks.
+// If n is less than bgzf.MaxBlockSize, a nil cache is returned.
+func NewSharedLRU(n int64) *SharedLRU {
+	if n < bgzf.MaxBlockSize {
+		return nil
+	}
+	c := SharedLRU{
+		table: make(map[sharedKey]*sharedNode),
+		cap:   n,
+	}
+	c.root.next = &c.root
+	c.root.prev = &c.root
+	return &c
+}
+
+// View returns a bgzf.Cache backed by c. Each bgzf.Reader using c must
+// be given its own view; Blocks put into a view are only visible to
+// that view, but all views share the size limit and eviction order of c.
+func (c *SharedLRU) View() bgzf.Cache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.views++
+	return &sharedView{c: c, id: c.views}
+}
+
+// Size returns the total size of the Blocks held by the cache.
+func (c *SharedLRU) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Cap returns the maximum total size of Blocks that can be held by the
+// cache.
+func (c *SharedLRU) Cap() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cap
+}
+
+// Len returns the number of Blocks held by the cache.
+func (c *SharedLRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.table)
+}
+
+// Resize changes the capacity of the cache to n bytes, dropping Blocks
+// if the size of the cached Blocks is greater than n.
+func (c *SharedLRU) Resize(n int64) {
+	c.mu.Lock()
+	c.cap = n
+	for c.size > c.cap && len(c.table) != 0 {
+		c.evict()
+	}
+	c.mu.Unlock()
+}
+
+// Stats returns the current statistics for the cache, summed over all
+// views. The number of cache hits is the difference between the Gets
+// and Misses fields.
+func (c *SharedLRU) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// ResetStats zeros the statistics kept by the cache.
+func (c *SharedLRU) ResetStats() {
+	c.mu.Lock()
+	c.stats = Stats{}
+	c.mu.Unlock()
+}
+
+func (c *SharedLRU) insertAfter(pos, n *sharedNode) {
+	n.prev = pos
+	pos.next, n.next, pos.next.prev = n, pos.next, n
+	c.table[n.key] = n
+	c.size += n.size
+}
+
+func (c *SharedLRU) remove(n *sharedNode) {
+	delete(c.table, n.key)
+	c.size -= n.size
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.next = nil
+	n.prev = nil
+}
+
+// evict removes the least recently used Block and returns it.
+func (c *SharedLRU) evict() bgzf.Block {
+	n := c.root.prev
+	c.remove(n)
+	c.stats.Evictions++
+	return n.b
+}
+
+// sharedView is a bgzf.Cache view of a SharedLRU.
+type sharedView struct {
+	c  *SharedLRU
+	id int
+}
+
+func (v *sharedView) Get(base int64) bgzf.Block {
+	c := v.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.Gets++
+	n, ok := c.table[sharedKey{view: v.id, base: base}]
+	if !ok {
+		c.stats.Misses++
+		return nil
+	}
+	c.remove(n)
+	return n.b
+}
+
+func (v *sharedView) Peek(base int64) (exist bool, next int64) {
+	c := v.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n, exist := c.table[sharedKey{view: v.id, base: base}]
+	if !exist {
+		return false, -1
+	}
+	return true, n.b.NextBase()
+}
+
+func (v *sharedView) Put(b bgzf.Block) (evicted bgzf.Block, retained bool) {
+	c := v.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.Puts++
+	k := sharedKey{view: v.id, base: b.Base()}
+	if _, ok := c.table[k]; ok {
+		return b, false
+	}
+	size := sizeOf(b)
+	if size > c.cap {
+		return b, false
+	}
+	used := b.Used()
+	if c.size+size > c.cap && !used {
+		return b, false
+	}
+	for c.size+size > c.cap {
+		evicted = c.evict()
+	}
+	n := &sharedNode{key: k, b: b, size: size}
+	if used {
+		c.insertAfter(&c.root, n)
+	} else {
+		c.insertAfter(c.root.prev, n)
+	}
+	c.stats.Retains++
+	return evicted, true
+}
@@ -0,0 +1,154 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/Schaudge/hts/bgzf"
+)
+
+// bgzfPayload returns a BGZF stream holding six full blocks and a
+// short seventh block, and the uncompressed payload.
+func bgzfPayload(t *testing.T) (compressed, payload []byte) {
+	rnd := rand.New(rand.NewSource(1))
+	payload = make([]byte, 6*bgzf.BlockSize+100)
+	for i := range payload {
+		payload[i] = "ACGT\n"[rnd.Intn(5)]
+	}
+	var buf bytes.Buffer
+	w := bgzf.NewWriter(&buf, 1)
+	_, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("unexpected error writing BGZF: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing BGZF writer: %v", err)
+	}
+	return buf.Bytes(), payload
+}
+
+func TestSharedLRU(t *testing.T) {
+	if c := NewSharedLRU(bgzf.MaxBlockSize - 1); c != nil {
+		t.Error("expected nil cache for capacity less than one block")
+	}
+
+	compressed, payload := bgzfPayload(t)
+	c := NewSharedLRU(2 * bgzf.MaxBlockSize)
+	r := bgzf.NewRandomReader(bytes.NewReader(compressed), c.View())
+	got, err := ioutil.ReadAll(r.NewStream())
+	if err != nil {
+		t.Fatalf("unexpected error reading: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("unexpected data read through shared cache")
+	}
+
+	// Each of the seven data blocks and the empty EOF block
+	// is a miss that is then retained. Only two full blocks
+	// fit in the cache, so the first four are evicted. The
+	// final two Gets are misses for the absent block after
+	// EOF, one for the short final read and one for the
+	// read returning io.EOF.
+	wantStats := Stats{Gets: 10, Misses: 10, Puts: 8, Retains: 8, Evictions: 4}
+	if stats := c.Stats(); stats != wantStats {
+		t.Errorf("unexpected statistics: got:%+v want:%+v", stats, wantStats)
+	}
+	if want := int64(2*bgzf.BlockSize + 100); c.Size() != want {
+		t.Errorf("unexpected cache size: got:%d want:%d", c.Size(), want)
+	}
+	if c.Size() > c.Cap() {
+		t.Errorf("cache size exceeds limit: %d > %d", c.Size(), c.Cap())
+	}
+	if c.Len() != 4 {
+		t.Errorf("unexpected number of cached blocks: got:%d want:4", c.Len())
+	}
+
+	c.ResetStats()
+	if stats := c.Stats(); stats != (Stats{}) {
+		t.Errorf("unexpected statistics after reset: %+v", stats)
+	}
+
+	c.Resize(bgzf.MaxBlockSize)
+	if c.Size() > c.Cap() {
+		t.Errorf("cache size exceeds limit after resize: %d > %d", c.Size(), c.Cap())
+	}
+	if c.Len() != 3 {
+		t.Errorf("unexpected number of cached blocks after resize: got:%d want:3", c.Len())
+	}
+	if stats := c.Stats(); stats.Evictions != 1 {
+		t.Errorf("unexpected evictions after resize: got:%d want:1", stats.Evictions)
+	}
+}
+
+func TestSharedLRUConcurrent(t *testing.T) {
+	compressed, payload := bgzfPayload(t)
+	c := NewSharedLRU(3 * bgzf.MaxBlockSize)
+
+	const readers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(2)
+
+		// Random access readers.
+		go func() {
+			defer wg.Done()
+			r := bgzf.NewRandomReader(bytes.NewReader(compressed), c.View())
+			for pass := 0; pass < 2; pass++ {
+				got, err := ioutil.ReadAll(r.NewStream())
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(got, payload) {
+					t.Error("unexpected data from random reader")
+					return
+				}
+			}
+		}()
+
+		// Stream readers.
+		go func() {
+			defer wg.Done()
+			r, err := bgzf.NewReader(bytes.NewReader(compressed), 1)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer r.Close()
+			r.SetCache(c.View())
+			got, err := ioutil.ReadAll(r)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !bytes.Equal(got, payload) {
+				t.Error("unexpected data from stream reader")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if c.Size() > c.Cap() {
+		t.Errorf("cache size exceeds limit: %d > %d", c.Size(), c.Cap())
+	}
+	stats := c.Stats()
+	if stats.Gets == 0 || stats.Puts == 0 || stats.Evictions == 0 {
+		t.Errorf("unexpected statistics: %+v", stats)
+	}
+	if stats.Misses > stats.Gets || stats.Retains > stats.Puts {
+		t.Errorf("inconsistent statistics: %+v", stats)
+	}
+}
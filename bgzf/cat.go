@@ -0,0 +1,39 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import "io"
+
+// Cat writes the BGZF streams read from readers to w as a single valid
+// BGZF stream without recompression. Block boundaries are preserved and
+// empty blocks, including the magic EOF blocks of the inputs, are dropped.
+// A single magic EOF block is written at the end of the output.
+//
+// Cat returns the compressed offset in the output at which the data from
+// each reader begins. These offsets may be used to merge indexes of the
+// inputs.
+func Cat(w io.Writer, readers ...io.Reader) (offsets []int64, err error) {
+	var off int64
+	offsets = make([]int64, len(readers))
+	for i, r := range readers {
+		offsets[i] = off
+		it := NewBlockIterator(r)
+		for it.Next() {
+			if it.Block().IsEOF() {
+				continue
+			}
+			n, err := w.Write(it.Raw())
+			off += int64(n)
+			if err != nil {
+				return offsets, err
+			}
+		}
+		if err := it.Error(); err != nil {
+			return offsets, err
+		}
+	}
+	_, err = io.WriteString(w, magicBlock)
+	return offsets, err
+}
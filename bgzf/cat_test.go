@@ -0,0 +1,88 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/Schaudge/hts/bgzf"
+)
+
+func TestCat(t *testing.T) {
+	var (
+		inputs []io.Reader
+		want   []byte
+		parts  [][]byte
+	)
+	for i, n := range []int{10, 0, 2 * BlockSize, 100} {
+		payload := bytes.Repeat([]byte{'a' + byte(i)}, n)
+		var buf bytes.Buffer
+		w := NewWriter(&buf, *conc)
+		_, err := w.Write(payload)
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		inputs = append(inputs, bytes.NewReader(buf.Bytes()))
+		want = append(want, payload...)
+		parts = append(parts, payload)
+	}
+
+	var out bytes.Buffer
+	offsets, err := Cat(&out, inputs...)
+	if err != nil {
+		t.Fatalf("Cat: %v", err)
+	}
+	ok, err := HasEOF(bytes.NewReader(out.Bytes()))
+	if err != nil || !ok {
+		t.Errorf("expected EOF block: %t %v", ok, err)
+	}
+	var nEOF int
+	it := NewBlockIterator(bytes.NewReader(out.Bytes()))
+	for it.Next() {
+		if it.Block().IsEOF() {
+			nEOF++
+		}
+	}
+	if nEOF != 1 {
+		t.Errorf("unexpected number of EOF blocks: got:%d want:1", nEOF)
+	}
+
+	r, err := NewReader(bytes.NewReader(out.Bytes()), *conc)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer r.Close()
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("unexpected concatenated data")
+	}
+	for i, off := range offsets {
+		if len(parts[i]) == 0 {
+			continue
+		}
+		err = r.Seek(Offset{File: off})
+		if err != nil {
+			t.Fatalf("Seek: %v", err)
+		}
+		p := make([]byte, len(parts[i]))
+		_, err = io.ReadFull(r, p)
+		if err != nil {
+			t.Fatalf("ReadFull: %v", err)
+		}
+		if !bytes.Equal(p, parts[i]) {
+			t.Errorf("unexpected data for input %d", i)
+		}
+	}
+}
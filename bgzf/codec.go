@@ -0,0 +1,128 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// BlockCodec is a block compression container format. BGZF is the
+// standard container; alternative containers may be used with Writers
+// created by NewWriterCodec and RandomReaders created by
+// NewRandomReaderCodec. Data written using a container other than BGZF
+// cannot be read by a Reader or by other BGZF tools.
+//
+// Each block written by a BlockCodec must be self-describing so that its
+// compressed size and uncompressed size can be obtained by ReadBlock
+// without decompressing the block data. Blocks must hold no more than
+// BlockSize bytes of uncompressed data and occupy no more than
+// MaxBlockSize bytes when compressed. Methods of a BlockCodec must be
+// safe for concurrent use.
+type BlockCodec interface {
+	// ReadBlock reads a complete compressed block from r
+	// into buf, returning the compressed size of the block
+	// and the size of the data it holds. ReadBlock returns
+	// io.EOF if no data remain in r.
+	ReadBlock(r io.Reader, buf []byte) (size int, isize uint32, err error)
+
+	// Decode decompresses the complete compressed block in
+	// src into dst, returning the number of bytes written.
+	Decode(dst, src []byte) (int, error)
+
+	// Encode appends a complete compressed block holding
+	// data to dst and returns the extended slice.
+	Encode(dst, data []byte) ([]byte, error)
+
+	// EOF returns the block written to mark the end of a
+	// stream.
+	EOF() []byte
+}
+
+// BGZFCodec is the BGZF BlockCodec.
+type BGZFCodec struct {
+	level   int
+	backend Backend
+}
+
+// NewBGZFCodec returns a BGZF BlockCodec using the given gzip compression
+// level and DEFLATE backend.
+func NewBGZFCodec(level int, b Backend) (*BGZFCodec, error) {
+	if level < gzip.DefaultCompression || level > gzip.BestCompression {
+		return nil, fmt.Errorf("bgzf: invalid compression level: %d", level)
+	}
+	if !b.valid() {
+		return nil, fmt.Errorf("bgzf: invalid backend: %v", b)
+	}
+	return &BGZFCodec{level: level, backend: b}, nil
+}
+
+// ReadBlock reads a complete BGZF block from r into buf.
+func (c *BGZFCodec) ReadBlock(r io.Reader, buf []byte) (size int, isize uint32, err error) {
+	return readBlockSizes(r, buf)
+}
+
+// Decode decompresses the BGZF block in src into dst.
+func (c *BGZFCodec) Decode(dst, src []byte) (int, error) {
+	if len(src) < 18 {
+		return 0, ErrCorrupt
+	}
+	if binary.LittleEndian.Uint32(src[len(src)-4:]) == 0 {
+		return 0, nil
+	}
+	xlen := int(binary.LittleEndian.Uint16(src[10:12]))
+	if 12+xlen > len(src)-8 {
+		return 0, ErrCorrupt
+	}
+	return inflate(c.backend, dst, src[12+xlen:len(src)-8])
+}
+
+// Encode appends a BGZF block holding data to dst.
+func (c *BGZFCodec) Encode(dst, data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	gz, err := newMemberWriter(c.backend, buf, c.level)
+	if err != nil {
+		return dst, err
+	}
+	gz.setHeader(gzip.Header{
+		Extra: []byte(bgzfExtra),
+		OS:    0xff,
+	})
+	_, err = gz.Write(data)
+	if err != nil {
+		return dst, err
+	}
+	err = gz.Close()
+	if err != nil {
+		return dst, err
+	}
+	b := buf.Bytes()
+	err = setBlockSize(b[len(dst):])
+	if err != nil {
+		return dst, err
+	}
+	return b, nil
+}
+
+// EOF returns the BGZF magic EOF marker block.
+func (c *BGZFCodec) EOF() []byte { return []byte(magicBlock) }
+
+// setBlockSize writes the size of the complete BGZF block b into
+// the BSIZE field of its header.
+func setBlockSize(b []byte) error {
+	i := bytes.Index(b, bgzfExtraPrefix)
+	if i < 0 {
+		return gzip.ErrHeader
+	}
+	size := len(b) - 1
+	if size >= MaxBlockSize {
+		return ErrBlockOverflow
+	}
+	b[i+4], b[i+5] = byte(size), byte(size>>8)
+	return nil
+}
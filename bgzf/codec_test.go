@@ -0,0 +1,63 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/cache"
+)
+
+func TestBGZFCodec(t *testing.T) {
+	payload := bytes.Repeat([]byte("ACGTTGCAACGGCCTTAAGG\n"), 10000)
+	c, err := NewBGZFCodec(gzip.DefaultCompression, Stdlib)
+	if err != nil {
+		t.Fatalf("NewBGZFCodec: %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := NewWriterCodec(&buf, *conc, c)
+	if err != nil {
+		t.Fatalf("NewWriterCodec: %v", err)
+	}
+	_, err = w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Blocks written by the BGZF codec are read by a Reader.
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), *conc)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	got, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("unexpected data read by Reader")
+	}
+
+	rr := NewRandomReaderCodec(bytes.NewReader(buf.Bytes()), cache.NewLRU(2), c)
+	got, err = ioutil.ReadAll(rr.NewStream())
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("unexpected data read by RandomReader")
+	}
+
+	if _, err = NewBGZFCodec(gzip.BestCompression+1, Stdlib); err == nil {
+		t.Error("expected error for invalid compression level")
+	}
+}
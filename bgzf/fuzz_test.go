@@ -0,0 +1,60 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	. "github.com/Schaudge/hts/bgzf"
+)
+
+func FuzzBGZFBlock(f *testing.F) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1)
+	_, err := w.Write([]byte("The quick brown fox jumps over the lazy dog.\n"))
+	if err != nil {
+		f.Fatalf("unexpected error writing: %v", err)
+	}
+	err = w.Flush()
+	if err != nil {
+		f.Fatalf("unexpected error flushing: %v", err)
+	}
+	_, err = w.Write(bytes.Repeat([]byte("ACGT"), 1000))
+	if err != nil {
+		f.Fatalf("unexpected error writing: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		f.Fatalf("unexpected error closing: %v", err)
+	}
+	valid := buf.Bytes()
+	f.Add(valid)
+	f.Add([]byte(MagicBlock))
+	f.Add(valid[:len(valid)/2])
+
+	// A gzip stream without the BGZF block size field.
+	buf.Reset()
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("not bgzf"))
+	gz.Close()
+	f.Add(buf.Bytes())
+
+	// A block with a corrupted block size.
+	corrupt := append([]byte(nil), valid...)
+	corrupt[16], corrupt[17] = 0xff, 0xff
+	f.Add(corrupt)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := NewReader(bytes.NewReader(data), 1)
+		if err != nil {
+			return
+		}
+		io.Copy(io.Discard, r)
+		r.Close()
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ErrNoGZI is returned by GZI.Offset when the GZI index is empty.
+var ErrNoGZI = errors.New("bgzf: no gzi index")
+
+// GZIEntry is a GZI index entry, recording the compressed file offset
+// of the start of a BGZF block and the uncompressed offset of the first
+// byte of data held by the block.
+type GZIEntry struct {
+	Compressed   int64
+	Uncompressed int64
+}
+
+// GZI is a BGZF block index as written by bgzip -i and used by
+// samtools faidx for random access into bgzipped text. Entries are
+// ordered by offset. The first entry is always {0, 0}.
+type GZI []GZIEntry
+
+// ReadGZI reads a GZI index from the given io.Reader.
+func ReadGZI(r io.Reader) (GZI, error) {
+	var n uint64
+	err := binary.Read(r, binary.LittleEndian, &n)
+	if err != nil {
+		return nil, err
+	}
+	// Limit the initial allocation so that corrupt counts
+	// fail on reading rather than on allocation.
+	c := n + 1
+	if c > 1<<16 {
+		c = 1 << 16
+	}
+	idx := make(GZI, 1, c)
+	var buf [16]byte
+	for i := uint64(0); i < n; i++ {
+		_, err = io.ReadFull(r, buf[:])
+		if err != nil {
+			return nil, fmt.Errorf("bgzf: failed to read gzi entry: %v", err)
+		}
+		e := GZIEntry{
+			Compressed:   int64(binary.LittleEndian.Uint64(buf[:8])),
+			Uncompressed: int64(binary.LittleEndian.Uint64(buf[8:])),
+		}
+		last := idx[len(idx)-1]
+		if e.Compressed <= last.Compressed || e.Uncompressed < last.Uncompressed {
+			return nil, errors.New("bgzf: gzi entries out of order")
+		}
+		idx = append(idx, e)
+	}
+	return idx, nil
+}
+
+// WriteGZI writes the GZI index to the given io.Writer. The implicit
+// leading {0, 0} entry is not written.
+func WriteGZI(w io.Writer, idx GZI) error {
+	if len(idx) != 0 && idx[0] == (GZIEntry{}) {
+		idx = idx[1:]
+	}
+	err := binary.Write(w, binary.LittleEndian, uint64(len(idx)))
+	if err != nil {
+		return err
+	}
+	var buf [16]byte
+	for _, e := range idx {
+		binary.LittleEndian.PutUint64(buf[:8], uint64(e.Compressed))
+		binary.LittleEndian.PutUint64(buf[8:], uint64(e.Uncompressed))
+		_, err = w.Write(buf[:])
+		if err != nil {
+			return fmt.Errorf("bgzf: failed to write gzi entry: %v", err)
+		}
+	}
+	return nil
+}
+
+// BuildGZI returns a GZI index for the BGZF stream read from r. The
+// index is built from the BGZF block headers and gzip member footers;
+// block data is not decompressed.
+func BuildGZI(r io.Reader) (GZI, error) {
+	idx := GZI{{}}
+	var uoff int64
+	it := NewBlockIterator(r)
+	for it.Next() {
+		b := it.Block()
+		if b.Offset != 0 && !b.IsEOF() {
+			idx = append(idx, GZIEntry{Compressed: b.Offset, Uncompressed: uoff})
+		}
+		uoff += int64(b.ISize)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Offset returns the virtual offset corresponding to the given offset into
+// the uncompressed data.
+func (idx GZI) Offset(off int64) (Offset, error) {
+	if off < 0 {
+		return Offset{}, errors.New("bgzf: negative uncompressed offset")
+	}
+	if len(idx) == 0 {
+		return Offset{}, ErrNoGZI
+	}
+	i := sort.Search(len(idx), func(i int) bool { return idx[i].Uncompressed > off }) - 1
+	if i < 0 {
+		i = 0
+	}
+	e := idx[i]
+	delta := off - e.Uncompressed
+	if delta >= MaxBlockSize {
+		return Offset{}, errors.New("bgzf: uncompressed offset beyond indexed data")
+	}
+	return Offset{File: e.Compressed, Block: uint16(delta)}, nil
+}
+
+// SetGZI sets the GZI index used by SeekUncompressed. If idx is nil,
+// SeekUncompressed falls back to a sequential scan.
+func (bg *Reader) SetGZI(idx GZI) {
+	bg.gzi = idx
+}
+
+// SeekUncompressed performs a seek to the given offset into the uncompressed
+// data stream. If a GZI index has been provided by SetGZI it is used to find
+// the block holding the offset, otherwise the block is found by a sequential
+// scan from the start of the BGZF stream, decompressing each block.
+func (bg *Reader) SeekUncompressed(off int64) error {
+	if bg.gzi == nil {
+		return bg.scanUncompressed(off)
+	}
+	o, err := bg.gzi.Offset(off)
+	if err != nil {
+		return err
+	}
+	return bg.Seek(o)
+}
+
+// scanUncompressed seeks to the given offset into the uncompressed data
+// stream by visiting each block in turn from the start of the stream.
+func (bg *Reader) scanUncompressed(off int64) error {
+	if off < 0 {
+		return errors.New("bgzf: negative uncompressed offset")
+	}
+	var o Offset
+	for {
+		err := bg.Seek(o)
+		if err == io.EOF {
+			return errors.New("bgzf: uncompressed offset beyond end of data")
+		}
+		if err != nil {
+			return err
+		}
+		n := int64(bg.BlockLen())
+		if off <= n {
+			return bg.Seek(Offset{File: o.File, Block: uint16(off)})
+		}
+		off -= n
+		o = Offset{File: bg.current.NextBase()}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	. "github.com/Schaudge/hts/bgzf"
+)
+
+func TestGZI(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	payload := make([]byte, 5*BlockSize+1234)
+	for i := range payload {
+		payload[i] = "ACGT\n"[rnd.Intn(5)]
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	_, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	idx, err := BuildGZI(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("BuildGZI: %v", err)
+	}
+	if len(idx) != 6 {
+		t.Errorf("unexpected number of gzi entries: got:%d want:6", len(idx))
+	}
+
+	var ibuf bytes.Buffer
+	err = WriteGZI(&ibuf, idx)
+	if err != nil {
+		t.Fatalf("WriteGZI: %v", err)
+	}
+	if ibuf.Len() != 8+16*(len(idx)-1) {
+		t.Errorf("unexpected gzi size: got:%d want:%d", ibuf.Len(), 8+16*(len(idx)-1))
+	}
+	got, err := ReadGZI(&ibuf)
+	if err != nil {
+		t.Fatalf("ReadGZI: %v", err)
+	}
+	if !reflect.DeepEqual(got, idx) {
+		t.Errorf("gzi round trip mismatch: got:%v want:%v", got, idx)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), *conc)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer r.Close()
+	p := make([]byte, 100)
+	// Seek without a GZI index, by scanning, and then with the index.
+	for _, gzi := range []GZI{nil, got} {
+		r.SetGZI(gzi)
+		for _, off := range []int64{3*BlockSize + 17, 0, 1, BlockSize - 1, BlockSize, int64(len(payload) - len(p))} {
+			err = r.SeekUncompressed(off)
+			if err != nil {
+				t.Fatalf("SeekUncompressed(%d) with gzi=%t: %v", off, gzi != nil, err)
+			}
+			_, err = io.ReadFull(r, p)
+			if err != nil {
+				t.Fatalf("ReadFull after seek to %d: %v", off, err)
+			}
+			if !bytes.Equal(p, payload[off:off+int64(len(p))]) {
+				t.Errorf("unexpected data after seek to %d with gzi=%t", off, gzi != nil)
+			}
+		}
+	}
+	r.SetGZI(nil)
+	if err := r.SeekUncompressed(int64(len(payload) + 1)); err == nil {
+		t.Error("expected error for seek beyond end of data")
+	}
+	if err := r.SeekUncompressed(int64(len(payload))); err != nil {
+		t.Errorf("unexpected error for seek to end of data: %v", err)
+	}
+}
+
+func TestWriterGZI(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	payload := make([]byte, 4*BlockSize+99)
+	for i := range payload {
+		payload[i] = "ACGT\n"[rnd.Intn(5)]
+	}
+	var buf, ibuf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	w.SetGZIWriter(&ibuf)
+	for p := payload; len(p) != 0; {
+		n := 1000
+		if n > len(p) {
+			n = len(p)
+		}
+		_, err := w.Write(p[:n])
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		p = p[n:]
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	want, err := BuildGZI(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("BuildGZI: %v", err)
+	}
+	if !reflect.DeepEqual(w.GZI(), want) {
+		t.Errorf("unexpected recorded gzi: got:%v want:%v", w.GZI(), want)
+	}
+	got, err := ReadGZI(&ibuf)
+	if err != nil {
+		t.Fatalf("ReadGZI: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected written gzi: got:%v want:%v", got, want)
+	}
+}
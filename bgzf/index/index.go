@@ -15,6 +15,7 @@ import (
 var (
 	ErrNoReference = errors.New("index: no reference")
 	ErrInvalid     = errors.New("index: invalid interval")
+	ErrNoBinCounts = errors.New("index: no bin counts")
 )
 
 // ReferenceStats holds mapping statistics for a genomic reference.
@@ -0,0 +1,46 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+// MakeOffset returns the Offset corresponding to the given 64-bit
+// virtual file offset. The upper 48 bits of v hold the compressed file
+// offset of a BGZF block and the lower 16 bits the offset into the
+// uncompressed data of that block.
+func MakeOffset(v uint64) Offset {
+	return Offset{
+		File:  int64(v >> 16),
+		Block: uint16(v),
+	}
+}
+
+// Virtual returns the 64-bit virtual file offset of o.
+func (o Offset) Virtual() uint64 {
+	return uint64(o.File)<<16 | uint64(o.Block)
+}
+
+// Compare returns -1, 0 or 1 depending on whether o is before, equal to
+// or after p in the BGZF stream.
+func (o Offset) Compare(p Offset) int {
+	a, b := o.Virtual(), p.Virtual()
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Less returns whether o is before p in the BGZF stream.
+func (o Offset) Less(p Offset) bool {
+	return o.Virtual() < p.Virtual()
+}
+
+// Contains returns whether the offset o is within the half-open
+// chunk [c.Begin, c.End).
+func (c Chunk) Contains(o Offset) bool {
+	v := o.Virtual()
+	return c.Begin.Virtual() <= v && v < c.End.Virtual()
+}
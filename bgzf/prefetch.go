@@ -0,0 +1,163 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"errors"
+	"io"
+)
+
+// Prefetcher is an io.Reader that reads ahead of its consumer from an
+// underlying io.Reader. Reads from the underlying io.Reader are made
+// in a separate goroutine in MaxBlockSize chunks, holding up to a fixed
+// number of chunks ahead of the current read position. A Prefetcher
+// is intended to be used as the source for a Reader performing
+// sequential reads from high latency storage so that decompression is
+// not blocked waiting for I/O.
+//
+// If the underlying io.Reader is an io.Seeker, the Prefetcher may be
+// seeked; any prefetched data is discarded on a Seek.
+type Prefetcher struct {
+	r io.Reader
+
+	full chan prefetchBuf
+	free chan []byte
+	stop chan struct{}
+	done chan struct{}
+
+	cur prefetchBuf
+	pos int64
+
+	closed bool
+}
+
+type prefetchBuf struct {
+	buf  []byte // buf is the complete buffer.
+	data []byte // data is the unread portion of buf.
+	err  error
+}
+
+// NewPrefetcher returns a Prefetcher reading up to n chunks of
+// MaxBlockSize bytes ahead of the consumer. If n is less than 1,
+// a single chunk is prefetched.
+func NewPrefetcher(r io.Reader, n int) *Prefetcher {
+	if n < 1 {
+		n = 1
+	}
+	p := &Prefetcher{
+		r:    r,
+		full: make(chan prefetchBuf, n),
+		free: make(chan []byte, n+1),
+	}
+	for i := 0; i <= n; i++ {
+		p.free <- make([]byte, MaxBlockSize)
+	}
+	p.start()
+	return p
+}
+
+// start starts the prefetch goroutine.
+func (p *Prefetcher) start() {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		for {
+			var buf []byte
+			select {
+			case buf = <-p.free:
+			case <-p.stop:
+				return
+			}
+			n, err := io.ReadFull(p.r, buf)
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			select {
+			case p.full <- prefetchBuf{buf: buf, data: buf[:n], err: err}:
+			case <-p.stop:
+				p.free <- buf
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// halt stops the prefetch goroutine and discards prefetched data.
+func (p *Prefetcher) halt() {
+	close(p.stop)
+	<-p.done
+	for {
+		select {
+		case b := <-p.full:
+			p.free <- b.buf
+		default:
+			if p.cur.buf != nil {
+				p.free <- p.cur.buf
+			}
+			p.cur = prefetchBuf{}
+			return
+		}
+	}
+}
+
+// Read reads prefetched data into b.
+func (p *Prefetcher) Read(b []byte) (int, error) {
+	if p.closed {
+		return 0, ErrClosed
+	}
+	for len(p.cur.data) == 0 {
+		if p.cur.err != nil {
+			return 0, p.cur.err
+		}
+		if p.cur.buf != nil {
+			p.free <- p.cur.buf
+		}
+		p.cur = <-p.full
+	}
+	n := copy(b, p.cur.data)
+	p.cur.data = p.cur.data[n:]
+	p.pos += int64(n)
+	return n, nil
+}
+
+// Seek implements the io.Seeker interface. It returns an error if the
+// underlying io.Reader is not an io.Seeker.
+func (p *Prefetcher) Seek(offset int64, whence int) (int64, error) {
+	if p.closed {
+		return 0, ErrClosed
+	}
+	s, ok := p.r.(io.Seeker)
+	if !ok {
+		return p.pos, errors.New("bgzf: prefetched reader is not an io.Seeker")
+	}
+	if whence == io.SeekCurrent {
+		offset += p.pos
+		whence = io.SeekStart
+	}
+	p.halt()
+	pos, err := s.Seek(offset, whence)
+	if err != nil {
+		// Leave the Prefetcher at the position it was
+		// at before the failed seek.
+		pos, _ = s.Seek(p.pos, io.SeekStart)
+	}
+	p.pos = pos
+	p.start()
+	return pos, err
+}
+
+// Close stops prefetching. It does not close the underlying io.Reader.
+func (p *Prefetcher) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.halt()
+	p.closed = true
+	return nil
+}
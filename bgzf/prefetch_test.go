@@ -0,0 +1,74 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/Schaudge/hts/bgzf"
+)
+
+func TestPrefetcher(t *testing.T) {
+	payload := bytes.Repeat([]byte("ACGTTGCAACGGCCTTAAGG\n"), 5*BlockSize/21)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	_, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for _, n := range []int{0, 1, 4} {
+		p := NewPrefetcher(bytes.NewReader(buf.Bytes()), n)
+		r, err := NewReader(p, *conc)
+		if err != nil {
+			t.Fatalf("NewReader: %v", err)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("unexpected data with %d block prefetch", n)
+		}
+
+		// Seek back to the start of a block and
+		// check the rest of the data is correct.
+		idx, err := BuildGZI(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("BuildGZI: %v", err)
+		}
+		e := idx[len(idx)/2]
+		err = r.Seek(Offset{File: e.Compressed})
+		if err != nil {
+			t.Fatalf("Seek: %v", err)
+		}
+		got, err = ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll after seek: %v", err)
+		}
+		if !bytes.Equal(got, payload[e.Uncompressed:]) {
+			t.Errorf("unexpected data after seek with %d block prefetch", n)
+		}
+		r.Close()
+
+		_, err = p.Seek(0, io.SeekStart)
+		if err != nil {
+			t.Fatalf("Prefetcher.Seek: %v", err)
+		}
+		b := make([]byte, 2)
+		_, err = io.ReadFull(p, b)
+		if err != nil || b[0] != 0x1f || b[1] != 0x8b {
+			t.Errorf("unexpected data after prefetcher seek: %x %v", b, err)
+		}
+		p.Close()
+	}
+}
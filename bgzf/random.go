@@ -0,0 +1,249 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// RandomReader provides concurrent random access to BGZF data held in
+// an io.ReaderAt. Any number of Streams may be obtained from a single
+// RandomReader and used from separate goroutines. Decompressed blocks
+// are held in a Cache, and concurrent requests for a block that is
+// being read share a single read.
+type RandomReader struct {
+	ra    io.ReaderAt
+	codec BlockCodec
+
+	mu      sync.Mutex
+	cache   Cache
+	pending map[int64]*sharedBlock
+}
+
+// sharedBlock is a block being read by a RandomReader. The ready
+// channel is closed when the block has been read.
+type sharedBlock struct {
+	ready chan struct{}
+	data  []byte
+	next  int64
+	err   error
+}
+
+// NewRandomReader returns a RandomReader reading BGZF data from ra and
+// holding decompressed blocks in c. The cache may be shared with other
+// RandomReaders and Readers, for example by way of the views of a
+// cache.SharedLRU, so that their memory use has a single limit, but
+// each must be given a distinct Cache or view since blocks are keyed
+// by file offset. If c is nil, blocks are not retained between reads.
+func NewRandomReader(ra io.ReaderAt, c Cache) *RandomReader {
+	return NewRandomReaderBackend(ra, c, Libdeflate)
+}
+
+// NewRandomReaderBackend returns a RandomReader using the specified
+// DEFLATE backend for decompression. The c parameter is interpreted as
+// for NewRandomReader.
+func NewRandomReaderBackend(ra io.ReaderAt, c Cache, b Backend) *RandomReader {
+	return NewRandomReaderCodec(ra, c, &BGZFCodec{level: gzip.DefaultCompression, backend: b})
+}
+
+// NewRandomReaderCodec returns a RandomReader reading blocks using the
+// provided BlockCodec rather than BGZF. The c parameter is interpreted
+// as for NewRandomReader.
+//
+// NewRandomReaderCodec is experimental.
+func NewRandomReaderCodec(ra io.ReaderAt, c Cache, codec BlockCodec) *RandomReader {
+	return &RandomReader{
+		ra:      ra,
+		codec:   codec,
+		cache:   c,
+		pending: make(map[int64]*sharedBlock),
+	}
+}
+
+// block returns the decompressed block starting at the given file
+// offset, reading it from the underlying io.ReaderAt if it is not
+// held in the cache. Concurrent requests for the same block share
+// a single read.
+func (r *RandomReader) block(off int64) ([]byte, int64, error) {
+	r.mu.Lock()
+	if b, ok := r.pending[off]; ok {
+		r.mu.Unlock()
+		<-b.ready
+		return b.data, b.next, b.err
+	}
+	if r.cache != nil {
+		// Blocks are removed from the cache by
+		// Get, so the block is put back to keep
+		// it available to other Streams.
+		if blk, ok := r.cache.Get(off).(*randomBlock); ok {
+			r.cache.Put(blk)
+			r.mu.Unlock()
+			return blk.data, blk.next, nil
+		}
+	}
+	b := &sharedBlock{ready: make(chan struct{})}
+	r.pending[off] = b
+	r.mu.Unlock()
+
+	b.data, b.next, b.err = r.readBlock(off)
+
+	r.mu.Lock()
+	delete(r.pending, off)
+	if b.err == nil && r.cache != nil {
+		r.cache.Put(&randomBlock{base: off, next: b.next, data: b.data})
+	}
+	r.mu.Unlock()
+	close(b.ready)
+	return b.data, b.next, b.err
+}
+
+// randomBlock is a decompressed block held in the Cache of a
+// RandomReader. Its data is never altered once it has been read, so
+// it may be shared by any number of Streams, and it is not owned by
+// any Reader.
+type randomBlock struct {
+	base, next int64
+	data       []byte
+	off        int
+}
+
+func (b *randomBlock) Base() int64     { return b.base }
+func (b *randomBlock) NextBase() int64 { return b.next }
+func (b *randomBlock) Used() bool      { return true }
+
+// Size returns the number of bytes of memory retained by the block.
+func (b *randomBlock) Size() int { return len(b.data) }
+
+func (b *randomBlock) Read(p []byte) (int, error) {
+	if b.off >= len(b.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, b.data[b.off:])
+	b.off += n
+	return n, nil
+}
+
+func (b *randomBlock) header() gzip.Header           { return gzip.Header{} }
+func (b *randomBlock) isMagicBlock() bool            { return len(b.data) == 0 }
+func (b *randomBlock) ownedBy(*Reader) bool          { return false }
+func (b *randomBlock) setOwner(*Reader)              {}
+func (b *randomBlock) hasData() bool                 { return true }
+func (b *randomBlock) readBuf([]byte, Backend) error { return ErrCorrupt }
+func (b *randomBlock) len() int                      { return len(b.data) - b.off }
+func (b *randomBlock) setBase(int64)                 {}
+func (b *randomBlock) setHeader(gzip.Header)         {}
+func (b *randomBlock) txOffset() Offset              { return Offset{File: b.base, Block: uint16(b.off)} }
+
+func (b *randomBlock) seek(off int64) error {
+	if off < 0 || off > int64(len(b.data)) {
+		return ErrCorrupt
+	}
+	b.off = int(off)
+	return nil
+}
+
+// readBlock reads and decompresses the block starting at off.
+func (r *RandomReader) readBlock(off int64) (data []byte, next int64, err error) {
+	buf := make([]byte, MaxBlockSize)
+	size, isize, err := r.codec.ReadBlock(io.NewSectionReader(r.ra, off, MaxBlockSize), buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	data = make([]byte, isize)
+	if isize == 0 {
+		return data, off + int64(size), nil
+	}
+	n, err := r.codec.Decode(data, buf[:size])
+	if err != nil {
+		return nil, 0, err
+	}
+	if n != int(isize) {
+		return nil, 0, ErrCorrupt
+	}
+	return data, off + int64(size), nil
+}
+
+// NewStream returns a new Stream positioned at the start of the BGZF data.
+func (r *RandomReader) NewStream() *Stream {
+	return &Stream{r: r}
+}
+
+// Stream is a sequential reader of the data held by a RandomReader.
+// A Stream is not safe for concurrent use, but distinct Streams from
+// the same RandomReader may be used concurrently.
+type Stream struct {
+	r *RandomReader
+
+	off  Offset
+	data []byte
+	next int64
+	have bool
+
+	lastChunk Chunk
+}
+
+// Seek moves the Stream to the given virtual offset.
+func (s *Stream) Seek(off Offset) error {
+	data, next, err := s.r.block(off.File)
+	if err != nil {
+		return err
+	}
+	if int(off.Block) > len(data) {
+		return ErrCorrupt
+	}
+	s.off = off
+	s.data = data
+	s.next = next
+	s.have = true
+	s.lastChunk = Chunk{Begin: off, End: off}
+	return nil
+}
+
+// Read reads decompressed data into p, crossing block boundaries as
+// necessary. It returns io.EOF at the end of the BGZF data.
+func (s *Stream) Read(p []byte) (int, error) {
+	if !s.have {
+		err := s.Seek(Offset{})
+		if err != nil {
+			return 0, err
+		}
+	}
+	s.lastChunk.Begin = s.off
+	var n int
+	for n < len(p) {
+		if int(s.off.Block) == len(s.data) {
+			data, next, err := s.r.block(s.next)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				s.lastChunk.End = s.off
+				return n, err
+			}
+			s.off = Offset{File: s.next}
+			s.data = data
+			s.next = next
+			continue
+		}
+		if n == 0 {
+			s.lastChunk.Begin = s.off
+		}
+		_n := copy(p[n:], s.data[s.off.Block:])
+		s.off.Block += uint16(_n)
+		n += _n
+	}
+	s.lastChunk.End = s.off
+	if n == 0 && len(p) != 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// LastChunk returns the region of the BGZF data read by the last
+// successful read operation or the resulting virtual offset of the
+// last successful seek operation.
+func (s *Stream) LastChunk() Chunk { return s.lastChunk }
@@ -0,0 +1,160 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"testing"
+
+	. "github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/cache"
+)
+
+func TestRandomReader(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	payload := make([]byte, 6*BlockSize+100)
+	for i := range payload {
+		payload[i] = "ACGT\n"[rnd.Intn(5)]
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	_, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	idx, err := BuildGZI(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("BuildGZI: %v", err)
+	}
+
+	r := NewRandomReader(bytes.NewReader(buf.Bytes()), cache.NewLRU(2))
+	got, err := ioutil.ReadAll(r.NewStream())
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("unexpected data from sequential read")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			s := r.NewStream()
+			p := make([]byte, 50)
+			for i := 0; i < 50; i++ {
+				u := rnd.Int63n(int64(len(payload) - len(p)))
+				off, err := idx.Offset(u)
+				if err != nil {
+					errs <- err
+					return
+				}
+				err = s.Seek(off)
+				if err != nil {
+					errs <- err
+					return
+				}
+				_, err = io.ReadFull(s, p)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(p, payload[u:u+int64(len(p))]) {
+					t.Errorf("unexpected data at uncompressed offset %d", u)
+					return
+				}
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRandomReaderSharedCache(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	payload := make([]byte, 6*BlockSize+100)
+	for i := range payload {
+		payload[i] = "ACGT\n"[rnd.Intn(5)]
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	_, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	c := cache.NewSharedLRU(3 * MaxBlockSize)
+	readers := []*RandomReader{
+		NewRandomReader(bytes.NewReader(buf.Bytes()), c.View()),
+		NewRandomReader(bytes.NewReader(buf.Bytes()), c.View()),
+	}
+	for pass := 0; pass < 2; pass++ {
+		for i, r := range readers {
+			got, err := ioutil.ReadAll(r.NewStream())
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Errorf("unexpected data from reader %d", i)
+			}
+			if c.Size() > c.Cap() {
+				t.Errorf("shared cache size exceeds limit: %d > %d", c.Size(), c.Cap())
+			}
+		}
+	}
+	if c.Len() == 0 {
+		t.Error("no blocks retained by shared cache")
+	}
+	stats := c.Stats()
+	if stats.Gets == 0 || stats.Puts == 0 || stats.Evictions == 0 {
+		t.Errorf("unexpected shared cache statistics: %+v", stats)
+	}
+
+	// The most recently read blocks of the
+	// last reader are retained by the cache.
+	idx, err := BuildGZI(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("BuildGZI: %v", err)
+	}
+	off, err := idx.Offset(int64(len(payload) - 1))
+	if err != nil {
+		t.Fatalf("Offset: %v", err)
+	}
+	c.ResetStats()
+	s := readers[1].NewStream()
+	err = s.Seek(off)
+	if err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	p := make([]byte, 1)
+	_, err = io.ReadFull(s, p)
+	if err != nil {
+		t.Fatalf("ReadFull: %v", err)
+	}
+	if p[0] != payload[len(payload)-1] {
+		t.Errorf("unexpected final byte: got:%q want:%q", p[0], payload[len(payload)-1])
+	}
+	if stats := c.Stats(); stats.Gets != 1 || stats.Misses != 0 {
+		t.Errorf("unexpected cache statistics for cached blocks: %+v", stats)
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMinRead is the default minimum length
+	// of reads made by a RangeReader.
+	DefaultMinRead = 1 << 20
+
+	// DefaultMaxRead is the default maximum length
+	// of coalesced reads made by a RangeReader.
+	DefaultMaxRead = 16 << 20
+
+	// DefaultRangeGap is the default largest gap
+	// between planned ranges coalesced into a single
+	// read by a RangeReader.
+	DefaultRangeGap = 1 << 20
+
+	// DefaultRangeCache is the default number of
+	// reads held by a RangeReader.
+	DefaultRangeCache = 16
+)
+
+// RangeOptions specifies the behaviour of a RangeReader.
+type RangeOptions struct {
+	// MinRead is the minimum length of reads
+	// from the underlying io.ReaderAt that are
+	// not part of a plan. If MinRead is zero,
+	// DefaultMinRead is used.
+	MinRead int
+
+	// MaxRead is the maximum length of reads
+	// formed by coalescing planned ranges. If
+	// MaxRead is zero, DefaultMaxRead is used.
+	MaxRead int
+
+	// Gap is the largest gap between planned
+	// ranges that are coalesced into a single
+	// read. If Gap is zero, DefaultRangeGap is
+	// used.
+	Gap int
+
+	// Cache is the number of reads held by the
+	// RangeReader. If Cache is zero,
+	// DefaultRangeCache is used.
+	Cache int
+
+	// Retry is called after a failed read of
+	// the underlying io.ReaderAt with the number
+	// of failed attempts and the error. If Retry
+	// returns true, the read is retried after
+	// the returned delay. If Retry is nil,
+	// failed reads are not retried.
+	Retry func(attempt int, err error) (time.Duration, bool)
+}
+
+// ExponentialBackoff returns a Retry function for RangeOptions that
+// allows up to n attempts, with delays starting at base and doubling
+// after each failed attempt.
+func ExponentialBackoff(base time.Duration, n int) func(attempt int, err error) (time.Duration, bool) {
+	return func(attempt int, _ error) (time.Duration, bool) {
+		if attempt >= n {
+			return 0, false
+		}
+		return base << (attempt - 1), true
+	}
+}
+
+// RangeReader is an io.ReaderAt providing buffered access to a high
+// latency io.ReaderAt such as an object storage client. Reads from the
+// underlying io.ReaderAt are made in large ranges, either planned from
+// the BGZF chunks that will be read or extended to a minimum length,
+// and are held in a bounded cache so that BGZF block reads are served
+// without a request per block.
+//
+// A RangeReader may be used concurrently, and may be used as the
+// source of a RandomReader or, via io.NewSectionReader, of a Reader.
+type RangeReader struct {
+	ra   io.ReaderAt
+	size int64
+	opts RangeOptions
+
+	mu      sync.Mutex
+	planned []byteRange
+	held    []*heldRange
+}
+
+// byteRange is a half-open range of file offsets.
+type byteRange struct {
+	start, end int64
+}
+
+// heldRange is data read from the underlying io.ReaderAt. The ready
+// channel is closed when the read has completed.
+type heldRange struct {
+	byteRange
+	ready chan struct{}
+	data  []byte
+	err   error
+}
+
+// NewRangeReader returns a RangeReader reading from ra, which holds
+// size bytes.
+func NewRangeReader(ra io.ReaderAt, size int64, opts RangeOptions) *RangeReader {
+	if opts.MinRead == 0 {
+		opts.MinRead = DefaultMinRead
+	}
+	if opts.MaxRead == 0 {
+		opts.MaxRead = DefaultMaxRead
+	}
+	if opts.Gap == 0 {
+		opts.Gap = DefaultRangeGap
+	}
+	if opts.Cache == 0 {
+		opts.Cache = DefaultRangeCache
+	}
+	return &RangeReader{ra: ra, size: size, opts: opts}
+}
+
+// Size returns the number of bytes held by the underlying io.ReaderAt.
+func (r *RangeReader) Size() int64 { return r.size }
+
+// Plan specifies that the BGZF data in chunks, such as those returned
+// by an index query, will be read. The file ranges of the chunks are
+// merged with any existing plan and coalesced into reads of up to
+// MaxRead bytes, which are made when first needed. Planned ranges that
+// have been read are removed from the plan.
+func (r *RangeReader) Plan(chunks []Chunk) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ranges := r.planned
+	for _, c := range chunks {
+		end := c.End.File
+		if c.End.Block != 0 {
+			end += MaxBlockSize
+		}
+		if end > r.size {
+			end = r.size
+		}
+		if c.Begin.File < end {
+			ranges = append(ranges, byteRange{start: c.Begin.File, end: end})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+
+	var planned []byteRange
+	for _, br := range ranges {
+		if n := len(planned); n != 0 && br.start <= planned[n-1].end+int64(r.opts.Gap) {
+			last := &planned[n-1]
+			if br.end > last.end {
+				last.end = br.end
+			}
+			continue
+		}
+		planned = append(planned, br)
+	}
+
+	// Split coalesced ranges into reads
+	// of at most MaxRead bytes.
+	r.planned = r.planned[:0]
+	max := int64(r.opts.MaxRead)
+	for _, br := range planned {
+		for br.end-br.start > max {
+			r.planned = append(r.planned, byteRange{start: br.start, end: br.start + max})
+			br.start += max
+		}
+		r.planned = append(r.planned, br)
+	}
+}
+
+// ReadAt implements the io.ReaderAt interface.
+func (r *RangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("bgzf: negative offset")
+	}
+	var n int
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		h, err := r.rangeFor(off)
+		if err != nil {
+			return n, err
+		}
+		c := copy(p[n:], h.data[off-h.start:])
+		n += c
+		off += int64(c)
+	}
+	return n, nil
+}
+
+// rangeFor returns a held range containing off, reading it from the
+// underlying io.ReaderAt if necessary. Concurrent requests for the
+// same range share a single read.
+func (r *RangeReader) rangeFor(off int64) (*heldRange, error) {
+	r.mu.Lock()
+	for i, h := range r.held {
+		if h.start <= off && off < h.end {
+			// Move the range to the end of the
+			// eviction order.
+			copy(r.held[i:], r.held[i+1:])
+			r.held[len(r.held)-1] = h
+			r.mu.Unlock()
+			<-h.ready
+			return h, h.err
+		}
+	}
+
+	br := byteRange{start: off, end: off + int64(r.opts.MinRead)}
+	for i, p := range r.planned {
+		if p.start <= off && off < p.end {
+			br = p
+			r.planned = append(r.planned[:i], r.planned[i+1:]...)
+			break
+		}
+	}
+	if br.end > r.size {
+		br.end = r.size
+	}
+	h := &heldRange{byteRange: br, ready: make(chan struct{})}
+	if len(r.held) >= r.opts.Cache {
+		r.held[0] = nil
+		r.held = r.held[1:]
+	}
+	r.held = append(r.held, h)
+	r.mu.Unlock()
+
+	h.data, h.err = r.read(br)
+	close(h.ready)
+	if h.err != nil {
+		// Do not retain failed reads.
+		r.mu.Lock()
+		for i, o := range r.held {
+			if o == h {
+				r.held = append(r.held[:i], r.held[i+1:]...)
+				break
+			}
+		}
+		r.mu.Unlock()
+	}
+	return h, h.err
+}
+
+// read reads the range br from the underlying io.ReaderAt, retrying
+// failed reads as specified by the Retry option.
+func (r *RangeReader) read(br byteRange) ([]byte, error) {
+	buf := make([]byte, br.end-br.start)
+	for attempt := 1; ; attempt++ {
+		n, err := r.ra.ReadAt(buf, br.start)
+		if n == len(buf) {
+			return buf, nil
+		}
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if r.opts.Retry == nil {
+			return nil, err
+		}
+		delay, ok := r.opts.Retry(attempt, err)
+		if !ok {
+			return nil, err
+		}
+		time.Sleep(delay)
+	}
+}
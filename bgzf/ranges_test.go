@@ -0,0 +1,142 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+
+	. "github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/cache"
+)
+
+// countingReaderAt is an io.ReaderAt counting its reads and failing
+// the first fail reads.
+type countingReaderAt struct {
+	mu    sync.Mutex
+	data  []byte
+	reads int
+	fail  int
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	r.reads++
+	fail := r.reads <= r.fail
+	r.mu.Unlock()
+	if fail {
+		return 0, errors.New("transient failure")
+	}
+	return bytes.NewReader(r.data).ReadAt(p, off)
+}
+
+func TestRangeReader(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	payload := make([]byte, 40*BlockSize)
+	for i := range payload {
+		payload[i] = "ACGT\n"[rnd.Intn(5)]
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, *conc)
+	_, err := w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	data := buf.Bytes()
+	var blocks []int64
+	it := NewBlockIterator(bytes.NewReader(data))
+	for it.Next() {
+		blocks = append(blocks, it.Block().Offset)
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("BlockIterator: %v", err)
+	}
+
+	// Reads must return the underlying data
+	// across held range boundaries.
+	src := &countingReaderAt{data: data}
+	rr := NewRangeReader(src, int64(len(data)), RangeOptions{MinRead: 1000, Cache: 4})
+	for i := 0; i < 200; i++ {
+		off := rnd.Int63n(int64(len(data)))
+		p := make([]byte, rnd.Intn(5000))
+		n, err := rr.ReadAt(p, off)
+		want := data[off:]
+		if len(want) > len(p) {
+			want = want[:len(p)]
+		}
+		if n != len(want) || !bytes.Equal(p[:n], want) {
+			t.Fatalf("unexpected data at %d: got:%d bytes want:%d bytes", off, n, len(want))
+		}
+		if n < len(p) && err != io.EOF {
+			t.Fatalf("unexpected error for short read at %d: %v", off, err)
+		}
+		if n == len(p) && err != nil {
+			t.Fatalf("unexpected error at %d: %v", off, err)
+		}
+	}
+
+	// Planned chunks close enough to coalesce
+	// are read with a single request.
+	src = &countingReaderAt{data: data}
+	rr = NewRangeReader(src, int64(len(data)), RangeOptions{MinRead: 1000, Gap: 2 * MaxBlockSize})
+	chunks := []Chunk{
+		{Begin: Offset{File: blocks[5]}, End: Offset{File: blocks[10], Block: 100}},
+		{Begin: Offset{File: blocks[12]}, End: Offset{File: blocks[15]}},
+	}
+	rr.Plan(chunks)
+	r := NewRandomReader(rr, cache.NewLRU(4))
+	for _, c := range chunks {
+		s := r.NewStream()
+		err = s.Seek(c.Begin)
+		if err != nil {
+			t.Fatalf("Seek: %v", err)
+		}
+		u := int64(5 * BlockSize)
+		if c.Begin.File == blocks[12] {
+			u = 12 * BlockSize
+		}
+		p := make([]byte, 3*BlockSize)
+		_, err = io.ReadFull(s, p)
+		if err != nil {
+			t.Fatalf("ReadFull: %v", err)
+		}
+		if !bytes.Equal(p, payload[u:u+int64(len(p))]) {
+			t.Errorf("unexpected data for chunk %v", c)
+		}
+	}
+	if src.reads != 1 {
+		t.Errorf("unexpected number of reads for coalesced plan: got:%d want:1", src.reads)
+	}
+
+	// Failed reads are retried when requested.
+	src = &countingReaderAt{data: data, fail: 2}
+	rr = NewRangeReader(src, int64(len(data)), RangeOptions{Retry: ExponentialBackoff(0, 3)})
+	p := make([]byte, 10)
+	_, err = rr.ReadAt(p, 0)
+	if err != nil {
+		t.Errorf("unexpected error after retries: %v", err)
+	}
+	if src.reads != 3 {
+		t.Errorf("unexpected number of reads with retries: got:%d want:3", src.reads)
+	}
+	src = &countingReaderAt{data: data, fail: 1}
+	rr = NewRangeReader(src, int64(len(data)), RangeOptions{})
+	_, err = rr.ReadAt(p, 0)
+	if err == nil {
+		t.Error("expected error without retries")
+	}
+	_, err = rr.ReadAt(p, 0)
+	if err != nil {
+		t.Errorf("unexpected error after failed read: %v", err)
+	}
+}
@@ -9,11 +9,10 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"runtime"
 	"sync"
-
-	"github.com/Schaudge/grailbase/compress/libdeflate"
 )
 
 // countReader wraps flate.Reader, adding support for querying current offset.
@@ -172,7 +171,7 @@ func (d *decompressor) lazyBlock() {
 		if w, ok := d.owner.cache.(Wrapper); ok {
 			d.blk = w.Wrap(&block{owner: d.owner})
 		} else {
-			d.blk = &block{owner: d.owner}
+			d.blk = newBlock(d.owner)
 		}
 		return
 	}
@@ -261,14 +260,7 @@ func (d *decompressor) nextBlockAt(off int64, rs io.ReadSeeker) *decompressor {
 
 	// Decompress data into the decompressor's Block.
 	go func() {
-		// Possible todo: use a pool of preallocated libdeflate.Decompressor
-		// objects instead.
-		var dd libdeflate.Decompressor
-		d.err = dd.Init()
-		if d.err == nil {
-			d.err = d.blk.readBuf(d.buf.data[:d.buf.size], dd)
-			dd.Cleanup()
-		}
+		d.err = d.blk.readBuf(d.buf.data[:d.buf.size], d.owner.backend)
 		d.releaseHead()
 		d.wg.Done()
 	}()
@@ -343,6 +335,10 @@ type Reader struct {
 	// or seek operation.
 	lastChunk Chunk
 
+	// delivered is the total number of
+	// uncompressed bytes returned by Read.
+	delivered int64
+
 	// Blocked specifies the behaviour of the
 	// Reader at the end of a BGZF member.
 	// If the Reader is Blocked, a Read that
@@ -363,12 +359,24 @@ type Reader struct {
 
 	current Block
 
+	// magic is whether the last block made
+	// current was a magic EOF block.
+	magic bool
+
 	// cache is the Reader block cache. If Cache is not nil,
 	// the cache is queried for blocks before an attempt to
 	// read from the underlying io.Reader.
 	mu    sync.RWMutex
 	cache Cache
 
+	// gzi is the optional GZI index used to
+	// seek to uncompressed offsets.
+	gzi GZI
+
+	// backend is the DEFLATE implementation
+	// used to decompress blocks.
+	backend Backend
+
 	err error
 }
 
@@ -378,6 +386,16 @@ type Reader struct {
 // If rd is 0, GOMAXPROCS concurrent will be created. The returned
 // Reader should be closed after use to avoid leaking resources.
 func NewReader(r io.Reader, rd int) (*Reader, error) {
+	return NewReaderBackend(r, rd, Libdeflate)
+}
+
+// NewReaderBackend returns a new BGZF reader using the specified DEFLATE
+// backend for decompression. The rd parameter is interpreted as for
+// NewReader.
+func NewReaderBackend(r io.Reader, rd int, b Backend) (*Reader, error) {
+	if !b.valid() {
+		return nil, fmt.Errorf("bgzf: invalid backend: %v", b)
+	}
 	if rd == 0 {
 		rd = runtime.GOMAXPROCS(0)
 	}
@@ -385,6 +403,8 @@ func NewReader(r io.Reader, rd int) (*Reader, error) {
 		r: r,
 
 		head: make(chan *countReader, 1),
+
+		backend: b,
 	}
 	bg.head <- newCountReader(r)
 
@@ -408,6 +428,7 @@ func NewReader(r io.Reader, rd int) (*Reader, error) {
 	}
 	bg.current = blk
 	bg.Header = bg.current.header()
+	bg.magic = bg.current.isMagicBlock()
 
 	// Set up work loop if rd was > 1.
 	if bg.control != nil {
@@ -493,6 +514,7 @@ func (bg *Reader) Seek(off Offset) error {
 			if bg.err != nil {
 				return bg.err
 			}
+			bg.magic = bg.current.isMagicBlock()
 		}
 	}
 
@@ -509,6 +531,21 @@ func (bg *Reader) Seek(off Offset) error {
 // the last successful seek operation.
 func (bg *Reader) LastChunk() Chunk { return bg.lastChunk }
 
+// EOFBlock returns whether the last block read was a BGZF magic EOF
+// block. After a Read has returned io.EOF at the end of the stream,
+// EOFBlock reports whether the stream was terminated by the magic
+// block, as required by the SAM specification.
+func (bg *Reader) EOFBlock() bool { return bg.magic }
+
+// Progress returns the compressed file offset of the block holding the
+// next byte to be read, and the total number of uncompressed bytes that
+// have been returned by Read. The compressed offset may be compared with
+// the size of the underlying file to calculate the proportion of the
+// file that has been read.
+func (bg *Reader) Progress() (compressed, uncompressed int64) {
+	return bg.lastChunk.End.File, bg.delivered
+}
+
 // BlockLen returns the number of bytes remaining to be read from the
 // current BGZF block.
 func (bg *Reader) BlockLen() int { return bg.current.len() }
@@ -558,6 +595,7 @@ func (bg *Reader) Read(p []byte) (int, error) {
 			if bg.Blocked {
 				bg.err = nil
 				bg.lastChunk.End = bg.current.txOffset()
+				bg.delivered += int64(n)
 				return n, io.EOF
 			}
 
@@ -569,6 +607,7 @@ func (bg *Reader) Read(p []byte) (int, error) {
 	}
 
 	bg.lastChunk.End = bg.current.txOffset()
+	bg.delivered += int64(n)
 	return n, bg.err
 }
 
@@ -581,6 +620,7 @@ func (bg *Reader) nextBlock() error {
 	ok := bg.cacheSwap(base)
 	if ok {
 		bg.Header = bg.current.header()
+		bg.magic = bg.current.isMagicBlock()
 		return nil
 	}
 
@@ -589,6 +629,9 @@ func (bg *Reader) nextBlock() error {
 		bg.dec.using(bg.current).nextBlockAt(base, nil)
 		bg.current, err = bg.dec.wait()
 	} else {
+		// The current block is not held by the cache
+		// and is about to be replaced, so release it.
+		releaseBlock(bg.current)
 		var ok bool
 		for i := 0; i < cap(bg.working); i++ {
 			dec := <-bg.working
@@ -621,6 +664,7 @@ func (bg *Reader) nextBlock() error {
 	} else {
 		bg.Header = h
 	}
+	bg.magic = bg.current.isMagicBlock()
 
 	return nil
 }
@@ -695,14 +739,19 @@ func (bg *Reader) cachePut(b Block) (evicted Block, retained bool) {
 	return bg.cache.Put(b)
 }
 
-// keep puts the given Block into the cache if it exists.
+// keep puts the given Block into the cache if it exists, otherwise
+// the Block is released for reuse.
 func (bg *Reader) keep(b Block) {
-	if b == nil || !b.hasData() {
+	if b == nil {
 		return
 	}
 	bg.mu.RLock()
 	defer bg.mu.RUnlock()
-	if bg.cache != nil {
+	if bg.cache == nil {
+		releaseBlock(b)
+		return
+	}
+	if b.hasData() {
 		bg.cache.Put(b)
 	}
 }
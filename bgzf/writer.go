@@ -7,11 +7,10 @@ package bgzf
 import (
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
-
-	"github.com/Schaudge/grailbase/compress/libdeflate"
 )
 
 // Writer implements BGZF blocked gzip compression.
@@ -23,8 +22,20 @@ type Writer struct {
 	gzip.Header
 	w io.Writer
 
+	// blockSize is the target size of
+	// uncompressed data blocks.
+	blockSize int
+
+	// codec is the block container used in
+	// place of BGZF if it is not nil.
+	codec BlockCodec
+
 	active *compressor
 
+	// compressors holds all the
+	// compressors of the Writer.
+	compressors []compressor
+
 	queue chan *compressor
 	qwg   sync.WaitGroup
 
@@ -34,8 +45,15 @@ type Writer struct {
 
 	closed bool
 
-	m   sync.Mutex
-	err error
+	m       sync.Mutex
+	err     error
+	written int64
+
+	// gzi is the GZI index recorded during
+	// writing if gziWriter is not nil.
+	gzi       GZI
+	gziWriter io.Writer
+	uwritten  int64
 }
 
 // NewWriter returns a new Writer. Writes to the returned writer are
@@ -53,17 +71,47 @@ func NewWriter(w io.Writer, wc int) *Writer {
 //
 // The number of concurrent write compressors is specified by wc.
 func NewWriterLevel(w io.Writer, level, wc int) (*Writer, error) {
+	return NewWriterBackend(w, level, wc, Libdeflate)
+}
+
+// NewWriterBackend returns a new Writer using the specified compression
+// level and DEFLATE backend. The level and wc parameters are interpreted
+// as for NewWriterLevel.
+func NewWriterBackend(w io.Writer, level, wc int, b Backend) (*Writer, error) {
 	if level < gzip.DefaultCompression || level > gzip.BestCompression {
 		return nil, fmt.Errorf("bgzf: invalid compression level: %d", level)
 	}
+	if !b.valid() {
+		return nil, fmt.Errorf("bgzf: invalid backend: %v", b)
+	}
+	return newWriter(w, level, wc, b, nil), nil
+}
+
+// NewWriterCodec returns a new Writer writing blocks using the provided
+// BlockCodec rather than BGZF. The wc parameter is interpreted as for
+// NewWriterLevel. The gzip header fields of the Writer are not used
+// when writing with a BlockCodec.
+//
+// NewWriterCodec is experimental. Data written by the returned Writer
+// can only be read using a RandomReader with the same BlockCodec.
+func NewWriterCodec(w io.Writer, wc int, c BlockCodec) (*Writer, error) {
+	if c == nil {
+		return nil, errors.New("bgzf: nil block codec")
+	}
+	return newWriter(w, gzip.DefaultCompression, wc, Libdeflate, c), nil
+}
+
+func newWriter(w io.Writer, level, wc int, b Backend, codec BlockCodec) *Writer {
 	wc++ // We count one for the active compressor.
 	if wc < 2 {
 		wc = 2
 	}
 	bg := &Writer{
-		w:       w,
-		waiting: make(chan *compressor, wc),
-		queue:   make(chan *compressor, wc),
+		w:         w,
+		blockSize: BlockSize,
+		codec:     codec,
+		waiting:   make(chan *compressor, wc),
+		queue:     make(chan *compressor, wc),
 	}
 	bg.Header.OS = 0xff // Set default OS to unknown.
 
@@ -71,11 +119,14 @@ func NewWriterLevel(w io.Writer, level, wc int) (*Writer, error) {
 	for i := range c {
 		c[i].Header = &bg.Header
 		c[i].level = level
+		c[i].backend = b
+		c[i].codec = codec
 		c[i].waiting = bg.waiting
 		c[i].flush = make(chan *compressor, 1)
 		c[i].qwg = &bg.qwg
 		bg.waiting <- &c[i]
 	}
+	bg.compressors = c
 	bg.active = <-bg.waiting
 
 	bg.wg.Add(1)
@@ -88,7 +139,7 @@ func NewWriterLevel(w io.Writer, level, wc int) (*Writer, error) {
 		}
 	}()
 
-	return bg, nil
+	return bg
 }
 
 func writeOK(bg *Writer, c *compressor) bool {
@@ -102,7 +153,14 @@ func writeOK(bg *Writer, c *compressor) bool {
 		return true
 	}
 
-	_, err := io.Copy(bg.w, &c.buf)
+	n, err := io.Copy(bg.w, &c.buf)
+	bg.m.Lock()
+	if bg.gziWriter != nil && bg.written != 0 && c.isize != 0 {
+		bg.gzi = append(bg.gzi, GZIEntry{Compressed: bg.written, Uncompressed: bg.uwritten})
+	}
+	bg.written += n
+	bg.uwritten += int64(c.isize)
+	bg.m.Unlock()
 	bg.qwg.Done()
 	if err != nil {
 		bg.setErr(err)
@@ -115,10 +173,17 @@ func writeOK(bg *Writer, c *compressor) bool {
 
 type compressor struct {
 	*gzip.Header
-	ld    *libdeflate.Writer
-	level int
+	gz      memberWriter
+	level   int
+	backend Backend
+
+	// codec and encoded are used in place
+	// of gz when writing with a BlockCodec.
+	codec   BlockCodec
+	encoded []byte
 
 	next  int
+	isize int
 	block [BlockSize]byte
 	buf   bytes.Buffer
 
@@ -133,44 +198,49 @@ type compressor struct {
 func (c *compressor) writeBlock() {
 	defer func() { c.flush <- c }()
 
-	if c.ld == nil {
-		c.ld, c.err = libdeflate.NewWriterLevel(&c.buf, c.level)
+	if c.codec != nil {
+		c.isize = c.next
+		c.encoded, c.err = c.codec.Encode(c.encoded[:0], c.block[:c.next])
+		if c.err != nil {
+			return
+		}
+		if len(c.encoded) > MaxBlockSize {
+			c.err = ErrBlockOverflow
+			return
+		}
+		c.buf.Write(c.encoded)
+		c.next = 0
+		return
+	}
+
+	if c.gz == nil {
+		c.gz, c.err = getMemberWriter(c.backend, &c.buf, c.level)
 		if c.err != nil {
 			return
 		}
 	} else {
-		c.ld.Reset(&c.buf)
+		c.gz.Reset(&c.buf)
 	}
-	c.ld.Header = gzip.Header{
+	c.gz.setHeader(gzip.Header{
 		Comment: c.Comment,
 		Extra:   append([]byte(bgzfExtra), c.Extra...),
 		ModTime: c.ModTime,
 		Name:    c.Name,
 		OS:      c.OS,
-	}
+	})
 
-	_, c.err = c.ld.Write(c.block[:c.next])
+	c.isize = c.next
+	_, c.err = c.gz.Write(c.block[:c.next])
 	if c.err != nil {
 		return
 	}
-	c.err = c.ld.Close()
+	c.err = c.gz.Close()
 	if c.err != nil {
 		return
 	}
 	c.next = 0
 
-	b := c.buf.Bytes()
-	i := bytes.Index(b, bgzfExtraPrefix)
-	if i < 0 {
-		c.err = gzip.ErrHeader
-		return
-	}
-	size := len(b) - 1
-	if size >= MaxBlockSize {
-		c.err = ErrBlockOverflow
-		return
-	}
-	b[i+4], b[i+5] = byte(size), byte(size>>8)
+	c.err = setBlockSize(c.buf.Bytes())
 }
 
 // Next returns the index of the start of the next write within the
@@ -186,6 +256,76 @@ func (bg *Writer) Next() (int, error) {
 	return bg.active.next, nil
 }
 
+// SetBlockSize sets the maximum size of the uncompressed data held by
+// each block written by the Writer to n, which must be between 1 and
+// BlockSize inclusive. Smaller blocks give finer grained random access
+// at the cost of compression ratio. If the current block holds more
+// than n bytes, it is flushed.
+func (bg *Writer) SetBlockSize(n int) error {
+	if n < 1 || n > BlockSize {
+		return fmt.Errorf("bgzf: invalid block size: %d", n)
+	}
+	if bg.closed {
+		return ErrClosed
+	}
+	if bg.active.next > n {
+		err := bg.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	bg.blockSize = n
+	return nil
+}
+
+// SetGZIWriter specifies that the Writer should record a GZI index of
+// the blocks it writes and write the index to w when the Writer is
+// closed. SetGZIWriter must be called before the first write.
+func (bg *Writer) SetGZIWriter(w io.Writer) {
+	bg.m.Lock()
+	bg.gziWriter = w
+	bg.gzi = GZI{{}}
+	bg.m.Unlock()
+}
+
+// GZI returns the GZI index recorded by the Writer. It returns nil if
+// SetGZIWriter has not been called. The returned index is only complete
+// after the Writer has been closed.
+func (bg *Writer) GZI() GZI {
+	bg.m.Lock()
+	defer bg.m.Unlock()
+	return bg.gzi
+}
+
+// VirtualOffset returns the virtual offset that will be held by the next
+// byte written to the Writer. If boundary is true, any buffered data is
+// first flushed so that the next byte will start a new BGZF block.
+// VirtualOffset waits for pending writes to complete.
+//
+// A Write that does not fit in the space remaining in the current block
+// begins a new block. In that case the returned offset refers to the end
+// of the current block, which a Reader treats as equivalent to the start
+// of the following block, but which will not compare equal to offsets
+// obtained from a Reader's LastChunk.
+func (bg *Writer) VirtualOffset(boundary bool) (Offset, error) {
+	if bg.closed {
+		return Offset{}, ErrClosed
+	}
+	if boundary {
+		err := bg.Flush()
+		if err != nil {
+			return Offset{}, err
+		}
+	}
+	err := bg.Wait()
+	if err != nil {
+		return Offset{}, err
+	}
+	bg.m.Lock()
+	defer bg.m.Unlock()
+	return Offset{File: bg.written, Block: uint16(bg.active.next)}, nil
+}
+
 // Write writes the compressed form of b to the underlying io.Writer.
 // Decompressed data blocks are limited to BlockSize, so individual
 // byte slices may span block boundaries, however the Writer attempts
@@ -203,22 +343,64 @@ func (bg *Writer) Write(b []byte) (int, error) {
 	var n int
 	for ; len(b) > 0 && err == nil; err = bg.Error() {
 		var _n int
-		if c.next == 0 || c.next+len(b) <= len(c.block) {
-			_n = copy(c.block[c.next:], b)
+		if c.next == 0 || c.next+len(b) <= bg.blockSize {
+			_n = copy(c.block[c.next:bg.blockSize], b)
 			b = b[_n:]
 			c.next += _n
 			n += _n
 		}
 
-		if c.next == len(c.block) || _n == 0 {
+		if c.next == bg.blockSize || _n == 0 {
+			bg.queue <- c
+			bg.qwg.Add(1)
+			go c.writeBlock()
+			c = <-bg.waiting
+		}
+	}
+	bg.active = c
+
+	return n, bg.Error()
+}
+
+// ReadFrom implements the io.ReaderFrom interface. Data is read from r
+// directly into the current data block until r returns io.EOF or an
+// error. Unlike Write, ReadFrom fills each data block completely, so
+// data read from r is not kept within a single block.
+func (bg *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if bg.closed {
+		return 0, ErrClosed
+	}
+	err := bg.Error()
+	if err != nil {
+		return 0, err
+	}
+
+	c := bg.active
+	var n int64
+	for err == nil {
+		var _n int
+		_n, err = r.Read(c.block[c.next:bg.blockSize])
+		c.next += _n
+		n += int64(_n)
+
+		if c.next == bg.blockSize {
 			bg.queue <- c
 			bg.qwg.Add(1)
 			go c.writeBlock()
 			c = <-bg.waiting
 		}
+		if err == nil {
+			err = bg.Error()
+		}
 	}
 	bg.active = c
 
+	if err == io.EOF {
+		err = nil
+	}
+	if err != nil {
+		return n, err
+	}
 	return n, bg.Error()
 }
 
@@ -286,7 +468,25 @@ func (bg *Writer) Close() error {
 		close(bg.queue)
 		bg.wg.Wait()
 		if bg.err == nil {
-			_, bg.err = bg.w.Write([]byte(magicBlock))
+			eof := []byte(magicBlock)
+			if bg.codec != nil {
+				eof = bg.codec.EOF()
+			}
+			_, bg.err = bg.w.Write(eof)
+		}
+		if bg.err == nil && bg.gziWriter != nil {
+			bg.err = WriteGZI(bg.gziWriter, bg.gzi)
+		}
+		if bg.err == nil {
+			// All compressors are idle, so their
+			// member writers can be reused.
+			for i := range bg.compressors {
+				c := &bg.compressors[i]
+				if c.gz != nil {
+					putMemberWriter(c.backend, c.level, c.gz)
+					c.gz = nil
+				}
+			}
 		}
 	}
 	return bg.err
@@ -0,0 +1,159 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zstd provides an experimental seekable zstd block container
+// for use with the bgzf package's BlockCodec interface.
+//
+// The container is not compatible with BGZF and is not read by samtools
+// or htslib. It is intended for internal pipelines where the improved
+// compression of zstd is more valuable than interoperability.
+//
+// Each block is a zstd skippable frame holding the size of the block and
+// of its uncompressed data, followed by a single zstd frame holding the
+// block data. The skippable frame plays the role of the BGZF BSIZE field,
+// allowing blocks to be located without decompression, so that virtual
+// offsets may be used as they are for BGZF. The end of a stream is marked
+// by a block with no data. Since zstd decoders ignore skippable frames,
+// a complete stream can be decompressed by standard zstd tools.
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	kzstd "github.com/klauspost/compress/zstd"
+
+	"github.com/Schaudge/hts/bgzf"
+)
+
+const (
+	// Magic is the skippable frame magic number
+	// marking the start of a block.
+	Magic = 0x184d2a5b
+
+	// HeaderSize is the size of the skippable
+	// frame at the start of each block.
+	HeaderSize = 16
+)
+
+var (
+	ErrCorrupt = errors.New("zstd: corrupt block")
+	ErrNoMagic = errors.New("zstd: missing block magic")
+)
+
+// Codec is a zstd bgzf.BlockCodec.
+type Codec struct {
+	enc *kzstd.Encoder
+	dec *kzstd.Decoder
+}
+
+var _ bgzf.BlockCodec = (*Codec)(nil)
+
+// Level is a zstd compression level.
+type Level = kzstd.EncoderLevel
+
+// Compression levels.
+const (
+	SpeedFastest = kzstd.SpeedFastest
+	SpeedDefault = kzstd.SpeedDefault
+)
+
+// NewCodec returns a zstd Codec using the given compression level.
+func NewCodec(level Level) (*Codec, error) {
+	enc, err := kzstd.NewWriter(nil, kzstd.WithEncoderLevel(level), kzstd.WithEncoderCRC(true))
+	if err != nil {
+		return nil, fmt.Errorf("zstd: failed to create encoder: %v", err)
+	}
+	dec, err := kzstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("zstd: failed to create decoder: %v", err)
+	}
+	return &Codec{enc: enc, dec: dec}, nil
+}
+
+// ReadBlock reads a complete block from r into buf.
+func (c *Codec) ReadBlock(r io.Reader, buf []byte) (size int, isize uint32, err error) {
+	if len(buf) < HeaderSize {
+		return 0, 0, io.ErrShortBuffer
+	}
+	n, err := io.ReadFull(r, buf[:HeaderSize])
+	if err != nil {
+		if err == io.ErrUnexpectedEOF || (err == io.EOF && n != 0) {
+			return 0, 0, ErrCorrupt
+		}
+		return 0, 0, err
+	}
+	if binary.LittleEndian.Uint32(buf) != Magic {
+		return 0, 0, ErrNoMagic
+	}
+	if binary.LittleEndian.Uint32(buf[4:]) != HeaderSize-8 {
+		return 0, 0, ErrCorrupt
+	}
+	size = int(binary.LittleEndian.Uint32(buf[8:]))
+	isize = binary.LittleEndian.Uint32(buf[12:])
+	if size < HeaderSize || size > len(buf) || isize > bgzf.BlockSize {
+		return 0, 0, ErrCorrupt
+	}
+	_, err = io.ReadFull(r, buf[HeaderSize:size])
+	if err != nil {
+		return 0, 0, ErrCorrupt
+	}
+	return size, isize, nil
+}
+
+// Decode decompresses the block in src into dst.
+func (c *Codec) Decode(dst, src []byte) (int, error) {
+	if len(src) < HeaderSize {
+		return 0, ErrCorrupt
+	}
+	if len(src) == HeaderSize {
+		return 0, nil
+	}
+	isize := int(binary.LittleEndian.Uint32(src[12:]))
+	if isize > len(dst) {
+		return 0, io.ErrShortBuffer
+	}
+	out, err := c.dec.DecodeAll(src[HeaderSize:], dst[:0])
+	if err != nil {
+		return 0, err
+	}
+	if len(out) != isize {
+		return 0, bgzf.ErrBlockSizeMismatch
+	}
+	// DecodeAll has written into dst unless
+	// the output exceeded its capacity.
+	return copy(dst, out), nil
+}
+
+// Encode appends a block holding data to dst.
+func (c *Codec) Encode(dst, data []byte) ([]byte, error) {
+	if len(data) > bgzf.BlockSize {
+		return dst, bgzf.ErrBlockOverflow
+	}
+	start := len(dst)
+	dst = appendHeader(dst)
+	if len(data) != 0 {
+		dst = c.enc.EncodeAll(data, dst)
+	}
+	binary.LittleEndian.PutUint32(dst[start+8:], uint32(len(dst)-start))
+	binary.LittleEndian.PutUint32(dst[start+12:], uint32(len(data)))
+	return dst, nil
+}
+
+// EOF returns the empty block marking the end of a stream.
+func (c *Codec) EOF() []byte {
+	b := appendHeader(nil)
+	binary.LittleEndian.PutUint32(b[8:], HeaderSize)
+	return b
+}
+
+// appendHeader appends a block header with zero sizes to dst.
+func appendHeader(dst []byte) []byte {
+	var h [HeaderSize]byte
+	binary.LittleEndian.PutUint32(h[:], Magic)
+	binary.LittleEndian.PutUint32(h[4:], HeaderSize-8)
+	return append(dst, h[:]...)
+}
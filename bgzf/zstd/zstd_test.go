@@ -0,0 +1,113 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	kzstd "github.com/klauspost/compress/zstd"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/cache"
+)
+
+func TestCodec(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	payload := make([]byte, 5*bgzf.BlockSize+1234)
+	for i := range payload {
+		payload[i] = "ACGT\n"[rnd.Intn(5)]
+	}
+
+	c, err := NewCodec(SpeedDefault)
+	if err != nil {
+		t.Fatalf("NewCodec: %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := bgzf.NewWriterCodec(&buf, 2, c)
+	if err != nil {
+		t.Fatalf("NewWriterCodec: %v", err)
+	}
+	_, err = w.Write(payload)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !bytes.HasSuffix(buf.Bytes(), c.EOF()) {
+		t.Error("missing EOF block")
+	}
+
+	// Check block offsets by reading each block in turn.
+	var (
+		offsets []int64
+		off     int64
+		data    = make([]byte, bgzf.BlockSize)
+		blk     = make([]byte, bgzf.MaxBlockSize)
+		r       = bytes.NewReader(buf.Bytes())
+	)
+	for {
+		size, isize, err := c.ReadBlock(r, blk)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadBlock: %v", err)
+		}
+		n, err := c.Decode(data[:isize], blk[:size])
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if n != int(isize) {
+			t.Errorf("unexpected decoded length: got:%d want:%d", n, isize)
+		}
+		offsets = append(offsets, off)
+		off += int64(size)
+	}
+	if len(offsets) != 7 {
+		t.Errorf("unexpected number of blocks: got:%d want:7", len(offsets))
+	}
+
+	rr := bgzf.NewRandomReaderCodec(bytes.NewReader(buf.Bytes()), cache.NewLRU(2), c)
+	s := rr.NewStream()
+	got, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("unexpected data read from stream")
+	}
+	p := make([]byte, 100)
+	err = s.Seek(bgzf.Offset{File: offsets[3], Block: 17})
+	if err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	_, err = io.ReadFull(s, p)
+	if err != nil {
+		t.Fatalf("ReadFull: %v", err)
+	}
+	if want := payload[3*bgzf.BlockSize+17:][:len(p)]; !bytes.Equal(p, want) {
+		t.Error("unexpected data after seek")
+	}
+
+	// Standard zstd decoders skip the block headers.
+	dec, err := kzstd.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create zstd decoder: %v", err)
+	}
+	defer dec.Close()
+	got, err = ioutil.ReadAll(dec)
+	if err != nil {
+		t.Fatalf("failed to decode with zstd decoder: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("unexpected data from zstd decoder")
+	}
+}
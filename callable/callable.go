@@ -0,0 +1,266 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package callable implements classification of reference positions by
+// depth of coverage, in the style of the GATK CallableLoci tool.
+//
+// Each position is classified as having no coverage, low coverage,
+// callable coverage or excessive coverage according to depth
+// thresholds, and consecutive positions of the same class are merged
+// into intervals. A Summary accepts per-base depths in the form
+// produced by coverage.Depth, so its Add method may be passed directly
+// as a coverage.DepthFunc. Summaries of separate shards may be combined
+// with Merge.
+package callable
+
+import (
+	"errors"
+
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrThreshold = errors.New("callable: invalid depth threshold")
+	ErrOptions   = errors.New("callable: mismatched options")
+	ErrRange     = errors.New("callable: invalid position")
+	ErrOverlap   = errors.New("callable: overlapping depths")
+)
+
+// State is the coverage class of a reference position.
+type State int
+
+const (
+	NoCoverage State = iota
+	LowCoverage
+	Callable
+	ExcessiveCoverage
+
+	numStates
+)
+
+var stateNames = [numStates]string{
+	NoCoverage:        "NO_COVERAGE",
+	LowCoverage:       "LOW_COVERAGE",
+	Callable:          "CALLABLE",
+	ExcessiveCoverage: "EXCESSIVE_COVERAGE",
+}
+
+// String returns the name of the state used by CallableLoci.
+func (s State) String() string {
+	if s < 0 || s >= numStates {
+		return "UNKNOWN"
+	}
+	return stateNames[s]
+}
+
+// Options specifies the depth thresholds of a Summary.
+type Options struct {
+	// MinDepth is the minimum depth of a
+	// callable position. Positions with a
+	// non-zero depth less than MinDepth have
+	// low coverage.
+	MinDepth int
+
+	// MaxDepth is the maximum depth of a
+	// callable position. Positions with a
+	// greater depth have excessive coverage.
+	// If MaxDepth is zero, there is no
+	// maximum depth.
+	MaxDepth int
+}
+
+// state returns the state of a position with depth d.
+func (o Options) state(d uint32) State {
+	switch {
+	case d == 0:
+		return NoCoverage
+	case int64(d) < int64(o.MinDepth):
+		return LowCoverage
+	case o.MaxDepth != 0 && int64(d) > int64(o.MaxDepth):
+		return ExcessiveCoverage
+	default:
+		return Callable
+	}
+}
+
+// Interval is a zero-based half-open interval of positions on a
+// reference sharing a coverage state.
+type Interval struct {
+	Ref        *sam.Reference
+	Start, End int
+	State      State
+}
+
+// Feature returns a four field BED feature describing the interval,
+// named by its state.
+func (i Interval) Feature() *bed.Feature {
+	return &bed.Feature{
+		Chrom:  i.Ref.Name(),
+		Start:  i.Start,
+		End:    i.End,
+		Name:   i.State.String(),
+		Fields: 4,
+	}
+}
+
+// Summary holds the coverage states of the positions for which depths
+// have been added. A Summary is not safe for concurrent use; depths of
+// concurrently counted shards should be added to separate summaries
+// that are then merged.
+type Summary struct {
+	opts      Options
+	intervals []Interval
+	bases     [numStates]int
+}
+
+// NewSummary returns a new empty Summary using the given thresholds.
+func NewSummary(opts Options) (*Summary, error) {
+	if opts.MinDepth < 0 || opts.MaxDepth < 0 || (opts.MaxDepth != 0 && opts.MaxDepth < opts.MinDepth) {
+		return nil, ErrThreshold
+	}
+	return &Summary{opts: opts}, nil
+}
+
+// Add adds the depths of consecutive positions on ref starting from the
+// zero-based position start. Depths may be added in any order, but each
+// position may only be added once. The signature of Add matches
+// coverage.DepthFunc.
+func (s *Summary) Add(ref *sam.Reference, start int, depths []uint32) error {
+	if ref == nil || start < 0 || start+len(depths) > ref.Len() {
+		return ErrRange
+	}
+	var runs []Interval
+	for i, d := range depths {
+		st := s.opts.state(d)
+		if n := len(runs); n != 0 && runs[n-1].State == st {
+			runs[n-1].End++
+			continue
+		}
+		runs = append(runs, Interval{Ref: ref, Start: start + i, End: start + i + 1, State: st})
+	}
+	return s.insert(runs)
+}
+
+// Merge adds the intervals of o to s. The summaries must have been
+// created with the same options and must not share positions.
+func (s *Summary) Merge(o *Summary) error {
+	if s.opts != o.opts {
+		return ErrOptions
+	}
+	return s.insert(o.intervals)
+}
+
+// insert adds the sorted intervals to s.
+func (s *Summary) insert(intervals []Interval) error {
+	if len(intervals) == 0 {
+		return nil
+	}
+	var err error
+	if n := len(s.intervals); n == 0 || !less(intervals[0], s.intervals[n-1]) {
+		// Intervals following all those held
+		// are appended in place. Only the first
+		// may overlap, in which case s is left
+		// unaltered.
+		for _, iv := range intervals {
+			s.intervals, err = appendInterval(s.intervals, iv)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		merged := make([]Interval, 0, len(s.intervals)+len(intervals))
+		a, b := s.intervals, intervals
+		for len(a) != 0 || len(b) != 0 {
+			var iv Interval
+			if len(b) == 0 || (len(a) != 0 && less(a[0], b[0])) {
+				iv, a = a[0], a[1:]
+			} else {
+				iv, b = b[0], b[1:]
+			}
+			merged, err = appendInterval(merged, iv)
+			if err != nil {
+				return err
+			}
+		}
+		s.intervals = merged
+	}
+	for _, iv := range intervals {
+		s.bases[iv.State] += iv.End - iv.Start
+	}
+	return nil
+}
+
+// less returns whether a sorts before b by reference and start.
+func less(a, b Interval) bool {
+	if a.Ref.ID() != b.Ref.ID() {
+		return a.Ref.ID() < b.Ref.ID()
+	}
+	return a.Start < b.Start
+}
+
+// appendInterval appends iv to the sorted intervals in dst, joining it
+// to the last interval if they abut and share a state.
+func appendInterval(dst []Interval, iv Interval) ([]Interval, error) {
+	if n := len(dst); n != 0 {
+		last := &dst[n-1]
+		if last.Ref.ID() == iv.Ref.ID() {
+			if iv.Start < last.End {
+				return dst, ErrOverlap
+			}
+			if iv.Start == last.End && iv.State == last.State {
+				last.End = iv.End
+				return dst, nil
+			}
+		}
+	}
+	return append(dst, iv), nil
+}
+
+// Bases returns the number of positions added with the given state.
+func (s *Summary) Bases(st State) int {
+	if st < 0 || st >= numStates {
+		return 0
+	}
+	return s.bases[st]
+}
+
+// Intervals returns the intervals of the summary in reference and
+// position order. Consecutive positions sharing a state are held in a
+// single interval. The returned slice must not be altered.
+func (s *Summary) Intervals() []Interval { return s.intervals }
+
+// Features returns the BED features of the intervals of the summary
+// with the given state, in reference and position order.
+func (s *Summary) Features(st State) []*bed.Feature {
+	var f []*bed.Feature
+	for _, iv := range s.intervals {
+		if iv.State == st {
+			f = append(f, iv.Feature())
+		}
+	}
+	return f
+}
+
+// WriteBED writes the intervals of the summary with the given states to
+// w in reference and position order. If no states are given, all
+// intervals are written.
+func (s *Summary) WriteBED(w *bed.Writer, states ...State) error {
+	var want [numStates]bool
+	for _, st := range states {
+		if 0 <= st && st < numStates {
+			want[st] = true
+		}
+	}
+	for _, iv := range s.intervals {
+		if len(states) != 0 && !want[iv.State] {
+			continue
+		}
+		err := w.Write(iv.Feature())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package callable
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestState(t *testing.T) {
+	opts := Options{MinDepth: 4, MaxDepth: 10}
+	for _, test := range []struct {
+		depth uint32
+		want  State
+	}{
+		{depth: 0, want: NoCoverage},
+		{depth: 1, want: LowCoverage},
+		{depth: 3, want: LowCoverage},
+		{depth: 4, want: Callable},
+		{depth: 10, want: Callable},
+		{depth: 11, want: ExcessiveCoverage},
+		{depth: 1<<32 - 1, want: ExcessiveCoverage},
+	} {
+		if got := opts.state(test.depth); got != test.want {
+			t.Errorf("unexpected state for depth %d: got:%v want:%v", test.depth, got, test.want)
+		}
+	}
+	if got := (Options{}).state(1 << 20); got != Callable {
+		t.Errorf("unexpected state without thresholds: got:%v want:%v", got, Callable)
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	for _, opts := range []Options{
+		{MinDepth: -1},
+		{MaxDepth: -1},
+		{MinDepth: 5, MaxDepth: 4},
+	} {
+		_, err := NewSummary(opts)
+		if err != ErrThreshold {
+			t.Errorf("unexpected error for %+v: got:%v want:%v", opts, err, ErrThreshold)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 20, nil, nil)
+	chr2, _ := sam.NewReference("chr2", "", "", 10, nil, nil)
+	_, err := sam.NewHeader(nil, []*sam.Reference{chr1, chr2})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	depths := map[*sam.Reference][]uint32{
+		chr1: {0, 0, 1, 2, 5, 5, 6, 20, 20, 5, 5, 5, 0, 3, 3, 4, 4, 4, 4, 4},
+		chr2: {7, 7, 7, 7, 7, 0, 0, 0, 0, 0},
+	}
+	want := []Interval{
+		{Ref: chr1, Start: 0, End: 2, State: NoCoverage},
+		{Ref: chr1, Start: 2, End: 4, State: LowCoverage},
+		{Ref: chr1, Start: 4, End: 7, State: Callable},
+		{Ref: chr1, Start: 7, End: 9, State: ExcessiveCoverage},
+		{Ref: chr1, Start: 9, End: 12, State: Callable},
+		{Ref: chr1, Start: 12, End: 13, State: NoCoverage},
+		{Ref: chr1, Start: 13, End: 15, State: LowCoverage},
+		{Ref: chr1, Start: 15, End: 20, State: Callable},
+		{Ref: chr2, Start: 0, End: 5, State: Callable},
+		{Ref: chr2, Start: 5, End: 10, State: NoCoverage},
+	}
+	wantBases := [numStates]int{
+		NoCoverage:        8,
+		LowCoverage:       4,
+		Callable:          16,
+		ExcessiveCoverage: 2,
+	}
+	opts := Options{MinDepth: 4, MaxDepth: 10}
+
+	type shard struct {
+		ref      *sam.Reference
+		beg, end int
+	}
+	for _, test := range []struct {
+		name   string
+		shards []shard
+	}{
+		{
+			name:   "whole",
+			shards: []shard{{chr1, 0, 20}, {chr2, 0, 10}},
+		},
+		{
+			name:   "ordered",
+			shards: []shard{{chr1, 0, 5}, {chr1, 5, 13}, {chr1, 13, 20}, {chr2, 0, 3}, {chr2, 3, 10}},
+		},
+		{
+			name:   "unordered",
+			shards: []shard{{chr2, 3, 10}, {chr1, 13, 20}, {chr1, 0, 5}, {chr2, 0, 3}, {chr1, 5, 13}},
+		},
+	} {
+		// Add all shards to one summary.
+		s, err := NewSummary(opts)
+		if err != nil {
+			t.Fatalf("unexpected error creating summary: %v", err)
+		}
+		for _, sh := range test.shards {
+			err = s.Add(sh.ref, sh.beg, depths[sh.ref][sh.beg:sh.end])
+			if err != nil {
+				t.Fatalf("unexpected error adding depths for %s: %v", test.name, err)
+			}
+		}
+		checkSummary(t, test.name, s, want, wantBases)
+
+		// Add each shard to its own summary
+		// and merge.
+		m, err := NewSummary(opts)
+		if err != nil {
+			t.Fatalf("unexpected error creating summary: %v", err)
+		}
+		for _, sh := range test.shards {
+			p, err := NewSummary(opts)
+			if err != nil {
+				t.Fatalf("unexpected error creating summary: %v", err)
+			}
+			err = p.Add(sh.ref, sh.beg, depths[sh.ref][sh.beg:sh.end])
+			if err != nil {
+				t.Fatalf("unexpected error adding depths for %s: %v", test.name, err)
+			}
+			err = m.Merge(p)
+			if err != nil {
+				t.Fatalf("unexpected error merging summary for %s: %v", test.name, err)
+			}
+		}
+		checkSummary(t, test.name+" merged", m, want, wantBases)
+	}
+}
+
+func checkSummary(t *testing.T, name string, s *Summary, want []Interval, wantBases [numStates]int) {
+	t.Helper()
+	if got := s.Intervals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected intervals for %s:\ngot: %v\nwant:%v", name, got, want)
+	}
+	for st := NoCoverage; st < numStates; st++ {
+		if got := s.Bases(st); got != wantBases[st] {
+			t.Errorf("unexpected %v bases for %s: got:%d want:%d", st, name, got, wantBases[st])
+		}
+	}
+}
+
+func TestSummaryErrors(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 20, nil, nil)
+	s, err := NewSummary(Options{MinDepth: 2})
+	if err != nil {
+		t.Fatalf("unexpected error creating summary: %v", err)
+	}
+	err = s.Add(chr1, 5, []uint32{1, 2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error adding depths: %v", err)
+	}
+	for _, test := range []struct {
+		ref   *sam.Reference
+		start int
+		n     int
+		want  error
+	}{
+		{ref: nil, start: 0, n: 1, want: ErrRange},
+		{ref: chr1, start: -1, n: 1, want: ErrRange},
+		{ref: chr1, start: 18, n: 3, want: ErrRange},
+		{ref: chr1, start: 7, n: 2, want: ErrOverlap},
+		{ref: chr1, start: 4, n: 2, want: ErrOverlap},
+		{ref: chr1, start: 0, n: 10, want: ErrOverlap},
+	} {
+		err := s.Add(test.ref, test.start, make([]uint32, test.n))
+		if err != test.want {
+			t.Errorf("unexpected error adding %d depths at %d: got:%v want:%v", test.n, test.start, err, test.want)
+		}
+	}
+	want := []Interval{
+		{Ref: chr1, Start: 5, End: 6, State: LowCoverage},
+		{Ref: chr1, Start: 6, End: 8, State: Callable},
+	}
+	if got := s.Intervals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("summary altered by failed additions:\ngot: %v\nwant:%v", got, want)
+	}
+
+	o, err := NewSummary(Options{MinDepth: 3})
+	if err != nil {
+		t.Fatalf("unexpected error creating summary: %v", err)
+	}
+	err = s.Merge(o)
+	if err != ErrOptions {
+		t.Errorf("unexpected error merging mismatched summaries: got:%v want:%v", err, ErrOptions)
+	}
+}
+
+func TestWriteBED(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 20, nil, nil)
+	s, err := NewSummary(Options{MinDepth: 2, MaxDepth: 5})
+	if err != nil {
+		t.Fatalf("unexpected error creating summary: %v", err)
+	}
+	err = s.Add(chr1, 0, []uint32{0, 1, 2, 2, 6, 2})
+	if err != nil {
+		t.Fatalf("unexpected error adding depths: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = s.WriteBED(bed.NewWriter(&buf))
+	if err != nil {
+		t.Fatalf("unexpected error writing BED: %v", err)
+	}
+	want := "chr1\t0\t1\tNO_COVERAGE\n" +
+		"chr1\t1\t2\tLOW_COVERAGE\n" +
+		"chr1\t2\t4\tCALLABLE\n" +
+		"chr1\t4\t5\tEXCESSIVE_COVERAGE\n" +
+		"chr1\t5\t6\tCALLABLE\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected BED output:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	err = s.WriteBED(bed.NewWriter(&buf), Callable)
+	if err != nil {
+		t.Fatalf("unexpected error writing BED: %v", err)
+	}
+	want = "chr1\t2\t4\tCALLABLE\n" +
+		"chr1\t5\t6\tCALLABLE\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected callable BED output:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	f := s.Features(ExcessiveCoverage)
+	if len(f) != 1 || f[0].Start != 4 || f[0].End != 5 || f[0].Name != "EXCESSIVE_COVERAGE" {
+		t.Errorf("unexpected excessive coverage features: %v", f)
+	}
+}
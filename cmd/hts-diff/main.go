@@ -0,0 +1,113 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The hts-diff command compares the records of two SAM or BAM files,
+// writing each difference found as a line of JSON to standard output
+// followed by a JSON summary of the comparison to standard error. Files
+// with a .sam extension are read as SAM and others as BAM. The command
+// exits with status 1 if any difference is found.
+//
+// Usage:
+//
+//	hts-diff [-ignore FIELD,...] [-ignore-tags TAG,...] [-by-name] [-threads n] a.bam b.bam
+//
+// Fields are named by their SAM column names, FLAG, RNAME, POS, MAPQ,
+// CIGAR, RNEXT, PNEXT, TLEN, SEQ and QUAL, and AUX names all aux fields.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/bamdiff"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ignore     = flag.String("ignore", "", "comma separated fields to ignore")
+	ignoreTags = flag.String("ignore-tags", "", "comma separated aux tags to ignore")
+	byName     = flag.Bool("by-name", false, "match records irrespective of sort order")
+	threads    = flag.Int("threads", 0, "number of decompression goroutines")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] a.bam b.bam\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var opts bamdiff.Options
+	var err error
+	opts.Ignore, err = bamdiff.ParseFields(*ignore)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *ignoreTags != "" {
+		for _, t := range strings.Split(*ignoreTags, ",") {
+			t = strings.TrimSpace(t)
+			if len(t) != 2 {
+				log.Fatalf("invalid tag %q", t)
+			}
+			opts.IgnoreTags = append(opts.IgnoreTags, sam.NewTag(t))
+		}
+	}
+	opts.ByName = *byName
+
+	a, closeA := open(flag.Arg(0))
+	defer closeA()
+	b, closeB := open(flag.Arg(1))
+	defer closeB()
+
+	enc := json.NewEncoder(os.Stdout)
+	sum, err := bamdiff.Diff(a, b, opts, func(d bamdiff.Difference) error {
+		return enc.Encode(d)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = json.NewEncoder(os.Stderr).Encode(sum)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if sum.Differences() != 0 {
+		closeA()
+		closeB()
+		os.Exit(1)
+	}
+}
+
+// open returns a source reading the named SAM or BAM file and a function
+// to close it.
+func open(path string) (bamdiff.Source, func()) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".sam") {
+		r, err := sam.NewReader(f)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return r, func() { f.Close() }
+	}
+	br, err := bam.NewReader(f, *threads)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return br, func() {
+		br.Close()
+		f.Close()
+	}
+}
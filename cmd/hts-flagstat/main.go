@@ -0,0 +1,64 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The hts-flagstat command writes counts of the flags of the records in
+// a BAM file, in the format of samtools flagstat.
+//
+// Usage:
+//
+//	hts-flagstat [-threads n] in.bam
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/stats"
+)
+
+var threads = flag.Int("threads", 0, "number of decompression goroutines")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] in.bam\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	br, err := bam.NewReader(f, *threads)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer br.Close()
+	br.Omit(bam.AllVariableLengthData)
+
+	var fs stats.FlagStat
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fs.Add(r)
+	}
+	_, err = fs.WriteTo(os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The hts-index command writes a BAI index for a coordinate sorted BAM
+// file, in the manner of samtools index.
+//
+// Usage:
+//
+//	hts-index [-threads n] in.bam [out.bai]
+//
+// If no output is given, the index is written to in.bam.bai.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+var threads = flag.Int("threads", 0, "number of decompression goroutines")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] in.bam [out.bai]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+	out := name + ".bai"
+	if flag.NArg() == 2 {
+		out = flag.Arg(1)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	br, err := bam.NewReader(f, *threads)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer br.Close()
+	if so := br.Header().SortOrder; so != sam.Coordinate {
+		log.Fatalf("hts-index: %s is not coordinate sorted: sort order %v", name, so)
+	}
+	br.Omit(bam.AllVariableLengthData)
+
+	var idx bam.Index
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	o, err := os.Create(out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = bam.WriteIndex(o, &idx)
+	if err != nil {
+		o.Close()
+		log.Fatal(err)
+	}
+	err = o.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The hts-merge command merges sorted BAM files, in the manner of
+// samtools merge.
+//
+// Usage:
+//
+//	hts-merge [-r] [-threads n] -o out.bam in1.bam in2.bam...
+//
+// With -r, the RG aux field of each record is set to a read group
+// named by the base name of its input without the .bam extension, as
+// samtools merge -r does.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Schaudge/hts/bam"
+)
+
+var (
+	tag     = flag.Bool("r", false, "tag records with a read group named by their input file")
+	output  = flag.String("o", "", "output BAM file")
+	threads = flag.Int("threads", 0, "number of decompression goroutines per input")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] -o out.bam in.bam...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || *output == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		src []*bam.Reader
+		ids []string
+	)
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		br, err := bam.NewReader(f, *threads)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer br.Close()
+		src = append(src, br)
+		if *tag {
+			ids = append(ids, strings.TrimSuffix(filepath.Base(name), ".bam"))
+		}
+	}
+
+	o, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = bam.MergeTagged(o, ids, src...)
+	if err != nil {
+		o.Close()
+		log.Fatal(err)
+	}
+	err = o.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The hts-validate command validates a SAM or BAM file, writing the
+// issues found in the manner of Picard ValidateSamFile. Files with a
+// .sam extension are read as SAM and others as BAM. The command exits
+// with status 1 if any issue of ERROR severity is found.
+//
+// Usage:
+//
+//	hts-validate [-mode VERBOSE|SUMMARY] [-max n] [-ignore KIND,...] [-warn KIND,...] [-threads n] in.bam
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+	"github.com/Schaudge/hts/validate"
+)
+
+var (
+	mode      = flag.String("mode", "VERBOSE", "output mode: VERBOSE writes each issue, SUMMARY writes counts of each kind")
+	maxIssues = flag.Int("max", validate.DefaultMaxIssues, "maximum number of issues to write in VERBOSE mode, negative for no limit")
+	ignore    = flag.String("ignore", "", "comma separated kinds of issue to ignore")
+	warn      = flag.String("warn", "", "comma separated kinds of issue to report as warnings")
+	threads   = flag.Int("threads", 0, "number of decompression goroutines")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] in.bam\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := validate.Options{Severity: make(map[validate.Kind]validate.Severity)}
+	for _, set := range []struct {
+		kinds    string
+		severity validate.Severity
+	}{
+		{kinds: *warn, severity: validate.Warning},
+		{kinds: *ignore, severity: validate.Ignore},
+	} {
+		if set.kinds == "" {
+			continue
+		}
+		for _, name := range strings.Split(set.kinds, ",") {
+			k, err := validate.ParseKind(strings.TrimSpace(name))
+			if err != nil {
+				log.Fatal(err)
+			}
+			opts.Severity[k] = set.severity
+		}
+	}
+	switch strings.ToUpper(*mode) {
+	case "VERBOSE":
+		opts.MaxIssues = *maxIssues
+	case "SUMMARY":
+		opts.MaxIssues = 1
+	default:
+		log.Fatalf("invalid mode %q", *mode)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	var r validate.Reader
+	if strings.EqualFold(filepath.Ext(flag.Arg(0)), ".sam") {
+		r, err = sam.NewReader(f)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		br, err := bam.NewReader(f, *threads)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer br.Close()
+		r = br
+	}
+
+	rep, err := validate.Validate(r, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if strings.EqualFold(*mode, "SUMMARY") {
+		err = rep.WriteSummary(os.Stdout)
+	} else {
+		for _, i := range rep.Issues {
+			_, err = fmt.Println(i)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil && len(rep.Issues) == 0 {
+			_, err = fmt.Println("No errors found")
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if rep.Errors() != 0 {
+		os.Exit(1)
+	}
+}
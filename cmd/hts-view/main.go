@@ -0,0 +1,221 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The hts-view command writes the records of a BAM file as SAM or BAM,
+// in the manner of samtools view.
+//
+// Usage:
+//
+//	hts-view [-b] [-H] [-e expr] [-threads n] in.bam [region...]
+//
+// Regions are given as ref, ref:beg or ref:beg-end with 1-based
+// inclusive coordinates, and require a BAI index at in.bam.bai. The
+// records written may be restricted by a filter expression as parsed by
+// filter.Parse.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/filter"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	asBAM   = flag.Bool("b", false, "write BAM rather than SAM")
+	header  = flag.Bool("H", false, "write only the header")
+	expr    = flag.String("e", "", "filter expression")
+	threads = flag.Int("threads", 0, "number of decompression and compression goroutines")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [options] in.bam [region...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	br, err := bam.NewReader(f, *threads)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer br.Close()
+	h := br.Header()
+
+	out := bufio.NewWriter(os.Stdout)
+	var w interface {
+		Write(*sam.Record) error
+	}
+	if *asBAM {
+		bw, err := bam.NewWriter(out, h, *threads)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w = bw
+	} else {
+		sw, err := sam.NewWriter(out, h, sam.FlagDecimal)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w = sw
+	}
+	if *header {
+		flush(w, out)
+		return
+	}
+
+	keep := func(*sam.Record) bool { return true }
+	if *expr != "" {
+		e, err := filter.Parse(*expr, h)
+		if err != nil {
+			log.Fatal(err)
+		}
+		keep = filter.Compile(e)
+	}
+
+	var src interface {
+		Read() (*sam.Record, error)
+	} = br
+	if flag.NArg() > 1 {
+		regions := make([]bam.Region, flag.NArg()-1)
+		for i, s := range flag.Args()[1:] {
+			regions[i], err = parseRegion(s, h)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		it, err := bam.NewRegionIterator(br, readIndex(name+".bai"), regions)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer it.Close()
+		src = iterSource{it}
+	}
+
+	for {
+		r, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !keep(r) {
+			continue
+		}
+		err = w.Write(r)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	flush(w, out)
+}
+
+// flush closes w if it is a BAM writer and flushes out.
+func flush(w interface{}, out *bufio.Writer) {
+	if c, ok := w.(io.Closer); ok {
+		err := c.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	err := out.Flush()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readIndex(name string) *bam.Index {
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	idx, err := bam.ReadIndex(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return idx
+}
+
+// iterSource adapts a bam.Iterator to return records from Read.
+type iterSource struct {
+	it *bam.Iterator
+}
+
+func (s iterSource) Read() (*sam.Record, error) {
+	if !s.it.Next() {
+		err := s.it.Error()
+		if err == nil {
+			err = io.EOF
+		}
+		return nil, err
+	}
+	return s.it.Record(), nil
+}
+
+// parseRegion returns the region described by s, given as ref, ref:beg
+// or ref:beg-end with 1-based inclusive coordinates. A reference whose
+// name contains a colon is matched by its full name first.
+func parseRegion(s string, h *sam.Header) (bam.Region, error) {
+	name, span := s, ""
+	ref := lookup(h, s)
+	if i := strings.LastIndexByte(s, ':'); ref == nil && i >= 0 {
+		name, span = s[:i], s[i+1:]
+		ref = lookup(h, name)
+	}
+	if ref == nil {
+		return bam.Region{}, fmt.Errorf("hts-view: unknown reference in region %q", s)
+	}
+	reg := bam.Region{Ref: ref, Start: 0, End: ref.Len()}
+	if span == "" {
+		return reg, nil
+	}
+	beg, end := span, ""
+	if i := strings.IndexByte(span, '-'); i >= 0 {
+		beg, end = span[:i], span[i+1:]
+	}
+	b, err := strconv.Atoi(strings.ReplaceAll(beg, ",", ""))
+	if err != nil || b < 1 {
+		return bam.Region{}, fmt.Errorf("hts-view: invalid start in region %q", s)
+	}
+	reg.Start = b - 1
+	if end != "" {
+		e, err := strconv.Atoi(strings.ReplaceAll(end, ",", ""))
+		if err != nil || e < b {
+			return bam.Region{}, fmt.Errorf("hts-view: invalid end in region %q", s)
+		}
+		if e < reg.End {
+			reg.End = e
+		}
+	}
+	return reg, nil
+}
+
+func lookup(h *sam.Header, name string) *sam.Reference {
+	for _, r := range h.Refs() {
+		if r.Name() == name {
+			return r
+		}
+	}
+	return nil
+}
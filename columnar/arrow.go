@@ -0,0 +1,102 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package columnar
+
+import (
+	"errors"
+	"io"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+var arrowTypes = [...]arrow.DataType{
+	Uint8:   arrow.PrimitiveTypes.Uint8,
+	Uint16:  arrow.PrimitiveTypes.Uint16,
+	Int32:   arrow.PrimitiveTypes.Int32,
+	Int64:   arrow.PrimitiveTypes.Int64,
+	Float64: arrow.PrimitiveTypes.Float64,
+	String:  arrow.BinaryTypes.String,
+	Binary:  arrow.BinaryTypes.Binary,
+}
+
+// ArrowSchema returns the Arrow schema of batches holding the given tag
+// columns.
+func ArrowSchema(tags []Tag) *arrow.Schema {
+	fields := Schema(tags)
+	f := make([]arrow.Field, len(fields))
+	for i, c := range fields {
+		f[i] = arrow.Field{Name: c.Name, Type: arrowTypes[c.Type], Nullable: c.Nullable}
+	}
+	return arrow.NewSchema(f, nil)
+}
+
+// Record returns b as an Arrow record with the given schema, which must
+// be the ArrowSchema of the batch's tag columns. The record shares
+// memory with b.
+func (b *Batch) Record(schema *arrow.Schema) (array.Record, error) {
+	if len(schema.Fields()) != len(b.Columns) {
+		return nil, errors.New("columnar: schema does not match batch")
+	}
+	cols := make([]array.Interface, len(b.Columns))
+	for i := range b.Columns {
+		c := &b.Columns[i]
+		f := schema.Field(i)
+		if f.Name != c.Name || !arrow.TypeEqual(f.Type, arrowTypes[c.Type]) {
+			for _, a := range cols[:i] {
+				a.Release()
+			}
+			return nil, errors.New("columnar: schema does not match batch")
+		}
+		var validity *memory.Buffer
+		if c.Validity != nil {
+			validity = memory.NewBufferBytes(c.Validity)
+		}
+		buffers := []*memory.Buffer{validity}
+		if c.Offsets != nil {
+			buffers = append(buffers, memory.NewBufferBytes(arrow.Int32Traits.CastToBytes(c.Offsets)))
+		}
+		buffers = append(buffers, memory.NewBufferBytes(c.Values))
+		data := array.NewData(f.Type, b.Len, buffers, nil, c.NullCount, 0)
+		cols[i] = array.MakeFromData(data)
+		data.Release()
+	}
+	rec := array.NewRecord(schema, cols, int64(b.Len))
+	for _, a := range cols {
+		a.Release()
+	}
+	return rec, nil
+}
+
+// IPCWriter writes batches as an Arrow IPC stream.
+type IPCWriter struct {
+	schema *arrow.Schema
+	w      *ipc.Writer
+}
+
+// NewIPCWriter returns an IPCWriter writing batches holding the given
+// tag columns to w.
+func NewIPCWriter(w io.Writer, tags []Tag) *IPCWriter {
+	schema := ArrowSchema(tags)
+	return &IPCWriter{schema: schema, w: ipc.NewWriter(w, ipc.WithSchema(schema))}
+}
+
+// Write writes b as a record batch message.
+func (w *IPCWriter) Write(b *Batch) error {
+	rec, err := b.Record(w.schema)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+	return w.w.Write(rec)
+}
+
+// Close writes the end of stream marker. It does not close the
+// underlying writer.
+func (w *IPCWriter) Close() error {
+	return w.w.Close()
+}
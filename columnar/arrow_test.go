@@ -0,0 +1,115 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package columnar
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+)
+
+func TestIPCWriter(t *testing.T) {
+	in := testRecords(t)
+	r, err := NewReader(&in, testTags, 2)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var (
+		buf     bytes.Buffer
+		batches []*Batch
+	)
+	w := NewIPCWriter(&buf, testTags)
+	for {
+		b, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading batch: %v", err)
+		}
+		err = w.Write(b)
+		if err != nil {
+			t.Fatalf("unexpected error writing batch: %v", err)
+		}
+		batches = append(batches, b)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	ir, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error creating IPC reader: %v", err)
+	}
+	defer ir.Release()
+	if !ir.Schema().Equal(ArrowSchema(testTags)) {
+		t.Errorf("unexpected schema: got:%v want:%v", ir.Schema(), ArrowSchema(testTags))
+	}
+	var n int
+	for ir.Next() {
+		if n == len(batches) {
+			t.Fatal("too many record batches")
+		}
+		rec, b := ir.Record(), batches[n]
+		if int(rec.NumRows()) != b.Len {
+			t.Errorf("unexpected number of rows in batch %d: got:%d want:%d", n, rec.NumRows(), b.Len)
+		}
+		for i := range b.Columns {
+			checkArrowColumn(t, n, &b.Columns[i], rec.Column(i))
+		}
+		n++
+	}
+	if ir.Err() != nil {
+		t.Errorf("unexpected error reading IPC stream: %v", ir.Err())
+	}
+	if n != len(batches) {
+		t.Errorf("unexpected number of record batches: got:%d want:%d", n, len(batches))
+	}
+
+	_, err = batches[0].Record(arrow.NewSchema(nil, nil))
+	if err == nil {
+		t.Error("expected error for mismatched schema")
+	}
+}
+
+func checkArrowColumn(t *testing.T, batch int, c *Column, a array.Interface) {
+	t.Helper()
+	for i := 0; i < a.Len(); i++ {
+		if a.IsNull(i) != c.IsNull(i) {
+			t.Errorf("unexpected null state of %s in batch %d row %d", c.Name, batch, i)
+			continue
+		}
+		if c.IsNull(i) {
+			continue
+		}
+		var ok bool
+		switch a := a.(type) {
+		case *array.Uint8:
+			ok = int64(a.Value(i)) == c.Int(i)
+		case *array.Uint16:
+			ok = int64(a.Value(i)) == c.Int(i)
+		case *array.Int32:
+			ok = int64(a.Value(i)) == c.Int(i)
+		case *array.Int64:
+			ok = a.Value(i) == c.Int(i)
+		case *array.Float64:
+			ok = a.Value(i) == c.Float(i)
+		case *array.String:
+			ok = a.Value(i) == string(c.Bytes(i))
+		case *array.Binary:
+			ok = bytes.Equal(a.Value(i), c.Bytes(i))
+		default:
+			t.Fatalf("unexpected array type for %s: %T", c.Name, a)
+		}
+		if !ok {
+			t.Errorf("unexpected value of %s in batch %d row %d", c.Name, batch, i)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package columnar implements conversion of alignment records into
+// column-oriented batches with a stable schema.
+//
+// Each batch holds a column for each of the fixed record fields listed
+// below, followed by a column for each selected aux tag. Column data is
+// held in the Apache Arrow columnar memory layout: a validity bitmap
+// with least significant bit ordering, 32-bit offsets for variable
+// width types, and little-endian values, so that batches are converted
+// to Arrow records without copying.
+//
+// Batches may be written as Arrow IPC streams with IPCWriter, and as
+// Parquet files with ParquetWriter.
+//
+//	name      String            query template name
+//	flags     Uint16            bitwise flags
+//	ref       String, nullable  reference name
+//	pos       Int32, nullable   0-based leftmost position
+//	mapq      Uint8             mapping quality
+//	cigar     String            CIGAR string
+//	mate_ref  String, nullable  mate reference name
+//	mate_pos  Int32, nullable   0-based mate position
+//	tlen      Int32             template length
+//	seq       String            sequence
+//	qual      Binary, nullable  base qualities without offset
+//
+// Tag columns are nullable and are named by their tag.
+package columnar
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// DefaultBatchSize is the default number of records in a batch.
+const DefaultBatchSize = 1 << 16
+
+// Type is the type of a column.
+type Type int
+
+const (
+	Uint8 Type = iota
+	Uint16
+	Int32
+	Int64
+	Float64
+	String
+	Binary
+)
+
+var typeNames = [...]string{
+	Uint8:   "uint8",
+	Uint16:  "uint16",
+	Int32:   "int32",
+	Int64:   "int64",
+	Float64: "float64",
+	String:  "utf8",
+	Binary:  "binary",
+}
+
+// String returns the Arrow name of the type.
+func (t Type) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+	return typeNames[t]
+}
+
+// width returns the size of fixed width values of the type, or zero
+// for variable width types.
+func (t Type) width() int {
+	switch t {
+	case Uint8:
+		return 1
+	case Uint16:
+		return 2
+	case Int32:
+		return 4
+	case Int64, Float64:
+		return 8
+	}
+	return 0
+}
+
+// Field describes a column.
+type Field struct {
+	Name     string
+	Type     Type
+	Nullable bool
+}
+
+// Tag specifies an aux tag column. The Type of a Tag must be Int64,
+// Float64 or String. Integer values are held in Int64 and Float64
+// columns, and floating point values in Float64 columns. String
+// columns hold the SAM text representation of values. Values that
+// cannot be held in the column type are null.
+type Tag struct {
+	Tag  sam.Tag
+	Type Type
+}
+
+var fixed = []Field{
+	{Name: "name", Type: String},
+	{Name: "flags", Type: Uint16},
+	{Name: "ref", Type: String, Nullable: true},
+	{Name: "pos", Type: Int32, Nullable: true},
+	{Name: "mapq", Type: Uint8},
+	{Name: "cigar", Type: String},
+	{Name: "mate_ref", Type: String, Nullable: true},
+	{Name: "mate_pos", Type: Int32, Nullable: true},
+	{Name: "tlen", Type: Int32},
+	{Name: "seq", Type: String},
+	{Name: "qual", Type: Binary, Nullable: true},
+}
+
+// Schema returns the fields of the columns of batches holding the given
+// tag columns.
+func Schema(tags []Tag) []Field {
+	f := append([]Field(nil), fixed...)
+	for _, t := range tags {
+		f = append(f, Field{Name: t.Tag.String(), Type: t.Type, Nullable: true})
+	}
+	return f
+}
+
+// Column is a column of a Batch in the Arrow memory layout.
+type Column struct {
+	Field
+
+	// NullCount is the number of null
+	// values in the column.
+	NullCount int
+
+	// Validity is the validity bitmap of the
+	// column, with bit i set if value i is not
+	// null. Validity is nil if no value is null.
+	Validity []byte
+
+	// Offsets holds the start of each value
+	// in Values, followed by the end of the
+	// last value, for String and Binary
+	// columns.
+	Offsets []int32
+
+	// Values holds the little-endian values
+	// of fixed width columns, or the
+	// concatenated values of String and
+	// Binary columns.
+	Values []byte
+}
+
+// IsNull returns whether value i of the column is null.
+func (c *Column) IsNull(i int) bool {
+	return c.Validity != nil && c.Validity[i/8]&(1<<(i%8)) == 0
+}
+
+// Int returns value i of an integer column.
+func (c *Column) Int(i int) int64 {
+	switch c.Type {
+	case Uint8:
+		return int64(c.Values[i])
+	case Uint16:
+		return int64(binary.LittleEndian.Uint16(c.Values[2*i:]))
+	case Int32:
+		return int64(int32(binary.LittleEndian.Uint32(c.Values[4*i:])))
+	case Int64:
+		return int64(binary.LittleEndian.Uint64(c.Values[8*i:]))
+	}
+	panic("columnar: not an integer column")
+}
+
+// Float returns value i of a Float64 column.
+func (c *Column) Float(i int) float64 {
+	if c.Type != Float64 {
+		panic("columnar: not a float column")
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(c.Values[8*i:]))
+}
+
+// Bytes returns value i of a String or Binary column.
+func (c *Column) Bytes(i int) []byte {
+	if c.Offsets == nil {
+		panic("columnar: not a variable width column")
+	}
+	return c.Values[c.Offsets[i]:c.Offsets[i+1]]
+}
+
+// Batch is a set of equal length columns holding records.
+type Batch struct {
+	Len     int
+	Columns []Column
+}
+
+// Builder accumulates records into a Batch.
+type Builder struct {
+	tags []Tag
+	cols []Column
+	n    int
+}
+
+// NewBuilder returns a Builder for batches holding the given tag columns.
+func NewBuilder(tags []Tag) (*Builder, error) {
+	for _, t := range tags {
+		switch t.Type {
+		case Int64, Float64, String:
+		default:
+			return nil, fmt.Errorf("columnar: invalid type %v for tag %v", t.Type, t.Tag)
+		}
+	}
+	b := &Builder{tags: append([]Tag(nil), tags...)}
+	b.reset()
+	return b, nil
+}
+
+func (b *Builder) reset() {
+	fields := Schema(b.tags)
+	b.cols = make([]Column, len(fields))
+	for i, f := range fields {
+		b.cols[i].Field = f
+		if f.Type.width() == 0 {
+			b.cols[i].Offsets = []int32{0}
+		}
+	}
+	b.n = 0
+}
+
+// Len returns the number of records held by the Builder.
+func (b *Builder) Len() int { return b.n }
+
+// Batch returns the records held by the Builder as a Batch and resets
+// the Builder.
+func (b *Builder) Batch() *Batch {
+	batch := &Batch{Len: b.n, Columns: b.cols}
+	b.reset()
+	return batch
+}
+
+// Append adds r to the Builder.
+func (b *Builder) Append(r *sam.Record) {
+	c := b.cols
+	c[0].appendString(r.Name, true, b.n)
+	c[1].appendInt(int64(r.Flags), true, b.n)
+	c[2].appendString(r.Ref.Name(), r.Ref != nil, b.n)
+	c[3].appendInt(int64(r.Pos), r.Pos >= 0, b.n)
+	c[4].appendInt(int64(r.MapQ), true, b.n)
+	c[5].appendString(r.Cigar.String(), true, b.n)
+	c[6].appendString(r.MateRef.Name(), r.MateRef != nil, b.n)
+	c[7].appendInt(int64(r.MatePos), r.MatePos >= 0, b.n)
+	c[8].appendInt(int64(r.TempLen), true, b.n)
+	c[9].appendBytes(r.Seq.Expand(), true, b.n)
+	c[10].appendBytes(r.Qual, hasQual(r.Qual), b.n)
+	for i, t := range b.tags {
+		col := &c[len(fixed)+i]
+		a := r.AuxTag(t.Tag)
+		if a == nil {
+			col.appendNull(b.n)
+			continue
+		}
+		switch t.Type {
+		case Int64:
+			v, ok := intValue(a.Value())
+			col.appendInt(v, ok, b.n)
+		case Float64:
+			v, ok := floatValue(a.Value())
+			col.appendFloat(v, ok, b.n)
+		case String:
+			s := a.String()
+			col.appendString(s[len("XX:t:"):], true, b.n)
+		}
+	}
+	b.n++
+}
+
+// hasQual returns whether q holds base qualities, rather than the 0xff
+// filled absent quality marker.
+func hasQual(q []byte) bool {
+	return len(q) != 0 && q[0] != 0xff
+}
+
+func intValue(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int8:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func floatValue(v interface{}) (float64, bool) {
+	if f, ok := v.(float32); ok {
+		return float64(f), true
+	}
+	i, ok := intValue(v)
+	return float64(i), ok
+}
+
+// setValid records the validity of value i, which is being appended to
+// the column.
+func (c *Column) setValid(valid bool, i int) {
+	if valid && c.Validity == nil {
+		return
+	}
+	if c.Validity == nil {
+		// Materialise the bitmap for the
+		// preceding valid values.
+		c.Validity = make([]byte, (i+8)/8)
+		for j := 0; j < i; j++ {
+			c.Validity[j/8] |= 1 << (j % 8)
+		}
+	}
+	for len(c.Validity) <= i/8 {
+		c.Validity = append(c.Validity, 0)
+	}
+	if valid {
+		c.Validity[i/8] |= 1 << (i % 8)
+	} else {
+		c.NullCount++
+	}
+}
+
+func (c *Column) appendNull(i int) {
+	c.setValid(false, i)
+	if c.Offsets != nil {
+		c.Offsets = append(c.Offsets, c.Offsets[len(c.Offsets)-1])
+		return
+	}
+	c.Values = append(c.Values, make([]byte, c.Type.width())...)
+}
+
+func (c *Column) appendInt(v int64, valid bool, i int) {
+	if !valid {
+		c.appendNull(i)
+		return
+	}
+	c.setValid(true, i)
+	switch c.Type {
+	case Uint8:
+		c.Values = append(c.Values, byte(v))
+	case Uint16:
+		c.Values = binary.LittleEndian.AppendUint16(c.Values, uint16(v))
+	case Int32:
+		c.Values = binary.LittleEndian.AppendUint32(c.Values, uint32(v))
+	case Int64:
+		c.Values = binary.LittleEndian.AppendUint64(c.Values, uint64(v))
+	}
+}
+
+func (c *Column) appendFloat(v float64, valid bool, i int) {
+	if !valid {
+		c.appendNull(i)
+		return
+	}
+	c.setValid(true, i)
+	c.Values = binary.LittleEndian.AppendUint64(c.Values, math.Float64bits(v))
+}
+
+func (c *Column) appendString(s string, valid bool, i int) {
+	if !valid {
+		c.appendNull(i)
+		return
+	}
+	c.setValid(true, i)
+	c.Values = append(c.Values, s...)
+	c.Offsets = append(c.Offsets, int32(len(c.Values)))
+}
+
+func (c *Column) appendBytes(b []byte, valid bool, i int) {
+	if !valid {
+		c.appendNull(i)
+		return
+	}
+	c.setValid(true, i)
+	c.Values = append(c.Values, b...)
+	c.Offsets = append(c.Offsets, int32(len(c.Values)))
+}
+
+// Source is a source of alignment records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Reader is a batching record reader. It returns the records of its
+// Source as Batches.
+type Reader struct {
+	src  Source
+	b    *Builder
+	size int
+	err  error
+}
+
+// NewReader returns a Reader returning batches of up to size records,
+// holding the given tag columns, read from src. If size is less than
+// one, DefaultBatchSize is used.
+func NewReader(src Source, tags []Tag, size int) (*Reader, error) {
+	if size < 1 {
+		size = DefaultBatchSize
+	}
+	b, err := NewBuilder(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{src: src, b: b, size: size}, nil
+}
+
+// Read returns the next batch of records. At the end of the Source, Read
+// returns the error returned by the Source, usually io.EOF.
+func (r *Reader) Read() (*Batch, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for r.b.Len() < r.size {
+		rec, err := r.src.Read()
+		if err != nil {
+			r.err = err
+			break
+		}
+		r.b.Append(rec)
+	}
+	if r.b.Len() == 0 {
+		return nil, r.err
+	}
+	return r.b.Batch(), nil
+}
@@ -0,0 +1,175 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package columnar
+
+import (
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+// testRecords returns five records, of which the even numbered records
+// are mapped and have an NM tag. All the records have an RG tag.
+func testRecords(t *testing.T) records {
+	t.Helper()
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	_, err = sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	nm := sam.NewTag("NM")
+	rg := sam.NewTag("RG")
+
+	var in records
+	for i := 0; i < 5; i++ {
+		var (
+			ref   *sam.Reference
+			pos   = -1
+			cigar = []sam.CigarOp{sam.NewCigarOp(sam.CigarSoftClipped, 4)}
+			qual  []byte
+			aux   []sam.Aux
+		)
+		if i%2 == 0 {
+			ref, pos = chr1, 10*i
+			cigar = []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+			qual = []byte{30, 31, 32, 33}
+			a, err := sam.NewAux(nm, i)
+			if err != nil {
+				t.Fatalf("unexpected error creating aux: %v", err)
+			}
+			aux = append(aux, a)
+		}
+		a, err := sam.NewAux(rg, "grp")
+		if err != nil {
+			t.Fatalf("unexpected error creating aux: %v", err)
+		}
+		aux = append(aux, a)
+		r, err := sam.NewRecord("r", ref, nil, pos, -1, 0, 60, cigar, []byte("ACGT"), qual, aux)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		if ref == nil {
+			r.Flags = sam.Unmapped
+		}
+		in = append(in, r)
+	}
+	return in
+}
+
+var testTags = []Tag{
+	{Tag: sam.NewTag("NM"), Type: Int64},
+	{Tag: sam.NewTag("RG"), Type: String},
+	{Tag: sam.NewTag("AS"), Type: Float64},
+}
+
+func TestReader(t *testing.T) {
+	in := testRecords(t)
+	tags := testTags
+	r, err := NewReader(&in, tags, 2)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	schema := Schema(tags)
+	var (
+		lens []int
+		n    int
+	)
+	for {
+		b, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading batch: %v", err)
+		}
+		lens = append(lens, b.Len)
+		if len(b.Columns) != len(schema) {
+			t.Fatalf("unexpected number of columns: got:%d want:%d", len(b.Columns), len(schema))
+		}
+		for i, c := range b.Columns {
+			if c.Field != schema[i] {
+				t.Errorf("unexpected field for column %d: got:%+v want:%+v", i, c.Field, schema[i])
+			}
+			if c.NullCount != 0 && !c.Nullable {
+				t.Errorf("unexpected nulls in column %s", c.Name)
+			}
+		}
+		for i := 0; i < b.Len; i++ {
+			mapped := n%2 == 0
+			col := func(name string) *Column {
+				for j := range b.Columns {
+					if b.Columns[j].Name == name {
+						return &b.Columns[j]
+					}
+				}
+				t.Fatalf("missing column %s", name)
+				return nil
+			}
+			if got := string(col("name").Bytes(i)); got != "r" {
+				t.Errorf("unexpected name for record %d: %q", n, got)
+			}
+			if got := string(col("seq").Bytes(i)); got != "ACGT" {
+				t.Errorf("unexpected sequence for record %d: %q", n, got)
+			}
+			if got := col("mapq").Int(i); got != 60 {
+				t.Errorf("unexpected mapping quality for record %d: %d", n, got)
+			}
+			for _, name := range []string{"ref", "pos", "qual", "NM"} {
+				if col(name).IsNull(i) == mapped {
+					t.Errorf("unexpected null state of %s for record %d", name, n)
+				}
+			}
+			if !col("mate_ref").IsNull(i) || !col("mate_pos").IsNull(i) || !col("AS").IsNull(i) {
+				t.Errorf("expected null mate and AS values for record %d", n)
+			}
+			if mapped {
+				if got := string(col("ref").Bytes(i)); got != "chr1" {
+					t.Errorf("unexpected reference for record %d: %q", n, got)
+				}
+				if got := col("pos").Int(i); got != int64(10*n) {
+					t.Errorf("unexpected position for record %d: %d", n, got)
+				}
+				if got := col("NM").Int(i); got != int64(n) {
+					t.Errorf("unexpected NM for record %d: %d", n, got)
+				}
+				if got := string(col("cigar").Bytes(i)); got != "4M" {
+					t.Errorf("unexpected cigar for record %d: %q", n, got)
+				}
+				if got := col("qual").Bytes(i); len(got) != 4 || got[0] != 30 {
+					t.Errorf("unexpected qualities for record %d: %v", n, got)
+				}
+			} else if got := col("flags").Int(i); got != int64(sam.Unmapped) {
+				t.Errorf("unexpected flags for record %d: %d", n, got)
+			}
+			if got := string(col("RG").Bytes(i)); got != "grp" {
+				t.Errorf("unexpected RG for record %d: %q", n, got)
+			}
+			n++
+		}
+	}
+	if len(lens) != 3 || lens[0] != 2 || lens[1] != 2 || lens[2] != 1 {
+		t.Errorf("unexpected batch lengths: %v", lens)
+	}
+
+	_, err = NewBuilder([]Tag{{Tag: sam.NewTag("NM"), Type: Binary}})
+	if err == nil {
+		t.Error("expected error for invalid tag column type")
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package columnar
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// parquetMagic starts and ends a Parquet file.
+const parquetMagic = "PAR1"
+
+// Parquet physical types, converted types, repetition types, encodings
+// and page types used by ParquetWriter, from parquet.thrift.
+const (
+	pqInt32     = 1
+	pqInt64     = 2
+	pqDouble    = 5
+	pqByteArray = 6
+
+	pqUTF8   = 0
+	pqUint8  = 11
+	pqUint16 = 12
+
+	pqRequired = 0
+	pqOptional = 1
+
+	pqPlain = 0
+	pqRLE   = 3
+
+	pqDataPage = 0
+)
+
+// parquetType returns the physical and converted types used to store a
+// column of type t. The converted type is negative if none is needed.
+func parquetType(t Type) (physical, converted int32) {
+	switch t {
+	case Uint8:
+		return pqInt32, pqUint8
+	case Uint16:
+		return pqInt32, pqUint16
+	case Int32:
+		return pqInt32, -1
+	case Int64:
+		return pqInt64, -1
+	case Float64:
+		return pqDouble, -1
+	case String:
+		return pqByteArray, pqUTF8
+	case Binary:
+		return pqByteArray, -1
+	}
+	panic("columnar: invalid type")
+}
+
+// ParquetWriter writes batches as a Parquet file. Each batch is written
+// as a row group holding a single uncompressed, PLAIN encoded data page
+// for each column.
+type ParquetWriter struct {
+	w      io.Writer
+	fields []Field
+	off    int64
+	rows   int64
+	groups [][]byte
+	err    error
+}
+
+// NewParquetWriter returns a ParquetWriter writing batches holding the
+// given tag columns to w.
+func NewParquetWriter(w io.Writer, tags []Tag) (*ParquetWriter, error) {
+	pw := &ParquetWriter{w: w, fields: Schema(tags)}
+	err := pw.write([]byte(parquetMagic))
+	if err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (w *ParquetWriter) write(b []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	n, err := w.w.Write(b)
+	w.off += int64(n)
+	w.err = err
+	return err
+}
+
+// Write writes b as a row group.
+func (w *ParquetWriter) Write(b *Batch) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(b.Columns) != len(w.fields) {
+		return errors.New("columnar: schema does not match batch")
+	}
+	for i, c := range b.Columns {
+		if c.Field != w.fields[i] {
+			return errors.New("columnar: schema does not match batch")
+		}
+	}
+	if b.Len == 0 {
+		return nil
+	}
+
+	var (
+		rg   thrift
+		size int64
+	)
+	rg.listHeader(1, thriftStruct, len(b.Columns))
+	for i := range b.Columns {
+		c := &b.Columns[i]
+		data := pageData(c, b.Len)
+		var ph thrift
+		ph.i32(1, pqDataPage)
+		ph.i32(2, int32(len(data)))
+		ph.i32(3, int32(len(data)))
+		ph.structBegin(5)
+		ph.i32(1, int32(b.Len))
+		ph.i32(2, pqPlain)
+		ph.i32(3, pqRLE)
+		ph.i32(4, pqRLE)
+		ph.structEnd()
+		ph.stop()
+
+		off := w.off
+		err := w.write(ph.b)
+		if err != nil {
+			return err
+		}
+		err = w.write(data)
+		if err != nil {
+			return err
+		}
+		n := int64(len(ph.b) + len(data))
+		size += n
+
+		physical, _ := parquetType(c.Type)
+		rg.elemBegin()
+		rg.i64(2, off)
+		rg.structBegin(3)
+		rg.i32(1, physical)
+		rg.listHeader(2, thriftI32, 2)
+		rg.listI32(pqPlain)
+		rg.listI32(pqRLE)
+		rg.listHeader(3, thriftBinary, 1)
+		rg.listBinary(c.Name)
+		rg.i32(4, 0) // UNCOMPRESSED
+		rg.i64(5, int64(b.Len))
+		rg.i64(6, n)
+		rg.i64(7, n)
+		rg.i64(9, off)
+		rg.structEnd()
+		rg.structEnd()
+	}
+	rg.i64(2, size)
+	rg.i64(3, int64(b.Len))
+	w.groups = append(w.groups, rg.b)
+	w.rows += int64(b.Len)
+	return nil
+}
+
+// pageData returns the definition levels of nullable columns followed
+// by the PLAIN encoded non-null values of c.
+func pageData(c *Column, n int) []byte {
+	var b []byte
+	if c.Nullable {
+		// Definition levels are RLE encoded
+		// with a bit width of one and prefixed
+		// by their length.
+		b = append(b, 0, 0, 0, 0)
+		for i := 0; i < n; {
+			j := i + 1
+			for j < n && c.IsNull(j) == c.IsNull(i) {
+				j++
+			}
+			b = binary.AppendUvarint(b, uint64(j-i)<<1)
+			if c.IsNull(i) {
+				b = append(b, 0)
+			} else {
+				b = append(b, 1)
+			}
+			i = j
+		}
+		binary.LittleEndian.PutUint32(b, uint32(len(b)-4))
+	}
+	for i := 0; i < n; i++ {
+		if c.IsNull(i) {
+			continue
+		}
+		switch c.Type {
+		case Uint8, Uint16, Int32:
+			b = binary.LittleEndian.AppendUint32(b, uint32(c.Int(i)))
+		case Int64:
+			b = binary.LittleEndian.AppendUint64(b, uint64(c.Int(i)))
+		case Float64:
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.Float(i)))
+		case String, Binary:
+			v := c.Bytes(i)
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(v)))
+			b = append(b, v...)
+		}
+	}
+	return b
+}
+
+// Close writes the file metadata and footer. It does not close the
+// underlying writer.
+func (w *ParquetWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	var m thrift
+	m.i32(1, 1)
+	m.listHeader(2, thriftStruct, len(w.fields)+1)
+	m.elemBegin()
+	m.binary(4, "schema")
+	m.i32(5, int32(len(w.fields)))
+	m.structEnd()
+	for _, f := range w.fields {
+		physical, converted := parquetType(f.Type)
+		rep := int32(pqRequired)
+		if f.Nullable {
+			rep = pqOptional
+		}
+		m.elemBegin()
+		m.i32(1, physical)
+		m.i32(3, rep)
+		m.binary(4, f.Name)
+		if converted >= 0 {
+			m.i32(6, converted)
+		}
+		m.structEnd()
+	}
+	m.i64(3, w.rows)
+	m.listHeader(4, thriftStruct, len(w.groups))
+	for _, g := range w.groups {
+		m.elemBegin()
+		m.b = append(m.b, g...)
+		m.structEnd()
+	}
+	m.binary(6, "github.com/Schaudge/hts/columnar")
+	m.stop()
+
+	m.b = binary.LittleEndian.AppendUint32(m.b, uint32(len(m.b)))
+	m.b = append(m.b, parquetMagic...)
+	err := w.write(m.b)
+	if err == nil {
+		w.err = errors.New("columnar: write to closed ParquetWriter")
+	}
+	return err
+}
+
+// Thrift compact protocol type codes.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thrift is a Thrift compact protocol encoder. Fields must be written
+// in increasing order of ID within each struct.
+type thrift struct {
+	b     []byte
+	last  int16
+	stack []int16
+}
+
+func (t *thrift) field(id int16, typ byte) {
+	if d := id - t.last; 0 < d && d <= 15 {
+		t.b = append(t.b, byte(d)<<4|typ)
+	} else {
+		t.b = append(t.b, typ)
+		t.b = binary.AppendVarint(t.b, int64(id))
+	}
+	t.last = id
+}
+
+func (t *thrift) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.b = binary.AppendVarint(t.b, int64(v))
+}
+
+func (t *thrift) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.b = binary.AppendVarint(t.b, v)
+}
+
+func (t *thrift) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.listBinary(s)
+}
+
+// structBegin starts a struct field. It must be followed by the fields
+// of the struct and a call to structEnd.
+func (t *thrift) structBegin(id int16) {
+	t.field(id, thriftStruct)
+	t.elemBegin()
+}
+
+// elemBegin starts a struct list element. It must be followed by the
+// fields of the struct and a call to structEnd.
+func (t *thrift) elemBegin() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thrift) structEnd() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thrift) stop() { t.b = append(t.b, 0) }
+
+// listHeader starts a list field of n elements of the given type.
+func (t *thrift) listHeader(id int16, typ byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.b = append(t.b, byte(n)<<4|typ)
+		return
+	}
+	t.b = append(t.b, 0xf0|typ)
+	t.b = binary.AppendUvarint(t.b, uint64(n))
+}
+
+func (t *thrift) listI32(v int32) { t.b = binary.AppendVarint(t.b, int64(v)) }
+
+func (t *thrift) listBinary(s string) {
+	t.b = binary.AppendUvarint(t.b, uint64(len(s)))
+	t.b = append(t.b, s...)
+}
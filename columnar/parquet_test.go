@@ -0,0 +1,259 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestParquetWriter(t *testing.T) {
+	in := testRecords(t)
+	r, err := NewReader(&in, testTags, 2)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var (
+		buf     bytes.Buffer
+		batches []*Batch
+	)
+	w, err := NewParquetWriter(&buf, testTags)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for {
+		b, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading batch: %v", err)
+		}
+		err = w.Write(b)
+		if err != nil {
+			t.Fatalf("unexpected error writing batch: %v", err)
+		}
+		batches = append(batches, b)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	err = w.Write(batches[0])
+	if err == nil {
+		t.Error("expected error writing to closed writer")
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte(parquetMagic)) || !bytes.HasSuffix(data, []byte(parquetMagic)) {
+		t.Fatal("missing magic")
+	}
+	n := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta, err := readThrift(bytes.NewReader(data[len(data)-8-n : len(data)-8]))
+	if err != nil {
+		t.Fatalf("unexpected error decoding file metadata: %v", err)
+	}
+	var rows int
+	for _, b := range batches {
+		rows += b.Len
+	}
+	if meta[1] != int64(1) || meta[3] != int64(rows) {
+		t.Errorf("unexpected version or number of rows: %v %v", meta[1], meta[3])
+	}
+
+	// The schema is a root followed by
+	// the fields.
+	fields := Schema(testTags)
+	schema := meta[2].([]interface{})
+	if len(schema) != len(fields)+1 || schema[0].(map[int16]interface{})[5] != int64(len(fields)) {
+		t.Fatalf("unexpected schema: %v", schema)
+	}
+	for i, f := range fields {
+		e := schema[i+1].(map[int16]interface{})
+		physical, converted := parquetType(f.Type)
+		rep := int64(pqRequired)
+		if f.Nullable {
+			rep = pqOptional
+		}
+		if string(e[4].([]byte)) != f.Name || e[1] != int64(physical) || e[3] != rep {
+			t.Errorf("unexpected schema element for %s: %v", f.Name, e)
+		}
+		if c, ok := e[6]; ok != (converted >= 0) || (ok && c != int64(converted)) {
+			t.Errorf("unexpected converted type for %s: %v", f.Name, e[6])
+		}
+	}
+
+	groups := meta[4].([]interface{})
+	if len(groups) != len(batches) {
+		t.Fatalf("unexpected number of row groups: got:%d want:%d", len(groups), len(batches))
+	}
+	for g, b := range batches {
+		rg := groups[g].(map[int16]interface{})
+		if rg[3] != int64(b.Len) {
+			t.Errorf("unexpected number of rows in row group %d: %v", g, rg[3])
+		}
+		chunks := rg[1].([]interface{})
+		if len(chunks) != len(b.Columns) {
+			t.Fatalf("unexpected number of column chunks in row group %d: %d", g, len(chunks))
+		}
+		for i := range b.Columns {
+			c := &b.Columns[i]
+			md := chunks[i].(map[int16]interface{})[3].(map[int16]interface{})
+			if string(md[3].([]interface{})[0].([]byte)) != c.Name {
+				t.Errorf("unexpected path for column %s: %v", c.Name, md[3])
+			}
+			off, size := md[9].(int64), md[7].(int64)
+			pr := bytes.NewReader(data[off : off+size])
+			ph, err := readThrift(pr)
+			if err != nil {
+				t.Fatalf("unexpected error decoding page header: %v", err)
+			}
+			page := make([]byte, ph[3].(int64))
+			_, err = io.ReadFull(pr, page)
+			if err != nil || pr.Len() != 0 {
+				t.Fatalf("unexpected page size for %s: %v", c.Name, ph)
+			}
+			checkParquetPage(t, g, c, b.Len, page)
+		}
+	}
+}
+
+// checkParquetPage checks that the data page p holds the n values of c.
+func checkParquetPage(t *testing.T, g int, c *Column, n int, p []byte) {
+	t.Helper()
+	valid := make([]bool, n)
+	if c.Nullable {
+		l := binary.LittleEndian.Uint32(p)
+		levels := p[4 : 4+l]
+		p = p[4+l:]
+		var i int
+		for len(levels) != 0 {
+			h, k := binary.Uvarint(levels)
+			if h&1 != 0 {
+				t.Fatalf("unexpected bit-packed run for %s", c.Name)
+			}
+			for j := 0; j < int(h>>1); j++ {
+				valid[i] = levels[k] == 1
+				i++
+			}
+			levels = levels[k+1:]
+		}
+		if i != n {
+			t.Fatalf("unexpected number of definition levels for %s: got:%d want:%d", c.Name, i, n)
+		}
+	} else {
+		for i := range valid {
+			valid[i] = true
+		}
+	}
+	for i := 0; i < n; i++ {
+		if valid[i] == c.IsNull(i) {
+			t.Errorf("unexpected null state of %s in row group %d row %d", c.Name, g, i)
+			continue
+		}
+		if !valid[i] {
+			continue
+		}
+		var ok bool
+		switch c.Type {
+		case Uint8, Uint16, Int32:
+			ok = int64(int32(binary.LittleEndian.Uint32(p))) == c.Int(i)
+			p = p[4:]
+		case Int64:
+			ok = int64(binary.LittleEndian.Uint64(p)) == c.Int(i)
+			p = p[8:]
+		case Float64:
+			ok = math.Float64frombits(binary.LittleEndian.Uint64(p)) == c.Float(i)
+			p = p[8:]
+		case String, Binary:
+			l := binary.LittleEndian.Uint32(p)
+			ok = bytes.Equal(p[4:4+l], c.Bytes(i))
+			p = p[4+l:]
+		}
+		if !ok {
+			t.Errorf("unexpected value of %s in row group %d row %d", c.Name, g, i)
+		}
+	}
+	if len(p) != 0 {
+		t.Errorf("unexpected trailing data for %s: %d bytes", c.Name, len(p))
+	}
+}
+
+// readThrift decodes a Thrift compact protocol struct, returning its
+// fields by ID. Integers are returned as int64, binary values as []byte,
+// lists as []interface{} and structs as map[int16]interface{}.
+func readThrift(r io.ByteReader) (map[int16]interface{}, error) {
+	m := make(map[int16]interface{})
+	var last int16
+	for {
+		h, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if h == 0 {
+			return m, nil
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			v, err := binary.ReadVarint(r)
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+		m[id], err = readThriftValue(r, h&0xf)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readThriftValue(r io.ByteReader, typ byte) (interface{}, error) {
+	switch typ {
+	case thriftI32, thriftI64:
+		return binary.ReadVarint(r)
+	case thriftBinary:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		for i := range b {
+			b[i], err = r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case thriftList:
+		h, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(h >> 4)
+		if n == 15 {
+			n, err = binary.ReadUvarint(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		l := make([]interface{}, n)
+		for i := range l {
+			l[i], err = readThriftValue(r, h&0xf)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	case thriftStruct:
+		return readThrift(r)
+	}
+	return nil, errors.New("unsupported thrift type")
+}
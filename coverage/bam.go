@@ -0,0 +1,58 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package coverage
+
+import (
+	"io"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/pileup"
+	"github.com/Schaudge/hts/sam"
+)
+
+// BAM is a Querier for indexed BAM data. Each query opens the BAM data
+// with Open so that queries may proceed concurrently.
+type BAM struct {
+	// Open returns a new handle to the
+	// BAM data.
+	Open func() (io.ReadSeekCloser, error)
+
+	// Index is the index of the BAM data.
+	Index *bam.Index
+}
+
+// Query returns an iterator over the records overlapping [beg,end) of
+// ref. The returned iterator is an io.Closer.
+func (b BAM) Query(ref *sam.Reference, beg, end int) (pileup.Iterator, error) {
+	f, err := b.Open()
+	if err != nil {
+		return nil, err
+	}
+	br, err := bam.NewReader(f, 1)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	it, err := bam.NewRegionIterator(br, b.Index, []bam.Region{{Ref: ref, Start: beg, End: end}})
+	if err != nil {
+		br.Close()
+		f.Close()
+		return nil, err
+	}
+	return &bamIterator{Iterator: it, r: br, f: f}, nil
+}
+
+type bamIterator struct {
+	*bam.Iterator
+	r *bam.Reader
+	f io.Closer
+}
+
+func (i *bamIterator) Close() error {
+	err := i.Iterator.Close()
+	i.r.Close()
+	i.f.Close()
+	return err
+}
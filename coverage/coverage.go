@@ -0,0 +1,398 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package coverage implements calculation of per-base and windowed
+// depth of alignment coverage.
+//
+// Regions are divided into shards that are counted concurrently, each
+// shard querying its records independently, and results are returned
+// in region order. Regions may be restricted to the features of a BED
+// file by way of picard.FromBED and the IntervalList Regions method.
+package coverage
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/depth"
+	"github.com/Schaudge/hts/pileup"
+	"github.com/Schaudge/hts/sam"
+	"github.com/Schaudge/hts/track"
+)
+
+var (
+	ErrRegion = errors.New("coverage: invalid region")
+	ErrWindow = errors.New("coverage: invalid window size")
+)
+
+// DefaultShardSize is the default length of the reference segments
+// counted by each worker.
+const DefaultShardSize = 1 << 20
+
+// Querier provides the records overlapping a reference region. Query
+// is called concurrently for different regions, so implementations
+// must not share reader state between queries. If the returned
+// pileup.Iterator is an io.Closer, it is closed when the region has
+// been counted.
+type Querier interface {
+	Query(ref *sam.Reference, beg, end int) (pileup.Iterator, error)
+}
+
+// Options specifies the records counted and the parallelism of a
+// coverage calculation.
+type Options struct {
+	// Exclude specifies flags of records
+	// that are not counted.
+	Exclude sam.Flags
+
+	// MinMapQ is the minimum mapping
+	// quality of counted records.
+	MinMapQ byte
+
+	// Filter, if not nil, is called for each
+	// record passing the flag and mapping
+	// quality filters, and the record is not
+	// counted if it returns false.
+	Filter func(*sam.Record) bool
+
+	// MateOverlap specifies that bases
+	// covered by both reads of an overlapping
+	// read pair are counted once.
+	MateOverlap bool
+
+	// Threads is the number of concurrent
+	// workers. If Threads is less than one,
+	// GOMAXPROCS workers are used.
+	Threads int
+
+	// ShardSize is the maximum length of
+	// the reference segments counted by each
+	// worker. If ShardSize is zero,
+	// DefaultShardSize is used.
+	ShardSize int
+}
+
+// Genome returns regions covering the full length of each reference in
+// h.
+func Genome(h *sam.Header) []bam.Region {
+	refs := h.Refs()
+	regions := make([]bam.Region, len(refs))
+	for i, r := range refs {
+		regions[i] = bam.Region{Ref: r, Start: 0, End: r.Len()}
+	}
+	return regions
+}
+
+// Chroms returns the depth.Chrom descriptions of refs for use with
+// depth.NewWriter.
+func Chroms(refs []*sam.Reference) []depth.Chrom {
+	chroms := make([]depth.Chrom, len(refs))
+	for i, r := range refs {
+		chroms[i] = depth.Chrom{Name: r.Name(), Length: r.Len()}
+	}
+	return chroms
+}
+
+// DepthFunc is called with the per-base depths of consecutive positions
+// on ref starting from the zero-based position start. The depths slice
+// is only valid for the duration of the call.
+type DepthFunc func(ref *sam.Reference, start int, depths []uint32) error
+
+// ToDepth returns a DepthFunc writing depths to w.
+func ToDepth(w *depth.Writer) DepthFunc {
+	return func(ref *sam.Reference, start int, depths []uint32) error {
+		return w.Write(ref.Name(), start, depths)
+	}
+}
+
+// ToBedGraph returns a DepthFunc writing depths to w.
+func ToBedGraph(w *track.BedGraphWriter) DepthFunc {
+	var values []float64
+	return func(ref *sam.Reference, start int, depths []uint32) error {
+		values = values[:0]
+		for _, d := range depths {
+			values = append(values, float64(d))
+		}
+		return w.WriteValues(ref.Name(), start, 1, values)
+	}
+}
+
+// Depth calculates the per-base depth of the records provided by q over
+// the given regions, calling fn with the depths of each region in order.
+// Regions longer than the shard size are passed to fn in more than one
+// call.
+func Depth(q Querier, regions []bam.Region, opts Options, fn DepthFunc) error {
+	size := opts.ShardSize
+	if size == 0 {
+		size = DefaultShardSize
+	}
+	return run(q, regions, size, opts, func(s *shard) error {
+		return fn(s.ref, s.beg, s.depths)
+	})
+}
+
+// Windows calculates the mean depth of the records provided by q in
+// consecutive windows of the given size over the given regions, calling
+// fn with each window in order. Windows start from the beginning of
+// each region, and the last window of a region may be shorter than
+// size. The returned track.Interval may be passed directly to a
+// track.BedGraphWriter.
+func Windows(q Querier, regions []bam.Region, size int, opts Options, fn func(track.Interval) error) error {
+	if size < 1 {
+		return ErrWindow
+	}
+	shardSize := opts.ShardSize
+	if shardSize == 0 {
+		shardSize = DefaultShardSize
+	}
+	// Align shards to window boundaries.
+	shardSize = (shardSize + size - 1) / size * size
+	return run(q, regions, shardSize, opts, func(s *shard) error {
+		for i := 0; i < len(s.depths); i += size {
+			end := i + size
+			if end > len(s.depths) {
+				end = len(s.depths)
+			}
+			var sum uint64
+			for _, d := range s.depths[i:end] {
+				sum += uint64(d)
+			}
+			err := fn(track.Interval{
+				Chrom: s.ref.Name(),
+				Start: s.beg + i,
+				End:   s.beg + end,
+				Value: float64(sum) / float64(end-i),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// shard is a reference segment counted by a single worker.
+type shard struct {
+	ref      *sam.Reference
+	beg, end int
+
+	depths []uint32
+	err    error
+	done   chan struct{}
+}
+
+// run counts the depths of the shards of regions concurrently, calling
+// fn with each shard in order.
+func run(q Querier, regions []bam.Region, size int, opts Options, fn func(*shard) error) error {
+	var shards []*shard
+	for _, r := range regions {
+		if r.Ref == nil || r.Start < 0 || r.End > r.Ref.Len() || r.Start > r.End {
+			return ErrRegion
+		}
+		for beg := r.Start; beg < r.End; beg += size {
+			end := beg + size
+			if end > r.End {
+				end = r.End
+			}
+			shards = append(shards, &shard{ref: r.Ref, beg: beg, end: end, done: make(chan struct{})})
+		}
+	}
+
+	threads := opts.Threads
+	if threads < 1 {
+		threads = runtime.GOMAXPROCS(0)
+	}
+	var (
+		jobs = make(chan *shard)
+		stop = make(chan struct{})
+
+		// tokens limits the number of shards held
+		// in memory ahead of the shard being passed
+		// to fn.
+		tokens = make(chan struct{}, 2*threads)
+
+		wg sync.WaitGroup
+	)
+	go func() {
+		defer close(jobs)
+		for _, s := range shards {
+			select {
+			case tokens <- struct{}{}:
+			case <-stop:
+				return
+			}
+			select {
+			case jobs <- s:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				s.depths, s.err = count(q, s.ref, s.beg, s.end, opts)
+				close(s.done)
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	for _, s := range shards {
+		<-s.done
+		if s.err != nil {
+			return s.err
+		}
+		err := fn(s)
+		if err != nil {
+			return err
+		}
+		s.depths = nil
+		<-tokens
+	}
+	return nil
+}
+
+// block is a zero-based half-open reference interval.
+type block struct{ beg, end int }
+
+// count returns the depths of the records provided by q over [beg,end)
+// of ref.
+func count(q Querier, ref *sam.Reference, beg, end int, opts Options) (depths []uint32, err error) {
+	it, err := q.Query(ref, beg, end)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := it.(io.Closer); ok {
+		defer func() {
+			cerr := c.Close()
+			if err == nil {
+				err = cerr
+			}
+		}()
+	}
+
+	// diff holds the change in depth at
+	// each position of the shard.
+	diff := make([]int32, end-beg+1)
+	add := func(blocks []block, d int32) {
+		for _, b := range blocks {
+			if b.end <= beg || b.beg >= end {
+				continue
+			}
+			if b.beg < beg {
+				b.beg = beg
+			}
+			if b.end > end {
+				b.end = end
+			}
+			diff[b.beg-beg] += d
+			diff[b.end-beg] -= d
+		}
+	}
+
+	var (
+		blocks  []block
+		overlap []block
+		pending map[string][]block
+	)
+	if opts.MateOverlap {
+		pending = make(map[string][]block)
+	}
+	for it.Next() {
+		rec := it.Record()
+		if !include(rec, opts) || rec.Ref.ID() != ref.ID() {
+			continue
+		}
+		blocks = alignedBlocks(blocks[:0], rec)
+		add(blocks, 1)
+
+		if !opts.MateOverlap || rec.Flags&sam.Paired == 0 {
+			continue
+		}
+		if mate, ok := pending[rec.Name]; ok {
+			delete(pending, rec.Name)
+			overlap = intersect(overlap[:0], blocks, mate)
+			add(overlap, -1)
+			continue
+		}
+		if rec.Flags&sam.MateUnmapped == 0 && rec.MateRef != nil && rec.MateRef.ID() == rec.Ref.ID() &&
+			rec.Pos <= rec.MatePos && rec.MatePos < rec.End() {
+			pending[rec.Name] = append([]block(nil), blocks...)
+		}
+	}
+	err = it.Error()
+	if err != nil {
+		return nil, err
+	}
+
+	depths = make([]uint32, end-beg)
+	var d int32
+	for i := range depths {
+		d += diff[i]
+		depths[i] = uint32(d)
+	}
+	return depths, nil
+}
+
+// include returns whether rec passes the record filters in opts.
+func include(rec *sam.Record, opts Options) bool {
+	if rec.Flags&opts.Exclude != 0 || rec.Flags&sam.Unmapped != 0 || rec.Ref == nil {
+		return false
+	}
+	if rec.MapQ < opts.MinMapQ {
+		return false
+	}
+	return opts.Filter == nil || opts.Filter(rec)
+}
+
+// alignedBlocks appends the reference intervals covered by aligned
+// bases of rec to dst. Deletions and reference skips are not included.
+func alignedBlocks(dst []block, rec *sam.Record) []block {
+	pos := rec.Pos
+	for _, co := range rec.Cigar {
+		n := co.Len()
+		switch co.Type() {
+		case sam.CigarMatch, sam.CigarEqual, sam.CigarMismatch:
+			if l := len(dst); l != 0 && dst[l-1].end == pos {
+				dst[l-1].end += n
+			} else {
+				dst = append(dst, block{beg: pos, end: pos + n})
+			}
+		}
+		pos += n * co.Type().Consumes().Reference
+	}
+	return dst
+}
+
+// intersect appends the intersections of the sorted intervals in a and
+// b to dst.
+func intersect(dst, a, b []block) []block {
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		beg, end := a[i].beg, a[i].end
+		if b[j].beg > beg {
+			beg = b[j].beg
+		}
+		if b[j].end < end {
+			end = b[j].end
+		}
+		if beg < end {
+			dst = append(dst, block{beg: beg, end: end})
+		}
+		if a[i].end < b[j].end {
+			i++
+		} else {
+			j++
+		}
+	}
+	return dst
+}
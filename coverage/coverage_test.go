@@ -0,0 +1,279 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/pileup"
+	"github.com/Schaudge/hts/sam"
+	"github.com/Schaudge/hts/track"
+)
+
+var _ Querier = BAM{}
+
+// records is a Querier over an in-memory set of records.
+type records []*sam.Record
+
+func (r records) Query(ref *sam.Reference, beg, end int) (pileup.Iterator, error) {
+	var it sliceIterator
+	for _, rec := range r {
+		if rec.Ref == ref && rec.Pos < end && rec.End() > beg {
+			it.recs = append(it.recs, rec)
+		}
+	}
+	return &it, nil
+}
+
+type sliceIterator struct {
+	recs []*sam.Record
+	rec  *sam.Record
+}
+
+func (it *sliceIterator) Next() bool {
+	if len(it.recs) == 0 {
+		return false
+	}
+	it.rec, it.recs = it.recs[0], it.recs[1:]
+	return true
+}
+func (it *sliceIterator) Record() *sam.Record { return it.rec }
+func (it *sliceIterator) Error() error        { return nil }
+
+func testHeader(t *testing.T, lengths ...int) *sam.Header {
+	t.Helper()
+	var refs []*sam.Reference
+	for i, l := range lengths {
+		ref, err := sam.NewReference(fmt.Sprintf("chr%d", i+1), "", "", l, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error making reference: %v", err)
+		}
+		refs = append(refs, ref)
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	h.SortOrder = sam.Coordinate
+	return h
+}
+
+func randomRecords(t *testing.T, h *sam.Header, n int) records {
+	rnd := rand.New(rand.NewSource(1))
+	cigars := []struct {
+		cigar string
+		len   int
+	}{{"50M", 50}, {"20M5D30M", 50}, {"10S40M", 50}, {"25M100N25M", 50}, {"30M2I20M", 52}}
+	b := htstestutil.NewBAMBuilder()
+	for i := 0; i < n; i++ {
+		ref := h.Refs()[rnd.Intn(len(h.Refs()))]
+		pos := rnd.Intn(ref.Len() - 200)
+		c := cigars[rnd.Intn(len(cigars))]
+		r := b.Record(fmt.Sprintf("r%d", i), ref.Name(), pos, c.cigar, strings.Repeat("A", c.len)).MapQ(byte(rnd.Intn(60)))
+		if rnd.Intn(10) == 0 {
+			r.Flags(sam.Duplicate)
+		}
+	}
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].LessByCoordinate(recs[j]) })
+	return recs
+}
+
+// naive returns the per-base depths of each reference in h.
+func naive(h *sam.Header, recs records, opts Options) map[string][]uint32 {
+	depths := make(map[string][]uint32)
+	for _, ref := range h.Refs() {
+		depths[ref.Name()] = make([]uint32, ref.Len())
+	}
+	for _, rec := range recs {
+		if !include(rec, opts) {
+			continue
+		}
+		for _, b := range alignedBlocks(nil, rec) {
+			for i := b.beg; i < b.end; i++ {
+				depths[rec.Ref.Name()][i]++
+			}
+		}
+	}
+	return depths
+}
+
+func TestDepth(t *testing.T) {
+	h := testHeader(t, 5000, 3000)
+	recs := randomRecords(t, h, 500)
+	for _, opts := range []Options{
+		{Threads: 1},
+		{Threads: 4, ShardSize: 333, MinMapQ: 20, Exclude: sam.Duplicate},
+		{Threads: 3, ShardSize: 1, Filter: func(r *sam.Record) bool { return strings.HasSuffix(r.Name, "7") }},
+	} {
+		want := naive(h, recs, opts)
+		got := make(map[string][]uint32)
+		err := Depth(recs, Genome(h), opts, func(ref *sam.Reference, start int, depths []uint32) error {
+			if start != len(got[ref.Name()]) {
+				return fmt.Errorf("out of order depths at %s:%d", ref.Name(), start)
+			}
+			got[ref.Name()] = append(got[ref.Name()], depths...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected depths for options %+v", opts)
+		}
+	}
+
+	region := []bam.Region{{Ref: h.Refs()[0], Start: 100, End: 10000}}
+	err := Depth(recs, region, Options{}, func(*sam.Reference, int, []uint32) error { return nil })
+	if err != ErrRegion {
+		t.Errorf("unexpected error for invalid region: got:%v want:%v", err, ErrRegion)
+	}
+	errStop := fmt.Errorf("stop")
+	err = Depth(recs, Genome(h), Options{ShardSize: 10}, func(*sam.Reference, int, []uint32) error { return errStop })
+	if err != errStop {
+		t.Errorf("unexpected error for stopped calculation: got:%v want:%v", err, errStop)
+	}
+}
+
+func TestMateOverlap(t *testing.T) {
+	h := testHeader(t, 100)
+	ref := h.Refs()[0]
+	b := htstestutil.NewBAMBuilder()
+	b.Record("p", ref.Name(), 10, "10M", strings.Repeat("A", 10)).Flags(sam.Paired|sam.Read1).Mate("=", 15, 0).
+		Record("p", ref.Name(), 15, "3M2D5M", strings.Repeat("A", 8)).Flags(sam.Paired|sam.Read2).Mate("=", 10, 0)
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+
+	for _, test := range []struct {
+		overlap bool
+		want    []uint32
+	}{
+		{overlap: false, want: []uint32{1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1}},
+		{overlap: true, want: []uint32{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+	} {
+		var got []uint32
+		err := Depth(records(recs), []bam.Region{{Ref: ref, Start: 10, End: 25}}, Options{MateOverlap: test.overlap, ShardSize: 7},
+			func(_ *sam.Reference, _ int, depths []uint32) error {
+				got = append(got, depths...)
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected depths with mate overlap %t:\ngot: %v\nwant:%v", test.overlap, got, test.want)
+		}
+	}
+}
+
+func TestWindows(t *testing.T) {
+	h := testHeader(t, 100)
+	ref := h.Refs()[0]
+	b := htstestutil.NewBAMBuilder()
+	b.Record("a", ref.Name(), 0, "10M", strings.Repeat("A", 10)).
+		Record("b", ref.Name(), 5, "10M", strings.Repeat("A", 10))
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+	var buf bytes.Buffer
+	bg, err := track.NewBedGraphWriter(&buf, "")
+	if err != nil {
+		t.Fatalf("unexpected error making writer: %v", err)
+	}
+	err = Windows(records(recs), []bam.Region{{Ref: ref, Start: 0, End: 24}}, 10, Options{ShardSize: 15}, bg.Write)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = bg.Flush()
+	if err != nil {
+		t.Fatalf("unexpected error flushing writer: %v", err)
+	}
+	want := "chr1\t0\t10\t1.5\nchr1\t10\t20\t0.5\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("unexpected bedGraph output:\ngot:\n%s\nwant suffix:\n%s", buf.String(), want)
+	}
+	err = Windows(records(recs), Genome(h), 0, Options{}, bg.Write)
+	if err != ErrWindow {
+		t.Errorf("unexpected error for zero window: got:%v want:%v", err, ErrWindow)
+	}
+}
+
+type nopCloser struct{ *bytes.Reader }
+
+func (nopCloser) Close() error { return nil }
+
+func TestBAM(t *testing.T) {
+	h := testHeader(t, 20000, 10000)
+	recs := randomRecords(t, h, 2000)
+
+	var buf bytes.Buffer
+	bw, err := bam.NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error making writer: %v", err)
+	}
+	for _, r := range recs {
+		err = bw.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	br, err := bam.NewReader(bytes.NewReader(buf.Bytes()), 1)
+	if err != nil {
+		t.Fatalf("unexpected error making reader: %v", err)
+	}
+	var idx bam.Index
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("unexpected error indexing record: %v", err)
+		}
+	}
+
+	q := BAM{
+		Open: func() (io.ReadSeekCloser, error) {
+			return nopCloser{bytes.NewReader(buf.Bytes())}, nil
+		},
+		Index: &idx,
+	}
+	opts := Options{Threads: 4, ShardSize: 3000, Exclude: sam.Duplicate}
+	want := naive(h, recs, opts)
+	got := make(map[string][]uint32)
+	err = Depth(q, Genome(h), opts, func(ref *sam.Reference, start int, depths []uint32) error {
+		got[ref.Name()] = append(got[ref.Name()], depths...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("unexpected depths from BAM")
+	}
+}
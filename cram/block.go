@@ -0,0 +1,145 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// block is a decompressed CRAM block.
+type block struct {
+	method    Method
+	content   contentType
+	contentID int32
+	data      []byte
+}
+
+// readBlock reads and decompresses a single block from c.
+func readBlock(c *cursor) (*block, error) {
+	start := c.off
+	var b block
+	b.method = Method(c.byte())
+	b.content = contentType(c.byte())
+	b.contentID = c.itf8()
+	size := c.itf8()
+	rawSize := c.itf8()
+	if c.err == nil && (size < 0 || rawSize < 0) {
+		return nil, ErrCorrupt
+	}
+	data := c.bytes(int(size))
+	end := c.off
+	sum := c.uint32()
+	if c.err != nil {
+		return nil, c.err
+	}
+	if crc32.ChecksumIEEE(c.b[start:end]) != sum {
+		return nil, ErrChecksum
+	}
+	var err error
+	b.data, err = decompress(b.method, data, int(rawSize))
+	if err != nil {
+		return nil, err
+	}
+	if len(b.data) != int(rawSize) {
+		return nil, ErrCorrupt
+	}
+	return &b, nil
+}
+
+// decompress decompresses data compressed with method m into a slice
+// of length size.
+func decompress(m Method, data []byte, size int) ([]byte, error) {
+	switch m {
+	case Raw:
+		return data, nil
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return readAllSize(r, size)
+	case Bzip2:
+		return readAllSize(bzip2.NewReader(bytes.NewReader(data)), size)
+	case Rans4x8:
+		return rans4x8Decode(data)
+	case RansNx16:
+		return ransNx16Decode(data, size)
+	}
+	return nil, ErrUnsupportedMethod(m)
+}
+
+// readAllSize reads all the data from r, expecting size bytes.
+func readAllSize(r io.Reader, size int) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	_, err := buf.ReadFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blocks holds the external blocks of a slice keyed by content ID.
+type blocks map[int32]*cursor
+
+func (b blocks) get(id int32) *cursor {
+	c, ok := b[id]
+	if !ok {
+		// Missing blocks behave as empty blocks so that
+		// reads from them fail with ErrCorrupt.
+		c = newCursor(nil)
+		b[id] = c
+	}
+	return c
+}
+
+// compress returns raw compressed using method m. For the rANS
+// methods, the smaller of the order-0 and order-1 encodings is used.
+func compress(m Method, raw []byte) ([]byte, error) {
+	switch m {
+	case Raw:
+		return raw, nil
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(raw)
+		if err != nil {
+			return nil, err
+		}
+		err = w.Close()
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Rans4x8:
+		return smaller(rans4x8Encode(raw, 0), rans4x8Encode(raw, 1)), nil
+	case RansNx16:
+		return smaller(ransNx16Encode(raw, 0), ransNx16Encode(raw, ransOrder1Flag)), nil
+	}
+	return nil, ErrUnsupportedMethod(m)
+}
+
+func smaller(a, b []byte) []byte {
+	if len(b) < len(a) {
+		return b
+	}
+	return a
+}
+
+// appendBlock appends a block holding data, the compressed form of
+// raw using method m, to b.
+func appendBlock(b []byte, m Method, ct contentType, id int32, raw, data []byte) []byte {
+	start := len(b)
+	b = append(b, byte(m), byte(ct))
+	b = appendITF8(b, id)
+	b = appendITF8(b, int32(len(data)))
+	b = appendITF8(b, int32(len(raw)))
+	b = append(b, data...)
+	return binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(b[start:]))
+}
@@ -0,0 +1,305 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Version is a CRAM file format version.
+type Version struct {
+	Major, Minor byte
+}
+
+// containerHeader is a CRAM container header.
+type containerHeader struct {
+	// length is the number of bytes in the
+	// container following the header.
+	length int32
+
+	refID     int32
+	start     int32
+	span      int32
+	records   int32
+	counter   int64
+	bases     int64
+	blocks    int32
+	landmarks []int32
+
+	// size is the size of the header.
+	size int
+}
+
+// isEOF returns whether h is the header of an EOF container.
+func (h *containerHeader) isEOF() bool {
+	return h.refID == -1 && h.start == eofStart && h.records == 0
+}
+
+// readContainerHeader reads a container header from r. If r is at
+// the end of the stream, io.EOF is returned.
+func readContainerHeader(r io.Reader) (*containerHeader, error) {
+	var buf [4]byte
+	_, err := io.ReadFull(r, buf[:])
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, ErrCorrupt
+		}
+		return nil, err
+	}
+	raw := append(make([]byte, 0, 64), buf[:]...)
+	h := containerHeader{length: int32(binary.LittleEndian.Uint32(buf[:]))}
+	fail := func(err error) (*containerHeader, error) {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrCorrupt
+		}
+		return nil, err
+	}
+	for _, v := range []*int32{&h.refID, &h.start, &h.span, &h.records} {
+		*v, err = readITF8(r, &raw)
+		if err != nil {
+			return fail(err)
+		}
+	}
+	for _, v := range []*int64{&h.counter, &h.bases} {
+		*v, err = readLTF8(r, &raw)
+		if err != nil {
+			return fail(err)
+		}
+	}
+	h.blocks, err = readITF8(r, &raw)
+	if err != nil {
+		return fail(err)
+	}
+	n, err := readITF8(r, &raw)
+	if err != nil {
+		return fail(err)
+	}
+	if n < 0 || n > h.blocks {
+		return nil, ErrCorrupt
+	}
+	h.landmarks = make([]int32, n)
+	for i := range h.landmarks {
+		h.landmarks[i], err = readITF8(r, &raw)
+		if err != nil {
+			return fail(err)
+		}
+	}
+	_, err = io.ReadFull(r, buf[:])
+	if err != nil {
+		return fail(err)
+	}
+	if crc32.ChecksumIEEE(raw) != binary.LittleEndian.Uint32(buf[:]) {
+		return nil, ErrChecksum
+	}
+	if h.length < 0 {
+		return nil, ErrCorrupt
+	}
+	h.size = len(raw) + len(buf)
+	return &h, nil
+}
+
+// readContainer reads the header and data of a container from r.
+func readContainer(r io.Reader) (*containerHeader, []byte, error) {
+	h, err := readContainerHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := make([]byte, h.length)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return nil, nil, ErrCorrupt
+	}
+	return h, data, nil
+}
+
+// compressionHeader is a CRAM container compression header.
+type compressionHeader struct {
+	readNames   bool
+	apDelta     bool
+	refRequired bool
+	subst       [5][4]byte
+	tagDict     [][][3]byte
+
+	series map[[2]byte]*encoding
+	tags   map[int32]*encoding
+}
+
+// key returns the data series key for the two character name.
+func key(s string) [2]byte { return [2]byte{s[0], s[1]} }
+
+// readCompressionHeader reads a compression header from the data of b.
+func readCompressionHeader(b *block) (*compressionHeader, error) {
+	if b.content != compressionHeaderContent {
+		return nil, ErrCorrupt
+	}
+	c := newCursor(b.data)
+	h := compressionHeader{
+		readNames:   true,
+		apDelta:     true,
+		refRequired: true,
+		series:      make(map[[2]byte]*encoding),
+		tags:        make(map[int32]*encoding),
+	}
+	// Substitutions are in base order unless
+	// an SM entry says otherwise.
+	for i := range h.subst {
+		h.subst[i] = substitutes(i, 0x1b)
+	}
+
+	// Preservation map.
+	c.itf8()
+	n := c.itf8()
+	for i := int32(0); i < n && c.err == nil; i++ {
+		switch k := string(c.bytes(2)); k {
+		case "RN":
+			h.readNames = c.byte() != 0
+		case "AP":
+			h.apDelta = c.byte() != 0
+		case "RR":
+			h.refRequired = c.byte() != 0
+		case "SM":
+			for j, v := range c.bytes(5) {
+				h.subst[j] = substitutes(j, v)
+			}
+		case "TD":
+			td := c.bytes(int(c.itf8()))
+			var line [][3]byte
+			for j := 0; j < len(td); {
+				if td[j] == 0 {
+					h.tagDict = append(h.tagDict, line)
+					line = nil
+					j++
+					continue
+				}
+				if j+3 > len(td) {
+					return nil, ErrCorrupt
+				}
+				line = append(line, [3]byte{td[j], td[j+1], td[j+2]})
+				j += 3
+			}
+		default:
+			return nil, ErrCorrupt
+		}
+	}
+
+	// Data series encoding map.
+	c.itf8()
+	n = c.itf8()
+	for i := int32(0); i < n && c.err == nil; i++ {
+		k := c.bytes(2)
+		e, err := readEncoding(c)
+		if err != nil {
+			return nil, err
+		}
+		h.series[[2]byte{k[0], k[1]}] = e
+	}
+
+	// Tag encoding map.
+	c.itf8()
+	n = c.itf8()
+	for i := int32(0); i < n && c.err == nil; i++ {
+		k := c.itf8()
+		e, err := readEncoding(c)
+		if err != nil {
+			return nil, err
+		}
+		h.tags[k] = e
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &h, nil
+}
+
+// bases is the ordering of bases used by the substitution matrix.
+const bases = "ACGTN"
+
+// substitutes returns the substitution bases for each of the four
+// substitution codes of the reference base with index ref, given the
+// substitution matrix byte v.
+func substitutes(ref int, v byte) [4]byte {
+	var s [4]byte
+	i := 0
+	for j := range bases {
+		if j == ref {
+			continue
+		}
+		code := v >> (6 - 2*uint(i)) & 3
+		s[code] = bases[j]
+		i++
+	}
+	return s
+}
+
+// baseIndex returns the substitution matrix index of the base b.
+func baseIndex(b byte) int {
+	switch b {
+	case 'A', 'a':
+		return 0
+	case 'C', 'c':
+		return 1
+	case 'G', 'g':
+		return 2
+	case 'T', 't':
+		return 3
+	}
+	return 4
+}
+
+// sliceHeader is a CRAM slice header.
+type sliceHeader struct {
+	refID     int32
+	start     int32
+	span      int32
+	records   int32
+	counter   int64
+	blocks    int32
+	contentID []int32
+	embedded  int32
+	md5       [16]byte
+}
+
+// multiRef is the reference ID of multi-reference slices.
+const multiRef = -2
+
+// readSliceHeader reads a slice header from the data of b.
+func readSliceHeader(b *block) (*sliceHeader, error) {
+	if b.content != sliceHeaderContent {
+		return nil, ErrCorrupt
+	}
+	c := newCursor(b.data)
+	var h sliceHeader
+	h.refID = c.itf8()
+	h.start = c.itf8()
+	h.span = c.itf8()
+	h.records = c.itf8()
+	h.counter = c.ltf8()
+	h.blocks = c.itf8()
+	h.contentID = c.itf8Array()
+	h.embedded = c.itf8()
+	copy(h.md5[:], c.bytes(16))
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &h, nil
+}
+
+// appendContainer appends a container holding data to b.
+func appendContainer(b []byte, refID, start, span, nrec int32, counter, bases int64, nblocks int32, landmarks []int32, data []byte) []byte {
+	h := binary.LittleEndian.AppendUint32(nil, uint32(len(data)))
+	h = appendITF8(h, refID)
+	h = appendITF8(h, start)
+	h = appendITF8(h, span)
+	h = appendITF8(h, nrec)
+	h = appendLTF8(h, counter)
+	h = appendLTF8(h, bases)
+	h = appendITF8(h, nblocks)
+	h = appendITF8Array(h, landmarks)
+	h = binary.LittleEndian.AppendUint32(h, crc32.ChecksumIEEE(h))
+	b = append(b, h...)
+	return append(b, data...)
+}
@@ -0,0 +1,116 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cram implements CRAM file format reading and writing.
+//
+// The package supports reading CRAM versions 3.0 and 3.1. Blocks compressed
+// with gzip, bzip2, rANS 4x8 and rANS Nx16 are supported; blocks compressed
+// with lzma or the CRAM 3.1 arithmetic, fqzcomp and name tokeniser codecs
+// are reported as ErrUnsupportedMethod. Written files are CRAM 3.0 unless
+// rANS Nx16 compression is used, in which case they are CRAM 3.1.
+//
+// The CRAM specification can be found at https://samtools.github.io/hts-specs/CRAMv3.pdf.
+package cram
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotCRAM         = errors.New("cram: not a CRAM file")
+	ErrCorrupt         = errors.New("cram: corrupt data")
+	ErrChecksum        = errors.New("cram: checksum mismatch")
+	ErrNoReference     = errors.New("cram: reference sequence unavailable")
+	ErrReferenceMD5    = errors.New("cram: reference md5 mismatch")
+	ErrNotASeeker      = errors.New("cram: not a seeker")
+	ErrInvalidEncoding = errors.New("cram: invalid encoding for data series")
+)
+
+// ErrUnsupportedMethod is returned when a block is compressed with a
+// method that is not supported by the package.
+type ErrUnsupportedMethod Method
+
+func (e ErrUnsupportedMethod) Error() string {
+	return fmt.Sprintf("cram: unsupported block compression method: %s", Method(e))
+}
+
+// ErrUnsupportedVersion is returned when a CRAM file has a version that
+// is not supported by the package.
+type ErrUnsupportedVersion struct {
+	Major, Minor byte
+}
+
+func (e ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("cram: unsupported version: %d.%d", e.Major, e.Minor)
+}
+
+// ReferenceProvider provides reference sequence for reference-based
+// encoding and decoding of CRAM records. Any sam.ReferenceProvider
+// satisfies ReferenceProvider.
+type ReferenceProvider interface {
+	// GetSequence returns the bases of the named reference
+	// sequence in the zero-based half-open interval [beg,end).
+	// The returned sequence may be shorter than requested
+	// if end is beyond the end of the reference sequence.
+	GetSequence(name string, beg, end int) ([]byte, error)
+}
+
+const magic = "CRAM"
+
+// Method is a block compression method.
+type Method byte
+
+// Block compression methods. Blocks compressed with any of these
+// methods can be read, but only Raw, Gzip, Rans4x8 and RansNx16
+// can be used for writing.
+const (
+	Raw      Method = 0
+	Gzip     Method = 1
+	Bzip2    Method = 2
+	LZMA     Method = 3
+	Rans4x8  Method = 4
+	RansNx16 Method = 5
+	Arith    Method = 6
+	FQZComp  Method = 7
+	Tok3     Method = 8
+)
+
+func (m Method) String() string {
+	switch m {
+	case Raw:
+		return "raw"
+	case Gzip:
+		return "gzip"
+	case Bzip2:
+		return "bzip2"
+	case LZMA:
+		return "lzma"
+	case Rans4x8:
+		return "rans4x8"
+	case RansNx16:
+		return "ransNx16"
+	case Arith:
+		return "arith"
+	case FQZComp:
+		return "fqzcomp"
+	case Tok3:
+		return "tok3"
+	}
+	return fmt.Sprintf("method(%d)", byte(m))
+}
+
+// contentType is a block content type.
+type contentType byte
+
+const (
+	fileHeaderContent        contentType = 0
+	compressionHeaderContent contentType = 1
+	sliceHeaderContent       contentType = 2
+	externalContent          contentType = 4
+	coreContent              contentType = 5
+)
+
+// eofStart is the alignment start of the EOF container.
+const eofStart = 4542278
@@ -0,0 +1,898 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+var itf8Tests = []struct {
+	v    int32
+	want []byte
+}{
+	{v: 0, want: []byte{0x00}},
+	{v: 0x7f, want: []byte{0x7f}},
+	{v: 0x80, want: []byte{0x80, 0x80}},
+	{v: 0x3fff, want: []byte{0xbf, 0xff}},
+	{v: 0x4000, want: []byte{0xc0, 0x40, 0x00}},
+	{v: 0x1fffff, want: []byte{0xdf, 0xff, 0xff}},
+	{v: 0x200000, want: []byte{0xe0, 0x20, 0x00, 0x00}},
+	{v: 0x0fffffff, want: []byte{0xef, 0xff, 0xff, 0xff}},
+	{v: 0x10000000, want: []byte{0xf1, 0x00, 0x00, 0x00, 0x00}},
+	{v: -1, want: []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+}
+
+func TestITF8(t *testing.T) {
+	for _, test := range itf8Tests {
+		b := appendITF8(nil, test.v)
+		if !bytes.Equal(b, test.want) {
+			t.Errorf("unexpected encoding of %d: got:%#v want:%#v", test.v, b, test.want)
+		}
+		got, n := itf8(test.want)
+		if got != test.v || n != len(test.want) {
+			t.Errorf("unexpected decoding of %#v: got:%d (%d bytes) want:%d (%d bytes)",
+				test.want, got, n, test.v, len(test.want))
+		}
+		_, n = itf8(test.want[:len(test.want)-1])
+		if n != 0 {
+			t.Errorf("expected failure decoding short %#v", test.want)
+		}
+	}
+}
+
+func TestLTF8(t *testing.T) {
+	for _, v := range []int64{0, 0x7f, 0x80, 0x3fff, 0x4000, 1<<28 - 1, 1 << 28, 1<<35 + 5, 1<<49 + 3, 1<<56 - 1, 1 << 56, 1<<63 - 1, -1} {
+		b := appendLTF8(nil, v)
+		got, n := ltf8(b)
+		if got != v || n != len(b) {
+			t.Errorf("unexpected round trip of %d: got:%d (%d of %d bytes)", v, got, n, len(b))
+		}
+	}
+	if b := appendLTF8(nil, -1); !bytes.Equal(b, bytes.Repeat([]byte{0xff}, 9)) {
+		t.Errorf("unexpected encoding of -1: %#v", b)
+	}
+}
+
+func TestRANS(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 3, 4, 5, 7, 33, 100, 1001, 10000} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = "AACGTTTTNN\x00"[rnd.Intn(11)]
+		}
+
+		for order := 0; order < 2; order++ {
+			got, err := rans4x8Decode(rans4x8Encode(data, order))
+			if err != nil {
+				t.Errorf("unexpected error decoding rANS 4x8 order %d length %d: %v", order, n, err)
+			} else if !bytes.Equal(got, data) {
+				t.Errorf("unexpected rANS 4x8 order %d result for length %d", order, n)
+			}
+		}
+
+		for _, flags := range []byte{0, ransOrder1Flag, ransX32Flag, ransOrder1Flag | ransX32Flag} {
+			got, err := ransNx16Decode(ransNx16Encode(data, flags), 0)
+			if err != nil {
+				t.Errorf("unexpected error decoding rANS Nx16 flags %#x length %d: %v", flags, n, err)
+			} else if !bytes.Equal(got, data) {
+				t.Errorf("unexpected rANS Nx16 flags %#x result for length %d", flags, n)
+			}
+		}
+	}
+}
+
+var ransNx16Tests = []struct {
+	name string
+	data []byte
+	size int
+	want string
+}{
+	{
+		name: "cat",
+		data: []byte{ransCatFlag, 5, 'h', 'e', 'l', 'l', 'o'},
+		want: "hello",
+	},
+	{
+		name: "nosz",
+		data: []byte{ransCatFlag | ransNoSizeFlag, 'h', 'e', 'l', 'l', 'o'},
+		size: 5,
+		want: "hello",
+	},
+	{
+		name: "stripe",
+		data: []byte{
+			ransStripeFlag, 5, 2, 4, 3,
+			ransCatFlag | ransNoSizeFlag, 'h', 'l', 'o',
+			ransCatFlag | ransNoSizeFlag, 'e', 'l',
+		},
+		want: "hello",
+	},
+	{
+		name: "pack",
+		data: []byte{
+			ransPackFlag | ransCatFlag, 6,
+			3, 'a', 'b', 'c', // Symbol map.
+			2,          // Packed length.
+			0x24, 0x01, // a=0, b=1, c=2 packed two bits per symbol.
+		},
+		want: "abcaba",
+	},
+	{
+		name: "rle",
+		data: []byte{
+			ransRLEFlag | ransCatFlag, 7,
+			4<<1 | 1, 3, // Uncompressed metadata length and literal count.
+			1, 'a', 3, 1, // Run symbols and run lengths.
+			'a', 'c', 'a',
+		},
+		want: "aaaacaa",
+	},
+}
+
+func TestRANSNx16Transforms(t *testing.T) {
+	for _, test := range ransNx16Tests {
+		got, err := ransNx16Decode(test.data, test.size)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", test.name, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("unexpected result for %s: got:%q want:%q", test.name, got, test.want)
+		}
+	}
+}
+
+// ransVectors are rANS streams made by an encoder written from the CRAM
+// codecs specification independently of this package, so that the
+// decoders are checked against the specification and not only against
+// rans4x8Encode and ransNx16Encode.
+var ransVectors = []struct {
+	name string
+	nx16 bool
+	data string
+	want string
+}{
+	{
+		name: "4x8 order 0",
+		data: "001f00000010000000418800438400478200548200000090002000900020ac008000be0080000000",
+		want: "AAAACCGTAAAACCGT",
+	},
+	{
+		name: "4x8 order 0 symbol run",
+		data: "001d000000100000004186004202840084008200000054ed2500265655002c57558e5f550100",
+		want: "ABCDABCDAAAABBCC",
+	},
+	{
+		name: "4x8 order 0 renormalised",
+		data: "0028000000200000004182004382004782004e8800548200000100800013028000250480007f0e8000c0c2c4ce00000000",
+		want: "ACGTACGTACGTACGTNNNNNNNNNNNNNNNN",
+	},
+	{
+		name: "4x8 order 1",
+		data: "01480000001c000000004188004e84005484000041438d564782aa0043438333478ccd0047418333548ccd004e4188004e880000544188014782aa54855500006f709e056dfdad0cc995201c072bfa1d20",
+		want: "ACGTACGTTTGACCAGTACGTNNACGTA",
+	},
+	{
+		name: "4x8 order 1 remainder",
+		data: "01330000001700000000418c004384000041418e004781005481000043419000004741900000544190000000001a2301003cfc020066ee0d0051cb14",
+		want: "AAAAACAAAAAGAAAAATAAAAA",
+	},
+	{
+		name: "Nx16 order 0",
+		nx16: true,
+		data: "00104143475400900088008400840000902000009020000054810000768100",
+		want: "AAAACCGTAAAACCGT",
+	},
+	{
+		name: "Nx16 order 0 alphabet run",
+		nx16: true,
+		data: "0010414202008c008800880084000032260000265600002c5700007e5c01",
+		want: "ABCDABCDAAAABBCC",
+	},
+	{
+		name: "Nx16 order 0 renormalised",
+		nx16: true,
+		data: "00204143474e540084008400840090008400708000007082000071840000738e000000000092002400fe",
+		want: "ACGTACGTACGTACGTNNNNNNNNNNNNNNNN",
+	},
+	{
+		name: "Nx16 pack",
+		nx16: true,
+		data: "a011044143475405e41b50fa00",
+		want: "ACGTTGCAAACCGGTTA",
+	},
+	{
+		name: "Nx16 pack one bit",
+		nx16: true,
+		data: "a00a02414e023b01",
+		want: "NNANNNAANA",
+	},
+	{
+		name: "Nx16 rle",
+		nx16: true,
+		data: "60140d06024154090500414347544143",
+		want: "AAAAAAAAAACGTTTTTTAC",
+	},
+}
+
+func TestRANSVectors(t *testing.T) {
+	for _, test := range ransVectors {
+		data, err := hex.DecodeString(test.data)
+		if err != nil {
+			t.Fatalf("unexpected error decoding hex for %s: %v", test.name, err)
+		}
+		var got []byte
+		if test.nx16 {
+			got, err = ransNx16Decode(data, 0)
+		} else {
+			got, err = rans4x8Decode(data)
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", test.name, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("unexpected result for %s: got:%q want:%q", test.name, got, test.want)
+		}
+	}
+}
+
+// cramEOF is the CRAM 3.0 EOF container given in the CRAM specification
+// and written by samtools.
+var cramEOF = []byte{
+	0x0f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+	0x0f, 0xe0, 0x45, 0x4f, 0x46, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00,
+	0x05, 0xbd, 0xd9, 0x4f,
+	0x00, 0x01, 0x00, 0x06, 0x06,
+	0x01, 0x00, 0x01, 0x00, 0x01, 0x00,
+	0xee, 0x63, 0x01, 0x4b,
+}
+
+func TestEOFContainer(t *testing.T) {
+	if got := testEOF(); !bytes.Equal(got, cramEOF) {
+		t.Errorf("unexpected EOF container:\ngot: %#v\nwant:%#v", got, cramEOF)
+	}
+
+	h, err := sam.NewHeader(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	data, _ := writeCRAM(t, h, nil, WriterOptions{})
+	if !bytes.HasSuffix(data, cramEOF) {
+		t.Errorf("written CRAM does not end with EOF container: %#v", data[len(data)-len(cramEOF):])
+	}
+	r, err := NewReader(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	_, err = r.Read()
+	if err != io.EOF {
+		t.Errorf("unexpected error reading EOF container: got:%v want:%v", err, io.EOF)
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	// Canonical Huffman codes for A:1, B:2, C:3, D:3 are
+	// A=0, B=10, C=110, D=111.
+	var p []byte
+	p = appendITF8Array(p, []int32{'D', 'C', 'B', 'A'})
+	p = appendITF8Array(p, []int32{3, 3, 2, 1})
+	huff := appendEncoding(nil, huffmanCodec, p)
+	beta := appendEncoding(nil, betaCodec, appendITF8(appendITF8(nil, 2), 4))
+	gamma := appendEncoding(nil, gammaCodec, appendITF8(nil, 1))
+	subexp := appendEncoding(nil, subexpCodec, appendITF8(appendITF8(nil, 0), 1))
+	single := appendEncoding(nil, huffmanCodec, append(appendITF8Array(nil, []int32{7}), appendITF8Array(nil, []int32{0})...))
+
+	for _, test := range []struct {
+		enc  []byte
+		core []byte
+		want []int32
+	}{
+		{enc: huff, core: []byte{0x5b, 0xc0}, want: []int32{'A', 'B', 'C', 'D', 'B'}}, // 0 10 110 111 10
+		{enc: beta, core: []byte{0x5f}, want: []int32{3, 13}},                         // 0101 1111
+		{enc: gamma, core: []byte{0xa2, 0x80}, want: []int32{0, 1, 4}},                // 1 010 00101
+		{enc: subexp, core: []byte{0x19, 0x90}, want: []int32{0, 1, 2, 5}},            // 00 01 100 11001
+		{enc: single, core: nil, want: []int32{7, 7, 7}},
+	} {
+		e, err := readEncoding(newCursor(test.enc))
+		if err != nil {
+			t.Errorf("unexpected error reading encoding: %v", err)
+			continue
+		}
+		s := &sliceData{core: bitReader{b: test.core}, ext: make(blocks)}
+		var got []int32
+		for range test.want {
+			got = append(got, e.readInt(s))
+		}
+		if err = s.error(); err != nil {
+			t.Errorf("unexpected error reading values with codec %d: %v", e.codec, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected values with codec %d: got:%v want:%v", e.codec, got, test.want)
+		}
+	}
+}
+
+// testRef is an in-memory ReferenceProvider.
+type testRef map[string]string
+
+func (r testRef) GetSequence(name string, beg, end int) ([]byte, error) {
+	s, ok := r[name]
+	if !ok {
+		return nil, fmt.Errorf("no sequence %q", name)
+	}
+	if end > len(s) {
+		end = len(s)
+	}
+	if beg > end {
+		beg = end
+	}
+	return []byte(s[beg:end]), nil
+}
+
+const (
+	testChr1 = "ACGTACGTACGTACGTACGTACGTACGTACGTACGTACGT"
+	testChr2 = "ttttggggccccaaaattttggggccccaaaa"
+
+	testHeader = "@HD\tVN:1.6\tSO:coordinate\n" +
+		"@SQ\tSN:chr1\tLN:40\n" +
+		"@SQ\tSN:chr2\tLN:32\n" +
+		"@RG\tID:grp\tSM:sample\n"
+)
+
+// testSAM is the expected SAM text of the records in the test CRAM data.
+var testSAM = []string{
+	"r1\t99\tchr1\t5\t30\t4M2I1M2D3M\t=\t20\t20\tACATGTATAC\t+,-./01234\tNM:i:3\tRG:Z:grp",
+	"r1\t147\tchr1\t20\t40\t5M\t=\t5\t-20\tTACGT\t56789",
+	"u1\t4\tchr1\t20\t0\t*\t*\t0\t0\tNNAC\t*",
+	"r2\t65\tchr1\t25\t10\t2S3M1N2M\tchr2\t3\t0\tGGACGAT\t*\tXZ:Z:ab",
+	"r3\t0\tchr2\t3\t20\t1H4M2P1I\t*\t0\t0\tTTGGA\t#####",
+}
+
+// testOptions control the construction of test CRAM data.
+type testOptions struct {
+	method      Method
+	embed       bool
+	noRR        bool
+	badMD5      bool
+	multiRef    bool
+	noReadNames bool
+}
+
+type testSeries struct {
+	ext map[int32]*bytes.Buffer
+}
+
+func (s *testSeries) buf(id int32) *bytes.Buffer {
+	b, ok := s.ext[id]
+	if !ok {
+		b = &bytes.Buffer{}
+		s.ext[id] = b
+	}
+	return b
+}
+
+func (s *testSeries) int(name string, v int) {
+	s.buf(seriesID(name)).Write(appendITF8(nil, int32(v)))
+}
+
+func (s *testSeries) byte(name string, v byte) {
+	s.buf(seriesID(name)).WriteByte(v)
+}
+
+func (s *testSeries) bytes(name string, v string) {
+	s.buf(seriesID(name)).WriteString(v)
+}
+
+func (s *testSeries) array(name string, v string) {
+	id := seriesID(name)
+	s.buf(id).Write(appendITF8(nil, int32(len(v))))
+	s.buf(id + 100).WriteString(v)
+}
+
+func (s *testSeries) tag(key int32, v string) {
+	s.buf(key).Write(appendITF8(nil, int32(len(v))))
+	s.buf(key + 1).WriteString(v)
+}
+
+const (
+	nmKey = 'N'<<16 | 'M'<<8 | 'c'
+	xzKey = 'X'<<16 | 'Z'<<8 | 'Z'
+)
+
+// testSlice writes the data series for the test records and returns the
+// slice header fields.
+func testSlice(s *testSeries, o testOptions, chr1 bool) (start, span, nrec int32) {
+	if chr1 {
+		if o.multiRef {
+			s.int("RI", 0)
+		}
+		// r1: 5 4M2I1M2D3M with a substitution, paired with the next record.
+		s.int("BF", 0x43)
+		s.int("CF", qualArrayFlag|mateDownFlag)
+		s.int("RL", 10)
+		if o.multiRef {
+			s.int("AP", 5)
+		} else {
+			s.int("AP", 0)
+		}
+		s.int("RG", 0)
+		if !o.noReadNames {
+			s.array("RN", "r1")
+		}
+		s.int("NF", 0)
+		s.int("TL", 0)
+		s.tag(nmKey, "\x03")
+		s.int("FN", 3)
+		s.byte("FC", 'X')
+		s.int("FP", 3)
+		s.int("BS", 0)
+		s.byte("FC", 'I')
+		s.int("FP", 2)
+		s.array("IN", "GT")
+		s.byte("FC", 'D')
+		s.int("FP", 3)
+		s.int("DL", 2)
+		s.int("MQ", 30)
+		s.bytes("QS", "\x0a\x0b\x0c\x0d\x0e\x0f\x10\x11\x12\x13")
+
+		// r1 mate: 20 5M.
+		if o.multiRef {
+			s.int("RI", 0)
+		}
+		s.int("BF", 0x93)
+		s.int("CF", qualArrayFlag)
+		s.int("RL", 5)
+		s.int("AP", 15)
+		s.int("RG", -1)
+		if !o.noReadNames {
+			s.array("RN", "r1")
+		}
+		s.int("TL", 1)
+		s.int("FN", 0)
+		s.int("MQ", 40)
+		s.bytes("QS", "\x14\x15\x16\x17\x18")
+
+		// u1: unmapped placed at 20.
+		if o.multiRef {
+			s.int("RI", 0)
+		}
+		s.int("BF", 0x4)
+		s.int("CF", 0)
+		s.int("RL", 4)
+		s.int("AP", 0)
+		s.int("RG", -1)
+		if !o.noReadNames {
+			s.array("RN", "u1")
+		}
+		s.int("TL", 1)
+		s.bytes("BA", "NNAC")
+
+		// r2: 25 2S3M1N2M with a detached mate on chr2.
+		if o.multiRef {
+			s.int("RI", 0)
+		}
+		s.int("BF", 0x41)
+		s.int("CF", detachedFlag)
+		s.int("RL", 7)
+		s.int("AP", 5)
+		s.int("RG", -1)
+		if !o.noReadNames {
+			s.array("RN", "r2")
+		}
+		s.int("MF", 0)
+		if o.noReadNames {
+			s.array("RN", "r2")
+		}
+		s.int("NS", 1)
+		s.int("NP", 3)
+		s.int("TS", 0)
+		s.int("TL", 2)
+		s.tag(xzKey, "ab\x00")
+		s.int("FN", 3)
+		s.byte("FC", 'S')
+		s.int("FP", 1)
+		s.array("SC", "GG")
+		s.byte("FC", 'N')
+		s.int("FP", 5)
+		s.int("RS", 1)
+		s.byte("FC", 'B')
+		s.int("FP", 1)
+		s.byte("BA", 'T')
+		s.byte("QS", 0xff)
+		s.int("MQ", 10)
+		start, span, nrec = 5, 30, 4
+		if !o.multiRef {
+			return start, span, nrec
+		}
+	}
+
+	// r3: chr2 3 1H4M2P1I with lower case reference.
+	if o.multiRef {
+		s.int("RI", 1)
+	}
+	s.int("BF", 0)
+	s.int("CF", 0)
+	s.int("RL", 5)
+	if o.multiRef {
+		s.int("AP", -22)
+	} else {
+		s.int("AP", 0)
+	}
+	s.int("RG", -1)
+	if !o.noReadNames {
+		s.array("RN", "r3")
+	}
+	s.int("TL", 1)
+	s.int("FN", 4)
+	s.byte("FC", 'H')
+	s.int("FP", 1)
+	s.int("HC", 1)
+	s.byte("FC", 'q')
+	s.int("FP", 0)
+	s.array("QQ", "\x02\x02\x02\x02\x02")
+	s.byte("FC", 'P')
+	s.int("FP", 4)
+	s.int("PD", 2)
+	s.byte("FC", 'i')
+	s.int("FP", 0)
+	s.byte("BA", 'A')
+	s.int("MQ", 20)
+	if o.multiRef {
+		return 0, 0, 5
+	}
+	return 3, 4, 1
+}
+
+// testCompressionHeader returns a compression header block for the test data.
+func testCompressionHeader(o testOptions) []byte {
+	var pm []byte
+	pm = append(pm, "RN"...)
+	pm = append(pm, b2i(!o.noReadNames))
+	pm = append(pm, "AP"...)
+	pm = append(pm, 1)
+	pm = append(pm, "RR"...)
+	pm = append(pm, b2i(!o.noRR))
+	pm = append(pm, "SM"...)
+	pm = append(pm, 0x1b, 0x1b, 0x1b, 0x1b, 0x1b)
+	td := []byte("NMc\x00\x00XZZ\x00")
+	pm = append(pm, "TD"...)
+	pm = appendITF8(pm, int32(len(td)))
+	pm = append(pm, td...)
+
+	var ds []byte
+	for _, n := range dataSeries {
+		ds = append(ds, n...)
+		id := seriesID(n)
+		if isArraySeries(n) {
+			ds = appendByteArrayLen(ds, id, id+100)
+		} else {
+			ds = appendEncoding(ds, externalCodec, appendITF8(nil, id))
+		}
+	}
+	var tm []byte
+	for _, k := range []int32{nmKey, xzKey} {
+		tm = appendITF8(tm, k)
+		tm = appendByteArrayLen(tm, k, k+1)
+	}
+
+	var ch []byte
+	for _, m := range []struct {
+		n    int
+		data []byte
+	}{{5, pm}, {len(dataSeries), ds}, {2, tm}} {
+		body := appendITF8(nil, int32(m.n))
+		body = append(body, m.data...)
+		ch = appendITF8(ch, int32(len(body)))
+		ch = append(ch, body...)
+	}
+	return ch
+}
+
+// buildTestCRAM returns CRAM data holding the test records and the
+// CRAM index entries describing them.
+func buildTestCRAM(t *testing.T, o testOptions) ([]byte, []IndexSlice) {
+	refs := testRef{"chr1": testChr1, "chr2": testChr2}
+
+	var buf []byte
+	buf = append(buf, magic...)
+	buf = append(buf, 3, 1)
+	buf = append(buf, make([]byte, 20)...)
+
+	hdr := binary.LittleEndian.AppendUint32(nil, uint32(len(testHeader)))
+	hdr = append(hdr, testHeader...)
+	hb := appendBlock(nil, Raw, fileHeaderContent, 0, hdr, hdr)
+	buf = appendContainer(buf, 0, 0, 0, 0, 0, 0, 1, []int32{0}, hb)
+
+	var idx []IndexSlice
+	type slice struct {
+		refID int32
+		chr1  bool
+		name  string
+	}
+	slices := []slice{{0, true, "chr1"}, {1, false, "chr2"}}
+	if o.multiRef {
+		slices = []slice{{multiRef, true, ""}}
+	}
+	var counter int64
+	for _, sl := range slices {
+		s := &testSeries{ext: make(map[int32]*bytes.Buffer)}
+		start, span, nrec := testSlice(s, o, sl.chr1)
+
+		var sliceBlocks []byte
+		ids := []int32{}
+		for id := range s.ext {
+			ids = append(ids, id)
+		}
+		sortInt32s(ids)
+		nblocks := 1 + len(ids)
+		sliceBlocks = appendBlock(sliceBlocks, Raw, coreContent, 0, nil, nil)
+		for _, id := range ids {
+			raw := s.ext[id].Bytes()
+			data, err := compress(o.method, raw)
+			if err != nil {
+				t.Fatalf("failed to compress block: %v", err)
+			}
+			sliceBlocks = appendBlock(sliceBlocks, o.method, externalContent, id, raw, data)
+		}
+		embedded := int32(-1)
+		var sum [16]byte
+		if sl.refID >= 0 {
+			ref, _ := refs.GetSequence(sl.name, int(start)-1, int(start+span)-1)
+			sum = md5.Sum(bytes.ToUpper(ref))
+			if o.badMD5 {
+				sum[0]++
+			}
+			if o.embed {
+				embedded = 1000
+				sliceBlocks = appendBlock(sliceBlocks, Raw, externalContent, embedded, ref, ref)
+				ids = append(ids, embedded)
+				nblocks++
+			}
+		}
+		contentIDs := append([]int32{0}, ids...)
+
+		var sh []byte
+		sh = appendITF8(sh, sl.refID)
+		sh = appendITF8(sh, start)
+		sh = appendITF8(sh, span)
+		sh = appendITF8(sh, nrec)
+		sh = appendLTF8(sh, counter)
+		sh = appendITF8(sh, int32(nblocks))
+		sh = appendITF8Array(sh, contentIDs)
+		sh = appendITF8(sh, embedded)
+		sh = append(sh, sum[:]...)
+
+		ch := testCompressionHeader(o)
+		data := appendBlock(nil, Raw, compressionHeaderContent, 0, ch, ch)
+		landmark := len(data)
+		data = appendBlock(data, Raw, sliceHeaderContent, 0, sh, sh)
+		data = append(data, sliceBlocks...)
+
+		idx = append(idx, IndexSlice{
+			RefID:     int(sl.refID),
+			Start:     int(start),
+			Span:      int(span),
+			Container: int64(len(buf)),
+			Slice:     int64(landmark),
+			Size:      int64(len(data) - landmark),
+		})
+		buf = appendContainer(buf, sl.refID, start, span, nrec, counter, 0, int32(1+nblocks+1), []int32{int32(landmark)}, data)
+		counter += int64(nrec)
+	}
+	return append(buf, testEOF()...), idx
+}
+
+// testEOF returns a CRAM EOF container.
+func testEOF() []byte {
+	ch := []byte{1, 0, 1, 0, 1, 0}
+	return appendContainer(nil, -1, eofStart, 0, 0, 0, 0, 1, nil, appendBlock(nil, Raw, compressionHeaderContent, 0, ch, ch))
+}
+
+func sortInt32s(s []int32) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j] < s[j-1]; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
+
+func readAll(r *Reader) ([]string, error) {
+	var got []string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return got, nil
+		}
+		if err != nil {
+			return got, err
+		}
+		b, err := rec.MarshalText()
+		if err != nil {
+			return got, err
+		}
+		got = append(got, string(b))
+	}
+}
+
+func TestReader(t *testing.T) {
+	refs := testRef{"chr1": testChr1, "chr2": testChr2}
+	for _, test := range []struct {
+		name string
+		opts testOptions
+		ref  ReferenceProvider
+		err  error
+	}{
+		{name: "raw", opts: testOptions{method: Raw}, ref: refs},
+		{name: "gzip", opts: testOptions{method: Gzip}, ref: refs},
+		{name: "rans4x8", opts: testOptions{method: Rans4x8}, ref: refs},
+		{name: "ransNx16", opts: testOptions{method: RansNx16}, ref: refs},
+		{name: "embedded", opts: testOptions{embed: true}},
+		{name: "multiref", opts: testOptions{multiRef: true}, ref: refs},
+		{name: "no read names", opts: testOptions{noReadNames: true}, ref: refs},
+		{name: "no reference", opts: testOptions{}, err: ErrNoReference},
+		{name: "bad md5", opts: testOptions{badMD5: true}, ref: refs, err: ErrReferenceMD5},
+	} {
+		data, _ := buildTestCRAM(t, test.opts)
+		r, err := NewReader(bytes.NewReader(data), test.ref)
+		if err != nil {
+			t.Fatalf("%s: unexpected error creating reader: %v", test.name, err)
+		}
+		if got := len(r.Header().Refs()); got != 2 {
+			t.Errorf("%s: unexpected number of references: got:%d want:2", test.name, got)
+		}
+		if r.Version() != (Version{3, 1}) {
+			t.Errorf("%s: unexpected version: %v", test.name, r.Version())
+		}
+		got, err := readAll(r)
+		if err != test.err {
+			t.Errorf("%s: unexpected error: got:%v want:%v", test.name, err, test.err)
+		}
+		if test.err != nil {
+			continue
+		}
+		want := testSAM
+		if test.opts.noReadNames {
+			want = append([]string(nil), testSAM...)
+			for i, name := range []string{"1", "1", "3", "r2", "5"} {
+				want[i] = name + want[i][2:]
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: unexpected records:\ngot: %q\nwant:%q", test.name, got, want)
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	refs := testRef{"chr1": testChr1, "chr2": testChr2}
+	data, _ := buildTestCRAM(t, testOptions{})
+
+	_, err := NewReader(bytes.NewReader([]byte("BAM\x01")), refs)
+	if err != ErrNotCRAM {
+		t.Errorf("unexpected error for non-CRAM data: got:%v want:%v", err, ErrNotCRAM)
+	}
+
+	v2 := append([]byte(nil), data...)
+	v2[4] = 2
+	_, err = NewReader(bytes.NewReader(v2), refs)
+	if err != (ErrUnsupportedVersion{2, 1}) {
+		t.Errorf("unexpected error for CRAM 2.1: got:%v", err)
+	}
+
+	// Corrupt the CRC of the last block of the last slice.
+	bad := append([]byte(nil), data...)
+	bad[len(bad)-len(testEOF())-1]++
+	r, err := NewReader(bytes.NewReader(bad), refs)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	_, err = readAll(r)
+	if err != ErrChecksum {
+		t.Errorf("unexpected error for corrupt data: got:%v want:%v", err, ErrChecksum)
+	}
+
+	if got := ErrUnsupportedMethod(LZMA).Error(); got != "cram: unsupported block compression method: lzma" {
+		t.Errorf("unexpected error message: %q", got)
+	}
+}
+
+func TestNoRRWithoutReference(t *testing.T) {
+	data, _ := buildTestCRAM(t, testOptions{noRR: true})
+	r, err := NewReader(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	got, err := readAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Bases matching the reference are unknown.
+	want := "r1\t99\tchr1\t5\t30\t4M2I1M2D3M\t=\t20\t20\tNNANGTNNNN\t+,-./01234\tNM:i:3\tRG:Z:grp"
+	if got[0] != want {
+		t.Errorf("unexpected first record:\ngot: %q\nwant:%q", got[0], want)
+	}
+}
+
+func TestIterator(t *testing.T) {
+	refs := testRef{"chr1": testChr1, "chr2": testChr2}
+	data, slices := buildTestCRAM(t, testOptions{})
+
+	var crai bytes.Buffer
+	w := gzip.NewWriter(&crai)
+	for _, s := range slices {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%d\n", s.RefID, s.Start, s.Span, s.Container, s.Slice, s.Size)
+	}
+	w.Close()
+	idx, err := ReadIndex(&crai)
+	if err != nil {
+		t.Fatalf("unexpected error reading index: %v", err)
+	}
+	if !reflect.DeepEqual(idx.Slices, slices) {
+		t.Fatalf("unexpected index:\ngot: %+v\nwant:%+v", idx.Slices, slices)
+	}
+
+	r, err := NewReader(bytes.NewReader(data), refs)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	hrefs := r.Header().Refs()
+	for _, test := range []struct {
+		ref      *sam.Reference
+		beg, end int
+		want     []string
+	}{
+		{ref: hrefs[0], beg: 0, end: 40, want: testSAM[:4]},
+		{ref: hrefs[0], beg: 0, end: 10, want: testSAM[:1]},
+		{ref: hrefs[0], beg: 13, end: 19, want: testSAM[:1]},
+		{ref: hrefs[0], beg: 14, end: 19, want: nil},
+		{ref: hrefs[0], beg: 19, end: 20, want: testSAM[1:3]},
+		{ref: hrefs[0], beg: 29, end: 40, want: testSAM[3:4]},
+		{ref: hrefs[0], beg: 30, end: 40, want: nil},
+		{ref: hrefs[1], beg: 0, end: 32, want: testSAM[4:]},
+		{ref: hrefs[1], beg: 10, end: 32, want: nil},
+	} {
+		it, err := NewIterator(r, idx, test.ref, test.beg, test.end)
+		if err != nil {
+			t.Fatalf("unexpected error creating iterator: %v", err)
+		}
+		var got []string
+		for it.Next() {
+			b, err := it.Record().MarshalText()
+			if err != nil {
+				t.Fatalf("unexpected error marshaling record: %v", err)
+			}
+			got = append(got, string(b))
+		}
+		err = it.Close()
+		if err != nil {
+			t.Errorf("unexpected error iterating over %s:%d-%d: %v", test.ref.Name(), test.beg, test.end, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected records for %s:%d-%d:\ngot: %q\nwant:%q", test.ref.Name(), test.beg, test.end, got, test.want)
+		}
+	}
+
+	r, err = NewReader(struct{ io.Reader }{bytes.NewReader(data)}, refs)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	_, err = NewIterator(r, idx, hrefs[0], 0, 10)
+	if err != ErrNotASeeker {
+		t.Errorf("unexpected error for non-seeker: got:%v want:%v", err, ErrNotASeeker)
+	}
+}
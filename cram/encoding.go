@@ -0,0 +1,324 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"sort"
+)
+
+// codecID is a CRAM data series encoding codec identifier.
+type codecID int32
+
+const (
+	nullCodec          codecID = 0
+	externalCodec      codecID = 1
+	golombCodec        codecID = 2
+	huffmanCodec       codecID = 3
+	byteArrayLenCodec  codecID = 4
+	byteArrayStopCodec codecID = 5
+	betaCodec          codecID = 6
+	subexpCodec        codecID = 7
+	golombRiceCodec    codecID = 8
+	gammaCodec         codecID = 9
+)
+
+// encoding is a data series encoding.
+type encoding struct {
+	codec codecID
+
+	// id is the external block content ID
+	// for EXTERNAL and BYTE_ARRAY_STOP.
+	id int32
+
+	// stop is the BYTE_ARRAY_STOP terminator.
+	stop byte
+
+	// huff is the HUFFMAN code table.
+	huff *huffman
+
+	// offset and k are the BETA, SUBEXP and
+	// GAMMA parameters; k holds the number
+	// of bits for BETA.
+	offset int32
+	k      int32
+
+	// lenEnc and valEnc are the BYTE_ARRAY_LEN
+	// length and value encodings.
+	lenEnc, valEnc *encoding
+}
+
+// readEncoding reads an encoding description from c.
+func readEncoding(c *cursor) (*encoding, error) {
+	id := codecID(c.itf8())
+	n := c.itf8()
+	p := newCursor(c.bytes(int(n)))
+	if c.err != nil {
+		return nil, c.err
+	}
+	e := &encoding{codec: id}
+	switch id {
+	case nullCodec:
+	case externalCodec:
+		e.id = p.itf8()
+	case huffmanCodec:
+		syms := p.itf8Array()
+		lens := p.itf8Array()
+		if p.err != nil {
+			return nil, p.err
+		}
+		var err error
+		e.huff, err = newHuffman(syms, lens)
+		if err != nil {
+			return nil, err
+		}
+	case byteArrayLenCodec:
+		var err error
+		e.lenEnc, err = readEncoding(p)
+		if err != nil {
+			return nil, err
+		}
+		e.valEnc, err = readEncoding(p)
+		if err != nil {
+			return nil, err
+		}
+	case byteArrayStopCodec:
+		e.stop = p.byte()
+		e.id = p.itf8()
+	case betaCodec:
+		e.offset = p.itf8()
+		e.k = p.itf8()
+		if e.k < 0 || e.k > 32 {
+			return nil, ErrInvalidEncoding
+		}
+	case subexpCodec:
+		e.offset = p.itf8()
+		e.k = p.itf8()
+		if e.k < 0 || e.k > 32 {
+			return nil, ErrInvalidEncoding
+		}
+	case gammaCodec:
+		e.offset = p.itf8()
+	default:
+		return nil, ErrInvalidEncoding
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return e, nil
+}
+
+// readInt reads an integer value from the slice data.
+func (e *encoding) readInt(s *sliceData) int32 {
+	if e == nil {
+		s.fail(ErrInvalidEncoding)
+		return 0
+	}
+	switch e.codec {
+	case externalCodec:
+		return s.external(e.id).itf8()
+	case huffmanCodec:
+		return e.huff.decode(&s.core)
+	case betaCodec:
+		return int32(s.core.bits(uint(e.k))) - e.offset
+	case subexpCodec:
+		var i uint
+		for s.core.bit() == 1 {
+			i++
+			if i > 32 {
+				s.fail(ErrCorrupt)
+				return 0
+			}
+		}
+		var v uint32
+		if i == 0 {
+			v = s.core.bits(uint(e.k))
+		} else {
+			b := i + uint(e.k) - 1
+			v = 1<<b | s.core.bits(b)
+		}
+		return int32(v) - e.offset
+	case gammaCodec:
+		var n uint
+		for s.core.bit() == 0 {
+			n++
+			if n > 32 {
+				s.fail(ErrCorrupt)
+				return 0
+			}
+		}
+		return int32(1<<n|s.core.bits(n)) - e.offset
+	}
+	s.fail(ErrInvalidEncoding)
+	return 0
+}
+
+// readByte reads a single byte value from the slice data.
+func (e *encoding) readByte(s *sliceData) byte {
+	if e != nil && e.codec == externalCodec {
+		return s.external(e.id).byte()
+	}
+	return byte(e.readInt(s))
+}
+
+// readArray reads a byte array value from the slice data.
+func (e *encoding) readArray(s *sliceData) []byte {
+	if e == nil {
+		s.fail(ErrInvalidEncoding)
+		return nil
+	}
+	switch e.codec {
+	case byteArrayLenCodec:
+		n := int(e.lenEnc.readInt(s))
+		if n < 0 {
+			s.fail(ErrCorrupt)
+			return nil
+		}
+		if e.valEnc.codec == externalCodec {
+			b := s.external(e.valEnc.id).bytes(n)
+			return append([]byte(nil), b...)
+		}
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = e.valEnc.readByte(s)
+		}
+		return b
+	case byteArrayStopCodec:
+		c := s.external(e.id)
+		if c.err != nil {
+			return nil
+		}
+		for i, v := range c.b[c.off:] {
+			if v == e.stop {
+				b := append([]byte(nil), c.b[c.off:c.off+i]...)
+				c.off += i + 1
+				return b
+			}
+		}
+		c.fail()
+		return nil
+	}
+	s.fail(ErrInvalidEncoding)
+	return nil
+}
+
+// bitReader reads bits from a byte slice, most significant bit first.
+type bitReader struct {
+	b   []byte
+	off int
+	pos uint
+	err error
+}
+
+func (r *bitReader) bit() uint32 { return r.bits(1) }
+
+func (r *bitReader) bits(n uint) uint32 {
+	var v uint32
+	for ; n > 0; n-- {
+		if r.off >= len(r.b) {
+			if r.err == nil {
+				r.err = ErrCorrupt
+			}
+			return 0
+		}
+		v = v<<1 | uint32(r.b[r.off]>>(7-r.pos))&1
+		r.pos++
+		if r.pos == 8 {
+			r.pos = 0
+			r.off++
+		}
+	}
+	return v
+}
+
+// huffman is a canonical Huffman code.
+type huffman struct {
+	// single holds the value of a code
+	// with a single zero length symbol.
+	single *int32
+
+	codes []huffmanCode
+}
+
+type huffmanCode struct {
+	len  uint
+	code uint32
+	sym  int32
+}
+
+func newHuffman(syms, lens []int32) (*huffman, error) {
+	if len(syms) != len(lens) || len(syms) == 0 {
+		return nil, ErrInvalidEncoding
+	}
+	if len(syms) == 1 && lens[0] == 0 {
+		return &huffman{single: &syms[0]}, nil
+	}
+	codes := make([]huffmanCode, len(syms))
+	for i, s := range syms {
+		if lens[i] < 0 || lens[i] > 31 {
+			return nil, ErrInvalidEncoding
+		}
+		codes[i] = huffmanCode{len: uint(lens[i]), sym: s}
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if codes[i].len != codes[j].len {
+			return codes[i].len < codes[j].len
+		}
+		return codes[i].sym < codes[j].sym
+	})
+	var (
+		code uint32
+		prev = codes[0].len
+	)
+	for i := range codes {
+		code <<= codes[i].len - prev
+		codes[i].code = code
+		prev = codes[i].len
+		code++
+	}
+	return &huffman{codes: codes}, nil
+}
+
+func (h *huffman) decode(r *bitReader) int32 {
+	if h.single != nil {
+		return *h.single
+	}
+	var (
+		code uint32
+		n    uint
+	)
+	for _, c := range h.codes {
+		for n < c.len {
+			code = code<<1 | r.bit()
+			n++
+		}
+		if r.err != nil {
+			return 0
+		}
+		if c.code == code {
+			return c.sym
+		}
+	}
+	if r.err == nil {
+		r.err = ErrCorrupt
+	}
+	return 0
+}
+
+// appendEncoding appends an encoding with the given codec and
+// parameters to b.
+func appendEncoding(b []byte, id codecID, params []byte) []byte {
+	b = appendITF8(b, int32(id))
+	b = appendITF8(b, int32(len(params)))
+	return append(b, params...)
+}
+
+// appendByteArrayLen appends a BYTE_ARRAY_LEN encoding with external
+// length and value blocks to b.
+func appendByteArrayLen(b []byte, lenID, valID int32) []byte {
+	var p []byte
+	p = appendEncoding(p, externalCodec, appendITF8(nil, lenID))
+	p = appendEncoding(p, externalCodec, appendITF8(nil, valID))
+	return appendEncoding(b, byteArrayLenCodec, p)
+}
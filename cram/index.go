@@ -0,0 +1,256 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// Index is a CRAM .crai index.
+type Index struct {
+	Slices []IndexSlice
+}
+
+// IndexSlice is a CRAM index record describing a single slice.
+type IndexSlice struct {
+	// RefID is the reference ID of the slice,
+	// or -1 for unmapped records.
+	RefID int
+
+	// Start is the one-based alignment start
+	// of the slice and Span is its length.
+	Start, Span int
+
+	// Container is the file offset of the
+	// container holding the slice.
+	Container int64
+
+	// Slice is the offset of the slice from the
+	// end of the container header and Size is
+	// the size of the slice in bytes.
+	Slice int64
+	Size  int64
+}
+
+// ReadIndex reads a gzip compressed CRAM index from r.
+func ReadIndex(r io.Reader) (*Index, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	var idx Index
+	sc := bufio.NewScanner(gz)
+	for line := 1; sc.Scan(); line++ {
+		f := bytes.Fields(sc.Bytes())
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) != 6 {
+			return nil, fmt.Errorf("cram: invalid index line %d", line)
+		}
+		var v [6]int64
+		for i := range v {
+			v[i], err = strconv.ParseInt(string(f[i]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cram: invalid index line %d: %v", line, err)
+			}
+		}
+		idx.Slices = append(idx.Slices, IndexSlice{
+			RefID:     int(v[0]),
+			Start:     int(v[1]),
+			Span:      int(v[2]),
+			Container: v[3],
+			Slice:     v[4],
+			Size:      v[5],
+		})
+	}
+	err = sc.Err()
+	if err != nil {
+		return nil, err
+	}
+	return &idx, nil
+}
+
+// WriteIndex writes idx to w as a gzip compressed CRAM index.
+func WriteIndex(w io.Writer, idx *Index) error {
+	gz := gzip.NewWriter(w)
+	bw := bufio.NewWriter(gz)
+	for _, e := range idx.Slices {
+		_, err := fmt.Fprintf(bw, "%d\t%d\t%d\t%d\t%d\t%d\n", e.RefID, e.Start, e.Span, e.Container, e.Slice, e.Size)
+		if err != nil {
+			return err
+		}
+	}
+	err := bw.Flush()
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Overlapping returns the index slices that may hold records from
+// the reference with the given ID in the zero-based half-open interval
+// [beg,end). The slices are returned in file order.
+func (idx *Index) Overlapping(refID, beg, end int) []IndexSlice {
+	var s []IndexSlice
+	for _, e := range idx.Slices {
+		if e.RefID != refID && e.RefID != multiRef {
+			continue
+		}
+		if refID >= 0 && e.RefID >= 0 && (e.Start-1 >= end || e.Start-1+e.Span <= beg) {
+			continue
+		}
+		s = append(s, e)
+	}
+	sort.SliceStable(s, func(i, j int) bool {
+		if s[i].Container != s[j].Container {
+			return s[i].Container < s[j].Container
+		}
+		return s[i].Slice < s[j].Slice
+	})
+	return s
+}
+
+// Iterator wraps a Reader to provide a convenient loop interface for
+// reading the CRAM records that overlap a region. Successive calls to
+// the Next method will step through the records of the region.
+// Iteration stops unrecoverably at the end of the region or the first
+// error.
+type Iterator struct {
+	r  *Reader
+	rs io.ReadSeeker
+
+	ref      *sam.Reference
+	beg, end int
+
+	slices []IndexSlice
+
+	// ch and data are the compression
+	// header and data of the container
+	// at offset off.
+	ch   *compressionHeader
+	data []byte
+	off  int64
+
+	recs []*sam.Record
+	rec  *sam.Record
+	err  error
+}
+
+// NewIterator returns an Iterator to read the records of r overlapping
+// the zero-based half-open interval [beg,end) of ref, using the slices
+// described by idx. If ref is nil, unmapped records without a position
+// are returned. The io.Reader underlying r must be an io.ReadSeeker.
+func NewIterator(r *Reader, idx *Index, ref *sam.Reference, beg, end int) (*Iterator, error) {
+	rs, ok := r.r.(io.ReadSeeker)
+	if !ok {
+		return nil, ErrNotASeeker
+	}
+	id := -1
+	if ref != nil {
+		id = ref.ID()
+	}
+	return &Iterator{
+		r:      r,
+		rs:     rs,
+		ref:    ref,
+		beg:    beg,
+		end:    end,
+		slices: idx.Overlapping(id, beg, end),
+		off:    -1,
+	}, nil
+}
+
+// Next advances the Iterator past the next record, which will then be
+// available through the Record method. It returns false when the
+// iteration stops, either by reaching the end of the region or an
+// error. After Next returns false, the Error method will return any
+// error that occurred during iteration.
+func (i *Iterator) Next() bool {
+	for i.err == nil {
+		for len(i.recs) != 0 {
+			rec := i.recs[0]
+			i.recs = i.recs[1:]
+			if i.overlaps(rec) {
+				i.rec = rec
+				return true
+			}
+		}
+		if len(i.slices) == 0 {
+			i.err = io.EOF
+			break
+		}
+		i.recs, i.err = i.readSlice(i.slices[0])
+		i.slices = i.slices[1:]
+	}
+	i.rec = nil
+	return false
+}
+
+// overlaps returns whether rec is in the region of the iterator.
+func (i *Iterator) overlaps(rec *sam.Record) bool {
+	if i.ref == nil {
+		return rec.Ref == nil
+	}
+	if rec.Ref != i.ref {
+		return false
+	}
+	if rec.Flags&sam.Unmapped != 0 {
+		return i.beg <= rec.Pos && rec.Pos < i.end
+	}
+	return rec.Pos < i.end && rec.End() > i.beg
+}
+
+// readSlice reads the records of the indexed slice e.
+func (i *Iterator) readSlice(e IndexSlice) ([]*sam.Record, error) {
+	if i.off != e.Container {
+		_, err := i.rs.Seek(e.Container, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		_, data, err := readContainer(i.rs)
+		if err != nil {
+			if err == io.EOF {
+				err = ErrCorrupt
+			}
+			return nil, err
+		}
+		i.ch, err = readContainerCompressionHeader(data)
+		if err != nil {
+			return nil, err
+		}
+		i.off = e.Container
+		i.data = data
+	}
+	return i.r.readSlice(i.ch, i.data, int(e.Slice))
+}
+
+// Error returns the first non-EOF error that was encountered by the
+// Iterator.
+func (i *Iterator) Error() error {
+	if i.err == io.EOF {
+		return nil
+	}
+	return i.err
+}
+
+// Record returns the most recent record read by a call to Next.
+func (i *Iterator) Record() *sam.Record { return i.rec }
+
+// Close releases the Iterator's resources.
+func (i *Iterator) Close() error {
+	i.recs = nil
+	i.data = nil
+	return i.Error()
+}
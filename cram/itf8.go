@@ -0,0 +1,253 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// cursor is a reader of CRAM primitive values from a byte slice.
+// The first error encountered is retained and all subsequent reads
+// return zero values.
+type cursor struct {
+	b   []byte
+	off int
+	err error
+}
+
+func newCursor(b []byte) *cursor { return &cursor{b: b} }
+
+func (c *cursor) fail() {
+	if c.err == nil {
+		c.err = ErrCorrupt
+	}
+}
+
+func (c *cursor) len() int { return len(c.b) - c.off }
+
+func (c *cursor) byte() byte {
+	if c.err != nil || c.off >= len(c.b) {
+		c.fail()
+		return 0
+	}
+	v := c.b[c.off]
+	c.off++
+	return v
+}
+
+func (c *cursor) bytes(n int) []byte {
+	if c.err != nil || n < 0 || c.off+n > len(c.b) {
+		c.fail()
+		return nil
+	}
+	v := c.b[c.off : c.off+n : c.off+n]
+	c.off += n
+	return v
+}
+
+func (c *cursor) uint32() uint32 {
+	b := c.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (c *cursor) int32() int32 { return int32(c.uint32()) }
+
+// itf8 reads an ITF8 encoded integer.
+func (c *cursor) itf8() int32 {
+	if c.err != nil || c.off >= len(c.b) {
+		c.fail()
+		return 0
+	}
+	v, n := itf8(c.b[c.off:])
+	if n == 0 {
+		c.fail()
+		return 0
+	}
+	c.off += n
+	return v
+}
+
+// ltf8 reads an LTF8 encoded integer.
+func (c *cursor) ltf8() int64 {
+	if c.err != nil || c.off >= len(c.b) {
+		c.fail()
+		return 0
+	}
+	v, n := ltf8(c.b[c.off:])
+	if n == 0 {
+		c.fail()
+		return 0
+	}
+	c.off += n
+	return v
+}
+
+// itf8Array reads an ITF8 length-prefixed array of ITF8 integers.
+func (c *cursor) itf8Array() []int32 {
+	n := c.itf8()
+	if n < 0 || int(n) > c.len() {
+		c.fail()
+		return nil
+	}
+	v := make([]int32, n)
+	for i := range v {
+		v[i] = c.itf8()
+	}
+	return v
+}
+
+// itf8 decodes the ITF8 encoded integer at the start of b, returning
+// the value and the number of bytes used. If b is too short, n is zero.
+func itf8(b []byte) (v int32, n int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	b0 := uint32(b[0])
+	switch {
+	case b0 < 0x80:
+		return int32(b0), 1
+	case b0 < 0xc0:
+		if len(b) < 2 {
+			return 0, 0
+		}
+		return int32((b0&0x3f)<<8 | uint32(b[1])), 2
+	case b0 < 0xe0:
+		if len(b) < 3 {
+			return 0, 0
+		}
+		return int32((b0&0x1f)<<16 | uint32(b[1])<<8 | uint32(b[2])), 3
+	case b0 < 0xf0:
+		if len(b) < 4 {
+			return 0, 0
+		}
+		return int32((b0&0x0f)<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])), 4
+	default:
+		if len(b) < 5 {
+			return 0, 0
+		}
+		return int32((b0&0x0f)<<28 | uint32(b[1])<<20 | uint32(b[2])<<12 | uint32(b[3])<<4 | uint32(b[4])&0x0f), 5
+	}
+}
+
+// ltf8 decodes the LTF8 encoded integer at the start of b, returning
+// the value and the number of bytes used. If b is too short, n is zero.
+func ltf8(b []byte) (v int64, n int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	b0 := b[0]
+	// The number of leading set bits gives the
+	// number of following bytes.
+	n = 1
+	for mask := byte(0x80); n < 9 && b0&mask != 0; mask >>= 1 {
+		n++
+	}
+	if len(b) < n {
+		return 0, 0
+	}
+	var u uint64
+	if n < 8 {
+		u = uint64(b0 & (0xff >> uint(n)))
+	}
+	for _, c := range b[1:n] {
+		u = u<<8 | uint64(c)
+	}
+	return int64(u), n
+}
+
+// readITF8 reads an ITF8 encoded integer from r, appending the bytes
+// read to buf.
+func readITF8(r io.Reader, buf *[]byte) (int32, error) {
+	var b [5]byte
+	_, err := io.ReadFull(r, b[:1])
+	if err != nil {
+		return 0, err
+	}
+	n := 1
+	for mask := byte(0x80); n < 5 && b[0]&mask != 0; mask >>= 1 {
+		n++
+	}
+	_, err = io.ReadFull(r, b[1:n])
+	if err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	*buf = append(*buf, b[:n]...)
+	v, _ := itf8(b[:n])
+	return v, nil
+}
+
+// readLTF8 reads an LTF8 encoded integer from r, appending the bytes
+// read to buf.
+func readLTF8(r io.Reader, buf *[]byte) (int64, error) {
+	var b [9]byte
+	_, err := io.ReadFull(r, b[:1])
+	if err != nil {
+		return 0, err
+	}
+	n := 1
+	for mask := byte(0x80); n < 9 && b[0]&mask != 0; mask >>= 1 {
+		n++
+	}
+	_, err = io.ReadFull(r, b[1:n])
+	if err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	*buf = append(*buf, b[:n]...)
+	v, _ := ltf8(b[:n])
+	return v, nil
+}
+
+// appendITF8 appends the ITF8 encoding of v to b.
+func appendITF8(b []byte, v int32) []byte {
+	u := uint32(v)
+	switch {
+	case u < 1<<7:
+		return append(b, byte(u))
+	case u < 1<<14:
+		return append(b, byte(u>>8)|0x80, byte(u))
+	case u < 1<<21:
+		return append(b, byte(u>>16)|0xc0, byte(u>>8), byte(u))
+	case u < 1<<28:
+		return append(b, byte(u>>24)|0xe0, byte(u>>16), byte(u>>8), byte(u))
+	default:
+		return append(b, byte(u>>28)|0xf0, byte(u>>20), byte(u>>12), byte(u>>4), byte(u)&0x0f)
+	}
+}
+
+// appendLTF8 appends the LTF8 encoding of v to b.
+func appendLTF8(b []byte, v int64) []byte {
+	u := uint64(v)
+	n := 0
+	for n < 8 && u >= 1<<(7*uint(n+1)) {
+		n++
+	}
+	if n == 8 {
+		b = append(b, 0xff)
+		for i := 7; i >= 0; i-- {
+			b = append(b, byte(u>>(8*uint(i))))
+		}
+		return b
+	}
+	prefix := byte(0xff << uint(8-n))
+	b = append(b, prefix|byte(u>>(8*uint(n))))
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(u>>(8*uint(i))))
+	}
+	return b
+}
+
+// appendITF8Array appends the length of v and its ITF8 encoded
+// elements to b.
+func appendITF8Array(b []byte, v []int32) []byte {
+	b = appendITF8(b, int32(len(v)))
+	for _, e := range v {
+		b = appendITF8(b, e)
+	}
+	return b
+}
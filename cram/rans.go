@@ -0,0 +1,719 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import "encoding/binary"
+
+// rANS 4x8 constants.
+const (
+	ransShift = 12
+	ransTotal = 1 << ransShift
+	ransLower = 1 << 23
+)
+
+// ransTable is a frequency table for a single rANS context.
+type ransTable struct {
+	freq [256]uint32
+	cum  [256]uint32
+	sym  []byte
+}
+
+// build fills the cumulative frequencies and the symbol lookup
+// of t for a total frequency of 1<<shift.
+func (t *ransTable) build(shift uint) bool {
+	total := uint32(1) << shift
+	t.sym = make([]byte, total)
+	var x uint32
+	for s, f := range t.freq {
+		if f == 0 {
+			continue
+		}
+		if x+f > total {
+			return false
+		}
+		t.cum[s] = x
+		for i := x; i < x+f; i++ {
+			t.sym[i] = byte(s)
+		}
+		x += f
+	}
+	return true
+}
+
+// decode returns the symbol encoded in the state x and the
+// advanced state.
+func (t *ransTable) decode(x uint32, shift uint) (byte, uint32) {
+	m := x & (1<<shift - 1)
+	s := t.sym[m]
+	return s, t.freq[s]*(x>>shift) + m - t.cum[s]
+}
+
+// ransReader is a source of rANS renormalisation data.
+type ransReader struct {
+	b   []byte
+	off int
+}
+
+// renorm8 renormalises the 4x8 state x.
+func (r *ransReader) renorm8(x uint32) uint32 {
+	for x < ransLower && r.off < len(r.b) {
+		x = x<<8 | uint32(r.b[r.off])
+		r.off++
+	}
+	return x
+}
+
+// renorm16 renormalises the Nx16 state x.
+func (r *ransReader) renorm16(x uint32) uint32 {
+	for x < 1<<15 && r.off+2 <= len(r.b) {
+		x = x<<16 | uint32(binary.LittleEndian.Uint16(r.b[r.off:]))
+		r.off += 2
+	}
+	return x
+}
+
+// states reads n initial rANS states.
+func (r *ransReader) states(n int) ([]uint32, bool) {
+	if r.off+4*n > len(r.b) {
+		return nil, false
+	}
+	s := make([]uint32, n)
+	for i := range s {
+		s[i] = binary.LittleEndian.Uint32(r.b[r.off:])
+		r.off += 4
+	}
+	return s, true
+}
+
+// rans4x8Decode decodes CRAM rANS 4x8 compressed data.
+func rans4x8Decode(data []byte) ([]byte, error) {
+	if len(data) < 9 {
+		return nil, ErrCorrupt
+	}
+	order := data[0]
+	size := binary.LittleEndian.Uint32(data[1:])
+	n := binary.LittleEndian.Uint32(data[5:])
+	if int64(size) != int64(len(data)-9) {
+		return nil, ErrCorrupt
+	}
+	out := make([]byte, n)
+	if n == 0 {
+		return out, nil
+	}
+	r := &ransReader{b: data[9:]}
+	var ok bool
+	switch order {
+	case 0:
+		ok = rans4x8Order0(r, out)
+	case 1:
+		ok = rans4x8Order1(r, out)
+	}
+	if !ok {
+		return nil, ErrCorrupt
+	}
+	return out, nil
+}
+
+// read4x8Freqs reads a 4x8 run-length encoded frequency table into t.
+func read4x8Freqs(r *ransReader, t *ransTable) bool {
+	next := func() (byte, bool) {
+		if r.off >= len(r.b) {
+			return 0, false
+		}
+		b := r.b[r.off]
+		r.off++
+		return b, true
+	}
+	peek := func() int {
+		if r.off >= len(r.b) {
+			return -1
+		}
+		return int(r.b[r.off])
+	}
+	b, ok := next()
+	if !ok {
+		return false
+	}
+	sym := int(b)
+	rle := 0
+	for {
+		f, ok := next()
+		if !ok {
+			return false
+		}
+		freq := uint32(f)
+		if f >= 0x80 {
+			lo, ok := next()
+			if !ok {
+				return false
+			}
+			freq = uint32(f&0x7f)<<8 | uint32(lo)
+		}
+		t.freq[sym] = freq
+		switch {
+		case rle == 0 && sym+1 == peek():
+			sym = int(r.b[r.off])
+			r.off++
+			b, ok = next()
+			if !ok {
+				return false
+			}
+			rle = int(b)
+		case rle != 0:
+			rle--
+			sym++
+			if sym > 255 {
+				return false
+			}
+		default:
+			b, ok = next()
+			if !ok {
+				return false
+			}
+			sym = int(b)
+		}
+		if sym == 0 {
+			return true
+		}
+	}
+}
+
+func rans4x8Order0(r *ransReader, out []byte) bool {
+	var t ransTable
+	if !read4x8Freqs(r, &t) || !t.build(ransShift) {
+		return false
+	}
+	x, ok := r.states(4)
+	if !ok {
+		return false
+	}
+	for i := range out {
+		j := i & 3
+		out[i], x[j] = t.decode(x[j], ransShift)
+		x[j] = r.renorm8(x[j])
+	}
+	return true
+}
+
+func rans4x8Order1(r *ransReader, out []byte) bool {
+	var t [256]*ransTable
+	if r.off >= len(r.b) {
+		return false
+	}
+	ctx := int(r.b[r.off])
+	r.off++
+	rle := 0
+	for {
+		t[ctx] = &ransTable{}
+		if !read4x8Freqs(r, t[ctx]) || !t[ctx].build(ransShift) {
+			return false
+		}
+		switch {
+		case rle == 0 && r.off < len(r.b) && ctx+1 == int(r.b[r.off]):
+			if r.off+2 > len(r.b) {
+				return false
+			}
+			ctx = int(r.b[r.off])
+			rle = int(r.b[r.off+1])
+			r.off += 2
+		case rle != 0:
+			rle--
+			ctx++
+			if ctx > 255 {
+				return false
+			}
+		default:
+			if r.off >= len(r.b) {
+				return false
+			}
+			ctx = int(r.b[r.off])
+			r.off++
+		}
+		if ctx == 0 {
+			break
+		}
+	}
+	x, ok := r.states(4)
+	if !ok {
+		return false
+	}
+	return ransOrder1(r, out, x, t[:], ransShift, r.renorm8)
+}
+
+// ransOrder1 decodes order-1 rANS data into out using the states in x.
+// Each state decodes a contiguous section of out, with the final state
+// also decoding any remainder.
+func ransOrder1(r *ransReader, out []byte, x []uint32, t []*ransTable, shift uint, renorm func(uint32) uint32) bool {
+	n := len(x)
+	seg := len(out) / n
+	ctx := make([]byte, n)
+	for i := 0; i < seg; i++ {
+		for j := range x {
+			tab := t[ctx[j]]
+			if tab == nil {
+				return false
+			}
+			var s byte
+			s, x[j] = tab.decode(x[j], shift)
+			x[j] = renorm(x[j])
+			out[j*seg+i] = s
+			ctx[j] = s
+		}
+	}
+	last := n - 1
+	for i := n * seg; i < len(out); i++ {
+		tab := t[ctx[last]]
+		if tab == nil {
+			return false
+		}
+		var s byte
+		s, x[last] = tab.decode(x[last], shift)
+		x[last] = renorm(x[last])
+		out[i] = s
+		ctx[last] = s
+	}
+	return true
+}
+
+// rANS Nx16 flags.
+const (
+	ransOrder1Flag = 0x01
+	ransX32Flag    = 0x04
+	ransStripeFlag = 0x08
+	ransNoSizeFlag = 0x10
+	ransCatFlag    = 0x20
+	ransRLEFlag    = 0x40
+	ransPackFlag   = 0x80
+)
+
+// uint7 reads a CRAM 3.1 variable length integer.
+func (r *ransReader) uint7() (uint32, bool) {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		if r.off >= len(r.b) {
+			return 0, false
+		}
+		c := r.b[r.off]
+		r.off++
+		v = v<<7 | uint32(c&0x7f)
+		if c&0x80 == 0 {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// ransNx16Decode decodes CRAM rANS Nx16 compressed data. The size
+// parameter is used only when the data does not record its own size.
+func ransNx16Decode(data []byte, size int) ([]byte, error) {
+	out, ok := ransNx16(data, size)
+	if !ok {
+		return nil, ErrCorrupt
+	}
+	return out, nil
+}
+
+func ransNx16(data []byte, size int) ([]byte, bool) {
+	r := &ransReader{b: data}
+	if len(data) == 0 {
+		return nil, false
+	}
+	flags := data[0]
+	r.off++
+	if flags&ransNoSizeFlag == 0 {
+		n, ok := r.uint7()
+		if !ok {
+			return nil, false
+		}
+		size = int(n)
+	}
+	if flags&ransStripeFlag != 0 {
+		return ransNx16Unstripe(r, size)
+	}
+
+	var (
+		pack    []byte
+		packLen = size
+	)
+	if flags&ransPackFlag != 0 {
+		if r.off >= len(r.b) {
+			return nil, false
+		}
+		n := int(r.b[r.off])
+		r.off++
+		if n == 0 {
+			n = 256
+		}
+		if r.off+n > len(r.b) {
+			return nil, false
+		}
+		pack = r.b[r.off : r.off+n]
+		r.off += n
+		l, ok := r.uint7()
+		if !ok {
+			return nil, false
+		}
+		packLen = int(l)
+	}
+
+	var (
+		meta   *ransReader
+		rleLen = packLen
+	)
+	if flags&ransRLEFlag != 0 {
+		metaLen, ok := r.uint7()
+		if !ok {
+			return nil, false
+		}
+		l, ok := r.uint7()
+		if !ok {
+			return nil, false
+		}
+		rleLen = int(l)
+		if metaLen&1 != 0 {
+			n := int(metaLen / 2)
+			if r.off+n > len(r.b) {
+				return nil, false
+			}
+			meta = &ransReader{b: r.b[r.off : r.off+n]}
+			r.off += n
+		} else {
+			c, ok := r.uint7()
+			if !ok || r.off+int(c) > len(r.b) {
+				return nil, false
+			}
+			m, ok := ransNx16Order0(&ransReader{b: r.b[r.off : r.off+int(c)]}, int(metaLen/2), 4)
+			if !ok {
+				return nil, false
+			}
+			meta = &ransReader{b: m}
+			r.off += int(c)
+		}
+	}
+
+	var out []byte
+	switch {
+	case r.off == len(r.b):
+	case flags&ransCatFlag != 0:
+		if r.off+rleLen > len(r.b) {
+			return nil, false
+		}
+		out = r.b[r.off : r.off+rleLen]
+	default:
+		n := 4
+		if flags&ransX32Flag != 0 {
+			n = 32
+		}
+		var ok bool
+		if flags&ransOrder1Flag != 0 {
+			out, ok = ransNx16Order1(r, rleLen, n)
+		} else {
+			out, ok = ransNx16Order0(r, rleLen, n)
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+
+	if meta != nil {
+		var ok bool
+		out, ok = unRLE(out, meta, packLen)
+		if !ok {
+			return nil, false
+		}
+	}
+	if pack != nil {
+		var ok bool
+		out, ok = unpack(out, pack, size)
+		if !ok {
+			return nil, false
+		}
+	}
+	if len(out) != size {
+		return nil, false
+	}
+	return out, true
+}
+
+// ransNx16Unstripe decodes striped rANS Nx16 data.
+func ransNx16Unstripe(r *ransReader, size int) ([]byte, bool) {
+	if r.off >= len(r.b) {
+		return nil, false
+	}
+	n := int(r.b[r.off])
+	r.off++
+	if n == 0 {
+		return nil, false
+	}
+	clen := make([]int, n)
+	for i := range clen {
+		c, ok := r.uint7()
+		if !ok {
+			return nil, false
+		}
+		clen[i] = int(c)
+	}
+	out := make([]byte, size)
+	for i := 0; i < n; i++ {
+		l := size / n
+		if i < size%n {
+			l++
+		}
+		if r.off+clen[i] > len(r.b) {
+			return nil, false
+		}
+		s, ok := ransNx16(r.b[r.off:r.off+clen[i]], l)
+		if !ok || len(s) != l {
+			return nil, false
+		}
+		r.off += clen[i]
+		for j, c := range s {
+			out[j*n+i] = c
+		}
+	}
+	return out, true
+}
+
+// readAlphabet reads a run-length encoded symbol alphabet.
+func readAlphabet(r *ransReader) ([256]bool, bool) {
+	var a [256]bool
+	if r.off >= len(r.b) {
+		return a, false
+	}
+	sym := int(r.b[r.off])
+	r.off++
+	rle := 0
+	for {
+		a[sym] = true
+		switch {
+		case rle == 0 && r.off < len(r.b) && sym+1 == int(r.b[r.off]):
+			if r.off+2 > len(r.b) {
+				return a, false
+			}
+			sym = int(r.b[r.off])
+			rle = int(r.b[r.off+1])
+			r.off += 2
+		case rle != 0:
+			rle--
+			sym++
+			if sym > 255 {
+				return a, false
+			}
+		default:
+			if r.off >= len(r.b) {
+				return a, false
+			}
+			sym = int(r.b[r.off])
+			r.off++
+		}
+		if sym == 0 {
+			return a, true
+		}
+	}
+}
+
+// normalise scales the frequencies in t by a power of two
+// so that they sum to total.
+func normalise(t *ransTable, sum, total uint32) bool {
+	if sum == 0 || sum > total {
+		return false
+	}
+	var shift uint
+	for sum < total {
+		sum *= 2
+		shift++
+	}
+	for i := range t.freq {
+		t.freq[i] <<= shift
+	}
+	return sum == total
+}
+
+func ransNx16Order0(r *ransReader, size, n int) ([]byte, bool) {
+	a, ok := readAlphabet(r)
+	if !ok {
+		return nil, false
+	}
+	var (
+		t   ransTable
+		sum uint32
+	)
+	for s, ok := range a {
+		if !ok {
+			continue
+		}
+		f, ok := r.uint7()
+		if !ok {
+			return nil, false
+		}
+		t.freq[s] = f
+		sum += f
+	}
+	if !normalise(&t, sum, ransTotal) || !t.build(ransShift) {
+		return nil, false
+	}
+	x, ok := r.states(n)
+	if !ok {
+		return nil, false
+	}
+	out := make([]byte, size)
+	for i := range out {
+		j := i % n
+		out[i], x[j] = t.decode(x[j], ransShift)
+		x[j] = r.renorm16(x[j])
+	}
+	return out, true
+}
+
+func ransNx16Order1(r *ransReader, size, n int) ([]byte, bool) {
+	if r.off >= len(r.b) {
+		return nil, false
+	}
+	c := r.b[r.off]
+	r.off++
+	shift := uint(c >> 4)
+	tr := r
+	if c&1 != 0 {
+		u, ok := r.uint7()
+		if !ok {
+			return nil, false
+		}
+		l, ok := r.uint7()
+		if !ok || r.off+int(l) > len(r.b) {
+			return nil, false
+		}
+		tab, ok := ransNx16Order0(&ransReader{b: r.b[r.off : r.off+int(l)]}, int(u), 4)
+		if !ok {
+			return nil, false
+		}
+		r.off += int(l)
+		tr = &ransReader{b: tab}
+	}
+
+	a, ok := readAlphabet(tr)
+	if !ok {
+		return nil, false
+	}
+	var t [256]*ransTable
+	for i, ok := range a {
+		if !ok {
+			continue
+		}
+		tab := &ransTable{}
+		var (
+			sum uint32
+			run int
+		)
+		for j, ok := range a {
+			if !ok {
+				continue
+			}
+			if run > 0 {
+				run--
+				continue
+			}
+			f, ok := tr.uint7()
+			if !ok {
+				return nil, false
+			}
+			tab.freq[j] = f
+			sum += f
+			if f == 0 {
+				if tr.off >= len(tr.b) {
+					return nil, false
+				}
+				run = int(tr.b[tr.off])
+				tr.off++
+			}
+		}
+		if sum == 0 {
+			continue
+		}
+		if !normalise(tab, sum, 1<<shift) || !tab.build(shift) {
+			return nil, false
+		}
+		t[i] = tab
+	}
+
+	x, ok := r.states(n)
+	if !ok {
+		return nil, false
+	}
+	out := make([]byte, size)
+	if !ransOrder1(r, out, x, t[:], shift, r.renorm16) {
+		return nil, false
+	}
+	return out, true
+}
+
+// unRLE expands the run-length encoded literals in lit using the
+// run-length metadata in meta.
+func unRLE(lit []byte, meta *ransReader, size int) ([]byte, bool) {
+	if meta.off >= len(meta.b) {
+		return nil, false
+	}
+	n := int(meta.b[meta.off])
+	meta.off++
+	if n == 0 {
+		n = 256
+	}
+	if meta.off+n > len(meta.b) {
+		return nil, false
+	}
+	var run [256]bool
+	for _, s := range meta.b[meta.off : meta.off+n] {
+		run[s] = true
+	}
+	meta.off += n
+	out := make([]byte, 0, size)
+	for _, c := range lit {
+		if !run[c] {
+			out = append(out, c)
+			continue
+		}
+		l, ok := meta.uint7()
+		if !ok || len(out)+int(l)+1 > size {
+			return nil, false
+		}
+		for i := uint32(0); i <= l; i++ {
+			out = append(out, c)
+		}
+	}
+	return out, true
+}
+
+// unpack expands bit-packed symbols in b using the symbol map m.
+func unpack(b, m []byte, size int) ([]byte, bool) {
+	out := make([]byte, size)
+	var bits uint
+	switch {
+	case len(m) <= 1:
+		for i := range out {
+			out[i] = m[0]
+		}
+		return out, true
+	case len(m) <= 2:
+		bits = 1
+	case len(m) <= 4:
+		bits = 2
+	case len(m) <= 16:
+		bits = 4
+	default:
+		return nil, false
+	}
+	per := 8 / int(bits)
+	if len(b) < (size+per-1)/per {
+		return nil, false
+	}
+	mask := byte(1)<<bits - 1
+	for i := range out {
+		v := b[i/per] >> (uint(i%per) * bits) & mask
+		if int(v) >= len(m) {
+			return nil, false
+		}
+		out[i] = m[v]
+	}
+	return out, true
+}
@@ -0,0 +1,306 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import "encoding/binary"
+
+// ransSymbol is a symbol to be rANS encoded by the given state
+// in the given context.
+type ransSymbol struct {
+	state int
+	ctx   byte
+	sym   byte
+}
+
+// order0Symbols returns the symbols of data in decoding order for
+// n interleaved order-0 states.
+func order0Symbols(data []byte, n int) []ransSymbol {
+	s := make([]ransSymbol, len(data))
+	for i, b := range data {
+		s[i] = ransSymbol{state: i % n, sym: b}
+	}
+	return s
+}
+
+// order1Symbols returns the symbols of data in decoding order for
+// n order-1 states, each decoding a contiguous section of the data
+// with the final state also decoding any remainder.
+func order1Symbols(data []byte, n int) []ransSymbol {
+	seg := len(data) / n
+	s := make([]ransSymbol, 0, len(data))
+	for i := 0; i < seg; i++ {
+		for j := 0; j < n; j++ {
+			p := j*seg + i
+			var ctx byte
+			if i != 0 {
+				ctx = data[p-1]
+			}
+			s = append(s, ransSymbol{state: j, ctx: ctx, sym: data[p]})
+		}
+	}
+	for p := n * seg; p < len(data); p++ {
+		var ctx byte
+		if p != 0 {
+			ctx = data[p-1]
+		}
+		s = append(s, ransSymbol{state: n - 1, ctx: ctx, sym: data[p]})
+	}
+	return s
+}
+
+// ransCounts returns the symbol counts for each context of syms.
+func ransCounts(syms []ransSymbol) *[256][256]uint32 {
+	var c [256][256]uint32
+	for _, s := range syms {
+		c[s.ctx][s.sym]++
+	}
+	return &c
+}
+
+// normaliseCounts returns the counts in c scaled to sum to total, with
+// each non-zero count remaining non-zero.
+func normaliseCounts(c [256]uint32, total uint32) [256]uint32 {
+	var (
+		f   [256]uint32
+		n   uint64
+		sum uint32
+		max int
+	)
+	for _, v := range c {
+		n += uint64(v)
+	}
+	if n == 0 {
+		return f
+	}
+	for s, v := range c {
+		if v == 0 {
+			continue
+		}
+		f[s] = uint32(uint64(v) * uint64(total) / n)
+		if f[s] == 0 {
+			f[s] = 1
+		}
+		sum += f[s]
+		if f[s] > f[max] {
+			max = s
+		}
+	}
+	if sum <= total {
+		f[max] += total - sum
+		return f
+	}
+	for sum > total {
+		for s := range f {
+			if f[s] > 1 && sum > total {
+				f[s]--
+				sum--
+			}
+		}
+	}
+	return f
+}
+
+// ransEncode encodes syms using the frequency tables in f with the given
+// number of states, renormalising with 8 or 16 bit words. The encoded
+// initial states are followed by the renormalisation data.
+func ransEncode(syms []ransSymbol, f *[256][256]uint32, states int, word uint) []byte {
+	var cum [256][256]uint32
+	for ctx := range f {
+		var x uint32
+		for s, v := range f[ctx] {
+			cum[ctx][s] = x
+			x += v
+		}
+	}
+	lower := uint32(ransLower)
+	if word == 16 {
+		lower = 1 << 15
+	}
+	x := make([]uint32, states)
+	for i := range x {
+		x[i] = lower
+	}
+
+	// rev holds the output in reverse order since
+	// rANS decoding is the reverse of encoding.
+	var rev []byte
+	for i := len(syms) - 1; i >= 0; i-- {
+		s := syms[i]
+		freq := f[s.ctx][s.sym]
+		v := x[s.state]
+		max := ((lower >> ransShift) << word) * freq
+		for v >= max {
+			if word == 16 {
+				rev = append(rev, byte(v>>8), byte(v))
+			} else {
+				rev = append(rev, byte(v))
+			}
+			v >>= word
+		}
+		x[s.state] = (v/freq)<<ransShift + v%freq + cum[s.ctx][s.sym]
+	}
+	for j := states - 1; j >= 0; j-- {
+		v := x[j]
+		rev = append(rev, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	for i, j := 0, len(rev)-1; i < j; i, j = i+1, j-1 {
+		rev[i], rev[j] = rev[j], rev[i]
+	}
+	return rev
+}
+
+// appendSymbols appends the symbols for which present returns true using
+// the run-length encoding of rANS symbol lists, calling fn, if not nil,
+// after each symbol. The list is terminated with a zero byte.
+func appendSymbols(dst []byte, present func(int) bool, fn func(dst []byte, s int) []byte) []byte {
+	rle := 0
+	for s := 0; s < 256; s++ {
+		if !present(s) {
+			continue
+		}
+		if rle != 0 {
+			rle--
+		} else {
+			dst = append(dst, byte(s))
+			if s != 0 && present(s-1) {
+				for rle = s + 1; rle < 256 && present(rle); rle++ {
+				}
+				rle -= s + 1
+				dst = append(dst, byte(rle))
+			}
+		}
+		if fn != nil {
+			dst = fn(dst, s)
+		}
+	}
+	return append(dst, 0)
+}
+
+// append4x8Freqs appends a rANS 4x8 frequency table.
+func append4x8Freqs(dst []byte, f *[256]uint32) []byte {
+	return appendSymbols(dst, func(s int) bool { return f[s] != 0 }, func(dst []byte, s int) []byte {
+		if f[s] < 0x80 {
+			return append(dst, byte(f[s]))
+		}
+		return append(dst, byte(f[s]>>8)|0x80, byte(f[s]))
+	})
+}
+
+// rans4x8Encode returns data compressed with rANS 4x8 using the given
+// order.
+func rans4x8Encode(data []byte, order int) []byte {
+	var syms []ransSymbol
+	if order == 0 {
+		syms = order0Symbols(data, 4)
+	} else {
+		syms = order1Symbols(data, 4)
+	}
+	c := ransCounts(syms)
+	var f [256][256]uint32
+	for ctx := range c {
+		f[ctx] = normaliseCounts(c[ctx], ransTotal)
+	}
+
+	b := make([]byte, 9, 9+len(data)/2)
+	b[0] = byte(order)
+	if len(data) != 0 {
+		if order == 0 {
+			b = append4x8Freqs(b, &f[0])
+		} else {
+			b = appendSymbols(b, func(ctx int) bool { return f[ctx] != [256]uint32{} }, func(dst []byte, ctx int) []byte {
+				return append4x8Freqs(dst, &f[ctx])
+			})
+		}
+		b = append(b, ransEncode(syms, &f, 4, 8)...)
+	}
+	binary.LittleEndian.PutUint32(b[1:], uint32(len(b)-9))
+	binary.LittleEndian.PutUint32(b[5:], uint32(len(data)))
+	return b
+}
+
+// appendUint7 appends v as a CRAM 3.1 variable length integer.
+func appendUint7(dst []byte, v uint32) []byte {
+	var b [5]byte
+	i := len(b) - 1
+	b[i] = byte(v & 0x7f)
+	for v >>= 7; v != 0; v >>= 7 {
+		i--
+		b[i] = byte(v&0x7f) | 0x80
+	}
+	return append(dst, b[i:]...)
+}
+
+// ransNx16Encode returns data compressed with rANS Nx16. Only the
+// ransOrder1Flag and ransX32Flag flags are used.
+func ransNx16Encode(data []byte, flags byte) []byte {
+	flags &= ransOrder1Flag | ransX32Flag
+	b := []byte{flags}
+	b = appendUint7(b, uint32(len(data)))
+	if len(data) == 0 {
+		return b
+	}
+	states := 4
+	if flags&ransX32Flag != 0 {
+		states = 32
+	}
+	var syms []ransSymbol
+	if flags&ransOrder1Flag == 0 {
+		syms = order0Symbols(data, states)
+	} else {
+		syms = order1Symbols(data, states)
+	}
+	c := ransCounts(syms)
+	var f [256][256]uint32
+	for ctx := range c {
+		f[ctx] = normaliseCounts(c[ctx], ransTotal)
+	}
+
+	if flags&ransOrder1Flag == 0 {
+		b = appendSymbols(b, func(s int) bool { return f[0][s] != 0 }, nil)
+		for _, v := range f[0] {
+			if v != 0 {
+				b = appendUint7(b, v)
+			}
+		}
+	} else {
+		// The alphabet holds all contexts and symbols.
+		var alpha [256]bool
+		for _, s := range syms {
+			alpha[s.ctx] = true
+			alpha[s.sym] = true
+		}
+		b = append(b, ransShift<<4)
+		b = appendSymbols(b, func(s int) bool { return alpha[s] }, nil)
+		for i, ok := range alpha {
+			if !ok {
+				continue
+			}
+			run := 0
+			for j, ok := range alpha {
+				if !ok {
+					continue
+				}
+				if run > 0 {
+					run--
+					continue
+				}
+				b = appendUint7(b, f[i][j])
+				if f[i][j] == 0 {
+					for k := j + 1; k < 256 && run < 255; k++ {
+						if !alpha[k] {
+							continue
+						}
+						if f[i][k] != 0 {
+							break
+						}
+						run++
+					}
+					b = append(b, byte(run))
+				}
+			}
+		}
+	}
+	return append(b, ransEncode(syms, &f, states, 16)...)
+}
@@ -0,0 +1,196 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// Reader implements CRAM data reading.
+type Reader struct {
+	r       io.Reader
+	h       *sam.Header
+	version Version
+	id      [20]byte
+	refs    refCache
+
+	recs []*sam.Record
+	err  error
+}
+
+// NewReader returns a new Reader reading from the given io.Reader.
+// The ReferenceProvider ref is used to obtain reference sequence for
+// slices that do not hold an embedded reference. If ref is nil, only
+// slices that do not require reference sequence can be read.
+func NewReader(r io.Reader, ref ReferenceProvider) (*Reader, error) {
+	var def [26]byte
+	_, err := io.ReadFull(r, def[:])
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotCRAM
+		}
+		return nil, err
+	}
+	if string(def[:4]) != magic {
+		return nil, ErrNotCRAM
+	}
+	cr := &Reader{
+		r:       r,
+		version: Version{Major: def[4], Minor: def[5]},
+		refs:    refCache{provider: ref},
+	}
+	if cr.version.Major != 3 || cr.version.Minor > 1 {
+		return nil, ErrUnsupportedVersion(cr.version)
+	}
+	copy(cr.id[:], def[6:])
+
+	_, data, err := readContainer(r)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrCorrupt
+		}
+		return nil, err
+	}
+	b, err := readBlock(newCursor(data))
+	if err != nil {
+		return nil, err
+	}
+	if b.content != fileHeaderContent || len(b.data) < 4 {
+		return nil, ErrCorrupt
+	}
+	n := binary.LittleEndian.Uint32(b.data)
+	if int64(n) > int64(len(b.data)-4) {
+		return nil, ErrCorrupt
+	}
+	cr.h, err = sam.NewHeader(b.data[4:4+n], nil)
+	if err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Header returns the SAM Header held by the Reader.
+func (r *Reader) Header() *sam.Header { return r.h }
+
+// Version returns the CRAM version of the stream.
+func (r *Reader) Version() Version { return r.version }
+
+// ID returns the file ID held in the CRAM file definition.
+func (r *Reader) ID() [20]byte { return r.id }
+
+// Read returns the next sam.Record in the CRAM stream.
+func (r *Reader) Read() (*sam.Record, error) {
+	for len(r.recs) == 0 {
+		if r.err != nil {
+			return nil, r.err
+		}
+		r.recs, r.err = r.readContainer()
+	}
+	rec := r.recs[0]
+	r.recs[0] = nil
+	r.recs = r.recs[1:]
+	return rec, nil
+}
+
+// readContainer reads and decodes the records of the next container.
+func (r *Reader) readContainer() ([]*sam.Record, error) {
+	h, data, err := readContainer(r.r)
+	if err != nil {
+		return nil, err
+	}
+	if h.isEOF() {
+		return nil, io.EOF
+	}
+	if h.records == 0 {
+		return nil, nil
+	}
+	ch, err := readContainerCompressionHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	var recs []*sam.Record
+	for _, off := range h.landmarks {
+		s, err := r.readSlice(ch, data, int(off))
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, s...)
+	}
+	return recs, nil
+}
+
+// readContainerCompressionHeader reads the compression header at the
+// start of the container data.
+func readContainerCompressionHeader(data []byte) (*compressionHeader, error) {
+	b, err := readBlock(newCursor(data))
+	if err != nil {
+		return nil, err
+	}
+	return readCompressionHeader(b)
+}
+
+// readSlice decodes the slice starting at offset off of the container
+// data.
+func (r *Reader) readSlice(ch *compressionHeader, data []byte, off int) ([]*sam.Record, error) {
+	if off < 0 || off >= len(data) {
+		return nil, ErrCorrupt
+	}
+	c := newCursor(data[off:])
+	b, err := readBlock(c)
+	if err != nil {
+		return nil, err
+	}
+	sh, err := readSliceHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	blks := make([]*block, 1, sh.blocks+1)
+	blks[0] = b
+	for i := int32(0); i < sh.blocks; i++ {
+		b, err = readBlock(c)
+		if err != nil {
+			return nil, err
+		}
+		blks = append(blks, b)
+	}
+	return decodeSlice(r.h, ch, blks, &r.refs)
+}
+
+// refCache retains the most recently obtained reference sequence.
+type refCache struct {
+	provider ReferenceProvider
+
+	name     string
+	beg, end int
+	seq      []byte
+}
+
+// get returns the bases of the reference with the given ID in the
+// interval [beg,end).
+func (c *refCache) get(refs []*sam.Reference, id int32, beg, end int) ([]byte, error) {
+	if c.provider == nil || id < 0 || int(id) >= len(refs) {
+		return nil, ErrNoReference
+	}
+	name := refs[id].Name()
+	if c.seq == nil || name != c.name || beg < c.beg || end > c.end {
+		seq, err := c.provider.GetSequence(name, beg, end)
+		if err != nil {
+			return nil, err
+		}
+		c.name, c.beg, c.end, c.seq = name, beg, end, seq
+	}
+	b := beg - c.beg
+	e := end - c.beg
+	if e > len(c.seq) {
+		e = len(c.seq)
+	}
+	if b > e {
+		b = e
+	}
+	return c.seq[b:e], nil
+}
@@ -0,0 +1,656 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"bytes"
+	"crypto/md5"
+	"strconv"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// CRAM compression bit flags.
+const (
+	qualArrayFlag    = 0x1
+	detachedFlag     = 0x2
+	mateDownFlag     = 0x4
+	unknownBasesFlag = 0x8
+)
+
+// CRAM mate bit flags.
+const (
+	mateReverseFlag  = 0x1
+	mateUnmappedFlag = 0x2
+)
+
+// sliceData holds the core and external data blocks of a slice.
+type sliceData struct {
+	core bitReader
+	ext  blocks
+	err  error
+}
+
+func (s *sliceData) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *sliceData) external(id int32) *cursor { return s.ext.get(id) }
+
+// error returns the first error encountered while reading s.
+func (s *sliceData) error() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.core.err != nil {
+		return s.core.err
+	}
+	for _, c := range s.ext {
+		if c.err != nil {
+			return c.err
+		}
+	}
+	return nil
+}
+
+// feature is a CRAM read feature.
+type feature struct {
+	code byte
+
+	// pos is the one-based position
+	// of the feature in the read.
+	pos int
+
+	base byte
+	qual byte
+	data []byte
+	n    int
+}
+
+// crecord is a CRAM record prior to reconstruction.
+type crecord struct {
+	flags     sam.Flags
+	cf        int32
+	refID     int32
+	length    int
+	pos       int
+	rg        int32
+	name      []byte
+	mateFlags int32
+	mateRef   int32
+	matePos   int32
+	tlen      int32
+
+	// next is the index of the next record of
+	// the template in the slice, or -1.
+	next int
+
+	aux      sam.AuxFields
+	features []feature
+	mapq     byte
+	bases    []byte
+	quals    []byte
+}
+
+// sliceDecoder decodes the records of a slice.
+type sliceDecoder struct {
+	h    *sam.Header
+	ch   *compressionHeader
+	sh   *sliceHeader
+	refs *refCache
+
+	// embedded holds the embedded
+	// reference of the slice.
+	embedded []byte
+
+	// ref holds the reference bases for
+	// a single reference slice.
+	ref    []byte
+	refBeg int
+	refOK  bool
+}
+
+// decodeSlice decodes the records of the slice held in blks, the first
+// of which must be the slice header block.
+func decodeSlice(h *sam.Header, ch *compressionHeader, blks []*block, refs *refCache) ([]*sam.Record, error) {
+	if len(blks) == 0 {
+		return nil, ErrCorrupt
+	}
+	sh, err := readSliceHeader(blks[0])
+	if err != nil {
+		return nil, err
+	}
+	if int(sh.blocks) != len(blks)-1 {
+		return nil, ErrCorrupt
+	}
+	s := sliceData{ext: make(blocks)}
+	for _, b := range blks[1:] {
+		switch b.content {
+		case coreContent:
+			s.core.b = b.data
+		case externalContent:
+			s.ext[b.contentID] = newCursor(b.data)
+		default:
+			return nil, ErrCorrupt
+		}
+	}
+	d := sliceDecoder{h: h, ch: ch, sh: sh, refs: refs}
+	if sh.embedded >= 0 {
+		c, ok := s.ext[sh.embedded]
+		if !ok {
+			return nil, ErrCorrupt
+		}
+		d.embedded = c.b
+	}
+
+	crecs := make([]crecord, sh.records)
+	pos := int(sh.start)
+	for i := range crecs {
+		d.readRecord(&s, &crecs[i], i, &pos)
+		if err = s.error(); err != nil {
+			return nil, err
+		}
+	}
+	return d.reconstruct(crecs)
+}
+
+// series returns the encoding for the named data series.
+func (d *sliceDecoder) series(name string) *encoding {
+	return d.ch.series[key(name)]
+}
+
+// readRecord reads the CRAM record at index i of the slice into cr.
+func (d *sliceDecoder) readRecord(s *sliceData, cr *crecord, i int, pos *int) {
+	ch := d.ch
+	cr.next = -1
+	cr.flags = sam.Flags(d.series("BF").readInt(s))
+	cr.cf = d.series("CF").readInt(s)
+	cr.refID = d.sh.refID
+	if d.sh.refID == multiRef {
+		cr.refID = d.series("RI").readInt(s)
+	}
+	cr.length = int(d.series("RL").readInt(s))
+	if cr.length < 0 {
+		s.fail(ErrCorrupt)
+		return
+	}
+	ap := int(d.series("AP").readInt(s))
+	if ch.apDelta {
+		*pos += ap
+		cr.pos = *pos
+	} else {
+		cr.pos = ap
+	}
+	cr.rg = d.series("RG").readInt(s)
+	if ch.readNames {
+		cr.name = d.series("RN").readArray(s)
+	}
+
+	switch {
+	case cr.cf&detachedFlag != 0:
+		cr.mateFlags = d.series("MF").readInt(s)
+		if !ch.readNames {
+			cr.name = d.series("RN").readArray(s)
+		}
+		cr.mateRef = d.series("NS").readInt(s)
+		cr.matePos = d.series("NP").readInt(s)
+		cr.tlen = d.series("TS").readInt(s)
+	case cr.cf&mateDownFlag != 0:
+		cr.next = i + int(d.series("NF").readInt(s)) + 1
+	}
+
+	tl := int(d.series("TL").readInt(s))
+	if s.error() != nil {
+		return
+	}
+	if tl < 0 || tl >= len(ch.tagDict) {
+		if tl != 0 || len(ch.tagDict) != 0 {
+			s.fail(ErrCorrupt)
+			return
+		}
+	} else {
+		for _, t := range ch.tagDict[tl] {
+			k := int32(t[0])<<16 | int32(t[1])<<8 | int32(t[2])
+			v := ch.tags[k].readArray(s)
+			if t[2] == 'Z' || t[2] == 'H' {
+				v = bytes.TrimSuffix(v, []byte{0})
+			}
+			cr.aux = append(cr.aux, append(sam.Aux{t[0], t[1], t[2]}, v...))
+		}
+	}
+
+	if cr.flags&sam.Unmapped == 0 {
+		n := int(d.series("FN").readInt(s))
+		if n < 0 {
+			s.fail(ErrCorrupt)
+			return
+		}
+		cr.features = make([]feature, n)
+		var fpos int
+		for j := range cr.features {
+			f := &cr.features[j]
+			f.code = d.series("FC").readByte(s)
+			fpos += int(d.series("FP").readInt(s))
+			f.pos = fpos
+			switch f.code {
+			case 'B':
+				f.base = d.series("BA").readByte(s)
+				f.qual = d.series("QS").readByte(s)
+			case 'X':
+				f.base = d.series("BS").readByte(s)
+			case 'I':
+				f.data = d.series("IN").readArray(s)
+			case 'i':
+				f.base = d.series("BA").readByte(s)
+			case 'S':
+				f.data = d.series("SC").readArray(s)
+			case 'b':
+				f.data = d.series("BB").readArray(s)
+			case 'q':
+				f.data = d.series("QQ").readArray(s)
+			case 'Q':
+				f.qual = d.series("QS").readByte(s)
+			case 'D':
+				f.n = int(d.series("DL").readInt(s))
+			case 'N':
+				f.n = int(d.series("RS").readInt(s))
+			case 'P':
+				f.n = int(d.series("PD").readInt(s))
+			case 'H':
+				f.n = int(d.series("HC").readInt(s))
+			default:
+				s.fail(ErrCorrupt)
+				return
+			}
+			if s.error() != nil {
+				return
+			}
+		}
+		cr.mapq = byte(d.series("MQ").readInt(s))
+	} else if cr.cf&unknownBasesFlag == 0 {
+		cr.bases = d.readBytes(s, "BA", cr.length)
+	}
+	if cr.cf&qualArrayFlag != 0 {
+		cr.quals = d.readBytes(s, "QS", cr.length)
+	}
+}
+
+// readBytes reads n bytes from the named data series.
+func (d *sliceDecoder) readBytes(s *sliceData, name string, n int) []byte {
+	e := d.series(name)
+	if e != nil && e.codec == externalCodec {
+		return append([]byte(nil), s.external(e.id).bytes(n)...)
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = e.readByte(s)
+	}
+	return b
+}
+
+// reconstruct returns the SAM records described by crecs.
+func (d *sliceDecoder) reconstruct(crecs []crecord) ([]*sam.Record, error) {
+	refs := d.h.Refs()
+	rgs := d.h.RGs()
+	recs := make([]*sam.Record, len(crecs))
+	for i := range crecs {
+		cr := &crecs[i]
+		r := &sam.Record{
+			Name:      string(cr.name),
+			Pos:       cr.pos - 1,
+			Flags:     cr.flags,
+			MateRef:   nil,
+			MatePos:   -1,
+			AuxFields: cr.aux,
+		}
+		var err error
+		r.Ref, err = reference(refs, cr.refID)
+		if err != nil {
+			return nil, err
+		}
+		if cr.cf&detachedFlag != 0 {
+			r.MateRef, err = reference(refs, cr.mateRef)
+			if err != nil {
+				return nil, err
+			}
+			r.MatePos = int(cr.matePos) - 1
+			r.TempLen = int(cr.tlen)
+			if cr.mateFlags&mateReverseFlag != 0 {
+				r.Flags |= sam.MateReverse
+			}
+			if cr.mateFlags&mateUnmappedFlag != 0 {
+				r.Flags |= sam.MateUnmapped
+			}
+		}
+		if cr.rg >= 0 {
+			if int(cr.rg) >= len(rgs) {
+				return nil, ErrCorrupt
+			}
+			aux, err := sam.NewAux(sam.NewTag("RG"), rgs[cr.rg].Name())
+			if err != nil {
+				return nil, err
+			}
+			r.AuxFields = append(r.AuxFields, aux)
+		}
+
+		var seq []byte
+		if cr.flags&sam.Unmapped == 0 {
+			r.MapQ = cr.mapq
+			seq, r.Cigar, err = d.alignment(cr)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			seq = cr.bases
+		}
+		if cr.cf&unknownBasesFlag != 0 {
+			seq = nil
+		}
+		r.Seq = sam.NewSeq(seq)
+		if cr.quals != nil {
+			r.Qual = cr.quals
+		} else if len(seq) != 0 {
+			r.Qual = missingQuals(cr)
+		}
+		recs[i] = r
+	}
+
+	d.linkMates(crecs, recs)
+	return recs, nil
+}
+
+// reference returns the reference with the given ID.
+func reference(refs []*sam.Reference, id int32) (*sam.Reference, error) {
+	if id < 0 {
+		return nil, nil
+	}
+	if int(id) >= len(refs) {
+		return nil, ErrCorrupt
+	}
+	return refs[id], nil
+}
+
+// missingQuals returns the quality scores of a record without preserved
+// quality scores. Scores provided by read features are retained.
+func missingQuals(cr *crecord) []byte {
+	q := make([]byte, cr.length)
+	for i := range q {
+		q[i] = 0xff
+	}
+	for _, f := range cr.features {
+		switch f.code {
+		case 'B', 'Q':
+			if f.pos > 0 && f.pos <= len(q) {
+				q[f.pos-1] = f.qual
+			}
+		case 'q':
+			if f.pos > 0 {
+				copy(q[f.pos-1:], f.data)
+			}
+		}
+	}
+	return q
+}
+
+// alignment returns the sequence and CIGAR of the mapped record cr.
+func (d *sliceDecoder) alignment(cr *crecord) ([]byte, sam.Cigar, error) {
+	// Determine the extent of the reference covered.
+	n := cr.length
+	for _, f := range cr.features {
+		switch f.code {
+		case 'I', 'S':
+			n -= len(f.data)
+		case 'i':
+			n--
+		case 'D', 'N':
+			n += f.n
+		}
+	}
+	if n < 0 {
+		return nil, nil, ErrCorrupt
+	}
+	ref, beg, err := d.reference(cr.refID, cr.pos-1, cr.pos-1+n)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := func(i int) byte {
+		i -= beg
+		if i < 0 || i >= len(ref) {
+			return 'N'
+		}
+		return upper(ref[i])
+	}
+
+	var (
+		seq    = make([]byte, cr.length)
+		cigar  sam.Cigar
+		rpos   = 0
+		refPos = cr.pos - 1
+	)
+	add := func(t sam.CigarOpType, n int) {
+		if n == 0 {
+			return
+		}
+		if len(cigar) != 0 && cigar[len(cigar)-1].Type() == t {
+			n += cigar[len(cigar)-1].Len()
+			cigar = cigar[:len(cigar)-1]
+		}
+		cigar = append(cigar, sam.NewCigarOp(t, n))
+	}
+	match := func(n int) bool {
+		if rpos+n > len(seq) {
+			return false
+		}
+		for i := 0; i < n; i++ {
+			seq[rpos+i] = base(refPos + i)
+		}
+		add(sam.CigarMatch, n)
+		rpos += n
+		refPos += n
+		return true
+	}
+	for _, f := range cr.features {
+		if f.pos-1 < rpos || !match(f.pos-1-rpos) {
+			return nil, nil, ErrCorrupt
+		}
+		switch f.code {
+		case 'X', 'B':
+			if rpos >= len(seq) {
+				return nil, nil, ErrCorrupt
+			}
+			if f.code == 'X' {
+				if f.base > 3 {
+					return nil, nil, ErrCorrupt
+				}
+				seq[rpos] = d.ch.subst[baseIndex(base(refPos))][f.base]
+			} else {
+				seq[rpos] = f.base
+			}
+			add(sam.CigarMatch, 1)
+			rpos++
+			refPos++
+		case 'I', 'S':
+			if rpos+len(f.data) > len(seq) {
+				return nil, nil, ErrCorrupt
+			}
+			copy(seq[rpos:], f.data)
+			t := sam.CigarInsertion
+			if f.code == 'S' {
+				t = sam.CigarSoftClipped
+			}
+			add(t, len(f.data))
+			rpos += len(f.data)
+		case 'i':
+			if rpos >= len(seq) {
+				return nil, nil, ErrCorrupt
+			}
+			seq[rpos] = f.base
+			add(sam.CigarInsertion, 1)
+			rpos++
+		case 'b':
+			if rpos+len(f.data) > len(seq) {
+				return nil, nil, ErrCorrupt
+			}
+			copy(seq[rpos:], f.data)
+			add(sam.CigarMatch, len(f.data))
+			rpos += len(f.data)
+			refPos += len(f.data)
+		case 'D':
+			add(sam.CigarDeletion, f.n)
+			refPos += f.n
+		case 'N':
+			add(sam.CigarSkipped, f.n)
+			refPos += f.n
+		case 'P':
+			add(sam.CigarPadded, f.n)
+		case 'H':
+			add(sam.CigarHardClipped, f.n)
+		}
+	}
+	if !match(len(seq) - rpos) {
+		return nil, nil, ErrCorrupt
+	}
+	return seq, cigar, nil
+}
+
+// reference returns the reference bases of the reference with the given
+// ID that are available for the interval [beg,end) and the position of
+// the first returned base.
+func (d *sliceDecoder) reference(id int32, beg, end int) ([]byte, int, error) {
+	if d.embedded != nil {
+		return d.embedded, int(d.sh.start) - 1, nil
+	}
+	if d.sh.refID != multiRef {
+		if !d.refOK {
+			err := d.sliceReference()
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		return d.ref, d.refBeg, nil
+	}
+	if !d.ch.refRequired {
+		return nil, beg, nil
+	}
+	ref, err := d.refs.get(d.h.Refs(), id, beg, end)
+	return ref, beg, err
+}
+
+// sliceReference fetches and validates the reference bases for a
+// single reference slice.
+func (d *sliceDecoder) sliceReference() error {
+	beg := int(d.sh.start) - 1
+	end := beg + int(d.sh.span)
+	if !d.ch.refRequired {
+		// Slices that do not require the reference
+		// hold all bases explicitly.
+		d.refBeg = beg
+		d.refOK = true
+		return nil
+	}
+	ref, err := d.refs.get(d.h.Refs(), d.sh.refID, beg, end)
+	if err != nil {
+		return err
+	}
+	if d.sh.md5 != ([16]byte{}) {
+		h := md5.New()
+		for _, b := range ref {
+			h.Write([]byte{upper(b)})
+		}
+		var sum [16]byte
+		copy(sum[:], h.Sum(nil))
+		if sum != d.sh.md5 {
+			return ErrReferenceMD5
+		}
+	}
+	d.ref = ref
+	d.refBeg = beg
+	d.refOK = true
+	return nil
+}
+
+func upper(b byte) byte {
+	if 'a' <= b && b <= 'z' {
+		return b &^ ' '
+	}
+	return b
+}
+
+// linkMates fills the mate fields of records whose mates are held in
+// the same slice and generates names for records without names.
+func (d *sliceDecoder) linkMates(crecs []crecord, recs []*sam.Record) {
+	seen := make([]bool, len(crecs))
+	for i := range crecs {
+		if seen[i] {
+			continue
+		}
+		tmpl := []*sam.Record{recs[i]}
+		seen[i] = true
+		for j := crecs[i].next; j > i && j < len(crecs) && !seen[j]; j = crecs[j].next {
+			tmpl = append(tmpl, recs[j])
+			seen[j] = true
+		}
+
+		if recs[i].Name == "" {
+			recs[i].Name = strconv.FormatInt(d.sh.counter+int64(i)+1, 10)
+		}
+		if len(tmpl) == 1 {
+			continue
+		}
+		for k, r := range tmpl {
+			m := tmpl[(k+1)%len(tmpl)]
+			if r.Name == "" {
+				r.Name = recs[i].Name
+			}
+			r.MateRef = m.Ref
+			r.MatePos = m.Pos
+			if m.Flags&sam.Unmapped != 0 {
+				r.Flags |= sam.MateUnmapped
+			}
+			if m.Flags&sam.Reverse != 0 {
+				r.Flags |= sam.MateReverse
+			}
+		}
+		if tlen, ok := templateLengths(tmpl); ok {
+			for k, r := range tmpl {
+				r.TempLen = tlen[k]
+			}
+		}
+	}
+}
+
+// templateLengths returns the template lengths of the records of a
+// template held in the same slice, in order. If any record of the
+// template is unmapped or the records are on different references,
+// templateLengths returns false.
+func templateLengths(tmpl []*sam.Record) ([]int, bool) {
+	ref := tmpl[0].Ref
+	left, right := -1, -1
+	for _, r := range tmpl {
+		if r.Flags&sam.Unmapped != 0 || r.Ref != ref {
+			return nil, false
+		}
+		if left < 0 || r.Pos < left {
+			left = r.Pos
+		}
+		if end := r.End(); end > right {
+			right = end
+		}
+	}
+	tlen := right - left
+	lens := make([]int, len(tmpl))
+	first := true
+	for k, r := range tmpl {
+		if first && r.Pos == left {
+			lens[k] = tlen
+			first = false
+		} else {
+			lens[k] = -tlen
+		}
+	}
+	return lens, true
+}
@@ -0,0 +1,886 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// WriterOptions specifies the behaviour of a Writer.
+type WriterOptions struct {
+	// Reference provides the reference sequence used for
+	// reference-based compression. If Reference is nil, all
+	// bases are stored and no reference sequence is needed
+	// to read the written data.
+	Reference ReferenceProvider
+
+	// EmbedReference specifies that the reference sequence
+	// covered by each slice is stored in the slice so that no
+	// external reference is needed to read the written data.
+	// EmbedReference requires a non-nil Reference.
+	EmbedReference bool
+
+	// RecordsPerSlice and SlicesPerContainer are the maximum
+	// number of records held in a slice and the maximum number
+	// of slices held in a container. If zero, 10000 records
+	// per slice and one slice per container are used.
+	RecordsPerSlice    int
+	SlicesPerContainer int
+
+	// Method is the compression method used for data series
+	// and tag blocks. SeriesMethod overrides Method for the
+	// data series named by the two letter codes of the CRAM
+	// specification, for example "QS" for quality scores.
+	// The zero value, Raw, stores blocks uncompressed.
+	Method       Method
+	SeriesMethod map[string]Method
+
+	// LossyNames specifies that read names are discarded
+	// unless they are needed to associate a record with a
+	// mate held in another slice. Discarded names are
+	// generated when the data are read.
+	LossyNames bool
+
+	// DiscardQuality specifies that quality scores are not
+	// stored, except for bases that differ from the reference.
+	DiscardQuality bool
+
+	// QualityBinning, if not nil, is applied to each stored
+	// quality score.
+	QualityBinning func(q byte) byte
+}
+
+// Illumina8Binning bins quality scores into the eight levels used
+// by Illumina quality score binning. It is suitable for use as the
+// QualityBinning field of WriterOptions.
+func Illumina8Binning(q byte) byte {
+	switch {
+	case q < 2, q == 0xff:
+		return q
+	case q < 10:
+		return 6
+	case q < 20:
+		return 15
+	case q < 25:
+		return 22
+	case q < 30:
+		return 27
+	case q < 35:
+		return 33
+	case q < 40:
+		return 37
+	}
+	return 40
+}
+
+// dataSeries is the set of data series written by a Writer. The content
+// ID of the external block holding each series is its index plus one.
+var dataSeries = []string{
+	"BF", "CF", "RI", "RL", "AP", "RG", "RN", "MF", "NS", "NP", "TS", "NF", "TL",
+	"FN", "FC", "FP", "BA", "QS", "BS", "IN", "SC", "BB", "QQ", "DL", "RS", "PD",
+	"HC", "MQ",
+}
+
+// embeddedID is the content ID of embedded reference blocks.
+var embeddedID = int32(len(dataSeries) + 1)
+
+// seriesID returns the content ID of the named data series.
+func seriesID(name string) int32 {
+	for i, n := range dataSeries {
+		if n == name {
+			return int32(i + 1)
+		}
+	}
+	panic("cram: unknown data series: " + name)
+}
+
+// isSeries returns whether name is a data series written by a Writer.
+func isSeries(name string) bool {
+	for _, n := range dataSeries {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isArraySeries returns whether the named data series holds byte arrays.
+func isArraySeries(name string) bool {
+	switch name {
+	case "RN", "IN", "SC", "BB", "QQ":
+		return true
+	}
+	return false
+}
+
+// canWrite returns whether blocks can be compressed with m.
+func canWrite(m Method) bool {
+	switch m {
+	case Raw, Gzip, Rans4x8, RansNx16:
+		return true
+	}
+	return false
+}
+
+// Writer implements CRAM data writing.
+type Writer struct {
+	w    io.Writer
+	h    *sam.Header
+	opts WriterOptions
+	rgs  map[string]int32
+	refs refCache
+
+	// off is the number of bytes
+	// written to w.
+	off int64
+
+	counter int64
+	refID   int
+	slice   []*sam.Record
+	slices  [][]*sam.Record
+
+	idx    Index
+	closed bool
+}
+
+// NewWriter returns a new Writer using the given SAM header and
+// options. Records written to the Writer are retained until the slice
+// holding them has been written, so they must not be altered until
+// the Writer has been flushed or closed.
+func NewWriter(w io.Writer, h *sam.Header, opts WriterOptions) (*Writer, error) {
+	if opts.EmbedReference && opts.Reference == nil {
+		return nil, errors.New("cram: embedded reference requires a reference provider")
+	}
+	if opts.RecordsPerSlice < 0 || opts.SlicesPerContainer < 0 {
+		return nil, errors.New("cram: invalid slice size")
+	}
+	if opts.RecordsPerSlice == 0 {
+		opts.RecordsPerSlice = 10000
+	}
+	if opts.SlicesPerContainer == 0 {
+		opts.SlicesPerContainer = 1
+	}
+	if !canWrite(opts.Method) {
+		return nil, ErrUnsupportedMethod(opts.Method)
+	}
+	minor := byte(0)
+	if opts.Method == RansNx16 {
+		minor = 1
+	}
+	for n, m := range opts.SeriesMethod {
+		if len(n) != 2 || !isSeries(n) {
+			return nil, fmt.Errorf("cram: unknown data series: %q", n)
+		}
+		if !canWrite(m) {
+			return nil, ErrUnsupportedMethod(m)
+		}
+		if m == RansNx16 {
+			minor = 1
+		}
+	}
+
+	cw := &Writer{
+		w:     w,
+		h:     h,
+		opts:  opts,
+		rgs:   make(map[string]int32),
+		refs:  refCache{provider: opts.Reference},
+		refID: -1,
+	}
+	for i, rg := range h.RGs() {
+		cw.rgs[rg.Name()] = int32(i)
+	}
+
+	b := append([]byte(magic), 3, minor)
+	b = append(b, make([]byte, 20)...)
+	text, err := h.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	hdr := binary.LittleEndian.AppendUint32(nil, uint32(len(text)))
+	hdr = append(hdr, text...)
+	data := appendBlock(nil, Raw, fileHeaderContent, 0, hdr, hdr)
+	b = appendContainer(b, 0, 0, 0, 0, 0, 0, 1, []int32{0}, data)
+	err = cw.write(b)
+	if err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.off += int64(n)
+	return err
+}
+
+// Write writes r to the CRAM stream. Records are grouped into slices
+// and containers by reference, so r should be written in coordinate
+// sorted order for reference-based compression to be effective.
+func (w *Writer) Write(r *sam.Record) error {
+	if w.closed {
+		return errors.New("cram: write to closed writer")
+	}
+	id := r.Ref.ID()
+	if id != w.refID && len(w.slice)+len(w.slices) != 0 {
+		err := w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	w.refID = id
+	w.slice = append(w.slice, r)
+	if len(w.slice) < w.opts.RecordsPerSlice {
+		return nil
+	}
+	w.slices = append(w.slices, w.slice)
+	w.slice = nil
+	if len(w.slices) < w.opts.SlicesPerContainer {
+		return nil
+	}
+	return w.Flush()
+}
+
+// Flush writes all pending records to the underlying io.Writer in a
+// new container.
+func (w *Writer) Flush() error {
+	if len(w.slice) != 0 {
+		w.slices = append(w.slices, w.slice)
+		w.slice = nil
+	}
+	if len(w.slices) == 0 {
+		return nil
+	}
+	b, err := w.container(w.slices)
+	w.slices = nil
+	if err != nil {
+		return err
+	}
+	return w.write(b)
+}
+
+// Close writes any pending records and the CRAM EOF container. It does
+// not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+	w.closed = true
+	ch := []byte{1, 0, 1, 0, 1, 0}
+	eof := appendContainer(nil, -1, eofStart, 0, 0, 0, 0, 1, nil, appendBlock(nil, Raw, compressionHeaderContent, 0, ch, ch))
+	return w.write(eof)
+}
+
+// Index returns a CRAM index for the slices written so far.
+func (w *Writer) Index() *Index {
+	return &Index{Slices: append([]IndexSlice(nil), w.idx.Slices...)}
+}
+
+// method returns the compression method for the named data series.
+func (w *Writer) method(name string) Method {
+	if m, ok := w.opts.SeriesMethod[name]; ok {
+		return m
+	}
+	return w.opts.Method
+}
+
+// encodedSlice is an encoded slice and the extent of its records.
+type encodedSlice struct {
+	start, span int
+	records     int
+	bases       int64
+	blocks      int
+	data        []byte
+}
+
+// container returns an encoded container holding the given slices
+// of records, all of which have the reference ID w.refID.
+func (w *Writer) container(slices [][]*sam.Record) ([]byte, error) {
+	e := containerEncoder{
+		w:     w,
+		refID: w.refID,
+		noRef: w.opts.Reference == nil || w.refID < 0,
+		td:    make(map[string]int),
+		tags:  make(map[int32]bool),
+	}
+	encoded := make([]encodedSlice, len(slices))
+	for i, recs := range slices {
+		var err error
+		encoded[i], err = e.slice(recs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ch := e.compressionHeader()
+	data := appendBlock(nil, Raw, compressionHeaderContent, 0, ch, ch)
+	var (
+		landmarks []int32
+		nrec      int
+		bases     int64
+		nblocks   = 1
+		beg, end  int
+	)
+	for i, s := range encoded {
+		landmarks = append(landmarks, int32(len(data)))
+		if i == 0 || s.start < beg {
+			beg = s.start
+		}
+		if i == 0 || s.start+s.span > end {
+			end = s.start + s.span
+		}
+		nrec += s.records
+		bases += s.bases
+		nblocks += s.blocks
+		data = append(data, s.data...)
+	}
+	b := appendContainer(nil, int32(w.refID), int32(beg), int32(end-beg), int32(nrec), w.counter, bases, int32(nblocks), landmarks, data)
+	for i, s := range encoded {
+		w.idx.Slices = append(w.idx.Slices, IndexSlice{
+			RefID:     w.refID,
+			Start:     s.start,
+			Span:      s.span,
+			Container: w.off,
+			Slice:     int64(landmarks[i]),
+			Size:      int64(len(s.data)),
+		})
+	}
+	w.counter += int64(nrec)
+	return b, nil
+}
+
+// containerEncoder encodes the slices of a container.
+type containerEncoder struct {
+	w       *Writer
+	refID   int
+	noRef   bool
+	counter int64
+
+	// td and tagDict hold the tag dictionary
+	// of the container and tags holds the
+	// keys of the tags it holds.
+	td      map[string]int
+	tagDict [][][3]byte
+	tags    map[int32]bool
+}
+
+// compressionHeader returns the compression header for the container.
+func (e *containerEncoder) compressionHeader() []byte {
+	var pm []byte
+	pm = append(pm, "RN"...)
+	pm = append(pm, b2i(!e.w.opts.LossyNames))
+	pm = append(pm, "AP"...)
+	pm = append(pm, 1)
+	pm = append(pm, "RR"...)
+	pm = append(pm, b2i(!e.noRef))
+	pm = append(pm, "SM"...)
+	pm = append(pm, 0x1b, 0x1b, 0x1b, 0x1b, 0x1b)
+	var td []byte
+	for _, line := range e.tagDict {
+		for _, t := range line {
+			td = append(td, t[:]...)
+		}
+		td = append(td, 0)
+	}
+	pm = append(pm, "TD"...)
+	pm = appendITF8(pm, int32(len(td)))
+	pm = append(pm, td...)
+
+	var ds []byte
+	for _, n := range dataSeries {
+		ds = append(ds, n...)
+		id := seriesID(n)
+		if isArraySeries(n) {
+			ds = appendByteArrayLen(ds, id, id)
+		} else {
+			ds = appendEncoding(ds, externalCodec, appendITF8(nil, id))
+		}
+	}
+
+	keys := make([]int32, 0, len(e.tags))
+	for k := range e.tags {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	var tm []byte
+	for _, k := range keys {
+		tm = appendITF8(tm, k)
+		tm = appendByteArrayLen(tm, k, k)
+	}
+
+	var ch []byte
+	for _, m := range []struct {
+		n    int
+		data []byte
+	}{{5, pm}, {len(dataSeries), ds}, {len(keys), tm}} {
+		body := appendITF8(nil, int32(m.n))
+		body = append(body, m.data...)
+		ch = appendITF8(ch, int32(len(body)))
+		ch = append(ch, body...)
+	}
+	return ch
+}
+
+func b2i(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// sliceEncoder accumulates the external data of a slice.
+type sliceEncoder struct {
+	ext map[int32][]byte
+
+	// ref holds the reference bases of the
+	// slice starting from refBeg.
+	ref    []byte
+	refBeg int
+}
+
+func (s *sliceEncoder) int(name string, v int) {
+	id := seriesID(name)
+	s.ext[id] = appendITF8(s.ext[id], int32(v))
+}
+
+func (s *sliceEncoder) byte(name string, v byte) {
+	id := seriesID(name)
+	s.ext[id] = append(s.ext[id], v)
+}
+
+func (s *sliceEncoder) bytes(name string, v []byte) {
+	id := seriesID(name)
+	s.ext[id] = append(s.ext[id], v...)
+}
+
+func (s *sliceEncoder) array(name string, v []byte) {
+	s.tag(seriesID(name), v)
+}
+
+func (s *sliceEncoder) tag(id int32, v []byte) {
+	s.ext[id] = appendITF8(s.ext[id], int32(len(v)))
+	s.ext[id] = append(s.ext[id], v...)
+}
+
+// base returns the upper case reference base at the zero-based
+// position i, or 'N' if i is outside the slice reference.
+func (s *sliceEncoder) base(i int) byte {
+	i -= s.refBeg
+	if i < 0 || i >= len(s.ref) {
+		return 'N'
+	}
+	return upper(s.ref[i])
+}
+
+// slice returns the encoded slice holding recs.
+func (e *containerEncoder) slice(recs []*sam.Record) (encodedSlice, error) {
+	w := e.w
+	var es encodedSlice
+	es.records = len(recs)
+	if e.refID >= 0 {
+		end := 0
+		for i, r := range recs {
+			if i == 0 || r.Pos+1 < es.start {
+				es.start = r.Pos + 1
+			}
+			if r.End() > end {
+				end = r.End()
+			}
+		}
+		es.span = end - (es.start - 1)
+	}
+
+	s := sliceEncoder{ext: make(map[int32][]byte)}
+	var sum [16]byte
+	if !e.noRef {
+		ref, err := w.refs.get(w.h.Refs(), int32(e.refID), es.start-1, es.start-1+es.span)
+		if err != nil {
+			return es, err
+		}
+		s.ref = ref
+		s.refBeg = es.start - 1
+		h := md5.New()
+		for _, b := range ref {
+			h.Write([]byte{upper(b)})
+		}
+		copy(sum[:], h.Sum(nil))
+	}
+
+	next, attached := pairMates(recs)
+	pos := es.start
+	for i, r := range recs {
+		n, err := e.record(&s, r, next[i]-i-1, attached[i], &pos)
+		if err != nil {
+			return es, err
+		}
+		es.bases += int64(n)
+	}
+
+	ids := make([]int32, 0, len(s.ext))
+	for id := range s.ext {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	blks := appendBlock(nil, Raw, coreContent, 0, nil, nil)
+	for _, id := range ids {
+		m := w.opts.Method
+		if int(id) <= len(dataSeries) {
+			m = w.method(dataSeries[id-1])
+		}
+		raw := s.ext[id]
+		data, err := compress(m, raw)
+		if err != nil {
+			return es, err
+		}
+		blks = appendBlock(blks, m, externalContent, id, raw, data)
+	}
+	embedded := int32(-1)
+	if w.opts.EmbedReference && !e.noRef {
+		embedded = embeddedID
+		ref := bytes.ToUpper(s.ref)
+		data, err := compress(w.opts.Method, ref)
+		if err != nil {
+			return es, err
+		}
+		blks = appendBlock(blks, w.opts.Method, externalContent, embedded, ref, data)
+		ids = append(ids, embedded)
+	}
+	es.blocks = 1 + 1 + len(ids)
+
+	var sh []byte
+	sh = appendITF8(sh, int32(e.refID))
+	sh = appendITF8(sh, int32(es.start))
+	sh = appendITF8(sh, int32(es.span))
+	sh = appendITF8(sh, int32(es.records))
+	sh = appendLTF8(sh, w.counter+e.counter)
+	sh = appendITF8(sh, int32(1+len(ids)))
+	sh = appendITF8Array(sh, append([]int32{0}, ids...))
+	sh = appendITF8(sh, embedded)
+	sh = append(sh, sum[:]...)
+	es.data = appendBlock(nil, Raw, sliceHeaderContent, 0, sh, sh)
+	es.data = append(es.data, blks...)
+	e.counter += int64(len(recs))
+	return es, nil
+}
+
+// pairMates returns the index of the next record of the template of
+// each record in recs, and whether each record is the second record
+// of a template. Only pairs of records whose mate information will be
+// reconstructed exactly when read are linked. Records without a linked
+// mate have a next index of -1.
+func pairMates(recs []*sam.Record) (next []int, attached []bool) {
+	next = make([]int, len(recs))
+	attached = make([]bool, len(recs))
+	first := make(map[string]int)
+	for i, r := range recs {
+		next[i] = -1
+		if r.Flags&sam.Paired == 0 || r.Name == "" {
+			continue
+		}
+		j, ok := first[r.Name]
+		if !ok {
+			first[r.Name] = i
+			continue
+		}
+		delete(first, r.Name)
+		if isMate(recs[j], r) {
+			next[j] = i
+			attached[i] = true
+		}
+	}
+	return next, attached
+}
+
+// isMate returns whether a and b, held in the same slice, are mates
+// that can be linked.
+func isMate(a, b *sam.Record) bool {
+	if b.Flags&sam.Paired == 0 {
+		return false
+	}
+	for _, p := range [][2]*sam.Record{{a, b}, {b, a}} {
+		r, m := p[0], p[1]
+		if r.MateRef.ID() != m.Ref.ID() || r.MatePos != m.Pos {
+			return false
+		}
+		if (r.Flags&sam.MateUnmapped != 0) != (m.Flags&sam.Unmapped != 0) {
+			return false
+		}
+		if (r.Flags&sam.MateReverse != 0) != (m.Flags&sam.Reverse != 0) {
+			return false
+		}
+	}
+	tlen, ok := templateLengths([]*sam.Record{a, b})
+	if !ok {
+		return a.TempLen == 0 && b.TempLen == 0
+	}
+	return a.TempLen == tlen[0] && b.TempLen == tlen[1]
+}
+
+// record encodes r into s, returning the read length. The delta to
+// the next record of the template is given by nf, and attached
+// indicates that r is linked from an earlier record of the slice.
+func (e *containerEncoder) record(s *sliceEncoder, r *sam.Record, nf int, attached bool, pos *int) (int, error) {
+	opts := &e.w.opts
+	mapped := r.Flags&sam.Unmapped == 0
+	seq := r.Seq.Expand()
+	var cf int
+	length := len(seq)
+	if mapped {
+		_, n := r.Cigar.Lengths()
+		if length == 0 {
+			length = n
+		} else if len(r.Cigar) != 0 && n != length {
+			return 0, errors.New("cram: sequence length does not match CIGAR")
+		}
+	}
+	if len(seq) == 0 {
+		cf |= unknownBasesFlag
+	}
+	quals := r.Qual
+	if len(quals) != len(seq) || len(seq) == 0 || opts.DiscardQuality || allMissing(quals) {
+		quals = nil
+	} else {
+		if opts.QualityBinning != nil {
+			q := make([]byte, len(quals))
+			for i, v := range quals {
+				q[i] = opts.QualityBinning(v)
+			}
+			quals = q
+		}
+		cf |= qualArrayFlag
+	}
+
+	aux := r.AuxFields
+	if r.RawAux != nil {
+		// Decode raw aux data without
+		// modifying the caller's record.
+		c := *r
+		err := c.DecodeAux()
+		if err != nil {
+			return 0, err
+		}
+		aux = c.AuxFields
+	}
+	rg := int32(-1)
+	if n := len(aux); n != 0 && aux[n-1].Tag() == sam.NewTag("RG") && aux[n-1].Type() == 'Z' {
+		if id, ok := e.w.rgs[string(aux[n-1][3:])]; ok {
+			rg = id
+			aux = aux[:n-1]
+		}
+	}
+
+	detached := !attached && nf < 0 && (r.Flags&sam.Paired != 0 || r.MateRef != nil || r.MatePos != -1 || r.TempLen != 0)
+	switch {
+	case detached:
+		cf |= detachedFlag
+	case nf >= 0:
+		cf |= mateDownFlag
+	}
+
+	flags := r.Flags
+	if attached || nf >= 0 {
+		// Mate flags are reconstructed from the
+		// linked mate.
+		flags &^= sam.MateUnmapped | sam.MateReverse
+	}
+	s.int("BF", int(flags))
+	s.int("CF", cf)
+	s.int("RL", length)
+	s.int("AP", r.Pos+1-*pos)
+	*pos = r.Pos + 1
+	s.int("RG", int(rg))
+	name := []byte(r.Name)
+	if !opts.LossyNames {
+		s.array("RN", name)
+	}
+	switch {
+	case detached:
+		var mf int
+		if r.Flags&sam.MateReverse != 0 {
+			mf |= mateReverseFlag
+		}
+		if r.Flags&sam.MateUnmapped != 0 {
+			mf |= mateUnmappedFlag
+		}
+		s.int("MF", mf)
+		if opts.LossyNames {
+			s.array("RN", name)
+		}
+		s.int("NS", r.MateRef.ID())
+		s.int("NP", r.MatePos+1)
+		s.int("TS", r.TempLen)
+	case nf >= 0:
+		s.int("NF", nf)
+	}
+
+	var (
+		line []byte
+		tags [][3]byte
+	)
+	for _, a := range aux {
+		t := [3]byte{a[0], a[1], a[2]}
+		line = append(line, t[:]...)
+		tags = append(tags, t)
+	}
+	tl, ok := e.td[string(line)]
+	if !ok {
+		tl = len(e.tagDict)
+		e.td[string(line)] = tl
+		e.tagDict = append(e.tagDict, tags)
+	}
+	s.int("TL", tl)
+	for _, a := range aux {
+		k := int32(a[0])<<16 | int32(a[1])<<8 | int32(a[2])
+		e.tags[k] = true
+		v := a[3:]
+		if a[2] == 'Z' || a[2] == 'H' {
+			v = append(v[:len(v):len(v)], 0)
+		}
+		s.tag(k, v)
+	}
+
+	if mapped {
+		feats := e.features(s, r, seq, quals)
+		s.int("FN", len(feats))
+		var fpos int
+		for _, f := range feats {
+			s.byte("FC", f.code)
+			s.int("FP", f.pos-fpos)
+			fpos = f.pos
+			switch f.code {
+			case 'B':
+				s.byte("BA", f.base)
+				s.byte("QS", f.qual)
+			case 'X':
+				s.byte("BS", f.base)
+			case 'I':
+				s.array("IN", f.data)
+			case 'S':
+				s.array("SC", f.data)
+			case 'b':
+				s.array("BB", f.data)
+			case 'D':
+				s.int("DL", f.n)
+			case 'N':
+				s.int("RS", f.n)
+			case 'P':
+				s.int("PD", f.n)
+			case 'H':
+				s.int("HC", f.n)
+			}
+		}
+		s.int("MQ", int(r.MapQ))
+	} else if len(seq) != 0 {
+		s.bytes("BA", seq)
+	}
+	if quals != nil {
+		s.bytes("QS", quals)
+	}
+	return length, nil
+}
+
+// allMissing returns whether all the quality scores in q are missing.
+func allMissing(q []byte) bool {
+	for _, v := range q {
+		if v != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// features returns the read features describing the alignment of the
+// mapped record r with the sequence seq and quality scores quals.
+func (e *containerEncoder) features(s *sliceEncoder, r *sam.Record, seq, quals []byte) []feature {
+	var (
+		feats  []feature
+		rpos   = 0
+		refPos = r.Pos
+	)
+	bases := func(n int) []byte {
+		if len(seq) == 0 {
+			return bytes.Repeat([]byte{'N'}, n)
+		}
+		return seq[rpos : rpos+n]
+	}
+	for _, co := range r.Cigar {
+		n := co.Len()
+		if n == 0 {
+			continue
+		}
+		switch t := co.Type(); t {
+		case sam.CigarMatch, sam.CigarEqual, sam.CigarMismatch:
+			switch {
+			case len(seq) == 0:
+			case e.noRef:
+				feats = append(feats, feature{code: 'b', pos: rpos + 1, data: seq[rpos : rpos+n]})
+			default:
+				for i := 0; i < n; i++ {
+					b := seq[rpos+i]
+					rb := s.base(refPos + i)
+					if b == rb || b == '=' {
+						continue
+					}
+					f := feature{pos: rpos + i + 1}
+					if code, ok := substitution(rb, b); ok {
+						f.code = 'X'
+						f.base = code
+					} else {
+						f.code = 'B'
+						f.base = b
+						f.qual = 0xff
+						if quals != nil {
+							f.qual = quals[rpos+i]
+						}
+					}
+					feats = append(feats, f)
+				}
+			}
+			rpos += n
+			refPos += n
+		case sam.CigarInsertion:
+			feats = append(feats, feature{code: 'I', pos: rpos + 1, data: bases(n)})
+			rpos += n
+		case sam.CigarSoftClipped:
+			feats = append(feats, feature{code: 'S', pos: rpos + 1, data: bases(n)})
+			rpos += n
+		case sam.CigarDeletion:
+			feats = append(feats, feature{code: 'D', pos: rpos + 1, n: n})
+			refPos += n
+		case sam.CigarSkipped:
+			feats = append(feats, feature{code: 'N', pos: rpos + 1, n: n})
+			refPos += n
+		case sam.CigarPadded:
+			feats = append(feats, feature{code: 'P', pos: rpos + 1, n: n})
+		case sam.CigarHardClipped:
+			feats = append(feats, feature{code: 'H', pos: rpos + 1, n: n})
+		}
+	}
+	return feats
+}
+
+// substitution returns the substitution code for the base b at a
+// position with the reference base ref, using the default substitution
+// matrix.
+func substitution(ref, b byte) (byte, bool) {
+	for code, s := range substitutes(baseIndex(ref), 0x1b) {
+		if s == b {
+			return byte(code), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,485 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cram
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+const writerTestHeader = "@HD\tVN:1.6\tSO:coordinate\n" +
+	"@SQ\tSN:chr1\tLN:2000\n" +
+	"@SQ\tSN:chr2\tLN:1500\n" +
+	"@RG\tID:grp1\tSM:sample\n" +
+	"@RG\tID:grp2\tSM:sample\n"
+
+// randomReference returns a random reference sequence of length n
+// with lower case and ambiguous bases.
+func randomReference(rnd *rand.Rand, n int) string {
+	const alphabet = "ACGTACGTACGTacgtNR"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[rnd.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// randomCigar returns a random CIGAR without adjacent operations of
+// the same type.
+func randomCigar(rnd *rand.Rand) sam.Cigar {
+	var c sam.Cigar
+	if rnd.Intn(5) == 0 {
+		c = append(c, sam.NewCigarOp(sam.CigarHardClipped, 1+rnd.Intn(5)))
+	}
+	if rnd.Intn(4) == 0 {
+		c = append(c, sam.NewCigarOp(sam.CigarSoftClipped, 1+rnd.Intn(5)))
+	}
+	c = append(c, sam.NewCigarOp(sam.CigarMatch, 1+rnd.Intn(30)))
+	for n := rnd.Intn(4); n > 0; n-- {
+		t := []sam.CigarOpType{sam.CigarInsertion, sam.CigarDeletion, sam.CigarSkipped, sam.CigarPadded}[rnd.Intn(4)]
+		c = append(c, sam.NewCigarOp(t, 1+rnd.Intn(5)))
+		c = append(c, sam.NewCigarOp(sam.CigarMatch, 1+rnd.Intn(30)))
+	}
+	if rnd.Intn(4) == 0 {
+		c = append(c, sam.NewCigarOp(sam.CigarSoftClipped, 1+rnd.Intn(5)))
+	}
+	if rnd.Intn(5) == 0 {
+		c = append(c, sam.NewCigarOp(sam.CigarHardClipped, 1+rnd.Intn(5)))
+	}
+	return c
+}
+
+// randomBases returns the read bases for a record aligned to ref with
+// the given CIGAR, including random substitutions.
+func randomBases(rnd *rand.Rand, ref string, pos int, cigar sam.Cigar) []byte {
+	var seq []byte
+	for _, co := range cigar {
+		n := co.Len()
+		switch co.Type() {
+		case sam.CigarMatch:
+			for i := 0; i < n; i++ {
+				b := byte('N')
+				if pos+i < len(ref) {
+					b = upper(ref[pos+i])
+				}
+				if rnd.Intn(10) == 0 {
+					b = "ACGTN"[rnd.Intn(5)]
+				}
+				seq = append(seq, b)
+			}
+			pos += n
+		case sam.CigarInsertion, sam.CigarSoftClipped:
+			for i := 0; i < n; i++ {
+				seq = append(seq, "ACGTN"[rnd.Intn(5)])
+			}
+		case sam.CigarDeletion, sam.CigarSkipped:
+			pos += n
+		}
+	}
+	return seq
+}
+
+// randomRecords returns a coordinate sorted set of random records
+// described by h with reference sequence from refs.
+func randomRecords(t *testing.T, rnd *rand.Rand, h *sam.Header, refs testRef, n int) []*sam.Record {
+	rgs := h.RGs()
+	aux := func(r *sam.Record) {
+		for _, f := range []struct {
+			tag string
+			val interface{}
+		}{
+			{"NM", rnd.Intn(1000)},
+			{"XZ", "value"},
+			{"XB", []int16{1, -2, 3}},
+			{"XA", sam.ASCII('a')},
+		} {
+			if rnd.Intn(2) == 0 {
+				continue
+			}
+			a, err := sam.NewAux(sam.NewTag(f.tag), f.val)
+			if err != nil {
+				t.Fatalf("failed to create aux: %v", err)
+			}
+			r.AuxFields = append(r.AuxFields, a)
+		}
+		if rnd.Intn(4) != 0 {
+			a, _ := sam.NewAux(sam.NewTag("RG"), rgs[rnd.Intn(len(rgs))].Name())
+			if rnd.Intn(4) == 0 {
+				r.AuxFields = append(sam.AuxFields{a}, r.AuxFields...)
+			} else {
+				r.AuxFields = append(r.AuxFields, a)
+			}
+		}
+	}
+	read := func(name string, ref *sam.Reference, pos int, flags sam.Flags) *sam.Record {
+		r := &sam.Record{Name: name, Ref: ref, Pos: pos, Flags: flags, MatePos: -1}
+		if flags&sam.Unmapped == 0 {
+			r.MapQ = byte(rnd.Intn(60))
+			r.Cigar = randomCigar(rnd)
+		}
+		if rnd.Intn(20) != 0 {
+			var seq []byte
+			if flags&sam.Unmapped == 0 {
+				seq = randomBases(rnd, refs[ref.Name()], pos, r.Cigar)
+			} else {
+				seq = randomBases(rnd, "", 0, sam.Cigar{sam.NewCigarOp(sam.CigarInsertion, 1+rnd.Intn(30))})
+			}
+			r.Seq = sam.NewSeq(seq)
+			r.Qual = make([]byte, len(seq))
+			missing := rnd.Intn(5) == 0
+			for i := range r.Qual {
+				if missing {
+					r.Qual[i] = 0xff
+				} else {
+					r.Qual[i] = byte(rnd.Intn(42))
+				}
+			}
+		}
+		aux(r)
+		return r
+	}
+	mate := func(a, b *sam.Record) {
+		a.Flags |= sam.Paired | sam.Read1
+		b.Flags |= sam.Paired | sam.Read2
+		for _, p := range [][2]*sam.Record{{a, b}, {b, a}} {
+			r, m := p[0], p[1]
+			r.MateRef = m.Ref
+			r.MatePos = m.Pos
+			if m.Flags&sam.Unmapped != 0 {
+				r.Flags |= sam.MateUnmapped
+			}
+			if m.Flags&sam.Reverse != 0 {
+				r.Flags |= sam.MateReverse
+			}
+		}
+		if tlen, ok := templateLengths([]*sam.Record{a, b}); ok {
+			a.TempLen, b.TempLen = tlen[0], tlen[1]
+		}
+	}
+
+	var recs []*sam.Record
+	hr := h.Refs()
+	for i := 0; i < n; i++ {
+		name := "read" + string(rune('a'+i%26)) + string(rune('a'+i/26%26)) + string(rune('a'+i/676))
+		ref := hr[rnd.Intn(len(hr))]
+		pos := rnd.Intn(ref.Len() - 100)
+		var flags sam.Flags
+		if rnd.Intn(2) == 0 {
+			flags |= sam.Reverse
+		}
+		a := read(name, ref, pos, flags)
+		recs = append(recs, a)
+		switch rnd.Intn(6) {
+		case 0:
+			// Unpaired.
+		case 1:
+			// Mate unmapped and placed.
+			b := read(name, ref, pos, sam.Unmapped)
+			mate(a, b)
+			recs = append(recs, b)
+		case 2:
+			// Mate on another reference.
+			a.Flags |= sam.Paired | sam.Read1
+			a.MateRef = hr[(ref.ID()+1)%len(hr)]
+			a.MatePos = rnd.Intn(1000)
+		case 3:
+			// Inconsistent template length.
+			b := read(name, ref, pos+rnd.Intn(100), sam.Reverse)
+			mate(a, b)
+			a.TempLen++
+			recs = append(recs, b)
+		default:
+			b := read(name, ref, pos+rnd.Intn(100), sam.Reverse)
+			mate(a, b)
+			recs = append(recs, b)
+		}
+	}
+	for i := 0; i < n/10; i++ {
+		recs = append(recs, read("unplaced"+string(rune('a'+i%26)), nil, -1, sam.Unmapped))
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		ri, rj := uint(recs[i].Ref.ID()), uint(recs[j].Ref.ID())
+		if ri != rj {
+			return ri < rj
+		}
+		return recs[i].Pos < recs[j].Pos
+	})
+	return recs
+}
+
+func writeCRAM(t *testing.T, h *sam.Header, recs []*sam.Record, opts WriterOptions) ([]byte, *Index) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h, opts)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, r := range recs {
+		err = w.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	return buf.Bytes(), w.Index()
+}
+
+func readRecords(t *testing.T, data []byte, ref ReferenceProvider) (*Reader, []*sam.Record) {
+	r, err := NewReader(bytes.NewReader(data), ref)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var recs []*sam.Record
+	for {
+		rec, err := r.Read()
+		if err != nil {
+			if err != io.EOF {
+				t.Fatalf("unexpected error reading records: %v", err)
+			}
+			return r, recs
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func newWriterTestData(t *testing.T) (*sam.Header, testRef, []*sam.Record) {
+	rnd := rand.New(rand.NewSource(1))
+	refs := testRef{
+		"chr1": randomReference(rnd, 2000),
+		"chr2": randomReference(rnd, 1500),
+	}
+	h, err := sam.NewHeader([]byte(writerTestHeader), nil)
+	if err != nil {
+		t.Fatalf("failed to parse header: %v", err)
+	}
+	return h, refs, randomRecords(t, rnd, h, refs, 500)
+}
+
+func samLines(t *testing.T, recs []*sam.Record) []string {
+	lines := make([]string, len(recs))
+	for i, r := range recs {
+		b, err := r.MarshalText()
+		if err != nil {
+			t.Fatalf("failed to marshal record: %v", err)
+		}
+		lines[i] = string(b)
+	}
+	return lines
+}
+
+func TestWriter(t *testing.T) {
+	h, refs, recs := newWriterTestData(t)
+	want := samLines(t, recs)
+	for _, test := range []struct {
+		name    string
+		opts    WriterOptions
+		ref     ReferenceProvider
+		version Version
+	}{
+		{name: "raw", opts: WriterOptions{Reference: refs}, ref: refs, version: Version{3, 0}},
+		{name: "gzip", opts: WriterOptions{Reference: refs, Method: Gzip}, ref: refs, version: Version{3, 0}},
+		{name: "rans4x8", opts: WriterOptions{Reference: refs, Method: Rans4x8}, ref: refs, version: Version{3, 0}},
+		{name: "ransNx16", opts: WriterOptions{Reference: refs, Method: RansNx16}, ref: refs, version: Version{3, 1}},
+		{name: "no reference", opts: WriterOptions{Method: Gzip}, version: Version{3, 0}},
+		{name: "embedded", opts: WriterOptions{Reference: refs, EmbedReference: true, Method: Gzip}, version: Version{3, 0}},
+		{
+			name: "series methods",
+			opts: WriterOptions{
+				Reference:    refs,
+				Method:       Gzip,
+				SeriesMethod: map[string]Method{"QS": RansNx16, "BA": Rans4x8, "RN": Raw},
+			},
+			ref:     refs,
+			version: Version{3, 1},
+		},
+		{
+			name:    "small slices",
+			opts:    WriterOptions{Reference: refs, Method: Rans4x8, RecordsPerSlice: 7, SlicesPerContainer: 3},
+			ref:     refs,
+			version: Version{3, 0},
+		},
+	} {
+		data, _ := writeCRAM(t, h, recs, test.opts)
+		r, got := readRecords(t, data, test.ref)
+		if r.Version() != test.version {
+			t.Errorf("%s: unexpected version: got:%v want:%v", test.name, r.Version(), test.version)
+		}
+		gotHeader, _ := r.Header().MarshalText()
+		wantHeader, _ := h.MarshalText()
+		if !bytes.Equal(gotHeader, wantHeader) {
+			t.Errorf("%s: unexpected header:\ngot: %q\nwant:%q", test.name, gotHeader, wantHeader)
+		}
+		gotLines := samLines(t, got)
+		if len(gotLines) != len(want) {
+			t.Errorf("%s: unexpected number of records: got:%d want:%d", test.name, len(gotLines), len(want))
+			continue
+		}
+		for i := range want {
+			if gotLines[i] != want[i] {
+				t.Errorf("%s: unexpected record %d:\ngot: %q\nwant:%q", test.name, i, gotLines[i], want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestWriterLossy(t *testing.T) {
+	h, refs, recs := newWriterTestData(t)
+	for _, test := range []struct {
+		name string
+		opts WriterOptions
+
+		// qual returns whether got is an acceptable
+		// quality score for the original score q.
+		qual func(got, q byte) bool
+	}{
+		{
+			name: "lossy names",
+			opts: WriterOptions{Reference: refs, LossyNames: true},
+			qual: func(got, q byte) bool { return got == q },
+		},
+		{
+			name: "discard quality",
+			opts: WriterOptions{Reference: refs, DiscardQuality: true},
+			qual: func(got, q byte) bool { return got == q || got == 0xff },
+		},
+		{
+			name: "binning",
+			opts: WriterOptions{Reference: refs, QualityBinning: Illumina8Binning},
+			qual: func(got, q byte) bool { return got == Illumina8Binning(q) },
+		},
+	} {
+		data, _ := writeCRAM(t, h, recs, test.opts)
+		_, got := readRecords(t, data, refs)
+		if len(got) != len(recs) {
+			t.Errorf("%s: unexpected number of records: got:%d want:%d", test.name, len(got), len(recs))
+			continue
+		}
+		names := make(map[string]string)
+		for i, r := range got {
+			want := *recs[i]
+			if len(r.Qual) != len(want.Qual) {
+				t.Errorf("%s: unexpected quality length for record %d: got:%d want:%d", test.name, i, len(r.Qual), len(want.Qual))
+				break
+			}
+			for j, q := range r.Qual {
+				if !test.qual(q, want.Qual[j]) {
+					t.Errorf("%s: unexpected quality for record %d at %d: got:%d original:%d", test.name, i, j, q, want.Qual[j])
+					break
+				}
+			}
+			r.Qual, want.Qual = nil, nil
+			if test.opts.LossyNames {
+				// Mates must share a name.
+				if n, ok := names[want.Name]; ok && n != r.Name && want.Flags&sam.Paired != 0 {
+					t.Errorf("%s: mate names differ for %q: %q != %q", test.name, want.Name, n, r.Name)
+				}
+				names[want.Name] = r.Name
+				r.Name, want.Name = "", ""
+			}
+			if g, w := samLines(t, []*sam.Record{r}), samLines(t, []*sam.Record{&want}); g[0] != w[0] {
+				t.Errorf("%s: unexpected record %d:\ngot: %q\nwant:%q", test.name, i, g[0], w[0])
+				break
+			}
+		}
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	h, refs, _ := newWriterTestData(t)
+	for _, test := range []struct {
+		name string
+		opts WriterOptions
+		err  string
+	}{
+		{name: "bzip2", opts: WriterOptions{Method: Bzip2}, err: "cram: unsupported block compression method: bzip2"},
+		{name: "series lzma", opts: WriterOptions{SeriesMethod: map[string]Method{"QS": LZMA}}, err: "cram: unsupported block compression method: lzma"},
+		{name: "unknown series", opts: WriterOptions{SeriesMethod: map[string]Method{"XX": Gzip}}, err: `cram: unknown data series: "XX"`},
+		{name: "embed without reference", opts: WriterOptions{EmbedReference: true}, err: "cram: embedded reference requires a reference provider"},
+		{name: "negative slice size", opts: WriterOptions{Reference: refs, RecordsPerSlice: -1}, err: "cram: invalid slice size"},
+	} {
+		_, err := NewWriter(&bytes.Buffer{}, h, test.opts)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: unexpected error: got:%v want:%s", test.name, err, test.err)
+		}
+	}
+}
+
+func TestWriterIndex(t *testing.T) {
+	h, refs, recs := newWriterTestData(t)
+	data, idx := writeCRAM(t, h, recs, WriterOptions{Reference: refs, Method: Gzip, RecordsPerSlice: 20, SlicesPerContainer: 2})
+
+	var buf bytes.Buffer
+	err := WriteIndex(&buf, idx)
+	if err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+	got, err := ReadIndex(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error reading index: %v", err)
+	}
+	if !reflect.DeepEqual(got, idx) {
+		t.Errorf("index did not round trip:\ngot: %+v\nwant:%+v", got, idx)
+	}
+
+	_, all := readRecords(t, data, refs)
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < 20; i++ {
+		cr, err := NewReader(bytes.NewReader(data), refs)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		var (
+			ref      *sam.Reference
+			beg, end int
+		)
+		if i != 0 {
+			hrefs := cr.Header().Refs()
+			ref = hrefs[rnd.Intn(len(hrefs))]
+			beg = rnd.Intn(ref.Len())
+			end = beg + rnd.Intn(200)
+		}
+		var want []string
+		for _, rec := range all {
+			var ok bool
+			switch {
+			case ref == nil:
+				ok = rec.Ref == nil
+			case rec.Ref.ID() != ref.ID():
+			case rec.Flags&sam.Unmapped != 0:
+				ok = beg <= rec.Pos && rec.Pos < end
+			default:
+				ok = rec.Pos < end && rec.End() > beg
+			}
+			if ok {
+				want = append(want, samLines(t, []*sam.Record{rec})[0])
+			}
+		}
+
+		it, err := NewIterator(cr, idx, ref, beg, end)
+		if err != nil {
+			t.Fatalf("unexpected error creating iterator: %v", err)
+		}
+		var gotLines []string
+		for it.Next() {
+			gotLines = append(gotLines, samLines(t, []*sam.Record{it.Record()})[0])
+		}
+		err = it.Close()
+		if err != nil {
+			t.Errorf("unexpected error iterating %s:%d-%d: %v", ref.Name(), beg, end, err)
+		}
+		if !reflect.DeepEqual(gotLines, want) {
+			t.Errorf("unexpected records for %s:%d-%d:\ngot: %q\nwant:%q", ref.Name(), beg, end, gotLines, want)
+		}
+	}
+}
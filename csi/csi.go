@@ -11,6 +11,7 @@ import (
 
 	"github.com/Schaudge/hts/bgzf"
 	"github.com/Schaudge/hts/bgzf/index"
+	"github.com/Schaudge/hts/internal"
 )
 
 var csiMagic = [3]byte{'C', 'S', 'I'}
@@ -291,18 +292,7 @@ func (c byBeginOffset) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
 
 // calculate bin given an alignment covering [beg,end) (zero-based, half-close-half-open)
 func reg2bin(beg, end int64, minShift, depth uint32) uint32 {
-	end--
-	s := minShift
-	t := uint32(((1 << (depth * nextBinShift)) - 1) / 7)
-	for level := depth; level > 0; level-- {
-		offset := beg >> s
-		if offset == end>>s {
-			return t + uint32(offset)
-		}
-		s += nextBinShift
-		t -= 1 << (level * nextBinShift)
-	}
-	return 0
+	return internal.BinForDepth(beg, end, minShift, depth)
 }
 
 // calculate the list of bins that may overlap with region [beg,end) (zero-based)
@@ -0,0 +1,105 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csi
+
+import (
+	"errors"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/index"
+)
+
+// Merge returns a CSI index for the file formed by BGZF concatenation
+// of the files indexed by idxs, without reading the concatenated file.
+// The value of offsets[i] is added to the compressed file offsets held
+// by idxs[i]; when whole files are concatenated it is the byte offset at
+// which the i-th file begins in the concatenated file. If the header
+// blocks of a file are removed before concatenation, their compressed
+// length must be subtracted from its offset. Indexes must be given in
+// concatenation order, must share the same minimum shift and depth and
+// the resulting file must be coordinate sorted.
+func Merge(idxs []*Index, offsets []int64) (*Index, error) {
+	if len(idxs) != len(offsets) {
+		return nil, errors.New("csi: mismatched index and offset counts")
+	}
+	if len(idxs) == 0 {
+		return nil, errors.New("csi: no index to merge")
+	}
+	var (
+		m   *Index
+		pos []map[uint32]int
+	)
+	for k, idx := range idxs {
+		if idx == nil {
+			return nil, errors.New("csi: nil index")
+		}
+		if k != 0 && offsets[k] < offsets[k-1] {
+			return nil, errors.New("csi: offsets out of order")
+		}
+		if m == nil {
+			m = &Index{Auxilliary: idx.Auxilliary, Version: idx.Version, minShift: idx.minShift, depth: idx.depth}
+		} else if idx.minShift != m.minShift || idx.depth != m.depth {
+			return nil, errors.New("csi: mismatched index parameters")
+		}
+		shift := offsets[k]
+		if idx.unmapped != nil {
+			if m.unmapped == nil {
+				m.unmapped = new(uint64)
+			}
+			*m.unmapped += *idx.unmapped
+		}
+		if len(idx.refs) > len(m.refs) {
+			refs := make([]refIndex, len(idx.refs))
+			copy(refs, m.refs)
+			m.refs = refs
+			for len(pos) < len(refs) {
+				pos = append(pos, make(map[uint32]int))
+			}
+		}
+		for rid, src := range idx.refs {
+			dst := &m.refs[rid]
+			for _, b := range src.bins {
+				chunks := make([]bgzf.Chunk, len(b.chunks))
+				for j, c := range b.chunks {
+					chunks[j] = shiftChunk(c, shift)
+				}
+				// The left offset of an existing bin is
+				// retained since it precedes that of b.
+				if i, ok := pos[rid][b.bin]; ok {
+					dst.bins[i].records += b.records
+					dst.bins[i].chunks = append(dst.bins[i].chunks, chunks...)
+					continue
+				}
+				pos[rid][b.bin] = len(dst.bins)
+				dst.bins = append(dst.bins, bin{
+					bin:     b.bin,
+					left:    shiftOffset(b.left, shift),
+					records: b.records,
+					chunks:  chunks,
+				})
+			}
+			if src.stats != nil {
+				if dst.stats == nil {
+					dst.stats = &index.ReferenceStats{Chunk: shiftChunk(src.stats.Chunk, shift)}
+				} else {
+					dst.stats.Chunk.End = shiftOffset(src.stats.Chunk.End, shift)
+				}
+				dst.stats.Mapped += src.stats.Mapped
+				dst.stats.Unmapped += src.stats.Unmapped
+			}
+		}
+	}
+	m.sort()
+	return m, nil
+}
+
+func shiftOffset(o bgzf.Offset, shift int64) bgzf.Offset {
+	o.File += shift
+	return o
+}
+
+func shiftChunk(c bgzf.Chunk, shift int64) bgzf.Chunk {
+	return bgzf.Chunk{Begin: shiftOffset(c.Begin, shift), End: shiftOffset(c.End, shift)}
+}
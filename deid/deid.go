@@ -0,0 +1,292 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package deid implements de-identification of alignment streams so
+// that they can be shared for support cases and public benchmarks.
+//
+// Read names are replaced by opaque hashes that are stable for a given
+// key, so that mates and other records of a template remain linked and
+// the same read is given the same name in separately de-identified
+// files. Aux fields are removed or retained by tag, and the bases and
+// base qualities aligned within configured regions may be masked.
+package deid
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+// NameLength is the length of de-identified read names.
+const NameLength = 32
+
+var (
+	idTag = sam.NewTag("ID")
+	plTag = sam.NewTag("PL")
+)
+
+// Options specifies the behaviour of a Transform.
+type Options struct {
+	// Key is the secret key used to hash
+	// read names with HMAC-SHA256. Names
+	// hashed with the same key are given the
+	// same hash. If Key is empty, names are
+	// hashed with unkeyed SHA-256, which may
+	// be reversed by hashing candidate names.
+	Key []byte
+
+	// Keep, if not nil, holds the tags of the
+	// aux fields retained by the Transform.
+	// All other aux fields are removed.
+	Keep []sam.Tag
+
+	// Strip holds the tags of aux fields that
+	// are removed by the Transform.
+	Strip []sam.Tag
+
+	// Mask holds the regions in which aligned
+	// bases are masked. Bases aligned to a
+	// reference position within a region, and
+	// bases inserted between two such
+	// positions, are masked. Soft clipped
+	// bases and the bases of unmapped records
+	// are not masked.
+	Mask *bed.Set
+
+	// MaskSeq and MaskQual specify that
+	// masked bases are replaced with N and
+	// that the qualities of masked bases are
+	// set to zero.
+	MaskSeq  bool
+	MaskQual bool
+}
+
+// Transform de-identifies alignment records. A Transform is not safe
+// for concurrent use.
+type Transform struct {
+	opts  Options
+	keep  map[sam.Tag]bool
+	strip map[sam.Tag]bool
+	hash  hash.Hash
+	sum   []byte
+}
+
+// NewTransform returns a new Transform using the given options.
+func NewTransform(opts Options) *Transform {
+	t := &Transform{opts: opts}
+	if opts.Keep != nil {
+		t.keep = make(map[sam.Tag]bool, len(opts.Keep))
+		for _, tag := range opts.Keep {
+			t.keep[tag] = true
+		}
+	}
+	if len(opts.Strip) != 0 {
+		t.strip = make(map[sam.Tag]bool, len(opts.Strip))
+		for _, tag := range opts.Strip {
+			t.strip[tag] = true
+		}
+	}
+	if len(opts.Key) != 0 {
+		t.hash = hmac.New(sha256.New, opts.Key)
+	} else {
+		t.hash = sha256.New()
+	}
+	return t
+}
+
+// Name returns the de-identified form of the read name, a string of
+// NameLength hexadecimal digits. The empty name is returned unaltered.
+func (t *Transform) Name(name string) string {
+	if name == "" {
+		return ""
+	}
+	t.hash.Reset()
+	t.hash.Write([]byte(name))
+	t.sum = t.hash.Sum(t.sum[:0])
+	return hex.EncodeToString(t.sum[:NameLength/2])
+}
+
+// Header returns a de-identified copy of h. Comments and programs are
+// removed, and read groups are reduced to their ID and platform. Read
+// group IDs are retained since they are referred to by the RG aux
+// fields of records.
+func (t *Transform) Header(h *sam.Header) (*sam.Header, error) {
+	c := h.Clone()
+	c.Comments = nil
+	progs := c.Progs()
+	for i := len(progs) - 1; i >= 0; i-- {
+		err := c.RemoveProgram(progs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, rg := range c.RGs() {
+		var tags []sam.Tag
+		rg.Tags(func(tag sam.Tag, _ string) {
+			if tag != idTag && tag != plTag {
+				tags = append(tags, tag)
+			}
+		})
+		for _, tag := range tags {
+			err := rg.Set(tag, "")
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
+
+// Apply de-identifies r in place.
+func (t *Transform) Apply(r *sam.Record) error {
+	r.Name = t.Name(r.Name)
+	if t.keep != nil || t.strip != nil {
+		err := r.DecodeAux()
+		if err != nil {
+			return err
+		}
+		aux := r.AuxFields[:0]
+		for _, a := range r.AuxFields {
+			tag := a.Tag()
+			if t.strip[tag] || (t.keep != nil && !t.keep[tag]) {
+				continue
+			}
+			aux = append(aux, a)
+		}
+		r.AuxFields = aux
+	}
+	if t.opts.Mask != nil && (t.opts.MaskSeq || t.opts.MaskQual) {
+		t.mask(r)
+	}
+	return nil
+}
+
+// mask masks the bases of r aligned within the mask regions.
+func (t *Transform) mask(r *sam.Record) {
+	if r.Flags&sam.Unmapped != 0 || r.Ref == nil {
+		return
+	}
+	start, end := r.Pos, r.End()
+	features := t.opts.Mask.Overlapping(r.Ref.Name(), start, end)
+	if len(features) == 0 {
+		return
+	}
+	masked := make([]bool, end-start)
+	for _, f := range features {
+		for p := maxInt(f.Start, start); p < minInt(f.End, end); p++ {
+			masked[p-start] = true
+		}
+	}
+	inMask := func(p int) bool {
+		return start <= p && p < end && masked[p-start]
+	}
+
+	var seq []byte
+	if t.opts.MaskSeq {
+		seq = r.Seq.Expand()
+	}
+	qual := r.Qual
+	if !t.opts.MaskQual || (len(qual) != 0 && qual[0] == 0xff) {
+		qual = nil
+	}
+	maskBase := func(i int) {
+		if i < len(seq) {
+			seq[i] = 'N'
+		}
+		if i < len(qual) {
+			qual[i] = 0
+		}
+	}
+
+	q, p := 0, start
+	for _, co := range r.Cigar {
+		typ, n := co.Type(), co.Len()
+		con := typ.Consumes()
+		switch {
+		case con.Query != 0 && con.Reference > 0:
+			for k := 0; k < n; k++ {
+				if inMask(p + k) {
+					maskBase(q + k)
+				}
+			}
+		case typ == sam.CigarInsertion:
+			if inMask(p-1) && inMask(p) {
+				for k := 0; k < n; k++ {
+					maskBase(q + k)
+				}
+			}
+		}
+		q += n * con.Query
+		p += n * con.Reference
+	}
+	if seq != nil {
+		r.Seq = sam.NewSeq(seq)
+	}
+}
+
+// Source is a source of alignment records and their header.
+type Source interface {
+	Header() *sam.Header
+	Read() (*sam.Record, error)
+}
+
+// Reader is a de-identifying record reader. It returns the records of
+// its Source in order, de-identified by a Transform.
+type Reader struct {
+	src Source
+	h   *sam.Header
+	t   *Transform
+}
+
+// NewReader returns a Reader de-identifying the records and header of
+// src with t.
+func NewReader(src Source, t *Transform) (*Reader, error) {
+	h, err := t.Header(src.Header())
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{src: src, h: h, t: t}, nil
+}
+
+// Header returns the de-identified header of the Reader.
+func (r *Reader) Header() *sam.Header { return r.h }
+
+// Read returns the next de-identified record. The references of the
+// record are replaced by those of the Reader's header.
+func (r *Reader) Read() (*sam.Record, error) {
+	rec, err := r.src.Read()
+	if err != nil {
+		return nil, err
+	}
+	refs := r.h.Refs()
+	if id := rec.Ref.ID(); id >= 0 {
+		rec.Ref = refs[id]
+	}
+	if id := rec.MateRef.ID(); id >= 0 {
+		rec.MateRef = refs[id]
+	}
+	err = r.t.Apply(rec)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
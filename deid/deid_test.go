@@ -0,0 +1,304 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package deid
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestName(t *testing.T) {
+	a := NewTransform(Options{Key: []byte("secret")})
+	b := NewTransform(Options{Key: []byte("secret")})
+	c := NewTransform(Options{Key: []byte("other")})
+	u := NewTransform(Options{})
+
+	name := a.Name("read1")
+	if len(name) != NameLength {
+		t.Errorf("unexpected name length: got:%d want:%d", len(name), NameLength)
+	}
+	if got := b.Name("read1"); got != name {
+		t.Errorf("unstable name for same key: got:%s want:%s", got, name)
+	}
+	if got := a.Name("read1"); got != name {
+		t.Errorf("unstable name for repeated hash: got:%s want:%s", got, name)
+	}
+	if got := a.Name("read2"); got == name {
+		t.Errorf("unexpected name collision: %s", got)
+	}
+	if got := c.Name("read1"); got == name {
+		t.Errorf("unexpected name match for different key: %s", got)
+	}
+	if got := u.Name("read1"); got == name || len(got) != NameLength {
+		t.Errorf("unexpected unkeyed name: %s", got)
+	}
+	if got := a.Name(""); got != "" {
+		t.Errorf("unexpected name for empty name: %q", got)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	rg, err := sam.NewReadGroup("rg1", "center", "", "lib1", "", "ILLUMINA", "unit1", "patient", "", "", time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error creating read group: %v", err)
+	}
+	err = h.AddReadGroup(rg)
+	if err != nil {
+		t.Fatalf("unexpected error adding read group: %v", err)
+	}
+	err = h.AddProgram(sam.NewProgram("bwa", "bwa", "bwa mem /home/user/patient.fq", "", "0.7"))
+	if err != nil {
+		t.Fatalf("unexpected error adding program: %v", err)
+	}
+	h.Comments = []string{"patient sample"}
+
+	got, err := NewTransform(Options{}).Header(h)
+	if err != nil {
+		t.Fatalf("unexpected error de-identifying header: %v", err)
+	}
+	if len(got.Progs()) != 0 || len(got.Comments) != 0 {
+		t.Errorf("unexpected programs or comments: %v %v", got.Progs(), got.Comments)
+	}
+	if len(got.RGs()) != 1 {
+		t.Fatalf("unexpected read groups: %v", got.RGs())
+	}
+	if got, want := got.RGs()[0].String(), "@RG\tID:rg1\tPL:ILLUMINA"; got != want {
+		t.Errorf("unexpected read group: got:%q want:%q", got, want)
+	}
+	if len(h.Progs()) != 1 || len(h.Comments) != 1 || h.RGs()[0].Get(sam.NewTag("SM")) != "patient" {
+		t.Error("original header altered")
+	}
+	if got.Refs()[0].Name() != "chr1" {
+		t.Errorf("unexpected references: %v", got.Refs())
+	}
+}
+
+func TestApply(t *testing.T) {
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	_, err = sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	mask := bed.NewSet([]*bed.Feature{{Chrom: "chr1", Start: 10, End: 20}})
+	aux := func(tag string, v interface{}) sam.Aux {
+		a, err := sam.NewAux(sam.NewTag(tag), v)
+		if err != nil {
+			t.Fatalf("unexpected error creating aux: %v", err)
+		}
+		return a
+	}
+	cigar := func(s string) []sam.CigarOp {
+		c, err := sam.ParseCigar([]byte(s))
+		if err != nil {
+			t.Fatalf("unexpected error parsing cigar: %v", err)
+		}
+		return c
+	}
+	qual := func(n int) []byte {
+		q := make([]byte, n)
+		for i := range q {
+			q[i] = 30
+		}
+		return q
+	}
+
+	for _, test := range []struct {
+		name     string
+		pos      int
+		cigar    string
+		seq      string
+		unmapped bool
+		opts     Options
+		wantSeq  string
+		wantQual string
+		wantAux  []string
+	}{
+		{
+			name:     "strip",
+			pos:      5,
+			cigar:    "5M2I10M",
+			seq:      "ACGTACCACGTACGTAC",
+			opts:     Options{Strip: []sam.Tag{sam.NewTag("OQ"), sam.NewTag("XS")}},
+			wantSeq:  "ACGTACCACGTACGTAC",
+			wantQual: ".................",
+			wantAux:  []string{"RG", "NM"},
+		},
+		{
+			name:     "keep",
+			pos:      5,
+			cigar:    "5M2I10M",
+			seq:      "ACGTACCACGTACGTAC",
+			opts:     Options{Keep: []sam.Tag{sam.NewTag("RG"), sam.NewTag("NM")}, Strip: []sam.Tag{sam.NewTag("NM")}},
+			wantSeq:  "ACGTACCACGTACGTAC",
+			wantQual: ".................",
+			wantAux:  []string{"RG"},
+		},
+		{
+			name:     "mask",
+			pos:      5,
+			cigar:    "5M2I10M",
+			seq:      "ACGTACCACGTACGTAC",
+			opts:     Options{Mask: mask, MaskSeq: true, MaskQual: true},
+			wantSeq:  "ACGTACCNNNNNNNNNN",
+			wantQual: ".......0000000000",
+			wantAux:  []string{"RG", "NM", "XS", "OQ"},
+		},
+		{
+			name:     "mask insertion",
+			pos:      12,
+			cigar:    "3M2I3M2S",
+			seq:      "ACGTACGTAC",
+			opts:     Options{Mask: mask, MaskSeq: true},
+			wantSeq:  "NNNNNNNNAC",
+			wantQual: "..........",
+			wantAux:  []string{"RG", "NM", "XS", "OQ"},
+		},
+		{
+			name:     "mask qualities",
+			pos:      18,
+			cigar:    "4M",
+			seq:      "ACGT",
+			opts:     Options{Mask: mask, MaskQual: true},
+			wantSeq:  "ACGT",
+			wantQual: "00..",
+			wantAux:  []string{"RG", "NM", "XS", "OQ"},
+		},
+		{
+			name:     "unmapped",
+			pos:      12,
+			cigar:    "*",
+			seq:      "ACGT",
+			unmapped: true,
+			opts:     Options{Mask: mask, MaskSeq: true, MaskQual: true},
+			wantSeq:  "ACGT",
+			wantQual: "....",
+			wantAux:  []string{"RG", "NM", "XS", "OQ"},
+		},
+	} {
+		r, err := sam.NewRecord("read1", chr1, nil, test.pos, -1, 0, 60, cigar(test.cigar), []byte(test.seq), qual(len(test.seq)), []sam.Aux{
+			aux("RG", "rg1"),
+			aux("NM", 1),
+			aux("XS", 10),
+			aux("OQ", "IIII"),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		if test.unmapped {
+			r.Flags |= sam.Unmapped
+		}
+		tr := NewTransform(test.opts)
+		err = tr.Apply(r)
+		if err != nil {
+			t.Fatalf("unexpected error applying transform for %s: %v", test.name, err)
+		}
+		if r.Name != tr.Name("read1") {
+			t.Errorf("unexpected name for %s: %s", test.name, r.Name)
+		}
+		if got := string(r.Seq.Expand()); got != test.wantSeq {
+			t.Errorf("unexpected sequence for %s: got:%s want:%s", test.name, got, test.wantSeq)
+		}
+		gotQual := make([]byte, len(r.Qual))
+		for i, q := range r.Qual {
+			switch q {
+			case 0:
+				gotQual[i] = '0'
+			case 30:
+				gotQual[i] = '.'
+			default:
+				gotQual[i] = '?'
+			}
+		}
+		if string(gotQual) != test.wantQual {
+			t.Errorf("unexpected qualities for %s: got:%s want:%s", test.name, gotQual, test.wantQual)
+		}
+		var gotAux []string
+		for _, a := range r.AuxFields {
+			gotAux = append(gotAux, a.Tag().String())
+		}
+		if !reflect.DeepEqual(gotAux, test.wantAux) {
+			t.Errorf("unexpected aux fields for %s: got:%v want:%v", test.name, gotAux, test.wantAux)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	h.Comments = []string{"private"}
+	var recs []*sam.Record
+	for _, name := range []string{"a", "a", "b"} {
+		r, err := sam.NewRecord(name, chr1, chr1, 10, 20, 14, 60,
+			[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}, []byte("ACGT"), nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		recs = append(recs, r)
+	}
+	tr := NewTransform(Options{Key: []byte("secret")})
+	r, err := NewReader(&source{h: h, recs: recs}, tr)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	if len(r.Header().Comments) != 0 {
+		t.Errorf("unexpected comments: %v", r.Header().Comments)
+	}
+	var names []string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+		if rec.Ref != r.Header().Refs()[0] || rec.MateRef != r.Header().Refs()[0] {
+			t.Errorf("record references not from reader header")
+		}
+		names = append(names, rec.Name)
+	}
+	want := []string{tr.Name("a"), tr.Name("a"), tr.Name("b")}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected names: got:%v want:%v", names, want)
+	}
+}
+
+type source struct {
+	h    *sam.Header
+	recs []*sam.Record
+}
+
+func (s *source) Header() *sam.Header { return s.h }
+
+func (s *source) Read() (*sam.Record, error) {
+	if len(s.recs) == 0 {
+		return nil, io.EOF
+	}
+	r := s.recs[0]
+	s.recs = s.recs[1:]
+	return r, nil
+}
@@ -0,0 +1,342 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package demux implements splitting of alignment records into
+// multiple BAM files by read group, cell barcode or other record key.
+package demux
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+	"github.com/klauspost/compress/gzip"
+)
+
+var (
+	ErrClosed = errors.New("demux: write to closed writer")
+	ErrNoPath = errors.New("demux: no path function")
+)
+
+// DefaultMaxOpen is the default maximum number of output files held
+// open by a Writer.
+const DefaultMaxOpen = 64
+
+// Key returns the output key of a record and whether the record has
+// a key.
+type Key func(*sam.Record) (key string, ok bool)
+
+// ByReadGroup returns the read group of a record.
+func ByReadGroup(r *sam.Record) (string, bool) {
+	return ByTag(sam.NewTag("RG"))(r)
+}
+
+// ByCell returns the corrected cell barcode of a record held in the CB
+// aux tag.
+func ByCell(r *sam.Record) (string, bool) {
+	return ByTag(sam.NewTag("CB"))(r)
+}
+
+// ByTag returns a Key returning the string value of the given aux tag.
+func ByTag(tag sam.Tag) Key {
+	return func(r *sam.Record) (string, bool) {
+		a := r.AuxTag(tag)
+		if a == nil {
+			return "", false
+		}
+		v, ok := a.Value().(string)
+		return v, ok && v != ""
+	}
+}
+
+// Options specifies the behaviour of a Writer.
+type Options struct {
+	// Key returns the output key of each
+	// record. If Key is nil, ByReadGroup is
+	// used.
+	Key Key
+
+	// Path returns the path of the output
+	// file for a key. Path must not be nil.
+	Path func(key string) string
+
+	// Default is the key used for records
+	// without a key. If Default is empty,
+	// records without a key are discarded.
+	Default string
+
+	// MaxOpen is the maximum number of output
+	// files held open. Least recently written
+	// files are closed and reopened for
+	// appending when needed. If MaxOpen is
+	// less than one, DefaultMaxOpen is used.
+	MaxOpen int
+
+	// Level is the compression level of the
+	// output files. If Level is zero, the
+	// default compression level is used.
+	Level int
+}
+
+// Writer writes records to BAM files chosen by record key.
+//
+// The header of each output is a copy of the input header. If the
+// input header has a read group with the same ID as the output key,
+// all other read groups are removed from the output header.
+type Writer struct {
+	h    *sam.Header
+	opts Options
+
+	outputs map[string]*output
+	lru     *list.List
+	buf     bytes.Buffer
+	closed  bool
+}
+
+// output is the state of an output file.
+type output struct {
+	key  string
+	path string
+	n    int
+
+	// f and bg are the open file and BGZF
+	// writer, and e is the output's LRU list
+	// element. They are nil when the file is
+	// closed.
+	f  *os.File
+	bg *bgzf.Writer
+	e  *list.Element
+}
+
+// NewWriter returns a Writer splitting records with the header h
+// according to opts.
+func NewWriter(h *sam.Header, opts Options) (*Writer, error) {
+	if opts.Path == nil {
+		return nil, ErrNoPath
+	}
+	if opts.Key == nil {
+		opts.Key = ByReadGroup
+	}
+	if opts.MaxOpen < 1 {
+		opts.MaxOpen = DefaultMaxOpen
+	}
+	if opts.Level == 0 {
+		opts.Level = gzip.DefaultCompression
+	}
+	return &Writer{
+		h:       h,
+		opts:    opts,
+		outputs: make(map[string]*output),
+		lru:     list.New(),
+	}, nil
+}
+
+// Write writes r to the output for its key.
+func (w *Writer) Write(r *sam.Record) error {
+	if w.closed {
+		return ErrClosed
+	}
+	key, ok := w.opts.Key(r)
+	if !ok {
+		if w.opts.Default == "" {
+			return nil
+		}
+		key = w.opts.Default
+	}
+	o, err := w.open(key)
+	if err != nil {
+		return err
+	}
+	w.buf.Reset()
+	err = bam.Marshal(r, &w.buf)
+	if err != nil {
+		return err
+	}
+	_, err = o.bg.Write(w.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	o.n++
+	return nil
+}
+
+// open returns the open output for key, creating the output file and
+// writing its header if it does not yet exist, and closing the least
+// recently used output if the open file limit is reached.
+func (w *Writer) open(key string) (*output, error) {
+	o, ok := w.outputs[key]
+	if ok && o.bg != nil {
+		w.lru.MoveToFront(o.e)
+		return o, nil
+	}
+	if w.lru.Len() >= w.opts.MaxOpen {
+		err := w.close(w.lru.Back().Value.(*output))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !ok {
+		o = &output{key: key, path: w.opts.Path(key)}
+		f, err := os.Create(o.path)
+		if err != nil {
+			return nil, err
+		}
+		o.f = f
+		o.bg, err = bgzf.NewWriterLevel(f, w.opts.Level, 1)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		h, err := w.header(key)
+		if err != nil {
+			w.close(o)
+			return nil, err
+		}
+		b, err := bam.MarshalHeader(h)
+		if err != nil {
+			w.close(o)
+			return nil, err
+		}
+		_, err = o.bg.Write(b)
+		if err != nil {
+			w.close(o)
+			return nil, err
+		}
+		w.outputs[key] = o
+	} else {
+		// Reopened files are extended with new
+		// BGZF blocks following the EOF marker
+		// block written on close, which is an
+		// empty block and so may be skipped
+		// by readers.
+		f, err := os.OpenFile(o.path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return nil, err
+		}
+		o.f = f
+		o.bg, err = bgzf.NewWriterLevel(f, w.opts.Level, 1)
+		if err != nil {
+			f.Close()
+			o.f = nil
+			return nil, err
+		}
+	}
+	o.e = w.lru.PushFront(o)
+	return o, nil
+}
+
+// close closes the file of the output o.
+func (w *Writer) close(o *output) error {
+	err := o.bg.Close()
+	cerr := o.f.Close()
+	if err == nil {
+		err = cerr
+	}
+	if o.e != nil {
+		w.lru.Remove(o.e)
+	}
+	o.f, o.bg, o.e = nil, nil, nil
+	return err
+}
+
+// header returns the output header for key.
+func (w *Writer) header(key string) (*sam.Header, error) {
+	h := w.h.Clone()
+	var found bool
+	for _, rg := range h.RGs() {
+		if rg.Name() == key {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return h, nil
+	}
+	for _, rg := range append([]*sam.ReadGroup(nil), h.RGs()...) {
+		if rg.Name() != key {
+			err := h.RemoveReadGroup(rg)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return h, nil
+}
+
+// Keys returns the keys of the outputs written by w in sorted order.
+func (w *Writer) Keys() []string {
+	keys := make([]string, 0, len(w.outputs))
+	for k := range w.outputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Count returns the number of records written to the output for key.
+func (w *Writer) Count(key string) int {
+	o, ok := w.outputs[key]
+	if !ok {
+		return 0
+	}
+	return o.n
+}
+
+// Close closes all the open output files.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	var err error
+	for w.lru.Len() != 0 {
+		cerr := w.close(w.lru.Back().Value.(*output))
+		if err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Source is a source of records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Split writes all the records read from src with the header h to
+// outputs chosen according to opts, and returns the keys of the
+// outputs written.
+func Split(src Source, h *sam.Header, opts Options) ([]string, error) {
+	w, err := NewWriter(h, opts)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		r, err := src.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			w.Close()
+			return nil, err
+		}
+		err = w.Write(r)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return w.Keys(), nil
+}
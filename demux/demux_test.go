@@ -0,0 +1,174 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demux
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestWriter(t *testing.T) {
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error making reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	for _, id := range []string{"rg0", "rg1", "rg2"} {
+		rg, err := sam.NewReadGroup(id, "", "", "lib-"+id, "", "", "", "", "", "", time.Time{}, 0)
+		if err != nil {
+			t.Fatalf("unexpected error making read group: %v", err)
+		}
+		err = h.AddReadGroup(rg)
+		if err != nil {
+			t.Fatalf("unexpected error adding read group: %v", err)
+		}
+	}
+
+	const n = 100
+	var recs []*sam.Record
+	for i := 0; i < n; i++ {
+		var aux []sam.Aux
+		if i%10 != 9 {
+			rg, err := sam.NewAux(sam.NewTag("RG"), fmt.Sprintf("rg%d", i%3))
+			if err != nil {
+				t.Fatalf("unexpected error making aux: %v", err)
+			}
+			aux = append(aux, rg)
+		}
+		r, err := sam.NewRecord(fmt.Sprintf("r%d", i), chr1, nil, i, -1, 0, 60,
+			[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}, []byte("ACGT"), []byte{30, 30, 30, 30}, aux)
+		if err != nil {
+			t.Fatalf("unexpected error making record: %v", err)
+		}
+		recs = append(recs, r)
+	}
+
+	for _, test := range []struct {
+		name    string
+		def     string
+		maxOpen int
+		want    map[string][]string
+	}{
+		{name: "bounded", maxOpen: 1},
+		{name: "default", def: "none"},
+	} {
+		dir := t.TempDir()
+		w, err := NewWriter(h, Options{
+			Path:    func(key string) string { return filepath.Join(dir, key+".bam") },
+			Default: test.def,
+			MaxOpen: test.maxOpen,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error making writer: %v", err)
+		}
+		want := make(map[string][]string)
+		for _, r := range recs {
+			err = w.Write(r)
+			if err != nil {
+				t.Fatalf("unexpected error writing record: %v", err)
+			}
+			key, ok := ByReadGroup(r)
+			if !ok {
+				if test.def == "" {
+					continue
+				}
+				key = test.def
+			}
+			want[key] = append(want[key], r.Name)
+			if n := w.lru.Len(); test.maxOpen > 0 && n > test.maxOpen {
+				t.Errorf("%s: too many open files: %d", test.name, n)
+			}
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatalf("unexpected error closing writer: %v", err)
+		}
+		if err = w.Write(recs[0]); err != ErrClosed {
+			t.Errorf("%s: unexpected error writing to closed writer: %v", test.name, err)
+		}
+
+		var keys []string
+		for key, names := range want {
+			keys = append(keys, key)
+			if c := w.Count(key); c != len(names) {
+				t.Errorf("%s: unexpected count for %s: got:%d want:%d", test.name, key, c, len(names))
+			}
+			f, err := os.Open(filepath.Join(dir, key+".bam"))
+			if err != nil {
+				t.Fatalf("unexpected error opening output: %v", err)
+			}
+			br, err := bam.NewReader(f, 1)
+			if err != nil {
+				t.Fatalf("unexpected error reading output: %v", err)
+			}
+			rgs := br.Header().RGs()
+			if key == test.def {
+				if len(rgs) != 3 {
+					t.Errorf("%s: unexpected read groups in %s header: %d", test.name, key, len(rgs))
+				}
+			} else if len(rgs) != 1 || rgs[0].Name() != key {
+				t.Errorf("%s: unexpected read groups in %s header: %v", test.name, key, rgs)
+			}
+			var got []string
+			for {
+				r, err := br.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: unexpected error reading %s: %v", test.name, key, err)
+				}
+				got = append(got, r.Name)
+			}
+			br.Close()
+			f.Close()
+			if !reflect.DeepEqual(got, names) {
+				t.Errorf("%s: unexpected records in %s:\ngot: %v\nwant:%v", test.name, key, got, names)
+			}
+		}
+		if len(keys) != len(w.Keys()) {
+			t.Errorf("%s: unexpected keys: got:%v want:%v", test.name, w.Keys(), keys)
+		}
+	}
+
+	_, err = NewWriter(h, Options{})
+	if err != ErrNoPath {
+		t.Errorf("unexpected error for missing path: %v", err)
+	}
+
+	dir := t.TempDir()
+	src := records(recs)
+	keys, err := Split(&src, h, Options{
+		Path: func(key string) string { return filepath.Join(dir, key+".bam") },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error splitting records: %v", err)
+	}
+	if want := []string{"rg0", "rg1", "rg2"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("unexpected split keys: got:%v want:%v", keys, want)
+	}
+}
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
@@ -0,0 +1,246 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demux
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// Splitter partitions a record stream by key into per-group Sources.
+//
+// Records read from the underlying stream while reading a group are
+// held until the Source of their own group is read, so memory use
+// grows with the number of records read ahead of the group being
+// consumed. Groups that will not be read should be discarded.
+type Splitter struct {
+	src Source
+	key Key
+	def string
+
+	groups map[string]*group
+	keys   []string
+	err    error
+}
+
+// group is the record queue of a Splitter group.
+type group struct {
+	recs    []*sam.Record
+	seen    bool
+	discard bool
+}
+
+// NewSplitter returns a Splitter partitioning the records read from src
+// by the given key. If key is nil, ByReadGroup is used. Records without
+// a key are placed in the group def, or skipped if def is empty.
+func NewSplitter(src Source, key Key, def string) *Splitter {
+	if key == nil {
+		key = ByReadGroup
+	}
+	return &Splitter{src: src, key: key, def: def, groups: make(map[string]*group)}
+}
+
+// Group returns a Source reading the records of the group with the given
+// key in stream order. Reading returns io.EOF when the underlying stream
+// is exhausted and all the records of the group have been read.
+func (s *Splitter) Group(key string) Source {
+	return groupReader{s: s, g: s.group(key)}
+}
+
+// Discard discards the held records of the group with the given key
+// and any records of the group that are read later.
+func (s *Splitter) Discard(key string) {
+	g := s.group(key)
+	g.recs = nil
+	g.discard = true
+}
+
+// Keys returns the keys of the groups seen so far, in order of first
+// appearance in the stream. All keys are known once the underlying
+// stream is exhausted; ReadAll may be used to ensure this.
+func (s *Splitter) Keys() []string {
+	return s.keys
+}
+
+// ReadAll reads the remainder of the underlying stream, holding the
+// records of each group that has not been discarded.
+func (s *Splitter) ReadAll() error {
+	for s.err == nil {
+		s.next()
+	}
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+func (s *Splitter) group(key string) *group {
+	g, ok := s.groups[key]
+	if !ok {
+		g = &group{}
+		s.groups[key] = g
+	}
+	return g
+}
+
+// next reads a record from the underlying stream and adds it to its
+// group, recording any error in s.err.
+func (s *Splitter) next() {
+	r, err := s.src.Read()
+	if err != nil {
+		s.err = err
+		return
+	}
+	key, ok := s.key(r)
+	if !ok {
+		if s.def == "" {
+			return
+		}
+		key = s.def
+	}
+	g := s.group(key)
+	if !g.seen {
+		g.seen = true
+		s.keys = append(s.keys, key)
+	}
+	if !g.discard {
+		g.recs = append(g.recs, r)
+	}
+}
+
+// groupReader is a Source reading a Splitter group.
+type groupReader struct {
+	s *Splitter
+	g *group
+}
+
+func (r groupReader) Read() (*sam.Record, error) {
+	for len(r.g.recs) == 0 {
+		if r.s.err != nil {
+			return nil, r.s.err
+		}
+		r.s.next()
+	}
+	rec := r.g.recs[0]
+	r.g.recs[0] = nil
+	r.g.recs = r.g.recs[1:]
+	return rec, nil
+}
+
+// DefaultGroupBuffer is the number of records buffered for each group
+// by Groups.
+const DefaultGroupBuffer = 64
+
+// ErrGroupDone is returned by a group Source's Read after Groups has
+// stopped early because fn returned an error for another group.
+var ErrGroupDone = errors.New("demux: group iteration stopped")
+
+// Groups reads the records of src, calling fn concurrently for each
+// group of records with the same key with a Source that reads the
+// records of the group. If key is nil, ByReadGroup is used. Records
+// without a key are placed in the group def, or skipped if def is empty.
+//
+// Each group's records are read in stream order, so the groups of
+// coordinate or queryname sorted input are themselves sorted. Each call
+// to fn is made in its own goroutine and reading src is blocked while
+// any group has DefaultGroupBuffer records waiting to be read, so fn
+// should read its records promptly. Records of a group are discarded
+// once fn returns for that group.
+//
+// Groups returns after all calls to fn have returned. It returns the
+// first error returned by fn or by reading src, in which case reading
+// of src stops and remaining groups' Sources return ErrGroupDone.
+func Groups(src Source, key Key, def string, fn func(key string, g Source) error) error {
+	if key == nil {
+		key = ByReadGroup
+	}
+	var (
+		wg     sync.WaitGroup
+		once   sync.Once
+		err    error
+		failed = make(chan struct{})
+	)
+	fail := func(e error) {
+		once.Do(func() {
+			err = e
+			close(failed)
+		})
+	}
+
+	groups := make(map[string]*chanGroup)
+loop:
+	for {
+		select {
+		case <-failed:
+			break loop
+		default:
+		}
+		r, rerr := src.Read()
+		if rerr != nil {
+			if rerr != io.EOF {
+				fail(rerr)
+			}
+			break
+		}
+		k, ok := key(r)
+		if !ok {
+			if def == "" {
+				continue
+			}
+			k = def
+		}
+		g, ok := groups[k]
+		if !ok {
+			g = &chanGroup{
+				c:      make(chan *sam.Record, DefaultGroupBuffer),
+				done:   make(chan struct{}),
+				failed: failed,
+			}
+			groups[k] = g
+			wg.Add(1)
+			go func(k string) {
+				defer wg.Done()
+				defer close(g.done)
+				ferr := fn(k, g)
+				if ferr != nil {
+					fail(ferr)
+				}
+			}(k)
+		}
+		select {
+		case g.c <- r:
+		case <-g.done:
+		case <-failed:
+			break loop
+		}
+	}
+	for _, g := range groups {
+		close(g.c)
+	}
+	wg.Wait()
+	return err
+}
+
+// chanGroup is a Source reading the records of a Groups group.
+type chanGroup struct {
+	c      chan *sam.Record
+	done   chan struct{}
+	failed chan struct{}
+}
+
+func (g *chanGroup) Read() (*sam.Record, error) {
+	select {
+	case r, ok := <-g.c:
+		if !ok {
+			return nil, io.EOF
+		}
+		return r, nil
+	case <-g.failed:
+		return nil, ErrGroupDone
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package demux
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// groupRecords returns n coordinate ordered records in read groups
+// rg0, rg1 and rg2 in turn, with every tenth record without a group.
+func groupRecords(t *testing.T, n int) []*sam.Record {
+	t.Helper()
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error making reference: %v", err)
+	}
+	_, err = sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	var recs []*sam.Record
+	for i := 0; i < n; i++ {
+		var aux []sam.Aux
+		if i%10 != 9 {
+			rg, err := sam.NewAux(sam.NewTag("RG"), fmt.Sprintf("rg%d", i%3))
+			if err != nil {
+				t.Fatalf("unexpected error making aux: %v", err)
+			}
+			aux = append(aux, rg)
+		}
+		r, err := sam.NewRecord(fmt.Sprintf("r%d", i), chr1, nil, i, -1, 0, 60,
+			[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}, []byte("ACGT"), []byte{30, 30, 30, 30}, aux)
+		if err != nil {
+			t.Fatalf("unexpected error making record: %v", err)
+		}
+		recs = append(recs, r)
+	}
+	return recs
+}
+
+// wantGroups returns the expected record names of each group of recs.
+func wantGroups(recs []*sam.Record, def string) map[string][]string {
+	want := make(map[string][]string)
+	for _, r := range recs {
+		k, ok := ByReadGroup(r)
+		if !ok {
+			if def == "" {
+				continue
+			}
+			k = def
+		}
+		want[k] = append(want[k], r.Name)
+	}
+	return want
+}
+
+func readNames(src Source) ([]string, error) {
+	var names []string
+	for {
+		r, err := src.Read()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return names, err
+		}
+		names = append(names, r.Name)
+	}
+}
+
+func mustReadNames(t *testing.T, src Source) []string {
+	t.Helper()
+	names, err := readNames(src)
+	if err != nil {
+		t.Fatalf("unexpected error reading group: %v", err)
+	}
+	return names
+}
+
+func TestSplitter(t *testing.T) {
+	recs := groupRecords(t, 100)
+	for _, def := range []string{"", "none"} {
+		src := records(recs)
+		s := NewSplitter(&src, nil, def)
+		s.Discard("rg1")
+
+		got := make(map[string][]string)
+		got["rg2"] = mustReadNames(t, s.Group("rg2"))
+		if len(src) != 0 {
+			t.Errorf("expected stream to be exhausted")
+		}
+		for _, k := range s.Keys() {
+			if k == "rg1" || k == "rg2" {
+				continue
+			}
+			got[k] = mustReadNames(t, s.Group(k))
+		}
+		if names := mustReadNames(t, s.Group("rg1")); names != nil {
+			t.Errorf("unexpected records in discarded group: %v", names)
+		}
+
+		want := wantGroups(recs, def)
+		delete(want, "rg1")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected groups with default %q:\ngot: %v\nwant:%v", def, got, want)
+		}
+		wantKeys := []string{"rg0", "rg1", "rg2"}
+		if def != "" {
+			wantKeys = append(wantKeys, def)
+		}
+		if !reflect.DeepEqual(s.Keys(), wantKeys) {
+			t.Errorf("unexpected keys: got:%v want:%v", s.Keys(), wantKeys)
+		}
+	}
+}
+
+func TestSplitterError(t *testing.T) {
+	errRead := errors.New("read error")
+	recs := groupRecords(t, 5)
+	s := NewSplitter(&failingSource{recs: recs, err: errRead}, nil, "")
+	err := s.ReadAll()
+	if err != errRead {
+		t.Errorf("unexpected error: got:%v want:%v", err, errRead)
+	}
+	g := s.Group("rg0")
+	for i := 0; i < 2; i++ {
+		_, err = g.Read()
+		if err != nil {
+			t.Fatalf("unexpected error reading held record: %v", err)
+		}
+	}
+	_, err = g.Read()
+	if err != errRead {
+		t.Errorf("unexpected error after held records: got:%v want:%v", err, errRead)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	// Use enough records to fill the group buffers.
+	recs := groupRecords(t, 20*DefaultGroupBuffer)
+	for _, def := range []string{"", "none"} {
+		var (
+			mu  sync.Mutex
+			got = make(map[string][]string)
+		)
+		src := records(recs)
+		err := Groups(&src, nil, def, func(key string, g Source) error {
+			names, err := readNames(g)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			got[key] = names
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := wantGroups(recs, def); !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected groups with default %q", def)
+		}
+	}
+}
+
+func TestGroupsError(t *testing.T) {
+	recs := groupRecords(t, 20*DefaultGroupBuffer)
+
+	errFn := errors.New("group error")
+	src := records(recs)
+	err := Groups(&src, nil, "", func(key string, g Source) error {
+		if key == "rg1" {
+			return errFn
+		}
+		for {
+			_, err := g.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				if err != ErrGroupDone {
+					t.Errorf("unexpected error reading group: %v", err)
+				}
+				return nil
+			}
+		}
+	})
+	if err != errFn {
+		t.Errorf("unexpected error: got:%v want:%v", err, errFn)
+	}
+
+	errRead := errors.New("read error")
+	err = Groups(&failingSource{recs: recs[:5], err: errRead}, nil, "", func(key string, g Source) error {
+		for {
+			_, err := g.Read()
+			if err != nil {
+				return nil
+			}
+		}
+	})
+	if err != errRead {
+		t.Errorf("unexpected error: got:%v want:%v", err, errRead)
+	}
+}
+
+// failingSource returns its records and then err.
+type failingSource struct {
+	recs []*sam.Record
+	err  error
+}
+
+func (s *failingSource) Read() (*sam.Record, error) {
+	if len(s.recs) == 0 {
+		return nil, s.err
+	}
+	r := s.recs[0]
+	s.recs = s.recs[1:]
+	return r, nil
+}
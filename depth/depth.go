@@ -0,0 +1,85 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package depth implements a compact random access container for
+// per-base read depth, modelled on the D4 format.
+//
+// Depths are stored in fixed-size blocks of positions. Each block is
+// either a single constant value, or a dense table of values packed
+// into a fixed number of bits per position together with a sparse list
+// of exceptions holding the values too large for the dense table. Since
+// most genome positions have depths within a narrow range, the dense
+// table holds nearly all values and the exception list is short.
+//
+// A file holds a header describing the chromosomes and encoding, the
+// blocks for each chromosome in header order, and a trailing index of
+// block offsets that provides random access.
+//
+//	header:
+//		magic     [4]byte  "HDP\x01"
+//		blockSize uint32
+//		bits      uint8
+//		nChroms   uint32
+//		chroms    [nChroms]{nameLen uint32; name [nameLen]byte; length uint64}
+//	blocks:
+//		constant  {0x00; value uvarint}
+//		packed    {0x01; values [ceil(n*bits/8)]byte; nExc uvarint; [nExc]{delta uvarint; value uvarint}}
+//	index:
+//		offsets   [nBlocks]uint64
+//	footer:
+//		index     uint64
+//		magic     [4]byte  "HDP\x01"
+//
+// All integers are little-endian. A packed value equal to the greatest
+// value representable in bits marks a position held in the exception
+// list, where positions are delta-encoded from the previous exception
+// in the block.
+package depth
+
+import (
+	"errors"
+)
+
+var (
+	ErrBadMagic  = errors.New("depth: bad magic number")
+	ErrBits      = errors.New("depth: invalid bit width")
+	ErrBlockSize = errors.New("depth: invalid block size")
+	ErrChrom     = errors.New("depth: unknown chromosome")
+	ErrOrder     = errors.New("depth: depths out of order")
+	ErrRange     = errors.New("depth: position out of range")
+	ErrCorrupt   = errors.New("depth: corrupt block")
+	ErrClosed    = errors.New("depth: write to closed writer")
+)
+
+var magic = [4]byte{'H', 'D', 'P', 1}
+
+const (
+	// DefaultBlockSize is the default number
+	// of positions held in each block.
+	DefaultBlockSize = 1 << 16
+
+	// DefaultBits is the default number of bits
+	// used for each value in a dense table.
+	DefaultBits = 6
+
+	// MaxBits is the greatest number of bits
+	// that may be used for each dense value.
+	MaxBits = 16
+)
+
+const (
+	constantBlock = 0
+	packedBlock   = 1
+)
+
+// Chrom is a chromosome held in a depth file.
+type Chrom struct {
+	Name   string
+	Length int
+}
+
+// blocks returns the number of blocks needed to hold the chromosome.
+func (c Chrom) blocks(size int) int {
+	return (c.Length + size - 1) / size
+}
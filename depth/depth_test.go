@@ -0,0 +1,169 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package depth
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	chroms := []Chrom{
+		{Name: "chr1", Length: 10000},
+		{Name: "empty", Length: 0},
+		{Name: "chr2", Length: 2500},
+		{Name: "unwritten", Length: 300},
+		{Name: "chr3", Length: 1000},
+	}
+	want := make(map[string][]uint32)
+	for _, c := range chroms {
+		want[c.Name] = make([]uint32, c.Length)
+	}
+	for i := range want["chr1"] {
+		// Mostly small depths with occasional large values.
+		d := uint32(rnd.Intn(40))
+		if rnd.Intn(50) == 0 {
+			d = uint32(rnd.Intn(100000))
+		}
+		want["chr1"][i] = d
+	}
+	for i := 700; i < 1900; i++ {
+		want["chr2"][i] = 70000
+	}
+	for i := range want["chr3"] {
+		want["chr3"][i] = 5
+	}
+
+	for _, bits := range []int{0, 1, 5, 16} {
+		for _, blockSize := range []int{0, 1, 256, 1000} {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, chroms, bits, blockSize)
+			if err != nil {
+				t.Fatalf("unexpected error creating writer: %v", err)
+			}
+			// Write chr1 in uneven pieces.
+			d := want["chr1"]
+			for pos := 0; pos < len(d); {
+				n := rnd.Intn(700)
+				if pos+n > len(d) {
+					n = len(d) - pos
+				}
+				err = w.Write("chr1", pos, d[pos:pos+n])
+				if err != nil {
+					t.Fatalf("unexpected error writing chr1: %v", err)
+				}
+				pos += n
+			}
+			// Leave gaps on chr2.
+			err = w.Write("chr2", 700, want["chr2"][700:1900])
+			if err != nil {
+				t.Fatalf("unexpected error writing chr2: %v", err)
+			}
+			err = w.Write("chr3", 0, want["chr3"])
+			if err != nil {
+				t.Fatalf("unexpected error writing chr3: %v", err)
+			}
+			err = w.Close()
+			if err != nil {
+				t.Fatalf("unexpected error closing writer: %v", err)
+			}
+
+			r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("unexpected error creating reader: %v", err)
+			}
+			if !reflect.DeepEqual(r.Chroms(), chroms) {
+				t.Errorf("unexpected chromosomes: got:%v want:%v", r.Chroms(), chroms)
+			}
+			for _, c := range chroms {
+				got, err := r.Query(c.Name, 0, c.Length)
+				if err != nil {
+					t.Fatalf("unexpected error querying %s: %v", c.Name, err)
+				}
+				if !reflect.DeepEqual(got, want[c.Name]) {
+					t.Errorf("unexpected depths for %s with bits=%d blockSize=%d", c.Name, bits, blockSize)
+				}
+			}
+			for i := 0; i < 50; i++ {
+				beg := rnd.Intn(10000)
+				end := beg + rnd.Intn(10000-beg+1)
+				got, err := r.Query("chr1", beg, end)
+				if err != nil {
+					t.Fatalf("unexpected error querying chr1:[%d,%d): %v", beg, end, err)
+				}
+				if !reflect.DeepEqual(got, want["chr1"][beg:end]) {
+					t.Errorf("unexpected depths for chr1:[%d,%d) with bits=%d blockSize=%d", beg, end, bits, blockSize)
+				}
+			}
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	const length = 1 << 20
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Chrom{{Name: "chr1", Length: length}}, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	d := make([]uint32, length)
+	for i := range d {
+		d[i] = uint32(30 + i%7)
+	}
+	err = w.Write("chr1", 0, d)
+	if err != nil {
+		t.Fatalf("unexpected error writing: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	// Six bits per position.
+	if max := length*DefaultBits/8 + 1024; buf.Len() > max {
+		t.Errorf("unexpectedly large encoding: got:%d bytes want:<=%d", buf.Len(), max)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	chroms := []Chrom{{Name: "chr1", Length: 100}, {Name: "chr2", Length: 100}}
+	_, err := NewWriter(&bytes.Buffer{}, chroms, MaxBits+1, 0)
+	if err != ErrBits {
+		t.Errorf("unexpected error for invalid bits: %v", err)
+	}
+	w, err := NewWriter(&bytes.Buffer{}, chroms, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, test := range []struct {
+		chrom string
+		pos   int
+		n     int
+		want  error
+	}{
+		{chrom: "chr1", pos: 10, n: 10, want: nil},
+		{chrom: "chr1", pos: 15, n: 1, want: ErrOrder},
+		{chrom: "chr1", pos: 95, n: 10, want: ErrRange},
+		{chrom: "chr2", pos: 0, n: 1, want: nil},
+		{chrom: "chr3", pos: 0, n: 1, want: ErrChrom},
+	} {
+		err = w.Write(test.chrom, test.pos, make([]uint32, test.n))
+		if err != test.want {
+			t.Errorf("unexpected error writing %s:%d: got:%v want:%v", test.chrom, test.pos, err, test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	w, _ = NewWriter(&buf, chroms, 0, 0)
+	w.Close()
+	b := buf.Bytes()
+	b[len(b)-1] = 0
+	_, err = NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != ErrBadMagic {
+		t.Errorf("unexpected error for bad magic: %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package depth
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// Reader implements random access depth file reading.
+type Reader struct {
+	r io.ReaderAt
+
+	chroms    []Chrom
+	chromMap  map[string]int
+	first     []int // first is the index of the first block of each chromosome.
+	blockSize int
+	bits      uint
+
+	// offsets holds the offset of each block
+	// followed by the offset of the index.
+	offsets []uint64
+
+	// cached holds the most recently
+	// decoded block.
+	cachedBlock int
+	cached      []uint32
+}
+
+// NewReader returns a Reader reading a depth file of the given size
+// from r.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	const footerSize = 12
+	if size < footerSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var footer [footerSize]byte
+	_, err := r.ReadAt(footer[:], size-footerSize)
+	if err != nil {
+		return nil, err
+	}
+	var m [4]byte
+	copy(m[:], footer[8:])
+	if m != magic {
+		return nil, ErrBadMagic
+	}
+	index := binary.LittleEndian.Uint64(footer[:8])
+	if index > uint64(size-footerSize) {
+		return nil, ErrCorrupt
+	}
+
+	sr := io.NewSectionReader(r, 0, int64(index))
+	var head struct {
+		Magic     [4]byte
+		BlockSize uint32
+		Bits      uint8
+		NChroms   uint32
+	}
+	err = binary.Read(sr, binary.LittleEndian, &head)
+	if err != nil {
+		return nil, err
+	}
+	if head.Magic != magic {
+		return nil, ErrBadMagic
+	}
+	if head.Bits < 1 || head.Bits > MaxBits {
+		return nil, ErrBits
+	}
+	if head.BlockSize < 1 || head.BlockSize > 1<<30 {
+		return nil, ErrBlockSize
+	}
+	dr := &Reader{
+		r:           r,
+		chromMap:    make(map[string]int),
+		blockSize:   int(head.BlockSize),
+		bits:        uint(head.Bits),
+		cachedBlock: -1,
+	}
+	var nBlocks int
+	for i := uint32(0); i < head.NChroms; i++ {
+		var n uint32
+		err = binary.Read(sr, binary.LittleEndian, &n)
+		if err != nil {
+			return nil, err
+		}
+		if n > uint32(index) {
+			return nil, ErrCorrupt
+		}
+		name := make([]byte, n)
+		_, err = io.ReadFull(sr, name)
+		if err != nil {
+			return nil, err
+		}
+		var length uint64
+		err = binary.Read(sr, binary.LittleEndian, &length)
+		if err != nil {
+			return nil, err
+		}
+		c := Chrom{Name: string(name), Length: int(length)}
+		dr.chromMap[c.Name] = len(dr.chroms)
+		dr.chroms = append(dr.chroms, c)
+		dr.first = append(dr.first, nBlocks)
+		nBlocks += c.blocks(dr.blockSize)
+	}
+
+	if uint64(nBlocks)*8 != uint64(size-footerSize)-index {
+		return nil, ErrCorrupt
+	}
+	idx := make([]byte, nBlocks*8)
+	_, err = r.ReadAt(idx, int64(index))
+	if err != nil {
+		return nil, err
+	}
+	dr.offsets = make([]uint64, nBlocks+1)
+	for i := range dr.offsets[:nBlocks] {
+		dr.offsets[i] = binary.LittleEndian.Uint64(idx[i*8:])
+	}
+	dr.offsets[nBlocks] = index
+	for i := 1; i < len(dr.offsets); i++ {
+		if dr.offsets[i] < dr.offsets[i-1] {
+			return nil, ErrCorrupt
+		}
+	}
+	return dr, nil
+}
+
+// Chroms returns the chromosomes held by the depth file.
+func (r *Reader) Chroms() []Chrom { return r.chroms }
+
+// Query returns the depths of the positions in the zero-based half-open
+// interval [beg,end) on the named chromosome.
+func (r *Reader) Query(chrom string, beg, end int) ([]uint32, error) {
+	id, ok := r.chromMap[chrom]
+	if !ok {
+		return nil, ErrChrom
+	}
+	c := r.chroms[id]
+	if beg < 0 || end < beg || end > c.Length {
+		return nil, ErrRange
+	}
+	depths := make([]uint32, 0, end-beg)
+	for pos := beg; pos < end; {
+		b := pos / r.blockSize
+		values, err := r.block(r.first[id] + b)
+		if err != nil {
+			return nil, err
+		}
+		i := pos - b*r.blockSize
+		n := len(values) - i
+		if n > end-pos {
+			n = end - pos
+		}
+		depths = append(depths, values[i:i+n]...)
+		pos += n
+	}
+	return depths, nil
+}
+
+// block returns the decoded values of the ith block in the file.
+func (r *Reader) block(i int) ([]uint32, error) {
+	if i == r.cachedBlock {
+		return r.cached, nil
+	}
+	n := r.blockLen(i)
+	buf := make([]byte, r.offsets[i+1]-r.offsets[i])
+	_, err := r.r.ReadAt(buf, int64(r.offsets[i]))
+	if err != nil {
+		return nil, err
+	}
+	if cap(r.cached) < n {
+		r.cached = make([]uint32, n)
+	}
+	r.cached = r.cached[:n]
+	r.cachedBlock = -1
+	err = decodeBlock(r.cached, buf, r.bits)
+	if err != nil {
+		return nil, err
+	}
+	r.cachedBlock = i
+	return r.cached, nil
+}
+
+// blockLen returns the number of positions held by the ith block in the
+// file.
+func (r *Reader) blockLen(i int) int {
+	id := sort.Search(len(r.first), func(k int) bool { return r.first[k] > i }) - 1
+	n := r.chroms[id].Length - (i-r.first[id])*r.blockSize
+	if n > r.blockSize {
+		n = r.blockSize
+	}
+	return n
+}
+
+// decodeBlock decodes the block encoded in b into values.
+func decodeBlock(values []uint32, b []byte, bits uint) error {
+	if len(b) == 0 {
+		return ErrCorrupt
+	}
+	switch b[0] {
+	case constantBlock:
+		v, n := binary.Uvarint(b[1:])
+		if n <= 0 || n != len(b)-1 || v > 1<<32-1 {
+			return ErrCorrupt
+		}
+		for i := range values {
+			values[i] = uint32(v)
+		}
+		return nil
+	case packedBlock:
+	default:
+		return ErrCorrupt
+	}
+
+	b = b[1:]
+	packed := (len(values)*int(bits) + 7) / 8
+	if len(b) < packed {
+		return ErrCorrupt
+	}
+	mask := uint64(1)<<bits - 1
+	var acc uint64
+	var nAcc uint
+	var j int
+	for i := range values {
+		for nAcc < bits {
+			acc |= uint64(b[j]) << nAcc
+			j++
+			nAcc += 8
+		}
+		values[i] = uint32(acc & mask)
+		acc >>= bits
+		nAcc -= bits
+	}
+	b = b[packed:]
+
+	nExc, n := binary.Uvarint(b)
+	if n <= 0 {
+		return ErrCorrupt
+	}
+	b = b[n:]
+	escape := uint32(mask)
+	pos := 0
+	for k := uint64(0); k < nExc; k++ {
+		delta, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrCorrupt
+		}
+		b = b[n:]
+		v, n := binary.Uvarint(b)
+		if n <= 0 || v > 1<<32-1 {
+			return ErrCorrupt
+		}
+		b = b[n:]
+		if delta > uint64(len(values)-pos) {
+			return ErrCorrupt
+		}
+		pos += int(delta)
+		if pos >= len(values) || values[pos] != escape {
+			return ErrCorrupt
+		}
+		values[pos] = uint32(v)
+	}
+	if len(b) != 0 {
+		return ErrCorrupt
+	}
+	return nil
+}
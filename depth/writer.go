@@ -0,0 +1,266 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package depth
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// Writer implements depth file writing. Depths must be written in
+// chromosome order and in position order within each chromosome.
+// Positions that are not written hold a depth of zero.
+type Writer struct {
+	w *bufio.Writer
+
+	chroms    []Chrom
+	blockSize int
+	bits      uint
+
+	n       int64
+	offsets []uint64
+
+	chrom int
+	block []uint32
+	start int // start is the first position of block.
+	pos   int // pos is the next position to be written.
+
+	closed bool
+	buf    []byte
+}
+
+// NewWriter returns a Writer that writes depths for the given
+// chromosomes to w. The dense tables use the given number of bits per
+// value, from 1 to MaxBits, and each block holds blockSize positions.
+// If bits or blockSize is zero, the default is used.
+func NewWriter(w io.Writer, chroms []Chrom, bits, blockSize int) (*Writer, error) {
+	if bits == 0 {
+		bits = DefaultBits
+	}
+	if bits < 1 || bits > MaxBits {
+		return nil, ErrBits
+	}
+	if blockSize == 0 {
+		blockSize = DefaultBlockSize
+	}
+	if blockSize < 1 || blockSize > 1<<30 {
+		return nil, ErrBlockSize
+	}
+	dw := &Writer{
+		w:         bufio.NewWriter(w),
+		chroms:    append([]Chrom(nil), chroms...),
+		blockSize: blockSize,
+		bits:      uint(bits),
+	}
+	var b []byte
+	b = append(b, magic[:]...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(blockSize))
+	b = append(b, byte(bits))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(chroms)))
+	for _, c := range chroms {
+		if c.Length < 0 {
+			return nil, ErrRange
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(c.Name)))
+		b = append(b, c.Name...)
+		b = binary.LittleEndian.AppendUint64(b, uint64(c.Length))
+	}
+	err := dw.write(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(dw.chroms) != 0 {
+		dw.newBlock()
+	}
+	return dw, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	_, err := w.w.Write(b)
+	w.n += int64(len(b))
+	return err
+}
+
+// Write writes the depths for consecutive positions on the named
+// chromosome, starting at the zero-based position pos.
+func (w *Writer) Write(chrom string, pos int, depths []uint32) error {
+	if w.closed {
+		return ErrClosed
+	}
+	for w.chrom < len(w.chroms) && w.chroms[w.chrom].Name != chrom {
+		err := w.finishChrom()
+		if err != nil {
+			return err
+		}
+	}
+	if w.chrom == len(w.chroms) {
+		return ErrChrom
+	}
+	if pos < w.pos {
+		return ErrOrder
+	}
+	if pos+len(depths) > w.chroms[w.chrom].Length {
+		return ErrRange
+	}
+	err := w.skipTo(pos)
+	if err != nil {
+		return err
+	}
+	for len(depths) != 0 {
+		n := copy(w.block[w.pos-w.start:], depths)
+		depths = depths[n:]
+		w.pos += n
+		if w.pos == w.start+len(w.block) {
+			err = w.flushBlock()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipTo fills zero depths up to the position pos on the current
+// chromosome, writing any blocks that are completed.
+func (w *Writer) skipTo(pos int) error {
+	for w.pos < pos {
+		end := w.start + len(w.block)
+		if pos < end {
+			w.pos = pos
+			return nil
+		}
+		w.pos = end
+		err := w.flushBlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newBlock prepares a zeroed block starting at the current position.
+func (w *Writer) newBlock() {
+	w.start = w.pos
+	n := w.chroms[w.chrom].Length - w.start
+	if n > w.blockSize {
+		n = w.blockSize
+	}
+	if cap(w.block) < n {
+		w.block = make([]uint32, n)
+	} else {
+		w.block = w.block[:n]
+		for i := range w.block {
+			w.block[i] = 0
+		}
+	}
+}
+
+// flushBlock encodes and writes the current block and prepares the
+// next block of the chromosome.
+func (w *Writer) flushBlock() error {
+	w.offsets = append(w.offsets, uint64(w.n))
+	w.buf = encodeBlock(w.buf[:0], w.block, w.bits)
+	err := w.write(w.buf)
+	if err != nil {
+		return err
+	}
+	w.pos = w.start + len(w.block)
+	w.newBlock()
+	return nil
+}
+
+// finishChrom writes the remaining blocks of the current chromosome and
+// moves to the next chromosome.
+func (w *Writer) finishChrom() error {
+	err := w.skipTo(w.chroms[w.chrom].Length)
+	if err != nil {
+		return err
+	}
+	w.chrom++
+	w.pos = 0
+	if w.chrom < len(w.chroms) {
+		w.newBlock()
+	}
+	return nil
+}
+
+// Close writes any remaining blocks, the index and the footer. Close
+// does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	for w.chrom < len(w.chroms) {
+		err := w.finishChrom()
+		if err != nil {
+			return err
+		}
+	}
+	w.closed = true
+	index := uint64(w.n)
+	b := w.buf[:0]
+	for _, off := range w.offsets {
+		b = binary.LittleEndian.AppendUint64(b, off)
+	}
+	b = binary.LittleEndian.AppendUint64(b, index)
+	b = append(b, magic[:]...)
+	err := w.write(b)
+	if err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// encodeBlock appends the encoding of the block values to dst.
+func encodeBlock(dst []byte, values []uint32, bits uint) []byte {
+	constant := true
+	for _, v := range values[1:] {
+		if v != values[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		dst = append(dst, constantBlock)
+		return binary.AppendUvarint(dst, uint64(values[0]))
+	}
+
+	dst = append(dst, packedBlock)
+	escape := uint32(1)<<bits - 1
+	packed := make([]byte, (len(values)*int(bits)+7)/8)
+	var nExc int
+	var acc uint64
+	var nAcc uint
+	var j int
+	for _, v := range values {
+		if v >= escape {
+			v = escape
+			nExc++
+		}
+		acc |= uint64(v) << nAcc
+		nAcc += bits
+		for nAcc >= 8 {
+			packed[j] = byte(acc)
+			j++
+			acc >>= 8
+			nAcc -= 8
+		}
+	}
+	if nAcc != 0 {
+		packed[j] = byte(acc)
+	}
+	dst = append(dst, packed...)
+	dst = binary.AppendUvarint(dst, uint64(nExc))
+	last := 0
+	for i, v := range values {
+		if v >= escape {
+			dst = binary.AppendUvarint(dst, uint64(i-last))
+			dst = binary.AppendUvarint(dst, uint64(v))
+			last = i
+		}
+	}
+	return dst
+}
@@ -0,0 +1,185 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package downsample implements deterministic subsampling of alignment
+// records by template.
+//
+// Templates are selected using a seeded hash of the read name, so all
+// the records of a template, including mates, secondary and
+// supplementary alignments, are either retained or discarded together,
+// and the same templates are selected from any ordering of the input.
+package downsample
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrFraction = errors.New("downsample: fraction out of range")
+	ErrCount    = errors.New("downsample: negative count")
+)
+
+// Source is a source of records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Hash returns the seeded 64-bit hash of a read name used to select
+// templates.
+func Hash(name string, seed uint64) uint64 {
+	// FNV-1a over the name, with the seed folded
+	// into the offset basis, followed by the
+	// splitmix64 finalizer to spread short names
+	// over the full range.
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+	h := uint64(offset) ^ seed
+	for i := 0; i < len(name); i++ {
+		h ^= uint64(name[i])
+		h *= prime
+	}
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// Keep returns whether the template with the given read name is
+// retained when sampling the given fraction of templates with seed.
+func Keep(name string, fraction float64, seed uint64) bool {
+	if fraction >= 1 {
+		return true
+	}
+	return float64(Hash(name, seed)>>11)/(1<<53) < fraction
+}
+
+// Reader is a record reader that retains a fraction of templates.
+type Reader struct {
+	src      Source
+	fraction float64
+	seed     uint64
+}
+
+// NewReader returns a Reader that reads records from src, retaining the
+// records of templates selected with probability fraction using the
+// given seed. The fraction must be in [0, 1].
+func NewReader(src Source, fraction float64, seed uint64) (*Reader, error) {
+	if !(0 <= fraction && fraction <= 1) {
+		return nil, ErrFraction
+	}
+	return &Reader{src: src, fraction: fraction, seed: seed}, nil
+}
+
+// Read returns the next retained record.
+func (r *Reader) Read() (*sam.Record, error) {
+	for {
+		rec, err := r.src.Read()
+		if err != nil {
+			return nil, err
+		}
+		if Keep(rec.Name, r.fraction, r.seed) {
+			return rec, nil
+		}
+	}
+}
+
+// Reservoir returns the records of exactly n templates read from src,
+// or of all the templates if there are fewer than n. The templates
+// with the n smallest name hashes for the given seed are selected, so
+// the selection does not depend on the order of the input. Records are
+// returned in the order they were read.
+//
+// The records of the selected templates are held in memory until src
+// is exhausted.
+func Reservoir(src Source, n int, seed uint64) ([]*sam.Record, error) {
+	if n < 0 {
+		return nil, ErrCount
+	}
+	var (
+		res    = reservoir{byName: make(map[string]*sample)}
+		serial int
+	)
+	for {
+		rec, err := src.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		serial++
+		if n == 0 {
+			continue
+		}
+		s, ok := res.byName[rec.Name]
+		if !ok {
+			h := Hash(rec.Name, seed)
+			if len(res.samples) == n {
+				if h >= res.samples[0].hash {
+					continue
+				}
+				evicted := heap.Pop(&res).(*sample)
+				delete(res.byName, evicted.name)
+			}
+			s = &sample{name: rec.Name, hash: h}
+			res.byName[rec.Name] = s
+			heap.Push(&res, s)
+		}
+		s.recs = append(s.recs, indexed{serial, rec})
+	}
+
+	var all []indexed
+	for _, s := range res.samples {
+		all = append(all, s.recs...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].serial < all[j].serial })
+	recs := make([]*sam.Record, len(all))
+	for i, r := range all {
+		recs[i] = r.rec
+	}
+	return recs, nil
+}
+
+// indexed is a record and its position in the input.
+type indexed struct {
+	serial int
+	rec    *sam.Record
+}
+
+// sample holds the records of a sampled template.
+type sample struct {
+	name string
+	hash uint64
+	recs []indexed
+}
+
+// reservoir is a max-heap of sampled templates ordered by hash.
+type reservoir struct {
+	samples []*sample
+	byName  map[string]*sample
+}
+
+func (r *reservoir) Len() int { return len(r.samples) }
+func (r *reservoir) Less(i, j int) bool {
+	a, b := r.samples[i], r.samples[j]
+	return a.hash > b.hash || (a.hash == b.hash && a.name > b.name)
+}
+func (r *reservoir) Swap(i, j int)      { r.samples[i], r.samples[j] = r.samples[j], r.samples[i] }
+func (r *reservoir) Push(x interface{}) { r.samples = append(r.samples, x.(*sample)) }
+func (r *reservoir) Pop() interface{} {
+	n := len(r.samples) - 1
+	s := r.samples[n]
+	r.samples[n] = nil
+	r.samples = r.samples[:n]
+	return s
+}
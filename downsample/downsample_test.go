@@ -0,0 +1,138 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package downsample
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+// templates returns n read pairs with the second reads following
+// all the first reads.
+func templates(t *testing.T, n int) records {
+	t.Helper()
+	recs := make(records, 2*n)
+	for i := 0; i < n; i++ {
+		for j, f := range []sam.Flags{sam.Read1, sam.Read2} {
+			r, err := sam.NewRecord(fmt.Sprintf("read%d", i), nil, nil, -1, -1, 0, 0, nil, []byte("ACGT"), nil, nil)
+			if err != nil {
+				t.Fatalf("unexpected error making record: %v", err)
+			}
+			r.Flags = sam.Paired | sam.Unmapped | sam.MateUnmapped | f
+			recs[j*n+i] = r
+		}
+	}
+	return recs
+}
+
+func names(recs []*sam.Record) map[string]int {
+	m := make(map[string]int)
+	for _, r := range recs {
+		m[r.Name]++
+	}
+	return m
+}
+
+func TestReader(t *testing.T) {
+	const n = 10000
+	in := templates(t, n)
+	for _, fraction := range []float64{0, 0.1, 0.5, 1} {
+		src := append(records(nil), in...)
+		r, err := NewReader(&src, fraction, 1)
+		if err != nil {
+			t.Fatalf("unexpected error making reader: %v", err)
+		}
+		var got []*sam.Record
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, rec)
+		}
+		kept := names(got)
+		for name, c := range kept {
+			if c != 2 {
+				t.Errorf("mates of %s not sampled together: %d records", name, c)
+			}
+		}
+		if d := math.Abs(float64(len(kept))/n - fraction); d > 0.02 {
+			t.Errorf("unexpected fraction of templates retained: got:%f want:%f", float64(len(kept))/n, fraction)
+		}
+	}
+
+	for _, fraction := range []float64{-0.1, 1.1, math.NaN()} {
+		_, err := NewReader(&in, fraction, 0)
+		if err != ErrFraction {
+			t.Errorf("unexpected error for fraction %f: got:%v want:%v", fraction, err, ErrFraction)
+		}
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	const n = 1000
+	in := templates(t, n)
+	for _, k := range []int{0, 1, 10, 500, n, 2 * n} {
+		src := append(records(nil), in...)
+		got, err := Reservoir(&src, k, 7)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := k
+		if want > n {
+			want = n
+		}
+		kept := names(got)
+		if len(kept) != want {
+			t.Errorf("unexpected number of templates for k=%d: got:%d want:%d", k, len(kept), want)
+		}
+		for name, c := range kept {
+			if c != 2 {
+				t.Errorf("mates of %s not sampled together: %d records", name, c)
+			}
+		}
+		for i := 1; i < len(got); i++ {
+			if got[i-1].Flags&sam.Read2 != 0 && got[i].Flags&sam.Read1 != 0 {
+				t.Errorf("records not returned in input order")
+				break
+			}
+		}
+
+		// The selection must be independent of input order.
+		rev := append(records(nil), in...)
+		for i, j := 0, len(rev)-1; i < j; i, j = i+1, j-1 {
+			rev[i], rev[j] = rev[j], rev[i]
+		}
+		other, err := Reservoir(&rev, k, 7)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		otherKept := names(other)
+		for name := range kept {
+			if otherKept[name] != 2 {
+				t.Errorf("selection depends on input order for k=%d", k)
+				break
+			}
+		}
+	}
+}
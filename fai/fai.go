@@ -0,0 +1,217 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fai implements FASTA index (.fai) reading, writing and
+// construction, and random access to indexed FASTA sequence data held
+// in plain or bgzipped files.
+//
+// The .fai format is described in the samtools faidx documentation.
+//
+// http://www.htslib.org/doc/faidx.html
+package fai
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Record is a FASTA index record describing the layout of a single
+// sequence in a FASTA file.
+type Record struct {
+	// Name is the name of the sequence.
+	Name string
+
+	// Length is the number of bases in the sequence.
+	Length int
+
+	// Start is the offset of the first base of the
+	// sequence in the uncompressed FASTA data.
+	Start int64
+
+	// BasesPerLine is the number of bases on each
+	// full line of the sequence.
+	BasesPerLine int
+
+	// BytesPerLine is the number of bytes on each
+	// full line of the sequence, including the line
+	// terminator.
+	BytesPerLine int
+}
+
+// offset returns the offset in the uncompressed FASTA data of the base
+// at the zero-based position pos.
+func (r Record) offset(pos int) int64 {
+	if r.BasesPerLine == 0 {
+		return r.Start
+	}
+	return r.Start + int64(pos/r.BasesPerLine)*int64(r.BytesPerLine) + int64(pos%r.BasesPerLine)
+}
+
+// Index is a FASTA index.
+type Index struct {
+	Records []Record
+
+	nameMap map[string]int
+}
+
+// NewIndex returns an Index holding the provided records. It returns an
+// error if any record name is duplicated.
+func NewIndex(recs []Record) (*Index, error) {
+	idx := &Index{Records: recs, nameMap: make(map[string]int, len(recs))}
+	for i, r := range recs {
+		if _, dup := idx.nameMap[r.Name]; dup {
+			return nil, fmt.Errorf("fai: duplicate sequence name: %q", r.Name)
+		}
+		idx.nameMap[r.Name] = i
+	}
+	return idx, nil
+}
+
+// Get returns the Record for the named sequence.
+func (idx *Index) Get(name string) (Record, bool) {
+	i, ok := idx.nameMap[name]
+	if !ok {
+		return Record{}, false
+	}
+	return idx.Records[i], true
+}
+
+// ReadIndex reads a .fai index from r.
+func ReadIndex(r io.Reader) (*Index, error) {
+	var recs []Record
+	sc := bufio.NewScanner(r)
+	var line int
+	for sc.Scan() {
+		line++
+		b := bytes.TrimSuffix(sc.Bytes(), []byte{'\r'})
+		if len(b) == 0 {
+			continue
+		}
+		f := bytes.Split(b, []byte{'\t'})
+		if len(f) != 5 {
+			return nil, fmt.Errorf("fai: unexpected number of fields at line %d: %d", line, len(f))
+		}
+		var (
+			rec = Record{Name: string(f[0])}
+			err error
+		)
+		rec.Length, err = strconv.Atoi(string(f[1]))
+		if err == nil {
+			rec.Start, err = strconv.ParseInt(string(f[2]), 10, 64)
+		}
+		if err == nil {
+			rec.BasesPerLine, err = strconv.Atoi(string(f[3]))
+		}
+		if err == nil {
+			rec.BytesPerLine, err = strconv.Atoi(string(f[4]))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fai: failed to parse line %d: %v", line, err)
+		}
+		if rec.Length < 0 || rec.Start < 0 || rec.BasesPerLine < 0 || rec.BytesPerLine < rec.BasesPerLine {
+			return nil, fmt.Errorf("fai: invalid record at line %d", line)
+		}
+		recs = append(recs, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return NewIndex(recs)
+}
+
+// WriteIndex writes idx to w in .fai format.
+func WriteIndex(w io.Writer, idx *Index) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range idx.Records {
+		_, err := fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", r.Name, r.Length, r.Start, r.BasesPerLine, r.BytesPerLine)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Build returns an Index for the FASTA data read from r. For bgzipped
+// FASTA, r must provide the uncompressed data, for example by reading
+// through a bgzf.Reader. All lines of each sequence except the last
+// must hold the same number of bases.
+func Build(r io.Reader) (*Index, error) {
+	var (
+		recs []Record
+		cur  *Record
+
+		br   = bufio.NewReader(r)
+		off  int64
+		line int
+
+		// short is true when the current sequence has
+		// had a line shorter than its full line length.
+		short bool
+	)
+	for {
+		b, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Accumulate long lines.
+			full := append([]byte(nil), b...)
+			for err == bufio.ErrBufferFull {
+				b, err = br.ReadSlice('\n')
+				full = append(full, b...)
+			}
+			b = full
+		}
+		if len(b) == 0 {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		line++
+		n := len(b)
+		seq := bytes.TrimSuffix(bytes.TrimSuffix(b, []byte{'\n'}), []byte{'\r'})
+
+		switch {
+		case len(seq) != 0 && seq[0] == '>':
+			name := seq[1:]
+			if i := bytes.IndexAny(name, " \t"); i >= 0 {
+				name = name[:i]
+			}
+			if len(name) == 0 {
+				return nil, fmt.Errorf("fai: empty sequence name at line %d", line)
+			}
+			recs = append(recs, Record{Name: string(name), Start: off + int64(n)})
+			cur = &recs[len(recs)-1]
+			short = false
+		case cur == nil:
+			if len(bytes.TrimSpace(seq)) != 0 {
+				return nil, fmt.Errorf("fai: sequence data before header at line %d", line)
+			}
+		case len(seq) == 0:
+			short = true
+		default:
+			switch {
+			case cur.BasesPerLine == 0:
+				cur.BasesPerLine = len(seq)
+				cur.BytesPerLine = n
+			case short || len(seq) > cur.BasesPerLine:
+				return nil, fmt.Errorf("fai: inconsistent line length in %q at line %d", cur.Name, line)
+			case len(seq) < cur.BasesPerLine:
+				short = true
+			case n != cur.BytesPerLine && err != io.EOF:
+				return nil, fmt.Errorf("fai: inconsistent line length in %q at line %d", cur.Name, line)
+			}
+			cur.Length += len(seq)
+		}
+		off += int64(n)
+		if err == io.EOF {
+			break
+		}
+	}
+	return NewIndex(recs)
+}
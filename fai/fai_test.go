@@ -0,0 +1,317 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fai
+
+import (
+	"bytes"
+	"crypto/md5"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/cache"
+	"github.com/Schaudge/hts/cram"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	_ cram.ReferenceProvider = (*File)(nil)
+	_ sam.ReferenceProvider  = (*File)(nil)
+)
+
+const testFasta = `>one first sequence
+ACGTACGTAC
+GTACGTACGT
+ACG
+>two
+CCCCGGGG
+TTTTAAAA
+>three
+GATTACA
+`
+
+const testFai = "one\t23\t20\t10\t11\n" +
+	"two\t16\t51\t8\t9\n" +
+	"three\t7\t76\t7\t8\n"
+
+func TestBuild(t *testing.T) {
+	for _, test := range []struct {
+		fasta string
+		want  string
+	}{
+		{fasta: testFasta, want: testFai},
+		{
+			fasta: strings.ReplaceAll(testFasta, "\n", "\r\n"),
+			want: "one\t23\t21\t10\t12\n" +
+				"two\t16\t56\t8\t10\n" +
+				"three\t7\t84\t7\t9\n",
+		},
+		{fasta: ">a\nACGT", want: "a\t4\t3\t4\t4\n"},
+		{fasta: ">a\nACGT\nAC\n\n>b\nA\n", want: "a\t6\t3\t4\t5\n" + "b\t1\t15\t1\t2\n"},
+		{fasta: ">empty\n>a\nAC\n", want: "empty\t0\t7\t0\t0\n" + "a\t2\t10\t2\t3\n"},
+	} {
+		idx, err := Build(strings.NewReader(test.fasta))
+		if err != nil {
+			t.Errorf("unexpected error building index: %v", err)
+			continue
+		}
+		var buf bytes.Buffer
+		err = WriteIndex(&buf, idx)
+		if err != nil {
+			t.Fatalf("unexpected error writing index: %v", err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("unexpected index for %q:\ngot:\n%s\nwant:\n%s", test.fasta, &buf, test.want)
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for _, fasta := range []string{
+		"ACGT\n",
+		">\nACGT\n",
+		">a\nACG\nACGT\n",
+		">a\nACGT\nAC\nAC\n",
+		">a\nACGT\n\nACGT\n",
+		">a\nAC\n>a\nAC\n",
+	} {
+		_, err := Build(strings.NewReader(fasta))
+		if err == nil {
+			t.Errorf("expected error for %q", fasta)
+		}
+	}
+}
+
+func TestReadIndex(t *testing.T) {
+	idx, err := ReadIndex(strings.NewReader(testFai))
+	if err != nil {
+		t.Fatalf("unexpected error reading index: %v", err)
+	}
+	rec, ok := idx.Get("two")
+	want := Record{Name: "two", Length: 16, Start: 51, BasesPerLine: 8, BytesPerLine: 9}
+	if !ok || rec != want {
+		t.Errorf("unexpected record: got:%+v want:%+v", rec, want)
+	}
+	_, ok = idx.Get("four")
+	if ok {
+		t.Error("unexpected record for missing sequence")
+	}
+	var buf bytes.Buffer
+	err = WriteIndex(&buf, idx)
+	if err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+	if buf.String() != testFai {
+		t.Errorf("index did not round trip:\ngot:\n%s\nwant:\n%s", &buf, testFai)
+	}
+
+	for _, bad := range []string{
+		"one\t23\t20\t10\n",
+		"one\t23\t20\t10\tx\n",
+		"one\t23\t20\t10\t9\n",
+		"one\t23\t20\t10\t11\none\t1\t40\t1\t2\n",
+	} {
+		_, err = ReadIndex(strings.NewReader(bad))
+		if err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	for _, test := range []struct {
+		region string
+		want   Region
+		str    string
+		err    bool
+	}{
+		{region: "chr1", want: Region{Name: "chr1", End: -1}, str: "chr1"},
+		{region: "chr1:100", want: Region{Name: "chr1", Start: 99, End: -1}, str: "chr1:100"},
+		{region: "chr1:100-", want: Region{Name: "chr1", Start: 99, End: -1}, str: "chr1:100"},
+		{region: "chr1:1,000-2,000", want: Region{Name: "chr1", Start: 999, End: 2000}, str: "chr1:1000-2000"},
+		{region: "HLA-A*01:01", want: Region{Name: "HLA-A*01", Start: 0, End: -1}, str: "HLA-A*01"},
+		{region: "HLA-A*01:01:1-10", want: Region{Name: "HLA-A*01:01", Start: 0, End: 10}, str: "HLA-A*01:01:1-10"},
+		{region: "chr1:x-y", want: Region{Name: "chr1:x-y", End: -1}, str: "chr1:x-y"},
+		{region: "", err: true},
+		{region: "chr1:0", err: true},
+		{region: "chr1:10-5", err: true},
+		{region: ":1-5", err: true},
+	} {
+		got, err := ParseRegion(test.region)
+		if (err != nil) != test.err {
+			t.Errorf("unexpected error state for %q: %v", test.region, err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if got != test.want {
+			t.Errorf("unexpected region for %q: got:%+v want:%+v", test.region, got, test.want)
+		}
+		if got.String() != test.str {
+			t.Errorf("unexpected region string for %q: got:%q want:%q", test.region, got, test.str)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	idx, err := Build(strings.NewReader(testFasta))
+	if err != nil {
+		t.Fatalf("unexpected error building index: %v", err)
+	}
+	f := NewFile(strings.NewReader(testFasta), idx)
+	for _, test := range []struct {
+		region string
+		want   string
+		err    bool
+	}{
+		{region: "one", want: "ACGTACGTACGTACGTACGTACG"},
+		{region: "one:10-11", want: "CG"},
+		{region: "one:21", want: "ACG"},
+		{region: "one:20-100", want: "TACG"},
+		{region: "two:8-9", want: "GT"},
+		{region: "three", want: "GATTACA"},
+		{region: "three:7-7", want: "A"},
+		{region: "four", err: true},
+	} {
+		got, err := f.Fetch(test.region)
+		if (err != nil) != test.err {
+			t.Errorf("unexpected error state for %q: %v", test.region, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("unexpected sequence for %q: got:%q want:%q", test.region, got, test.want)
+		}
+	}
+	_, err = f.GetSequence("one", 5, 4)
+	if err == nil {
+		t.Error("expected error for inverted interval")
+	}
+
+	for _, name := range []string{"one", "two", "three", "two"} {
+		got, err := f.MD5(name)
+		if err != nil {
+			t.Fatalf("unexpected error getting MD5 for %q: %v", name, err)
+		}
+		seq, _ := f.GetSequence(name, 0, -1)
+		want := md5.Sum(seq)
+		if !bytes.Equal(got, want[:]) {
+			t.Errorf("unexpected MD5 for %q: got:%x want:%x", name, got, want)
+		}
+	}
+	_, err = f.MD5("four")
+	if err == nil {
+		t.Error("expected error for missing sequence MD5")
+	}
+}
+
+func TestFetchRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	const bases = "ACGTN"
+	var (
+		fasta bytes.Buffer
+		seqs  = make(map[string][]byte)
+		names []string
+	)
+	for i := 0; i < 5; i++ {
+		name := string(rune('a' + i))
+		names = append(names, name)
+		seq := make([]byte, 1+rnd.Intn(20000))
+		for j := range seq {
+			seq[j] = bases[rnd.Intn(len(bases))]
+		}
+		seqs[name] = seq
+		width := 1 + rnd.Intn(120)
+		fasta.WriteString(">" + name + "\n")
+		for j := 0; j < len(seq); j += width {
+			end := j + width
+			if end > len(seq) {
+				end = len(seq)
+			}
+			fasta.Write(seq[j:end])
+			fasta.WriteByte('\n')
+		}
+	}
+
+	idx, err := Build(bytes.NewReader(fasta.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error building index: %v", err)
+	}
+
+	var bgzfBuf, gziBuf bytes.Buffer
+	w := bgzf.NewWriter(&bgzfBuf, 1)
+	w.SetGZIWriter(&gziBuf)
+	err = w.SetBlockSize(1 << 12)
+	if err != nil {
+		t.Fatalf("unexpected error setting block size: %v", err)
+	}
+	_, err = w.Write(fasta.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error writing bgzf: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing bgzf: %v", err)
+	}
+	gzi, err := bgzf.ReadGZI(&gziBuf)
+	if err != nil {
+		t.Fatalf("unexpected error reading gzi: %v", err)
+	}
+	if len(gzi) < 2 {
+		t.Fatalf("expected multiple blocks in gzi: %v", gzi)
+	}
+
+	// The index built from the bgzipped stream must
+	// match the index built from the plain data.
+	r, err := bgzf.NewReader(bytes.NewReader(bgzfBuf.Bytes()), 1)
+	if err != nil {
+		t.Fatalf("unexpected error opening bgzf: %v", err)
+	}
+	bgIdx, err := Build(r)
+	if err != nil {
+		t.Fatalf("unexpected error building index from bgzf: %v", err)
+	}
+	var want, got bytes.Buffer
+	WriteIndex(&want, idx)
+	WriteIndex(&got, bgIdx)
+	if got.String() != want.String() {
+		t.Errorf("index mismatch:\ngot:\n%s\nwant:\n%s", &got, &want)
+	}
+
+	bg, err := NewBGZFFile(bytes.NewReader(bgzfBuf.Bytes()), idx, gzi, cache.NewLRU(4))
+	if err != nil {
+		t.Fatalf("unexpected error opening bgzipped file: %v", err)
+	}
+	files := map[string]*File{
+		"plain": NewFile(bytes.NewReader(fasta.Bytes()), idx),
+		"bgzf":  bg,
+	}
+	for i := 0; i < 500; i++ {
+		name := names[rnd.Intn(len(names))]
+		seq := seqs[name]
+		beg := rnd.Intn(len(seq))
+		end := beg + rnd.Intn(len(seq)-beg+10)
+		want := seq[beg:]
+		if end < len(seq) {
+			want = seq[beg:end]
+		}
+		for kind, f := range files {
+			got, err := f.GetSequence(name, beg, end)
+			if err != nil {
+				t.Errorf("unexpected error fetching %s:%d-%d from %s: %v", name, beg, end, kind, err)
+				continue
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("unexpected sequence for %s:%d-%d from %s", name, beg, end, kind)
+			}
+		}
+	}
+
+	_, err = NewBGZFFile(bytes.NewReader(bgzfBuf.Bytes()), idx, nil, nil)
+	if err != bgzf.ErrNoGZI {
+		t.Errorf("unexpected error for missing gzi: got:%v want:%v", err, bgzf.ErrNoGZI)
+	}
+}
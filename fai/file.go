@@ -0,0 +1,170 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fai
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+// File provides random access to the sequences of an indexed FASTA
+// file. File satisfies the sam.ReferenceProvider and
+// cram.ReferenceProvider interfaces and is safe for concurrent use.
+type File struct {
+	idx *Index
+
+	ra io.ReaderAt
+
+	bg  *bgzf.RandomReader
+	gzi bgzf.GZI
+
+	mu  sync.Mutex
+	md5 map[string][]byte
+
+	// closer is closed when the File
+	// is closed, if not nil.
+	closer io.Closer
+}
+
+// NewFile returns a File reading uncompressed FASTA data from ra
+// using the provided index.
+func NewFile(ra io.ReaderAt, idx *Index) *File {
+	return &File{idx: idx, ra: ra}
+}
+
+// NewBGZFFile returns a File reading bgzipped FASTA data from ra using
+// the provided FASTA index and GZI block index. Decompressed BGZF blocks
+// are retained between reads in c, which may be nil, as described for
+// bgzf.NewRandomReader.
+func NewBGZFFile(ra io.ReaderAt, idx *Index, gzi bgzf.GZI, c bgzf.Cache) (*File, error) {
+	if len(gzi) == 0 {
+		return nil, bgzf.ErrNoGZI
+	}
+	return &File{idx: idx, bg: bgzf.NewRandomReader(ra, c), gzi: gzi}, nil
+}
+
+// Close closes the file opened by OpenFS. It is a no-op for Files
+// created by NewFile or NewBGZFFile.
+func (f *File) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+// Index returns the FASTA index used by the File.
+func (f *File) Index() *Index { return f.idx }
+
+// Fetch returns the bases of the region described by s in samtools
+// region notation. See ParseRegion for details.
+func (f *File) Fetch(s string) ([]byte, error) {
+	r, err := ParseRegion(s)
+	if err != nil {
+		return nil, err
+	}
+	return f.GetSequence(r.Name, r.Start, r.End)
+}
+
+// GetSequence returns the bases of the named sequence in the zero-based
+// half-open interval [beg,end). If end is negative or beyond the end of
+// the sequence, the bases to the end of the sequence are returned.
+func (f *File) GetSequence(name string, beg, end int) ([]byte, error) {
+	rec, ok := f.idx.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("fai: no sequence %q", name)
+	}
+	if end < 0 || end > rec.Length {
+		end = rec.Length
+	}
+	if beg < 0 || beg > end {
+		return nil, fmt.Errorf("fai: invalid interval [%d,%d) for %q", beg, end, name)
+	}
+	if beg == end {
+		return []byte{}, nil
+	}
+
+	off := rec.offset(beg)
+	raw := make([]byte, rec.offset(end-1)+1-off)
+	err := f.readAt(raw, off)
+	if err != nil {
+		return nil, err
+	}
+
+	seq := raw[:0]
+	for _, b := range raw {
+		if b != '\n' && b != '\r' {
+			seq = append(seq, b)
+		}
+	}
+	if len(seq) != end-beg {
+		return nil, fmt.Errorf("fai: unexpected sequence length for %q: got %d want %d", name, len(seq), end-beg)
+	}
+	return seq, nil
+}
+
+// MD5 returns the MD5 checksum of the named sequence as described for
+// the @SQ M5 tag. Checksums are retained after the first call for each
+// sequence.
+func (f *File) MD5(name string) ([]byte, error) {
+	f.mu.Lock()
+	sum, ok := f.md5[name]
+	f.mu.Unlock()
+	if ok {
+		return sum, nil
+	}
+	rec, ok := f.idx.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("fai: no sequence %q", name)
+	}
+	const chunk = 1 << 20
+	h := sam.NewSequenceHash()
+	for beg := 0; beg < rec.Length; beg += chunk {
+		seq, err := f.GetSequence(name, beg, beg+chunk)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(seq)
+	}
+	sum = h.Sum(nil)
+	f.mu.Lock()
+	if f.md5 == nil {
+		f.md5 = make(map[string][]byte)
+	}
+	f.md5[name] = sum
+	f.mu.Unlock()
+	return sum, nil
+}
+
+// readAt fills p with uncompressed FASTA data starting at off.
+func (f *File) readAt(p []byte, off int64) error {
+	if f.bg == nil {
+		n, err := f.ra.ReadAt(p, off)
+		if n == len(p) {
+			return nil
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	o, err := f.gzi.Offset(off)
+	if err != nil {
+		return err
+	}
+	s := f.bg.NewStream()
+	err = s.Seek(o)
+	if err != nil {
+		return err
+	}
+	_, err = io.ReadFull(s, p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
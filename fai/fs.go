@@ -0,0 +1,70 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fai
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/internal"
+)
+
+// OpenFS returns a File reading the FASTA file with the given name from
+// fsys using the index held in the file with ".fai" appended to name.
+// If a GZI block index is held in the file with ".gzi" appended to
+// name, the FASTA data is read as bgzipped data holding decompressed
+// blocks in c, as described for NewBGZFFile. If the FASTA file does not
+// implement io.ReaderAt, its contents are read into memory. The
+// returned File should be closed after use.
+func OpenFS(fsys fs.FS, name string, c bgzf.Cache) (*File, error) {
+	idx, err := readIndexFS(fsys, name+".fai")
+	if err != nil {
+		return nil, err
+	}
+	gzi, err := readGZIFS(fsys, name+".gzi")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	ra, _, err := internal.ReaderAt(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	var file *File
+	if gzi == nil {
+		file = NewFile(ra, idx)
+	} else {
+		file, err = NewBGZFFile(ra, idx, gzi, c)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	file.closer = f
+	return file, nil
+}
+
+func readIndexFS(fsys fs.FS, name string) (*Index, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadIndex(f)
+}
+
+func readGZIFS(fsys fs.FS, name string) (bgzf.GZI, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return bgzf.ReadGZI(f)
+}
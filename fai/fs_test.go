@@ -0,0 +1,77 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fai
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/cache"
+)
+
+// streamFS hides the io.ReaderAt and io.Seeker methods of the files
+// of an fs.FS, as is the case for compressed zip archive members.
+type streamFS struct{ fs.FS }
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+func TestOpenFS(t *testing.T) {
+	var bgzfBuf, gziBuf bytes.Buffer
+	w := bgzf.NewWriter(&bgzfBuf, 1)
+	w.SetGZIWriter(&gziBuf)
+	err := w.SetBlockSize(16)
+	if err != nil {
+		t.Fatalf("unexpected error setting block size: %v", err)
+	}
+	_, err = w.Write([]byte(testFasta))
+	if err != nil {
+		t.Fatalf("unexpected error writing bgzf: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing bgzf: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"ref.fa":        {Data: []byte(testFasta)},
+		"ref.fa.fai":    {Data: []byte(testFai)},
+		"ref.fa.gz":     {Data: bgzfBuf.Bytes()},
+		"ref.fa.gz.fai": {Data: []byte(testFai)},
+		"ref.fa.gz.gzi": {Data: gziBuf.Bytes()},
+		"nofai.fa":      {Data: []byte(testFasta)},
+	}
+	for _, fsys := range []fs.FS{fsys, streamFS{fsys}} {
+		for _, name := range []string{"ref.fa", "ref.fa.gz"} {
+			f, err := OpenFS(fsys, name, cache.NewLRU(2))
+			if err != nil {
+				t.Fatalf("unexpected error opening %s: %v", name, err)
+			}
+			got, err := f.Fetch("two:8-9")
+			if err != nil {
+				t.Errorf("unexpected error fetching from %s: %v", name, err)
+			}
+			if string(got) != "GT" {
+				t.Errorf("unexpected sequence from %s: got:%q want:%q", name, got, "GT")
+			}
+			err = f.Close()
+			if err != nil {
+				t.Errorf("unexpected error closing %s: %v", name, err)
+			}
+		}
+		_, err = OpenFS(fsys, "nofai.fa", nil)
+		if err == nil {
+			t.Error("expected error for missing index")
+		}
+	}
+}
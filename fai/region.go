@@ -0,0 +1,69 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fai
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Region is a sequence region. Start and End are zero-based
+// half-open coordinates. An End of -1 indicates the end of the
+// sequence.
+type Region struct {
+	Name  string
+	Start int
+	End   int
+}
+
+// ParseRegion parses a region in samtools notation, "name",
+// "name:beg" or "name:beg-end", where beg and end are one-based
+// inclusive coordinates that may contain thousands separators. If the
+// text following the last colon is not a valid range, the entire
+// string is taken to be the sequence name.
+func ParseRegion(s string) (Region, error) {
+	if s == "" {
+		return Region{}, fmt.Errorf("fai: empty region")
+	}
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return Region{Name: s, End: -1}, nil
+	}
+	name, rng := s[:i], strings.ReplaceAll(s[i+1:], ",", "")
+	beg, end := rng, ""
+	ranged := false
+	if j := strings.IndexByte(rng, '-'); j >= 0 {
+		beg, end = rng[:j], rng[j+1:]
+		ranged = true
+	}
+	b, err := strconv.Atoi(beg)
+	if err != nil {
+		return Region{Name: s, End: -1}, nil
+	}
+	r := Region{Name: name, Start: b - 1, End: -1}
+	if ranged && end != "" {
+		r.End, err = strconv.Atoi(end)
+		if err != nil {
+			return Region{Name: s, End: -1}, nil
+		}
+	}
+	if name == "" || r.Start < 0 || (r.End >= 0 && r.End < r.Start) {
+		return Region{}, fmt.Errorf("fai: invalid region: %q", s)
+	}
+	return r, nil
+}
+
+// String returns the samtools notation of the region.
+func (r Region) String() string {
+	switch {
+	case r.Start == 0 && r.End < 0:
+		return r.Name
+	case r.End < 0:
+		return fmt.Sprintf("%s:%d", r.Name, r.Start+1)
+	default:
+		return fmt.Sprintf("%s:%d-%d", r.Name, r.Start+1, r.End)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fastq implements FASTQ format reading and writing, and
+// conversion between SAM/BAM records and FASTQ records.
+//
+// Only the common four line FASTQ layout is supported; sequence and
+// quality lines may not be wrapped. Quality scores are encoded with
+// the Sanger offset of 33.
+package fastq
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrBadHeader  = errors.New("fastq: missing '@' at start of record")
+	ErrBadPlus    = errors.New("fastq: missing '+' separator line")
+	ErrBadQuality = errors.New("fastq: invalid quality score")
+	ErrLength     = errors.New("fastq: sequence/quality length mismatch")
+)
+
+// Record is a FASTQ record.
+type Record struct {
+	// Name is the read name, the text of the header
+	// line up to the first space or tab.
+	Name string
+
+	// Comment is the text of the header line following
+	// the first space or tab.
+	Comment string
+
+	// Seq is the read sequence.
+	Seq []byte
+
+	// Qual holds the Phred quality scores of the read.
+	// Scores are not offset by 33.
+	Qual []byte
+}
+
+// MarshalText implements encoding.TextMarshaler. The returned text
+// does not include a trailing newline.
+func (r *Record) MarshalText() ([]byte, error) {
+	if len(r.Seq) != len(r.Qual) {
+		return nil, ErrLength
+	}
+	b := make([]byte, 0, len(r.Name)+len(r.Comment)+2*len(r.Seq)+6)
+	b = append(b, '@')
+	b = append(b, r.Name...)
+	if r.Comment != "" {
+		b = append(b, ' ')
+		b = append(b, r.Comment...)
+	}
+	b = append(b, '\n')
+	b = append(b, r.Seq...)
+	b = append(b, "\n+\n"...)
+	for _, q := range r.Qual {
+		if q > '~'-33 {
+			return nil, ErrBadQuality
+		}
+		b = append(b, q+33)
+	}
+	return b, nil
+}
+
+// MarshalFASTA returns the FASTA representation of the record, with
+// sequence lines wrapped at width bases. If width is less than one,
+// the sequence is not wrapped. Quality scores are not included. The
+// returned text does not include a trailing newline.
+func (r *Record) MarshalFASTA(width int) []byte {
+	if width < 1 {
+		width = len(r.Seq)
+	}
+	b := make([]byte, 0, len(r.Name)+len(r.Comment)+2*len(r.Seq)+2)
+	b = append(b, '>')
+	b = append(b, r.Name...)
+	if r.Comment != "" {
+		b = append(b, ' ')
+		b = append(b, r.Comment...)
+	}
+	for seq := r.Seq; len(seq) != 0; {
+		n := width
+		if n > len(seq) {
+			n = len(seq)
+		}
+		b = append(b, '\n')
+		b = append(b, seq[:n]...)
+		seq = seq[n:]
+	}
+	return b
+}
+
+// Reader implements FASTQ format reading.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a new Reader, reading from the given io.Reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next Record in the FASTQ stream.
+func (r *Reader) Read() (*Record, error) {
+	var (
+		head []byte
+		err  error
+	)
+	for len(head) == 0 {
+		head, err = r.readLine()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if head[0] != '@' {
+		return nil, fmt.Errorf("%v at line %d", ErrBadHeader, r.line)
+	}
+	var rec Record
+	head = head[1:]
+	if i := bytes.IndexAny(head, " \t"); i >= 0 {
+		rec.Comment = string(head[i+1:])
+		head = head[:i]
+	}
+	rec.Name = string(head)
+
+	seq, err := r.readLine()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	rec.Seq = append(make([]byte, 0, len(seq)), seq...)
+
+	plus, err := r.readLine()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if len(plus) == 0 || plus[0] != '+' {
+		return nil, fmt.Errorf("%v at line %d", ErrBadPlus, r.line)
+	}
+
+	qual, err := r.readLine()
+	if err != nil && (err != io.EOF || len(rec.Seq) != 0) {
+		return nil, unexpectedEOF(err)
+	}
+	if len(qual) != len(rec.Seq) {
+		return nil, fmt.Errorf("%v at line %d", ErrLength, r.line)
+	}
+	rec.Qual = make([]byte, len(qual))
+	for i, q := range qual {
+		if q < '!' || q > '~' {
+			return nil, fmt.Errorf("%v at line %d", ErrBadQuality, r.line)
+		}
+		rec.Qual[i] = q - 33
+	}
+	return &rec, nil
+}
+
+// readLine returns the next line without its line terminator. The
+// returned slice is only valid until the next call to readLine.
+func (r *Reader) readLine() ([]byte, error) {
+	b, err := r.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		full := append([]byte(nil), b...)
+		for err == bufio.ErrBufferFull {
+			b, err = r.r.ReadSlice('\n')
+			full = append(full, b...)
+		}
+		b = full
+	}
+	if len(b) == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return nil, err
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	r.line++
+	b = bytes.TrimSuffix(b, []byte{'\n'})
+	b = bytes.TrimSuffix(b, []byte{'\r'})
+	return b, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Writer implements FASTQ format writing.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer to the given io.Writer.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes r to the FASTQ stream.
+func (w *Writer) Write(r *Record) error {
+	b, err := r.MarshalText()
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,320 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastq
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestReadWrite(t *testing.T) {
+	const fq = "@r1 1:N:0:ACGT\n" +
+		"ACGTN\n" +
+		"+\n" +
+		"IIII!\n" +
+		"\n" +
+		"@r2\n" +
+		"\n" +
+		"+r2\n" +
+		"\n" +
+		"@r3\tBC:Z:AC\r\n" +
+		"GG\r\n" +
+		"+\r\n" +
+		"5#"
+	want := []*Record{
+		{Name: "r1", Comment: "1:N:0:ACGT", Seq: []byte("ACGTN"), Qual: []byte{40, 40, 40, 40, 0}},
+		{Name: "r2", Seq: []byte{}, Qual: []byte{}},
+		{Name: "r3", Comment: "BC:Z:AC", Seq: []byte("GG"), Qual: []byte{20, 2}},
+	}
+	r := NewReader(strings.NewReader(fq))
+	var got []*Record
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+		got = append(got, rec)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected records:\ngot: %+v\nwant:%+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, rec := range got {
+		err := w.Write(rec)
+		if err != nil {
+			t.Fatalf("unexpected error writing: %v", err)
+		}
+	}
+	const wantText = "@r1 1:N:0:ACGT\nACGTN\n+\nIIII!\n" +
+		"@r2\n\n+\n\n" +
+		"@r3 BC:Z:AC\nGG\n+\n5#\n"
+	if buf.String() != wantText {
+		t.Errorf("unexpected output:\ngot:\n%s\nwant:\n%s", &buf, wantText)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, test := range []struct {
+		fq  string
+		err error
+	}{
+		{fq: ">r\nACGT\n+\nIIII\n", err: ErrBadHeader},
+		{fq: "@r\nACGT\n-\nIIII\n", err: ErrBadPlus},
+		{fq: "@r\nACGT\n+\nIII\n", err: ErrLength},
+		{fq: "@r\nACGT\n+\nII I\n", err: ErrBadQuality},
+		{fq: "@r\nACGT\n+\n", err: io.ErrUnexpectedEOF},
+		{fq: "@r\nACGT\n", err: io.ErrUnexpectedEOF},
+	} {
+		_, err := NewReader(strings.NewReader(test.fq)).Read()
+		if err == nil || !strings.HasPrefix(err.Error(), test.err.Error()) {
+			t.Errorf("unexpected error for %q: got:%v want:%v", test.fq, err, test.err)
+		}
+	}
+	err := NewWriter(io.Discard).Write(&Record{Name: "r", Seq: []byte("AC"), Qual: []byte{1}})
+	if err != ErrLength {
+		t.Errorf("unexpected error for length mismatch: got:%v want:%v", err, ErrLength)
+	}
+}
+
+func newSAM(t *testing.T, name string, flags sam.Flags, seq string, qual []byte, aux ...sam.Aux) *sam.Record {
+	r, err := sam.NewRecord(name, nil, nil, -1, -1, 0, 0, nil, []byte(seq), qual, aux)
+	if err != nil {
+		t.Fatalf("unexpected error creating record: %v", err)
+	}
+	r.Flags = flags
+	return r
+}
+
+func mustAux(t *testing.T, tag string, v interface{}) sam.Aux {
+	a, err := sam.NewAux(sam.NewTag(tag), v)
+	if err != nil {
+		t.Fatalf("unexpected error creating aux: %v", err)
+	}
+	return a
+}
+
+func TestFromSAM(t *testing.T) {
+	bc := mustAux(t, "BC", "ACGT")
+	rx := mustAux(t, "RX", "TTGA")
+	arr := mustAux(t, "XB", []int16{1, -2})
+	for _, test := range []struct {
+		rec  *sam.Record
+		opts *SAMOptions
+		want *Record
+	}{
+		{
+			rec:  newSAM(t, "r", sam.Paired|sam.Read1, "AACGN", []byte{1, 2, 3, 4, 5}, bc, rx),
+			opts: &SAMOptions{Tags: []sam.Tag{sam.NewTag("RX"), sam.NewTag("BC"), sam.NewTag("OX")}},
+			want: &Record{Name: "r/1", Comment: "RX:Z:TTGA\tBC:Z:ACGT", Seq: []byte("AACGN"), Qual: []byte{1, 2, 3, 4, 5}},
+		},
+		{
+			rec:  newSAM(t, "r", sam.Paired|sam.Read2|sam.Reverse, "AACGN", []byte{1, 2, 3, 4, 5}, arr),
+			opts: &SAMOptions{Tags: []sam.Tag{sam.NewTag("XB")}},
+			want: &Record{Name: "r/2", Comment: "XB:B:s,1,-2", Seq: []byte("NCGTT"), Qual: []byte{5, 4, 3, 2, 1}},
+		},
+		{
+			rec:  newSAM(t, "r", sam.Paired|sam.Read2, "ACG", nil),
+			opts: &SAMOptions{NoSuffix: true},
+			want: &Record{Name: "r", Seq: []byte("ACG"), Qual: []byte{1, 1, 1}},
+		},
+		{
+			rec:  newSAM(t, "r", sam.Reverse, "ACG", []byte{0xff, 0xff, 0xff}),
+			opts: &SAMOptions{MissingQuality: 30},
+			want: &Record{Name: "r", Seq: []byte("CGT"), Qual: []byte{30, 30, 30}},
+		},
+	} {
+		got := FromSAM(test.rec, test.opts)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected record:\ngot: %+v\nwant:%+v", got, test.want)
+		}
+	}
+}
+
+func TestSAMConverter(t *testing.T) {
+	q := func(n int) []byte { return bytes.Repeat([]byte{30}, n) }
+	recs := []*sam.Record{
+		newSAM(t, "a", sam.Paired|sam.Read2, "CC", q(2)),
+		newSAM(t, "b", sam.Paired|sam.Read1, "GG", q(2)),
+		newSAM(t, "a", sam.Paired|sam.Read1|sam.Supplementary, "TTTT", q(4)),
+		newSAM(t, "a", sam.Paired|sam.Read1, "AA", q(2)),
+		newSAM(t, "c", 0, "AC", q(2)),
+		newSAM(t, "c", sam.Secondary, "AC", q(2)),
+		newSAM(t, "b", sam.Paired|sam.Read2, "TT", q(2)),
+		newSAM(t, "d", sam.Paired|sam.Read2, "GA", q(2)),
+	}
+	var r1, r2, s bytes.Buffer
+	c := NewSAMConverter(NewWriter(&r1), NewWriter(&r2), NewWriter(&s), nil)
+	for _, r := range recs {
+		err := c.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing: %v", err)
+		}
+	}
+	err := c.Flush()
+	if err != nil {
+		t.Fatalf("unexpected error flushing: %v", err)
+	}
+	for _, test := range []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "read1", got: r1.String(), want: "@a/1\nAA\n+\n??\n@b/1\nGG\n+\n??\n"},
+		{name: "read2", got: r2.String(), want: "@a/2\nCC\n+\n??\n@b/2\nTT\n+\n??\n"},
+		{name: "single", got: s.String(), want: "@c\nAC\n+\n??\n@d/2\nGA\n+\n??\n"},
+	} {
+		if test.got != test.want {
+			t.Errorf("unexpected %s output:\ngot:\n%s\nwant:\n%s", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestToSAM(t *testing.T) {
+	for _, test := range []struct {
+		rec   *Record
+		flags sam.Flags
+		opts  *ImportOptions
+		want  string
+	}{
+		{
+			rec:  &Record{Name: "r", Comment: "BC:Z:ACGT\tQT:Z:IIII RX:Z:AAC-GGT XX:Z:dropped", Seq: []byte("ACGT"), Qual: []byte{30, 30, 30, 30}},
+			opts: &ImportOptions{ReadGroup: "rg1"},
+			want: "r\t4\t*\t0\t0\t*\t*\t0\t0\tACGT\t????\tRG:Z:rg1\tBC:Z:ACGT\tQT:Z:IIII\tRX:Z:AAC-GGT",
+		},
+		{
+			rec:   &Record{Name: "M1:1:FC:1:1:10:20:ACGT+TTTT", Comment: "1:Y:0:GGCC+AATT", Seq: []byte("A"), Qual: []byte{2}},
+			flags: sam.Paired | sam.Read1,
+			want:  "M1:1:FC:1:1:10:20:ACGT+TTTT\t589\t*\t0\t0\t*\t*\t0\t0\tA\t#\tBC:Z:GGCC-AATT\tRX:Z:ACGT-TTTT",
+		},
+		{
+			rec:  &Record{Name: "r/2", Comment: "2:N:0:1", Seq: []byte("A"), Qual: []byte{2}},
+			want: "r\t141\t*\t0\t0\t*\t*\t0\t0\tA\t#",
+		},
+		{
+			rec:  &Record{Name: "M1:1:FC:1:1:10:20:ACGT", Comment: "1:N:0:GGCC", Seq: []byte("A"), Qual: []byte{2}},
+			opts: &ImportOptions{NoIllumina: true},
+			want: "M1:1:FC:1:1:10:20:ACGT\t4\t*\t0\t0\t*\t*\t0\t0\tA\t#",
+		},
+	} {
+		rec, err := ToSAM(test.rec, test.flags, test.opts)
+		if err != nil {
+			t.Errorf("unexpected error converting %+v: %v", test.rec, err)
+			continue
+		}
+		got, err := rec.MarshalText()
+		if err != nil {
+			t.Fatalf("unexpected error marshaling: %v", err)
+		}
+		if string(got) != test.want {
+			t.Errorf("unexpected record:\ngot: %q\nwant:%q", got, test.want)
+		}
+	}
+
+	_, err := ToSAM(&Record{Name: "r", Comment: "BC:i:x", Seq: []byte("A"), Qual: []byte{2}}, 0, nil)
+	if err == nil {
+		t.Error("expected error for invalid comment tag")
+	}
+}
+
+func TestImporter(t *testing.T) {
+	const (
+		fq1 = "@a/1 BC:Z:AC\nAC\n+\nII\n@b/1\nGG\n+\nII\n"
+		fq2 = "@a/2 BC:Z:AC\nTT\n+\nII\n@b/2\nCC\n+\nII\n"
+	)
+	im := NewImporter(NewReader(strings.NewReader(fq1)), NewReader(strings.NewReader(fq2)), nil)
+	var got []string
+	for {
+		recs, err := im.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error importing: %v", err)
+		}
+		for _, r := range recs {
+			b, err := r.MarshalText()
+			if err != nil {
+				t.Fatalf("unexpected error marshaling: %v", err)
+			}
+			got = append(got, string(b))
+		}
+	}
+	want := []string{
+		"a\t77\t*\t0\t0\t*\t*\t0\t0\tAC\tII\tBC:Z:AC",
+		"a\t141\t*\t0\t0\t*\t*\t0\t0\tTT\tII\tBC:Z:AC",
+		"b\t77\t*\t0\t0\t*\t*\t0\t0\tGG\tII",
+		"b\t141\t*\t0\t0\t*\t*\t0\t0\tCC\tII",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected records:\ngot: %q\nwant:%q", got, want)
+	}
+
+	// Round trip through the SAM converter.
+	var r1, r2 bytes.Buffer
+	c := NewSAMConverter(NewWriter(&r1), NewWriter(&r2), nil, &SAMOptions{Tags: []sam.Tag{sam.NewTag("BC")}})
+	im = NewImporter(NewReader(strings.NewReader(fq1)), NewReader(strings.NewReader(fq2)), nil)
+	for {
+		recs, err := im.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error importing: %v", err)
+		}
+		for _, r := range recs {
+			err = c.Write(r)
+			if err != nil {
+				t.Fatalf("unexpected error converting: %v", err)
+			}
+		}
+	}
+	if r1.String() != fq1 {
+		t.Errorf("read 1 did not round trip:\ngot:\n%s\nwant:\n%s", &r1, fq1)
+	}
+	if r2.String() != fq2 {
+		t.Errorf("read 2 did not round trip:\ngot:\n%s\nwant:\n%s", &r2, fq2)
+	}
+
+	for _, test := range []struct{ fq1, fq2 string }{
+		{fq1: "@a/1\nA\n+\nI\n", fq2: "@b/2\nA\n+\nI\n"},
+		{fq1: "@a/1\nA\n+\nI\n", fq2: ""},
+	} {
+		im = NewImporter(NewReader(strings.NewReader(test.fq1)), NewReader(strings.NewReader(test.fq2)), nil)
+		_, err := im.Read()
+		if err == nil || err == io.EOF {
+			t.Errorf("expected error for mismatched pair input: %q %q", test.fq1, test.fq2)
+		}
+	}
+}
+
+func TestMarshalFASTA(t *testing.T) {
+	r := &Record{Name: "chr1:1-10", Comment: "consensus", Seq: []byte("ACGTNACGTA")}
+	for _, test := range []struct {
+		width int
+		want  string
+	}{
+		{width: 0, want: ">chr1:1-10 consensus\nACGTNACGTA"},
+		{width: 4, want: ">chr1:1-10 consensus\nACGT\nNACG\nTA"},
+		{width: 10, want: ">chr1:1-10 consensus\nACGTNACGTA"},
+	} {
+		got := string(r.MarshalFASTA(test.width))
+		if got != test.want {
+			t.Errorf("unexpected FASTA for width %d:\ngot: %q\nwant:%q", test.width, got, test.want)
+		}
+	}
+	if got := string((&Record{Name: "empty"}).MarshalFASTA(60)); got != ">empty" {
+		t.Errorf("unexpected FASTA for empty record: %q", got)
+	}
+}
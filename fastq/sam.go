@@ -0,0 +1,401 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+// defaultMissingQuality is the quality score written for bases of
+// SAM records without quality scores, matching samtools fastq.
+const defaultMissingQuality = 1
+
+// SAMOptions specifies how SAM records are converted to FASTQ records.
+type SAMOptions struct {
+	// Secondary and Supplementary specify whether
+	// secondary and supplementary alignments are
+	// converted. By default they are skipped.
+	Secondary     bool
+	Supplementary bool
+
+	// NoSuffix specifies that "/1" and "/2" are not
+	// appended to the names of the first and second
+	// reads of a pair.
+	NoSuffix bool
+
+	// Tags lists the aux tags that are written to the
+	// FASTQ comment in SAM format, separated by tabs.
+	// Tags not present in a record are omitted.
+	Tags []sam.Tag
+
+	// MissingQuality is the quality score written for
+	// records without quality scores. If zero, a score
+	// of 1 is used.
+	MissingQuality byte
+}
+
+// FromSAM returns a FASTQ record holding the sequence and quality of
+// the SAM record r in the orientation it was sequenced. If opts is nil,
+// default options are used.
+func FromSAM(r *sam.Record, opts *SAMOptions) *Record {
+	if opts == nil {
+		opts = &SAMOptions{}
+	}
+	rec := &Record{
+		Name: r.Name,
+		Seq:  r.Seq.Expand(),
+		Qual: append([]byte(nil), r.Qual...),
+	}
+	if !opts.NoSuffix && r.Flags&sam.Paired != 0 {
+		switch r.Flags & (sam.Read1 | sam.Read2) {
+		case sam.Read1:
+			rec.Name += "/1"
+		case sam.Read2:
+			rec.Name += "/2"
+		}
+	}
+	if len(rec.Qual) != len(rec.Seq) || allMissing(rec.Qual) {
+		q := opts.MissingQuality
+		if q == 0 {
+			q = defaultMissingQuality
+		}
+		rec.Qual = bytes.Repeat([]byte{q}, len(rec.Seq))
+	}
+	if r.Flags&sam.Reverse != 0 {
+		samutil.ReverseComplement(rec.Seq)
+		samutil.Reverse(rec.Qual)
+	}
+	var comment []string
+	for _, t := range opts.Tags {
+		aux := r.AuxTag(t)
+		if aux != nil {
+			comment = append(comment, formatAux(aux))
+		}
+	}
+	rec.Comment = strings.Join(comment, "\t")
+	return rec
+}
+
+// allMissing returns whether q holds only missing quality scores.
+func allMissing(q []byte) bool {
+	for _, v := range q {
+		if v != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// formatAux returns the SAM text representation of a.
+func formatAux(a sam.Aux) string {
+	if a.Type() != 'B' {
+		return a.String()
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s:B:%c", a.Tag(), a[3])
+	rv := reflect.ValueOf(a.Value())
+	for i := 0; i < rv.Len(); i++ {
+		fmt.Fprintf(&buf, ",%v", rv.Index(i).Interface())
+	}
+	return buf.String()
+}
+
+// SAMConverter writes SAM records to FASTQ streams, separating the
+// first and second reads of pairs. Mates do not need to be adjacent
+// in the input, but mates that have not yet been paired are held in
+// memory, so name-collated input is most efficient.
+type SAMConverter struct {
+	read1, read2, single *Writer
+
+	opts    SAMOptions
+	pending map[string]*sam.Record
+}
+
+// NewSAMConverter returns a SAMConverter writing the first and second
+// reads of pairs to read1 and read2 and unpaired reads and reads whose
+// mate is not seen to single. If single is nil, those reads are
+// discarded. If opts is nil, default options are used.
+func NewSAMConverter(read1, read2, single *Writer, opts *SAMOptions) *SAMConverter {
+	c := &SAMConverter{
+		read1:   read1,
+		read2:   read2,
+		single:  single,
+		pending: make(map[string]*sam.Record),
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+	return c
+}
+
+// Write converts r and writes it to the appropriate FASTQ stream once
+// its mate has been seen. Secondary and supplementary alignments are
+// skipped unless included by the converter's options.
+func (c *SAMConverter) Write(r *sam.Record) error {
+	if r.Flags&sam.Secondary != 0 && !c.opts.Secondary {
+		return nil
+	}
+	if r.Flags&sam.Supplementary != 0 && !c.opts.Supplementary {
+		return nil
+	}
+	end := r.Flags & (sam.Read1 | sam.Read2)
+	if r.Flags&sam.Paired == 0 || end == 0 || end == sam.Read1|sam.Read2 {
+		return c.writeSingle(r)
+	}
+	m, ok := c.pending[r.Name]
+	if !ok || m.Flags&(sam.Read1|sam.Read2) == end {
+		if ok {
+			// A repeated end for the same name cannot be
+			// paired with the held read, so the held read
+			// is treated as an orphan.
+			err := c.writeSingle(m)
+			if err != nil {
+				return err
+			}
+		}
+		c.pending[r.Name] = r
+		return nil
+	}
+	delete(c.pending, r.Name)
+	if end == sam.Read2 {
+		r, m = m, r
+	}
+	err := c.read1.Write(FromSAM(r, &c.opts))
+	if err != nil {
+		return err
+	}
+	return c.read2.Write(FromSAM(m, &c.opts))
+}
+
+// Flush writes all reads still waiting for their mates to the single
+// read stream, or discards them if there is no single read stream.
+// Reads are written in name order.
+func (c *SAMConverter) Flush() error {
+	names := make([]string, 0, len(c.pending))
+	for n := range c.pending {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		err := c.writeSingle(c.pending[n])
+		if err != nil {
+			return err
+		}
+		delete(c.pending, n)
+	}
+	return nil
+}
+
+func (c *SAMConverter) writeSingle(r *sam.Record) error {
+	if c.single == nil {
+		return nil
+	}
+	return c.single.Write(FromSAM(r, &c.opts))
+}
+
+// ImportOptions specifies how FASTQ records are converted to unaligned
+// SAM records.
+type ImportOptions struct {
+	// ReadGroup is the read group identifier added to
+	// each record as an RG tag. If empty, no RG tag is
+	// added.
+	ReadGroup string
+
+	// NoIllumina specifies that Illumina read name and
+	// comment conventions are not interpreted.
+	NoIllumina bool
+}
+
+// importTags are the SAM format tags retained from FASTQ comments.
+var importTags = map[sam.Tag]bool{
+	sam.NewTag("BC"): true,
+	sam.NewTag("QT"): true,
+	sam.NewTag("RX"): true,
+	sam.NewTag("OX"): true,
+	sam.NewTag("BZ"): true,
+	sam.NewTag("MI"): true,
+	sam.NewTag("CB"): true,
+	sam.NewTag("CR"): true,
+	sam.NewTag("CY"): true,
+	sam.NewTag("RG"): true,
+}
+
+// ToSAM returns an unaligned SAM record holding the sequence and
+// quality of r. The flags provided are combined with sam.Unmapped and,
+// for paired reads, sam.MateUnmapped. A "/1" or "/2" suffix on the read
+// name is removed and, if flags does not specify the read's end, used
+// to set it.
+//
+// Barcode, UMI and barcode quality tags (BC, RX, QT and their related
+// tags) written in SAM format in the FASTQ comment are stored in the
+// record. Unless opts.NoIllumina is set, a sample barcode in a CASAVA
+// 1.8 comment ("1:N:0:ACGT+TTGA") is stored as BC and a UMI in the
+// eighth colon-separated field of the read name is stored as RX, with
+// dual index separators converted from '+' to '-'; a CASAVA filter
+// flag of Y sets sam.QCFail. If opts is nil, default options are used.
+func ToSAM(r *Record, flags sam.Flags, opts *ImportOptions) (*sam.Record, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	name := r.Name
+	switch {
+	case strings.HasSuffix(name, "/1"):
+		name = name[:len(name)-2]
+		if flags&(sam.Read1|sam.Read2) == 0 {
+			flags |= sam.Paired | sam.Read1
+		}
+	case strings.HasSuffix(name, "/2"):
+		name = name[:len(name)-2]
+		if flags&(sam.Read1|sam.Read2) == 0 {
+			flags |= sam.Paired | sam.Read2
+		}
+	}
+
+	var (
+		aux  []sam.Aux
+		seen = make(map[sam.Tag]bool)
+	)
+	add := func(t sam.Tag, v interface{}) error {
+		if seen[t] {
+			return nil
+		}
+		a, err := sam.NewAux(t, v)
+		if err != nil {
+			return err
+		}
+		seen[t] = true
+		aux = append(aux, a)
+		return nil
+	}
+	if opts.ReadGroup != "" {
+		err := add(sam.NewTag("RG"), opts.ReadGroup)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range strings.Fields(r.Comment) {
+		if len(f) > 5 && f[2] == ':' && f[4] == ':' {
+			a, err := sam.ParseAux([]byte(f))
+			if err != nil {
+				return nil, fmt.Errorf("fastq: invalid tag in comment of %q: %v", r.Name, err)
+			}
+			if importTags[a.Tag()] && !seen[a.Tag()] {
+				seen[a.Tag()] = true
+				aux = append(aux, a)
+			}
+			continue
+		}
+		if opts.NoIllumina {
+			continue
+		}
+		casava := strings.Split(f, ":")
+		if len(casava) != 4 || (casava[1] != "Y" && casava[1] != "N") {
+			continue
+		}
+		if casava[1] == "Y" {
+			flags |= sam.QCFail
+		}
+		if bc := casava[3]; bc != "" && !isNumeric(bc) {
+			err := add(sam.NewTag("BC"), strings.ReplaceAll(bc, "+", "-"))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !opts.NoIllumina {
+		if f := strings.Split(name, ":"); len(f) == 8 && f[7] != "" {
+			err := add(sam.NewTag("RX"), strings.ReplaceAll(f[7], "+", "-"))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	qual := append([]byte(nil), r.Qual...)
+	rec, err := sam.NewRecord(name, nil, nil, -1, -1, 0, 0, nil, r.Seq, qual, aux)
+	if err != nil {
+		return nil, err
+	}
+	rec.Flags = flags | sam.Unmapped
+	if rec.Flags&sam.Paired != 0 {
+		rec.Flags |= sam.MateUnmapped
+	}
+	return rec, nil
+}
+
+// isNumeric returns whether s is a non-empty string of decimal digits.
+// Numeric CASAVA index fields hold sample sheet numbers rather than
+// barcode sequence.
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Importer reads FASTQ records from one or two streams and returns
+// unaligned SAM records.
+type Importer struct {
+	read1, read2 *Reader
+	opts         *ImportOptions
+}
+
+// NewImporter returns an Importer reading single-end reads from read1,
+// or paired reads from read1 and read2 if read2 is not nil. If opts is
+// nil, default options are used.
+func NewImporter(read1, read2 *Reader, opts *ImportOptions) *Importer {
+	return &Importer{read1: read1, read2: read2, opts: opts}
+}
+
+// Read returns the next read or read pair as unaligned SAM records.
+// For paired input, the names of the two reads must match after
+// removal of any "/1" and "/2" suffix.
+func (im *Importer) Read() ([]*sam.Record, error) {
+	r1, err := im.read1.Read()
+	if im.read2 == nil {
+		if err != nil {
+			return nil, err
+		}
+		rec, err := ToSAM(r1, 0, im.opts)
+		if err != nil {
+			return nil, err
+		}
+		return []*sam.Record{rec}, nil
+	}
+	r2, err2 := im.read2.Read()
+	switch {
+	case err == io.EOF && err2 == io.EOF:
+		return nil, io.EOF
+	case err == io.EOF || err2 == io.EOF:
+		return nil, errors.New("fastq: paired streams have different numbers of reads")
+	case err != nil:
+		return nil, err
+	case err2 != nil:
+		return nil, err2
+	}
+	rec1, err := ToSAM(r1, sam.Paired|sam.Read1, im.opts)
+	if err != nil {
+		return nil, err
+	}
+	rec2, err := ToSAM(r2, sam.Paired|sam.Read2, im.opts)
+	if err != nil {
+		return nil, err
+	}
+	if rec1.Name != rec2.Name {
+		return nil, fmt.Errorf("fastq: mismatched read names: %q and %q", r1.Name, r2.Name)
+	}
+	return []*sam.Record{rec1, rec2}, nil
+}
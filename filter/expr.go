@@ -0,0 +1,679 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// Parse returns a filter expression parsed from src using the
+// expression language of samtools view -e. The header h is used to
+// resolve the library of records' read groups and may be nil.
+//
+// Expressions are built from numeric and quoted string literals, aux
+// tag values written as [XX], record fields and the operators, in
+// order of increasing binding
+//
+//	||
+//	&&
+//	|
+//	^
+//	&
+//	== != =~ !~
+//	< <= > >=
+//	<< >>
+//	+ -
+//	* / %
+//	! ~ - + (unary)
+//
+// The right hand operand of the regular expression operators =~ and !~
+// must be a string literal holding a Go regular expression.
+//
+// The record fields are
+//
+//	endpos      alignment end position (1-based)
+//	flag        flag value
+//	flag.X      flag bit X, one of paired, proper_pair, unmap,
+//	            munmap, reverse, mreverse, read1, read2,
+//	            secondary, qcfail, dup and supplementary
+//	hclen       number of hard clipped bases
+//	library     library of the record's read group
+//	mapq        mapping quality
+//	mpos        mate position (1-based), also pnext
+//	mrefid      mate reference ID
+//	mrname      mate reference name, also rnext
+//	ncigar      number of CIGAR operations
+//	pos         alignment position (1-based)
+//	qlen        number of query bases in the CIGAR
+//	qname       read name
+//	qual        base qualities as a phred+33 string
+//	refid       reference ID
+//	rlen        number of reference bases in the CIGAR
+//	rname       reference name
+//	sclen       number of soft clipped bases
+//	seq         read sequence
+//	tlen        template length
+//
+// An aux tag absent from a record has a null value; comparisons with
+// null are false and a null value is false when tested for truth.
+// Non-zero numbers and non-empty strings are true.
+func Parse(src string, h *sam.Header) (Expr, error) {
+	p := &parser{lex: lexer{src: src}, libs: libraries(h)}
+	err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	n, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+	return toExpr(n), nil
+}
+
+func libraries(h *sam.Header) map[string]string {
+	libs := make(map[string]string)
+	if h == nil {
+		return libs
+	}
+	for _, rg := range h.RGs() {
+		libs[rg.Name()] = rg.Library()
+	}
+	return libs
+}
+
+// toExpr converts a parsed node to an Expr, mapping logical operators
+// and simple flag and mapping quality tests to their filter
+// equivalents so that they may be simplified.
+func toExpr(n node) Expr {
+	switch n := n.(type) {
+	case binaryNode:
+		switch n.op {
+		case "&&":
+			return And(toExpr(n.l), toExpr(n.r))
+		case "||":
+			return Or(toExpr(n.l), toExpr(n.r))
+		case ">=", ">":
+			if v, ok := n.l.(varNode); ok && v.name == "mapq" {
+				if c, ok := n.r.(constNode); ok && c.kind == numVal && c.n >= 0 && c.n < 256 {
+					q := math.Floor(c.n)
+					if n.op == ">" || q < c.n {
+						q++
+					}
+					if q > 255 {
+						return False()
+					}
+					return MinMapQ(byte(q))
+				}
+			}
+		}
+	case unaryNode:
+		if n.op == "!" {
+			return Not(toExpr(n.x))
+		}
+	case flagNode:
+		return Flags(n.flag, 0)
+	case constNode:
+		return constExpr(n.truth())
+	}
+	return Func(func(r *sam.Record) bool { return n.eval(r).truth() })
+}
+
+type valKind int
+
+const (
+	nullVal valKind = iota
+	numVal
+	strVal
+)
+
+// value is the result of evaluating an expression node.
+type value struct {
+	kind valKind
+	n    float64
+	s    string
+}
+
+func (v value) truth() bool {
+	switch v.kind {
+	case numVal:
+		return v.n != 0
+	case strVal:
+		return v.s != ""
+	}
+	return false
+}
+
+func num(f float64) value { return value{kind: numVal, n: f} }
+
+func boolean(b bool) value {
+	if b {
+		return num(1)
+	}
+	return num(0)
+}
+
+// node is a parsed expression node.
+type node interface {
+	eval(*sam.Record) value
+}
+
+type constNode value
+
+func (n constNode) eval(*sam.Record) value { return value(n) }
+func (n constNode) truth() bool            { return value(n).truth() }
+
+type tagNode sam.Tag
+
+func (n tagNode) eval(r *sam.Record) value {
+	a := r.AuxTag(sam.Tag(n))
+	if a == nil {
+		return value{}
+	}
+	switch a.Kind() {
+	case 'A':
+		return value{kind: strVal, s: string(rune(a.Value().(byte)))}
+	case 'Z':
+		return value{kind: strVal, s: a.Value().(string)}
+	case 'H':
+		return value{kind: strVal, s: fmt.Sprintf("%X", a.Value())}
+	case 'i', 'f':
+		v, ok := normalize(a.Value()).(int64)
+		if ok {
+			return num(float64(v))
+		}
+		return num(normalize(a.Value()).(float64))
+	}
+	return value{}
+}
+
+type flagNode struct {
+	flag sam.Flags
+}
+
+func (n flagNode) eval(r *sam.Record) value { return boolean(r.Flags&n.flag != 0) }
+
+var flagNames = map[string]sam.Flags{
+	"paired":        sam.Paired,
+	"proper_pair":   sam.ProperPair,
+	"unmap":         sam.Unmapped,
+	"munmap":        sam.MateUnmapped,
+	"reverse":       sam.Reverse,
+	"mreverse":      sam.MateReverse,
+	"read1":         sam.Read1,
+	"read2":         sam.Read2,
+	"secondary":     sam.Secondary,
+	"qcfail":        sam.QCFail,
+	"dup":           sam.Duplicate,
+	"supplementary": sam.Supplementary,
+}
+
+type varNode struct {
+	name string
+	fn   func(*sam.Record) value
+}
+
+func (n varNode) eval(r *sam.Record) value { return n.fn(r) }
+
+func str(s string) value { return value{kind: strVal, s: s} }
+
+func refName(ref *sam.Reference) string {
+	if ref == nil {
+		return "*"
+	}
+	return ref.Name()
+}
+
+func clipped(r *sam.Record, t sam.CigarOpType) value {
+	var n int
+	for _, co := range r.Cigar {
+		if co.Type() == t {
+			n += co.Len()
+		}
+	}
+	return num(float64(n))
+}
+
+func (p *parser) variable(name string) (node, bool) {
+	if strings.HasPrefix(name, "flag.") {
+		f, ok := flagNames[strings.TrimPrefix(name, "flag.")]
+		if !ok {
+			return nil, false
+		}
+		return flagNode{flag: f}, true
+	}
+	var fn func(*sam.Record) value
+	switch name {
+	case "endpos":
+		fn = func(r *sam.Record) value { return num(float64(r.End())) }
+	case "flag":
+		fn = func(r *sam.Record) value { return num(float64(r.Flags)) }
+	case "hclen":
+		fn = func(r *sam.Record) value { return clipped(r, sam.CigarHardClipped) }
+	case "library":
+		libs := p.libs
+		fn = func(r *sam.Record) value {
+			a := r.AuxTag(rgTag)
+			if a == nil {
+				return value{}
+			}
+			id, _ := a.Value().(string)
+			lib, ok := libs[id]
+			if !ok {
+				return value{}
+			}
+			return str(lib)
+		}
+	case "mapq":
+		fn = func(r *sam.Record) value { return num(float64(r.MapQ)) }
+	case "mpos", "pnext":
+		fn = func(r *sam.Record) value { return num(float64(r.MatePos + 1)) }
+	case "mrefid":
+		fn = func(r *sam.Record) value { return num(float64(r.MateRef.ID())) }
+	case "mrname", "rnext":
+		fn = func(r *sam.Record) value { return str(refName(r.MateRef)) }
+	case "ncigar":
+		fn = func(r *sam.Record) value { return num(float64(len(r.Cigar))) }
+	case "pos":
+		fn = func(r *sam.Record) value { return num(float64(r.Pos + 1)) }
+	case "qlen":
+		fn = func(r *sam.Record) value {
+			_, q := r.Cigar.Lengths()
+			return num(float64(q))
+		}
+	case "qname":
+		fn = func(r *sam.Record) value { return str(r.Name) }
+	case "qual":
+		fn = func(r *sam.Record) value {
+			q := make([]byte, len(r.Qual))
+			for i, v := range r.Qual {
+				q[i] = v + 33
+			}
+			return str(string(q))
+		}
+	case "refid":
+		fn = func(r *sam.Record) value { return num(float64(r.Ref.ID())) }
+	case "rlen":
+		fn = func(r *sam.Record) value {
+			ref, _ := r.Cigar.Lengths()
+			return num(float64(ref))
+		}
+	case "rname":
+		fn = func(r *sam.Record) value { return str(refName(r.Ref)) }
+	case "sclen":
+		fn = func(r *sam.Record) value { return clipped(r, sam.CigarSoftClipped) }
+	case "seq":
+		fn = func(r *sam.Record) value { return str(string(r.Seq.Expand())) }
+	case "tlen":
+		fn = func(r *sam.Record) value { return num(float64(r.TempLen)) }
+	default:
+		return nil, false
+	}
+	return varNode{name: name, fn: fn}, true
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n unaryNode) eval(r *sam.Record) value {
+	v := n.x.eval(r)
+	if n.op == "!" {
+		return boolean(!v.truth())
+	}
+	if v.kind != numVal {
+		return value{}
+	}
+	switch n.op {
+	case "-":
+		return num(-v.n)
+	case "~":
+		return num(float64(^int64(v.n)))
+	}
+	return v
+}
+
+type binaryNode struct {
+	op   string
+	l, r node
+}
+
+func (n binaryNode) eval(r *sam.Record) value {
+	switch n.op {
+	case "&&":
+		return boolean(n.l.eval(r).truth() && n.r.eval(r).truth())
+	case "||":
+		return boolean(n.l.eval(r).truth() || n.r.eval(r).truth())
+	}
+	a, b := n.l.eval(r), n.r.eval(r)
+	if a.kind == nullVal || b.kind == nullVal {
+		if n.op == "==" || n.op == "!=" || n.op == "<" || n.op == "<=" || n.op == ">" || n.op == ">=" {
+			return boolean(false)
+		}
+		return value{}
+	}
+	if a.kind != b.kind {
+		switch n.op {
+		case "==", "<", "<=", ">", ">=":
+			return boolean(false)
+		case "!=":
+			return boolean(true)
+		}
+		return value{}
+	}
+	if a.kind == strVal {
+		switch n.op {
+		case "==":
+			return boolean(a.s == b.s)
+		case "!=":
+			return boolean(a.s != b.s)
+		case "<":
+			return boolean(a.s < b.s)
+		case "<=":
+			return boolean(a.s <= b.s)
+		case ">":
+			return boolean(a.s > b.s)
+		case ">=":
+			return boolean(a.s >= b.s)
+		case "+":
+			return str(a.s + b.s)
+		}
+		return value{}
+	}
+	x, y := a.n, b.n
+	switch n.op {
+	case "==":
+		return boolean(x == y)
+	case "!=":
+		return boolean(x != y)
+	case "<":
+		return boolean(x < y)
+	case "<=":
+		return boolean(x <= y)
+	case ">":
+		return boolean(x > y)
+	case ">=":
+		return boolean(x >= y)
+	case "+":
+		return num(x + y)
+	case "-":
+		return num(x - y)
+	case "*":
+		return num(x * y)
+	case "/":
+		if y == 0 {
+			return value{}
+		}
+		return num(x / y)
+	case "%":
+		if int64(y) == 0 {
+			return value{}
+		}
+		return num(float64(int64(x) % int64(y)))
+	case "&":
+		return num(float64(int64(x) & int64(y)))
+	case "|":
+		return num(float64(int64(x) | int64(y)))
+	case "^":
+		return num(float64(int64(x) ^ int64(y)))
+	case "<<":
+		return num(float64(int64(x) << uint64(y)))
+	case ">>":
+		return num(float64(int64(x) >> uint64(y)))
+	}
+	return value{}
+}
+
+type matchNode struct {
+	x      node
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (n matchNode) eval(r *sam.Record) value {
+	v := n.x.eval(r)
+	if v.kind != strVal {
+		return boolean(false)
+	}
+	return boolean(n.re.MatchString(v.s) != n.negate)
+}
+
+// binding is the binding power of binary operators.
+var binding = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6, "=~": 6, "!~": 6,
+	"<": 7, "<=": 7, ">": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+type parser struct {
+	lex  lexer
+	tok  token
+	libs map[string]string
+}
+
+func (p *parser) next() error {
+	var err error
+	p.tok, err = p.lex.next()
+	return err
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("filter: %s at offset %d in %q", fmt.Sprintf(format, args...), p.tok.pos, p.lex.src)
+}
+
+// parse parses a binary expression with operators binding more tightly
+// than min.
+func (p *parser) parse(min int) (node, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp {
+		op := p.tok.text
+		bp, ok := binding[op]
+		if !ok || bp <= min {
+			break
+		}
+		err = p.next()
+		if err != nil {
+			return nil, err
+		}
+		if op == "=~" || op == "!~" {
+			if p.tok.kind != tokStr {
+				return nil, p.errorf("regular expression must be a string literal")
+			}
+			re, err := regexp.Compile(p.tok.text)
+			if err != nil {
+				return nil, p.errorf("invalid regular expression: %v", err)
+			}
+			err = p.next()
+			if err != nil {
+				return nil, err
+			}
+			l = matchNode{x: l, re: re, negate: op == "!~"}
+			continue
+		}
+		r, err := p.parse(bp)
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) unary() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokOp:
+		switch tok.text {
+		case "!", "~", "-", "+":
+			err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			x, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return unaryNode{op: tok.text, x: x}, nil
+		case "(":
+			err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			x, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokOp || p.tok.text != ")" {
+				return nil, p.errorf("missing )")
+			}
+			return x, p.next()
+		}
+	case tokNum:
+		f, err := parseNum(tok.text)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok.text)
+		}
+		return constNode(num(f)), p.next()
+	case tokStr:
+		return constNode(str(tok.text)), p.next()
+	case tokTag:
+		return tagNode(sam.NewTag(tok.text)), p.next()
+	case tokIdent:
+		v, ok := p.variable(tok.text)
+		if !ok {
+			return nil, p.errorf("unknown field %q", tok.text)
+		}
+		return v, p.next()
+	case tokEOF:
+		return nil, p.errorf("unexpected end of expression")
+	}
+	return nil, p.errorf("unexpected %q", tok.text)
+}
+
+func parseNum(s string) (float64, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		i, err := strconv.ParseInt(s[2:], 16, 64)
+		return float64(i), err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokOp
+	tokNum
+	tokStr
+	tokTag
+	tokIdent
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("filter: %s at offset %d in %q", fmt.Sprintf(format, args...), l.pos, l.src)
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && strings.IndexByte(" \t\n\r", l.src[l.pos]) >= 0 {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos == len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		for l.pos < len(l.src) && (isIdent(l.src[l.pos]) ||
+			((l.src[l.pos] == '+' || l.src[l.pos] == '-') && (l.src[l.pos-1] == 'e' || l.src[l.pos-1] == 'E') && !strings.HasPrefix(l.src[start:], "0x"))) {
+			l.pos++
+		}
+		return token{kind: tokNum, text: l.src[start:l.pos], pos: start}, nil
+	case isIdent(c):
+		for l.pos < len(l.src) && isIdent(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	case c == '[':
+		if l.pos+3 >= len(l.src) || l.src[l.pos+3] != ']' || !isIdent(l.src[l.pos+1]) || !isIdent(l.src[l.pos+2]) {
+			return token{}, l.errorf("invalid aux tag")
+		}
+		l.pos += 4
+		return token{kind: tokTag, text: l.src[start+1 : start+3], pos: start}, nil
+	case c == '"' || c == '\'':
+		var b strings.Builder
+		for l.pos++; l.pos < len(l.src); l.pos++ {
+			switch l.src[l.pos] {
+			case c:
+				l.pos++
+				return token{kind: tokStr, text: b.String(), pos: start}, nil
+			case '\\':
+				l.pos++
+				if l.pos == len(l.src) {
+					break
+				}
+				switch e := l.src[l.pos]; e {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(e)
+				}
+			default:
+				b.WriteByte(l.src[l.pos])
+			}
+		}
+		l.pos = start
+		return token{}, l.errorf("unterminated string")
+	}
+	for _, op := range []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<<", ">>"} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += 2
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	if strings.IndexByte("!~+-*/%&|^<>()", c) >= 0 {
+		l.pos++
+		return token{kind: tokOp, text: string(c), pos: start}, nil
+	}
+	return token{}, l.errorf("unexpected character %q", c)
+}
@@ -0,0 +1,125 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestParse(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	chr2, _ := sam.NewReference("chr2", "", "", 1000, nil, nil)
+	rg1, _ := sam.NewReadGroup("rg1", "", "", "libA", "", "", "", "", "", "", time.Time{}, 0)
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1, chr2})
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	err = h.AddReadGroup(rg1)
+	if err != nil {
+		t.Fatalf("unexpected error adding read group: %v", err)
+	}
+
+	b := htstestutil.NewBAMBuilder()
+	b.Record("read:a", "chr1", 99, "2H3S45M", strings.Repeat("A", 48)).MapQ(60).
+		Flags(sam.Paired|sam.ProperPair|sam.Read1).Mate("=", 299, 250).
+		Tag("NM", 1).Tag("RG", "rg1").Tag("XS", float32(12.5)).
+		Record("read:b", "chr1", 199, "20M", strings.Repeat("A", 20)).MapQ(10).
+		Flags(sam.Paired|sam.Duplicate|sam.Reverse|sam.Read2).Mate("chr2", 9, 0).
+		Tag("NM", 3).Tag("RG", "rg2").
+		Record("other", "chr2", 99, "30M", strings.Repeat("A", 30)).MapQ(30).
+		Flags(sam.Reverse).Tag("XA", sam.ASCII('x'))
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+
+	for _, test := range []struct {
+		expr string
+		want string
+	}{
+		{expr: "mapq >= 30", want: "ac"},
+		{expr: "mapq > 30", want: "a"},
+		{expr: "mapq >= 30 && [NM] <= 2 && flag.paired", want: "a"},
+		{expr: "flag.dup || flag.reverse && !flag.paired", want: "bc"},
+		{expr: "(flag.dup || flag.reverse) && !flag.paired", want: "c"},
+		{expr: "[NM]", want: "ab"},
+		{expr: "![NM]", want: "c"},
+		{expr: "[NM] != 1", want: "b"},
+		{expr: "[XS] > 12", want: "a"},
+		{expr: "[XA] == \"x\"", want: "c"},
+		{expr: "flag & 0x400", want: "b"},
+		{expr: "(flag & 16) == 16", want: "bc"},
+		{expr: "pos == 100", want: "ac"},
+		{expr: "endpos == 144", want: "a"},
+		{expr: "mpos == 300 && mrname == rname", want: "a"},
+		{expr: "rnext == 'chr2' && mrefid == 1", want: "b"},
+		{expr: "refid == 1", want: "c"},
+		{expr: "rname =~ '^chr[12]$' && qname !~ \"^read\"", want: "c"},
+		{expr: "qname =~ \":b$\"", want: "b"},
+		{expr: "library == \"libA\"", want: "a"},
+		{expr: "sclen == 3 && hclen == 2 && qlen == 48 && rlen == 45 && ncigar == 3", want: "a"},
+		{expr: "tlen > 0", want: "a"},
+		{expr: "seq =~ '^A+$' && qual == ''", want: "abc"},
+		{expr: "2 * 3 + 1 == 7 && 1 << 3 == 8 && -2 < 0 && 7 % 4 == 3 && 1.5e1 == 15", want: "abc"},
+		{expr: "~0 == -1 && (5 ^ 1) == 4 && (4 | 1) == 5 && 8 >> 2 == 2", want: "abc"},
+		{expr: "[ZZ] == 0 || [ZZ] != 0", want: ""},
+		{expr: "1 / 0", want: ""},
+	} {
+		e, err := Parse(test.expr, h)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", test.expr, err)
+			continue
+		}
+		fn := Compile(e)
+		var got string
+		for i, r := range recs {
+			if fn(r) {
+				got += string("abc"[i])
+			}
+		}
+		if got != test.want {
+			t.Errorf("unexpected result for %q: got:%q want:%q", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestParseCompiles(t *testing.T) {
+	e, err := Parse("flag.paired && !flag.dup && mapq > 20 && mapq >= 10", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := flagsExpr{require: sam.Paired, exclude: sam.Duplicate}
+	got, ok := simplify(e).(andExpr)
+	if !ok || len(got) != 2 || !reflect.DeepEqual(got[0], want) || got[1] != mapQExpr(21) {
+		t.Errorf("unexpected compiled expression: %#v", simplify(e))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"mapq >=",
+		"(mapq > 1",
+		"mapq > 1)",
+		"[N] > 1",
+		"unknown > 1",
+		"flag.unknown",
+		"qname =~ qname",
+		"qname =~ '('",
+		"qname == 'x",
+		"mapq $ 1",
+	} {
+		_, err := Parse(expr, nil)
+		if err == nil {
+			t.Errorf("expected error parsing %q", expr)
+		}
+	}
+}
@@ -0,0 +1,475 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package filter implements composable alignment record filters.
+//
+// Filters are built as expressions from predicates over record fields
+// and combined with And, Or and Not. Compile simplifies an expression,
+// merging flag and mapping quality tests and removing constant terms,
+// and returns a single function suitable for use as the Filter option
+// of the pileup and coverage packages, or with Reader and Writer.
+package filter
+
+import (
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Expr is a record filter expression.
+type Expr interface {
+	// compile returns a function returning
+	// whether a record satisfies the expression.
+	compile() func(*sam.Record) bool
+}
+
+// Compile returns a function returning whether a record satisfies e.
+func Compile(e Expr) func(*sam.Record) bool {
+	return simplify(e).compile()
+}
+
+type constExpr bool
+
+// True returns an expression satisfied by all records.
+func True() Expr { return constExpr(true) }
+
+// False returns an expression satisfied by no record.
+func False() Expr { return constExpr(false) }
+
+func (e constExpr) compile() func(*sam.Record) bool {
+	if e {
+		return func(*sam.Record) bool { return true }
+	}
+	return func(*sam.Record) bool { return false }
+}
+
+type funcExpr func(*sam.Record) bool
+
+// Func returns an expression satisfied by records for which fn returns
+// true.
+func Func(fn func(*sam.Record) bool) Expr { return funcExpr(fn) }
+
+func (e funcExpr) compile() func(*sam.Record) bool { return e }
+
+type flagsExpr struct {
+	require, exclude sam.Flags
+}
+
+// Flags returns an expression satisfied by records with all the flags
+// in require set and all the flags in exclude unset.
+func Flags(require, exclude sam.Flags) Expr {
+	return flagsExpr{require: require, exclude: exclude}
+}
+
+func (e flagsExpr) compile() func(*sam.Record) bool {
+	mask, want := e.require|e.exclude, e.require
+	return func(r *sam.Record) bool { return r.Flags&mask == want }
+}
+
+type anyFlagsExpr sam.Flags
+
+// AnyFlags returns an expression satisfied by records with any of the
+// given flags set.
+func AnyFlags(flags sam.Flags) Expr { return anyFlagsExpr(flags) }
+
+func (e anyFlagsExpr) compile() func(*sam.Record) bool {
+	return func(r *sam.Record) bool { return r.Flags&sam.Flags(e) != 0 }
+}
+
+type mapQExpr byte
+
+// MinMapQ returns an expression satisfied by records with a mapping
+// quality of at least q.
+func MinMapQ(q byte) Expr { return mapQExpr(q) }
+
+func (e mapQExpr) compile() func(*sam.Record) bool {
+	return func(r *sam.Record) bool { return r.MapQ >= byte(e) }
+}
+
+type lengthExpr struct {
+	min, max int
+}
+
+// Length returns an expression satisfied by records with a read length
+// of at least min and at most max. If max is negative the length is
+// not limited.
+func Length(min, max int) Expr { return lengthExpr{min: min, max: max} }
+
+func (e lengthExpr) compile() func(*sam.Record) bool {
+	if e.max < 0 {
+		return func(r *sam.Record) bool { return r.Seq.Length >= e.min }
+	}
+	return func(r *sam.Record) bool { return e.min <= r.Seq.Length && r.Seq.Length <= e.max }
+}
+
+type refExpr []string
+
+// Ref returns an expression satisfied by records aligned to any of the
+// named references.
+func Ref(names ...string) Expr { return refExpr(names) }
+
+func (e refExpr) compile() func(*sam.Record) bool {
+	set := make(map[string]bool, len(e))
+	for _, n := range e {
+		set[n] = true
+	}
+	return func(r *sam.Record) bool { return r.Ref != nil && set[r.Ref.Name()] }
+}
+
+type overlapExpr []bam.Region
+
+// Overlaps returns an expression satisfied by records with alignments
+// overlapping any of the given regions. Regions are zero-based and
+// half-open.
+func Overlaps(regions ...bam.Region) Expr { return overlapExpr(regions) }
+
+func (e overlapExpr) compile() func(*sam.Record) bool {
+	byRef := make(map[*sam.Reference][]bam.Region)
+	for _, reg := range e {
+		byRef[reg.Ref] = append(byRef[reg.Ref], reg)
+	}
+	return func(r *sam.Record) bool {
+		if r.Ref == nil {
+			return false
+		}
+		regions := byRef[r.Ref]
+		if len(regions) == 0 {
+			return false
+		}
+		start, end := r.Start(), r.End()
+		for _, reg := range regions {
+			if start < reg.End && reg.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type tagExpr struct {
+	tag sam.Tag
+	fn  func(interface{}) bool
+}
+
+// Tag returns an expression satisfied by records with the given aux
+// tag for which fn returns true when called with the tag's value. If
+// fn is nil, the expression is satisfied by records with the tag.
+func Tag(tag sam.Tag, fn func(interface{}) bool) Expr { return tagExpr{tag: tag, fn: fn} }
+
+// TagEquals returns an expression satisfied by records with the given
+// aux tag with a value equal to v. Integer and floating point values
+// are compared by value, independent of their type.
+func TagEquals(tag sam.Tag, v interface{}) Expr {
+	want := normalize(v)
+	return tagExpr{tag: tag, fn: func(got interface{}) bool { return normalize(got) == want }}
+}
+
+func (e tagExpr) compile() func(*sam.Record) bool {
+	if e.fn == nil {
+		return func(r *sam.Record) bool { return r.AuxTag(e.tag) != nil }
+	}
+	return func(r *sam.Record) bool {
+		a := r.AuxTag(e.tag)
+		return a != nil && e.fn(a.Value())
+	}
+}
+
+// normalize returns numeric values as int64 or float64 so they may be
+// compared independently of their aux type.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case float32:
+		if f := float64(v); f == float64(int64(f)) {
+			return int64(f)
+		}
+		return float64(v)
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+		return v
+	case []byte:
+		return string(v)
+	}
+	return v
+}
+
+var rgTag = sam.NewTag("RG")
+
+type readGroupExpr []string
+
+// ReadGroup returns an expression satisfied by records in any of the
+// given read groups.
+func ReadGroup(ids ...string) Expr { return readGroupExpr(ids) }
+
+func (e readGroupExpr) compile() func(*sam.Record) bool {
+	set := make(map[string]bool, len(e))
+	for _, id := range e {
+		set[id] = true
+	}
+	return func(r *sam.Record) bool {
+		a := r.AuxTag(rgTag)
+		if a == nil {
+			return false
+		}
+		id, ok := a.Value().(string)
+		return ok && set[id]
+	}
+}
+
+type andExpr []Expr
+
+// And returns an expression satisfied by records satisfying all of
+// exprs. And with no arguments is satisfied by all records.
+func And(exprs ...Expr) Expr { return andExpr(exprs) }
+
+func (e andExpr) compile() func(*sam.Record) bool {
+	fns := compileAll(e)
+	switch len(fns) {
+	case 0:
+		return True().compile()
+	case 1:
+		return fns[0]
+	case 2:
+		a, b := fns[0], fns[1]
+		return func(r *sam.Record) bool { return a(r) && b(r) }
+	}
+	return func(r *sam.Record) bool {
+		for _, fn := range fns {
+			if !fn(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type orExpr []Expr
+
+// Or returns an expression satisfied by records satisfying any of
+// exprs. Or with no arguments is satisfied by no record.
+func Or(exprs ...Expr) Expr { return orExpr(exprs) }
+
+func (e orExpr) compile() func(*sam.Record) bool {
+	fns := compileAll(e)
+	switch len(fns) {
+	case 0:
+		return False().compile()
+	case 1:
+		return fns[0]
+	case 2:
+		a, b := fns[0], fns[1]
+		return func(r *sam.Record) bool { return a(r) || b(r) }
+	}
+	return func(r *sam.Record) bool {
+		for _, fn := range fns {
+			if fn(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type notExpr struct{ Expr }
+
+// Not returns an expression satisfied by records not satisfying e.
+func Not(e Expr) Expr { return notExpr{e} }
+
+func (e notExpr) compile() func(*sam.Record) bool {
+	fn := e.Expr.compile()
+	return func(r *sam.Record) bool { return !fn(r) }
+}
+
+func compileAll(exprs []Expr) []func(*sam.Record) bool {
+	fns := make([]func(*sam.Record) bool, len(exprs))
+	for i, e := range exprs {
+		fns[i] = e.compile()
+	}
+	return fns
+}
+
+// simplify returns an expression equivalent to e with nested
+// conjunctions and disjunctions flattened, constant terms removed,
+// double negations cancelled, and the flag and mapping quality tests
+// of each conjunction merged.
+func simplify(e Expr) Expr {
+	switch e := e.(type) {
+	case notExpr:
+		switch s := simplify(e.Expr).(type) {
+		case constExpr:
+			return !s
+		case notExpr:
+			return s.Expr
+		case flagsExpr:
+			if s.require == 0 {
+				return anyFlagsExpr(s.exclude)
+			}
+			if s.exclude == 0 && s.require&(s.require-1) == 0 {
+				// Negation of a single required flag.
+				return flagsExpr{exclude: s.require}
+			}
+			return notExpr{s}
+		case anyFlagsExpr:
+			return flagsExpr{exclude: sam.Flags(s)}
+		default:
+			return notExpr{s}
+		}
+
+	case andExpr:
+		var (
+			terms    []Expr
+			flags    flagsExpr
+			hasFlags bool
+			mapQ     mapQExpr
+		)
+		var add func(Expr) bool
+		add = func(t Expr) bool {
+			switch t := simplify(t).(type) {
+			case constExpr:
+				return bool(t)
+			case andExpr:
+				for _, c := range t {
+					if !add(c) {
+						return false
+					}
+				}
+			case flagsExpr:
+				flags.require |= t.require
+				flags.exclude |= t.exclude
+				hasFlags = true
+			case mapQExpr:
+				if t > mapQ {
+					mapQ = t
+				}
+			default:
+				terms = append(terms, t)
+			}
+			return true
+		}
+		for _, t := range e {
+			if !add(t) {
+				return constExpr(false)
+			}
+		}
+		if flags.require&flags.exclude != 0 {
+			return constExpr(false)
+		}
+		// Place the cheap merged tests first.
+		var head []Expr
+		if hasFlags {
+			head = append(head, flags)
+		}
+		if mapQ != 0 {
+			head = append(head, mapQ)
+		}
+		terms = append(head, terms...)
+		if len(terms) == 1 {
+			return terms[0]
+		}
+		return andExpr(terms)
+
+	case orExpr:
+		var terms []Expr
+		var add func(Expr) bool
+		add = func(t Expr) bool {
+			switch t := simplify(t).(type) {
+			case constExpr:
+				return !bool(t)
+			case orExpr:
+				for _, c := range t {
+					if !add(c) {
+						return false
+					}
+				}
+			default:
+				terms = append(terms, t)
+			}
+			return true
+		}
+		for _, t := range e {
+			if !add(t) {
+				return constExpr(true)
+			}
+		}
+		if len(terms) == 1 {
+			return terms[0]
+		}
+		return orExpr(terms)
+	}
+	return e
+}
+
+// Source is a source of records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Reader is a record reader returning only records satisfying a
+// filter expression.
+type Reader struct {
+	src  Source
+	keep func(*sam.Record) bool
+}
+
+// NewReader returns a Reader reading records from src that satisfy e.
+func NewReader(src Source, e Expr) *Reader {
+	return &Reader{src: src, keep: Compile(e)}
+}
+
+// Read returns the next record satisfying the filter expression.
+func (r *Reader) Read() (*sam.Record, error) {
+	for {
+		rec, err := r.src.Read()
+		if err != nil {
+			return nil, err
+		}
+		if r.keep(rec) {
+			return rec, nil
+		}
+	}
+}
+
+// Sink is a destination for records.
+type Sink interface {
+	Write(*sam.Record) error
+}
+
+// Writer is a record writer that writes only records satisfying a
+// filter expression.
+type Writer struct {
+	dst  Sink
+	keep func(*sam.Record) bool
+}
+
+// NewWriter returns a Writer writing records satisfying e to dst.
+func NewWriter(dst Sink, e Expr) *Writer {
+	return &Writer{dst: dst, keep: Compile(e)}
+}
+
+// Write writes r to the underlying Sink if it satisfies the filter
+// expression. Records not satisfying the expression are discarded.
+func (w *Writer) Write(r *sam.Record) error {
+	if !w.keep(r) {
+		return nil
+	}
+	return w.dst.Write(r)
+}
@@ -0,0 +1,221 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filter
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+func (r *records) Write(rec *sam.Record) error {
+	*r = append(*r, rec)
+	return nil
+}
+
+func TestFilter(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().Ref("chr1", 1000).Ref("chr2", 1000)
+	b.Record("a", "chr1", 100, "50M", strings.Repeat("A", 50)).MapQ(60).Flags(sam.Paired|sam.ProperPair).Tag("NM", 1).Tag("RG", "rg1").
+		Record("b", "chr1", 200, "20M", strings.Repeat("A", 20)).MapQ(10).Flags(sam.Paired|sam.Duplicate).Tag("NM", 3).Tag("RG", "rg2").
+		Record("c", "chr2", 100, "30M", strings.Repeat("A", 30)).MapQ(30).Flags(sam.Reverse).Tag("XF", float32(1)).
+		Record("d", "*", -1, "4S", "AAAA").Flags(sam.Unmapped).Tag("RG", "rg1")
+	h, err := b.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	chr1 := h.Refs()[0]
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+
+	for _, test := range []struct {
+		name string
+		expr Expr
+		want string
+	}{
+		{name: "true", expr: True(), want: "abcd"},
+		{name: "false", expr: False(), want: ""},
+		{name: "flags", expr: Flags(sam.Paired, sam.Duplicate), want: "a"},
+		{name: "any flags", expr: AnyFlags(sam.Reverse | sam.Unmapped), want: "cd"},
+		{name: "mapq", expr: MinMapQ(30), want: "ac"},
+		{name: "length", expr: Length(25, 40), want: "c"},
+		{name: "unbounded length", expr: Length(25, -1), want: "ac"},
+		{name: "ref", expr: Ref("chr2"), want: "c"},
+		{name: "overlaps", expr: Overlaps(bam.Region{Ref: chr1, Start: 140, End: 210}), want: "ab"},
+		{name: "overlaps end", expr: Overlaps(bam.Region{Ref: chr1, Start: 150, End: 200}), want: ""},
+		{name: "has tag", expr: Tag(sam.NewTag("NM"), nil), want: "ab"},
+		{name: "tag", expr: Tag(sam.NewTag("NM"), func(v interface{}) bool { return normalize(v).(int64) <= 2 }), want: "a"},
+		{name: "tag equals", expr: TagEquals(sam.NewTag("NM"), 3), want: "b"},
+		{name: "tag equals float", expr: TagEquals(sam.NewTag("XF"), 1), want: "c"},
+		{name: "read group", expr: ReadGroup("rg1"), want: "ad"},
+		{name: "and", expr: And(Flags(sam.Paired, 0), MinMapQ(20), Not(AnyFlags(sam.Duplicate))), want: "a"},
+		{name: "empty and", expr: And(), want: "abcd"},
+		{name: "or", expr: Or(Ref("chr2"), ReadGroup("rg2")), want: "bc"},
+		{name: "empty or", expr: Or(), want: ""},
+		{name: "not", expr: Not(Flags(0, sam.Unmapped)), want: "d"},
+		{name: "not not", expr: Not(Not(MinMapQ(30))), want: "ac"},
+		{name: "contradiction", expr: And(Flags(sam.Paired, 0), Flags(0, sam.Paired)), want: ""},
+		{name: "nested", expr: Or(And(MinMapQ(50), Or(False(), ReadGroup("rg1"))), Not(Or(True(), Ref("chr1")))), want: "a"},
+		{name: "func", expr: Func(func(r *sam.Record) bool { return r.Name == "d" }), want: "d"},
+	} {
+		fn := Compile(test.expr)
+		var got string
+		for _, r := range recs {
+			if fn(r) {
+				got += r.Name
+			}
+		}
+		if got != test.want {
+			t.Errorf("unexpected result for %s: got:%q want:%q", test.name, got, test.want)
+		}
+	}
+
+	src := append(records(nil), recs...)
+	r := NewReader(&src, MinMapQ(30))
+	var read []*sam.Record
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		read = append(read, rec)
+	}
+	if want := []*sam.Record{recs[0], recs[2]}; !reflect.DeepEqual(read, want) {
+		t.Errorf("unexpected records read: got:%v want:%v", read, want)
+	}
+
+	var dst records
+	w := NewWriter(&dst, ReadGroup("rg1"))
+	for _, rec := range recs {
+		err := w.Write(rec)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if want := (records{recs[0], recs[3]}); !reflect.DeepEqual(dst, want) {
+		t.Errorf("unexpected records written: got:%v want:%v", dst, want)
+	}
+}
+
+func TestFilterLazyAux(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	rg1, _ := sam.NewReadGroup("rg1", "", "", "libA", "", "", "", "", "", "", time.Time{}, 0)
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	err = h.AddReadGroup(rg1)
+	if err != nil {
+		t.Fatalf("unexpected error adding read group: %v", err)
+	}
+	var buf bytes.Buffer
+	bw, err := bam.NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	b := htstestutil.NewBAMBuilder()
+	b.Record("a", "chr1", 100, "50M", strings.Repeat("A", 50)).MapQ(60).Tag("NM", 1).Tag("RG", "rg1").
+		Record("b", "chr1", 200, "20M", strings.Repeat("A", 20)).MapQ(10).Tag("NM", 3).Tag("RG", "rg2").
+		Record("c", "chr1", 300, "30M", strings.Repeat("A", 30)).MapQ(30)
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+	for _, rec := range recs {
+		err = bw.Write(rec)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	parse := func(src string) Expr {
+		e, err := Parse(src, h)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", src, err)
+		}
+		return e
+	}
+	for _, test := range []struct {
+		name string
+		expr Expr
+		want string
+	}{
+		{name: "has tag", expr: Tag(sam.NewTag("NM"), nil), want: "ab"},
+		{name: "tag equals", expr: TagEquals(sam.NewTag("NM"), 3), want: "b"},
+		{name: "read group", expr: ReadGroup("rg1"), want: "a"},
+		{name: "parsed tag", expr: parse("[NM] <= 2"), want: "a"},
+		{name: "parsed library", expr: parse("library == \"libA\""), want: "a"},
+	} {
+		br, err := bam.NewReader(bytes.NewReader(buf.Bytes()), 1)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader: %v", err)
+		}
+		br.LazyAux(true)
+		r := NewReader(br, test.expr)
+		var got string
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got += rec.Name
+		}
+		br.Close()
+		if got != test.want {
+			t.Errorf("unexpected result for %s: got:%q want:%q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	for _, test := range []struct {
+		expr Expr
+		want Expr
+	}{
+		{
+			expr: And(Flags(sam.Paired, 0), MinMapQ(10), And(Flags(0, sam.Duplicate), MinMapQ(20)), True()),
+			want: andExpr{flagsExpr{require: sam.Paired, exclude: sam.Duplicate}, mapQExpr(20)},
+		},
+		{expr: And(Ref("chr1"), False()), want: constExpr(false)},
+		{expr: Or(Ref("chr1"), True()), want: constExpr(true)},
+		{expr: Or(False(), Or(Ref("chr1"))), want: refExpr{"chr1"}},
+		{expr: Not(Not(Ref("chr1"))), want: refExpr{"chr1"}},
+		{expr: Not(Flags(0, sam.Duplicate)), want: anyFlagsExpr(sam.Duplicate)},
+		{expr: Not(AnyFlags(sam.Duplicate)), want: flagsExpr{exclude: sam.Duplicate}},
+	} {
+		if got := simplify(test.expr); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected simplification of %#v: got:%#v want:%#v", test.expr, got, test.want)
+		}
+	}
+}
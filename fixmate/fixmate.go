@@ -0,0 +1,286 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fixmate implements repair of the mate information of read
+// pairs in name grouped alignment records, in the manner of samtools
+// fixmate.
+//
+// For each pair of primary first and last fragment records sharing a
+// name, the mate reference, mate position, mate unmapped and mate
+// reverse flags and template length of each record are set from its
+// mate, and the following aux tags are set, or removed when the mate
+// is unmapped:
+//
+//	MC  CIGAR string of the mate
+//	MQ  mapping quality of the mate
+//	ms  score of the mate, if requested
+//
+// An unmapped read with a mapped mate is placed at the position of its
+// mate. The proper pair flag is removed from pairs that cannot be
+// proper pairs. Secondary and supplementary records of the pair are
+// given the mate information of their primary record, but their
+// template lengths are not altered.
+//
+// The ms tag is used by the markdup package to score read pairs.
+package fixmate
+
+import (
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+// DefaultMinBaseQ is the default minimum quality of bases contributing
+// to the mate score.
+const DefaultMinBaseQ = 15
+
+// Source is a source of records with records of the same name adjacent,
+// as produced by a queryname sort or collation.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Options specifies the behaviour of mate repair.
+type Options struct {
+	// MateScore specifies that the ms aux
+	// tag is set to the sum of the base
+	// qualities of the mate that are at least
+	// MinBaseQ. If MinBaseQ is zero,
+	// DefaultMinBaseQ is used.
+	MateScore bool
+	MinBaseQ  byte
+
+	// AnyOrientation specifies that the proper
+	// pair flag is only removed from pairs with
+	// an unmapped read or with reads on
+	// different references. Otherwise it is
+	// also removed from pairs not in forward
+	// and reverse orientation facing each
+	// other.
+	AnyOrientation bool
+}
+
+var (
+	mcTag = sam.NewTag("MC")
+	mqTag = sam.NewTag("MQ")
+	msTag = sam.NewTag("ms")
+)
+
+// Reader is a mate repairing record reader. It returns the records of
+// its Source in order with their mate information set. Records that
+// are not paired, or whose mate record is absent, are returned
+// unaltered.
+type Reader struct {
+	src  Source
+	opts Options
+
+	group []*sam.Record
+	next  *sam.Record
+	err   error
+}
+
+// NewReader returns a Reader repairing the mate information of the
+// records read from src.
+func NewReader(src Source, opts Options) *Reader {
+	if opts.MinBaseQ == 0 {
+		opts.MinBaseQ = DefaultMinBaseQ
+	}
+	return &Reader{src: src, opts: opts}
+}
+
+// Read returns the next record.
+func (r *Reader) Read() (*sam.Record, error) {
+	if len(r.group) == 0 {
+		if r.err != nil {
+			return nil, r.err
+		}
+		r.fill()
+		if len(r.group) == 0 {
+			return nil, r.err
+		}
+		err := r.fix(r.group)
+		if err != nil {
+			r.group = r.group[:0]
+			r.err = err
+			return nil, err
+		}
+	}
+	rec := r.group[0]
+	r.group[0] = nil
+	r.group = r.group[1:]
+	return rec, nil
+}
+
+// fill reads the next group of records sharing a name.
+func (r *Reader) fill() {
+	r.group = r.group[:0]
+	if r.next == nil {
+		r.next, r.err = r.src.Read()
+		if r.err != nil {
+			return
+		}
+	}
+	r.group = append(r.group, r.next)
+	r.next = nil
+	for {
+		rec, err := r.src.Read()
+		if err != nil {
+			r.err = err
+			return
+		}
+		if rec.Name != r.group[0].Name {
+			r.next = rec
+			return
+		}
+		r.group = append(r.group, rec)
+	}
+}
+
+// fix repairs the mate information of the records of group.
+func (r *Reader) fix(group []*sam.Record) error {
+	var first, last *sam.Record
+	for _, rec := range group {
+		if rec.Flags&(sam.Paired|sam.Secondary|sam.Supplementary) != sam.Paired {
+			continue
+		}
+		switch rec.Flags & (sam.Read1 | sam.Read2) {
+		case sam.Read1:
+			if first != nil {
+				return nil
+			}
+			first = rec
+		case sam.Read2:
+			if last != nil {
+				return nil
+			}
+			last = rec
+		}
+	}
+	if first == nil || last == nil {
+		return nil
+	}
+
+	// Place an unmapped read at the
+	// position of its mapped mate.
+	for _, p := range [][2]*sam.Record{{first, last}, {last, first}} {
+		rec, mate := p[0], p[1]
+		if rec.Flags&sam.Unmapped != 0 && mate.Flags&sam.Unmapped == 0 {
+			rec.Ref, rec.Pos = mate.Ref, mate.Pos
+		}
+	}
+
+	proper := r.proper(first, last)
+	err := setMate(first, last, r.opts)
+	if err != nil {
+		return err
+	}
+	err = setMate(last, first, r.opts)
+	if err != nil {
+		return err
+	}
+	first.TempLen, last.TempLen = tlen(first, last)
+	if !proper {
+		first.Flags &^= sam.ProperPair
+		last.Flags &^= sam.ProperPair
+	}
+
+	for _, rec := range group {
+		if rec == first || rec == last || rec.Flags&sam.Paired == 0 {
+			continue
+		}
+		switch rec.Flags & (sam.Read1 | sam.Read2) {
+		case sam.Read1:
+			err = setMate(rec, last, r.opts)
+		case sam.Read2:
+			err = setMate(rec, first, r.opts)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !proper {
+			rec.Flags &^= sam.ProperPair
+		}
+	}
+	return nil
+}
+
+// proper returns whether a and b may be flagged as a proper pair.
+func (r *Reader) proper(a, b *sam.Record) bool {
+	if a.Flags&sam.Unmapped != 0 || b.Flags&sam.Unmapped != 0 || a.Ref != b.Ref {
+		return false
+	}
+	if r.opts.AnyOrientation {
+		return true
+	}
+	fwd, rev := a, b
+	if a.Flags&sam.Reverse != 0 {
+		fwd, rev = b, a
+	}
+	return fwd.Flags&sam.Reverse == 0 && rev.Flags&sam.Reverse != 0 && fwd.Pos <= rev.End()
+}
+
+// setMate sets the mate information of rec from mate.
+func setMate(rec, mate *sam.Record, opts Options) error {
+	rec.MateRef, rec.MatePos = mate.Ref, mate.Pos
+	rec.Flags &^= sam.MateUnmapped | sam.MateReverse
+	if mate.Flags&sam.Reverse != 0 {
+		rec.Flags |= sam.MateReverse
+	}
+	var err error
+	if mate.Flags&sam.Unmapped != 0 {
+		rec.Flags |= sam.MateUnmapped
+		err = samutil.DelAux(rec, mcTag)
+		if err != nil {
+			return err
+		}
+		err = samutil.DelAux(rec, mqTag)
+	} else {
+		err = samutil.SetAux(rec, mcTag, mate.Cigar.String())
+		if err != nil {
+			return err
+		}
+		err = samutil.SetAux(rec, mqTag, int(mate.MapQ))
+	}
+	if err != nil || !opts.MateScore {
+		return err
+	}
+	return samutil.SetAux(rec, msTag, score(mate, opts.MinBaseQ))
+}
+
+// score returns the sum of the base qualities of rec that are at least
+// minQ.
+func score(rec *sam.Record, minQ byte) int {
+	var s int
+	for _, q := range rec.Qual {
+		if q >= minQ && q != 0xff {
+			s += int(q)
+		}
+	}
+	return s
+}
+
+// tlen returns the template lengths of the records of a pair. The
+// template length is the distance from the leftmost to the rightmost
+// mapped base of the pair, and is positive for the leftmost record, or
+// for the first fragment if both records start at the same position.
+// The template length is zero if either record is unmapped or if the
+// records are on different references.
+func tlen(first, last *sam.Record) (int, int) {
+	if first.Flags&sam.Unmapped != 0 || last.Flags&sam.Unmapped != 0 || first.Ref != last.Ref {
+		return 0, 0
+	}
+	left, right := first.Pos, first.End()
+	if last.Pos < left {
+		left = last.Pos
+	}
+	if end := last.End(); end > right {
+		right = end
+	}
+	n := right - left
+	if last.Pos < first.Pos {
+		return -n, n
+	}
+	return n, -n
+}
@@ -0,0 +1,183 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fixmate
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+func TestReader(t *testing.T) {
+	const (
+		p1 = sam.Paired | sam.Read1
+		p2 = sam.Paired | sam.Read2
+		pp = sam.ProperPair
+	)
+	b := htstestutil.NewBAMBuilder().Ref("chr1", 10000).Ref("chr2", 10000)
+	// read adds a read of n bases with base quality 20 and
+	// stale mate information to be repaired.
+	read := func(name, ref string, pos int, cigar string, n int, flags sam.Flags) {
+		b.Record(name, ref, pos, cigar, strings.Repeat("A", n)).
+			Qual(strings.Repeat("5", n)).MapQ(60).Flags(flags).Mate("*", -1, 999)
+	}
+
+	// Forward-reverse proper pair with a
+	// supplementary alignment.
+	read("fr", "chr1", 100, "5S45M", 50, p1|pp)
+	read("fr", "chr1", 300, "50M", 50, p2|pp|sam.Reverse)
+	read("fr", "chr2", 500, "20M", 20, p1|sam.Supplementary)
+
+	// Pair with an unmapped read.
+	read("un", "chr1", 1000, "50M", 50, p1|pp|sam.Reverse)
+	read("un", "*", -1, "50S", 50, p2|pp|sam.Unmapped)
+
+	// Pair on different references.
+	read("diff", "chr1", 10, "50M", 50, p1|pp)
+	read("diff", "chr2", 10, "50M", 50, p2|pp|sam.Reverse)
+
+	// Reverse-forward pair.
+	read("rf", "chr1", 100, "50M", 50, p1|pp|sam.Reverse)
+	read("rf", "chr1", 400, "50M", 50, p2|pp)
+
+	// Forward-forward pair.
+	read("ff", "chr1", 100, "50M", 50, p1|pp)
+	read("ff", "chr1", 200, "50M", 50, p2|pp)
+
+	// Unpaired read.
+	read("single", "chr1", 10, "50M", 50, pp)
+
+	h, err := b.Header()
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	chr1, chr2 := h.Refs()[0], h.Refs()[1]
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error creating records: %v", err)
+	}
+	in := records(recs)
+	want := append(records(nil), in...)
+
+	r := NewReader(&in, Options{MateScore: true})
+	var got records
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		got = append(got, rec)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of records: got:%d want:%d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected record order at %d: got:%v want:%v", i, got[i], want[i])
+		}
+	}
+
+	type mate struct {
+		ref     *sam.Reference
+		pos     int
+		tlen    int
+		flags   sam.Flags
+		mc      string
+		mq      int
+		hasMate bool
+	}
+	for i, m := range []mate{
+		{ref: chr1, pos: 300, tlen: 250, flags: p1 | pp | sam.MateReverse, mc: "50M", mq: 60, hasMate: true},
+		{ref: chr1, pos: 100, tlen: -250, flags: p2 | pp | sam.Reverse, mc: "5S45M", mq: 60, hasMate: true},
+		{ref: chr1, pos: 300, tlen: 999, flags: p1 | sam.Supplementary | sam.MateReverse, mc: "50M", mq: 60, hasMate: true},
+
+		{ref: chr1, pos: 1000, tlen: 0, flags: p1 | sam.Reverse | sam.MateUnmapped, hasMate: true},
+		{ref: chr1, pos: 1000, tlen: 0, flags: p2 | sam.Unmapped | sam.MateReverse, mc: "50M", mq: 60, hasMate: true},
+
+		{ref: chr2, pos: 10, tlen: 0, flags: p1 | sam.MateReverse, mc: "50M", mq: 60, hasMate: true},
+		{ref: chr1, pos: 10, tlen: 0, flags: p2 | sam.Reverse, mc: "50M", mq: 60, hasMate: true},
+
+		{ref: chr1, pos: 400, tlen: 350, flags: p1 | sam.Reverse, mc: "50M", mq: 60, hasMate: true},
+		{ref: chr1, pos: 100, tlen: -350, flags: p2 | sam.MateReverse, mc: "50M", mq: 60, hasMate: true},
+
+		{ref: chr1, pos: 200, tlen: 150, flags: p1, mc: "50M", mq: 60, hasMate: true},
+		{ref: chr1, pos: 100, tlen: -150, flags: p2, mc: "50M", mq: 60, hasMate: true},
+
+		{ref: nil, pos: -1, tlen: 999, flags: pp},
+	} {
+		rec := got[i]
+		if rec.MateRef != m.ref || rec.MatePos != m.pos {
+			t.Errorf("unexpected mate position for %s %d: got:%v:%d want:%v:%d", rec.Name, i, rec.MateRef, rec.MatePos, m.ref, m.pos)
+		}
+		if rec.TempLen != m.tlen {
+			t.Errorf("unexpected template length for %s %d: got:%d want:%d", rec.Name, i, rec.TempLen, m.tlen)
+		}
+		if rec.Flags != m.flags {
+			t.Errorf("unexpected flags for %s %d: got:%v want:%v", rec.Name, i, rec.Flags, m.flags)
+		}
+		var mc string
+		if a := rec.AuxFields.Get(mcTag); a != nil {
+			mc = a.Value().(string)
+		}
+		if mc != m.mc {
+			t.Errorf("unexpected MC for %s %d: got:%q want:%q", rec.Name, i, mc, m.mc)
+		}
+		var mq int
+		if a := rec.AuxFields.Get(mqTag); a != nil {
+			mq = int(a.Value().(int8))
+		}
+		if mq != m.mq {
+			t.Errorf("unexpected MQ for %s %d: got:%d want:%d", rec.Name, i, mq, m.mq)
+		}
+		if hasMS := rec.AuxFields.Get(msTag) != nil; hasMS != m.hasMate {
+			t.Errorf("unexpected ms presence for %s %d: got:%t want:%t", rec.Name, i, hasMS, m.hasMate)
+		}
+	}
+	if got[4].Ref != chr1 || got[4].Pos != 1000 {
+		t.Errorf("unexpected placement of unmapped read: got:%v:%d want:chr1:1000", got[4].Ref, got[4].Pos)
+	}
+	if ms := got[0].AuxFields.Get(msTag).Value(); ms != int16(1000) {
+		t.Errorf("unexpected mate score: got:%v want:1000", ms)
+	}
+
+	b = htstestutil.NewBAMBuilder()
+	read("fr", "chr1", 100, "50M", 50, p1|pp)
+	read("fr", "chr1", 300, "50M", 50, p2|pp)
+	recs, err = b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error creating records: %v", err)
+	}
+	in = records(recs)
+	r = NewReader(&in, Options{AnyOrientation: true})
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		if rec.Flags&pp == 0 {
+			t.Errorf("unexpected removal of proper pair flag with any orientation: %v", rec)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gff implements GFF3 and GTF annotation reading and writing,
+// and assembly of gene, transcript and exon models from annotation
+// features.
+//
+// The GFF3 format is described in the GFF3 specification.
+//
+// https://github.com/The-Sequence-Ontology/Specifications/blob/master/gff3.md
+//
+// Feature coordinates are converted from the one-based inclusive
+// coordinates used by GFF3 and GTF to zero-based half-open coordinates.
+package gff
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFields = errors.New("gff: invalid number of fields")
+)
+
+// Format is an annotation file format.
+type Format int
+
+const (
+	GFF3 Format = iota
+	GTF
+)
+
+// Attribute is a feature attribute.
+type Attribute struct {
+	Key    string
+	Values []string
+}
+
+// Attributes is an ordered set of feature attributes.
+type Attributes []Attribute
+
+// Get returns the first value of the attribute with the given key, or
+// the empty string if the attribute is not present.
+func (a Attributes) Get(key string) string {
+	v := a.Values(key)
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
+// Values returns all the values of the attribute with the given key.
+// For GTF features, the values of repeated keys are combined.
+func (a Attributes) Values(key string) []string {
+	var v []string
+	for _, e := range a {
+		if e.Key == key {
+			v = append(v, e.Values...)
+		}
+	}
+	return v
+}
+
+// Feature is a GFF3 or GTF feature. Start and End are zero-based
+// half-open coordinates.
+type Feature struct {
+	SeqID  string
+	Source string
+	Type   string
+	Start  int
+	End    int
+
+	// Score is NaN if the score is missing.
+	Score float64
+
+	// Strand is one of '+', '-', '.' or '?'.
+	Strand byte
+
+	// Phase is the CDS phase, or -1 if missing.
+	Phase int
+
+	Attributes Attributes
+}
+
+// Len returns the length of the feature.
+func (f *Feature) Len() int { return f.End - f.Start }
+
+// UnmarshalGFF parses a single GFF3 or GTF feature line into f. The
+// attribute syntax is taken from format.
+func (f *Feature) UnmarshalGFF(b []byte, format Format) error {
+	fields := strings.Split(string(b), "\t")
+	if len(fields) != 9 && !(len(fields) == 8 && format == GTF) {
+		return ErrFields
+	}
+	*f = Feature{
+		SeqID:  unescape(fields[0]),
+		Source: unescape(fields[1]),
+		Type:   unescape(fields[2]),
+		Score:  math.NaN(),
+		Phase:  -1,
+	}
+	start, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return fmt.Errorf("gff: invalid start: %v", err)
+	}
+	f.End, err = strconv.Atoi(fields[4])
+	if err != nil {
+		return fmt.Errorf("gff: invalid end: %v", err)
+	}
+	if start < 1 || f.End < start-1 {
+		return fmt.Errorf("gff: invalid interval [%d,%d]", start, f.End)
+	}
+	f.Start = start - 1
+	if fields[5] != "." {
+		f.Score, err = strconv.ParseFloat(fields[5], 64)
+		if err != nil {
+			return fmt.Errorf("gff: invalid score: %v", err)
+		}
+	}
+	switch s := fields[6]; s {
+	case "+", "-", ".", "?":
+		f.Strand = s[0]
+	default:
+		return fmt.Errorf("gff: invalid strand: %q", s)
+	}
+	switch p := fields[7]; p {
+	case ".":
+	case "0", "1", "2":
+		f.Phase = int(p[0] - '0')
+	default:
+		return fmt.Errorf("gff: invalid phase: %q", p)
+	}
+	if len(fields) == 8 {
+		return nil
+	}
+	if format == GTF {
+		f.Attributes, err = parseGTFAttributes(fields[8])
+	} else {
+		f.Attributes, err = parseGFF3Attributes(fields[8])
+	}
+	return err
+}
+
+// parseGFF3Attributes parses GFF3 tag=value pairs separated by
+// semicolons. Multiple values are separated by commas.
+func parseGFF3Attributes(s string) (Attributes, error) {
+	if s == "." || s == "" {
+		return nil, nil
+	}
+	var attrs Attributes
+	for _, kv := range strings.Split(s, ";") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		i := strings.IndexByte(kv, '=')
+		if i < 1 {
+			return nil, fmt.Errorf("gff: invalid attribute: %q", kv)
+		}
+		vals := strings.Split(kv[i+1:], ",")
+		for j, v := range vals {
+			vals[j] = unescape(v)
+		}
+		attrs = append(attrs, Attribute{Key: unescape(kv[:i]), Values: vals})
+	}
+	return attrs, nil
+}
+
+// parseGTFAttributes parses GTF key "value" pairs separated by
+// semicolons. Values may be unquoted.
+func parseGTFAttributes(s string) (Attributes, error) {
+	var attrs Attributes
+	for len(s) != 0 {
+		s = strings.TrimLeft(s, " ;")
+		if s == "" {
+			break
+		}
+		i := strings.IndexByte(s, ' ')
+		if i < 1 {
+			return nil, fmt.Errorf("gff: invalid attribute: %q", s)
+		}
+		key := s[:i]
+		s = strings.TrimLeft(s[i:], " ")
+		var val string
+		if strings.HasPrefix(s, `"`) {
+			j := strings.IndexByte(s[1:], '"')
+			if j < 0 {
+				return nil, fmt.Errorf("gff: unterminated attribute value: %q", s)
+			}
+			val = s[1 : j+1]
+			s = s[j+2:]
+		} else {
+			j := strings.IndexByte(s, ';')
+			if j < 0 {
+				j = len(s)
+			}
+			val = strings.TrimSpace(s[:j])
+			s = s[j:]
+		}
+		attrs = append(attrs, Attribute{Key: key, Values: []string{val}})
+	}
+	return attrs, nil
+}
+
+// unescape returns s with GFF3 percent-encoding decoded. If s is not
+// validly encoded, it is returned unaltered.
+func unescape(s string) string {
+	if strings.IndexByte(s, '%') < 0 {
+		return s
+	}
+	u, err := url.PathUnescape(s)
+	if err != nil {
+		return s
+	}
+	return u
+}
+
+// escape returns s with the characters reserved in the given GFF3
+// context percent-encoded.
+func escape(s, reserved string) string {
+	if !strings.ContainsAny(s, reserved) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if strings.IndexByte(reserved, c) >= 0 {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+const (
+	reservedColumn = "\t\n\r%\x7f"
+	reservedAttr   = "\t\n\r%;=&,\x7f"
+)
+
+// MarshalGFF returns the text representation of f in the given format.
+func (f *Feature) MarshalGFF(format Format) ([]byte, error) {
+	if f.Start < 0 || f.End < f.Start {
+		return nil, fmt.Errorf("gff: invalid interval [%d,%d)", f.Start, f.End)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\t%s\t%s\t%d\t%d\t",
+		escape(f.SeqID, reservedColumn), escape(dot(f.Source), reservedColumn), escape(f.Type, reservedColumn),
+		f.Start+1, f.End)
+	if math.IsNaN(f.Score) {
+		buf.WriteByte('.')
+	} else {
+		buf.WriteString(strconv.FormatFloat(f.Score, 'g', -1, 64))
+	}
+	strand := f.Strand
+	if strand == 0 {
+		strand = '.'
+	}
+	fmt.Fprintf(&buf, "\t%c\t", strand)
+	if f.Phase < 0 {
+		buf.WriteByte('.')
+	} else {
+		buf.WriteString(strconv.Itoa(f.Phase))
+	}
+	buf.WriteByte('\t')
+	if len(f.Attributes) == 0 {
+		buf.WriteByte('.')
+		return buf.Bytes(), nil
+	}
+	for i, a := range f.Attributes {
+		if format == GTF {
+			for j, v := range a.Values {
+				if i != 0 || j != 0 {
+					buf.WriteByte(' ')
+				}
+				fmt.Fprintf(&buf, "%s \"%s\";", a.Key, v)
+			}
+			continue
+		}
+		if i != 0 {
+			buf.WriteByte(';')
+		}
+		buf.WriteString(escape(a.Key, reservedAttr))
+		buf.WriteByte('=')
+		for j, v := range a.Values {
+			if j != 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(escape(v, reservedAttr))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func dot(s string) string {
+	if s == "" {
+		return "."
+	}
+	return s
+}
+
+// Reader implements GFF3 and GTF format reading.
+type Reader struct {
+	r      *bufio.Reader
+	format Format
+	line   int
+	done   bool
+}
+
+// NewReader returns a new Reader, reading features in the given format
+// from r.
+func NewReader(r io.Reader, format Format) *Reader {
+	return &Reader{r: bufio.NewReader(r), format: format}
+}
+
+// Read returns the next Feature in the stream. Comment and directive
+// lines are skipped. Reading stops at a GFF3 ##FASTA directive.
+func (r *Reader) Read() (*Feature, error) {
+	for !r.done {
+		b, err := r.r.ReadBytes('\n')
+		if len(b) == 0 {
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		r.line++
+		b = bytes.TrimSuffix(b, []byte{'\n'})
+		b = bytes.TrimSuffix(b, []byte{'\r'})
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		if b[0] == '#' {
+			if bytes.HasPrefix(b, []byte("##FASTA")) {
+				r.done = true
+			}
+			continue
+		}
+		var f Feature
+		err = f.UnmarshalGFF(b, r.format)
+		if err != nil {
+			return nil, fmt.Errorf("%v at line %d", err, r.line)
+		}
+		return &f, nil
+	}
+	return nil, io.EOF
+}
+
+// Writer implements GFF3 and GTF format writing.
+type Writer struct {
+	w      io.Writer
+	format Format
+}
+
+// NewWriter returns a Writer writing features in the given format to w.
+// For GFF3, the ##gff-version directive is written.
+func NewWriter(w io.Writer, format Format) (*Writer, error) {
+	if format == GFF3 {
+		_, err := io.WriteString(w, "##gff-version 3\n")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Writer{w: w, format: format}, nil
+}
+
+// Write writes f to the stream.
+func (w *Writer) Write(f *Feature) error {
+	b, err := f.MarshalGFF(w.format)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,290 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gff
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testGFF3 = `##gff-version 3
+##sequence-region chr1 1 10000
+chr1	test	gene	1000	9000	.	+	.	ID=gene1;Name=EDEN%3B1
+chr1	test	mRNA	1050	9000	.	+	.	ID=tx1;Parent=gene1;Name=EDEN.1
+chr1	test	exon	1050	1500	.	+	.	Parent=tx1
+chr1	test	exon	3000	3902	.	+	.	Parent=tx1
+chr1	test	exon	5000	5500	.	+	.	Parent=tx1,tx2
+chr1	test	CDS	1201	1500	.	+	0	ID=cds1;Parent=tx1
+chr1	test	CDS	3000	3902	.	+	0	ID=cds1;Parent=tx1
+chr1	test	mRNA	1050	5500	.	+	.	ID=tx2;Parent=gene1
+chr1	test	exon	1050	1500	.	+	.	Parent=tx2
+chr1	test	five_prime_UTR	1050	1200	.	+	.	Parent=tx1
+chr2	test	ncRNA_gene	10	20	0.5	-	.	ID=gene2;Note=a,b
+chr2	test	exon	10	20	.	-	.	ID=ex;Parent=ncrna
+chr2	test	ncRNA	10	20	.	-	.	ID=ncrna
+###
+##FASTA
+>chr1
+ACGT
+`
+
+const testGTF = `#!genome-build test
+chr1	test	exon	1050	1500	.	+	.	gene_id "g1"; transcript_id "t1"; gene_name "EDEN";
+chr1	test	exon	3000	3902	.	+	.	gene_id "g1"; transcript_id "t1"; exon_number 2;
+chr1	test	CDS	1201	1500	.	+	0	gene_id "g1"; transcript_id "t1";
+chr1	test	transcript	1050	5500	.	+	.	gene_id "g1"; transcript_id "t2"; transcript_name "EDEN-2";
+chr1	test	exon	1050	1500	.	+	.	gene_id "g1"; transcript_id "t2";
+chr1	test	exon	5000	5500	.	+	.	gene_id "g1"; transcript_id "t2";
+chr1	test	gene	100	200	.	-	.	gene_id "g2";
+`
+
+func readAll(t *testing.T, text string, format Format) []*Feature {
+	r := NewReader(strings.NewReader(text), format)
+	var features []*Feature
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			return features
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+		features = append(features, f)
+	}
+}
+
+func TestReadGFF3(t *testing.T) {
+	features := readAll(t, testGFF3, GFF3)
+	if len(features) != 13 {
+		t.Fatalf("unexpected number of features: got:%d want:13", len(features))
+	}
+	g := features[0]
+	want := &Feature{
+		SeqID: "chr1", Source: "test", Type: "gene", Start: 999, End: 9000,
+		Score: math.NaN(), Strand: '+', Phase: -1,
+		Attributes: Attributes{{Key: "ID", Values: []string{"gene1"}}, {Key: "Name", Values: []string{"EDEN;1"}}},
+	}
+	if !math.IsNaN(g.Score) {
+		t.Errorf("expected missing score, got:%v", g.Score)
+	}
+	got := *g
+	got.Score, want.Score = 0, 0
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("unexpected feature:\ngot: %+v\nwant:%+v", &got, want)
+	}
+	if got := features[4].Attributes.Values("Parent"); !reflect.DeepEqual(got, []string{"tx1", "tx2"}) {
+		t.Errorf("unexpected parents: %v", got)
+	}
+	if features[5].Phase != 0 || features[10].Score != 0.5 || features[10].Strand != '-' {
+		t.Errorf("unexpected phase, score or strand: %+v %+v", features[5], features[10])
+	}
+	if got := features[10].Attributes.Get("Note"); got != "a" {
+		t.Errorf("unexpected first note: %q", got)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, GFF3)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, f := range features {
+		err = w.Write(f)
+		if err != nil {
+			t.Fatalf("unexpected error writing: %v", err)
+		}
+	}
+	// Remove directives that are not retained.
+	var wantText []string
+	for _, l := range strings.Split(testGFF3[:strings.Index(testGFF3, "###")], "\n") {
+		if l != "" && (!strings.HasPrefix(l, "##") || strings.HasPrefix(l, "##gff-version")) {
+			wantText = append(wantText, l)
+		}
+	}
+	if buf.String() != strings.Join(wantText, "\n")+"\n" {
+		t.Errorf("unexpected output:\ngot:\n%s\nwant:\n%s", &buf, strings.Join(wantText, "\n"))
+	}
+}
+
+func TestReadGTF(t *testing.T) {
+	features := readAll(t, testGTF, GTF)
+	if len(features) != 7 {
+		t.Fatalf("unexpected number of features: got:%d want:7", len(features))
+	}
+	want := Attributes{
+		{Key: "gene_id", Values: []string{"g1"}},
+		{Key: "transcript_id", Values: []string{"t1"}},
+		{Key: "exon_number", Values: []string{"2"}},
+	}
+	if !reflect.DeepEqual(features[1].Attributes, want) {
+		t.Errorf("unexpected attributes:\ngot: %+v\nwant:%+v", features[1].Attributes, want)
+	}
+	b, err := features[1].MarshalGFF(GTF)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling: %v", err)
+	}
+	const wantText = `chr1	test	exon	3000	3902	.	+	.	gene_id "g1"; transcript_id "t1"; exon_number "2";`
+	if string(b) != wantText {
+		t.Errorf("unexpected text:\ngot: %q\nwant:%q", b, wantText)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, test := range []struct {
+		line   string
+		format Format
+	}{
+		{line: "chr1\ttest\tgene\t1\t10\t.\t+\t.", format: GFF3},
+		{line: "chr1\ttest\tgene\t0\t10\t.\t+\t.\tID=a", format: GFF3},
+		{line: "chr1\ttest\tgene\t10\t1\t.\t+\t.\tID=a", format: GFF3},
+		{line: "chr1\ttest\tgene\t1\t10\tx\t+\t.\tID=a", format: GFF3},
+		{line: "chr1\ttest\tgene\t1\t10\t.\tx\t.\tID=a", format: GFF3},
+		{line: "chr1\ttest\tgene\t1\t10\t.\t+\t3\tID=a", format: GFF3},
+		{line: "chr1\ttest\tgene\t1\t10\t.\t+\t.\tID", format: GFF3},
+		{line: "chr1\ttest\tgene\t1\t10\t.\t+\t.\tgene_id \"a", format: GTF},
+	} {
+		_, err := NewReader(strings.NewReader(test.line), test.format).Read()
+		if err == nil || !strings.HasSuffix(err.Error(), "at line 1") {
+			t.Errorf("unexpected error for %q: %v", test.line, err)
+		}
+	}
+}
+
+type transcriptModel struct {
+	id, gene   string
+	start, end int
+	exons, cds int
+	other      int
+	introns    []Interval
+}
+
+func models(genes []*Gene) (names []string, tx []transcriptModel) {
+	for _, g := range genes {
+		names = append(names, g.ID)
+		for _, t := range g.Transcripts {
+			tx = append(tx, transcriptModel{
+				id: t.ID, gene: t.Gene.ID,
+				start: t.Start, end: t.End,
+				exons: len(t.Exons), cds: len(t.CDS), other: len(t.Other),
+				introns: t.Introns(),
+			})
+		}
+	}
+	return names, tx
+}
+
+func TestBuildModels(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		text   string
+		format Format
+		genes  []string
+		tx     []transcriptModel
+		extent map[string]Interval
+	}{
+		{
+			name: "gff3", text: testGFF3, format: GFF3,
+			genes: []string{"gene1", "gene2", "ncrna"},
+			tx: []transcriptModel{
+				{id: "tx1", gene: "gene1", start: 1049, end: 9000, exons: 3, cds: 2, other: 1,
+					introns: []Interval{{1500, 2999}, {3902, 4999}}},
+				{id: "tx2", gene: "gene1", start: 1049, end: 5500, exons: 2,
+					introns: []Interval{{1500, 4999}}},
+				{id: "ncrna", gene: "ncrna", start: 9, end: 20, exons: 1},
+			},
+			extent: map[string]Interval{"gene1": {999, 9000}, "ncrna": {9, 20}},
+		},
+		{
+			name: "gtf", text: testGTF, format: GTF,
+			genes: []string{"g1", "g2"},
+			tx: []transcriptModel{
+				{id: "t1", gene: "g1", start: 1049, end: 3902, exons: 2, cds: 1,
+					introns: []Interval{{1500, 2999}}},
+				{id: "t2", gene: "g1", start: 1049, end: 5500, exons: 2,
+					introns: []Interval{{1500, 4999}}},
+			},
+			extent: map[string]Interval{"g1": {1049, 5500}, "g2": {99, 200}},
+		},
+	} {
+		genes, err := BuildModels(readAll(t, test.text, test.format), test.format)
+		if err != nil {
+			t.Errorf("unexpected error building %s models: %v", test.name, err)
+			continue
+		}
+		names, tx := models(genes)
+		if !reflect.DeepEqual(names, test.genes) {
+			t.Errorf("unexpected %s genes: got:%v want:%v", test.name, names, test.genes)
+		}
+		if !reflect.DeepEqual(tx, test.tx) {
+			t.Errorf("unexpected %s transcripts:\ngot: %+v\nwant:%+v", test.name, tx, test.tx)
+		}
+		for _, g := range genes {
+			want, ok := test.extent[g.ID]
+			if ok && (g.Start != want.Start || g.End != want.End) {
+				t.Errorf("unexpected %s extent for %s: got:[%d,%d) want:%v", test.name, g.ID, g.Start, g.End, want)
+			}
+		}
+		if test.format == GFF3 && genes[0].Name != "EDEN;1" {
+			t.Errorf("unexpected gene name: %q", genes[0].Name)
+		}
+		if test.format == GTF && (genes[0].Name != "EDEN" || genes[0].Transcripts[1].Name != "EDEN-2") {
+			t.Errorf("unexpected gene or transcript name: %q %q", genes[0].Name, genes[0].Transcripts[1].Name)
+		}
+	}
+
+	_, err := BuildModels(readAll(t, "chr1\tt\texon\t1\t10\t.\t+\t.\tParent=missing\n", GFF3), GFF3)
+	if err == nil {
+		t.Error("expected error for unknown parent")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	genes, err := BuildModels(readAll(t, testGTF, GTF), GTF)
+	if err != nil {
+		t.Fatalf("unexpected error building models: %v", err)
+	}
+	idx := NewIndex(genes)
+	for _, test := range []struct {
+		seq      string
+		beg, end int
+		want     []string
+	}{
+		{seq: "chr1", beg: 0, end: 99, want: nil},
+		{seq: "chr1", beg: 150, end: 160, want: []string{"g2"}},
+		{seq: "chr1", beg: 150, end: 1100, want: []string{"g2", "g1"}},
+		{seq: "chr1", beg: 5500, end: 6000, want: nil},
+		{seq: "chr2", beg: 0, end: 6000, want: nil},
+	} {
+		var got []string
+		for _, g := range idx.Overlapping(test.seq, test.beg, test.end) {
+			got = append(got, g.ID)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected overlaps for %s:[%d,%d): got:%v want:%v", test.seq, test.beg, test.end, got, test.want)
+		}
+	}
+
+	var junc []string
+	for _, tx := range idx.Junction("chr1", 1500, 2999) {
+		junc = append(junc, tx.ID)
+	}
+	if !reflect.DeepEqual(junc, []string{"t1"}) {
+		t.Errorf("unexpected junction transcripts: %v", junc)
+	}
+	if idx.Junction("chr1", 1500, 3000) != nil {
+		t.Error("unexpected junction match")
+	}
+
+	t1 := genes[0].Transcripts[0]
+	if n := t1.ExonOverlap(1400, 3100); n != 201 {
+		t.Errorf("unexpected exon overlap: got:%d want:201", n)
+	}
+	if n := t1.ExonicLength(); n != 451+903 {
+		t.Errorf("unexpected exonic length: got:%d want:%d", n, 451+903)
+	}
+}
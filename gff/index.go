@@ -0,0 +1,118 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gff
+
+import "sort"
+
+// Index provides gene overlap and splice junction queries over a set of
+// gene models for assigning reads to features.
+type Index struct {
+	trees     map[string]*geneTree
+	junctions map[junction][]*Transcript
+}
+
+// junction is a splice junction on a reference sequence.
+type junction struct {
+	seqID string
+	Interval
+}
+
+// NewIndex returns an Index over the given genes.
+func NewIndex(genes []*Gene) *Index {
+	idx := &Index{
+		trees:     make(map[string]*geneTree),
+		junctions: make(map[junction][]*Transcript),
+	}
+	bySeq := make(map[string][]*Gene)
+	for _, g := range genes {
+		bySeq[g.SeqID] = append(bySeq[g.SeqID], g)
+		for _, t := range g.Transcripts {
+			for _, iv := range t.Introns() {
+				j := junction{seqID: t.SeqID, Interval: iv}
+				idx.junctions[j] = append(idx.junctions[j], t)
+			}
+		}
+	}
+	for seq, gs := range bySeq {
+		idx.trees[seq] = newGeneTree(gs)
+	}
+	return idx
+}
+
+// Overlapping returns the genes on the named reference sequence that
+// overlap the zero-based half-open interval [beg,end), in start order.
+func (idx *Index) Overlapping(seqID string, beg, end int) []*Gene {
+	t, ok := idx.trees[seqID]
+	if !ok {
+		return nil
+	}
+	var found []*Gene
+	t.do(0, len(t.genes), beg, end, func(g *Gene) { found = append(found, g) })
+	return found
+}
+
+// Junction returns the transcripts with an intron spanning exactly the
+// zero-based half-open interval [beg,end) on the named reference
+// sequence.
+func (idx *Index) Junction(seqID string, beg, end int) []*Transcript {
+	return idx.junctions[junction{seqID: seqID, Interval: Interval{Start: beg, End: end}}]
+}
+
+// ExonOverlap returns the number of bases of the zero-based half-open
+// interval [beg,end) that fall within exons of the transcript.
+func (t *Transcript) ExonOverlap(beg, end int) int {
+	var n int
+	for _, e := range t.Exons {
+		if e.Start >= end {
+			break
+		}
+		n += max(0, min(end, e.End)-max(beg, e.Start))
+	}
+	return n
+}
+
+// geneTree is a static interval tree of genes, held as an implicit
+// balanced binary tree over the genes sorted by start, with each node
+// augmented by the greatest end within its subtree.
+type geneTree struct {
+	genes  []*Gene
+	maxEnd []int
+}
+
+func newGeneTree(genes []*Gene) *geneTree {
+	sort.SliceStable(genes, func(i, j int) bool { return genes[i].Start < genes[j].Start })
+	t := &geneTree{genes: genes, maxEnd: make([]int, len(genes))}
+	t.augment(0, len(genes))
+	return t
+}
+
+func (t *geneTree) augment(lo, hi int) int {
+	if lo >= hi {
+		return -1
+	}
+	mid := int(uint(lo+hi) >> 1)
+	m := max(t.genes[mid].End, max(t.augment(lo, mid), t.augment(mid+1, hi)))
+	t.maxEnd[mid] = m
+	return m
+}
+
+func (t *geneTree) do(lo, hi, beg, end int, fn func(*Gene)) {
+	if lo >= hi || beg >= end {
+		return
+	}
+	mid := int(uint(lo+hi) >> 1)
+	if t.maxEnd[mid] <= beg {
+		return
+	}
+	t.do(lo, mid, beg, end, fn)
+	g := t.genes[mid]
+	if g.Start >= end {
+		return
+	}
+	if g.End > beg {
+		fn(g)
+	}
+	t.do(mid+1, hi, beg, end, fn)
+}
@@ -0,0 +1,294 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Interval is a zero-based half-open interval.
+type Interval struct {
+	Start, End int
+}
+
+// Gene is a gene model.
+type Gene struct {
+	ID   string
+	Name string
+
+	SeqID      string
+	Strand     byte
+	Start, End int
+
+	// Feature is the feature describing the gene. It
+	// is nil if the gene is implied by its transcripts.
+	Feature *Feature
+
+	Transcripts []*Transcript
+}
+
+// Transcript is a transcript model.
+type Transcript struct {
+	ID   string
+	Name string
+	Gene *Gene
+
+	SeqID      string
+	Strand     byte
+	Start, End int
+
+	// Feature is the feature describing the transcript.
+	// It is nil if the transcript is implied by its parts.
+	Feature *Feature
+
+	// Exons and CDS hold the exon and coding sequence
+	// features of the transcript sorted by start.
+	Exons []*Feature
+	CDS   []*Feature
+
+	// Other holds other parts of the transcript, such
+	// as UTRs and start and stop codons, sorted by start.
+	Other []*Feature
+}
+
+// Introns returns the intervals between consecutive exons of the
+// transcript. These are the splice junctions of the transcript.
+func (t *Transcript) Introns() []Interval {
+	var introns []Interval
+	for i := 1; i < len(t.Exons); i++ {
+		beg, end := t.Exons[i-1].End, t.Exons[i].Start
+		if beg < end {
+			introns = append(introns, Interval{Start: beg, End: end})
+		}
+	}
+	return introns
+}
+
+// ExonicLength returns the total length of the exons of the transcript.
+func (t *Transcript) ExonicLength() int {
+	var n int
+	for _, e := range t.Exons {
+		n += e.Len()
+	}
+	return n
+}
+
+// geneLike are the GFF3 feature types that are taken to be genes
+// when they have no parent.
+var geneLike = map[string]bool{
+	"gene":         true,
+	"ncRNA_gene":   true,
+	"pseudogene":   true,
+	"rRNA_gene":    true,
+	"tRNA_gene":    true,
+	"snRNA_gene":   true,
+	"snoRNA_gene":  true,
+	"miRNA_gene":   true,
+	"protein_gene": true,
+}
+
+// BuildModels assembles gene models from the given features. Features
+// in GFF3 format are related by their ID and Parent attributes; a
+// feature with exon or CDS children is a transcript, and the parent of
+// a transcript is its gene. Features in GTF format are related by their
+// gene_id and transcript_id attributes. Genes and transcripts without
+// describing features are implied, and their extents are computed from
+// their parts. Genes are returned in the order they are first seen.
+func BuildModels(features []*Feature, format Format) ([]*Gene, error) {
+	if format == GTF {
+		return buildGTF(features)
+	}
+	return buildGFF3(features)
+}
+
+type modelBuilder struct {
+	genes       []*Gene
+	geneByID    map[string]*Gene
+	transcripts map[string]*Transcript
+}
+
+func newModelBuilder() *modelBuilder {
+	return &modelBuilder{
+		geneByID:    make(map[string]*Gene),
+		transcripts: make(map[string]*Transcript),
+	}
+}
+
+func (b *modelBuilder) gene(id string, f *Feature) *Gene {
+	g, ok := b.geneByID[id]
+	if !ok {
+		g = &Gene{ID: id, SeqID: f.SeqID, Strand: f.Strand, Start: f.Start, End: f.End}
+		b.geneByID[id] = g
+		b.genes = append(b.genes, g)
+	}
+	return g
+}
+
+func (b *modelBuilder) transcript(id string, g *Gene, f *Feature) *Transcript {
+	t, ok := b.transcripts[id]
+	if !ok {
+		t = &Transcript{ID: id, Gene: g, SeqID: f.SeqID, Strand: f.Strand, Start: f.Start, End: f.End}
+		b.transcripts[id] = t
+		g.Transcripts = append(g.Transcripts, t)
+	}
+	return t
+}
+
+func (t *Transcript) add(f *Feature) {
+	switch f.Type {
+	case "exon":
+		t.Exons = append(t.Exons, f)
+	case "CDS":
+		t.CDS = append(t.CDS, f)
+	default:
+		t.Other = append(t.Other, f)
+	}
+}
+
+// finish sorts transcript parts and computes the extents of implied
+// genes and transcripts.
+func (b *modelBuilder) finish() []*Gene {
+	for _, g := range b.genes {
+		for _, t := range g.Transcripts {
+			for _, parts := range [][]*Feature{t.Exons, t.CDS, t.Other} {
+				sort.SliceStable(parts, func(i, j int) bool { return parts[i].Start < parts[j].Start })
+				if t.Feature == nil {
+					for _, f := range parts {
+						t.Start = min(t.Start, f.Start)
+						t.End = max(t.End, f.End)
+					}
+				}
+			}
+			if g.Feature == nil {
+				g.Start = min(g.Start, t.Start)
+				g.End = max(g.End, t.End)
+			}
+		}
+	}
+	return b.genes
+}
+
+func buildGTF(features []*Feature) ([]*Gene, error) {
+	b := newModelBuilder()
+	for _, f := range features {
+		gid := f.Attributes.Get("gene_id")
+		if gid == "" {
+			continue
+		}
+		g := b.gene(gid, f)
+		if name := f.Attributes.Get("gene_name"); name != "" && g.Name == "" {
+			g.Name = name
+		}
+		if f.Type == "gene" {
+			if g.Feature != nil {
+				return nil, fmt.Errorf("gff: duplicate gene %q", gid)
+			}
+			g.Feature = f
+			g.Start, g.End = f.Start, f.End
+			continue
+		}
+		tid := f.Attributes.Get("transcript_id")
+		if tid == "" {
+			continue
+		}
+		t := b.transcript(tid, g, f)
+		if t.Gene != g {
+			return nil, fmt.Errorf("gff: transcript %q in genes %q and %q", tid, t.Gene.ID, gid)
+		}
+		if name := f.Attributes.Get("transcript_name"); name != "" && t.Name == "" {
+			t.Name = name
+		}
+		if f.Type == "transcript" {
+			if t.Feature != nil {
+				return nil, fmt.Errorf("gff: duplicate transcript %q", tid)
+			}
+			t.Feature = f
+			t.Start, t.End = f.Start, f.End
+			continue
+		}
+		t.add(f)
+	}
+	return b.finish(), nil
+}
+
+func buildGFF3(features []*Feature) ([]*Gene, error) {
+	byID := make(map[string]*Feature)
+	isTranscript := make(map[string]bool)
+	hasTranscript := make(map[string]bool)
+	for _, f := range features {
+		id := f.Attributes.Get("ID")
+		if id != "" {
+			if _, ok := byID[id]; !ok {
+				byID[id] = f
+			}
+		}
+	}
+	for _, f := range features {
+		for _, p := range f.Attributes.Values("Parent") {
+			if _, ok := byID[p]; !ok {
+				return nil, fmt.Errorf("gff: unknown parent %q", p)
+			}
+			if f.Type == "exon" || f.Type == "CDS" {
+				isTranscript[p] = true
+			}
+		}
+	}
+	for id := range isTranscript {
+		for _, p := range byID[id].Attributes.Values("Parent") {
+			hasTranscript[p] = true
+		}
+	}
+
+	b := newModelBuilder()
+	for _, f := range features {
+		id := f.Attributes.Get("ID")
+		if id == "" || byID[id] != f {
+			continue
+		}
+		parents := f.Attributes.Values("Parent")
+		switch {
+		case isTranscript[id]:
+			// A transcript without a gene implies a gene
+			// of its own. Only the first parent of a
+			// transcript with multiple parents is used.
+			var g *Gene
+			if len(parents) == 0 {
+				g = b.gene(id, f)
+			} else {
+				g = b.gene(parents[0], byID[parents[0]])
+			}
+			t := b.transcript(id, g, f)
+			t.Feature = f
+			t.Name = f.Attributes.Get("Name")
+		case hasTranscript[id] || (len(parents) == 0 && geneLike[f.Type]):
+			g := b.gene(id, f)
+			g.Feature = f
+			g.Name = f.Attributes.Get("Name")
+		}
+	}
+	for _, f := range features {
+		for _, p := range f.Attributes.Values("Parent") {
+			if t, ok := b.transcripts[p]; ok {
+				t.add(f)
+			}
+		}
+	}
+	return b.finish(), nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -1,22 +1,31 @@
 module github.com/Schaudge/hts
 
-go 1.19
+go 1.20
 
 require (
 	github.com/Schaudge/grailbase v0.0.0-20240223061707-44c758a471c0
 	github.com/Schaudge/grailbio v0.0.0-20240223024842-cff4bfc6ee22
+	github.com/apache/arrow/go/arrow v0.0.0-20200730104253-651201b0f516
 	github.com/biogo/boom v0.0.0-20150317015657-28119bc1ffc1
 	github.com/grailbio/testutil v0.0.3
 	github.com/klauspost/compress v1.8.6
+	google.golang.org/grpc v1.56.3
+	google.golang.org/protobuf v1.31.0
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/google/flatbuffers v1.11.0 // indirect
 	github.com/klauspost/cpuid v1.2.1 // indirect
 	github.com/kr/pretty v0.2.0 // indirect
 	github.com/kr/text v0.1.0 // indirect
+	golang.org/x/net v0.19.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
 	v.io v0.2.0 // indirect
 	v.io/x/lib v0.1.18 // indirect
 )
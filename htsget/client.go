@@ -0,0 +1,340 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htsget
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/cram"
+)
+
+var (
+	ErrNoURLs     = errors.New("htsget: ticket has no urls")
+	ErrBadDataURL = errors.New("htsget: invalid data url")
+)
+
+// Client is an htsget client.
+type Client struct {
+	// BaseURL is the URL of the reads
+	// endpoint of the htsget server, for
+	// example "https://example.org/reads".
+	BaseURL string
+
+	// HTTPClient is the client used for
+	// requests. If nil, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+
+	// Auth is called on each HTTP request
+	// made by the Client, both to the htsget
+	// server and to the data URLs of tickets,
+	// to allow authentication headers to be
+	// added. The request URL may be examined
+	// to restrict which requests are altered.
+	Auth func(*http.Request) error
+
+	// Retries is the number of times a
+	// request is retried after a network
+	// error or a 5xx or 429 response.
+	Retries int
+
+	// Backoff returns the delay before the
+	// given retry attempt, counting from one.
+	// If nil, an exponential backoff from
+	// 100ms is used.
+	Backoff func(attempt int) time.Duration
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	if c.Backoff == nil {
+		return 100 * time.Millisecond << uint(attempt-1)
+	}
+	return c.Backoff(attempt)
+}
+
+// do performs the request made by newReq, retrying according to the
+// Client's retry policy. A successful response is returned with its
+// body open.
+func (c *Client) do(ctx context.Context, newReq func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt != 0 {
+			t := time.NewTimer(c.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return nil, ctx.Err()
+			case <-t.C:
+			}
+		}
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		if c.Auth != nil {
+			err = c.Auth(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			if attempt < c.Retries && ctx.Err() == nil {
+				continue
+			}
+			return nil, err
+		}
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			if attempt < c.Retries {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				continue
+			}
+		}
+		if resp.StatusCode/100 != 2 {
+			defer resp.Body.Close()
+			return nil, responseError(resp)
+		}
+		return resp, nil
+	}
+}
+
+// responseError returns the htsget error held in the body of resp, or
+// an error describing the status of resp if the body is not an htsget
+// error.
+func responseError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	var e errorResponse
+	if json.Unmarshal(b, &e) != nil || e.Error == nil {
+		return &Error{Status: resp.StatusCode, Type: http.StatusText(resp.StatusCode)}
+	}
+	e.Error.Status = resp.StatusCode
+	return e.Error
+}
+
+// Ticket returns the ticket for the given request.
+func (c *Client) Ticket(ctx context.Context, req Request) (*Ticket, error) {
+	u, err := url.Parse(strings.TrimSuffix(c.BaseURL, "/") + "/" + url.PathEscape(req.ID))
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = req.query().Encode()
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		r, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		r.Header.Set("Accept", "application/vnd.ga4gh.htsget.v1.2.0+json, application/json")
+		return r, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var t ticketResponse
+	err = json.NewDecoder(resp.Body).Decode(&t)
+	if err != nil {
+		return nil, fmt.Errorf("htsget: invalid ticket: %v", err)
+	}
+	if t.Ticket == nil || len(t.Ticket.URLs) == 0 {
+		return nil, ErrNoURLs
+	}
+	return t.Ticket, nil
+}
+
+// Open returns a stream of the slice described by req. The stream is
+// the concatenation of the data blocks of the request's ticket, which
+// are fetched in order as the stream is read. The returned stream must
+// be closed after use.
+//
+// Servers may return records outside the requested region, so records
+// read from the stream should be filtered by the caller.
+func (c *Client) Open(ctx context.Context, req Request) (io.ReadCloser, error) {
+	t, err := c.Ticket(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.OpenTicket(ctx, t), nil
+}
+
+// OpenTicket returns a stream of the concatenated data blocks of t.
+// The returned stream must be closed after use.
+func (c *Client) OpenTicket(ctx context.Context, t *Ticket) io.ReadCloser {
+	return &stream{ctx: ctx, c: c, urls: t.URLs}
+}
+
+// stream is a concatenation of ticket data blocks.
+type stream struct {
+	ctx  context.Context
+	c    *Client
+	urls []URL
+	cur  io.ReadCloser
+	err  error
+}
+
+func (s *stream) Read(p []byte) (int, error) {
+	for s.err == nil {
+		if s.cur == nil {
+			if len(s.urls) == 0 {
+				s.err = io.EOF
+				break
+			}
+			s.cur, s.err = s.c.fetch(s.ctx, s.urls[0])
+			s.urls = s.urls[1:]
+			if s.err != nil {
+				break
+			}
+		}
+		n, err := s.cur.Read(p)
+		if err == io.EOF {
+			s.cur.Close()
+			s.cur = nil
+			err = nil
+			if n == 0 {
+				continue
+			}
+		}
+		if err != nil {
+			s.err = err
+		}
+		return n, err
+	}
+	return 0, s.err
+}
+
+func (s *stream) Close() error {
+	s.err = errors.New("htsget: read from closed stream")
+	if s.cur == nil {
+		return nil
+	}
+	err := s.cur.Close()
+	s.cur = nil
+	return err
+}
+
+// fetch returns the data of the ticket data block u.
+func (c *Client) fetch(ctx context.Context, u URL) (io.ReadCloser, error) {
+	if isDataURL(u.URL) {
+		b, err := decodeDataURL(u.URL)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		r, err := http.NewRequest(http.MethodGet, u.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range u.Headers {
+			r.Header.Set(k, v)
+		}
+		return r, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// decodeDataURL returns the data held by the data URL u.
+func decodeDataURL(u string) ([]byte, error) {
+	i := strings.IndexByte(u, ',')
+	if !isDataURL(u) || i < 0 {
+		return nil, ErrBadDataURL
+	}
+	meta, data := u[len("data:"):i], u[i+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		b, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, ErrBadDataURL
+		}
+		return b, nil
+	}
+	s, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, ErrBadDataURL
+	}
+	return []byte(s), nil
+}
+
+// BAMReader is a bam.Reader reading an htsget slice.
+type BAMReader struct {
+	*bam.Reader
+	stream io.ReadCloser
+}
+
+// BAMReader returns a BAMReader reading the BAM slice described by req,
+// using rd goroutines for decompression. The format of req is set to
+// BAM.
+func (c *Client) BAMReader(ctx context.Context, req Request, rd int) (*BAMReader, error) {
+	req.Format = BAM
+	s, err := c.Open(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	br, err := bam.NewReader(s, rd)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return &BAMReader{Reader: br, stream: s}, nil
+}
+
+// Close closes the BAMReader and its underlying stream.
+func (r *BAMReader) Close() error {
+	err := r.Reader.Close()
+	serr := r.stream.Close()
+	if err == nil {
+		err = serr
+	}
+	return err
+}
+
+// CRAMReader is a cram.Reader reading an htsget slice.
+type CRAMReader struct {
+	*cram.Reader
+	stream io.ReadCloser
+}
+
+// CRAMReader returns a CRAMReader reading the CRAM slice described by
+// req, using ref to obtain reference sequence. The format of req is
+// set to CRAM.
+func (c *Client) CRAMReader(ctx context.Context, req Request, ref cram.ReferenceProvider) (*CRAMReader, error) {
+	req.Format = CRAM
+	s, err := c.Open(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	cr, err := cram.NewReader(s, ref)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return &CRAMReader{Reader: cr, stream: s}, nil
+}
+
+// Close closes the underlying stream of the CRAMReader.
+func (r *CRAMReader) Close() error {
+	return r.stream.Close()
+}
@@ -0,0 +1,127 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package htsget implements a client and a server for the GA4GH htsget
+// protocol for retrieving slices of remote BAM and CRAM files.
+//
+// The htsget protocol is described in the htsget specification.
+//
+// https://samtools.github.io/hts-specs/htsget.html
+package htsget
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Data formats.
+const (
+	BAM  = "BAM"
+	CRAM = "CRAM"
+)
+
+// Data classes.
+const (
+	ClassHeader = "header"
+	ClassBody   = "body"
+)
+
+// Request is an htsget reads request.
+type Request struct {
+	// ID is the identifier of the
+	// requested file.
+	ID string
+
+	// Format is the requested data format.
+	// If empty, the server default is used.
+	Format string
+
+	// Class is the requested data class.
+	// If ClassHeader, only the header is
+	// requested.
+	Class string
+
+	// ReferenceName is the name of the
+	// reference of the requested region.
+	// If empty, the whole file is requested.
+	// The name "*" requests unplaced reads.
+	ReferenceName string
+
+	// Start and End are the zero-based
+	// half-open coordinates of the region.
+	// If End is zero, the region extends
+	// to the end of the reference.
+	Start, End int
+}
+
+// query returns the URL query parameters for the request.
+func (r Request) query() url.Values {
+	q := make(url.Values)
+	if r.Format != "" {
+		q.Set("format", r.Format)
+	}
+	if r.Class != "" {
+		q.Set("class", r.Class)
+	}
+	if r.ReferenceName != "" {
+		q.Set("referenceName", r.ReferenceName)
+		if r.Start > 0 {
+			q.Set("start", strconv.Itoa(r.Start))
+		}
+		if r.End > 0 {
+			q.Set("end", strconv.Itoa(r.End))
+		}
+	}
+	return q
+}
+
+// Ticket is an htsget ticket describing the data blocks that make up a
+// requested slice.
+type Ticket struct {
+	Format string `json:"format"`
+	URLs   []URL  `json:"urls"`
+	MD5    string `json:"md5,omitempty"`
+}
+
+// URL is a data block in a ticket. The data of the slice is the
+// concatenation of the data of each URL.
+type URL struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Class   string            `json:"class,omitempty"`
+}
+
+// Error is an htsget error response.
+type Error struct {
+	// Status is the HTTP status code
+	// of the response.
+	Status int `json:"-"`
+
+	Type    string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("htsget: %s (status %d)", e.Type, e.Status)
+	}
+	return fmt.Sprintf("htsget: %s: %s (status %d)", e.Type, e.Message, e.Status)
+}
+
+// ticketResponse is the JSON envelope of a ticket.
+type ticketResponse struct {
+	Ticket *Ticket `json:"htsget"`
+}
+
+// errorResponse is the JSON envelope of an error.
+type errorResponse struct {
+	Error *Error `json:"htsget"`
+}
+
+// isDataURL returns whether u is an inline data URL.
+func isDataURL(u string) bool {
+	return strings.HasPrefix(u, "data:")
+}
@@ -0,0 +1,206 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htsget
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+func testBAM(t *testing.T) ([]byte, []string) {
+	ref, err := sam.NewReference("chr1", "", "", 10000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error making reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{ref})
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	var buf bytes.Buffer
+	bw, err := bam.NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error making writer: %v", err)
+	}
+	var names []string
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("r%d", i)
+		seq := bytes.Repeat([]byte{'A'}, 50)
+		qual := bytes.Repeat([]byte{30}, 50)
+		r, err := sam.NewRecord(name, ref, nil, i*100, -1, 0, 60,
+			[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 50)}, seq, qual, nil)
+		if err != nil {
+			t.Fatalf("unexpected error making record: %v", err)
+		}
+		err = bw.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+		names = append(names, name)
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	return buf.Bytes(), names
+}
+
+func TestClient(t *testing.T) {
+	data, names := testBAM(t)
+	const split = 100
+	var (
+		failures  int32 = 1
+		gotQuery  string
+		dataAuths int32
+	)
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/reads/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(errorResponse{&Error{Type: "PermissionDenied", Message: "no token"}})
+			return
+		}
+		if strings.TrimPrefix(r.URL.Path, "/reads/") != "sample 1" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(errorResponse{&Error{Type: "NotFound", Message: "no such id"}})
+			return
+		}
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gotQuery = r.URL.RawQuery
+		json.NewEncoder(w).Encode(ticketResponse{&Ticket{
+			Format: BAM,
+			URLs: []URL{
+				{URL: "data:application/vnd.ga4gh.bam;base64," + base64.StdEncoding.EncodeToString(data[:split]), Class: ClassHeader},
+				{URL: srv.URL + "/data", Headers: map[string]string{"Range": fmt.Sprintf("bytes=%d-", split)}, Class: ClassBody},
+			},
+		}})
+	})
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			atomic.AddInt32(&dataAuths, 1)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := &Client{
+		BaseURL: srv.URL + "/reads/",
+		Auth: func(r *http.Request) error {
+			if strings.HasPrefix(r.URL.Path, "/reads/") {
+				r.Header.Set("Authorization", "Bearer token")
+			}
+			return nil
+		},
+		Retries: 2,
+		Backoff: func(int) time.Duration { return time.Millisecond },
+	}
+	ctx := context.Background()
+	req := Request{ID: "sample 1", ReferenceName: "chr1", Start: 100, End: 500}
+
+	s, err := c.Open(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error opening stream: %v", err)
+	}
+	got, err := io.ReadAll(s)
+	if err != nil {
+		t.Fatalf("unexpected error reading stream: %v", err)
+	}
+	s.Close()
+	if !bytes.Equal(got, data) {
+		t.Errorf("unexpected stream data: got %d bytes want %d", len(got), len(data))
+	}
+	const wantQuery = "end=500&referenceName=chr1&start=100"
+	if gotQuery != wantQuery {
+		t.Errorf("unexpected query: got:%q want:%q", gotQuery, wantQuery)
+	}
+	if dataAuths != 0 {
+		t.Errorf("unexpected authorization of data requests")
+	}
+
+	br, err := c.BAMReader(ctx, req, 1)
+	if err != nil {
+		t.Fatalf("unexpected error opening BAM reader: %v", err)
+	}
+	var gotNames []string
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		gotNames = append(gotNames, r.Name)
+	}
+	err = br.Close()
+	if err != nil {
+		t.Errorf("unexpected error closing BAM reader: %v", err)
+	}
+	if !reflect.DeepEqual(gotNames, names) {
+		t.Errorf("unexpected records: got:%v want:%v", gotNames, names)
+	}
+	if !strings.Contains(gotQuery, "format=BAM") {
+		t.Errorf("expected BAM format in query: %q", gotQuery)
+	}
+
+	_, err = c.Ticket(ctx, Request{ID: "missing"})
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusNotFound || e.Type != "NotFound" {
+		t.Errorf("unexpected error for missing id: %v", err)
+	}
+	c.Auth = nil
+	_, err = c.Ticket(ctx, req)
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusUnauthorized {
+		t.Errorf("unexpected error without authorization: %v", err)
+	}
+
+	atomic.StoreInt32(&failures, 5)
+	c.Auth = func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer token")
+		return nil
+	}
+	_, err = c.Ticket(ctx, req)
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusServiceUnavailable {
+		t.Errorf("unexpected error after exhausting retries: %v", err)
+	}
+}
+
+func TestDataURL(t *testing.T) {
+	for _, test := range []struct {
+		url     string
+		want    string
+		wantErr error
+	}{
+		{url: "data:;base64,aGVsbG8=", want: "hello"},
+		{url: "data:text/plain,hello%20world", want: "hello world"},
+		{url: "data:;base64,!!", wantErr: ErrBadDataURL},
+		{url: "data:hello", wantErr: ErrBadDataURL},
+	} {
+		got, err := decodeDataURL(test.url)
+		if err != test.wantErr {
+			t.Errorf("unexpected error for %q: got:%v want:%v", test.url, err, test.wantErr)
+		}
+		if string(got) != test.want {
+			t.Errorf("unexpected data for %q: got:%q want:%q", test.url, got, test.want)
+		}
+	}
+}
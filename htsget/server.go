@@ -0,0 +1,485 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htsget
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/internal"
+	"github.com/Schaudge/hts/sam"
+)
+
+// ErrNotFound is returned by a Store when the requested file does not
+// exist.
+var ErrNotFound = errors.New("htsget: not found")
+
+// ReadAtCloser is the interface that groups the ReadAt and Close
+// methods.
+type ReadAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// File is an indexed BAM file served by a Server.
+type File struct {
+	// Data and Size are the BAM data
+	// and its length.
+	Data ReadAtCloser
+	Size int64
+
+	// Index is the BAI index of the data.
+	Index *bam.Index
+
+	// URL is the URL from which clients
+	// fetch byte ranges of the data, such
+	// as a presigned object store URL. If
+	// empty, the data is served by the
+	// Server from its data endpoint.
+	URL string
+}
+
+// Store provides access to the files served by a Server.
+type Store interface {
+	// Open returns the file with the given
+	// ID. If the file does not exist, Open
+	// returns ErrNotFound. The Data of the
+	// returned file is closed by the Server
+	// when it is no longer needed.
+	Open(ctx context.Context, id string) (*File, error)
+}
+
+// Dir is a Store serving the BAM files held in a local directory. The
+// file with ID id is read from id.bam and its index from id.bam.bai.
+type Dir string
+
+// Open implements the Store interface.
+func (d Dir) Open(ctx context.Context, id string) (*File, error) {
+	return FS{FS: os.DirFS(string(d))}.Open(ctx, id)
+}
+
+// FS is a Store serving the BAM files held in the root of a file
+// system, such as an embedded or zip-backed file system. The file with
+// ID id is read from id.bam and its index from id.bam.bai. BAM files
+// that do not implement io.ReaderAt are read into memory.
+type FS struct {
+	FS fs.FS
+}
+
+// Open implements the Store interface.
+func (s FS) Open(_ context.Context, id string) (*File, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return nil, ErrNotFound
+	}
+	name := id + ".bam"
+	idx, err := bam.ReadIndexFS(s.FS, name+".bai")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	f, err := s.FS.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	ra, size, err := internal.ReaderAt(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &File{Data: readAtCloser{ReaderAt: ra, Closer: f}, Size: size, Index: idx}, nil
+}
+
+type readAtCloser struct {
+	io.ReaderAt
+	io.Closer
+}
+
+// Server is an http.Handler serving htsget reads tickets for BAM files.
+//
+// The Server handles requests for tickets at /reads/{id} and, for files
+// without a URL, requests for byte ranges of file data at /data/{id}.
+// When the Server is mounted below the root of a site, the mount prefix
+// should be removed with http.StripPrefix.
+//
+// Tickets hold the BAM header and end-of-file marker as inline data
+// blocks, and the records of the requested region as byte ranges of
+// the file. Where a region starts or ends within a BGZF block, the
+// partial block is recompressed and held inline, so that the
+// concatenated data is a valid BAM stream.
+type Server struct {
+	// Store provides the served files.
+	Store Store
+
+	// BaseURL is the public URL at which
+	// the Server is mounted, used to
+	// construct data URLs. If empty, it is
+	// derived from each request.
+	BaseURL string
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "InvalidInput", "method not allowed")
+		return
+	}
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/reads/"):
+		s.serveTicket(w, r, strings.TrimPrefix(r.URL.Path, "/reads/"))
+	case strings.HasPrefix(r.URL.Path, "/data/"):
+		s.serveData(w, r, strings.TrimPrefix(r.URL.Path, "/data/"))
+	default:
+		writeError(w, http.StatusNotFound, "NotFound", "unknown endpoint")
+	}
+}
+
+// writeError writes an htsget error response.
+func writeError(w http.ResponseWriter, status int, typ, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{&Error{Type: typ, Message: msg}})
+}
+
+// openError writes the error response for an error returned by a Store.
+func openError(w http.ResponseWriter, err error) {
+	if err == ErrNotFound {
+		writeError(w, http.StatusNotFound, "NotFound", "no such id")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "InternalError", err.Error())
+}
+
+func (s *Server) serveData(w http.ResponseWriter, r *http.Request, id string) {
+	f, err := s.Store.Open(r.Context(), id)
+	if err != nil {
+		openError(w, err)
+		return
+	}
+	defer f.Data.Close()
+	w.Header().Set("Content-Type", "application/vnd.ga4gh.bam")
+	http.ServeContent(w, r, "", time.Time{}, io.NewSectionReader(f.Data, 0, f.Size))
+}
+
+func (s *Server) serveTicket(w http.ResponseWriter, r *http.Request, id string) {
+	req, err := parseRequest(id, r.URL.Query())
+	if err != nil {
+		e := err.(*Error)
+		writeError(w, e.Status, e.Type, e.Message)
+		return
+	}
+	f, err := s.Store.Open(r.Context(), id)
+	if err != nil {
+		openError(w, err)
+		return
+	}
+	defer f.Data.Close()
+
+	dataURL := f.URL
+	if dataURL == "" {
+		dataURL = s.baseURL(r) + "/data/" + url.PathEscape(id)
+	}
+	t, err := newTicket(f, req, dataURL)
+	if err != nil {
+		if e, ok := err.(*Error); ok {
+			writeError(w, e.Status, e.Type, e.Message)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.ga4gh.htsget.v1.2.0+json")
+	json.NewEncoder(w).Encode(ticketResponse{t})
+}
+
+// baseURL returns the public URL of the Server for the request r.
+func (s *Server) baseURL(r *http.Request) string {
+	if s.BaseURL != "" {
+		return strings.TrimSuffix(s.BaseURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	prefix := ""
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		prefix = strings.TrimSuffix(u.Path, r.URL.Path)
+	}
+	return scheme + "://" + r.Host + prefix
+}
+
+// parseRequest returns the Request described by the query parameters q.
+func parseRequest(id string, q url.Values) (Request, error) {
+	req := Request{
+		ID:            id,
+		Format:        q.Get("format"),
+		Class:         q.Get("class"),
+		ReferenceName: q.Get("referenceName"),
+	}
+	invalid := func(msg string) error {
+		return &Error{Status: http.StatusBadRequest, Type: "InvalidInput", Message: msg}
+	}
+	if req.Format != "" && req.Format != BAM {
+		return req, &Error{Status: http.StatusBadRequest, Type: "UnsupportedFormat", Message: "only BAM is supported"}
+	}
+	req.Format = BAM
+	if req.Class != "" && req.Class != ClassHeader {
+		return req, invalid("invalid class")
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"start", &req.Start}, {"end", &req.End}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		if req.ReferenceName == "" || req.ReferenceName == "*" {
+			return req, invalid(p.name + " requires referenceName")
+		}
+		n, err := strconv.ParseUint(v, 10, 31)
+		if err != nil {
+			return req, invalid("invalid " + p.name)
+		}
+		*p.dst = int(n)
+	}
+	if req.End != 0 && req.Start > req.End {
+		return req, &Error{Status: http.StatusBadRequest, Type: "InvalidRange", Message: "start greater than end"}
+	}
+	if req.ReferenceName == "*" {
+		return req, invalid("unplaced reads are not supported")
+	}
+	return req, nil
+}
+
+// newTicket returns the ticket for req on the file f, with byte ranges
+// of the file fetched from dataURL.
+func newTicket(f *File, req Request, dataURL string) (*Ticket, error) {
+	br, err := bam.NewReader(io.NewSectionReader(f.Data, 0, f.Size), 1)
+	if err != nil {
+		return nil, err
+	}
+	h := br.Header()
+	br.Close()
+
+	c, err := bgzf.NewBGZFCodec(gzip.DefaultCompression, bgzf.Libdeflate)
+	if err != nil {
+		return nil, err
+	}
+	tb := ticketBuilder{t: &Ticket{Format: BAM}, f: f, codec: c, dataURL: dataURL}
+
+	hdr, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	err = tb.inline(hdr, ClassHeader)
+	if err != nil {
+		return nil, err
+	}
+	if req.Class != ClassHeader {
+		err = tb.body(h, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tb.t.URLs = append(tb.t.URLs, URL{URL: dataURI(c.EOF()), Class: ClassBody})
+	return tb.t, nil
+}
+
+// ticketBuilder accumulates the data blocks of a ticket.
+type ticketBuilder struct {
+	t       *Ticket
+	f       *File
+	codec   *bgzf.BGZFCodec
+	dataURL string
+
+	// beg and end are the byte range of the
+	// last data block if it is a range, and
+	// are equal otherwise.
+	beg, end int64
+}
+
+// inline adds data compressed as BGZF blocks as an inline data block.
+func (tb *ticketBuilder) inline(data []byte, class string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var b []byte
+	for len(data) != 0 {
+		n := len(data)
+		if n > bgzf.BlockSize {
+			n = bgzf.BlockSize
+		}
+		var err error
+		b, err = tb.codec.Encode(b, data[:n])
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	tb.t.URLs = append(tb.t.URLs, URL{URL: dataURI(b), Class: class})
+	tb.beg, tb.end = 0, 0
+	return nil
+}
+
+// byteRange adds the byte range [beg,end) of the file as a data block,
+// extending the previous data block if it is an adjacent range.
+func (tb *ticketBuilder) byteRange(beg, end int64) {
+	if beg >= end {
+		return
+	}
+	if tb.beg != tb.end && tb.end == beg {
+		tb.end = end
+		tb.t.URLs[len(tb.t.URLs)-1].Headers["Range"] = fmt.Sprintf("bytes=%d-%d", tb.beg, tb.end-1)
+		return
+	}
+	tb.beg, tb.end = beg, end
+	tb.t.URLs = append(tb.t.URLs, URL{
+		URL:     tb.dataURL,
+		Headers: map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", beg, end-1)},
+		Class:   ClassBody,
+	})
+}
+
+// body adds the data blocks holding the records requested by req.
+func (tb *ticketBuilder) body(h *sam.Header, req Request) error {
+	if req.ReferenceName == "" {
+		// The whole file is requested; skip the
+		// header, which ends at a record boundary
+		// that need not be a block boundary.
+		br, err := bam.NewReader(io.NewSectionReader(tb.f.Data, 0, tb.f.Size), 1)
+		if err != nil {
+			return err
+		}
+		defer br.Close()
+		_, err = br.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		first := br.LastChunk().Begin
+		end, err := tb.dataEnd()
+		if err != nil {
+			return err
+		}
+		return tb.chunk(bgzf.Chunk{Begin: first, End: bgzf.Offset{File: end}})
+	}
+
+	var ref *sam.Reference
+	for _, r := range h.Refs() {
+		if r.Name() == req.ReferenceName {
+			ref = r
+			break
+		}
+	}
+	if ref == nil {
+		return &Error{Status: http.StatusNotFound, Type: "NotFound", Message: "no such reference"}
+	}
+	end := req.End
+	if end == 0 || end > ref.Len() {
+		end = ref.Len()
+	}
+	if req.Start >= ref.Len() && ref.Len() > 0 {
+		return &Error{Status: http.StatusBadRequest, Type: "InvalidRange", Message: "start beyond end of reference"}
+	}
+	chunks, err := tb.f.Index.RegionChunks([]bam.Region{{Ref: ref, Start: req.Start, End: end}})
+	if err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		err = tb.chunk(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dataEnd returns the file offset of the end of the BAM data, excluding
+// any trailing EOF marker block.
+func (tb *ticketBuilder) dataEnd() (int64, error) {
+	end := tb.f.Size
+	ok, err := bgzf.HasEOF(io.NewSectionReader(tb.f.Data, 0, tb.f.Size))
+	if err != nil && err != bgzf.ErrNoEnd {
+		return 0, err
+	}
+	if ok {
+		end -= int64(len(tb.codec.EOF()))
+	}
+	return end, nil
+}
+
+// chunk adds the data blocks holding the data of c. Partial blocks at
+// the ends of the chunk are held inline and whole blocks as ranges.
+func (tb *ticketBuilder) chunk(c bgzf.Chunk) error {
+	beg := c.Begin.File
+	if c.Begin.Block != 0 {
+		data, size, err := tb.block(c.Begin.File)
+		if err != nil {
+			return err
+		}
+		if c.End.File == c.Begin.File {
+			return tb.inline(data[c.Begin.Block:c.End.Block], ClassBody)
+		}
+		err = tb.inline(data[c.Begin.Block:], ClassBody)
+		if err != nil {
+			return err
+		}
+		beg += int64(size)
+	}
+	tb.byteRange(beg, c.End.File)
+	if c.End.Block != 0 {
+		data, _, err := tb.block(c.End.File)
+		if err != nil {
+			return err
+		}
+		return tb.inline(data[:c.End.Block], ClassBody)
+	}
+	return nil
+}
+
+// block returns a copy of the decompressed data of the BGZF block at
+// the file offset off, and the compressed size of the block.
+func (tb *ticketBuilder) block(off int64) ([]byte, int, error) {
+	it := bgzf.NewBlockIterator(io.NewSectionReader(tb.f.Data, off, tb.f.Size-off))
+	if !it.Next() {
+		err := it.Error()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	data, err := it.Data()
+	if err != nil {
+		return nil, 0, err
+	}
+	return append([]byte(nil), data...), it.Block().Size, nil
+}
+
+// dataURI returns b as a base64 encoded data URL.
+func dataURI(b []byte) string {
+	return "data:application/vnd.ga4gh.bam;base64," + base64.StdEncoding.EncodeToString(b)
+}
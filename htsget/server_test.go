@@ -0,0 +1,283 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htsget
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+// writeIndexedBAM writes a coordinate sorted BAM of n records and its
+// index to dir as id.bam and id.bam.bai, and returns the records.
+func writeIndexedBAM(t *testing.T, dir, id string, n int) []*sam.Record {
+	rnd := rand.New(rand.NewSource(1))
+	var refs []*sam.Reference
+	for _, name := range []string{"chr1", "chr2", "chr3"} {
+		ref, err := sam.NewReference(name, "", "", 1<<20, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error making reference: %v", err)
+		}
+		refs = append(refs, ref)
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	h.SortOrder = sam.Coordinate
+	recs := make([]*sam.Record, n)
+	for i := range recs {
+		// Leave chr3 without records.
+		ref := refs[rnd.Intn(2)]
+		l := 50 + rnd.Intn(100)
+		seq := bytes.Repeat([]byte{'C'}, l)
+		qual := bytes.Repeat([]byte{30}, l)
+		recs[i], err = sam.NewRecord(fmt.Sprintf("r%d", i), ref, nil, rnd.Intn(ref.Len()-1000), -1, 0, 60,
+			[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, l)}, seq, qual, nil)
+		if err != nil {
+			t.Fatalf("unexpected error making record: %v", err)
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].LessByCoordinate(recs[j]) })
+
+	var buf bytes.Buffer
+	bw, err := bam.NewWriter(&buf, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error making writer: %v", err)
+	}
+	for _, r := range recs {
+		err = bw.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	br, err := bam.NewReader(bytes.NewReader(buf.Bytes()), 1)
+	if err != nil {
+		t.Fatalf("unexpected error making reader: %v", err)
+	}
+	var idx bam.Index
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("unexpected error indexing record: %v", err)
+		}
+	}
+	var ibuf bytes.Buffer
+	err = bam.WriteIndex(&ibuf, &idx)
+	if err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+	path := filepath.Join(dir, id+".bam")
+	err = os.WriteFile(path, buf.Bytes(), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error writing BAM: %v", err)
+	}
+	err = os.WriteFile(path+".bai", ibuf.Bytes(), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+	return recs
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	recs := writeIndexedBAM(t, dir, "sample", 5000)
+
+	mux := http.NewServeMux()
+	mux.Handle("/htsget/", http.StripPrefix("/htsget", &Server{Store: Dir(dir)}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL + "/htsget/reads"}
+	ctx := context.Background()
+
+	for _, req := range []Request{
+		{ID: "sample"},
+		{ID: "sample", Class: ClassHeader},
+		{ID: "sample", ReferenceName: "chr1"},
+		{ID: "sample", ReferenceName: "chr2", Start: 1000, End: 2000},
+		{ID: "sample", ReferenceName: "chr2", Start: 500000, End: 600000},
+		{ID: "sample", ReferenceName: "chr1", Start: 1 << 19},
+		{ID: "sample", ReferenceName: "chr3"},
+	} {
+		br, err := c.BAMReader(ctx, req, 1)
+		if err != nil {
+			t.Fatalf("unexpected error opening %+v: %v", req, err)
+		}
+		if len(br.Header().Refs()) != 3 {
+			t.Errorf("unexpected header for %+v: %v", req, br.Header().Refs())
+		}
+		got := make(map[string]bool)
+		for {
+			r, err := br.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error reading %+v: %v", req, err)
+			}
+			if got[r.Name] {
+				t.Errorf("duplicate record %s for %+v", r.Name, req)
+			}
+			got[r.Name] = true
+		}
+		br.Close()
+
+		var want, extra []string
+		for _, r := range recs {
+			if req.Class != ClassHeader && overlaps(r, req) {
+				want = append(want, r.Name)
+				if !got[r.Name] {
+					t.Errorf("missing record %s for %+v", r.Name, req)
+				}
+			} else if got[r.Name] {
+				extra = append(extra, r.Name)
+			}
+		}
+		if req.ReferenceName == "" && len(extra) != 0 {
+			t.Errorf("unexpected records for %+v: %v", req, extra)
+		}
+		if req.Class == ClassHeader && len(got) != 0 {
+			t.Errorf("unexpected records for header request: %d", len(got))
+		}
+	}
+
+	for _, test := range []struct {
+		req    Request
+		status int
+		typ    string
+	}{
+		{req: Request{ID: "missing"}, status: http.StatusNotFound, typ: "NotFound"},
+		{req: Request{ID: "sample", ReferenceName: "chrX"}, status: http.StatusNotFound, typ: "NotFound"},
+		{req: Request{ID: "sample", Format: CRAM}, status: http.StatusBadRequest, typ: "UnsupportedFormat"},
+		{req: Request{ID: "sample", ReferenceName: "chr1", Start: 10, End: 5}, status: http.StatusBadRequest, typ: "InvalidRange"},
+		{req: Request{ID: "sample", ReferenceName: "chr1", Start: 1 << 21}, status: http.StatusBadRequest, typ: "InvalidRange"},
+	} {
+		_, err := c.Ticket(ctx, test.req)
+		e, ok := err.(*Error)
+		if !ok || e.Status != test.status || e.Type != test.typ {
+			t.Errorf("unexpected error for %+v: got:%v want:%s (status %d)", test.req, err, test.typ, test.status)
+		}
+	}
+
+	tk, err := c.Ticket(ctx, Request{ID: "sample", ReferenceName: "chr1"})
+	if err != nil {
+		t.Fatalf("unexpected error getting ticket: %v", err)
+	}
+	var classes []string
+	for _, u := range tk.URLs {
+		if !isDataURL(u.URL) && u.URL != srv.URL+"/htsget/data/sample" {
+			t.Errorf("unexpected data URL: %s", u.URL)
+		}
+		classes = append(classes, u.Class)
+	}
+	if classes[0] != ClassHeader || !reflect.DeepEqual(classes[1:], repeat(ClassBody, len(classes)-1)) {
+		t.Errorf("unexpected classes: %v", classes)
+	}
+}
+
+func overlaps(r *sam.Record, req Request) bool {
+	if req.ReferenceName == "" {
+		return true
+	}
+	if r.Ref.Name() != req.ReferenceName {
+		return false
+	}
+	end := req.End
+	if end == 0 {
+		end = r.Ref.Len()
+	}
+	return r.Pos < end && r.End() > req.Start
+}
+
+func repeat(s string, n int) []string {
+	v := make([]string, n)
+	for i := range v {
+		v[i] = s
+	}
+	return v
+}
+
+// streamFS hides the io.ReaderAt and io.Seeker methods of the files
+// of an fs.FS, as is the case for compressed zip archive members.
+type streamFS struct{ fs.FS }
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+func TestFS(t *testing.T) {
+	dir := t.TempDir()
+	writeIndexedBAM(t, dir, "sample", 100)
+	data, err := os.ReadFile(filepath.Join(dir, "sample.bam"))
+	if err != nil {
+		t.Fatalf("unexpected error reading BAM: %v", err)
+	}
+	bai, err := os.ReadFile(filepath.Join(dir, "sample.bam.bai"))
+	if err != nil {
+		t.Fatalf("unexpected error reading index: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"sample.bam":     {Data: data},
+		"sample.bam.bai": {Data: bai},
+	}
+	ctx := context.Background()
+	for _, store := range []Store{FS{FS: fsys}, FS{FS: streamFS{fsys}}} {
+		f, err := store.Open(ctx, "sample")
+		if err != nil {
+			t.Fatalf("unexpected error opening file: %v", err)
+		}
+		if f.Size != int64(len(data)) {
+			t.Errorf("unexpected file size: got:%d want:%d", f.Size, len(data))
+		}
+		got := make([]byte, f.Size)
+		_, err = f.Data.ReadAt(got, 0)
+		if err != nil && err != io.EOF {
+			t.Errorf("unexpected error reading file data: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Error("unexpected file data")
+		}
+		err = f.Data.Close()
+		if err != nil {
+			t.Errorf("unexpected error closing file data: %v", err)
+		}
+		for _, id := range []string{"missing", "../sample", ""} {
+			_, err = store.Open(ctx, id)
+			if err != ErrNotFound {
+				t.Errorf("unexpected error for %q: got:%v want:%v", id, err, ErrNotFound)
+			}
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htstestutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+// BAMBuilder builds small BAM streams for tests. Methods of a
+// BAMBuilder and of the RecordBuilders it returns may be chained:
+//
+//	data, bai, err := htstestutil.NewBAMBuilder().
+//		Ref("chr1", 1000).
+//		SortOrder(sam.Coordinate).
+//		Record("r0", "chr1", 10, "4M", "ACGT").MapQ(60).Tag("NM", 0).
+//		Record("r1", "*", -1, "*", "ACGT").Flags(sam.Unmapped).
+//		BuildIndexed()
+//
+// The first error made by a method is returned by Build or
+// BuildIndexed.
+type BAMBuilder struct {
+	refs   []refSpec
+	groups []groupSpec
+	order  sam.SortOrder
+	recs   []*RecordBuilder
+	err    error
+}
+
+// NewBAMBuilder returns a new BAMBuilder with no references and an
+// unknown sort order.
+func NewBAMBuilder() *BAMBuilder {
+	return &BAMBuilder{}
+}
+
+// refSpec and groupSpec describe the references and read groups of a
+// BAMBuilder, which are made anew for each header since references and
+// read groups may only belong to one header.
+type (
+	refSpec struct {
+		name   string
+		length int
+	}
+	groupSpec struct {
+		id, sample string
+	}
+)
+
+// Ref adds a reference with the given name and length to the header.
+func (b *BAMBuilder) Ref(name string, length int) *BAMBuilder {
+	_, err := sam.NewReference(name, "", "", length, nil, nil)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.refs = append(b.refs, refSpec{name: name, length: length})
+	return b
+}
+
+// ReadGroup adds a read group with the given ID and sample to the
+// header.
+func (b *BAMBuilder) ReadGroup(id, sample string) *BAMBuilder {
+	b.groups = append(b.groups, groupSpec{id: id, sample: sample})
+	return b
+}
+
+// SortOrder sets the sort order of the header.
+func (b *BAMBuilder) SortOrder(o sam.SortOrder) *BAMBuilder {
+	b.order = o
+	return b
+}
+
+// Record adds a record with the given name, reference, zero-based
+// position, CIGAR and sequence, returning a RecordBuilder to set its
+// other fields. A ref of "*" gives an unplaced record, and cigar and
+// seq may be "*" to leave them empty. The record is mapped unless
+// the Unmapped flag is set with Flags.
+func (b *BAMBuilder) Record(name, ref string, pos int, cigar, seq string) *RecordBuilder {
+	r := &RecordBuilder{b: b, name: name, ref: ref, mateRef: "*", pos: pos, matePos: -1, cigar: cigar, seq: seq, qual: "*"}
+	b.recs = append(b.recs, r)
+	return r
+}
+
+func (b *BAMBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Header returns the header of the BAM stream.
+func (b *BAMBuilder) Header() (*sam.Header, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	refs := make([]*sam.Reference, len(b.refs))
+	for i, spec := range b.refs {
+		var err error
+		refs[i], err = sam.NewReference(spec.name, "", "", spec.length, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		return nil, err
+	}
+	h.SortOrder = b.order
+	if h.SortOrder != sam.UnknownOrder {
+		h.Version = "1.6"
+	}
+	for _, spec := range b.groups {
+		rg, err := sam.NewReadGroup(spec.id, "", "", "", "", "", "", spec.sample, "", "", time.Time{}, 0)
+		if err != nil {
+			return nil, err
+		}
+		err = h.AddReadGroup(rg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Records returns the records of the BAM stream, using the references
+// of h, which must be returned by Header.
+func (b *BAMBuilder) Records(h *sam.Header) ([]*sam.Record, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	refs := make(map[string]*sam.Reference)
+	for _, ref := range h.Refs() {
+		refs[ref.Name()] = ref
+	}
+	recs := make([]*sam.Record, len(b.recs))
+	for i, rb := range b.recs {
+		var err error
+		recs[i], err = rb.record(refs)
+		if err != nil {
+			return nil, fmt.Errorf("htstestutil: record %d (%s): %w", i, rb.name, err)
+		}
+	}
+	return recs, nil
+}
+
+// Build returns the BAM encoding of the header and records.
+func (b *BAMBuilder) Build() ([]byte, error) {
+	h, err := b.Header()
+	if err != nil {
+		return nil, err
+	}
+	recs, err := b.Records(h)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w, err := bam.NewWriter(&buf, h, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range recs {
+		err = w.Write(r)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// BuildIndexed returns the BAM encoding of the header and records and
+// the BAI encoding of its index. The records must be in coordinate
+// order.
+func (b *BAMBuilder) BuildIndexed() (data, bai []byte, err error) {
+	data, err = b.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	br, err := bam.NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer br.Close()
+	var idx bam.Index
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	var buf bytes.Buffer
+	err = bam.WriteIndex(&buf, &idx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, buf.Bytes(), nil
+}
+
+// RecordBuilder sets the fields of a record added by a BAMBuilder.
+type RecordBuilder struct {
+	b *BAMBuilder
+
+	name      string
+	ref       string
+	pos       int
+	mapQ      byte
+	cigar     string
+	flags     sam.Flags
+	mateRef   string
+	matePos   int
+	tempLen   int
+	seq, qual string
+	aux       []sam.Aux
+}
+
+// Flags sets the flags of the record.
+func (r *RecordBuilder) Flags(f sam.Flags) *RecordBuilder {
+	r.flags = f
+	return r
+}
+
+// MapQ sets the mapping quality of the record.
+func (r *RecordBuilder) MapQ(q byte) *RecordBuilder {
+	r.mapQ = q
+	return r
+}
+
+// Qual sets the base qualities of the record from their SAM encoding,
+// Phred scores offset by 33. If Qual is not called, the qualities of
+// the record are absent.
+func (r *RecordBuilder) Qual(qual string) *RecordBuilder {
+	r.qual = qual
+	return r
+}
+
+// Mate sets the mate reference, zero-based mate position and template
+// length of the record. A ref of "=" names the reference of the record.
+func (r *RecordBuilder) Mate(ref string, pos, tempLen int) *RecordBuilder {
+	r.mateRef, r.matePos, r.tempLen = ref, pos, tempLen
+	return r
+}
+
+// Tag adds an aux field with the given tag and value, of a type accepted
+// by sam.NewAux, to the record.
+func (r *RecordBuilder) Tag(tag string, value interface{}) *RecordBuilder {
+	if len(tag) != 2 {
+		r.b.setErr(fmt.Errorf("htstestutil: invalid tag %q", tag))
+		return r
+	}
+	aux, err := sam.NewAux(sam.NewTag(tag), value)
+	if err != nil {
+		r.b.setErr(err)
+		return r
+	}
+	r.aux = append(r.aux, aux)
+	return r
+}
+
+// Record adds another record to the BAMBuilder of r as BAMBuilder.Record
+// does.
+func (r *RecordBuilder) Record(name, ref string, pos int, cigar, seq string) *RecordBuilder {
+	return r.b.Record(name, ref, pos, cigar, seq)
+}
+
+// Build returns the BAM encoding of the BAMBuilder of r as
+// BAMBuilder.Build does.
+func (r *RecordBuilder) Build() ([]byte, error) {
+	return r.b.Build()
+}
+
+// BuildIndexed returns the BAM and BAI encodings of the BAMBuilder of r
+// as BAMBuilder.BuildIndexed does.
+func (r *RecordBuilder) BuildIndexed() (data, bai []byte, err error) {
+	return r.b.BuildIndexed()
+}
+
+// record returns the record described by r, resolving reference names
+// with refs.
+func (r *RecordBuilder) record(refs map[string]*sam.Reference) (*sam.Record, error) {
+	ref, err := lookupRef(r.ref, refs, nil)
+	if err != nil {
+		return nil, err
+	}
+	mateRef, err := lookupRef(r.mateRef, refs, ref)
+	if err != nil {
+		return nil, err
+	}
+	var cigar sam.Cigar
+	if r.cigar != "*" {
+		cigar, err = sam.ParseCigar([]byte(r.cigar))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var seq, qual []byte
+	if r.seq != "*" {
+		seq = []byte(r.seq)
+	}
+	if r.qual != "*" {
+		qual = make([]byte, len(r.qual))
+		for i := range qual {
+			qual[i] = r.qual[i] - 33
+		}
+	}
+	rec, err := sam.NewRecord(r.name, ref, mateRef, r.pos, r.matePos, r.tempLen, r.mapQ, cigar, seq, qual, r.aux)
+	if err != nil {
+		return nil, err
+	}
+	rec.Flags = r.flags
+	if len(cigar) != 0 && len(seq) != 0 && !cigar.IsValid(len(seq)) {
+		return nil, errors.New("htstestutil: sequence/CIGAR length mismatch")
+	}
+	return rec, nil
+}
+
+// lookupRef returns the reference named name in refs, nil for "*" or
+// self for "=".
+func lookupRef(name string, refs map[string]*sam.Reference, self *sam.Reference) (*sam.Reference, error) {
+	switch name {
+	case "*":
+		return nil, nil
+	case "=":
+		if self == nil {
+			return nil, errors.New("htstestutil: mate reference \"=\" of unplaced record")
+		}
+		return self, nil
+	}
+	ref, ok := refs[name]
+	if !ok {
+		return nil, fmt.Errorf("htstestutil: unknown reference %q", name)
+	}
+	return ref, nil
+}
@@ -0,0 +1,109 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htstestutil
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestBAMBuilder(t *testing.T) {
+	b := NewBAMBuilder().
+		Ref("chr1", 1000).
+		Ref("chr2", 500).
+		ReadGroup("rg0", "sample").
+		SortOrder(sam.Coordinate).
+		Record("p0", "chr1", 10, "4M", "ACGT").Qual("IIII").MapQ(60).Flags(sam.Paired|sam.Read1).Mate("=", 100, 94).Tag("RG", "rg0").
+		Record("r0", "chr1", 50, "2S2M", "ACGT").MapQ(20).Tag("NM", 0).
+		Record("p0", "chr1", 100, "4M", "TTTT").MapQ(60).Flags(sam.Paired|sam.Read2|sam.Reverse).Mate("chr1", 10, -94).
+		Record("r1", "chr2", 5, "4M", "GGGG").
+		Record("u0", "*", -1, "*", "ACGT").Flags(sam.Unmapped)
+	data, bai, err := b.BuildIndexed()
+	if err != nil {
+		t.Fatalf("unexpected error building BAM: %v", err)
+	}
+
+	br, err := bam.NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	h := br.Header()
+	if len(h.Refs()) != 2 || h.SortOrder != sam.Coordinate || len(h.RGs()) != 1 {
+		t.Errorf("unexpected header: refs:%v sort order:%v read groups:%v", h.Refs(), h.SortOrder, h.RGs())
+	}
+	var got []string
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		b, err := r.MarshalSAM(sam.FlagDecimal)
+		if err != nil {
+			t.Fatalf("unexpected error formatting record: %v", err)
+		}
+		got = append(got, string(b))
+	}
+	want := []string{
+		"p0\t65\tchr1\t11\t60\t4M\t=\t101\t94\tACGT\tIIII\tRG:Z:rg0",
+		"r0\t0\tchr1\t51\t20\t2S2M\t*\t0\t0\tACGT\t*\tNM:i:0",
+		"p0\t145\tchr1\t101\t60\t4M\t=\t11\t-94\tTTTT\t*",
+		"r1\t0\tchr2\t6\t0\t4M\t*\t0\t0\tGGGG\t*",
+		"u0\t4\t*\t0\t0\t*\t*\t0\t0\tACGT\t*",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of records: got:%d want:%d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unexpected record %d:\ngot: %q\nwant:%q", i, got[i], want[i])
+		}
+	}
+
+	idx, err := bam.ReadIndex(bytes.NewReader(bai))
+	if err != nil {
+		t.Fatalf("unexpected error reading index: %v", err)
+	}
+	chunks, err := idx.Chunks(h.Refs()[1], 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error getting chunks: %v", err)
+	}
+	it, err := bam.NewIterator(br, chunks)
+	if err != nil {
+		t.Fatalf("unexpected error creating iterator: %v", err)
+	}
+	var n int
+	for it.Next() {
+		if it.Record().Ref == h.Refs()[1] {
+			n++
+		}
+	}
+	if err = it.Close(); err != nil || n != 1 {
+		t.Errorf("unexpected indexed query: got:%d records with error %v", n, err)
+	}
+
+	// Headers may be made more than once.
+	_, err = b.Build()
+	if err != nil {
+		t.Errorf("unexpected error rebuilding BAM: %v", err)
+	}
+
+	for _, bad := range []*BAMBuilder{
+		NewBAMBuilder().Record("r", "chrX", 0, "4M", "ACGT").b,
+		NewBAMBuilder().Ref("chr1", 100).Record("r", "chr1", 0, "5M", "ACGT").b,
+		NewBAMBuilder().Ref("chr1", 100).Record("r", "chr1", 0, "4M", "ACGT").Tag("long", 1).b,
+	} {
+		_, err = bad.Build()
+		if err == nil {
+			t.Error("expected error for invalid record")
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htstestutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CheckDeterminism calls write runs times, each time with a new buffer,
+// and returns an error describing the first difference between the
+// bytes written by the first call and those written by any other call.
+// The run number, from zero, is passed to write so that callers may
+// vary settings that must not affect the output, such as write
+// concurrency or the order of aux fields. Any error returned by write
+// is returned.
+//
+// On success, CheckDeterminism returns the hex encoded SHA-256 digest
+// of the output, which may be compared with a digest recorded on
+// another architecture or by another run to check determinism between
+// environments.
+func CheckDeterminism(runs int, write func(w io.Writer, run int) error) (digest string, err error) {
+	if runs < 1 {
+		return "", errors.New("htstestutil: no determinism runs")
+	}
+	var want []byte
+	for run := 0; run < runs; run++ {
+		var buf bytes.Buffer
+		err := write(&buf, run)
+		if err != nil {
+			return "", fmt.Errorf("htstestutil: run %d: %w", run, err)
+		}
+		if run == 0 {
+			want = buf.Bytes()
+			continue
+		}
+		got := buf.Bytes()
+		if !bytes.Equal(got, want) {
+			return "", fmt.Errorf("htstestutil: run %d output differs from run 0 at byte %d: lengths %d and %d",
+				run, firstDifference(got, want), len(got), len(want))
+		}
+	}
+	sum := sha256.Sum256(want)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// firstDifference returns the offset of the first byte that differs
+// between a and b, or the length of the shorter if one is a prefix of
+// the other.
+func firstDifference(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,63 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htstestutil
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestCheckDeterminism(t *testing.T) {
+	g, err := NewGenerator(1, GeneratorOptions{
+		Tags:   []string{"NM", "AS", "RG", "MC", "BC", "XF", "XB"},
+		Paired: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating generator: %v", err)
+	}
+	recs := g.Records(500)
+
+	digest, err := CheckDeterminism(4, func(w io.Writer, run int) error {
+		bw, err := bam.NewDeterministicWriter(w, g.Header(), 6, run+1)
+		if err != nil {
+			return err
+		}
+		rnd := rand.New(rand.NewSource(int64(run)))
+		for _, r := range recs {
+			// Shuffle the aux fields of a copy
+			// of the record.
+			c := *r
+			c.AuxFields = append([]sam.Aux(nil), r.AuxFields...)
+			rnd.Shuffle(len(c.AuxFields), func(i, j int) {
+				c.AuxFields[i], c.AuxFields[j] = c.AuxFields[j], c.AuxFields[i]
+			})
+			err = bw.Write(&c)
+			if err != nil {
+				bw.Close()
+				return err
+			}
+		}
+		return bw.Close()
+	})
+	if err != nil {
+		t.Fatalf("unexpected determinism failure: %v", err)
+	}
+	if len(digest) != 64 {
+		t.Errorf("unexpected digest length: got:%d want:64", len(digest))
+	}
+
+	_, err = CheckDeterminism(2, func(w io.Writer, run int) error {
+		_, err := io.WriteString(w, strings.Repeat("a", 10+run))
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "at byte 10") {
+		t.Errorf("unexpected error for differing output: %v", err)
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htstestutil
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// GeneratorOptions specifies the records made by a Generator. Zero
+// values select the documented defaults.
+type GeneratorOptions struct {
+	// References and ReferenceLength are
+	// the number and length of the header
+	// references. They default to 3 and
+	// 1e6.
+	References      int
+	ReferenceLength int
+
+	// MinReadLength and MaxReadLength
+	// bound the length of read sequences.
+	// They default to 50 and 150.
+	MinReadLength int
+	MaxReadLength int
+
+	// MaxCigarOps is the maximum number of
+	// operations in the CIGAR of a mapped
+	// record. A value of 1 gives records
+	// with a single match operation. It
+	// defaults to 1.
+	MaxCigarOps int
+
+	// Tags is the set of aux tags given to
+	// each record, from NM, AS, RG, MC, BC,
+	// XF and XB. MC is only given to paired
+	// records with mapped mates. If Tags is
+	// nil, records have NM and AS tags.
+	Tags []string
+
+	// ReadGroups is the number of read
+	// groups in the header if the RG tag is
+	// given to records. It defaults to 2.
+	ReadGroups int
+
+	// Paired specifies that records are
+	// made in read pairs, with the first
+	// and second reads of a pair returned
+	// by consecutive calls to Next.
+	Paired bool
+
+	// Unmapped is the fraction of records,
+	// or of pairs if Paired is true, that
+	// are unmapped and unplaced.
+	Unmapped float64
+
+	// Invalid is the fraction of records
+	// that are deliberately made invalid.
+	Invalid float64
+}
+
+// Generator makes random records for property-based tests and
+// benchmark corpora. The records made by a Generator are determined by
+// its seed and options.
+type Generator struct {
+	rnd    *rand.Rand
+	opts   GeneratorOptions
+	h      *sam.Header
+	groups []string
+
+	n    int
+	mate *sam.Record
+}
+
+// generatorTags is the set of aux tags a Generator can give to
+// records.
+var generatorTags = map[string]bool{
+	"NM": true, "AS": true, "RG": true, "MC": true, "BC": true, "XF": true, "XB": true,
+}
+
+// NewGenerator returns a Generator using the given random seed and
+// options.
+func NewGenerator(seed int64, opts GeneratorOptions) (*Generator, error) {
+	if opts.References == 0 {
+		opts.References = 3
+	}
+	if opts.ReferenceLength == 0 {
+		opts.ReferenceLength = 1e6
+	}
+	if opts.MinReadLength == 0 {
+		opts.MinReadLength = 50
+	}
+	if opts.MaxReadLength == 0 {
+		opts.MaxReadLength = 150
+	}
+	if opts.MaxCigarOps == 0 {
+		opts.MaxCigarOps = 1
+	}
+	if opts.Tags == nil {
+		opts.Tags = []string{"NM", "AS"}
+	}
+	if opts.ReadGroups == 0 {
+		opts.ReadGroups = 2
+	}
+	switch {
+	case opts.References < 0:
+		return nil, fmt.Errorf("htstestutil: invalid number of references: %d", opts.References)
+	case opts.MinReadLength < 1 || opts.MaxReadLength < opts.MinReadLength:
+		return nil, fmt.Errorf("htstestutil: invalid read length range: [%d,%d]", opts.MinReadLength, opts.MaxReadLength)
+	case opts.ReferenceLength < 2*opts.MaxReadLength:
+		return nil, fmt.Errorf("htstestutil: reference length %d too short for reads of length %d", opts.ReferenceLength, opts.MaxReadLength)
+	case opts.MaxCigarOps < 1:
+		return nil, fmt.Errorf("htstestutil: invalid maximum CIGAR operations: %d", opts.MaxCigarOps)
+	}
+	var hasRG bool
+	for _, t := range opts.Tags {
+		if !generatorTags[t] {
+			return nil, fmt.Errorf("htstestutil: unsupported tag: %q", t)
+		}
+		hasRG = hasRG || t == "RG"
+	}
+
+	refs := make([]*sam.Reference, opts.References)
+	for i := range refs {
+		var err error
+		refs[i], err = sam.NewReference(fmt.Sprintf("chr%d", i+1), "", "", opts.ReferenceLength, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		return nil, err
+	}
+	g := &Generator{rnd: rand.New(rand.NewSource(seed)), opts: opts, h: h}
+	if hasRG {
+		for i := 0; i < opts.ReadGroups; i++ {
+			name := fmt.Sprintf("group%d", i)
+			rg, err := sam.NewReadGroup(name, "", "", "lib", "", "ILLUMINA", "", "sample", "", "", time.Time{}, 0)
+			if err != nil {
+				return nil, err
+			}
+			err = h.AddReadGroup(rg)
+			if err != nil {
+				return nil, err
+			}
+			g.groups = append(g.groups, name)
+		}
+	}
+	return g, nil
+}
+
+// Header returns the header holding the references of generated
+// records.
+func (g *Generator) Header() *sam.Header { return g.h }
+
+// Next returns a new record and whether it is valid. Invalid records
+// have a sequence and quality length mismatch, a CIGAR inconsistent
+// with their sequence or a position beyond the end of their reference.
+func (g *Generator) Next() (rec *sam.Record, valid bool) {
+	if g.mate != nil {
+		rec, g.mate = g.mate, nil
+	} else {
+		rec = g.record()
+	}
+	if g.rnd.Float64() < g.opts.Invalid {
+		g.invalidate(rec)
+		return rec, false
+	}
+	return rec, true
+}
+
+// Records returns n records, all of which are valid if the Invalid
+// option is zero.
+func (g *Generator) Records(n int) []*sam.Record {
+	recs := make([]*sam.Record, n)
+	for i := range recs {
+		recs[i], _ = g.Next()
+	}
+	return recs
+}
+
+// record returns a new unpaired record, or the first read of a new
+// pair, holding the second read in g.mate.
+func (g *Generator) record() *sam.Record {
+	name := fmt.Sprintf("read%d", g.n)
+	g.n++
+
+	mapped := len(g.h.Refs()) != 0 && g.rnd.Float64() >= g.opts.Unmapped
+	r := g.read(name, mapped)
+	if !g.opts.Paired {
+		g.addTags(r, nil)
+		return r
+	}
+
+	m := g.read(name, mapped)
+	r.Flags |= sam.Paired | sam.Read1
+	m.Flags |= sam.Paired | sam.Read2
+	if mapped {
+		// Place the mate downstream of the
+		// first read on the opposite strand.
+		m.Ref = r.Ref
+		insert := r.End() - r.Pos + g.rnd.Intn(300)
+		if m.Pos = r.Pos + insert - (m.End() - m.Pos); m.Pos < r.Pos {
+			m.Pos = r.Pos
+		}
+		if m.End() > r.Ref.Len() {
+			m.Pos = r.Ref.Len() - (m.End() - m.Pos)
+		}
+		r.Flags &^= sam.Reverse
+		m.Flags |= sam.Reverse
+		r.Flags |= sam.ProperPair | sam.MateReverse
+		m.Flags |= sam.ProperPair
+		r.MateRef, r.MatePos = m.Ref, m.Pos
+		m.MateRef, m.MatePos = r.Ref, r.Pos
+		r.TempLen = m.End() - r.Pos
+		m.TempLen = -r.TempLen
+	} else {
+		r.Flags |= sam.MateUnmapped
+		m.Flags |= sam.MateUnmapped
+	}
+	g.addTags(r, m)
+	g.addTags(m, r)
+	g.mate = m
+	return r
+}
+
+// read returns a new record with the given name, mapped to a random
+// position if mapped is true.
+func (g *Generator) read(name string, mapped bool) *sam.Record {
+	n := g.opts.MinReadLength + g.rnd.Intn(g.opts.MaxReadLength-g.opts.MinReadLength+1)
+	seq := make([]byte, n)
+	qual := make([]byte, n)
+	for i := range seq {
+		seq[i] = "ACGT"[g.rnd.Intn(4)]
+		if g.rnd.Intn(100) == 0 {
+			seq[i] = 'N'
+		}
+		qual[i] = byte(2 + g.rnd.Intn(39))
+	}
+	r := &sam.Record{
+		Name:    name,
+		Pos:     -1,
+		MatePos: -1,
+		Seq:     sam.NewSeq(seq),
+		Qual:    qual,
+	}
+	if !mapped {
+		r.Flags = sam.Unmapped
+		return r
+	}
+	refs := g.h.Refs()
+	r.Ref = refs[g.rnd.Intn(len(refs))]
+	r.Cigar = g.cigar(n)
+	r.MapQ = byte(g.rnd.Intn(61))
+	if g.rnd.Intn(2) == 0 {
+		r.Flags = sam.Reverse
+	}
+	// Leave space downstream for a mate.
+	limit := r.Ref.Len() - (r.End() - r.Pos) - g.opts.MaxReadLength
+	if limit < 1 {
+		limit = 1
+	}
+	r.Pos = g.rnd.Intn(limit)
+	return r
+}
+
+// cigar returns a random CIGAR with at most the maximum number of
+// operations consuming n query bases.
+func (g *Generator) cigar(n int) sam.Cigar {
+	maxOps := g.opts.MaxCigarOps
+	var lead, trail int
+	if maxOps >= 3 && n >= 4 && g.rnd.Intn(4) == 0 {
+		lead = 1 + g.rnd.Intn(n/4)
+		maxOps--
+	}
+	if maxOps >= 3 && n-lead >= 4 && g.rnd.Intn(4) == 0 {
+		trail = 1 + g.rnd.Intn((n-lead)/4)
+		maxOps--
+	}
+	body := n - lead - trail
+
+	// The body alternates match operations
+	// with gaps of insertions, deletions or
+	// reference skips.
+	gaps := g.rnd.Intn((maxOps-1)/2 + 1)
+	gapOps := make([]sam.CigarOpType, gaps)
+	pieces := gaps + 1
+	for i := range gapOps {
+		gapOps[i] = []sam.CigarOpType{sam.CigarInsertion, sam.CigarDeletion, sam.CigarSkipped}[g.rnd.Intn(3)]
+		if gapOps[i] == sam.CigarInsertion {
+			pieces++
+		}
+	}
+	for pieces > body {
+		// Drop gaps until there are enough
+		// query bases for every piece.
+		if gapOps[len(gapOps)-1] == sam.CigarInsertion {
+			pieces--
+		}
+		gapOps = gapOps[:len(gapOps)-1]
+		pieces--
+	}
+	lengths := g.split(body, pieces)
+
+	var c sam.Cigar
+	if lead != 0 {
+		c = append(c, sam.NewCigarOp(sam.CigarSoftClipped, lead))
+	}
+	c = append(c, sam.NewCigarOp(sam.CigarMatch, lengths[0]))
+	lengths = lengths[1:]
+	for _, t := range gapOps {
+		switch t {
+		case sam.CigarInsertion:
+			c = append(c, sam.NewCigarOp(t, lengths[0]))
+			lengths = lengths[1:]
+		case sam.CigarDeletion:
+			c = append(c, sam.NewCigarOp(t, 1+g.rnd.Intn(min(10, g.maxGap()))))
+		case sam.CigarSkipped:
+			c = append(c, sam.NewCigarOp(t, 1+g.rnd.Intn(min(1000, g.maxGap()))))
+		}
+		c = append(c, sam.NewCigarOp(sam.CigarMatch, lengths[0]))
+		lengths = lengths[1:]
+	}
+	if trail != 0 {
+		c = append(c, sam.NewCigarOp(sam.CigarSoftClipped, trail))
+	}
+	return c
+}
+
+// maxGap returns the maximum length of a deletion or reference skip,
+// limited so that the reference span of a record is at most half the
+// reference length.
+func (g *Generator) maxGap() int {
+	return max(1, (g.opts.ReferenceLength/2-g.opts.MaxReadLength)/g.opts.MaxCigarOps)
+}
+
+// split returns k random positive lengths summing to n, which must be
+// at least k.
+func (g *Generator) split(n, k int) []int {
+	lengths := make([]int, k)
+	for i := range lengths {
+		lengths[i] = 1
+	}
+	for i := 0; i < n-k; i++ {
+		lengths[g.rnd.Intn(k)]++
+	}
+	return lengths
+}
+
+// addTags adds the aux tags of the options to r, which has the given
+// mate if it is paired.
+func (g *Generator) addTags(r, mate *sam.Record) {
+	for _, t := range g.opts.Tags {
+		var v interface{}
+		switch t {
+		case "NM":
+			nm := g.rnd.Intn(4)
+			for _, co := range r.Cigar {
+				switch co.Type() {
+				case sam.CigarInsertion, sam.CigarDeletion:
+					nm += co.Len()
+				}
+			}
+			v = nm
+		case "AS":
+			v = g.rnd.Intn(r.Seq.Length + 1)
+		case "RG":
+			v = g.groups[g.n%len(g.groups)]
+		case "MC":
+			if mate == nil || mate.Flags&sam.Unmapped != 0 {
+				continue
+			}
+			v = mate.Cigar.String()
+		case "BC":
+			var b strings.Builder
+			for i := 0; i < 8; i++ {
+				b.WriteByte("ACGT"[g.rnd.Intn(4)])
+			}
+			v = b.String()
+		case "XF":
+			v = g.rnd.Float32()
+		case "XB":
+			a := make([]int16, g.rnd.Intn(5))
+			for i := range a {
+				a[i] = int16(g.rnd.Intn(1 << 16))
+			}
+			v = a
+		}
+		aux, err := sam.NewAux(sam.NewTag(t), v)
+		if err != nil {
+			panic(fmt.Sprintf("htstestutil: failed to make %s tag: %v", t, err))
+		}
+		r.AuxFields = append(r.AuxFields, aux)
+	}
+}
+
+// invalidate makes r invalid.
+func (g *Generator) invalidate(r *sam.Record) {
+	switch k := g.rnd.Intn(3); {
+	case k == 0 || (k == 1 && len(r.Cigar) == 0) || (k == 2 && r.Ref == nil):
+		r.Qual = append(r.Qual, 30)
+	case k == 1:
+		r.Cigar = append(r.Cigar, sam.NewCigarOp(sam.CigarInsertion, 1))
+	default:
+		r.Pos = r.Ref.Len() + g.rnd.Intn(1000)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,83 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package htstestutil
+
+import (
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestGenerator(t *testing.T) {
+	for _, opts := range []GeneratorOptions{
+		{},
+		{MaxCigarOps: 9, Tags: []string{"NM", "AS", "RG", "MC", "BC", "XF", "XB"}, Paired: true, Unmapped: 0.1},
+		{MinReadLength: 1, MaxReadLength: 5, MaxCigarOps: 7, References: 1, ReferenceLength: 100},
+		{Paired: true, Invalid: 0.2, MaxCigarOps: 5},
+	} {
+		g, err := NewGenerator(1, opts)
+		if err != nil {
+			t.Fatalf("unexpected error creating generator for %+v: %v", opts, err)
+		}
+		again, err := NewGenerator(1, opts)
+		if err != nil {
+			t.Fatalf("unexpected error creating generator for %+v: %v", opts, err)
+		}
+		var invalid int
+		for i := 0; i < 1000; i++ {
+			r, valid := g.Next()
+			// Records from distinct generators refer to distinct
+			// headers, so are compared by their text.
+			if other, _ := again.Next(); r.String() != other.String() {
+				t.Fatalf("unexpected nondeterminism for %+v: %v != %v", opts, r, other)
+			}
+			if !valid {
+				invalid++
+				if isValid(r) {
+					t.Errorf("unexpected valid record reported invalid for %+v: %v", opts, r)
+				}
+				continue
+			}
+			if !isValid(r) {
+				t.Errorf("unexpected invalid record for %+v: %v", opts, r)
+			}
+			if len(r.Cigar) > g.opts.MaxCigarOps {
+				t.Errorf("unexpected number of CIGAR operations for %+v: %v", opts, r.Cigar)
+			}
+			if opts.Paired != (r.Flags&sam.Paired != 0) {
+				t.Errorf("unexpected pairing for %+v: %v", opts, r.Flags)
+			}
+			if opts.Paired && (r.Flags&sam.Read1 != 0) != (i%2 == 0) {
+				t.Errorf("unexpected read order for %+v at %d: %v", opts, i, r.Flags)
+			}
+			for _, tag := range g.opts.Tags {
+				if tag == "MC" && r.Flags&sam.MateUnmapped != 0 {
+					continue
+				}
+				if r.AuxFields.Get(sam.NewTag(tag)) == nil {
+					t.Errorf("missing %s tag for %+v: %v", tag, opts, r)
+				}
+			}
+		}
+		if (invalid != 0) != (opts.Invalid != 0) {
+			t.Errorf("unexpected number of invalid records for %+v: %d", opts, invalid)
+		}
+	}
+
+	_, err := NewGenerator(1, GeneratorOptions{Tags: []string{"ZZ"}})
+	if err == nil {
+		t.Error("expected error for unsupported tag")
+	}
+}
+
+func isValid(r *sam.Record) bool {
+	if len(r.Qual) != r.Seq.Length {
+		return false
+	}
+	if r.Ref == nil {
+		return r.Pos == -1 && len(r.Cigar) == 0
+	}
+	return r.Cigar.IsValid(r.Seq.Length) && 0 <= r.Pos && r.End() <= r.Ref.Len()
+}
@@ -0,0 +1,79 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import "github.com/Schaudge/hts/bgzf/index"
+
+func (i *Index) countBin(rid int, bin uint32) {
+	if rid >= len(i.BinCounts) {
+		counts := make([]map[uint32]uint64, rid+1)
+		copy(counts, i.BinCounts)
+		i.BinCounts = counts
+	}
+	if i.BinCounts[rid] == nil {
+		i.BinCounts[rid] = make(map[uint32]uint64)
+	}
+	i.BinCounts[rid][bin]++
+}
+
+// EstimateRecordCount returns an estimate of the number of records
+// overlapping the interval [beg,end) on the reference with the given
+// ID. The estimate assumes that the records in each bin are uniformly
+// distributed over the span of the bin. Bin counts must have been
+// recorded during index construction.
+func (i *Index) EstimateRecordCount(rid, beg, end int) (uint64, error) {
+	if rid < 0 || rid >= len(i.Refs) {
+		return 0, index.ErrNoReference
+	}
+	if !i.CountBins {
+		return 0, index.ErrNoBinCounts
+	}
+	if end <= beg {
+		return 0, index.ErrInvalid
+	}
+	if rid >= len(i.BinCounts) || i.BinCounts[rid] == nil {
+		return 0, nil
+	}
+	counts := i.BinCounts[rid]
+	var n float64
+	for _, b := range OverlappingBinsFor(beg, end) {
+		c, ok := counts[b]
+		if !ok {
+			continue
+		}
+		bBeg, bEnd := binSpan(b)
+		oBeg, oEnd := beg, end
+		if bBeg > oBeg {
+			oBeg = bBeg
+		}
+		if bEnd < oEnd {
+			oEnd = bEnd
+		}
+		if oEnd <= oBeg {
+			continue
+		}
+		n += float64(c) * float64(oEnd-oBeg) / float64(bEnd-bBeg)
+	}
+	return uint64(n + 0.5), nil
+}
+
+// binSpan returns the interval [beg,end) covered by the given bin.
+func binSpan(bin uint32) (beg, end int) {
+	for _, l := range []struct {
+		offset, shift uint32
+	}{
+		{level5, level5Shift},
+		{level4, level4Shift},
+		{level3, level3Shift},
+		{level2, level2Shift},
+		{level1, level1Shift},
+	} {
+		if bin >= l.offset {
+			beg = int(bin-l.offset) << l.shift
+			return beg, beg + 1<<l.shift
+		}
+	}
+	return 0, 1 << level0Shift
+}
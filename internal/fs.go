@@ -0,0 +1,45 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+)
+
+// ReadSeeker returns an io.Reader reading f. The returned io.Reader
+// implements io.Seeker if f implements io.Seeker, or implements
+// io.ReaderAt and reports its size.
+func ReadSeeker(f fs.File) io.Reader {
+	if rs, ok := f.(io.ReadSeeker); ok {
+		return rs
+	}
+	if ra, ok := f.(io.ReaderAt); ok {
+		fi, err := f.Stat()
+		if err == nil {
+			return io.NewSectionReader(ra, 0, fi.Size())
+		}
+	}
+	return f
+}
+
+// ReaderAt returns an io.ReaderAt reading f and the size of f. If f
+// does not implement io.ReaderAt, the contents of f are read into
+// memory.
+func ReaderAt(f fs.File) (io.ReaderAt, int64, error) {
+	if ra, ok := f.(io.ReaderAt); ok {
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		return ra, fi.Size(), nil
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(b), int64(len(b)), nil
+}
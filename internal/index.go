@@ -7,6 +7,7 @@ package internal
 
 import (
 	"errors"
+	"math/bits"
 	"sort"
 
 	"github.com/Schaudge/hts/bgzf"
@@ -36,6 +37,16 @@ type Index struct {
 	Unmapped   *uint64
 	IsSorted   bool
 	LastRecord int
+
+	// CountBins specifies whether Add records
+	// per-bin record counts in BinCounts.
+	CountBins bool
+
+	// BinCounts holds the number of placed records
+	// in each bin of each reference. It is only
+	// populated when CountBins is true and is not
+	// stored in the serialised index.
+	BinCounts []map[uint32]uint64
 }
 
 // RefIndex is the index of a single reference.
@@ -119,6 +130,9 @@ func (i *Index) Add(r Record, bin uint32, c bgzf.Chunk, placed, mapped bool) err
 		Chunks: []bgzf.Chunk{c},
 	})
 found:
+	if i.CountBins {
+		i.countBin(rid, bin)
+	}
 
 	// Record interval tile information.
 	biv := r.Start() / TileWidth
@@ -128,9 +142,8 @@ found:
 	i.LastRecord = r.Start()
 	eiv := r.End() / TileWidth
 	if eiv == len(ref.Intervals) {
-		if eiv > biv {
-			panic("index: unexpected alignment length")
-		}
+		// Tiles before eiv are already covered by earlier
+		// records, so only the final tile needs an offset.
 		ref.Intervals = append(ref.Intervals, c.Begin)
 	} else if eiv > len(ref.Intervals) {
 		intvs := make([]bgzf.Offset, eiv)
@@ -278,23 +291,53 @@ const (
 	level5Shift
 )
 
+// binLevel holds the index level of intervals whose first and last
+// positions first differ at bit n-1, indexed by n.
+var binLevel = func() (t [65]uint8) {
+	shifts := [...]int{level0Shift, level1Shift, level2Shift, level3Shift, level4Shift, level5Shift}
+	for n := range t {
+		for l := len(shifts) - 1; l >= 0; l-- {
+			if n <= shifts[l] {
+				t[n] = uint8(l)
+				break
+			}
+		}
+	}
+	return t
+}()
+
+// binOffset and binShift hold the first bin and the position shift of
+// each index level. The level 0 shift clears all position bits.
+var (
+	binOffset = [...]uint32{level0, level1, level2, level3, level4, level5}
+	binShift  = [...]uint{64, level1Shift, level2Shift, level3Shift, level4Shift, level5Shift}
+)
+
 // BinFor returns the bin number for given an interval covering
 // [beg,end) (zero-based, half-close-half-open).
 func BinFor(beg, end int) uint32 {
 	end--
-	switch {
-	case beg>>level5Shift == end>>level5Shift:
-		return level5 + uint32(beg>>level5Shift)
-	case beg>>level4Shift == end>>level4Shift:
-		return level4 + uint32(beg>>level4Shift)
-	case beg>>level3Shift == end>>level3Shift:
-		return level3 + uint32(beg>>level3Shift)
-	case beg>>level2Shift == end>>level2Shift:
-		return level2 + uint32(beg>>level2Shift)
-	case beg>>level1Shift == end>>level1Shift:
-		return level1 + uint32(beg>>level1Shift)
+	l := binLevel[bits.Len64(uint64(beg^end))]
+	return binOffset[l] + uint32(uint64(beg)>>binShift[l])
+}
+
+// BinForDepth returns the bin number for an interval covering [beg,end)
+// (zero-based, half-close-half-open) in a CSI index with the given
+// minimum shift and depth.
+func BinForDepth(beg, end int64, minShift, depth uint32) uint32 {
+	end--
+	// k is the number of levels above the
+	// deepest that the interval must rise
+	// to fit in a single bin.
+	var k uint32
+	if n := uint32(bits.Len64(uint64(beg ^ end))); n > minShift {
+		k = (n - minShift + nextBinShift - 1) / nextBinShift
+	}
+	if k >= depth {
+		return 0
 	}
-	return level0
+	level := depth - k
+	return uint32(((1<<(level*nextBinShift))-1)/7) + uint32(beg>>(minShift+k*nextBinShift))
 }
 
 // OverlappingBinsFor returns the bin numbers for all bins overlapping
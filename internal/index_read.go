@@ -43,18 +43,30 @@ func ReadIndex(r io.Reader, n int32, typ string) (Index, error) {
 	return idx, nil
 }
 
+// bootstrapSize is the maximum number of elements pre-allocated for
+// counts read from an index, so that corrupt counts fail on reading
+// rather than on allocation.
+const bootstrapSize = 1 << 10
+
 func readIndices(r io.Reader, n int32, typ string) ([]RefIndex, error) {
-	var err error
-	idx := make([]RefIndex, n)
-	for i := range idx {
-		idx[i].Bins, idx[i].Stats, err = readBins(r, typ)
+	if n < 0 {
+		return nil, fmt.Errorf("%s: invalid reference count: %d", typ, n)
+	}
+	idx := make([]RefIndex, 0, min(int(n), bootstrapSize))
+	for i := 0; i < int(n); i++ {
+		var (
+			ref RefIndex
+			err error
+		)
+		ref.Bins, ref.Stats, err = readBins(r, typ)
 		if err != nil {
 			return nil, err
 		}
-		idx[i].Intervals, err = readIntervals(r, typ)
+		ref.Intervals, err = readIntervals(r, typ)
 		if err != nil {
 			return nil, err
 		}
+		idx = append(idx, ref)
 	}
 	return idx, nil
 }
@@ -68,33 +80,39 @@ func readBins(r io.Reader, typ string) ([]Bin, *ReferenceStats, error) {
 	if n == 0 {
 		return nil, nil, nil
 	}
+	if n < 0 {
+		return nil, nil, fmt.Errorf("%s: invalid bin count: %d", typ, n)
+	}
 	var stats *ReferenceStats
-	bins := make([]Bin, n)
-	for i := 0; i < len(bins); i++ {
-		err = binary.Read(r, binary.LittleEndian, &bins[i].Bin)
+	bins := make([]Bin, 0, min(int(n), bootstrapSize))
+	for i := 0; i < int(n); i++ {
+		var (
+			bin     Bin
+			nChunks int32
+		)
+		err = binary.Read(r, binary.LittleEndian, &bin.Bin)
 		if err != nil {
 			return nil, nil, fmt.Errorf("%s: failed to read bin number: %v", typ, err)
 		}
-		err = binary.Read(r, binary.LittleEndian, &n)
+		err = binary.Read(r, binary.LittleEndian, &nChunks)
 		if err != nil {
 			return nil, nil, fmt.Errorf("%s: failed to read bin count: %v", typ, err)
 		}
-		if bins[i].Bin == StatsDummyBin {
-			if n != 2 {
+		if bin.Bin == StatsDummyBin {
+			if nChunks != 2 {
 				return nil, nil, fmt.Errorf("%s: malformed dummy bin header", typ)
 			}
 			stats, err = readStats(r, typ)
 			if err != nil {
 				return nil, nil, err
 			}
-			bins = bins[:len(bins)-1]
-			i--
 			continue
 		}
-		bins[i].Chunks, err = readChunks(r, n, typ)
+		bin.Chunks, err = readChunks(r, nChunks, typ)
 		if err != nil {
 			return nil, nil, err
 		}
+		bins = append(bins, bin)
 	}
 	if !sort.IsSorted(byBinNumber(bins)) {
 		sort.Sort(byBinNumber(bins))
@@ -106,16 +124,21 @@ func readChunks(r io.Reader, n int32, typ string) ([]bgzf.Chunk, error) {
 	if n == 0 {
 		return nil, nil
 	}
-	chunks := make([]bgzf.Chunk, n)
+	if n < 0 {
+		return nil, fmt.Errorf("%s: invalid chunk count: %d", typ, n)
+	}
+	chunks := make([]bgzf.Chunk, 0, min(int(n), bootstrapSize))
 	var buf [16]byte
-	for i := range chunks {
+	for i := 0; i < int(n); i++ {
 		// Get the begin and end offset in a single read.
 		_, err := io.ReadFull(r, buf[:])
 		if err != nil {
 			return nil, fmt.Errorf("%s: failed to read chunk virtual offset: %v", typ, err)
 		}
-		chunks[i].Begin = makeOffset(binary.LittleEndian.Uint64(buf[:8]))
-		chunks[i].End = makeOffset(binary.LittleEndian.Uint64(buf[8:]))
+		chunks = append(chunks, bgzf.Chunk{
+			Begin: makeOffset(binary.LittleEndian.Uint64(buf[:8])),
+			End:   makeOffset(binary.LittleEndian.Uint64(buf[8:])),
+		})
 	}
 	if !sort.IsSorted(byBeginOffset(chunks)) {
 		sort.Sort(byBeginOffset(chunks))
@@ -159,7 +182,10 @@ func readIntervals(r io.Reader, typ string) ([]bgzf.Offset, error) {
 	if n == 0 {
 		return nil, nil
 	}
-	offsets := make([]bgzf.Offset, n)
+	if n < 0 {
+		return nil, fmt.Errorf("%s: invalid interval count: %d", typ, n)
+	}
+	offsets := make([]bgzf.Offset, 0, min(int(n), bootstrapSize))
 	// chunkSize determines the number of offsets consumed by each binary.Read.
 	const chunkSize = 512
 	var vOffs [chunkSize]uint64
@@ -170,7 +196,7 @@ func readIntervals(r io.Reader, typ string) ([]bgzf.Offset, error) {
 			return nil, fmt.Errorf("%s: failed to read tile interval virtual offset: %v", typ, err)
 		}
 		for k := 0; k < l; k++ {
-			offsets[i+k] = makeOffset(vOffs[k])
+			offsets = append(offsets, makeOffset(vOffs[k]))
 		}
 	}
 
@@ -0,0 +1,143 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// binForSwitch is the direct comparison implementation of BinFor.
+func binForSwitch(beg, end int) uint32 {
+	end--
+	switch {
+	case beg>>level5Shift == end>>level5Shift:
+		return level5 + uint32(beg>>level5Shift)
+	case beg>>level4Shift == end>>level4Shift:
+		return level4 + uint32(beg>>level4Shift)
+	case beg>>level3Shift == end>>level3Shift:
+		return level3 + uint32(beg>>level3Shift)
+	case beg>>level2Shift == end>>level2Shift:
+		return level2 + uint32(beg>>level2Shift)
+	case beg>>level1Shift == end>>level1Shift:
+		return level1 + uint32(beg>>level1Shift)
+	}
+	return level0
+}
+
+// binForLoop is the iterative implementation of BinForDepth, following
+// hts_reg2bin in htslib.
+func binForLoop(beg, end int64, minShift, depth uint32) uint32 {
+	end--
+	s := minShift
+	t := uint32(((1 << (depth * nextBinShift)) - 1) / 7)
+	for level := depth; level > 0; level-- {
+		offset := beg >> s
+		if offset == end>>s {
+			return t + uint32(offset)
+		}
+		s += nextBinShift
+		t -= 1 << ((level - 1) * nextBinShift)
+	}
+	return 0
+}
+
+// intervals returns n random intervals of varied length within
+// [0,limit), and edge case intervals.
+func intervals(n int, limit int64) [][2]int64 {
+	rnd := rand.New(rand.NewSource(1))
+	iv := [][2]int64{
+		{-1, 0},
+		{0, 1},
+		{0, limit},
+		{limit - 1, limit},
+		{TileWidth - 1, TileWidth + 1},
+	}
+	for i := 0; i < n; i++ {
+		beg := rnd.Int63n(limit)
+		end := beg + 1 + rnd.Int63n(int64(1)<<uint(rnd.Intn(30)))
+		if end > limit {
+			end = limit
+		}
+		iv = append(iv, [2]int64{beg, end})
+	}
+	return iv
+}
+
+func TestBinFor(t *testing.T) {
+	for _, iv := range intervals(1e5, 1<<indexWordBits) {
+		beg, end := int(iv[0]), int(iv[1])
+		got := BinFor(beg, end)
+		want := binForSwitch(beg, end)
+		if got != want {
+			t.Errorf("unexpected bin for [%d,%d): got:%d want:%d", beg, end, got, want)
+		}
+	}
+}
+
+func TestBinForDepth(t *testing.T) {
+	for _, p := range []struct{ minShift, depth uint32 }{
+		{14, 5},
+		{14, 6},
+		{12, 7},
+		{16, 3},
+	} {
+		for _, iv := range intervals(1e4, 1<<(p.minShift+p.depth*nextBinShift)) {
+			got := BinForDepth(iv[0], iv[1], p.minShift, p.depth)
+			want := binForLoop(iv[0], iv[1], p.minShift, p.depth)
+			if got != want {
+				t.Errorf("unexpected bin for [%d,%d) with min shift %d and depth %d: got:%d want:%d",
+					iv[0], iv[1], p.minShift, p.depth, got, want)
+			}
+		}
+		if p.minShift == 14 && p.depth == 5 {
+			for _, iv := range intervals(1e3, 1<<indexWordBits) {
+				got := BinForDepth(iv[0], iv[1], p.minShift, p.depth)
+				want := BinFor(int(iv[0]), int(iv[1]))
+				if got != want {
+					t.Errorf("unexpected BAI equivalent bin for [%d,%d): got:%d want:%d", iv[0], iv[1], got, want)
+				}
+			}
+		}
+	}
+}
+
+var sink uint32
+
+func BenchmarkBinFor(b *testing.B) {
+	iv := intervals(1<<12, 1<<indexWordBits)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := iv[i&(1<<12-1)]
+		sink += BinFor(int(v[0]), int(v[1]))
+	}
+}
+
+func BenchmarkBinForSwitch(b *testing.B) {
+	iv := intervals(1<<12, 1<<indexWordBits)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := iv[i&(1<<12-1)]
+		sink += binForSwitch(int(v[0]), int(v[1]))
+	}
+}
+
+func BenchmarkBinForDepth(b *testing.B) {
+	iv := intervals(1<<12, 1<<indexWordBits)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := iv[i&(1<<12-1)]
+		sink += BinForDepth(v[0], v[1], 14, 5)
+	}
+}
+
+func BenchmarkBinForLoop(b *testing.B) {
+	iv := intervals(1<<12, 1<<indexWordBits)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := iv[i&(1<<12-1)]
+		sink += binForLoop(v[0], v[1], 14, 5)
+	}
+}
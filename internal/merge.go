@@ -0,0 +1,92 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"errors"
+
+	"github.com/Schaudge/hts/bgzf"
+)
+
+// Merge returns an index for the concatenation of the BGZF files
+// indexed by idxs. The compressed file offsets held by idxs[i] are
+// shifted by offsets[i], the position of the i-th file's data in the
+// concatenated file. Files must be provided in concatenation order and
+// records in the concatenated file must be coordinate sorted.
+func Merge(idxs []*Index, offsets []int64) (Index, error) {
+	if len(idxs) != len(offsets) {
+		return Index{}, errors.New("index: mismatched index and offset counts")
+	}
+	var (
+		m   Index
+		pos []map[uint32]int
+	)
+	for k, idx := range idxs {
+		if k != 0 && offsets[k] < offsets[k-1] {
+			return Index{}, errors.New("index: offsets out of order")
+		}
+		if idx == nil {
+			continue
+		}
+		shift := offsets[k]
+		if idx.Unmapped != nil {
+			if m.Unmapped == nil {
+				m.Unmapped = new(uint64)
+			}
+			*m.Unmapped += *idx.Unmapped
+		}
+		if len(idx.Refs) > len(m.Refs) {
+			refs := make([]RefIndex, len(idx.Refs))
+			copy(refs, m.Refs)
+			m.Refs = refs
+			for len(pos) < len(refs) {
+				pos = append(pos, make(map[uint32]int))
+			}
+		}
+		for rid, src := range idx.Refs {
+			dst := &m.Refs[rid]
+			for _, b := range src.Bins {
+				chunks := make([]bgzf.Chunk, len(b.Chunks))
+				for j, c := range b.Chunks {
+					chunks[j] = shiftChunk(c, shift)
+				}
+				if i, ok := pos[rid][b.Bin]; ok {
+					dst.Bins[i].Chunks = append(dst.Bins[i].Chunks, chunks...)
+				} else {
+					pos[rid][b.Bin] = len(dst.Bins)
+					dst.Bins = append(dst.Bins, Bin{Bin: b.Bin, Chunks: chunks})
+				}
+			}
+			for j, o := range src.Intervals {
+				if j == len(dst.Intervals) {
+					dst.Intervals = append(dst.Intervals, bgzf.Offset{})
+				}
+				if isZero(dst.Intervals[j]) && !isZero(o) {
+					dst.Intervals[j] = shiftOffset(o, shift)
+				}
+			}
+			if src.Stats != nil {
+				if dst.Stats == nil {
+					dst.Stats = &ReferenceStats{Chunk: shiftChunk(src.Stats.Chunk, shift)}
+				} else {
+					dst.Stats.Chunk.End = shiftOffset(src.Stats.Chunk.End, shift)
+				}
+				dst.Stats.Mapped += src.Stats.Mapped
+				dst.Stats.Unmapped += src.Stats.Unmapped
+			}
+		}
+	}
+	m.sort()
+	return m, nil
+}
+
+func shiftOffset(o bgzf.Offset, shift int64) bgzf.Offset {
+	o.File += shift
+	return o
+}
+
+func shiftChunk(c bgzf.Chunk, shift int64) bgzf.Chunk {
+	return bgzf.Chunk{Begin: shiftOffset(c.Begin, shift), End: shiftOffset(c.End, shift)}
+}
@@ -0,0 +1,91 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package samutil provides helpers shared by packages that modify
+// alignment records.
+package samutil
+
+import "github.com/Schaudge/hts/sam"
+
+// SetAux sets the aux field with tag t of r to v, replacing any
+// existing field. Raw aux data held by r is decoded first.
+func SetAux(r *sam.Record, t sam.Tag, v interface{}) error {
+	a, err := sam.NewAux(t, v)
+	if err != nil {
+		return err
+	}
+	return PutAux(r, a)
+}
+
+// PutAux sets the aux field of r with the tag of a to a, replacing any
+// existing field. Raw aux data held by r is decoded first.
+func PutAux(r *sam.Record, a sam.Aux) error {
+	err := r.DecodeAux()
+	if err != nil {
+		return err
+	}
+	t := a.Tag()
+	for i, f := range r.AuxFields {
+		if f.Tag() == t {
+			r.AuxFields[i] = a
+			return nil
+		}
+	}
+	r.AuxFields = append(r.AuxFields, a)
+	return nil
+}
+
+// DelAux removes the aux fields with any of the given tags from r. Raw
+// aux data held by r is decoded first.
+func DelAux(r *sam.Record, tags ...sam.Tag) error {
+	err := r.DecodeAux()
+	if err != nil {
+		return err
+	}
+	aux := r.AuxFields[:0]
+outer:
+	for _, f := range r.AuxFields {
+		for _, t := range tags {
+			if f.Tag() == t {
+				continue outer
+			}
+		}
+		aux = append(aux, f)
+	}
+	r.AuxFields = aux
+	return nil
+}
+
+// complement holds the complements of IUPAC nucleotide codes. U is
+// complemented to A. Other bytes are their own complement.
+var complement = func() [256]byte {
+	var c [256]byte
+	for i := range c {
+		c[i] = byte(i)
+	}
+	for _, p := range []string{"AT", "CG", "RY", "KM", "BV", "DH", "NN", "SS", "WW"} {
+		c[p[0]], c[p[1]] = p[1], p[0]
+		c[p[0]+'a'-'A'], c[p[1]+'a'-'A'] = p[1]+'a'-'A', p[0]+'a'-'A'
+	}
+	c['U'], c['u'] = 'A', 'a'
+	return c
+}()
+
+// Complement returns the complement of the IUPAC nucleotide code b,
+// preserving case. Other bytes are returned unaltered.
+func Complement(b byte) byte { return complement[b] }
+
+// ReverseComplement reverse complements s in place.
+func ReverseComplement(s []byte) {
+	for i, j := 0, len(s)-1; i <= j; i, j = i+1, j-1 {
+		s[i], s[j] = complement[s[j]], complement[s[i]]
+	}
+}
+
+// Reverse reverses s in place.
+func Reverse(s []byte) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
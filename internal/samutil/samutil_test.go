@@ -0,0 +1,105 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package samutil
+
+import (
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestAux(t *testing.T) {
+	nm, err := sam.NewAux(sam.NewTag("NM"), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating aux: %v", err)
+	}
+	r := &sam.Record{
+		AuxFields: sam.AuxFields{nm},
+		RawAux:    []byte("RGZgroup\x00MCZ4M\x00"),
+	}
+	err = SetAux(r, sam.NewTag("MC"), "2M")
+	if err != nil {
+		t.Fatalf("unexpected error setting aux: %v", err)
+	}
+	err = SetAux(r, sam.NewTag("ms"), 30)
+	if err != nil {
+		t.Fatalf("unexpected error setting aux: %v", err)
+	}
+	if r.RawAux != nil {
+		t.Error("raw aux not decoded")
+	}
+	want := "RG:Z:group MC:Z:2M NM:i:1 ms:i:30"
+	if got := auxString(r); got != want {
+		t.Errorf("unexpected aux after set: got:%q want:%q", got, want)
+	}
+
+	err = DelAux(r, sam.NewTag("MC"))
+	if err != nil {
+		t.Fatalf("unexpected error deleting aux: %v", err)
+	}
+	want = "RG:Z:group NM:i:1 ms:i:30"
+	if got := auxString(r); got != want {
+		t.Errorf("unexpected aux after delete: got:%q want:%q", got, want)
+	}
+
+	rg, err := sam.NewAux(sam.NewTag("RG"), "other")
+	if err != nil {
+		t.Fatalf("unexpected error creating aux: %v", err)
+	}
+	err = PutAux(r, rg)
+	if err != nil {
+		t.Fatalf("unexpected error putting aux: %v", err)
+	}
+	err = DelAux(r, sam.NewTag("NM"), sam.NewTag("ms"))
+	if err != nil {
+		t.Fatalf("unexpected error deleting aux: %v", err)
+	}
+	want = "RG:Z:other"
+	if got := auxString(r); got != want {
+		t.Errorf("unexpected aux after put and delete: got:%q want:%q", got, want)
+	}
+
+	err = SetAux(r, sam.NewTag("MC"), struct{}{})
+	if err == nil {
+		t.Error("expected error for invalid aux value")
+	}
+}
+
+func auxString(r *sam.Record) string {
+	var s string
+	for i, a := range r.AuxFields {
+		if i != 0 {
+			s += " "
+		}
+		s += a.String()
+	}
+	return s
+}
+
+func TestReverseComplement(t *testing.T) {
+	for _, test := range []struct {
+		seq, want string
+	}{
+		{seq: "", want: ""},
+		{seq: "A", want: "T"},
+		{seq: "ACGTN", want: "NACGT"},
+		{seq: "acgtRYKMBVDHSW.*", want: "*.WSDHBVKMRYacgt"},
+	} {
+		s := []byte(test.seq)
+		ReverseComplement(s)
+		if string(s) != test.want {
+			t.Errorf("unexpected reverse complement of %q: got:%q want:%q", test.seq, s, test.want)
+		}
+	}
+	if Complement('g') != 'c' || Complement('U') != 'A' || Complement('X') != 'X' {
+		t.Error("unexpected complement")
+	}
+
+	s := []byte("ABC")
+	Reverse(s)
+	if string(s) != "CBA" {
+		t.Errorf("unexpected reverse: got:%q want:%q", s, "CBA")
+	}
+}
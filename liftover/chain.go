@@ -0,0 +1,276 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package liftover
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Chain is an alignment chain between a target and a query assembly, as
+// described in the UCSC chain format. Records are lifted from the target
+// assembly, the source of a liftover, to the query assembly. All
+// coordinates are zero-based half-open. Query coordinates of chains on
+// the reverse strand of the query are given on the reverse strand.
+type Chain struct {
+	Score float64
+
+	TName        string
+	TSize        int
+	TStart, TEnd int
+	QName        string
+	QSize        int
+	QReverse     bool
+	QStart, QEnd int
+	ID           string
+
+	// Blocks are the ungapped aligned blocks
+	// of the chain in order.
+	Blocks []Block
+}
+
+// Block is an ungapped aligned block of a Chain.
+type Block struct {
+	TStart, QStart int
+	Size           int
+}
+
+// ReadChains returns the chains read from r in the UCSC chain format.
+func ReadChains(r io.Reader) ([]*Chain, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	var (
+		chains []*Chain
+		c      *Chain
+		t, q   int
+		line   int
+	)
+	for sc.Scan() {
+		line++
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 || b[0] == '#' {
+			continue
+		}
+		f := bytes.Fields(b)
+		if string(f[0]) == "chain" {
+			if c != nil {
+				return nil, fmt.Errorf("liftover: line %d: unterminated chain %s", line, c.ID)
+			}
+			var err error
+			c, err = parseChainHeader(f)
+			if err != nil {
+				return nil, fmt.Errorf("liftover: line %d: %v", line, err)
+			}
+			t, q = c.TStart, c.QStart
+			continue
+		}
+		if c == nil {
+			return nil, fmt.Errorf("liftover: line %d: alignment data outside chain", line)
+		}
+		if len(f) != 1 && len(f) != 3 {
+			return nil, fmt.Errorf("liftover: line %d: invalid alignment data", line)
+		}
+		v := make([]int, len(f))
+		for i, s := range f {
+			n, err := strconv.Atoi(string(s))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("liftover: line %d: invalid alignment data: %q", line, s)
+			}
+			v[i] = n
+		}
+		c.Blocks = append(c.Blocks, Block{TStart: t, QStart: q, Size: v[0]})
+		t += v[0]
+		q += v[0]
+		if len(v) == 3 {
+			t += v[1]
+			q += v[2]
+			continue
+		}
+		if t != c.TEnd || q != c.QEnd {
+			return nil, fmt.Errorf("liftover: line %d: chain %s blocks do not match chain extent", line, c.ID)
+		}
+		chains = append(chains, c)
+		c = nil
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if c != nil {
+		return nil, fmt.Errorf("liftover: unterminated chain %s", c.ID)
+	}
+	return chains, nil
+}
+
+// parseChainHeader parses the fields of a chain header line.
+func parseChainHeader(f [][]byte) (*Chain, error) {
+	if len(f) != 12 && len(f) != 13 {
+		return nil, fmt.Errorf("invalid chain header")
+	}
+	score, err := strconv.ParseFloat(string(f[1]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chain score: %q", f[1])
+	}
+	var v [6]int
+	for i, j := range []int{3, 5, 6, 8, 10, 11} {
+		v[i], err = strconv.Atoi(string(f[j]))
+		if err != nil || v[i] < 0 {
+			return nil, fmt.Errorf("invalid chain coordinate: %q", f[j])
+		}
+	}
+	c := &Chain{
+		Score: score,
+		TName: string(f[2]), TSize: v[0], TStart: v[1], TEnd: v[2],
+		QName: string(f[7]), QSize: v[3], QStart: v[4], QEnd: v[5],
+	}
+	if len(f) == 13 {
+		c.ID = string(f[12])
+	}
+	if string(f[4]) != "+" {
+		return nil, fmt.Errorf("invalid target strand: %q", f[4])
+	}
+	switch string(f[9]) {
+	case "+":
+	case "-":
+		c.QReverse = true
+	default:
+		return nil, fmt.Errorf("invalid query strand: %q", f[9])
+	}
+	if c.TStart > c.TEnd || c.TEnd > c.TSize || c.QStart > c.QEnd || c.QEnd > c.QSize {
+		return nil, fmt.Errorf("invalid chain extent")
+	}
+	return c, nil
+}
+
+// Location is a position on an assembly.
+type Location struct {
+	Name string
+	Pos  int
+
+	// Reverse indicates that the location
+	// was reached through a chain on the
+	// reverse strand of the query.
+	Reverse bool
+}
+
+// Map is a set of chains indexed for lifting positions from the target
+// to the query assembly. Where chains overlap on the target, the chain
+// with the highest score is used.
+type Map struct {
+	chains []*Chain
+	refs   map[string]*refBlocks
+}
+
+// refBlocks is the set of chain blocks on a target reference, sorted by
+// start, with the greatest end of the blocks up to each block.
+type refBlocks struct {
+	blocks []chainBlock
+	maxEnd []int
+}
+
+type chainBlock struct {
+	Block
+	chain *Chain
+}
+
+// NewMap returns a Map holding the given chains.
+func NewMap(chains []*Chain) *Map {
+	m := &Map{chains: chains, refs: make(map[string]*refBlocks)}
+	for _, c := range chains {
+		rb, ok := m.refs[c.TName]
+		if !ok {
+			rb = &refBlocks{}
+			m.refs[c.TName] = rb
+		}
+		for _, b := range c.Blocks {
+			if b.Size != 0 {
+				rb.blocks = append(rb.blocks, chainBlock{Block: b, chain: c})
+			}
+		}
+	}
+	for _, rb := range m.refs {
+		sort.SliceStable(rb.blocks, func(i, j int) bool {
+			return rb.blocks[i].TStart < rb.blocks[j].TStart
+		})
+		rb.maxEnd = make([]int, len(rb.blocks))
+		end := -1
+		for i, b := range rb.blocks {
+			if e := b.TStart + b.Size; e > end {
+				end = e
+			}
+			rb.maxEnd[i] = end
+		}
+	}
+	return m
+}
+
+// ReadMap returns a Map holding the chains read from r in the UCSC chain
+// format.
+func ReadMap(r io.Reader) (*Map, error) {
+	chains, err := ReadChains(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewMap(chains), nil
+}
+
+// Chains returns the chains held by the Map.
+func (m *Map) Chains() []*Chain { return m.chains }
+
+// Lift returns the location on the query assembly of the position pos
+// on the named target reference, and whether the position is aligned
+// by a chain.
+func (m *Map) Lift(name string, pos int) (Location, bool) {
+	b := m.find(name, pos)
+	if b == nil {
+		return Location{}, false
+	}
+	return b.lift(pos), true
+}
+
+// find returns the block of the highest scoring chain holding pos on
+// the named target reference, or nil if there is none.
+func (m *Map) find(name string, pos int) *chainBlock {
+	rb, ok := m.refs[name]
+	if !ok {
+		return nil
+	}
+	i := sort.Search(len(rb.blocks), func(i int) bool { return rb.blocks[i].TStart > pos })
+	var best *chainBlock
+	for j := i - 1; j >= 0 && rb.maxEnd[j] > pos; j-- {
+		b := &rb.blocks[j]
+		if pos < b.TStart+b.Size && (best == nil || b.chain.Score > best.chain.Score) {
+			best = b
+		}
+	}
+	return best
+}
+
+// next returns the start of the first block starting after pos on the
+// named target reference, or -1 if there is none.
+func (m *Map) next(name string, pos int) int {
+	rb, ok := m.refs[name]
+	if !ok {
+		return -1
+	}
+	i := sort.Search(len(rb.blocks), func(i int) bool { return rb.blocks[i].TStart > pos })
+	if i == len(rb.blocks) {
+		return -1
+	}
+	return rb.blocks[i].TStart
+}
+
+// lift returns the query location of the target position pos, which
+// must be within the block.
+func (b *chainBlock) lift(pos int) Location {
+	q := b.QStart + pos - b.TStart
+	if b.chain.QReverse {
+		q = b.chain.QSize - 1 - q
+	}
+	return Location{Name: b.chain.QName, Pos: q, Reverse: b.chain.QReverse}
+}
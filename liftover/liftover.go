@@ -0,0 +1,537 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package liftover implements remapping of alignment records between
+// genome assemblies using UCSC chain files, such as hg19ToHg38.over.chain.
+//
+// The reference, position and mate position of each record are lifted
+// from the target assembly of the chains to the query assembly. Records
+// lifted through a chain on the reverse strand of the query have their
+// CIGAR, sequence and qualities reversed, their sequence complemented
+// and their strand flags inverted. Records whose alignment is not held
+// within a single ungapped chain block are rejected or, if requested,
+// split into one record for each block they align within.
+//
+// Lifted records are annotated with their original alignment in an OA
+// aux field, as described in the SAM tags specification. The MD and NM
+// aux fields, which describe the source reference bases, are removed.
+package liftover
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrNoChain  = errors.New("liftover: no chain covers record")
+	ErrGap      = errors.New("liftover: record spans chain gap")
+	ErrNoTarget = errors.New("liftover: lifted reference not in header")
+)
+
+var (
+	oaTag = sam.NewTag("OA")
+	mdTag = sam.NewTag("MD")
+	nmTag = sam.NewTag("NM")
+	mcTag = sam.NewTag("MC")
+)
+
+// Options specifies the behaviour of a Lifter.
+type Options struct {
+	// Split specifies that records whose
+	// alignment spans more than one chain
+	// block are split into a record for
+	// each block, with the bases outside
+	// the block soft clipped. The record
+	// with the most aligned bases keeps the
+	// flags of the original record and the
+	// remaining records are marked as
+	// supplementary. Otherwise such records
+	// are rejected with ErrGap.
+	Split bool
+}
+
+// Lifter lifts records to the query assembly of a Map.
+type Lifter struct {
+	m    *Map
+	refs map[string]*sam.Reference
+	opts Options
+}
+
+// NewLifter returns a Lifter lifting records through m to the
+// references of h, which describes the query assembly. Header may be
+// used to construct h.
+func NewLifter(m *Map, h *sam.Header, opts Options) *Lifter {
+	refs := make(map[string]*sam.Reference, len(h.Refs()))
+	for _, r := range h.Refs() {
+		refs[r.Name()] = r
+	}
+	return &Lifter{m: m, refs: refs, opts: opts}
+}
+
+// Header returns a header for records lifted from the assembly of src
+// through the chains of m. The references of the header are the query
+// references of the chains on the references of src, in the order of
+// src, and the read groups, programs and comments of src are retained.
+// The sort order of the header is unsorted since lifting does not
+// retain coordinate order.
+func (m *Map) Header(src *sam.Header) (*sam.Header, error) {
+	byTarget := make(map[string][]*Chain)
+	for _, c := range m.chains {
+		byTarget[c.TName] = append(byTarget[c.TName], c)
+	}
+	var refs []*sam.Reference
+	seen := make(map[string]bool)
+	for _, r := range src.Refs() {
+		for _, c := range byTarget[r.Name()] {
+			if seen[c.QName] {
+				continue
+			}
+			seen[c.QName] = true
+			ref, err := sam.NewReference(c.QName, "", "", c.QSize, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, ref)
+		}
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		return nil, err
+	}
+	h.Version = src.Version
+	h.SortOrder = sam.Unsorted
+	for _, rg := range src.RGs() {
+		err = h.AddReadGroup(rg.Clone())
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range src.Progs() {
+		err = h.AddProgram(p.Clone())
+		if err != nil {
+			return nil, err
+		}
+	}
+	h.Comments = append(h.Comments, src.Comments...)
+	return h, nil
+}
+
+// Lift returns the records resulting from lifting r. The returned
+// records are copies and r is not altered. If the alignment of r cannot
+// be lifted, no records are returned and the error is ErrNoChain,
+// ErrGap or ErrNoTarget. Unmapped records are always lifted, losing
+// their placement if their position cannot be lifted.
+func (l *Lifter) Lift(r *sam.Record) ([]*sam.Record, error) {
+	if r.Flags&sam.Unmapped != 0 || r.Ref == nil {
+		rec, err := l.copy(r)
+		if err != nil {
+			return nil, err
+		}
+		rec.Ref, rec.Pos = nil, -1
+		if r.Ref != nil {
+			loc, ok := l.m.Lift(r.Ref.Name(), r.Pos)
+			if ref := l.refs[loc.Name]; ok && ref != nil {
+				rec.Ref, rec.Pos = ref, loc.Pos
+			}
+		}
+		err = l.liftMate(rec, r)
+		if err != nil {
+			return nil, err
+		}
+		return []*sam.Record{rec}, nil
+	}
+
+	pieces, split := l.pieces(r)
+	switch {
+	case len(pieces) == 0:
+		return nil, ErrNoChain
+	case split && !l.opts.Split:
+		return nil, ErrGap
+	}
+	primary := 0
+	for i, p := range pieces {
+		if p.aligned > pieces[primary].aligned {
+			primary = i
+		}
+	}
+	lifted := make([]*sam.Record, 0, len(pieces))
+	for i, p := range pieces {
+		rec, err := l.place(r, p)
+		if err != nil {
+			return nil, err
+		}
+		if i != primary {
+			rec.Flags |= sam.Supplementary
+		}
+		err = l.liftMate(rec, r)
+		if err != nil {
+			return nil, err
+		}
+		lifted = append(lifted, rec)
+	}
+	return lifted, nil
+}
+
+// copy returns a copy of r with its own aux fields, annotated with the
+// original alignment of r and without MD and NM fields.
+func (l *Lifter) copy(r *sam.Record) (*sam.Record, error) {
+	rec := *r
+	rec.Scratch = nil
+	aux := r.AuxFields
+	if r.RawAux != nil {
+		err := rec.DecodeAux()
+		if err != nil {
+			return nil, err
+		}
+		aux = rec.AuxFields
+	}
+	var (
+		nm string
+		oa string
+	)
+	rec.AuxFields = make(sam.AuxFields, 0, len(aux)+1)
+	for _, a := range aux {
+		switch a.Tag() {
+		case nmTag:
+			nm = fmt.Sprint(a.Value())
+			continue
+		case mdTag:
+			continue
+		case oaTag:
+			oa, _ = a.Value().(string)
+			continue
+		}
+		rec.AuxFields = append(rec.AuxFields, a)
+	}
+	if r.Ref != nil {
+		strand := '+'
+		if r.Flags&sam.Reverse != 0 {
+			strand = '-'
+		}
+		oa += fmt.Sprintf("%s,%d,%c,%s,%d,%s;", r.Ref.Name(), r.Pos+1, strand, sam.Cigar(r.Cigar), r.MapQ, nm)
+		a, err := sam.NewAux(oaTag, oa)
+		if err != nil {
+			return nil, err
+		}
+		rec.AuxFields = append(rec.AuxFields, a)
+	}
+	return &rec, nil
+}
+
+// piece is the part of an alignment held within a single chain block.
+type piece struct {
+	blk *chainBlock
+
+	// pos is the target position of the
+	// first aligned base and ops are the
+	// CIGAR operations of the piece.
+	pos int
+	ops []sam.CigarOp
+
+	// beg and end are the read offsets of
+	// the aligned bases, excluding hard
+	// clipped bases.
+	beg, end int
+
+	// aligned is the number of aligned
+	// bases in the piece.
+	aligned int
+}
+
+// pieces returns the pieces of the alignment of r held within chain
+// blocks, and whether any part of the alignment is not held within the
+// block of the first piece.
+func (l *Lifter) pieces(r *sam.Record) (pieces []piece, split bool) {
+	var (
+		name = r.Ref.Name()
+		ref  = r.Pos
+		q    int
+		cur  *piece
+	)
+	closePiece := func() {
+		if cur == nil {
+			return
+		}
+		if p, ok := trim(*cur); ok {
+			pieces = append(pieces, p)
+		}
+		cur = nil
+	}
+	for _, co := range r.Cigar {
+		t, n := co.Type(), co.Len()
+		con := t.Consumes()
+		switch {
+		case t == sam.CigarHardClipped:
+			continue
+		case t == sam.CigarSoftClipped:
+			q += n
+			continue
+		case con.Reference <= 0:
+			if con.Query == 0 {
+				continue
+			}
+			q += n
+			if cur == nil {
+				split = true
+				continue
+			}
+			cur.ops = append(cur.ops, co)
+			cur.end = q
+			continue
+		}
+		for n > 0 {
+			b := l.m.find(name, ref)
+			if b == nil {
+				// Skip to the next block or the
+				// end of the operation.
+				split = true
+				closePiece()
+				k := n
+				if next := l.m.next(name, ref); next >= 0 && next-ref < k {
+					k = next - ref
+				}
+				ref += k
+				q += k * con.Query
+				n -= k
+				continue
+			}
+			if cur == nil || cur.blk != b {
+				if cur != nil {
+					split = true
+				}
+				closePiece()
+				cur = &piece{blk: b, pos: ref, beg: q}
+			}
+			k := n
+			if end := b.TStart + b.Size; end-ref < k {
+				k = end - ref
+			}
+			cur.ops = append(cur.ops, sam.NewCigarOp(t, k))
+			if con.Query != 0 {
+				cur.aligned += k
+			}
+			ref += k
+			q += k * con.Query
+			cur.end = q
+			n -= k
+		}
+	}
+	closePiece()
+	return pieces, split
+}
+
+// trim removes leading and trailing operations of p that do not align
+// bases, returning false if p has no aligned bases.
+func trim(p piece) (piece, bool) {
+	if p.aligned == 0 {
+		return p, false
+	}
+	for {
+		co := p.ops[0]
+		con := co.Type().Consumes()
+		if con.Query != 0 && con.Reference != 0 {
+			break
+		}
+		if con.Reference != 0 {
+			p.pos += co.Len()
+		}
+		p.ops = p.ops[1:]
+	}
+	for {
+		co := p.ops[len(p.ops)-1]
+		con := co.Type().Consumes()
+		if con.Query != 0 && con.Reference != 0 {
+			break
+		}
+		if con.Query != 0 {
+			p.end -= co.Len()
+		}
+		p.ops = p.ops[:len(p.ops)-1]
+	}
+	return p, true
+}
+
+// place returns a copy of r holding the alignment of p lifted to the
+// query assembly.
+func (l *Lifter) place(r *sam.Record, p piece) (*sam.Record, error) {
+	ref := l.refs[p.blk.chain.QName]
+	if ref == nil {
+		return nil, ErrNoTarget
+	}
+	rec, err := l.copy(r)
+	if err != nil {
+		return nil, err
+	}
+
+	_, readLen := sam.Cigar(r.Cigar).Lengths()
+	var cigar []sam.CigarOp
+	if co := r.Cigar[0]; co.Type() == sam.CigarHardClipped {
+		cigar = append(cigar, co)
+	}
+	if p.beg != 0 {
+		cigar = append(cigar, sam.NewCigarOp(sam.CigarSoftClipped, p.beg))
+	}
+	cigar = append(cigar, p.ops...)
+	if p.end != readLen {
+		cigar = append(cigar, sam.NewCigarOp(sam.CigarSoftClipped, readLen-p.end))
+	}
+	if co := r.Cigar[len(r.Cigar)-1]; len(r.Cigar) > 1 && co.Type() == sam.CigarHardClipped {
+		cigar = append(cigar, co)
+	}
+	rec.Cigar = cigar
+	rec.Ref = ref
+
+	if !p.blk.chain.QReverse {
+		rec.Pos = p.blk.lift(p.pos).Pos
+		return rec, nil
+	}
+	refLen, _ := sam.Cigar(p.ops).Lengths()
+	rec.Pos = p.blk.lift(p.pos + refLen - 1).Pos
+	reverseCigar(rec.Cigar)
+	seq := r.Seq.Expand()
+	samutil.ReverseComplement(seq)
+	rec.Seq = sam.NewSeq(seq)
+	if r.Qual != nil {
+		rec.Qual = append([]byte(nil), r.Qual...)
+		samutil.Reverse(rec.Qual)
+	}
+	rec.Flags ^= sam.Reverse
+	return rec, nil
+}
+
+// liftMate sets the mate fields of rec, a copy of the original record
+// r, from those of r lifted to the query assembly. If the mate position cannot be
+// lifted, the mate is marked as unmapped.
+func (l *Lifter) liftMate(rec, r *sam.Record) error {
+	if r.MateRef == nil || r.MatePos < 0 {
+		return nil
+	}
+	var mateCigar sam.Cigar
+	if a := rec.AuxTag(mcTag); a != nil {
+		if s, ok := a.Value().(string); ok {
+			mateCigar, _ = sam.ParseCigar([]byte(s))
+		}
+	}
+	mateLen, _ := mateCigar.Lengths()
+	if mateLen < 1 {
+		mateLen = 1
+	}
+
+	loc, ok := l.m.Lift(r.MateRef.Name(), r.MatePos)
+	ref := l.refs[loc.Name]
+	if !ok || ref == nil {
+		rec.MateRef, rec.MatePos, rec.TempLen = nil, -1, 0
+		rec.Flags |= sam.MateUnmapped
+		rec.Flags &^= sam.ProperPair
+		return samutil.DelAux(rec, mcTag)
+	}
+	if loc.Reverse {
+		// The lifted mate starts at the lifted
+		// position of its last aligned base.
+		end, ok := l.m.Lift(r.MateRef.Name(), r.MatePos+mateLen-1)
+		if ok && end.Name == loc.Name && end.Reverse {
+			loc.Pos = end.Pos
+		} else {
+			loc.Pos -= mateLen - 1
+		}
+		rec.Flags ^= sam.MateReverse
+		if mateCigar != nil {
+			reverseCigar(mateCigar)
+			err := samutil.SetAux(rec, mcTag, mateCigar.String())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	rec.MateRef, rec.MatePos = ref, loc.Pos
+	if rec.MateRef != rec.Ref {
+		rec.Flags &^= sam.ProperPair
+		rec.TempLen = 0
+		return nil
+	}
+	if rec.Flags&sam.Unmapped != 0 || rec.Flags&sam.MateUnmapped != 0 {
+		rec.TempLen = 0
+		return nil
+	}
+	if mateCigar == nil {
+		// Without the mate CIGAR the template
+		// length is only known to be retained
+		// through forward strand chains.
+		if loc.Reverse || (rec.Flags^r.Flags)&sam.Reverse != 0 {
+			rec.TempLen = 0
+		}
+		return nil
+	}
+	left, right := rec.Pos, rec.End()
+	if rec.MatePos < left {
+		left = rec.MatePos
+	}
+	if end := rec.MatePos + mateLen; end > right {
+		right = end
+	}
+	rec.TempLen = right - left
+	if rec.MatePos < rec.Pos || (rec.MatePos == rec.Pos && rec.Flags&sam.Read2 != 0) {
+		rec.TempLen = -rec.TempLen
+	}
+	return nil
+}
+
+// Source is a source of records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Reader is a lifting record reader. It returns the lifted records of
+// its Source in order.
+type Reader struct {
+	src Source
+	l   *Lifter
+
+	// Rejected, if not nil, is called with
+	// each record that cannot be lifted and
+	// the reason it was rejected.
+	Rejected func(r *sam.Record, err error)
+
+	pending []*sam.Record
+}
+
+// NewReader returns a Reader lifting the records of src with l.
+func NewReader(src Source, l *Lifter) *Reader {
+	return &Reader{src: src, l: l}
+}
+
+// Read returns the next lifted record.
+func (r *Reader) Read() (*sam.Record, error) {
+	for len(r.pending) == 0 {
+		rec, err := r.src.Read()
+		if err != nil {
+			return nil, err
+		}
+		lifted, err := r.l.Lift(rec)
+		switch err {
+		case nil:
+			r.pending = lifted
+		case ErrNoChain, ErrGap, ErrNoTarget:
+			if r.Rejected != nil {
+				r.Rejected(rec, err)
+			}
+		default:
+			return nil, err
+		}
+	}
+	rec := r.pending[0]
+	r.pending[0] = nil
+	r.pending = r.pending[1:]
+	return rec, nil
+}
+
+// reverseCigar reverses c in place.
+func reverseCigar(c []sam.CigarOp) {
+	for i, j := 0, len(c)-1; i < j; i, j = i+1, j-1 {
+		c[i], c[j] = c[j], c[i]
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package liftover
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// testChains maps chr1 to chrA with a 50 base deletion in chrA after
+// 500 bases, and chr2 to the reverse strand of chrB.
+const testChains = `# test chains
+chain 1000 chr1 1000 + 0 1000 chrA 1100 + 100 1050 1
+500	50	0
+450
+
+chain 900 chr2 500 + 0 500 chrB 500 - 0 500 2
+500
+`
+
+func testMap(t *testing.T) *Map {
+	t.Helper()
+	m, err := ReadMap(strings.NewReader(testChains))
+	if err != nil {
+		t.Fatalf("unexpected error reading chains: %v", err)
+	}
+	return m
+}
+
+func TestReadChains(t *testing.T) {
+	chains, err := ReadChains(strings.NewReader(testChains))
+	if err != nil {
+		t.Fatalf("unexpected error reading chains: %v", err)
+	}
+	want := []*Chain{
+		{
+			Score: 1000,
+			TName: "chr1", TSize: 1000, TStart: 0, TEnd: 1000,
+			QName: "chrA", QSize: 1100, QStart: 100, QEnd: 1050,
+			ID:     "1",
+			Blocks: []Block{{TStart: 0, QStart: 100, Size: 500}, {TStart: 550, QStart: 600, Size: 450}},
+		},
+		{
+			Score: 900,
+			TName: "chr2", TSize: 500, TStart: 0, TEnd: 500,
+			QName: "chrB", QSize: 500, QReverse: true, QStart: 0, QEnd: 500,
+			ID:     "2",
+			Blocks: []Block{{TStart: 0, QStart: 0, Size: 500}},
+		},
+	}
+	if !reflect.DeepEqual(chains, want) {
+		t.Errorf("unexpected chains:\ngot: %+v\nwant:%+v", chains, want)
+	}
+
+	for _, bad := range []string{
+		"chain 1 chr1 100 + 0 100 chrA 100 + 0 100\n50\n",
+		"chain 1 chr1 100 + 0 100 chrA 100 + 0 100\n100 0 0\n",
+		"chain 1 chr1 100 - 0 100 chrA 100 + 0 100\n100\n",
+		"50 0 0\n",
+	} {
+		_, err := ReadChains(strings.NewReader(bad))
+		if err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestMapLift(t *testing.T) {
+	m := testMap(t)
+	for _, test := range []struct {
+		name string
+		pos  int
+		want Location
+		ok   bool
+	}{
+		{name: "chr1", pos: 10, want: Location{Name: "chrA", Pos: 110}, ok: true},
+		{name: "chr1", pos: 499, want: Location{Name: "chrA", Pos: 599}, ok: true},
+		{name: "chr1", pos: 520, ok: false},
+		{name: "chr1", pos: 600, want: Location{Name: "chrA", Pos: 650}, ok: true},
+		{name: "chr1", pos: 1000, ok: false},
+		{name: "chr2", pos: 0, want: Location{Name: "chrB", Pos: 499, Reverse: true}, ok: true},
+		{name: "chr3", pos: 0, ok: false},
+	} {
+		got, ok := m.Lift(test.name, test.pos)
+		if ok != test.ok || got != test.want {
+			t.Errorf("unexpected lift of %s:%d: got:%+v %t want:%+v %t", test.name, test.pos, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestLift(t *testing.T) {
+	m := testMap(t)
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	chr2, _ := sam.NewReference("chr2", "", "", 500, nil, nil)
+	src, err := sam.NewHeader(nil, []*sam.Reference{chr1, chr2})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	dst, err := m.Header(src)
+	if err != nil {
+		t.Fatalf("unexpected error creating lifted header: %v", err)
+	}
+	var names []string
+	for _, r := range dst.Refs() {
+		names = append(names, r.Name())
+	}
+	if want := []string{"chrA", "chrB"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("unexpected lifted references: got:%v want:%v", names, want)
+	}
+	chrA, chrB := dst.Refs()[0], dst.Refs()[1]
+
+	cigar := func(s string) []sam.CigarOp {
+		c, err := sam.ParseCigar([]byte(s))
+		if err != nil {
+			t.Fatalf("unexpected error parsing cigar: %v", err)
+		}
+		return c
+	}
+	aux := func(tag string, v interface{}) sam.Aux {
+		a, err := sam.NewAux(sam.NewTag(tag), v)
+		if err != nil {
+			t.Fatalf("unexpected error creating aux: %v", err)
+		}
+		return a
+	}
+	newRecord := func(name string, ref, mref *sam.Reference, pos, mpos, tlen int, c, seq string, aux ...sam.Aux) *sam.Record {
+		qual := make([]byte, len(seq))
+		for i := range qual {
+			qual[i] = byte(i + 1)
+		}
+		r, err := sam.NewRecord(name, ref, mref, pos, mpos, tlen, 60, cigar(c), []byte(seq), qual, aux)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		return r
+	}
+
+	type result struct {
+		ref     *sam.Reference
+		pos     int
+		cigar   string
+		flags   sam.Flags
+		seq     string
+		mateRef *sam.Reference
+		matePos int
+		tlen    int
+		oa      string
+	}
+	for _, test := range []struct {
+		name  string
+		rec   *sam.Record
+		split bool
+		want  []result
+		err   error
+	}{
+		{
+			name: "forward",
+			rec:  newRecord("r0", chr1, nil, 10, -1, 0, "4M", "ACGT", aux("NM", 1), aux("MD", "3A0")),
+			want: []result{{ref: chrA, pos: 110, cigar: "4M", seq: "ACGT", matePos: -1, oa: "chr1,11,+,4M,60,1;"}},
+		},
+		{
+			name: "reverse chain",
+			rec:  newRecord("r1", chr2, nil, 10, -1, 0, "2S4M", "GGACGT"),
+			want: []result{{ref: chrB, pos: 486, cigar: "4M2S", flags: sam.Reverse, seq: "ACGTCC", matePos: -1, oa: "chr2,11,+,2S4M,60,;"}},
+		},
+		{
+			name: "gap rejected",
+			rec:  newRecord("r2", chr1, nil, 480, -1, 0, "80M", strings.Repeat("A", 80)),
+			err:  ErrGap,
+		},
+		{
+			name:  "gap split",
+			rec:   newRecord("r2", chr1, nil, 480, -1, 0, "80M", strings.Repeat("A", 80)),
+			split: true,
+			want: []result{
+				{ref: chrA, pos: 580, cigar: "20M60S", seq: strings.Repeat("A", 80), matePos: -1, oa: "chr1,481,+,80M,60,;"},
+				{ref: chrA, pos: 600, cigar: "70S10M", flags: sam.Supplementary, seq: strings.Repeat("A", 80), matePos: -1, oa: "chr1,481,+,80M,60,;"},
+			},
+		},
+		{
+			name: "no chain",
+			rec:  newRecord("r3", chr1, nil, 510, -1, 0, "4M", "ACGT"),
+			err:  ErrNoChain,
+		},
+		{
+			name: "mate",
+			rec:  newRecord("r4", chr1, chr1, 10, 600, 594, "4M", "ACGT", aux("MC", "4M")),
+			want: []result{{ref: chrA, pos: 110, cigar: "4M", seq: "ACGT", mateRef: chrA, matePos: 650, tlen: 544, oa: "chr1,11,+,4M,60,;"}},
+		},
+		{
+			name: "mate in gap",
+			rec:  newRecord("r5", chr1, chr1, 10, 520, 514, "4M", "ACGT", aux("MC", "4M")),
+			want: []result{{ref: chrA, pos: 110, cigar: "4M", flags: sam.MateUnmapped, seq: "ACGT", matePos: -1, oa: "chr1,11,+,4M,60,;"}},
+		},
+	} {
+		l := NewLifter(m, dst, Options{Split: test.split})
+		got, err := l.Lift(test.rec)
+		if err != test.err {
+			t.Errorf("unexpected error for %s: got:%v want:%v", test.name, err, test.err)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("unexpected number of records for %s: got:%d want:%d", test.name, len(got), len(test.want))
+			continue
+		}
+		for i, r := range got {
+			var oa string
+			if a := r.AuxFields.Get(oaTag); a != nil {
+				oa, _ = a.Value().(string)
+			}
+			res := result{
+				ref:     r.Ref,
+				pos:     r.Pos,
+				cigar:   sam.Cigar(r.Cigar).String(),
+				flags:   r.Flags,
+				seq:     string(r.Seq.Expand()),
+				mateRef: r.MateRef,
+				matePos: r.MatePos,
+				tlen:    r.TempLen,
+				oa:      oa,
+			}
+			if res != test.want[i] {
+				t.Errorf("unexpected record %d for %s:\ngot: %+v\nwant:%+v", i, test.name, res, test.want[i])
+			}
+			if r.AuxFields.Get(nmTag) != nil || r.AuxFields.Get(mdTag) != nil {
+				t.Errorf("unexpected NM or MD field for %s", test.name)
+			}
+		}
+	}
+
+	// Reverse chain qualities are reversed.
+	r := newRecord("r1", chr2, nil, 10, -1, 0, "4M", "ACGT")
+	got, err := NewLifter(m, dst, Options{}).Lift(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []byte{4, 3, 2, 1}; !reflect.DeepEqual(got[0].Qual, want) {
+		t.Errorf("unexpected qualities: got:%v want:%v", got[0].Qual, want)
+	}
+	if !reflect.DeepEqual(r.Qual, []byte{1, 2, 3, 4}) || r.Ref != chr2 {
+		t.Error("original record altered")
+	}
+
+	// Unmapped records placed in a gap lose
+	// their placement.
+	u := newRecord("u", chr1, nil, 520, -1, 0, "*", "ACGT")
+	u.Flags |= sam.Unmapped
+	got, err = NewLifter(m, dst, Options{}).Lift(u)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].Ref != nil || got[0].Pos != -1 {
+		t.Errorf("unexpected placement of unmapped record: %v:%d", got[0].Ref, got[0].Pos)
+	}
+}
+
+func TestReader(t *testing.T) {
+	m := testMap(t)
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	src, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	dst, err := m.Header(src)
+	if err != nil {
+		t.Fatalf("unexpected error creating lifted header: %v", err)
+	}
+	var recs records
+	for i, pos := range []int{10, 510, 600} {
+		r, err := sam.NewRecord(string(rune('a'+i)), chr1, nil, pos, -1, 0, 60,
+			[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}, []byte("ACGT"), nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		recs = append(recs, r)
+	}
+	lr := NewReader(&recs, NewLifter(m, dst, Options{}))
+	var rejected []string
+	lr.Rejected = func(r *sam.Record, err error) {
+		if err != ErrNoChain {
+			t.Errorf("unexpected rejection error: %v", err)
+		}
+		rejected = append(rejected, r.Name)
+	}
+	var got []int
+	for {
+		r, err := lr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+		got = append(got, r.Pos)
+	}
+	if want := []int{110, 650}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected lifted positions: got:%v want:%v", got, want)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(rejected, want) {
+		t.Errorf("unexpected rejected records: got:%v want:%v", rejected, want)
+	}
+}
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
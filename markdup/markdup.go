@@ -0,0 +1,648 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package markdup implements duplicate marking of coordinate sorted
+// alignment records.
+//
+// Fragments, either read pairs or unpaired reads, are grouped by
+// library and by the unclipped 5' positions and strands of their
+// reads. Within each group, the fragment with the highest score is
+// retained and the remaining fragments are marked as duplicates.
+// Unpaired reads sharing an end with a read pair are duplicates.
+// Duplicates are classified as optical when they lie close to another
+// member of their group on the flow cell, as determined from the
+// read names.
+//
+// Duplicate marking adds the following aux tags to records in groups
+// of more than one fragment, which may be read with the corresponding
+// sam.Record methods:
+//
+//	DI  bag ID shared by the records of a duplicate group
+//	DS  number of fragments in the duplicate group
+//	DL  number of fragments in the group that are not optical duplicates
+//	DT  duplicate type of duplicate records: SQ for optical, LB otherwise
+//	LI  bag ID shared by fragments with the same leftmost end
+//	LS  number of fragments in the linear bag
+//	LD  linear duplicate state: primary or duplicate
+//
+// The mate positions of read pairs are obtained from the MC aux tag,
+// and mate scores from the ms aux tag, as added by samtools fixmate.
+// Without an MC tag, the mate is assumed to be unclipped and to start
+// at the mate position.
+package markdup
+
+import (
+	"container/heap"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Default option values.
+const (
+	DefaultWindow          = 1000
+	DefaultOpticalDistance = 100
+	DefaultMinBaseQ        = 15
+)
+
+// Source is a source of coordinate sorted records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Options specifies the behaviour of duplicate marking.
+type Options struct {
+	// Window is the maximum distance between
+	// the position of a record and the position
+	// of its unclipped 5' end. If Window is
+	// zero, DefaultWindow is used.
+	Window int
+
+	// OpticalDistance is the maximum distance
+	// in each axis between optical duplicates.
+	// If OpticalDistance is zero,
+	// DefaultOpticalDistance is used. If it is
+	// negative, optical duplicates are not
+	// identified.
+	OpticalDistance int
+
+	// MinBaseQ is the minimum quality of bases
+	// contributing to the score of a read. If
+	// MinBaseQ is zero, DefaultMinBaseQ is used.
+	MinBaseQ byte
+
+	// ParseName returns the flow cell location
+	// of a read from its name. If ParseName is
+	// nil, ParseIlluminaName is used.
+	ParseName func(name string) (loc Location, ok bool)
+}
+
+// Location is the position of a cluster on a flow cell.
+type Location struct {
+	// Group identifies the flow cell
+	// and lane of the cluster.
+	Group string
+
+	Tile, X, Y int
+}
+
+// ParseIlluminaName returns the location of a read from its Illumina
+// read name. The tile, x and y coordinates are taken from the last
+// three colon-separated fields of the name, and the preceding fields
+// are the location's group.
+func ParseIlluminaName(name string) (loc Location, ok bool) {
+	f := strings.Split(name, ":")
+	if len(f) < 5 {
+		return Location{}, false
+	}
+	n := len(f)
+	var v [3]int
+	for i, s := range f[n-3:] {
+		// Trim any trailing non-digit suffix,
+		// such as "#0/1".
+		end := 0
+		for end < len(s) && '0' <= s[end] && s[end] <= '9' {
+			end++
+		}
+		var err error
+		v[i], err = strconv.Atoi(s[:end])
+		if err != nil {
+			return Location{}, false
+		}
+	}
+	return Location{Group: strings.Join(f[:n-3], ":"), Tile: v[0], X: v[1], Y: v[2]}, true
+}
+
+var (
+	rgTag = sam.NewTag("RG")
+	mcTag = sam.NewTag("MC")
+	msTag = sam.NewTag("ms")
+
+	diTag = sam.NewTag("DI")
+	dsTag = sam.NewTag("DS")
+	dlTag = sam.NewTag("DL")
+	dtTag = sam.NewTag("DT")
+	liTag = sam.NewTag("LI")
+	lsTag = sam.NewTag("LS")
+	ldTag = sam.NewTag("LD")
+)
+
+// Reader is a duplicate marking record reader. It returns the records of
+// its Source in order with their duplicate flags and tags set.
+// Secondary, supplementary and unmapped records are returned unaltered.
+type Reader struct {
+	src  Source
+	opts Options
+	libs map[string]string
+
+	queue []*entry
+	done  bool
+
+	// pending holds the fragments of read
+	// pairs awaiting their second read.
+	pending map[string]*fragment
+
+	ends  map[endKey]*end
+	order endHeap
+
+	bags, linearBags int
+}
+
+// NewReader returns a Reader marking duplicates in the records read from
+// src. The header h is used to determine the library of each record
+// from its read group.
+func NewReader(src Source, h *sam.Header, opts Options) *Reader {
+	if opts.Window == 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.OpticalDistance == 0 {
+		opts.OpticalDistance = DefaultOpticalDistance
+	}
+	if opts.MinBaseQ == 0 {
+		opts.MinBaseQ = DefaultMinBaseQ
+	}
+	if opts.ParseName == nil {
+		opts.ParseName = ParseIlluminaName
+	}
+	libs := make(map[string]string)
+	if h != nil {
+		for _, rg := range h.RGs() {
+			libs[rg.Name()] = rg.Library()
+		}
+	}
+	return &Reader{
+		src:     src,
+		opts:    opts,
+		libs:    libs,
+		pending: make(map[string]*fragment),
+		ends:    make(map[endKey]*end),
+	}
+}
+
+// entry is a queued record.
+type entry struct {
+	rec  *sam.Record
+	frag *fragment
+}
+
+// fragment is a read pair or unpaired read.
+type fragment struct {
+	name   string
+	score  int
+	loc    Location
+	hasLoc bool
+
+	decided bool
+	dup     bool
+	optical bool
+
+	bag    *bag
+	linear *bag
+	linDup bool
+}
+
+// bag holds the tag values of a group of fragments.
+type bag struct {
+	id, size, library int
+}
+
+// endKey identifies a fragment end.
+type endKey struct {
+	lib string
+	ref int
+	pos int
+	rev bool
+}
+
+// less returns whether k sorts before o by reference, position and
+// strand, with the forward strand first.
+func (k endKey) less(o endKey) bool {
+	if k.ref != o.ref {
+		return k.ref < o.ref
+	}
+	if k.pos != o.pos {
+		return k.pos < o.pos
+	}
+	return !k.rev && o.rev
+}
+
+// pairKey identifies a read pair by both its ends. The ends are held
+// in canonical order so that the key does not depend on which read of
+// the pair is read first.
+type pairKey struct {
+	lower, upper endKey
+}
+
+// end holds the fragments with a read at an end.
+type end struct {
+	key endKey
+
+	// pairs holds the read pairs with
+	// the end as their lower end.
+	pairs map[pairKey][]*fragment
+
+	// singles holds the unpaired reads
+	// with the end.
+	singles []*fragment
+
+	// linear holds all the fragments with
+	// the end as the first read in order.
+	linear []*fragment
+
+	// hasPair is whether any read pair
+	// has a read at the end.
+	hasPair bool
+}
+
+// Read returns the next record.
+func (r *Reader) Read() (*sam.Record, error) {
+	for {
+		if len(r.queue) != 0 {
+			e := r.queue[0]
+			if e.frag == nil || e.frag.decided || r.done {
+				r.queue[0] = nil
+				r.queue = r.queue[1:]
+				if e.frag != nil {
+					err := r.apply(e.rec, e.frag)
+					if err != nil {
+						return nil, err
+					}
+				}
+				return e.rec, nil
+			}
+		}
+		if r.done {
+			return nil, io.EOF
+		}
+		rec, err := r.src.Read()
+		if err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			r.done = true
+			r.closeAll()
+			continue
+		}
+		r.add(rec)
+	}
+}
+
+// add adds rec to the queue and its fragment to the appropriate end.
+func (r *Reader) add(rec *sam.Record) {
+	e := &entry{rec: rec}
+	r.queue = append(r.queue, e)
+	if rec.Flags&(sam.Unmapped|sam.Secondary|sam.Supplementary) != 0 || rec.Ref == nil {
+		if rec.Ref == nil {
+			// Unplaced records follow all placed records.
+			r.closeAll()
+		}
+		return
+	}
+	r.closeBefore(rec.Ref.ID(), rec.Pos)
+
+	paired := rec.Flags&(sam.Paired|sam.MateUnmapped) == sam.Paired && rec.MateRef != nil
+	if paired {
+		if f, ok := r.pending[rec.Name]; ok {
+			delete(r.pending, rec.Name)
+			e.frag = f
+			return
+		}
+	}
+
+	lib := r.libs[auxString(rec, rgTag)]
+	f := &fragment{name: rec.Name, score: r.score(rec)}
+	if r.opts.OpticalDistance > 0 {
+		f.loc, f.hasLoc = r.opts.ParseName(rec.Name)
+	}
+	e.frag = f
+
+	first := endKey{lib: lib, ref: rec.Ref.ID(), pos: fivePrime(rec.Pos, rec.Cigar, rec.Flags&sam.Reverse != 0), rev: rec.Flags&sam.Reverse != 0}
+	en := r.end(first)
+	en.linear = append(en.linear, f)
+	if !paired {
+		en.singles = append(en.singles, f)
+		return
+	}
+
+	if ms, ok := auxInt(rec, msTag); ok {
+		f.score += ms
+	}
+	mateRev := rec.Flags&sam.MateReverse != 0
+	var mateCigar sam.Cigar
+	if mc := auxString(rec, mcTag); mc != "" {
+		mateCigar, _ = sam.ParseCigar([]byte(mc))
+	}
+	second := endKey{lib: lib, ref: rec.MateRef.ID(), pos: fivePrime(rec.MatePos, mateCigar, mateRev), rev: mateRev}
+	en.hasPair = true
+	r.end(second).hasPair = true
+
+	// Register the pair under its lower end
+	// so that duplicate pairs are grouped
+	// regardless of which of their reads
+	// sorts first.
+	k := pairKey{lower: first, upper: second}
+	if second.less(first) {
+		k = pairKey{lower: second, upper: first}
+	}
+	lower := r.end(k.lower)
+	if lower.pairs == nil {
+		lower.pairs = make(map[pairKey][]*fragment)
+	}
+	lower.pairs[k] = append(lower.pairs[k], f)
+	r.pending[rec.Name] = f
+}
+
+// end returns the end for k, creating it if necessary.
+func (r *Reader) end(k endKey) *end {
+	en, ok := r.ends[k]
+	if !ok {
+		en = &end{key: k}
+		r.ends[k] = en
+		heap.Push(&r.order, en)
+	}
+	return en
+}
+
+// closeBefore closes all ends that cannot gain fragments from records
+// at or after pos on the reference with the given ID.
+func (r *Reader) closeBefore(ref, pos int) {
+	for len(r.order) != 0 {
+		k := r.order[0].key
+		if k.ref > ref || (k.ref == ref && k.pos+r.opts.Window >= pos) {
+			break
+		}
+		r.close(heap.Pop(&r.order).(*end))
+	}
+}
+
+// closeAll closes all open ends.
+func (r *Reader) closeAll() {
+	for len(r.order) != 0 {
+		r.close(heap.Pop(&r.order).(*end))
+	}
+}
+
+// close decides the duplicate state of the fragments of en.
+func (r *Reader) close(en *end) {
+	delete(r.ends, en.key)
+
+	keys := make([]pairKey, 0, len(en.pairs))
+	for k := range en.pairs {
+		keys = append(keys, k)
+	}
+	// Assign bag IDs deterministically.
+	sortPairKeys(keys)
+	for _, k := range keys {
+		r.mark(en.pairs[k], false)
+	}
+	r.mark(en.singles, en.hasPair)
+
+	if len(en.linear) > 1 {
+		b := &bag{id: r.linearBags, size: len(en.linear)}
+		r.linearBags++
+		best := 0
+		for i, f := range en.linear {
+			if f.score > en.linear[best].score {
+				best = i
+			}
+		}
+		for i, f := range en.linear {
+			f.linear = b
+			f.linDup = i != best
+		}
+	}
+	for _, f := range en.linear {
+		f.decided = true
+	}
+}
+
+// mark marks the duplicates in a group of fragments. If allDup is true,
+// all the fragments are marked as duplicates.
+func (r *Reader) mark(frags []*fragment, allDup bool) {
+	if len(frags) == 0 {
+		return
+	}
+	best := 0
+	for i, f := range frags {
+		if f.score > frags[best].score {
+			best = i
+		}
+	}
+	if allDup {
+		best = -1
+	}
+	for i, f := range frags {
+		f.dup = i != best
+	}
+	if len(frags) < 2 {
+		return
+	}
+
+	// Order the retained fragment first so
+	// that optical duplicates are identified
+	// relative to it.
+	ordered := make([]*fragment, 0, len(frags))
+	if best >= 0 {
+		ordered = append(ordered, frags[best])
+	}
+	for i, f := range frags {
+		if i != best {
+			ordered = append(ordered, f)
+		}
+	}
+	optical := 0
+	if d := r.opts.OpticalDistance; d > 0 {
+		for i, f := range ordered {
+			if !f.dup || !f.hasLoc {
+				continue
+			}
+			for _, o := range ordered[:i] {
+				if o.hasLoc && isOptical(f.loc, o.loc, d) {
+					f.optical = true
+					optical++
+					break
+				}
+			}
+		}
+	}
+	b := &bag{id: r.bags, size: len(frags), library: len(frags) - optical}
+	r.bags++
+	for _, f := range frags {
+		f.bag = b
+	}
+}
+
+func isOptical(a, b Location, d int) bool {
+	return a.Group == b.Group && a.Tile == b.Tile && abs(a.X-b.X) <= d && abs(a.Y-b.Y) <= d
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// apply sets the duplicate flag and tags of rec from its fragment.
+func (r *Reader) apply(rec *sam.Record, f *fragment) error {
+	err := samutil.DelAux(rec, diTag, dsTag, dlTag, dtTag, liTag, lsTag, ldTag)
+	if err != nil {
+		return err
+	}
+
+	var fields []auxField
+	if f.dup {
+		rec.Flags |= sam.Duplicate
+		typ := "LB"
+		if f.optical {
+			typ = "SQ"
+		}
+		fields = append(fields, auxField{dtTag, typ})
+	} else {
+		rec.Flags &^= sam.Duplicate
+	}
+	if f.bag != nil {
+		fields = append(fields,
+			auxField{diTag, bagID(f.bag.id)},
+			auxField{dsTag, f.bag.size},
+			auxField{dlTag, f.bag.library},
+		)
+	}
+	if f.linear != nil {
+		state := "primary"
+		if f.linDup {
+			state = "duplicate"
+		}
+		fields = append(fields,
+			auxField{liTag, bagID(f.linear.id)},
+			auxField{lsTag, f.linear.size},
+			auxField{ldTag, state},
+		)
+	}
+	for _, f := range fields {
+		err = samutil.SetAux(rec, f.tag, f.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auxField is an aux tag and value to be set on a record.
+type auxField struct {
+	tag   sam.Tag
+	value interface{}
+}
+
+// score returns the sum of the base qualities of rec that are at least
+// the minimum base quality.
+func (r *Reader) score(rec *sam.Record) int {
+	var s int
+	for _, q := range rec.Qual {
+		if q >= r.opts.MinBaseQ && q != 0xff {
+			s += int(q)
+		}
+	}
+	return s
+}
+
+// fivePrime returns the unclipped 5' position of an alignment at pos
+// with the given CIGAR and strand.
+func fivePrime(pos int, cigar sam.Cigar, rev bool) int {
+	if !rev {
+		for _, co := range cigar {
+			switch co.Type() {
+			case sam.CigarSoftClipped, sam.CigarHardClipped:
+				pos -= co.Len()
+			default:
+				return pos
+			}
+		}
+		return pos
+	}
+	end := pos
+	var clip int
+	for _, co := range cigar {
+		switch co.Type() {
+		case sam.CigarSoftClipped, sam.CigarHardClipped:
+			clip += co.Len()
+		default:
+			clip = 0
+			end += co.Len() * co.Type().Consumes().Reference
+		}
+	}
+	return end + clip - 1
+}
+
+func auxString(rec *sam.Record, t sam.Tag) string {
+	a := rec.AuxTag(t)
+	if a == nil {
+		return ""
+	}
+	s, _ := a.Value().(string)
+	return s
+}
+
+func auxInt(rec *sam.Record, t sam.Tag) (int, bool) {
+	a := rec.AuxTag(t)
+	if a == nil {
+		return 0, false
+	}
+	switch v := a.Value().(type) {
+	case int8:
+		return int(v), true
+	case uint8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case uint16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case uint32:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// bagID returns the aux value of the bag ID id, using a string value if
+// id is out of the range of an int32.
+func bagID(id int) interface{} {
+	if id < math.MinInt32 || math.MaxInt32 < id {
+		return strconv.Itoa(id)
+	}
+	return id
+}
+
+func sortPairKeys(keys []pairKey) {
+	// Insertion sort; the number of distinct pairs
+	// sharing a lower end is small.
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j].upper.less(keys[j-1].upper); j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+}
+
+// endHeap is a min-heap of ends ordered by reference, position and
+// strand. A pair's lower end is therefore closed no later than its
+// other end.
+type endHeap []*end
+
+func (h endHeap) Len() int            { return len(h) }
+func (h endHeap) Less(i, j int) bool  { return h[i].key.less(h[j].key) }
+func (h endHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *endHeap) Push(x interface{}) { *h = append(*h, x.(*end)) }
+func (h *endHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
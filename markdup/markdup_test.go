@@ -0,0 +1,225 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package markdup
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+// seq is the sequence of test records, and qual returns their base
+// qualities, all with the score q.
+var seq = strings.Repeat("A", 50)
+
+func qual(q byte) string { return strings.Repeat(string(rune(q+33)), len(seq)) }
+
+func TestReader(t *testing.T) {
+	const (
+		first  = sam.Paired | sam.Read1 | sam.MateReverse
+		second = sam.Paired | sam.Read2 | sam.Reverse
+	)
+	const (
+		a = "M:1:FC:1:1101:1000:1000"
+		b = "M:1:FC:1:1101:1050:1020"
+		c = "M:1:FC:1:1102:1000:1000"
+		d = "M:1:FC:1:1101:5000:5000"
+		e = "M:1:FC:1:2000:1000:1000"
+		s = "M:1:FC:1:1101:1010:1010"
+		u = "M:1:FC:1:1101:1020:1020"
+	)
+	builder := htstestutil.NewBAMBuilder().Ref("chr1", 10000)
+	builder.Record(a, "chr1", 100, "50M", seq).Qual(qual(30)).Flags(first).Mate("=", 300, 0).Tag("MC", "50M").Tag("DT", "SQ").
+		Record(b, "chr1", 100, "50M", seq).Qual(qual(20)).Flags(first).Mate("=", 300, 0).Tag("MC", "50M").
+		Record(c, "chr1", 100, "50M", seq).Qual(qual(25)).Flags(first).Mate("=", 300, 0).Tag("MC", "50M").
+		Record(d, "chr1", 100, "50M", seq).Qual(qual(30)).Flags(first).Mate("=", 400, 0).Tag("MC", "50M").
+		Record(s, "chr1", 100, "50M", seq).Qual(qual(30)).
+		Record(e, "chr1", 105, "5S45M", seq).Qual(qual(40)).Flags(first).Mate("=", 300, 0).Tag("MC", "50M").
+		Record(a, "chr1", 300, "50M", seq).Qual(qual(30)).Flags(second).Mate("=", 100, 0).Tag("MC", "50M").
+		Record(b, "chr1", 300, "50M", seq).Qual(qual(30)).Flags(second).Mate("=", 100, 0).Tag("MC", "50M").
+		Record(c, "chr1", 300, "50M", seq).Qual(qual(30)).Flags(second).Mate("=", 100, 0).Tag("MC", "50M").
+		Record(e, "chr1", 300, "50M", seq).Qual(qual(30)).Flags(second).Mate("=", 105, 0).Tag("MC", "5S45M").
+		Record(d, "chr1", 400, "50M", seq).Qual(qual(30)).Flags(second).Mate("=", 100, 0).Tag("MC", "50M").
+		Record(u, "chr1", 600, "50M", seq).Qual(qual(30))
+	h, err := builder.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	in, err := builder.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+	want := make([]*sam.Record, len(in))
+	copy(want, in)
+
+	type state struct {
+		dup      bool
+		dupType  sam.DupType
+		size     int
+		library  int
+		linear   sam.LinearDupState
+		linSize  int
+		hasBagID bool
+	}
+	states := map[string]state{
+		a: {dup: true, dupType: sam.DupTypeLB, size: 4, library: 3, linear: sam.LinearDuplicate, linSize: 6, hasBagID: true},
+		b: {dup: true, dupType: sam.DupTypeSQ, size: 4, library: 3, linear: sam.LinearDuplicate, linSize: 6, hasBagID: true},
+		c: {dup: true, dupType: sam.DupTypeLB, size: 4, library: 3, linear: sam.LinearDuplicate, linSize: 6, hasBagID: true},
+		d: {size: -1, library: -1, linear: sam.LinearDuplicate, linSize: 6},
+		e: {size: 4, library: 3, linear: sam.LinearPrimary, linSize: 6, hasBagID: true},
+		s: {dup: true, dupType: sam.DupTypeLB, size: -1, library: -1, linear: sam.LinearDuplicate, linSize: 6},
+		u: {size: -1, library: -1, linSize: -1},
+	}
+
+	src := records(in)
+	r := NewReader(&src, h, Options{})
+	var bagID int64 = -2
+	for i := 0; ; i++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("unexpected number of records: got:%d want:%d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rec != want[i] {
+			t.Fatalf("unexpected record order at %d: got:%s want:%s", i, rec.Name, want[i].Name)
+		}
+		st := states[rec.Name]
+		if got := rec.Flags&sam.Duplicate != 0; got != st.dup {
+			t.Errorf("unexpected duplicate state for %s: got:%t want:%t", rec.Name, got, st.dup)
+		}
+		if got, err := rec.DupType(); err != nil || got != st.dupType {
+			t.Errorf("unexpected duplicate type for %s: got:%v want:%v err:%v", rec.Name, got, st.dupType, err)
+		}
+		if got, err := rec.BagSize(); err != nil || got != st.size {
+			t.Errorf("unexpected bag size for %s: got:%d want:%d err:%v", rec.Name, got, st.size, err)
+		}
+		if got, err := rec.LibraryBagSize(); err != nil || got != st.library {
+			t.Errorf("unexpected library bag size for %s: got:%d want:%d err:%v", rec.Name, got, st.library, err)
+		}
+		if got, err := rec.LinearDup(); err != nil || got != st.linear {
+			t.Errorf("unexpected linear duplicate state for %s: got:%v want:%v err:%v", rec.Name, got, st.linear, err)
+		}
+		if got, err := rec.LinearBagSize(); err != nil || got != st.linSize {
+			t.Errorf("unexpected linear bag size for %s: got:%d want:%d err:%v", rec.Name, got, st.linSize, err)
+		}
+		id, err := rec.BagID()
+		if err != nil {
+			t.Errorf("unexpected error getting bag ID for %s: %v", rec.Name, err)
+		}
+		if (id >= 0) != st.hasBagID {
+			t.Errorf("unexpected bag ID presence for %s: %d", rec.Name, id)
+		}
+		if id >= 0 {
+			if bagID != -2 && id != bagID {
+				t.Errorf("unexpected bag ID for %s: got:%d want:%d", rec.Name, id, bagID)
+			}
+			bagID = id
+		}
+	}
+}
+
+func TestReaderClippedPair(t *testing.T) {
+	// The forward read of y is soft clipped to
+	// sort after its reverse mate, but both
+	// pairs have the same unclipped ends.
+	const (
+		fwd = sam.Paired | sam.Read1 | sam.MateReverse
+		rev = sam.Paired | sam.Read2 | sam.Reverse
+	)
+	builder := htstestutil.NewBAMBuilder().Ref("chr1", 10000)
+	builder.Record("x", "chr1", 100, "50M", seq).Qual(qual(30)).Flags(fwd).Mate("=", 102, 0).Tag("MC", "50M").
+		Record("x", "chr1", 102, "50M", seq).Qual(qual(30)).Flags(rev).Mate("=", 100, 0).Tag("MC", "50M").
+		Record("y", "chr1", 102, "50M", seq).Qual(qual(20)).Flags(rev).Mate("=", 104, 0).Tag("MC", "4S46M").
+		Record("y", "chr1", 104, "4S46M", seq).Qual(qual(20)).Flags(fwd).Mate("=", 102, 0).Tag("MC", "50M")
+	h, err := builder.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	recs, err := builder.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+	src := records(recs)
+	r := NewReader(&src, h, Options{})
+	dups := make(map[string]int)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rec.Flags&sam.Duplicate != 0 {
+			dups[rec.Name]++
+		}
+		if got, err := rec.BagSize(); err != nil || got != 2 {
+			t.Errorf("unexpected bag size for %s: got:%d want:2 err:%v", rec.Name, got, err)
+		}
+	}
+	if len(dups) != 1 || dups["y"] != 2 {
+		t.Errorf("unexpected duplicates: got:%v want:map[y:2]", dups)
+	}
+}
+
+func TestParseIlluminaName(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want Location
+		ok   bool
+	}{
+		{name: "M1:55:FC1:3:1101:15589:1331", want: Location{Group: "M1:55:FC1:3", Tile: 1101, X: 15589, Y: 1331}, ok: true},
+		{name: "HWUSI:6:73:941:1973#0/1", want: Location{Group: "HWUSI:6", Tile: 73, X: 941, Y: 1973}, ok: true},
+		{name: "read1", ok: false},
+		{name: "a:b:c:d:e", ok: false},
+	} {
+		got, ok := ParseIlluminaName(test.name)
+		if ok != test.ok || got != test.want {
+			t.Errorf("unexpected location for %q: got:%+v,%t want:%+v,%t", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestFivePrime(t *testing.T) {
+	for _, test := range []struct {
+		pos   int
+		cigar string
+		rev   bool
+		want  int
+	}{
+		{pos: 100, cigar: "50M", want: 100},
+		{pos: 105, cigar: "2H3S45M", want: 100},
+		{pos: 100, cigar: "50M", rev: true, want: 149},
+		{pos: 100, cigar: "20M5D20M3S2H", rev: true, want: 149},
+		{pos: 100, cigar: "", rev: true, want: 99},
+	} {
+		co, err := sam.ParseCigar([]byte(test.cigar))
+		if err != nil {
+			t.Fatalf("unexpected error parsing cigar: %v", err)
+		}
+		if got := fivePrime(test.pos, co, test.rev); got != test.want {
+			t.Errorf("unexpected 5' position for %d %s rev=%t: got:%d want:%d", test.pos, test.cigar, test.rev, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package picard implements reading and writing of the Picard sequence
+// dictionary (.dict) and interval_list formats used by Picard and GATK.
+//
+// Both formats begin with a SAM header. A sequence dictionary holds
+// only the header, and an interval list follows the header with one
+// interval per line.
+//
+// https://gatk.broadinstitute.org/hc/en-us/articles/360035531852
+package picard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// dictVersion is the SAM version written to dictionaries for headers
+// without a version.
+const dictVersion = "1.6"
+
+// ReadDict reads a sequence dictionary from r and returns it as a SAM
+// header. Any read group, program and comment lines are retained.
+func ReadDict(r io.Reader) (*sam.Header, error) {
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return sam.NewHeader(text, nil)
+}
+
+// WriteDict writes the @HD line and reference sequences of h to w as a
+// sequence dictionary. If h has no version, version 1.6 is written.
+func WriteDict(w io.Writer, h *sam.Header) error {
+	vers := h.Version
+	if vers == "" {
+		vers = dictVersion
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "@HD\tVN:%s", vers)
+	if h.SortOrder != sam.UnknownOrder {
+		fmt.Fprintf(&buf, "\tSO:%s", h.SortOrder)
+	}
+	buf.WriteByte('\n')
+	for _, r := range h.Refs() {
+		fmt.Fprintf(&buf, "%s\n", r)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// NewDict returns a SAM header holding clones of the given references,
+// suitable for writing with WriteDict.
+func NewDict(refs []*sam.Reference) (*sam.Header, error) {
+	clones := make([]*sam.Reference, len(refs))
+	for i, r := range refs {
+		clones[i] = r.Clone()
+	}
+	h, err := sam.NewHeader(nil, clones)
+	if err != nil {
+		return nil, err
+	}
+	h.Version = dictVersion
+	return h, nil
+}
@@ -0,0 +1,253 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package picard
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrFields    = errors.New("picard: invalid number of fields")
+	ErrReference = errors.New("picard: reference not in header")
+)
+
+// Interval is an interval_list interval. Start and End are zero-based
+// half-open coordinates on Ref.
+type Interval struct {
+	Ref        *sam.Reference
+	Start, End int
+
+	// Strand is '+' or '-'.
+	Strand byte
+
+	// Name is the name of the interval. It
+	// is empty if the interval is unnamed.
+	Name string
+}
+
+// Len returns the length of the interval.
+func (iv Interval) Len() int { return iv.End - iv.Start }
+
+// Region returns the interval as a BAM region.
+func (iv Interval) Region() bam.Region {
+	return bam.Region{Ref: iv.Ref, Start: iv.Start, End: iv.End}
+}
+
+// BED returns the interval as a six field BED feature. An unnamed
+// interval is given the name ".".
+func (iv Interval) BED() *bed.Feature {
+	name := iv.Name
+	if name == "" {
+		name = "."
+	}
+	return &bed.Feature{
+		Chrom:  iv.Ref.Name(),
+		Start:  iv.Start,
+		End:    iv.End,
+		Name:   name,
+		Strand: iv.Strand,
+		Fields: 6,
+	}
+}
+
+// IntervalList is a Picard interval list.
+type IntervalList struct {
+	Header    *sam.Header
+	Intervals []Interval
+}
+
+// FromBED returns an IntervalList holding the given BED features on the
+// references of h. BED features without a strand are placed on the
+// forward strand, and BED names of "." are treated as unnamed. It is an
+// error for a feature to be on a chromosome that is not in h or to
+// extend beyond the end of its reference.
+func FromBED(h *sam.Header, features []*bed.Feature) (*IntervalList, error) {
+	refs := refMap(h)
+	l := &IntervalList{Header: h, Intervals: make([]Interval, 0, len(features))}
+	for _, f := range features {
+		ref, ok := refs[f.Chrom]
+		if !ok {
+			return nil, ErrReference
+		}
+		iv := Interval{Ref: ref, Start: f.Start, End: f.End, Strand: '+'}
+		if f.Fields >= 6 && f.Strand == '-' {
+			iv.Strand = '-'
+		}
+		if f.Fields >= 4 && f.Name != "." {
+			iv.Name = f.Name
+		}
+		err := validate(iv)
+		if err != nil {
+			return nil, err
+		}
+		l.Intervals = append(l.Intervals, iv)
+	}
+	return l, nil
+}
+
+// BED returns the intervals of the list as BED features.
+func (l *IntervalList) BED() []*bed.Feature {
+	features := make([]*bed.Feature, len(l.Intervals))
+	for i, iv := range l.Intervals {
+		features[i] = iv.BED()
+	}
+	return features
+}
+
+// Regions returns the intervals of the list as BAM regions, for use
+// with bam.NewRegionIterator.
+func (l *IntervalList) Regions() []bam.Region {
+	regions := make([]bam.Region, len(l.Intervals))
+	for i, iv := range l.Intervals {
+		regions[i] = iv.Region()
+	}
+	return regions
+}
+
+// ReadIntervalList reads an interval list from r. Interval coordinates
+// are converted from the one-based inclusive coordinates of the format
+// to zero-based half-open coordinates.
+func ReadIntervalList(r io.Reader) (*IntervalList, error) {
+	br := bufio.NewReader(r)
+	var (
+		text    []byte
+		l       IntervalList
+		refs    map[string]*sam.Reference
+		line    int
+		inIntvl bool
+	)
+	for {
+		b, err := br.ReadBytes('\n')
+		if len(b) == 0 {
+			if err == nil || err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		line++
+		if !inIntvl && b[0] == '@' {
+			text = append(text, b...)
+			continue
+		}
+		if !inIntvl {
+			inIntvl = true
+			l.Header, err = sam.NewHeader(text, nil)
+			if err != nil {
+				return nil, err
+			}
+			refs = refMap(l.Header)
+		}
+		b = bytes.TrimSuffix(b, []byte{'\n'})
+		b = bytes.TrimSuffix(b, []byte{'\r'})
+		if len(b) == 0 {
+			continue
+		}
+		iv, err := parseInterval(b, refs)
+		if err != nil {
+			return nil, fmt.Errorf("%v at line %d", err, line)
+		}
+		l.Intervals = append(l.Intervals, iv)
+	}
+	if !inIntvl {
+		var err error
+		l.Header, err = sam.NewHeader(text, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &l, nil
+}
+
+func parseInterval(b []byte, refs map[string]*sam.Reference) (Interval, error) {
+	fields := strings.Split(string(b), "\t")
+	if len(fields) != 5 {
+		return Interval{}, ErrFields
+	}
+	ref, ok := refs[fields[0]]
+	if !ok {
+		return Interval{}, ErrReference
+	}
+	iv := Interval{Ref: ref}
+	start, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Interval{}, fmt.Errorf("picard: invalid start: %v", err)
+	}
+	iv.Start = start - 1
+	iv.End, err = strconv.Atoi(fields[2])
+	if err != nil {
+		return Interval{}, fmt.Errorf("picard: invalid end: %v", err)
+	}
+	switch s := fields[3]; s {
+	case "+", "-":
+		iv.Strand = s[0]
+	default:
+		return Interval{}, fmt.Errorf("picard: invalid strand: %q", s)
+	}
+	if fields[4] != "." {
+		iv.Name = fields[4]
+	}
+	return iv, validate(iv)
+}
+
+// validate returns an error if iv is not a valid interval on its
+// reference.
+func validate(iv Interval) error {
+	if iv.Start < 0 || iv.End < iv.Start || iv.End > iv.Ref.Len() {
+		return fmt.Errorf("picard: invalid interval %s:[%d,%d)", iv.Ref.Name(), iv.Start, iv.End)
+	}
+	return nil
+}
+
+// WriteIntervalList writes l to w. The header of l is written in full
+// and must hold the references of all the intervals.
+func WriteIntervalList(w io.Writer, l *IntervalList) error {
+	text, err := l.Header.MarshalText()
+	if err != nil {
+		return err
+	}
+	refs := refMap(l.Header)
+	bw := bufio.NewWriter(w)
+	bw.Write(text)
+	for _, iv := range l.Intervals {
+		if iv.Ref == nil || refs[iv.Ref.Name()] != iv.Ref {
+			return ErrReference
+		}
+		err = validate(iv)
+		if err != nil {
+			return err
+		}
+		strand := iv.Strand
+		if strand != '-' {
+			strand = '+'
+		}
+		name := iv.Name
+		if name == "" {
+			name = "."
+		}
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%c\t%s\n", iv.Ref.Name(), iv.Start+1, iv.End, strand, name)
+	}
+	return bw.Flush()
+}
+
+func refMap(h *sam.Header) map[string]*sam.Reference {
+	refs := make(map[string]*sam.Reference, len(h.Refs()))
+	for _, r := range h.Refs() {
+		refs[r.Name()] = r
+	}
+	return refs
+}
@@ -0,0 +1,161 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package picard
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+const testDict = `@HD	VN:1.6
+@SQ	SN:chr1	LN:1000	M5:0123456789abcdef0123456789abcdef	UR:file:/ref.fa
+@SQ	SN:chr2	LN:500
+`
+
+func TestDict(t *testing.T) {
+	h, err := ReadDict(strings.NewReader(testDict))
+	if err != nil {
+		t.Fatalf("unexpected error reading dict: %v", err)
+	}
+	if len(h.Refs()) != 2 || h.Refs()[0].Name() != "chr1" || h.Refs()[1].Len() != 500 {
+		t.Errorf("unexpected references: %v", h.Refs())
+	}
+	var buf bytes.Buffer
+	err = WriteDict(&buf, h)
+	if err != nil {
+		t.Fatalf("unexpected error writing dict: %v", err)
+	}
+	if buf.String() != testDict {
+		t.Errorf("unexpected dict:\ngot:\n%s\nwant:\n%s", &buf, testDict)
+	}
+
+	d, err := NewDict(h.Refs())
+	if err != nil {
+		t.Fatalf("unexpected error making dict: %v", err)
+	}
+	buf.Reset()
+	err = WriteDict(&buf, d)
+	if err != nil {
+		t.Fatalf("unexpected error writing dict: %v", err)
+	}
+	if buf.String() != testDict {
+		t.Errorf("unexpected dict:\ngot:\n%s\nwant:\n%s", &buf, testDict)
+	}
+}
+
+const testIntervals = `@HD	VN:1.6	SO:coordinate
+@SQ	SN:chr1	LN:1000	M5:0123456789abcdef0123456789abcdef	UR:file:/ref.fa
+@SQ	SN:chr2	LN:500
+chr1	1	100	+	first
+chr1	200	300	-	.
+chr2	10	500	+	third
+`
+
+type interval struct {
+	ref        string
+	start, end int
+	strand     byte
+	name       string
+}
+
+func intervals(l *IntervalList) []interval {
+	var ivs []interval
+	for _, iv := range l.Intervals {
+		ivs = append(ivs, interval{iv.Ref.Name(), iv.Start, iv.End, iv.Strand, iv.Name})
+	}
+	return ivs
+}
+
+func TestIntervalList(t *testing.T) {
+	l, err := ReadIntervalList(strings.NewReader(testIntervals))
+	if err != nil {
+		t.Fatalf("unexpected error reading interval list: %v", err)
+	}
+	want := []interval{
+		{"chr1", 0, 100, '+', "first"},
+		{"chr1", 199, 300, '-', ""},
+		{"chr2", 9, 500, '+', "third"},
+	}
+	if got := intervals(l); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected intervals:\ngot: %v\nwant:%v", got, want)
+	}
+	var buf bytes.Buffer
+	err = WriteIntervalList(&buf, l)
+	if err != nil {
+		t.Fatalf("unexpected error writing interval list: %v", err)
+	}
+	if buf.String() != testIntervals {
+		t.Errorf("unexpected interval list:\ngot:\n%s\nwant:\n%s", &buf, testIntervals)
+	}
+
+	regions := l.Regions()
+	if len(regions) != 3 || regions[1].Ref != l.Header.Refs()[0] || regions[1].Start != 199 || regions[1].End != 300 {
+		t.Errorf("unexpected regions: %v", regions)
+	}
+
+	features := l.BED()
+	var bedText []string
+	for _, f := range features {
+		b, err := f.MarshalText()
+		if err != nil {
+			t.Fatalf("unexpected error marshaling BED: %v", err)
+		}
+		bedText = append(bedText, string(b))
+	}
+	wantBED := []string{
+		"chr1\t0\t100\tfirst\t0\t+",
+		"chr1\t199\t300\t.\t0\t-",
+		"chr2\t9\t500\tthird\t0\t+",
+	}
+	if !reflect.DeepEqual(bedText, wantBED) {
+		t.Errorf("unexpected BED:\ngot: %q\nwant:%q", bedText, wantBED)
+	}
+
+	back, err := FromBED(l.Header, features)
+	if err != nil {
+		t.Fatalf("unexpected error converting from BED: %v", err)
+	}
+	if got := intervals(back); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected intervals from BED:\ngot: %v\nwant:%v", got, want)
+	}
+	_, err = FromBED(l.Header, []*bed.Feature{{Chrom: "chr3", End: 10, Fields: 3}})
+	if err != ErrReference {
+		t.Errorf("unexpected error for missing reference: %v", err)
+	}
+}
+
+func TestIntervalListErrors(t *testing.T) {
+	for _, test := range []string{
+		"chr1\t1\t100\t+",
+		"chr3\t1\t100\t+\t.",
+		"chr1\t0\t100\t+\t.",
+		"chr1\t1\t1001\t+\t.",
+		"chr1\t1\t100\t.\t.",
+		"chr1\tx\t100\t+\t.",
+	} {
+		_, err := ReadIntervalList(strings.NewReader(testDict + test + "\n"))
+		if err == nil || !strings.HasSuffix(err.Error(), "at line 4") {
+			t.Errorf("unexpected error for %q: %v", test, err)
+		}
+	}
+
+	h, err := ReadDict(strings.NewReader(testDict))
+	if err != nil {
+		t.Fatalf("unexpected error reading dict: %v", err)
+	}
+	other, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error making reference: %v", err)
+	}
+	err = WriteIntervalList(&bytes.Buffer{}, &IntervalList{Header: h, Intervals: []Interval{{Ref: other, End: 10}}})
+	if err != ErrReference {
+		t.Errorf("unexpected error for foreign reference: %v", err)
+	}
+}
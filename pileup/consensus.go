@@ -0,0 +1,227 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pileup
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Schaudge/hts/fastq"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrInvalidRegion = errors.New("pileup: invalid consensus region")
+)
+
+// MaxConsensusQual is the largest quality score of consensus bases.
+const MaxConsensusQual = 93
+
+// Method is a consensus base calling method.
+type Method int
+
+const (
+	Majority        Method = iota // The most frequent base is called.
+	QualityWeighted               // The base with the greatest sum of base qualities is called.
+)
+
+// ConsensusOptions specifies how consensus bases are called.
+type ConsensusOptions struct {
+	// Method is the base calling method.
+	Method Method
+
+	// MinDepth is the minimum number of
+	// bases at a position for a base to be
+	// called. Positions with fewer bases
+	// are called N. If MinDepth is less
+	// than one, one base is required.
+	MinDepth int
+
+	// MinFraction is the minimum fraction
+	// of the bases, or of the base quality
+	// sum for QualityWeighted, that must
+	// support the called base. Positions
+	// without sufficient support are
+	// called N.
+	MinFraction float64
+
+	// Deletions specifies that positions
+	// where deletions outnumber bases are
+	// omitted from the consensus.
+	Deletions bool
+
+	// Insertions specifies that insertions
+	// held by more than half of the records
+	// covering a position are included in
+	// the consensus.
+	Insertions bool
+}
+
+// Consensus returns the haploid consensus sequence of the zero-based
+// half-open region [start, end) of ref called from the columns of p.
+// Columns are read from p until one lies beyond the region, so p
+// should be restricted to the region by its options if it is reused.
+//
+// The returned record is named for the region in samtools region
+// notation. Positions without columns are called N. The quality of a
+// called base is the sum of the qualities of the bases supporting it
+// less the sum of the qualities of other bases, clamped to the range
+// zero to MaxConsensusQual. N calls have quality zero.
+func Consensus(p *Pileup, ref *sam.Reference, start, end int, opts ConsensusOptions) (*fastq.Record, error) {
+	if ref == nil || start < 0 || end <= start || end > ref.Len() {
+		return nil, ErrInvalidRegion
+	}
+	if opts.MinDepth < 1 {
+		opts.MinDepth = 1
+	}
+	rec := &fastq.Record{Name: fmt.Sprintf("%s:%d-%d", ref.Name(), start+1, end)}
+	pos := start
+	for p.Next() {
+		col := p.Column()
+		if col.Ref.ID() < ref.ID() || (col.Ref == ref && col.Pos < start) {
+			continue
+		}
+		if col.Ref != ref || col.Pos >= end {
+			break
+		}
+		for ; pos < col.Pos; pos++ {
+			rec.Seq = append(rec.Seq, 'N')
+			rec.Qual = append(rec.Qual, 0)
+		}
+		callColumn(rec, col, opts)
+		pos++
+	}
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+	for ; pos < end; pos++ {
+		rec.Seq = append(rec.Seq, 'N')
+		rec.Qual = append(rec.Qual, 0)
+	}
+	return rec, nil
+}
+
+// callColumn appends the consensus of col to rec.
+func callColumn(rec *fastq.Record, col *Column, opts ConsensusOptions) {
+	var (
+		counts  [4]int
+		weights [4]int
+		depth   int
+		dels    int
+	)
+	for _, e := range col.Entries {
+		switch {
+		case e.IsDel:
+			dels++
+		case e.IsRefSkip:
+		default:
+			depth++
+			if i := baseIndex(e.Base); i >= 0 {
+				counts[i]++
+				weights[i] += qual(e.Qual)
+			}
+		}
+	}
+	if opts.Deletions && dels > depth {
+		return
+	}
+
+	support := counts
+	if opts.Method == QualityWeighted {
+		support = weights
+	}
+	best, total := 0, 0
+	for i, n := range support {
+		total += n
+		if n > support[best] {
+			best = i
+		}
+	}
+	if depth < opts.MinDepth || support[best] == 0 || float64(support[best]) < opts.MinFraction*float64(total) {
+		rec.Seq = append(rec.Seq, 'N')
+		rec.Qual = append(rec.Qual, 0)
+	} else {
+		var sum int
+		for _, w := range weights {
+			sum += w
+		}
+		rec.Seq = append(rec.Seq, "ACGT"[best])
+		rec.Qual = append(rec.Qual, clampQual(2*weights[best]-sum))
+	}
+
+	if opts.Insertions {
+		callInsertion(rec, col)
+	}
+}
+
+// callInsertion appends the insertion following col held by more than
+// half of the records of the column to rec.
+func callInsertion(rec *fastq.Record, col *Column) {
+	var n int
+	seqs := make(map[string]int)
+	for _, e := range col.Entries {
+		if e.IsRefSkip {
+			continue
+		}
+		n++
+		if ins := e.Inserted(); ins != nil {
+			seqs[string(ins)]++
+		}
+	}
+	var ins string
+	for s, c := range seqs {
+		if 2*c > n {
+			ins = s
+		}
+	}
+	if ins == "" {
+		return
+	}
+	for i := range ins {
+		var sum int
+		for _, e := range col.Entries {
+			if e.IsRefSkip {
+				continue
+			}
+			q := e.Qual
+			if e.Indel <= 0 || string(e.Inserted()) != ins {
+				sum -= qual(q)
+				continue
+			}
+			if j := e.Offset + 1 + i; j < len(e.Record.Qual) {
+				q = e.Record.Qual[j]
+			}
+			sum += qual(q)
+		}
+		rec.Seq = append(rec.Seq, ins[i])
+		rec.Qual = append(rec.Qual, clampQual(sum))
+	}
+}
+
+// baseIndex returns the index of b in ACGT, or -1 if b is not a
+// nucleotide.
+func baseIndex(b byte) int {
+	switch b &^ 0x20 {
+	case 'A':
+		return 0
+	case 'C':
+		return 1
+	case 'G':
+		return 2
+	case 'T':
+		return 3
+	}
+	return -1
+}
+
+func clampQual(q int) byte {
+	switch {
+	case q < 0:
+		return 0
+	case q > MaxConsensusQual:
+		return MaxConsensusQual
+	}
+	return byte(q)
+}
@@ -0,0 +1,119 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pileup
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+)
+
+func TestConsensus(t *testing.T) {
+	h := testHeader(t)
+	chr1 := h.Refs()[0]
+	amplicon := func() records {
+		b := htstestutil.NewBAMBuilder()
+		b.Record("a", "chr1", 2, "6M", "ACGTAC").Qual(testQual[:6]).MapQ(60).
+			Record("b", "chr1", 2, "3M2I3M", "ACGTTTAC").Qual(testQual[:8]).MapQ(60).
+			Record("c", "chr1", 3, "2M1D3M", "CGACA").Qual(testQual[:5]).MapQ(60).
+			Record("d", "chr1", 3, "5M", "CTTAC").Qual(testQual[:5]).MapQ(60).
+			Record("e", "chr2", 3, "5M", "CTTAC").Qual(testQual[:5]).MapQ(60)
+		return newRecords(t, b, h)
+	}
+	indels := func() records {
+		b := htstestutil.NewBAMBuilder()
+		b.Record("a", "chr1", 0, "3M1I1M", "ACGAT").Qual(testQual[:5]).MapQ(60).
+			Record("b", "chr1", 0, "3M1I1M", "ACGAT").Qual(testQual[:5]).MapQ(60).
+			Record("c", "chr1", 0, "1M1D1M1I1M", "AGAT").Qual(testQual[:4]).MapQ(60).
+			Record("d", "chr1", 0, "1M1D1M1I1M", "AGAT").Qual(testQual[:4]).MapQ(60).
+			Record("e", "chr1", 0, "1M1D1M1I1M", "AGAT").Qual(testQual[:4]).MapQ(60)
+		return newRecords(t, b, h)
+	}
+	weighted := func() records {
+		// Base qualities are 10, 10 and 40.
+		b := htstestutil.NewBAMBuilder()
+		b.Record("a", "chr1", 0, "1M", "A").Qual("+").MapQ(60).
+			Record("b", "chr1", 0, "1M", "A").Qual("+").MapQ(60).
+			Record("c", "chr1", 0, "1M", "C").Qual("I").MapQ(60)
+		return newRecords(t, b, h)
+	}
+
+	for _, test := range []struct {
+		name       string
+		recs       records
+		start, end int
+		opts       ConsensusOptions
+
+		wantSeq  string
+		wantQual []byte
+	}{
+		{
+			name: "majority", recs: amplicon(), start: 0, end: 12,
+			wantSeq:  "NNACGTACANNN",
+			wantQual: []byte{0, 0, 60, 93, 64, 93, 93, 93, 34, 0, 0, 0},
+		},
+		{
+			name: "subregion", recs: amplicon(), start: 4, end: 6,
+			wantSeq:  "GT",
+			wantQual: []byte{64, 93},
+		},
+		{
+			name: "min depth", recs: amplicon(), start: 0, end: 12,
+			opts:     ConsensusOptions{MinDepth: 2},
+			wantSeq:  "NNACGTACNNNN",
+			wantQual: []byte{0, 0, 60, 93, 64, 93, 93, 93, 0, 0, 0, 0},
+		},
+		{
+			name: "min fraction", recs: amplicon(), start: 2, end: 6,
+			opts:     ConsensusOptions{MinFraction: 0.8},
+			wantSeq:  "ACNT",
+			wantQual: []byte{60, 93, 0, 93},
+		},
+		{
+			name: "gaps included", recs: indels(), start: 0, end: 4,
+			wantSeq:  "ACGT",
+			wantQual: []byte{93, 62, 93, 93},
+		},
+		{
+			name: "gaps applied", recs: indels(), start: 0, end: 4,
+			opts:     ConsensusOptions{Deletions: true, Insertions: true},
+			wantSeq:  "AGAT",
+			wantQual: []byte{93, 93, 93, 93},
+		},
+		{
+			name: "majority quality", recs: weighted(), start: 0, end: 1,
+			wantSeq:  "A",
+			wantQual: []byte{0},
+		},
+		{
+			name: "quality weighted", recs: weighted(), start: 0, end: 1,
+			opts:     ConsensusOptions{Method: QualityWeighted},
+			wantSeq:  "C",
+			wantQual: []byte{20},
+		},
+	} {
+		p := New(&test.recs, Options{})
+		got, err := Consensus(p, chr1, test.start, test.end, test.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if string(got.Seq) != test.wantSeq || !reflect.DeepEqual(got.Qual, test.wantQual) {
+			t.Errorf("%s: unexpected consensus:\ngot: %s %v\nwant:%s %v", test.name, got.Seq, got.Qual, test.wantSeq, test.wantQual)
+		}
+	}
+
+	recs := amplicon()
+	if _, err := Consensus(New(&recs, Options{}), chr1, 10, 200, ConsensusOptions{}); err != ErrInvalidRegion {
+		t.Errorf("unexpected error for invalid region: %v", err)
+	}
+	got, err := Consensus(New(&recs, Options{}), chr1, 2, 4, ConsensusOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "chr1:3-4" {
+		t.Errorf("unexpected consensus name: got:%q want:%q", got.Name, "chr1:3-4")
+	}
+}
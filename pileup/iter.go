@@ -0,0 +1,25 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package pileup
+
+import "iter"
+
+// All returns an iterator over the remaining columns of the Pileup. An
+// error is yielded with a nil column and ends the iteration. Each
+// column is only valid until the iteration continues.
+func (p *Pileup) All() iter.Seq2[*Column, error] {
+	return func(yield func(*Column, error) bool) {
+		for p.Next() {
+			if !yield(p.Column(), nil) {
+				return
+			}
+		}
+		if err := p.Error(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
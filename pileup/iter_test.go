@@ -0,0 +1,71 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package pileup
+
+import (
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+)
+
+func TestPileupAll(t *testing.T) {
+	h := testHeader(t)
+	b := htstestutil.NewBAMBuilder()
+	b.Record("r0", "chr1", 2, "4M", "ACGT").Qual(testQual[:4]).MapQ(60)
+	recs := newRecords(t, b, h)
+
+	src := recs
+	var got []int
+	for c, err := range New(&src, Options{}).All() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, c.Pos)
+	}
+	if len(got) != 4 || got[0] != 2 || got[3] != 5 {
+		t.Errorf("unexpected column positions: got:%v want:[2 3 4 5]", got)
+	}
+
+	// Stopping an iteration leaves the
+	// remaining columns to be read.
+	src = recs
+	p := New(&src, Options{})
+	for c, err := range p.All() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Pos != 2 {
+			t.Errorf("unexpected first column position: got:%d want:2", c.Pos)
+		}
+		break
+	}
+	if !p.Next() {
+		t.Fatalf("unexpected end of pileup after stopped iteration: %v", p.Error())
+	}
+	if pos := p.Column().Pos; pos != 3 {
+		t.Errorf("unexpected column position after stopped iteration: got:%d want:3", pos)
+	}
+
+	// An error is yielded with a nil
+	// column and ends the iteration.
+	b = htstestutil.NewBAMBuilder()
+	b.Record("r0", "chr1", 5, "2M", "AC").Qual(testQual[:2]).MapQ(60).
+		Record("r1", "chr1", 2, "2M", "GT").Qual(testQual[:2]).MapQ(60)
+	src = newRecords(t, b, h)
+	var errs []error
+	for c, err := range New(&src, Options{}).All() {
+		if err != nil {
+			if c != nil {
+				t.Errorf("unexpected non-nil column with error: %+v", c)
+			}
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 1 || errs[0] != ErrUnsorted {
+		t.Errorf("unexpected errors for unsorted input: got:%v want:[%v]", errs, ErrUnsorted)
+	}
+}
@@ -0,0 +1,497 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pileup implements construction of per-position pileup columns
+// from coordinate sorted alignment records.
+//
+// A Pileup reads records from a Source and returns a Column for each
+// reference position covered by at least one record, in order. Each
+// column holds an Entry for every record covering the position,
+// describing the aligned base and any following indel.
+//
+//	p := pileup.New(r, pileup.Options{MinMapQ: 20, Exclude: pileup.DefaultExclude})
+//	for p.Next() {
+//		col := p.Column()
+//		fmt.Println(col.Ref.Name(), col.Pos, len(col.Entries))
+//	}
+//	return p.Error()
+//
+// Consensus calls a haploid consensus sequence for a region from the
+// columns of a Pileup.
+package pileup
+
+import (
+	"errors"
+	"io"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrUnsorted = errors.New("pileup: records not coordinate sorted")
+)
+
+// DefaultExclude is the set of flags of records excluded from
+// pileups by samtools mpileup.
+const DefaultExclude = sam.Unmapped | sam.Secondary | sam.QCFail | sam.Duplicate
+
+// Source is a source of coordinate sorted records. bam.Reader,
+// cram.Reader and sam.Reader satisfy Source.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Iterator is a record iterator such as a bam.Iterator or
+// cram.Iterator.
+type Iterator interface {
+	Next() bool
+	Record() *sam.Record
+	Error() error
+}
+
+// FromIterator returns a Source reading records from it, allowing
+// pileups to be built over index queries.
+func FromIterator(it Iterator) Source { return iterSource{it} }
+
+type iterSource struct{ it Iterator }
+
+func (s iterSource) Read() (*sam.Record, error) {
+	if s.it.Next() {
+		return s.it.Record(), nil
+	}
+	err := s.it.Error()
+	if err == nil {
+		err = io.EOF
+	}
+	return nil, err
+}
+
+// Options specifies the records and bases included in a pileup.
+type Options struct {
+	// Exclude specifies flags of records
+	// that are excluded.
+	Exclude sam.Flags
+
+	// MinMapQ is the minimum mapping
+	// quality of included records.
+	MinMapQ byte
+
+	// MinBaseQ is the minimum quality of
+	// included aligned bases. Deletions and
+	// reference skips are not filtered by
+	// base quality.
+	MinBaseQ byte
+
+	// Filter, if not nil, is called for each
+	// record passing the flag and mapping
+	// quality filters, and the record is
+	// excluded if it returns false.
+	Filter func(*sam.Record) bool
+
+	// MateOverlap specifies that where both
+	// reads of a pair cover a position, only
+	// one entry is included in the column so
+	// that each base of the sequenced fragment
+	// is counted once. The entry with an
+	// aligned base is preferred over a deletion
+	// or reference skip, and otherwise the entry
+	// with the higher base quality is retained.
+	MateOverlap bool
+
+	// MaxDepth is the maximum number of
+	// records in a pileup. A record is not
+	// added if the number of records covering
+	// its start position has reached MaxDepth.
+	// If MaxDepth is zero, depth is not limited.
+	MaxDepth int
+
+	// Region, if not nil, restricts the
+	// returned columns to the region.
+	Region *bam.Region
+}
+
+// Column is a pileup column.
+type Column struct {
+	Ref *sam.Reference
+	Pos int
+
+	// Entries holds the entries of the
+	// records covering the position in
+	// the order the records were read.
+	Entries []Entry
+}
+
+// Depth returns the number of entries in the column that are not
+// deletions or reference skips.
+func (c *Column) Depth() int {
+	var n int
+	for _, e := range c.Entries {
+		if !e.IsDel && !e.IsRefSkip {
+			n++
+		}
+	}
+	return n
+}
+
+// Entry describes the alignment of a record at a pileup position.
+type Entry struct {
+	Record *sam.Record
+
+	// Offset is the query position of the
+	// aligned base. For deletions and
+	// reference skips, it is the query
+	// position of the next aligned base.
+	Offset int
+
+	// Base and Qual are the aligned base
+	// and its quality. They are zero for
+	// deletions and reference skips. Base
+	// is zero and Qual is 0xff if the
+	// record has no sequence or quality.
+	Base byte
+	Qual byte
+
+	// IsDel and IsRefSkip are whether the
+	// position is deleted or skipped in the
+	// alignment of the record.
+	IsDel     bool
+	IsRefSkip bool
+
+	// Indel is the length of an insertion
+	// following the position if positive, or
+	// of a deletion following the position if
+	// negative.
+	Indel int
+
+	// IsHead and IsTail are whether the
+	// position is the first or last aligned
+	// reference position of the record.
+	IsHead bool
+	IsTail bool
+}
+
+// Reverse returns whether the record of the entry is aligned to the
+// reverse strand.
+func (e *Entry) Reverse() bool { return e.Record.Flags&sam.Reverse != 0 }
+
+// Inserted returns the bases inserted after the position, or nil if
+// there is no insertion.
+func (e *Entry) Inserted() []byte {
+	if e.Indel <= 0 || e.Record.Seq.Length == 0 {
+		return nil
+	}
+	ins := make([]byte, e.Indel)
+	for i := range ins {
+		ins[i] = e.Record.Seq.BaseChar(e.Offset + 1 + i)
+	}
+	return ins
+}
+
+// Pileup builds pileup columns from a Source.
+type Pileup struct {
+	src  Source
+	opts Options
+
+	next *sam.Record
+	done bool
+	err  error
+
+	ref    *sam.Reference
+	pos    int
+	active []*cursor
+	free   []*cursor
+
+	// mates holds the column index of entries
+	// of paired records by read name when
+	// resolving mate overlaps.
+	mates map[string]int
+
+	col Column
+}
+
+// New returns a Pileup building columns from the records read from src
+// according to opts.
+func New(src Source, opts Options) *Pileup {
+	return &Pileup{src: src, opts: opts}
+}
+
+// Next advances the Pileup to the next column, which will then be
+// available through the Column method. It returns false when there are
+// no more columns or an error occurred. After Next returns false, the
+// Error method returns any error that occurred.
+func (p *Pileup) Next() bool {
+	for p.err == nil {
+		if len(p.active) == 0 {
+			if !p.peek() {
+				return false
+			}
+			p.ref, p.pos = p.next.Ref, p.next.Pos
+		}
+		for p.peek() && p.next.Ref == p.ref && p.next.Pos == p.pos {
+			p.add(p.next)
+			p.next = nil
+		}
+		if p.err != nil {
+			return false
+		}
+		p.column()
+		p.pos++
+		p.retire()
+		if p.inRegion() {
+			return true
+		}
+	}
+	return false
+}
+
+// Column returns the current column. The column is only valid until
+// the next call to Next.
+func (p *Pileup) Column() *Column { return &p.col }
+
+// Error returns the first error that occurred during pileup
+// construction.
+func (p *Pileup) Error() error { return p.err }
+
+// inRegion returns whether the current column is within the region
+// specified by the options.
+func (p *Pileup) inRegion() bool {
+	r := p.opts.Region
+	return r == nil || (p.col.Ref == r.Ref && r.Start <= p.col.Pos && p.col.Pos < r.End)
+}
+
+// peek ensures that the next included record is available in p.next,
+// returning false if there are no more records.
+func (p *Pileup) peek() bool {
+	for p.next == nil && !p.done && p.err == nil {
+		rec, err := p.src.Read()
+		if err != nil {
+			if err == io.EOF {
+				p.done = true
+			} else {
+				p.err = err
+			}
+			break
+		}
+		if !p.include(rec) {
+			continue
+		}
+		if p.ref != nil && (rec.Ref.ID() < p.ref.ID() || (rec.Ref == p.ref && rec.Pos < p.pos)) {
+			p.err = ErrUnsorted
+			break
+		}
+		p.next = rec
+	}
+	return p.next != nil
+}
+
+// include returns whether rec passes the record filters.
+func (p *Pileup) include(rec *sam.Record) bool {
+	if rec.Flags&p.opts.Exclude != 0 || rec.Flags&sam.Unmapped != 0 || rec.Ref == nil {
+		return false
+	}
+	if rec.MapQ < p.opts.MinMapQ || rec.End() <= rec.Pos {
+		return false
+	}
+	for _, co := range rec.Cigar {
+		if co.Type() == sam.CigarBack {
+			return false
+		}
+	}
+	if r := p.opts.Region; r != nil && (rec.Ref != r.Ref || rec.End() <= r.Start || rec.Pos >= r.End) {
+		return false
+	}
+	return p.opts.Filter == nil || p.opts.Filter(rec)
+}
+
+// add adds rec to the active records if the depth limit allows.
+func (p *Pileup) add(rec *sam.Record) {
+	if p.opts.MaxDepth > 0 && len(p.active) >= p.opts.MaxDepth {
+		return
+	}
+	var c *cursor
+	if n := len(p.free); n != 0 {
+		c = p.free[n-1]
+		p.free = p.free[:n-1]
+	} else {
+		c = &cursor{}
+	}
+	c.reset(rec)
+	p.active = append(p.active, c)
+}
+
+// retire removes active records that end before the current position.
+func (p *Pileup) retire() {
+	active := p.active[:0]
+	for _, c := range p.active {
+		if c.end > p.pos {
+			active = append(active, c)
+		} else {
+			c.rec = nil
+			p.free = append(p.free, c)
+		}
+	}
+	for i := len(active); i < len(p.active); i++ {
+		p.active[i] = nil
+	}
+	p.active = active
+}
+
+// column fills the current column from the active records.
+func (p *Pileup) column() {
+	p.col.Ref = p.ref
+	p.col.Pos = p.pos
+	p.col.Entries = p.col.Entries[:0]
+	for _, c := range p.active {
+		e, ok := c.entry(p.pos)
+		if !ok {
+			continue
+		}
+		if !e.IsDel && !e.IsRefSkip && e.Qual < p.opts.MinBaseQ {
+			continue
+		}
+		p.col.Entries = append(p.col.Entries, e)
+	}
+	if p.opts.MateOverlap {
+		p.resolveOverlaps()
+	}
+}
+
+// resolveOverlaps removes the entries of the current column that
+// duplicate the position of a mate with a preferred entry.
+func (p *Pileup) resolveOverlaps() {
+	if p.mates == nil {
+		p.mates = make(map[string]int)
+	}
+	entries := p.col.Entries
+	drop := false
+	for i := range entries {
+		rec := entries[i].Record
+		if rec.Flags&(sam.Paired|sam.Secondary|sam.Supplementary) != sam.Paired {
+			continue
+		}
+		j, ok := p.mates[rec.Name]
+		if !ok {
+			p.mates[rec.Name] = i
+			continue
+		}
+		if preferred(&entries[i], &entries[j]) {
+			entries[j] = entries[i]
+		}
+		entries[i].Record = nil
+		drop = true
+	}
+	for k := range p.mates {
+		delete(p.mates, k)
+	}
+	if !drop {
+		return
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Record != nil {
+			kept = append(kept, e)
+		}
+	}
+	p.col.Entries = kept
+}
+
+// preferred returns whether the entry a is preferred over the entry b
+// of its mate.
+func preferred(a, b *Entry) bool {
+	aGap, bGap := a.IsDel || a.IsRefSkip, b.IsDel || b.IsRefSkip
+	if aGap != bGap {
+		return bGap
+	}
+	return qual(a.Qual) > qual(b.Qual)
+}
+
+// qual returns q, or zero if q is the missing quality value.
+func qual(q byte) int {
+	if q == 0xff {
+		return 0
+	}
+	return int(q)
+}
+
+// cursor tracks the position of a pileup within the alignment of a
+// record.
+type cursor struct {
+	rec *sam.Record
+	end int
+
+	// op is the index of the current CIGAR
+	// operation, and ref and query are the
+	// reference and query positions at the
+	// start of the operation.
+	op         int
+	ref, query int
+}
+
+func (c *cursor) reset(rec *sam.Record) {
+	*c = cursor{rec: rec, end: rec.End(), ref: rec.Pos}
+}
+
+// entry returns the entry for the record at the reference position
+// pos, which must not be less than the position of any previous call.
+func (c *cursor) entry(pos int) (Entry, bool) {
+	cigar := c.rec.Cigar
+	for c.op < len(cigar) {
+		co := cigar[c.op]
+		con := co.Type().Consumes()
+		n := co.Len()
+		if con.Reference != 0 && pos < c.ref+n {
+			break
+		}
+		c.ref += n * con.Reference
+		c.query += n * con.Query
+		c.op++
+	}
+	if c.op == len(cigar) || pos < c.ref {
+		return Entry{}, false
+	}
+	co := cigar[c.op]
+	e := Entry{
+		Record: c.rec,
+		IsHead: pos == c.rec.Pos,
+		IsTail: pos == c.end-1,
+	}
+	switch co.Type() {
+	case sam.CigarDeletion:
+		e.IsDel = true
+		e.Offset = c.query
+	case sam.CigarSkipped:
+		e.IsRefSkip = true
+		e.Offset = c.query
+	default:
+		e.Offset = c.query + pos - c.ref
+		e.Qual = 0xff
+		if e.Offset < c.rec.Seq.Length {
+			e.Base = c.rec.Seq.BaseChar(e.Offset)
+		}
+		if e.Offset < len(c.rec.Qual) {
+			e.Qual = c.rec.Qual[e.Offset]
+		}
+	}
+	if pos == c.ref+co.Len()-1 {
+		// Look for an indel following the
+		// last position of the operation.
+	loop:
+		for _, next := range cigar[c.op+1:] {
+			switch next.Type() {
+			case sam.CigarInsertion:
+				e.Indel = next.Len()
+				break loop
+			case sam.CigarDeletion:
+				e.Indel = -next.Len()
+				break loop
+			case sam.CigarPadded:
+				continue
+			default:
+				break loop
+			}
+		}
+	}
+	return e, true
+}
@@ -0,0 +1,245 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pileup
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	_ Source   = (*bam.Reader)(nil)
+	_ Source   = (*sam.Reader)(nil)
+	_ Iterator = (*bam.Iterator)(nil)
+)
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+// testQual holds the SAM encoding of the base qualities 30, 31, 32 and
+// so on, for slicing to the length of test record sequences.
+const testQual = "?@ABCDEF"
+
+// testHeader returns a header with the references chr1 and chr2.
+func testHeader(t *testing.T) *sam.Header {
+	t.Helper()
+	h, err := htstestutil.NewBAMBuilder().Ref("chr1", 100).Ref("chr2", 100).Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	return h
+}
+
+// newRecords returns the records of b using the references of h.
+func newRecords(t *testing.T, b *htstestutil.BAMBuilder, h *sam.Header) records {
+	t.Helper()
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+	return recs
+}
+
+// render returns a compact description of each column: the position
+// followed by the bases of the column, with '*' for deletions, '>' for
+// reference skips and indels following the base.
+func render(p *Pileup) ([]string, error) {
+	var cols []string
+	for p.Next() {
+		c := p.Column()
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s:%d:", c.Ref.Name(), c.Pos)
+		for _, e := range c.Entries {
+			switch {
+			case e.IsDel:
+				b.WriteByte('*')
+			case e.IsRefSkip:
+				b.WriteByte('>')
+			default:
+				b.WriteByte(e.Base)
+			}
+			switch {
+			case e.Indel > 0:
+				fmt.Fprintf(&b, "+%d%s", e.Indel, e.Inserted())
+			case e.Indel < 0:
+				fmt.Fprintf(&b, "%d", e.Indel)
+			}
+		}
+		cols = append(cols, b.String())
+	}
+	return cols, p.Error()
+}
+
+func TestPileup(t *testing.T) {
+	h := testHeader(t)
+	b := htstestutil.NewBAMBuilder()
+	b.Record("r0", "chr1", 2, "2S3M", "NNACG").Qual(testQual[:5]).MapQ(60).
+		Record("dup", "chr1", 2, "3M", "TTT").Qual(testQual[:3]).MapQ(60).Flags(sam.Duplicate).
+		Record("r1", "chr1", 3, "1M2I1M1D2M", "CTTGAC").Qual(testQual[:6]).MapQ(60).
+		Record("low", "chr1", 3, "2M", "GG").Qual(testQual[:2]).MapQ(5).
+		Record("r2", "chr1", 4, "1M2N1M", "GC").Qual(testQual[:2]).MapQ(60).
+		Record("r3", "chr1", 20, "2M", "AA").Qual(testQual[:2]).MapQ(60).
+		Record("r4", "chr2", 0, "1M", "T").Qual(testQual[:1]).MapQ(60)
+	recs := newRecords(t, b, h)
+	src := recs
+	got, err := render(New(&src, Options{Exclude: DefaultExclude, MinMapQ: 10}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"chr1:2:A",
+		"chr1:3:CC+2TT",
+		"chr1:4:GG-1G",
+		"chr1:5:*>",
+		"chr1:6:A>",
+		"chr1:7:CC",
+		"chr1:20:A",
+		"chr1:21:A",
+		"chr2:0:T",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected pileup:\ngot: %q\nwant:%q", got, want)
+	}
+
+	src = recs
+	got, err = render(New(&src, Options{Exclude: DefaultExclude, MinMapQ: 10, MaxDepth: 1}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = []string{
+		"chr1:2:A",
+		"chr1:3:C",
+		"chr1:4:G",
+		"chr1:20:A",
+		"chr1:21:A",
+		"chr2:0:T",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected max depth pileup:\ngot: %q\nwant:%q", got, want)
+	}
+
+	src = recs
+	got, err = render(New(&src, Options{
+		Exclude:  DefaultExclude,
+		MinBaseQ: 31,
+		Filter:   func(r *sam.Record) bool { return r.Name != "r2" },
+		Region:   &bam.Region{Ref: h.Refs()[0], Start: 3, End: 6},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = []string{
+		"chr1:3:C",
+		"chr1:4:GG-1G",
+		"chr1:5:*",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected filtered pileup:\ngot: %q\nwant:%q", got, want)
+	}
+
+	b = htstestutil.NewBAMBuilder()
+	b.Record("r0", "chr1", 5, "2M", "AC").Qual(testQual[:2]).MapQ(60).
+		Record("r1", "chr1", 2, "2M", "GT").Qual(testQual[:2]).MapQ(60)
+	src = newRecords(t, b, h)
+	_, err = render(New(&src, Options{}))
+	if err != ErrUnsorted {
+		t.Errorf("unexpected error for unsorted input: got:%v want:%v", err, ErrUnsorted)
+	}
+}
+
+func TestMateOverlap(t *testing.T) {
+	h := testHeader(t)
+	b := htstestutil.NewBAMBuilder()
+	b.Record("p", "chr1", 2, "4M", "ACGT").Qual(testQual[:4]).MapQ(60).Flags(sam.Paired).
+		Record("p", "chr1", 4, "3M", "TCA").Qual("?IA").MapQ(60).Flags(sam.Paired).
+		// Records with the same name that are not
+		// paired are not treated as mates.
+		Record("u", "chr1", 4, "1M", "A").Qual(testQual[:1]).MapQ(60).
+		Record("u", "chr1", 4, "1M", "A").Qual(testQual[:1]).MapQ(60).
+		Record("q", "chr1", 10, "1M1D1M", "AC").Qual(testQual[:2]).MapQ(60).Flags(sam.Paired).
+		Record("q", "chr1", 11, "1M", "G").Qual(testQual[:1]).MapQ(60).Flags(sam.Paired)
+	recs := newRecords(t, b, h)
+
+	for _, test := range []struct {
+		overlap bool
+		want    []string
+	}{
+		{
+			overlap: false,
+			want: []string{
+				"chr1:2:A", "chr1:3:C", "chr1:4:GTAA", "chr1:5:TC", "chr1:6:A",
+				"chr1:10:A-1", "chr1:11:*G", "chr1:12:C",
+			},
+		},
+		{
+			overlap: true,
+			want: []string{
+				"chr1:2:A", "chr1:3:C", "chr1:4:GAA", "chr1:5:C", "chr1:6:A",
+				"chr1:10:A-1", "chr1:11:G", "chr1:12:C",
+			},
+		},
+	} {
+		src := recs
+		got, err := render(New(&src, Options{MateOverlap: test.overlap}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected pileup with mate overlap=%t:\ngot: %q\nwant:%q", test.overlap, got, test.want)
+		}
+	}
+}
+
+func TestEntry(t *testing.T) {
+	h := testHeader(t)
+	b := htstestutil.NewBAMBuilder()
+	b.Record("r0", "chr1", 10, "2M1I2M", "ACGTA").Qual(testQual[:5]).MapQ(60).Flags(sam.Reverse)
+	src := newRecords(t, b, h)
+	p := New(&src, Options{})
+	var entries []Entry
+	for p.Next() {
+		entries = append(entries, p.Column().Entries...)
+	}
+	if p.Error() != nil {
+		t.Fatalf("unexpected error: %v", p.Error())
+	}
+	if len(entries) != 4 {
+		t.Fatalf("unexpected number of entries: got:%d want:4", len(entries))
+	}
+	for i, want := range []struct {
+		offset       int
+		base, qual   byte
+		head, tail   bool
+		indel        int
+		insertedBase string
+	}{
+		{offset: 0, base: 'A', qual: 30, head: true},
+		{offset: 1, base: 'C', qual: 31, indel: 1, insertedBase: "G"},
+		{offset: 3, base: 'T', qual: 33},
+		{offset: 4, base: 'A', qual: 34, tail: true},
+	} {
+		e := entries[i]
+		if e.Offset != want.offset || e.Base != want.base || e.Qual != want.qual ||
+			e.IsHead != want.head || e.IsTail != want.tail || e.Indel != want.indel ||
+			!e.Reverse() || string(e.Inserted()) != want.insertedBase {
+			t.Errorf("unexpected entry %d: %+v", i, e)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package readgroup implements assignment of a read group to all the
+// records of an alignment stream, in the manner of Picard
+// AddOrReplaceReadGroups.
+//
+// The header of the stream has all its read groups replaced by the
+// assigned read group, and the RG aux field of every record is set to
+// the ID of the read group, replacing any existing read group.
+package readgroup
+
+import (
+	"errors"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Source is a source of alignment records and their header.
+type Source interface {
+	Header() *sam.Header
+	Read() (*sam.Record, error)
+}
+
+var rgTag = sam.NewTag("RG")
+
+// required holds the read group fields required by Picard
+// AddOrReplaceReadGroups in addition to the ID.
+var required = []sam.Tag{
+	sam.NewTag("LB"),
+	sam.NewTag("PL"),
+	sam.NewTag("PU"),
+	sam.NewTag("SM"),
+}
+
+// Reader is a read group assigning record reader. It returns the
+// records of its Source in order with their RG aux field set.
+type Reader struct {
+	src Source
+	h   *sam.Header
+	tag sam.Aux
+}
+
+// NewReader returns a Reader assigning the read group rg to the records
+// read from src. The LB, PL, PU and SM fields of rg must be set. rg is
+// cloned into the header of the Reader and is not altered.
+func NewReader(src Source, rg *sam.ReadGroup) (*Reader, error) {
+	for _, t := range required {
+		if rg.Get(t) == "" {
+			return nil, errors.New("readgroup: missing " + t.String() + " field")
+		}
+	}
+	tag, err := sam.NewAux(rgTag, rg.Name())
+	if err != nil {
+		return nil, err
+	}
+	h := src.Header().Clone()
+	rgs := h.RGs()
+	for i := len(rgs) - 1; i >= 0; i-- {
+		err = h.RemoveReadGroup(rgs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = h.AddReadGroup(rg.Clone())
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{src: src, h: h, tag: tag}, nil
+}
+
+// Header returns the header of the Reader, holding only the assigned
+// read group.
+func (r *Reader) Header() *sam.Header { return r.h }
+
+// Read returns the next record. The references of the record are
+// replaced by those of the Reader's header.
+func (r *Reader) Read() (*sam.Record, error) {
+	rec, err := r.src.Read()
+	if err != nil {
+		return nil, err
+	}
+	refs := r.h.Refs()
+	if id := rec.Ref.ID(); id >= 0 {
+		rec.Ref = refs[id]
+	}
+	if id := rec.MateRef.ID(); id >= 0 {
+		rec.MateRef = refs[id]
+	}
+	err = samutil.PutAux(rec, r.tag)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
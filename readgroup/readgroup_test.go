@@ -0,0 +1,210 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package readgroup
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+type source struct {
+	h    *sam.Header
+	recs []*sam.Record
+}
+
+func (s *source) Header() *sam.Header { return s.h }
+
+func (s *source) Read() (*sam.Record, error) {
+	if len(s.recs) == 0 {
+		return nil, io.EOF
+	}
+	rec := s.recs[0]
+	s.recs = s.recs[1:]
+	return rec, nil
+}
+
+func TestReader(t *testing.T) {
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	for _, id := range []string{"old1", "old2"} {
+		rg, err := sam.NewReadGroup(id, "", "", "lib", "", "ILLUMINA", "unit", "sample", "", "", time.Time{}, 0)
+		if err != nil {
+			t.Fatalf("unexpected error creating read group: %v", err)
+		}
+		err = h.AddReadGroup(rg)
+		if err != nil {
+			t.Fatalf("unexpected error adding read group: %v", err)
+		}
+	}
+
+	old, err := sam.NewAux(rgTag, "old1")
+	if err != nil {
+		t.Fatalf("unexpected error creating aux: %v", err)
+	}
+	nm, err := sam.NewAux(sam.NewTag("NM"), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating aux: %v", err)
+	}
+	var recs []*sam.Record
+	for i, aux := range [][]sam.Aux{{nm, old}, {nm}, nil} {
+		cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+		r, err := sam.NewRecord("r", chr1, chr1, i, i+10, 14, 60, cigar, []byte("ACGT"), nil, aux)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		recs = append(recs, r)
+	}
+
+	rg, err := sam.NewReadGroup("new", "", "", "lib2", "", "ILLUMINA", "unit2", "sample2", "", "", time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error creating read group: %v", err)
+	}
+	r, err := NewReader(&source{h: h, recs: recs}, rg)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	rgs := r.Header().RGs()
+	if len(rgs) != 1 || rgs[0].Name() != "new" || rgs[0].Library() != "lib2" {
+		t.Errorf("unexpected read groups: %v", rgs)
+	}
+	if len(h.RGs()) != 2 {
+		t.Errorf("source header altered: %v", h.RGs())
+	}
+	var n int
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		var got []string
+		for _, a := range rec.AuxFields {
+			if a.Tag() == rgTag {
+				got = append(got, a.Value().(string))
+			}
+		}
+		if len(got) != 1 || got[0] != "new" {
+			t.Errorf("unexpected read groups for record %d: %v", n, got)
+		}
+		if rec.AuxFields.Get(sam.NewTag("NM")) == nil && n < 2 {
+			t.Errorf("lost aux field for record %d: %v", n, rec)
+		}
+		if rec.Ref != r.Header().Refs()[0] || rec.MateRef != r.Header().Refs()[0] {
+			t.Errorf("record references not from reader header: %v", rec)
+		}
+		n++
+	}
+	if n != len(recs) {
+		t.Errorf("unexpected number of records: got:%d want:%d", n, len(recs))
+	}
+
+	incomplete, err := sam.NewReadGroup("new", "", "", "lib2", "", "ILLUMINA", "", "sample2", "", "", time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error creating read group: %v", err)
+	}
+	_, err = NewReader(&source{h: h}, incomplete)
+	if err == nil {
+		t.Error("expected error for missing PU field")
+	}
+}
+
+func TestReaderLazyAux(t *testing.T) {
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	old, err := sam.NewAux(rgTag, "old")
+	if err != nil {
+		t.Fatalf("unexpected error creating aux: %v", err)
+	}
+	var in bytes.Buffer
+	bw, err := bam.NewWriter(&in, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+	rec, err := sam.NewRecord("r", chr1, nil, 0, -1, 0, 60, cigar, []byte("ACGT"), nil, []sam.Aux{old})
+	if err != nil {
+		t.Fatalf("unexpected error creating record: %v", err)
+	}
+	err = bw.Write(rec)
+	if err != nil {
+		t.Fatalf("unexpected error writing record: %v", err)
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	br, err := bam.NewReader(&in, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	defer br.Close()
+	br.LazyAux(true)
+	rg, err := sam.NewReadGroup("new", "", "", "lib", "", "ILLUMINA", "unit", "sample", "", "", time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error creating read group: %v", err)
+	}
+	r, err := NewReader(br, rg)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	rec, err = r.Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading record: %v", err)
+	}
+
+	// Round trip the record to check that
+	// the old read group is not also written.
+	var out bytes.Buffer
+	bw, err = bam.NewWriter(&out, r.Header(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	err = bw.Write(rec)
+	if err != nil {
+		t.Fatalf("unexpected error writing record: %v", err)
+	}
+	err = bw.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	br, err = bam.NewReader(&out, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	defer br.Close()
+	rec, err = br.Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading record: %v", err)
+	}
+	var got []string
+	for _, a := range rec.AuxFields {
+		if a.Tag() == rgTag {
+			got = append(got, a.Value().(string))
+		}
+	}
+	if len(got) != 1 || got[0] != "new" {
+		t.Errorf("unexpected read groups: got:%v want:[new]", got)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package recstream
+
+import (
+	"context"
+	"io"
+
+	"github.com/Schaudge/hts/recstream/recstreampb"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Server is a recstreampb.RecordStreamServer streaming the records
+// provided by Open for each call of the Stream method.
+type Server struct {
+	recstreampb.UnimplementedRecordStreamServer
+
+	// Open returns the header and records to
+	// stream for a call. If Source implements
+	// io.Closer, it is closed when the call
+	// completes.
+	Open func(ctx context.Context) (*sam.Header, Source, error)
+
+	// BatchSize is the number of records in each
+	// batch. If BatchSize is less than one,
+	// DefaultBatchSize is used.
+	BatchSize int
+}
+
+// Stream implements the recstreampb.RecordStreamServer interface.
+func (s *Server) Stream(stream recstreampb.RecordStream_StreamServer) error {
+	h, src, err := s.Open(stream.Context())
+	if err != nil {
+		serverConn{stream}.writeError(err.Error())
+		return err
+	}
+	if c, ok := src.(io.Closer); ok {
+		defer c.Close()
+	}
+	return Serve(stream, h, src, s.BatchSize)
+}
+
+// Serve sends h and the records read from src over a gRPC record
+// stream in the same way as Send. It is intended to be called by the
+// Stream method of a recstreampb.RecordStreamServer.
+func Serve(stream recstreampb.RecordStream_StreamServer, h *sam.Header, src Source, size int) error {
+	return send(serverConn{stream}, h, src, size)
+}
+
+// NewClientReceiver returns a Receiver reading records from a gRPC
+// record stream opened by calling the Stream method of c, allowing up
+// to window batches in flight. If window is less than one,
+// DefaultWindow is used. Cancelling ctx terminates the stream, and
+// must be done if the records are not read until io.EOF is returned.
+func NewClientReceiver(ctx context.Context, c recstreampb.RecordStreamClient, window int) (*Receiver, error) {
+	stream, err := c.Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newReceiver(clientConn{stream}, window)
+}
+
+// serverConn is a senderConn over the server side of a gRPC record
+// stream.
+type serverConn struct {
+	stream recstreampb.RecordStream_StreamServer
+}
+
+func (c serverConn) writeHeader(h *sam.Header) error {
+	return c.stream.Send(&recstreampb.Frame{Body: &recstreampb.Frame_Header{Header: headerMessage(h)}})
+}
+
+func (c serverConn) writeBatch(recs []*sam.Record) error {
+	b := &recstreampb.RecordBatch{Records: make([]*recstreampb.Record, len(recs))}
+	for i, r := range recs {
+		m, err := recordMessage(r)
+		if err != nil {
+			return err
+		}
+		b.Records[i] = m
+	}
+	return c.stream.Send(&recstreampb.Frame{Body: &recstreampb.Frame_Batch{Batch: b}})
+}
+
+func (c serverConn) writeEnd() error {
+	return c.stream.Send(&recstreampb.Frame{Body: &recstreampb.Frame_End{End: &recstreampb.End{}}})
+}
+
+func (c serverConn) writeError(msg string) error {
+	return c.stream.Send(&recstreampb.Frame{Body: &recstreampb.Frame_Error{Error: msg}})
+}
+
+func (c serverConn) readControl() (kind byte, credit uint32, err error) {
+	m, err := c.stream.Recv()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, 0, err
+	}
+	switch b := m.Body.(type) {
+	case *recstreampb.Control_Credit:
+		return frameCredit, b.Credit, nil
+	case *recstreampb.Control_Done:
+		return frameDone, 0, nil
+	default:
+		return 0, 0, ErrProtocol
+	}
+}
+
+// clientConn is a receiverConn over the client side of a gRPC record
+// stream.
+type clientConn struct {
+	stream recstreampb.RecordStream_StreamClient
+}
+
+func (c clientConn) writeCredit(n uint32) error {
+	err := c.stream.Send(&recstreampb.Control{Body: &recstreampb.Control_Credit{Credit: n}})
+	if err == io.EOF {
+		// The server has ended the call,
+		// and its outcome is reported by
+		// the next call to Recv.
+		err = nil
+	}
+	return err
+}
+
+func (c clientConn) writeDone() error {
+	err := c.stream.Send(&recstreampb.Control{Body: &recstreampb.Control_Done{Done: true}})
+	if err != nil && err != io.EOF {
+		return err
+	}
+	err = c.stream.CloseSend()
+	if err != nil {
+		return err
+	}
+	// Wait for the server to complete the call
+	// so that the resources of the stream are
+	// released.
+	_, err = c.stream.Recv()
+	switch err {
+	case io.EOF:
+		return nil
+	case nil:
+		return ErrProtocol
+	}
+	return err
+}
+
+// recv returns the next frame sent by the server.
+func (c clientConn) recv() (*recstreampb.Frame, error) {
+	f, err := c.stream.Recv()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return f, err
+}
+
+func (c clientConn) readHeader() (*sam.Header, error) {
+	f, err := c.recv()
+	if err != nil {
+		return nil, err
+	}
+	switch b := f.Body.(type) {
+	case *recstreampb.Frame_Header:
+		return headerFromMessage(b.Header)
+	case *recstreampb.Frame_Error:
+		return nil, RemoteError(b.Error)
+	default:
+		return nil, ErrProtocol
+	}
+}
+
+func (c clientConn) readBatch(h *sam.Header) ([]*sam.Record, error) {
+	f, err := c.recv()
+	if err != nil {
+		return nil, err
+	}
+	switch b := f.Body.(type) {
+	case *recstreampb.Frame_Batch:
+		msgs := b.Batch.GetRecords()
+		recs := make([]*sam.Record, len(msgs))
+		for i, m := range msgs {
+			recs[i], err = recordFromMessage(m, h)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return recs, nil
+	case *recstreampb.Frame_End:
+		return nil, io.EOF
+	case *recstreampb.Frame_Error:
+		return nil, RemoteError(b.Error)
+	default:
+		return nil, ErrProtocol
+	}
+}
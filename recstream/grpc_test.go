@@ -0,0 +1,207 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package recstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/recstream/recstreampb"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestGRPC(t *testing.T) {
+	h, recs := testRecords(t)
+
+	const size, window = 10, 2
+	srcs := make(chan *source, 1)
+	srv := grpc.NewServer()
+	recstreampb.RegisterRecordStreamServer(srv, &Server{
+		Open: func(ctx context.Context) (*sam.Header, Source, error) {
+			select {
+			case src := <-srcs:
+				return h, src, nil
+			default:
+				return nil, nil, errors.New("no source")
+			}
+		},
+		BatchSize: size,
+	})
+	l := bufconn.Listen(1 << 20)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error dialing: %v", err)
+	}
+	defer cc.Close()
+	client := recstreampb.NewRecordStreamClient(cc)
+
+	_, err = NewClientReceiver(ctx, client, window)
+	if _, ok := err.(RemoteError); !ok {
+		t.Errorf("unexpected error for failed open: %v", err)
+	}
+
+	for _, fail := range []int{0, 30} {
+		src := &source{recs: recs, fail: fail}
+		srcs <- src
+		r, err := NewClientReceiver(ctx, client, window)
+		if err != nil {
+			t.Fatalf("unexpected error creating receiver: %v", err)
+		}
+		if len(r.Header().Comments) != 1 || r.Header().Comments[0] != "streamed" {
+			t.Errorf("unexpected header comments: %v", r.Header().Comments)
+		}
+
+		// The server must not run ahead of
+		// the granted credit.
+		time.Sleep(50 * time.Millisecond)
+		if n := src.count(); fail == 0 && n > (window+1)*size {
+			t.Errorf("server read ahead of credit: got:%d records", n)
+		}
+
+		var n int
+		for {
+			rec, err := r.Read()
+			if err != nil {
+				if fail == 0 && err != io.EOF {
+					t.Errorf("unexpected error reading: %v", err)
+				}
+				if _, ok := err.(RemoteError); fail != 0 && !ok {
+					t.Errorf("unexpected error for failing source: %v", err)
+				}
+				break
+			}
+			if rec.String() != recs[n].String() {
+				t.Errorf("unexpected record %d: got:%v want:%v", n, rec, recs[n])
+			}
+			n++
+		}
+		want := len(recs)
+		if fail != 0 {
+			want = fail
+		}
+		if n != want {
+			t.Errorf("unexpected number of records: got:%d want:%d", n, want)
+		}
+	}
+}
+
+func TestMessages(t *testing.T) {
+	const text = "@HD\tVN:1.6\tSO:coordinate\n" +
+		"@SQ\tSN:chr1\tLN:1000\tM5:0123456789abcdef0123456789abcdef\tAS:hg\n" +
+		"@SQ\tSN:chr2\tLN:2000\n" +
+		"@RG\tID:rg1\tLB:libA\tSM:s1\n" +
+		"@PG\tID:p1\tPN:aligner\n" +
+		"@PG\tID:p2\tPN:sorter\tPP:p1\n" +
+		"@CO\tstreamed\n"
+	h, err := sam.NewHeader([]byte(text), nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	b := htstestutil.NewBAMBuilder()
+	b.Record("r0", "chr1", 10, "2S4M1I3M", "AACGTACGTA").Qual("IIIIIIIIII").MapQ(60).
+		Flags(sam.Paired|sam.Read1|sam.MateReverse).Mate("chr2", 20, 0).
+		Tag("RG", "rg1").Tag("NM", 1).Tag("XS", -200).Tag("XI", 1<<20).Tag("XU", uint(40000)).
+		Tag("XF", float32(0.5)).Tag("XA", sam.ASCII('x')).Tag("XH", sam.Hex("1AE3")).
+		Tag("Bc", []int8{-1, 2}).Tag("BC", []uint8{1, 2}).Tag("Bs", []int16{-300}).Tag("BS", []uint16{300}).
+		Tag("Bi", []int32{-1 << 20}).Tag("BI", []uint32{1 << 31}).Tag("Bf", []float32{1.5, -2}).
+		Record("r1", "*", -1, "*", "ACGT")
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error creating records: %v", err)
+	}
+	// Raw aux data is sent as
+	// decoded fields.
+	raw, err := sam.NewAux(sam.NewTag("XR"), int32(7))
+	if err != nil {
+		t.Fatalf("unexpected error creating aux: %v", err)
+	}
+	recs[1].RawAux = []byte(raw)
+
+	hm := new(recstreampb.Header)
+	roundTrip(t, headerMessage(h), hm)
+	got, err := headerFromMessage(hm)
+	if err != nil {
+		t.Fatalf("unexpected error decoding header: %v", err)
+	}
+	gotText, err := got.MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected error marshaling header: %v", err)
+	}
+	if string(gotText) != text {
+		t.Errorf("unexpected header:\ngot: %q\nwant:%q", gotText, text)
+	}
+
+	for i, r := range recs {
+		m, err := recordMessage(r)
+		if err != nil {
+			t.Fatalf("unexpected error encoding record %d: %v", i, err)
+		}
+		rm := new(recstreampb.Record)
+		roundTrip(t, m, rm)
+		got, err := recordFromMessage(rm, got)
+		if err != nil {
+			t.Fatalf("unexpected error decoding record %d: %v", i, err)
+		}
+		if got.String() != r.String() {
+			t.Errorf("unexpected record %d:\ngot: %v\nwant:%v", i, got, r)
+		}
+	}
+	if recs[1].AuxFields != nil {
+		t.Error("unexpected modification of record with raw aux data")
+	}
+
+	for _, test := range []struct {
+		name string
+		m    *recstreampb.Record
+	}{
+		{name: "reference", m: &recstreampb.Record{RefId: 2, MateRefId: -1}},
+		{name: "mapq", m: &recstreampb.Record{RefId: -1, MateRefId: -1, MapQ: 256}},
+		{name: "aux range", m: &recstreampb.Record{RefId: -1, MateRefId: -1, Aux: []*recstreampb.Aux{
+			{Tag: "NM", Type: "c", Value: &recstreampb.Aux_Int{Int: 128}},
+		}}},
+		{name: "aux type", m: &recstreampb.Record{RefId: -1, MateRefId: -1, Aux: []*recstreampb.Aux{
+			{Tag: "NM", Type: "Z", Value: &recstreampb.Aux_Int{Int: 1}},
+		}}},
+		{name: "array range", m: &recstreampb.Record{RefId: -1, MateRefId: -1, Aux: []*recstreampb.Aux{
+			{Tag: "Bc", Type: "B", Value: &recstreampb.Aux_Array{Array: &recstreampb.Array{Type: "C", Ints: []int64{-1}}}},
+		}}},
+	} {
+		_, err := recordFromMessage(test.m, got)
+		if err == nil {
+			t.Errorf("expected error for invalid %s", test.name)
+		}
+	}
+}
+
+// roundTrip marshals src and unmarshals the result into dst.
+func roundTrip(t *testing.T, src, dst proto.Message) {
+	t.Helper()
+	b, err := proto.Marshal(src)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling message: %v", err)
+	}
+	err = proto.Unmarshal(b, dst)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshaling message: %v", err)
+	}
+}
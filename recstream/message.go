@@ -0,0 +1,386 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package recstream
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Schaudge/hts/recstream/recstreampb"
+	"github.com/Schaudge/hts/sam"
+)
+
+var errAux = errors.New("recstream: invalid aux field")
+
+// headerMessage returns the recstreampb message holding h.
+func headerMessage(h *sam.Header) *recstreampb.Header {
+	var omit []string
+	if h.GroupOrder == sam.GroupUnspecified {
+		// Tags reports an unspecified group
+		// order as GO:none.
+		omit = append(omit, "GO")
+	}
+	m := &recstreampb.Header{
+		Fields:   fields(h.Tags, omit...),
+		Comments: h.Comments,
+	}
+	for _, r := range h.Refs() {
+		m.References = append(m.References, &recstreampb.Reference{
+			Name:   r.Name(),
+			Length: int32(r.Len()),
+			Fields: fields(r.Tags, "SN", "LN"),
+		})
+	}
+	for _, rg := range h.RGs() {
+		m.ReadGroups = append(m.ReadGroups, &recstreampb.ReadGroup{
+			Id:     rg.Name(),
+			Fields: fields(rg.Tags, "ID"),
+		})
+	}
+	for _, p := range h.Progs() {
+		m.Programs = append(m.Programs, &recstreampb.Program{
+			Id:     p.UID(),
+			Fields: fields(p.Tags, "ID"),
+		})
+	}
+	return m
+}
+
+// fields returns the non-empty tag-value pairs provided by the Tags
+// method of a header line, omitting those with the given tags.
+func fields(tags func(func(sam.Tag, string)), omit ...string) []*recstreampb.Field {
+	var f []*recstreampb.Field
+	tags(func(t sam.Tag, v string) {
+		if v == "" {
+			return
+		}
+		for _, o := range omit {
+			if t.String() == o {
+				return
+			}
+		}
+		f = append(f, &recstreampb.Field{Tag: t.String(), Value: v})
+	})
+	return f
+}
+
+// headerFromMessage returns the header held by m.
+func headerFromMessage(m *recstreampb.Header) (*sam.Header, error) {
+	refs := make([]*sam.Reference, len(m.GetReferences()))
+	for i, rm := range m.GetReferences() {
+		r, err := sam.NewReference(rm.Name, "", "", int(rm.Length), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		err = setFields(r.Set, rm.Fields)
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = r
+	}
+	h, err := sam.NewHeader(nil, refs)
+	if err != nil {
+		return nil, err
+	}
+	err = setFields(h.Set, m.GetFields())
+	if err != nil {
+		return nil, err
+	}
+	for _, rm := range m.GetReadGroups() {
+		rg, err := sam.NewReadGroup(rm.Id, "", "", "", "", "", "", "", "", "", time.Time{}, 0)
+		if err != nil {
+			return nil, err
+		}
+		err = setFields(rg.Set, rm.Fields)
+		if err != nil {
+			return nil, err
+		}
+		err = h.AddReadGroup(rg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, pm := range m.GetPrograms() {
+		p := sam.NewProgram(pm.Id, "", "", "", "")
+		err = setFields(p.Set, pm.Fields)
+		if err != nil {
+			return nil, err
+		}
+		err = h.AddProgram(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	h.Comments = m.GetComments()
+	return h, nil
+}
+
+// setFields sets the fields f of a header line with its Set method.
+func setFields(set func(sam.Tag, string) error, f []*recstreampb.Field) error {
+	for _, tv := range f {
+		if len(tv.Tag) != 2 {
+			return ErrFrame
+		}
+		err := set(sam.NewTag(tv.Tag), tv.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordMessage returns the recstreampb message holding r.
+func recordMessage(r *sam.Record) (*recstreampb.Record, error) {
+	aux := r.AuxFields
+	if r.RawAux != nil {
+		// Decode raw aux data without
+		// modifying the caller's record.
+		c := *r
+		err := c.DecodeAux()
+		if err != nil {
+			return nil, err
+		}
+		aux = c.AuxFields
+	}
+	m := &recstreampb.Record{
+		Name:           r.Name,
+		RefId:          int32(r.Ref.ID()),
+		MateRefId:      int32(r.MateRef.ID()),
+		Pos:            int32(r.Pos),
+		MatePos:        int32(r.MatePos),
+		Flags:          uint32(r.Flags),
+		MapQ:           uint32(r.MapQ),
+		Cigar:          make([]uint32, len(r.Cigar)),
+		TemplateLength: int32(r.TempLen),
+		Seq:            r.Seq.Expand(),
+		Qual:           r.Qual,
+		Aux:            make([]*recstreampb.Aux, len(aux)),
+	}
+	for i, co := range r.Cigar {
+		m.Cigar[i] = uint32(co)
+	}
+	for i, a := range aux {
+		am, err := auxMessage(a)
+		if err != nil {
+			return nil, err
+		}
+		m.Aux[i] = am
+	}
+	return m, nil
+}
+
+// recordFromMessage returns the record held by m, which refers to the
+// references of h.
+func recordFromMessage(m *recstreampb.Record, h *sam.Header) (*sam.Record, error) {
+	refs := h.Refs()
+	ref := func(id int32) (*sam.Reference, error) {
+		switch {
+		case id == -1:
+			return nil, nil
+		case id < -1 || int(id) >= len(refs):
+			return nil, ErrFrame
+		}
+		return refs[id], nil
+	}
+	if m.MapQ > math.MaxUint8 || m.Flags > math.MaxUint16 {
+		return nil, ErrFrame
+	}
+	r := &sam.Record{
+		Name:    m.Name,
+		Pos:     int(m.Pos),
+		MapQ:    byte(m.MapQ),
+		Flags:   sam.Flags(m.Flags),
+		MatePos: int(m.MatePos),
+		TempLen: int(m.TemplateLength),
+		Seq:     sam.NewSeq(m.Seq),
+	}
+	var err error
+	r.Ref, err = ref(m.RefId)
+	if err != nil {
+		return nil, err
+	}
+	r.MateRef, err = ref(m.MateRefId)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Cigar) != 0 {
+		r.Cigar = make(sam.Cigar, len(m.Cigar))
+		for i, co := range m.Cigar {
+			r.Cigar[i] = sam.CigarOp(co)
+		}
+	}
+	if len(m.Qual) != 0 {
+		r.Qual = m.Qual
+	}
+	for _, am := range m.Aux {
+		a, err := auxFromMessage(am)
+		if err != nil {
+			return nil, err
+		}
+		r.AuxFields = append(r.AuxFields, a)
+	}
+	return r, nil
+}
+
+// auxMessage returns the recstreampb message holding a.
+func auxMessage(a sam.Aux) (*recstreampb.Aux, error) {
+	m := &recstreampb.Aux{Tag: a.Tag().String(), Type: string(a.Type())}
+	switch v := a.Value().(type) {
+	case int8:
+		m.Value = &recstreampb.Aux_Int{Int: int64(v)}
+	case uint8:
+		if a.Type() == 'A' {
+			m.Value = &recstreampb.Aux_Text{Text: string(v)}
+		} else {
+			m.Value = &recstreampb.Aux_Int{Int: int64(v)}
+		}
+	case int16:
+		m.Value = &recstreampb.Aux_Int{Int: int64(v)}
+	case uint16:
+		m.Value = &recstreampb.Aux_Int{Int: int64(v)}
+	case int32:
+		m.Value = &recstreampb.Aux_Int{Int: int64(v)}
+	case uint32:
+		m.Value = &recstreampb.Aux_Int{Int: int64(v)}
+	case float32:
+		m.Value = &recstreampb.Aux_Float{Float: v}
+	case string:
+		m.Value = &recstreampb.Aux_Text{Text: v}
+	case []byte:
+		if a.Type() == 'H' {
+			m.Value = &recstreampb.Aux_Text{Text: string(v)}
+		} else {
+			m.Value = &recstreampb.Aux_Array{Array: &recstreampb.Array{Type: "C", Ints: ints(v)}}
+		}
+	case []int8:
+		m.Value = &recstreampb.Aux_Array{Array: &recstreampb.Array{Type: "c", Ints: ints(v)}}
+	case []int16:
+		m.Value = &recstreampb.Aux_Array{Array: &recstreampb.Array{Type: "s", Ints: ints(v)}}
+	case []uint16:
+		m.Value = &recstreampb.Aux_Array{Array: &recstreampb.Array{Type: "S", Ints: ints(v)}}
+	case []int32:
+		m.Value = &recstreampb.Aux_Array{Array: &recstreampb.Array{Type: "i", Ints: ints(v)}}
+	case []uint32:
+		m.Value = &recstreampb.Aux_Array{Array: &recstreampb.Array{Type: "I", Ints: ints(v)}}
+	case []float32:
+		m.Value = &recstreampb.Aux_Array{Array: &recstreampb.Array{Type: "f", Floats: v}}
+	case error:
+		return nil, v
+	default:
+		return nil, errAux
+	}
+	return m, nil
+}
+
+// auxFromMessage returns the aux field held by m.
+func auxFromMessage(m *recstreampb.Aux) (sam.Aux, error) {
+	if len(m.Tag) != 2 || len(m.Type) != 1 {
+		return nil, errAux
+	}
+	t := sam.NewTag(m.Tag)
+	var v interface{}
+	switch x := m.Value.(type) {
+	case *recstreampb.Aux_Int:
+		var ok bool
+		switch m.Type[0] {
+		case 'c':
+			v, ok = narrow[int8](x.Int)
+		case 'C':
+			v, ok = narrow[uint8](x.Int)
+		case 's':
+			v, ok = narrow[int16](x.Int)
+		case 'S':
+			v, ok = narrow[uint16](x.Int)
+		case 'i':
+			v, ok = narrow[int32](x.Int)
+		case 'I':
+			v, ok = narrow[uint32](x.Int)
+		}
+		if !ok {
+			return nil, errAux
+		}
+	case *recstreampb.Aux_Float:
+		if m.Type != "f" {
+			return nil, errAux
+		}
+		v = x.Float
+	case *recstreampb.Aux_Text:
+		switch m.Type {
+		case "A":
+			if len(x.Text) != 1 {
+				return nil, errAux
+			}
+			v = sam.ASCII(x.Text[0])
+		case "Z":
+			v = x.Text
+		case "H":
+			v = sam.Hex(x.Text)
+		default:
+			return nil, errAux
+		}
+	case *recstreampb.Aux_Array:
+		if m.Type != "B" || len(x.Array.GetType()) != 1 {
+			return nil, errAux
+		}
+		var ok bool
+		switch x.Array.Type[0] {
+		case 'c':
+			v, ok = narrowAll[int8](x.Array.Ints)
+		case 'C':
+			v, ok = narrowAll[uint8](x.Array.Ints)
+		case 's':
+			v, ok = narrowAll[int16](x.Array.Ints)
+		case 'S':
+			v, ok = narrowAll[uint16](x.Array.Ints)
+		case 'i':
+			v, ok = narrowAll[int32](x.Array.Ints)
+		case 'I':
+			v, ok = narrowAll[uint32](x.Array.Ints)
+		case 'f':
+			v, ok = x.Array.Floats, true
+		}
+		if !ok {
+			return nil, errAux
+		}
+	default:
+		return nil, errAux
+	}
+	return sam.NewAux(t, v)
+}
+
+// integer is the set of aux integer types.
+type integer interface {
+	int8 | uint8 | int16 | uint16 | int32 | uint32
+}
+
+// ints returns the elements of v as int64.
+func ints[T integer](v []T) []int64 {
+	s := make([]int64, len(v))
+	for i, e := range v {
+		s[i] = int64(e)
+	}
+	return s
+}
+
+// narrow returns v as a T, and whether v is in the range of T.
+func narrow[T integer](v int64) (T, bool) {
+	n := T(v)
+	return n, int64(n) == v
+}
+
+// narrowAll returns the elements of v as T, and whether all the
+// elements are in the range of T.
+func narrowAll[T integer](v []int64) ([]T, bool) {
+	s := make([]T, len(v))
+	for i, e := range v {
+		n, ok := narrow[T](e)
+		if !ok {
+			return nil, false
+		}
+		s[i] = n
+	}
+	return s, true
+}
@@ -0,0 +1,427 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package recstream implements streaming of alignment records between
+// processes over a reliable byte stream such as a network connection.
+//
+// A sender writes the SAM header and then batches of records, and the
+// receiver grants credit for batches as it consumes them, so that the
+// number of batches in flight is bounded by the receiver's window.
+//
+// Over a byte stream, each frame is a 4 byte little-endian payload
+// length, a kind byte and the payload, and headers and records are
+// carried in their BAM binary encodings. The same protocol is carried
+// as the protocol buffer messages of the recstreampb package, which
+// hold the fields of headers and records, by the gRPC adapters Serve,
+// Server and NewClientReceiver.
+package recstream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+const (
+	// DefaultBatchSize is the default number
+	// of records in a batch.
+	DefaultBatchSize = 256
+
+	// DefaultWindow is the default number of
+	// batches a receiver allows in flight.
+	DefaultWindow = 4
+)
+
+// Frame kinds sent by the sender.
+const (
+	frameHeader = 'H'
+	frameBatch  = 'B'
+	frameEnd    = 'E'
+	frameError  = 'X'
+)
+
+// Frame kinds sent by the receiver.
+const (
+	frameCredit = 'C'
+	frameDone   = 'D'
+)
+
+// maxFrame is the largest accepted frame payload.
+const maxFrame = 1 << 30
+
+var (
+	ErrFrame    = errors.New("recstream: invalid frame")
+	ErrProtocol = errors.New("recstream: unexpected frame")
+)
+
+// RemoteError is an error reported by a sender.
+type RemoteError string
+
+func (e RemoteError) Error() string { return "recstream: remote error: " + string(e) }
+
+// Source is a source of alignment records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Send writes h and the records read from src to rw in batches of up
+// to size records, sending each batch only when credit for it has been
+// granted by the receiver. If size is less than one, DefaultBatchSize
+// is used. Send returns after the receiver has acknowledged the end of
+// the stream. If src returns an error other than io.EOF, the error is
+// sent to the receiver and returned. rw should be closed after Send
+// returns.
+func Send(rw io.ReadWriter, h *sam.Header, src Source, size int) error {
+	return send(&streamConn{rw: rw}, h, src, size)
+}
+
+// senderConn is the sender's side of a connection carrying the
+// messages of the protocol.
+type senderConn interface {
+	writeHeader(h *sam.Header) error
+	writeBatch(recs []*sam.Record) error
+	writeEnd() error
+	writeError(msg string) error
+
+	// readControl returns the next message from the
+	// receiver, either frameCredit with the credit
+	// granted or frameDone.
+	readControl() (kind byte, credit uint32, err error)
+}
+
+// receiverConn is the receiver's side of a connection carrying the
+// messages of the protocol.
+type receiverConn interface {
+	writeCredit(n uint32) error
+	writeDone() error
+
+	// readHeader returns the header sent by the sender.
+	// An error sent instead is returned as a RemoteError.
+	readHeader() (*sam.Header, error)
+
+	// readBatch returns the next batch of records,
+	// which refer to the references of h. At the end
+	// of the stream readBatch returns io.EOF, and an
+	// error sent by the sender is returned as a
+	// RemoteError.
+	readBatch(h *sam.Header) ([]*sam.Record, error)
+}
+
+// streamConn is a connection over a reliable byte stream.
+type streamConn struct {
+	rw  io.ReadWriter
+	buf bytes.Buffer
+}
+
+func (c *streamConn) writeHeader(h *sam.Header) error {
+	c.buf.Reset()
+	err := h.EncodeBinary(&c.buf)
+	if err != nil {
+		return err
+	}
+	return writeFrame(c.rw, frameHeader, c.buf.Bytes())
+}
+
+func (c *streamConn) writeBatch(recs []*sam.Record) error {
+	c.buf.Reset()
+	for _, r := range recs {
+		err := bam.Marshal(r, &c.buf)
+		if err != nil {
+			return err
+		}
+	}
+	return writeFrame(c.rw, frameBatch, c.buf.Bytes())
+}
+
+func (c *streamConn) writeEnd() error { return writeFrame(c.rw, frameEnd, nil) }
+
+func (c *streamConn) writeError(msg string) error { return writeFrame(c.rw, frameError, []byte(msg)) }
+
+func (c *streamConn) readControl() (kind byte, credit uint32, err error) {
+	kind, p, err := readFrame(c.rw)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch {
+	case kind == frameCredit && len(p) == 4:
+		return kind, binary.LittleEndian.Uint32(p), nil
+	case kind == frameDone:
+		return kind, 0, nil
+	}
+	return 0, 0, ErrProtocol
+}
+
+func (c *streamConn) writeCredit(n uint32) error {
+	var p [4]byte
+	binary.LittleEndian.PutUint32(p[:], n)
+	return writeFrame(c.rw, frameCredit, p[:])
+}
+
+func (c *streamConn) writeDone() error { return writeFrame(c.rw, frameDone, nil) }
+
+func (c *streamConn) readHeader() (*sam.Header, error) {
+	kind, p, err := readFrame(c.rw)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case frameHeader:
+	case frameError:
+		return nil, RemoteError(p)
+	default:
+		return nil, ErrProtocol
+	}
+	h, err := sam.NewHeader(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = h.DecodeBinary(bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (c *streamConn) readBatch(h *sam.Header) ([]*sam.Record, error) {
+	kind, p, err := readFrame(c.rw)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case frameBatch:
+	case frameEnd:
+		return nil, io.EOF
+	case frameError:
+		return nil, RemoteError(p)
+	default:
+		return nil, ErrProtocol
+	}
+	var recs []*sam.Record
+	for len(p) != 0 {
+		if len(p) < 4 {
+			return nil, ErrFrame
+		}
+		n := 4 + int(binary.LittleEndian.Uint32(p))
+		if n < 4 || n > len(p) {
+			return nil, ErrFrame
+		}
+		rec, err := bam.Unmarshal(p[:n], h)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+		p = p[n:]
+	}
+	return recs, nil
+}
+
+func send(c senderConn, h *sam.Header, src Source, size int) error {
+	if size < 1 {
+		size = DefaultBatchSize
+	}
+	err := c.writeHeader(h)
+	if err != nil {
+		return err
+	}
+
+	cr := newCredits(c)
+	recs := make([]*sam.Record, 0, size)
+	for {
+		recs = recs[:0]
+		var srcErr error
+		for len(recs) < size {
+			var rec *sam.Record
+			rec, srcErr = src.Read()
+			if srcErr != nil {
+				break
+			}
+			recs = append(recs, rec)
+		}
+		if len(recs) != 0 {
+			err = cr.take()
+			if err != nil {
+				return err
+			}
+			err = c.writeBatch(recs)
+			if err != nil {
+				return err
+			}
+		}
+		switch {
+		case srcErr == io.EOF:
+			err = c.writeEnd()
+			if err != nil {
+				return err
+			}
+			return cr.wait()
+		case srcErr != nil:
+			c.writeError(srcErr.Error())
+			return srcErr
+		}
+	}
+}
+
+// credits holds the credit granted by a receiver, read from r in a
+// separate goroutine.
+type credits struct {
+	mu   sync.Mutex
+	cond sync.Cond
+	n    int
+	done bool
+	err  error
+}
+
+func newCredits(r senderConn) *credits {
+	c := &credits{}
+	c.cond.L = &c.mu
+	go func() {
+		for {
+			kind, n, err := r.readControl()
+			c.mu.Lock()
+			switch {
+			case err != nil:
+				c.err = err
+			case kind == frameCredit:
+				c.n += int(n)
+			case kind == frameDone:
+				c.done = true
+			default:
+				c.err = ErrProtocol
+			}
+			stop := c.err != nil || c.done
+			c.cond.Broadcast()
+			c.mu.Unlock()
+			if stop {
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// take waits for and consumes a single credit.
+func (c *credits) take() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.n == 0 && c.err == nil && !c.done {
+		c.cond.Wait()
+	}
+	if c.n == 0 {
+		if c.err != nil {
+			return c.err
+		}
+		return ErrProtocol
+	}
+	c.n--
+	return nil
+}
+
+// wait waits for the receiver's acknowledgement of the end of the
+// stream.
+func (c *credits) wait() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.err == nil && !c.done {
+		c.cond.Wait()
+	}
+	if c.done {
+		return nil
+	}
+	return c.err
+}
+
+// Receiver reads records sent by Send.
+type Receiver struct {
+	rw receiverConn
+	h  *sam.Header
+
+	batch []*sam.Record
+	err   error
+}
+
+// NewReceiver returns a Receiver reading records from rw, allowing up
+// to window batches in flight. If window is less than one,
+// DefaultWindow is used.
+func NewReceiver(rw io.ReadWriter, window int) (*Receiver, error) {
+	return newReceiver(&streamConn{rw: rw}, window)
+}
+
+func newReceiver(rw receiverConn, window int) (*Receiver, error) {
+	if window < 1 {
+		window = DefaultWindow
+	}
+	h, err := rw.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	err = rw.writeCredit(uint32(window))
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{rw: rw, h: h}, nil
+}
+
+// Header returns the SAM header sent by the sender.
+func (r *Receiver) Header() *sam.Header { return r.h }
+
+// Read returns the next record. At the end of the stream Read returns
+// io.EOF.
+func (r *Receiver) Read() (*sam.Record, error) {
+	for len(r.batch) == 0 {
+		if r.err != nil {
+			return nil, r.err
+		}
+		recs, err := r.rw.readBatch(r.h)
+		switch {
+		case err == io.EOF:
+			r.err = r.rw.writeDone()
+			if r.err == nil {
+				r.err = io.EOF
+			}
+		case err != nil:
+			r.err = err
+		default:
+			r.batch = recs
+			r.err = r.rw.writeCredit(1)
+		}
+	}
+	rec := r.batch[0]
+	r.batch[0] = nil
+	r.batch = r.batch[1:]
+	return rec, nil
+}
+
+func writeFrame(w io.Writer, kind byte, p []byte) error {
+	var hdr [5]byte
+	binary.LittleEndian.PutUint32(hdr[:4], uint32(len(p)))
+	hdr[4] = kind
+	_, err := w.Write(append(hdr[:], p...))
+	return err
+}
+
+func readFrame(r io.Reader) (kind byte, p []byte, err error) {
+	var hdr [5]byte
+	_, err = io.ReadFull(r, hdr[:])
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	n := binary.LittleEndian.Uint32(hdr[:4])
+	if n > maxFrame {
+		return 0, nil, ErrFrame
+	}
+	p = make([]byte, n)
+	_, err = io.ReadFull(r, p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return hdr[4], p, err
+}
@@ -0,0 +1,140 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package recstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// source is a Source of n records counting the records read and
+// failing after fail records if fail is positive.
+type source struct {
+	mu   sync.Mutex
+	recs []*sam.Record
+	read int
+	fail int
+}
+
+func (s *source) Read() (*sam.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail > 0 && s.read == s.fail {
+		return nil, errors.New("source failure")
+	}
+	if s.read == len(s.recs) {
+		return nil, io.EOF
+	}
+	s.read++
+	return s.recs[s.read-1], nil
+}
+
+func (s *source) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read
+}
+
+// testRecords returns a header and records to stream.
+func testRecords(t *testing.T) (*sam.Header, []*sam.Record) {
+	chr1, err := sam.NewReference("chr1", "", "", 1e6, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	h.Comments = []string{"streamed"}
+	var recs []*sam.Record
+	for i := 0; i < 1000; i++ {
+		cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}
+		r, err := sam.NewRecord(fmt.Sprintf("r%d", i), chr1, chr1, i, i+100, 104, 60, cigar, []byte("ACGT"), []byte{30, 30, 30, 30}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		recs = append(recs, r)
+	}
+	return h, recs
+}
+
+func TestStream(t *testing.T) {
+	h, recs := testRecords(t)
+
+	for _, fail := range []int{0, 30} {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("unexpected error listening: %v", err)
+		}
+		src := &source{recs: recs, fail: fail}
+		const size, window = 10, 2
+		sent := make(chan error, 1)
+		go func() {
+			conn, err := l.Accept()
+			if err != nil {
+				sent <- err
+				return
+			}
+			defer conn.Close()
+			sent <- Send(conn, h, src, size)
+		}()
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("unexpected error dialing: %v", err)
+		}
+		r, err := NewReceiver(conn, window)
+		if err != nil {
+			t.Fatalf("unexpected error creating receiver: %v", err)
+		}
+		if len(r.Header().Comments) != 1 || r.Header().Comments[0] != "streamed" {
+			t.Errorf("unexpected header comments: %v", r.Header().Comments)
+		}
+
+		// The sender must not run ahead of
+		// the granted credit.
+		time.Sleep(50 * time.Millisecond)
+		if n := src.count(); fail == 0 && n > (window+1)*size {
+			t.Errorf("sender read ahead of credit: got:%d records", n)
+		}
+
+		var n int
+		for {
+			rec, err := r.Read()
+			if err != nil {
+				if fail == 0 && err != io.EOF {
+					t.Errorf("unexpected error reading: %v", err)
+				}
+				if _, ok := err.(RemoteError); fail != 0 && !ok {
+					t.Errorf("unexpected error for failing source: %v", err)
+				}
+				break
+			}
+			if rec.String() != recs[n].String() {
+				t.Errorf("unexpected record %d: got:%v want:%v", n, rec, recs[n])
+			}
+			n++
+		}
+		want := len(recs)
+		if fail != 0 {
+			want = fail
+		}
+		if n != want {
+			t.Errorf("unexpected number of records: got:%d want:%d", n, want)
+		}
+		err = <-sent
+		if (err != nil) != (fail != 0) {
+			t.Errorf("unexpected send error: %v", err)
+		}
+		conn.Close()
+		l.Close()
+	}
+}
@@ -0,0 +1,11 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative recstream.proto
+
+// Package recstreampb holds the protocol buffer messages and gRPC
+// service of the record stream protocol, generated from
+// recstream.proto. Servers and clients of the service are provided by
+// the recstream package.
+package recstreampb
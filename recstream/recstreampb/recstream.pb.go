@@ -0,0 +1,1330 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Record stream service definitions.
+//
+// Headers and records are carried as structured messages holding the
+// fields of sam.Header and sam.Record. The messages correspond to the
+// frames of the recstream package, which provides server and client
+// adapters for the service and implements the same protocol over any
+// reliable byte stream.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: recstream.proto
+
+package recstreampb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Header is a SAM header.
+type Header struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// fields holds the fields of the @HD line.
+	Fields     []*Field     `protobuf:"bytes,1,rep,name=fields,proto3" json:"fields,omitempty"`
+	References []*Reference `protobuf:"bytes,2,rep,name=references,proto3" json:"references,omitempty"`
+	ReadGroups []*ReadGroup `protobuf:"bytes,3,rep,name=read_groups,json=readGroups,proto3" json:"read_groups,omitempty"`
+	Programs   []*Program   `protobuf:"bytes,4,rep,name=programs,proto3" json:"programs,omitempty"`
+	Comments   []string     `protobuf:"bytes,5,rep,name=comments,proto3" json:"comments,omitempty"`
+}
+
+func (x *Header) Reset() {
+	*x = Header{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Header) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Header) ProtoMessage() {}
+
+func (x *Header) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Header.ProtoReflect.Descriptor instead.
+func (*Header) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Header) GetFields() []*Field {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *Header) GetReferences() []*Reference {
+	if x != nil {
+		return x.References
+	}
+	return nil
+}
+
+func (x *Header) GetReadGroups() []*ReadGroup {
+	if x != nil {
+		return x.ReadGroups
+	}
+	return nil
+}
+
+func (x *Header) GetPrograms() []*Program {
+	if x != nil {
+		return x.Programs
+	}
+	return nil
+}
+
+func (x *Header) GetComments() []string {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+// Field is a tag-value field of a SAM header line.
+type Field struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tag   string `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *Field) Reset() {
+	*x = Field{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Field) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Field) ProtoMessage() {}
+
+func (x *Field) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Field.ProtoReflect.Descriptor instead.
+func (*Field) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Field) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *Field) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// Reference is an @SQ header line.
+type Reference struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name   string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Length int32  `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	// fields holds the fields of the line
+	// other than SN and LN.
+	Fields []*Field `protobuf:"bytes,3,rep,name=fields,proto3" json:"fields,omitempty"`
+}
+
+func (x *Reference) Reset() {
+	*x = Reference{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Reference) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reference) ProtoMessage() {}
+
+func (x *Reference) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reference.ProtoReflect.Descriptor instead.
+func (*Reference) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Reference) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Reference) GetLength() int32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *Reference) GetFields() []*Field {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+// ReadGroup is an @RG header line.
+type ReadGroup struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// fields holds the fields of the line
+	// other than ID.
+	Fields []*Field `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+}
+
+func (x *ReadGroup) Reset() {
+	*x = ReadGroup{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReadGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadGroup) ProtoMessage() {}
+
+func (x *ReadGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadGroup.ProtoReflect.Descriptor instead.
+func (*ReadGroup) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReadGroup) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReadGroup) GetFields() []*Field {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+// Program is a @PG header line.
+type Program struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// fields holds the fields of the line
+	// other than ID.
+	Fields []*Field `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+}
+
+func (x *Program) Reset() {
+	*x = Program{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Program) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Program) ProtoMessage() {}
+
+func (x *Program) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Program.ProtoReflect.Descriptor instead.
+func (*Program) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Program) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Program) GetFields() []*Field {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+// Record is an alignment record.
+type Record struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// ref_id and mate_ref_id are the indexes of the
+	// references of the record and its mate in the
+	// header, or -1 if the record or mate is not
+	// placed.
+	RefId     int32 `protobuf:"varint,2,opt,name=ref_id,json=refId,proto3" json:"ref_id,omitempty"`
+	MateRefId int32 `protobuf:"varint,3,opt,name=mate_ref_id,json=mateRefId,proto3" json:"mate_ref_id,omitempty"`
+	// pos and mate_pos are zero-based, or -1 if
+	// the record or mate is not placed.
+	Pos     int32  `protobuf:"varint,4,opt,name=pos,proto3" json:"pos,omitempty"`
+	MatePos int32  `protobuf:"varint,5,opt,name=mate_pos,json=matePos,proto3" json:"mate_pos,omitempty"`
+	Flags   uint32 `protobuf:"varint,6,opt,name=flags,proto3" json:"flags,omitempty"`
+	MapQ    uint32 `protobuf:"varint,7,opt,name=map_q,json=mapQ,proto3" json:"map_q,omitempty"`
+	// cigar holds the CIGAR operations encoded
+	// as in BAM, the operation length shifted
+	// left by four bits and the operation type
+	// in the low bits.
+	Cigar          []uint32 `protobuf:"varint,8,rep,packed,name=cigar,proto3" json:"cigar,omitempty"`
+	TemplateLength int32    `protobuf:"varint,9,opt,name=template_length,json=templateLength,proto3" json:"template_length,omitempty"`
+	// seq holds the bases as ASCII letters.
+	Seq []byte `protobuf:"bytes,10,opt,name=seq,proto3" json:"seq,omitempty"`
+	// qual holds the Phred scaled base qualities,
+	// and is empty if the qualities are absent.
+	Qual []byte `protobuf:"bytes,11,opt,name=qual,proto3" json:"qual,omitempty"`
+	Aux  []*Aux `protobuf:"bytes,12,rep,name=aux,proto3" json:"aux,omitempty"`
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Record) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Record) GetRefId() int32 {
+	if x != nil {
+		return x.RefId
+	}
+	return 0
+}
+
+func (x *Record) GetMateRefId() int32 {
+	if x != nil {
+		return x.MateRefId
+	}
+	return 0
+}
+
+func (x *Record) GetPos() int32 {
+	if x != nil {
+		return x.Pos
+	}
+	return 0
+}
+
+func (x *Record) GetMatePos() int32 {
+	if x != nil {
+		return x.MatePos
+	}
+	return 0
+}
+
+func (x *Record) GetFlags() uint32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *Record) GetMapQ() uint32 {
+	if x != nil {
+		return x.MapQ
+	}
+	return 0
+}
+
+func (x *Record) GetCigar() []uint32 {
+	if x != nil {
+		return x.Cigar
+	}
+	return nil
+}
+
+func (x *Record) GetTemplateLength() int32 {
+	if x != nil {
+		return x.TemplateLength
+	}
+	return 0
+}
+
+func (x *Record) GetSeq() []byte {
+	if x != nil {
+		return x.Seq
+	}
+	return nil
+}
+
+func (x *Record) GetQual() []byte {
+	if x != nil {
+		return x.Qual
+	}
+	return nil
+}
+
+func (x *Record) GetAux() []*Aux {
+	if x != nil {
+		return x.Aux
+	}
+	return nil
+}
+
+// Aux is an auxiliary field of a record.
+type Aux struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// tag is the two character tag of the field.
+	Tag string `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// type is the SAM type character of the
+	// value, one of AcCsSiIfZHB.
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Types that are assignable to Value:
+	//	*Aux_Int
+	//	*Aux_Float
+	//	*Aux_Text
+	//	*Aux_Array
+	Value isAux_Value `protobuf_oneof:"value"`
+}
+
+func (x *Aux) Reset() {
+	*x = Aux{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Aux) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Aux) ProtoMessage() {}
+
+func (x *Aux) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Aux.ProtoReflect.Descriptor instead.
+func (*Aux) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Aux) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *Aux) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (m *Aux) GetValue() isAux_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (x *Aux) GetInt() int64 {
+	if x, ok := x.GetValue().(*Aux_Int); ok {
+		return x.Int
+	}
+	return 0
+}
+
+func (x *Aux) GetFloat() float32 {
+	if x, ok := x.GetValue().(*Aux_Float); ok {
+		return x.Float
+	}
+	return 0
+}
+
+func (x *Aux) GetText() string {
+	if x, ok := x.GetValue().(*Aux_Text); ok {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Aux) GetArray() *Array {
+	if x, ok := x.GetValue().(*Aux_Array); ok {
+		return x.Array
+	}
+	return nil
+}
+
+type isAux_Value interface {
+	isAux_Value()
+}
+
+type Aux_Int struct {
+	// int holds values of the integer types.
+	Int int64 `protobuf:"zigzag64,3,opt,name=int,proto3,oneof"`
+}
+
+type Aux_Float struct {
+	// float holds values of type f.
+	Float float32 `protobuf:"fixed32,4,opt,name=float,proto3,oneof"`
+}
+
+type Aux_Text struct {
+	// text holds values of types A, Z and H,
+	// the latter as hexadecimal digits.
+	Text string `protobuf:"bytes,5,opt,name=text,proto3,oneof"`
+}
+
+type Aux_Array struct {
+	// array holds values of type B.
+	Array *Array `protobuf:"bytes,6,opt,name=array,proto3,oneof"`
+}
+
+func (*Aux_Int) isAux_Value() {}
+
+func (*Aux_Float) isAux_Value() {}
+
+func (*Aux_Text) isAux_Value() {}
+
+func (*Aux_Array) isAux_Value() {}
+
+// Array is the value of an auxiliary field of type B.
+type Array struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// type is the SAM type character of the
+	// elements, one of cCsSiIf.
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// ints holds elements of the integer types
+	// and floats holds elements of type f.
+	Ints   []int64   `protobuf:"zigzag64,2,rep,packed,name=ints,proto3" json:"ints,omitempty"`
+	Floats []float32 `protobuf:"fixed32,3,rep,packed,name=floats,proto3" json:"floats,omitempty"`
+}
+
+func (x *Array) Reset() {
+	*x = Array{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Array) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Array) ProtoMessage() {}
+
+func (x *Array) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Array.ProtoReflect.Descriptor instead.
+func (*Array) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Array) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Array) GetInts() []int64 {
+	if x != nil {
+		return x.Ints
+	}
+	return nil
+}
+
+func (x *Array) GetFloats() []float32 {
+	if x != nil {
+		return x.Floats
+	}
+	return nil
+}
+
+// RecordBatch is a batch of records.
+type RecordBatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Records []*Record `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+}
+
+func (x *RecordBatch) Reset() {
+	*x = RecordBatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RecordBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordBatch) ProtoMessage() {}
+
+func (x *RecordBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordBatch.ProtoReflect.Descriptor instead.
+func (*RecordBatch) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RecordBatch) GetRecords() []*Record {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+// Frame is a message sent by a record stream sender. The
+// first frame holds the header and the last frame holds
+// either the end of the stream or an error.
+type Frame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Body:
+	//	*Frame_Header
+	//	*Frame_Batch
+	//	*Frame_End
+	//	*Frame_Error
+	Body isFrame_Body `protobuf_oneof:"body"`
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{9}
+}
+
+func (m *Frame) GetBody() isFrame_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *Frame) GetHeader() *Header {
+	if x, ok := x.GetBody().(*Frame_Header); ok {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *Frame) GetBatch() *RecordBatch {
+	if x, ok := x.GetBody().(*Frame_Batch); ok {
+		return x.Batch
+	}
+	return nil
+}
+
+func (x *Frame) GetEnd() *End {
+	if x, ok := x.GetBody().(*Frame_End); ok {
+		return x.End
+	}
+	return nil
+}
+
+func (x *Frame) GetError() string {
+	if x, ok := x.GetBody().(*Frame_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isFrame_Body interface {
+	isFrame_Body()
+}
+
+type Frame_Header struct {
+	Header *Header `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type Frame_Batch struct {
+	Batch *RecordBatch `protobuf:"bytes,2,opt,name=batch,proto3,oneof"`
+}
+
+type Frame_End struct {
+	End *End `protobuf:"bytes,3,opt,name=end,proto3,oneof"`
+}
+
+type Frame_Error struct {
+	Error string `protobuf:"bytes,4,opt,name=error,proto3,oneof"`
+}
+
+func (*Frame_Header) isFrame_Body() {}
+
+func (*Frame_Batch) isFrame_Body() {}
+
+func (*Frame_End) isFrame_Body() {}
+
+func (*Frame_Error) isFrame_Body() {}
+
+// End marks the end of a record stream.
+type End struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *End) Reset() {
+	*x = End{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *End) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*End) ProtoMessage() {}
+
+func (x *End) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use End.ProtoReflect.Descriptor instead.
+func (*End) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{10}
+}
+
+// Control is a message sent by a record stream receiver.
+type Control struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Body:
+	//	*Control_Credit
+	//	*Control_Done
+	Body isControl_Body `protobuf_oneof:"body"`
+}
+
+func (x *Control) Reset() {
+	*x = Control{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recstream_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Control) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Control) ProtoMessage() {}
+
+func (x *Control) ProtoReflect() protoreflect.Message {
+	mi := &file_recstream_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Control.ProtoReflect.Descriptor instead.
+func (*Control) Descriptor() ([]byte, []int) {
+	return file_recstream_proto_rawDescGZIP(), []int{11}
+}
+
+func (m *Control) GetBody() isControl_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *Control) GetCredit() uint32 {
+	if x, ok := x.GetBody().(*Control_Credit); ok {
+		return x.Credit
+	}
+	return 0
+}
+
+func (x *Control) GetDone() bool {
+	if x, ok := x.GetBody().(*Control_Done); ok {
+		return x.Done
+	}
+	return false
+}
+
+type isControl_Body interface {
+	isControl_Body()
+}
+
+type Control_Credit struct {
+	// credit is the number of further
+	// batches the receiver will accept.
+	Credit uint32 `protobuf:"varint,1,opt,name=credit,proto3,oneof"`
+}
+
+type Control_Done struct {
+	// done acknowledges the end of
+	// the stream.
+	Done bool `protobuf:"varint,2,opt,name=done,proto3,oneof"`
+}
+
+func (*Control_Credit) isControl_Body() {}
+
+func (*Control_Done) isControl_Body() {}
+
+var File_recstream_proto protoreflect.FileDescriptor
+
+var file_recstream_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x10, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x2e, 0x76, 0x31, 0x22, 0x87, 0x02, 0x0a, 0x06, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x2f,
+	0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x76,
+	0x31, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x52, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12,
+	0x3b, 0x0a, 0x0a, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65,
+	0x52, 0x0a, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x3c, 0x0a, 0x0b,
+	0x72, 0x65, 0x61, 0x64, 0x5f, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x52, 0x0a,
+	0x72, 0x65, 0x61, 0x64, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x12, 0x35, 0x0a, 0x08, 0x70, 0x72,
+	0x6f, 0x67, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68,
+	0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d,
+	0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x05, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x2f, 0x0a,
+	0x05, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x67, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x74, 0x61, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x68,
+	0x0a, 0x09, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x2f, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65,
+	0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x52, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x22, 0x4c, 0x0a, 0x09, 0x52, 0x65, 0x61, 0x64,
+	0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2f, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x52, 0x06,
+	0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x22, 0x4a, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x61,
+	0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x2f, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x52, 0x06, 0x66, 0x69, 0x65, 0x6c,
+	0x64, 0x73, 0x22, 0xb9, 0x02, 0x0a, 0x06, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x65, 0x66, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x72, 0x65, 0x66, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x0b, 0x6d, 0x61, 0x74, 0x65,
+	0x5f, 0x72, 0x65, 0x66, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x6d,
+	0x61, 0x74, 0x65, 0x52, 0x65, 0x66, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6f, 0x73, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x70, 0x6f, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x6d, 0x61,
+	0x74, 0x65, 0x5f, 0x70, 0x6f, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x6d, 0x61,
+	0x74, 0x65, 0x50, 0x6f, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x12, 0x13, 0x0a, 0x05, 0x6d,
+	0x61, 0x70, 0x5f, 0x71, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x6d, 0x61, 0x70, 0x51,
+	0x12, 0x14, 0x0a, 0x05, 0x63, 0x69, 0x67, 0x61, 0x72, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0d, 0x52,
+	0x05, 0x63, 0x69, 0x67, 0x61, 0x72, 0x12, 0x27, 0x0a, 0x0f, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61,
+	0x74, 0x65, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0e, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12,
+	0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73, 0x65,
+	0x71, 0x12, 0x12, 0x0a, 0x04, 0x71, 0x75, 0x61, 0x6c, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x04, 0x71, 0x75, 0x61, 0x6c, 0x12, 0x27, 0x0a, 0x03, 0x61, 0x75, 0x78, 0x18, 0x0c, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75, 0x78, 0x52, 0x03, 0x61, 0x75, 0x78, 0x22, 0xa7,
+	0x01, 0x0a, 0x03, 0x41, 0x75, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x67, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x74, 0x61, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x03,
+	0x69, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x12, 0x48, 0x00, 0x52, 0x03, 0x69, 0x6e, 0x74,
+	0x12, 0x16, 0x0a, 0x05, 0x66, 0x6c, 0x6f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x48,
+	0x00, 0x52, 0x05, 0x66, 0x6c, 0x6f, 0x61, 0x74, 0x12, 0x14, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x2f,
+	0x0a, 0x05, 0x61, 0x72, 0x72, 0x61, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e,
+	0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x76, 0x31,
+	0x2e, 0x41, 0x72, 0x72, 0x61, 0x79, 0x48, 0x00, 0x52, 0x05, 0x61, 0x72, 0x72, 0x61, 0x79, 0x42,
+	0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x47, 0x0a, 0x05, 0x41, 0x72, 0x72, 0x61,
+	0x79, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x12, 0x52, 0x04, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6c, 0x6f,
+	0x61, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x02, 0x52, 0x06, 0x66, 0x6c, 0x6f, 0x61, 0x74,
+	0x73, 0x22, 0x41, 0x0a, 0x0b, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x12, 0x32, 0x0a, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x72, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x73, 0x22, 0xbd, 0x01, 0x0a, 0x05, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x32,
+	0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x76,
+	0x31, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x48, 0x00, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x12, 0x35, 0x0a, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1d, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x48, 0x00, 0x52, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x12, 0x29, 0x0a, 0x03, 0x65, 0x6e, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63,
+	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x64, 0x48, 0x00, 0x52,
+	0x03, 0x65, 0x6e, 0x64, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x06, 0x0a, 0x04,
+	0x62, 0x6f, 0x64, 0x79, 0x22, 0x05, 0x0a, 0x03, 0x45, 0x6e, 0x64, 0x22, 0x41, 0x0a, 0x07, 0x43,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x12, 0x18, 0x0a, 0x06, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x06, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74,
+	0x12, 0x14, 0x0a, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00,
+	0x52, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x32, 0x50,
+	0x0a, 0x0c, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x40,
+	0x0a, 0x06, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x19, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72,
+	0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x74,
+	0x72, 0x6f, 0x6c, 0x1a, 0x17, 0x2e, 0x68, 0x74, 0x73, 0x2e, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x28, 0x01, 0x30, 0x01,
+	0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x53,
+	0x63, 0x68, 0x61, 0x75, 0x64, 0x67, 0x65, 0x2f, 0x68, 0x74, 0x73, 0x2f, 0x72, 0x65, 0x63, 0x73,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x2f, 0x72, 0x65, 0x63, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x70,
+	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_recstream_proto_rawDescOnce sync.Once
+	file_recstream_proto_rawDescData = file_recstream_proto_rawDesc
+)
+
+func file_recstream_proto_rawDescGZIP() []byte {
+	file_recstream_proto_rawDescOnce.Do(func() {
+		file_recstream_proto_rawDescData = protoimpl.X.CompressGZIP(file_recstream_proto_rawDescData)
+	})
+	return file_recstream_proto_rawDescData
+}
+
+var file_recstream_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_recstream_proto_goTypes = []interface{}{
+	(*Header)(nil),      // 0: hts.recstream.v1.Header
+	(*Field)(nil),       // 1: hts.recstream.v1.Field
+	(*Reference)(nil),   // 2: hts.recstream.v1.Reference
+	(*ReadGroup)(nil),   // 3: hts.recstream.v1.ReadGroup
+	(*Program)(nil),     // 4: hts.recstream.v1.Program
+	(*Record)(nil),      // 5: hts.recstream.v1.Record
+	(*Aux)(nil),         // 6: hts.recstream.v1.Aux
+	(*Array)(nil),       // 7: hts.recstream.v1.Array
+	(*RecordBatch)(nil), // 8: hts.recstream.v1.RecordBatch
+	(*Frame)(nil),       // 9: hts.recstream.v1.Frame
+	(*End)(nil),         // 10: hts.recstream.v1.End
+	(*Control)(nil),     // 11: hts.recstream.v1.Control
+}
+var file_recstream_proto_depIdxs = []int32{
+	1,  // 0: hts.recstream.v1.Header.fields:type_name -> hts.recstream.v1.Field
+	2,  // 1: hts.recstream.v1.Header.references:type_name -> hts.recstream.v1.Reference
+	3,  // 2: hts.recstream.v1.Header.read_groups:type_name -> hts.recstream.v1.ReadGroup
+	4,  // 3: hts.recstream.v1.Header.programs:type_name -> hts.recstream.v1.Program
+	1,  // 4: hts.recstream.v1.Reference.fields:type_name -> hts.recstream.v1.Field
+	1,  // 5: hts.recstream.v1.ReadGroup.fields:type_name -> hts.recstream.v1.Field
+	1,  // 6: hts.recstream.v1.Program.fields:type_name -> hts.recstream.v1.Field
+	6,  // 7: hts.recstream.v1.Record.aux:type_name -> hts.recstream.v1.Aux
+	7,  // 8: hts.recstream.v1.Aux.array:type_name -> hts.recstream.v1.Array
+	5,  // 9: hts.recstream.v1.RecordBatch.records:type_name -> hts.recstream.v1.Record
+	0,  // 10: hts.recstream.v1.Frame.header:type_name -> hts.recstream.v1.Header
+	8,  // 11: hts.recstream.v1.Frame.batch:type_name -> hts.recstream.v1.RecordBatch
+	10, // 12: hts.recstream.v1.Frame.end:type_name -> hts.recstream.v1.End
+	11, // 13: hts.recstream.v1.RecordStream.Stream:input_type -> hts.recstream.v1.Control
+	9,  // 14: hts.recstream.v1.RecordStream.Stream:output_type -> hts.recstream.v1.Frame
+	14, // [14:15] is the sub-list for method output_type
+	13, // [13:14] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_recstream_proto_init() }
+func file_recstream_proto_init() {
+	if File_recstream_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_recstream_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Header); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Field); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Reference); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadGroup); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Program); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Record); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Aux); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Array); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RecordBatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Frame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*End); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recstream_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Control); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_recstream_proto_msgTypes[6].OneofWrappers = []interface{}{
+		(*Aux_Int)(nil),
+		(*Aux_Float)(nil),
+		(*Aux_Text)(nil),
+		(*Aux_Array)(nil),
+	}
+	file_recstream_proto_msgTypes[9].OneofWrappers = []interface{}{
+		(*Frame_Header)(nil),
+		(*Frame_Batch)(nil),
+		(*Frame_End)(nil),
+		(*Frame_Error)(nil),
+	}
+	file_recstream_proto_msgTypes[11].OneofWrappers = []interface{}{
+		(*Control_Credit)(nil),
+		(*Control_Done)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_recstream_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_recstream_proto_goTypes,
+		DependencyIndexes: file_recstream_proto_depIdxs,
+		MessageInfos:      file_recstream_proto_msgTypes,
+	}.Build()
+	File_recstream_proto = out.File
+	file_recstream_proto_rawDesc = nil
+	file_recstream_proto_goTypes = nil
+	file_recstream_proto_depIdxs = nil
+}
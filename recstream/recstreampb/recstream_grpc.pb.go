@@ -0,0 +1,153 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Record stream service definitions.
+//
+// Headers and records are carried as structured messages holding the
+// fields of sam.Header and sam.Record. The messages correspond to the
+// frames of the recstream package, which provides server and client
+// adapters for the service and implements the same protocol over any
+// reliable byte stream.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: recstream.proto
+
+package recstreampb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	RecordStream_Stream_FullMethodName = "/hts.recstream.v1.RecordStream/Stream"
+)
+
+// RecordStreamClient is the client API for RecordStream service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RecordStreamClient interface {
+	Stream(ctx context.Context, opts ...grpc.CallOption) (RecordStream_StreamClient, error)
+}
+
+type recordStreamClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRecordStreamClient(cc grpc.ClientConnInterface) RecordStreamClient {
+	return &recordStreamClient{cc}
+}
+
+func (c *recordStreamClient) Stream(ctx context.Context, opts ...grpc.CallOption) (RecordStream_StreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RecordStream_ServiceDesc.Streams[0], RecordStream_Stream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &recordStreamStreamClient{stream}
+	return x, nil
+}
+
+type RecordStream_StreamClient interface {
+	Send(*Control) error
+	Recv() (*Frame, error)
+	grpc.ClientStream
+}
+
+type recordStreamStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *recordStreamStreamClient) Send(m *Control) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *recordStreamStreamClient) Recv() (*Frame, error) {
+	m := new(Frame)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RecordStreamServer is the server API for RecordStream service.
+// All implementations must embed UnimplementedRecordStreamServer
+// for forward compatibility
+type RecordStreamServer interface {
+	Stream(RecordStream_StreamServer) error
+	mustEmbedUnimplementedRecordStreamServer()
+}
+
+// UnimplementedRecordStreamServer must be embedded to have forward compatible implementations.
+type UnimplementedRecordStreamServer struct {
+}
+
+func (UnimplementedRecordStreamServer) Stream(RecordStream_StreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedRecordStreamServer) mustEmbedUnimplementedRecordStreamServer() {}
+
+// UnsafeRecordStreamServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RecordStreamServer will
+// result in compilation errors.
+type UnsafeRecordStreamServer interface {
+	mustEmbedUnimplementedRecordStreamServer()
+}
+
+func RegisterRecordStreamServer(s grpc.ServiceRegistrar, srv RecordStreamServer) {
+	s.RegisterService(&RecordStream_ServiceDesc, srv)
+}
+
+func _RecordStream_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RecordStreamServer).Stream(&recordStreamStreamServer{stream})
+}
+
+type RecordStream_StreamServer interface {
+	Send(*Frame) error
+	Recv() (*Control, error)
+	grpc.ServerStream
+}
+
+type recordStreamStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *recordStreamStreamServer) Send(m *Frame) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *recordStreamStreamServer) Recv() (*Control, error) {
+	m := new(Control)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RecordStream_ServiceDesc is the grpc.ServiceDesc for RecordStream service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RecordStream_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hts.recstream.v1.RecordStream",
+	HandlerType: (*RecordStreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _RecordStream_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "recstream.proto",
+}
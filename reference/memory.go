@@ -0,0 +1,127 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package reference provides implementations of sam.ReferenceProvider
+// for in-memory genomes and for remote sequences retrieved with the
+// GA4GH refget protocol. Indexed FASTA files are provided by fai.File.
+package reference
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// Memory is a sam.ReferenceProvider holding reference sequences in
+// memory. A Memory is safe for concurrent use once all its sequences
+// have been added.
+type Memory struct {
+	names []string
+	seqs  map[string][]byte
+	md5   map[string][]byte
+}
+
+// NewMemory returns an empty Memory.
+func NewMemory() *Memory {
+	return &Memory{
+		seqs: make(map[string][]byte),
+		md5:  make(map[string][]byte),
+	}
+}
+
+// ReadFASTA returns a Memory holding the sequences read from the FASTA
+// data in r. Sequence names are the first word of each header line.
+func ReadFASTA(r io.Reader) (*Memory, error) {
+	m := NewMemory()
+	br := bufio.NewReader(r)
+	var (
+		name string
+		seq  []byte
+		has  bool
+		line int
+	)
+	for {
+		b, err := br.ReadBytes('\n')
+		if len(b) == 0 && err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+		line++
+		b = bytes.TrimRight(b, "\r\n")
+		if len(b) != 0 && b[0] == '>' {
+			if has {
+				err = m.Add(name, seq)
+				if err != nil {
+					return nil, err
+				}
+			}
+			f := bytes.Fields(b[1:])
+			if len(f) == 0 {
+				return nil, fmt.Errorf("reference: empty sequence name at line %d", line)
+			}
+			name, seq, has = string(f[0]), nil, true
+			continue
+		}
+		if !has && len(bytes.TrimSpace(b)) != 0 {
+			return nil, fmt.Errorf("reference: sequence data before header at line %d", line)
+		}
+		seq = append(seq, bytes.TrimSpace(b)...)
+	}
+	if has {
+		err := m.Add(name, seq)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Add adds the named sequence to the Memory. It is an error to add a
+// sequence with the name of a sequence already held.
+func (m *Memory) Add(name string, seq []byte) error {
+	if _, ok := m.seqs[name]; ok {
+		return fmt.Errorf("reference: duplicate sequence name: %q", name)
+	}
+	m.names = append(m.names, name)
+	m.seqs[name] = seq
+	m.md5[name] = sam.SequenceMD5(seq)
+	return nil
+}
+
+// Names returns the names of the sequences held by the Memory in the
+// order they were added.
+func (m *Memory) Names() []string { return m.names }
+
+// GetSequence returns the bases of the named sequence in the zero-based
+// half-open interval [beg,end). If end is negative or beyond the end of
+// the sequence, the bases to the end of the sequence are returned. The
+// returned slice must not be altered.
+func (m *Memory) GetSequence(name string, beg, end int) ([]byte, error) {
+	seq, ok := m.seqs[name]
+	if !ok {
+		return nil, fmt.Errorf("reference: no sequence %q", name)
+	}
+	if end < 0 || end > len(seq) {
+		end = len(seq)
+	}
+	if beg < 0 || beg > end {
+		return nil, fmt.Errorf("reference: invalid interval [%d,%d) for %q", beg, end, name)
+	}
+	return seq[beg:end:end], nil
+}
+
+// MD5 returns the MD5 checksum of the named sequence as described for
+// the @SQ M5 tag.
+func (m *Memory) MD5(name string) ([]byte, error) {
+	sum, ok := m.md5[name]
+	if !ok {
+		return nil, fmt.Errorf("reference: no sequence %q", name)
+	}
+	return sum, nil
+}
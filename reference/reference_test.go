@@ -0,0 +1,145 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reference
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/cram"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	_ sam.ReferenceProvider  = (*Memory)(nil)
+	_ sam.ReferenceProvider  = (*Refget)(nil)
+	_ cram.ReferenceProvider = (*Memory)(nil)
+)
+
+const testFasta = `>one first sequence
+ACGTACGTAC
+gtacgtacgt
+ACG
+>two
+CCCCGGGG
+TTTTAAAA
+`
+
+func TestMemory(t *testing.T) {
+	m, err := ReadFASTA(strings.NewReader(testFasta))
+	if err != nil {
+		t.Fatalf("unexpected error reading FASTA: %v", err)
+	}
+	if got := strings.Join(m.Names(), ","); got != "one,two" {
+		t.Errorf("unexpected names: %s", got)
+	}
+	for _, test := range []struct {
+		name     string
+		beg, end int
+		want     string
+		err      bool
+	}{
+		{name: "one", beg: 0, end: -1, want: "ACGTACGTACgtacgtacgtACG"},
+		{name: "one", beg: 20, end: 100, want: "ACG"},
+		{name: "two", beg: 2, end: 6, want: "CCGG"},
+		{name: "two", beg: 6, end: 2, err: true},
+		{name: "three", beg: 0, end: 1, err: true},
+	} {
+		got, err := m.GetSequence(test.name, test.beg, test.end)
+		if (err != nil) != test.err {
+			t.Errorf("unexpected error state for %s:[%d,%d): %v", test.name, test.beg, test.end, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("unexpected sequence for %s:[%d,%d): got:%q want:%q", test.name, test.beg, test.end, got, test.want)
+		}
+	}
+	got, err := m.MD5("one")
+	if err != nil {
+		t.Fatalf("unexpected error getting MD5: %v", err)
+	}
+	want := md5.Sum([]byte("ACGTACGTACGTACGTACGTACG"))
+	if !bytes.Equal(got, want[:]) {
+		t.Errorf("unexpected MD5: got:%x want:%x", got, want)
+	}
+	if err = m.Add("two", nil); err == nil {
+		t.Error("expected error for duplicate sequence")
+	}
+	_, err = ReadFASTA(strings.NewReader("ACGT\n>one\nACGT\n"))
+	if err == nil {
+		t.Error("expected error for sequence before header")
+	}
+}
+
+func TestRefget(t *testing.T) {
+	const seq = "GATTACAGATTACA"
+	sum := md5.Sum([]byte(seq))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/refget/sequence/"+hex.EncodeToString(sum[:]) {
+			http.NotFound(w, r)
+			return
+		}
+		beg, end := 0, len(seq)
+		if v := r.URL.Query().Get("start"); v != "" {
+			beg, _ = strconv.Atoi(v)
+		}
+		if v := r.URL.Query().Get("end"); v != "" {
+			end, _ = strconv.Atoi(v)
+		}
+		w.Write([]byte(seq[beg:end]))
+	}))
+	defer srv.Close()
+
+	ref, err := sam.NewReference("chrT", "", "", len(seq), sum[:], nil)
+	if err != nil {
+		t.Fatalf("unexpected error making reference: %v", err)
+	}
+	bad, err := sam.NewReference("chrB", "", "", 10, make([]byte, 16), nil)
+	if err != nil {
+		t.Fatalf("unexpected error making reference: %v", err)
+	}
+	r, err := NewRefget(srv.URL+"/refget/", []*sam.Reference{ref, bad})
+	if err != nil {
+		t.Fatalf("unexpected error making refget client: %v", err)
+	}
+	for _, test := range []struct {
+		beg, end int
+		want     string
+	}{
+		{beg: 0, end: -1, want: seq},
+		{beg: 2, end: 6, want: "TTAC"},
+		{beg: 10, end: 100, want: "TACA"},
+		{beg: 5, end: 5, want: ""},
+	} {
+		got, err := r.GetSequence("chrT", test.beg, test.end)
+		if err != nil {
+			t.Errorf("unexpected error for [%d,%d): %v", test.beg, test.end, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("unexpected sequence for [%d,%d): got:%q want:%q", test.beg, test.end, got, test.want)
+		}
+	}
+	got, err := r.MD5("chrT")
+	if err != nil || !bytes.Equal(got, sum[:]) {
+		t.Errorf("unexpected MD5: got:%x want:%x err:%v", got, sum, err)
+	}
+	_, err = r.GetSequence("chrB", 0, 5)
+	if err == nil {
+		t.Error("expected error for missing remote sequence")
+	}
+
+	noMD5, _ := sam.NewReference("chrN", "", "", 10, nil, nil)
+	_, err = NewRefget(srv.URL, []*sam.Reference{noMD5})
+	if err == nil {
+		t.Error("expected error for reference without MD5")
+	}
+}
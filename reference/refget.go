@@ -0,0 +1,112 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reference
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// ENA is the base URL of the European Nucleotide Archive refget
+// service, which serves sequences by their M5 checksums.
+const ENA = "https://www.ebi.ac.uk/ena/cram"
+
+// Refget is a sam.ReferenceProvider retrieving sequences from a GA4GH
+// refget server. Sequences are identified to the server by the MD5
+// checksums held in the M5 tags of their SAM header references.
+//
+// The refget protocol is described in the refget specification.
+//
+// https://samtools.github.io/hts-specs/refget.html
+type Refget struct {
+	// BaseURL is the URL of the refget
+	// service, below which sequences are
+	// retrieved from /sequence/{md5}.
+	BaseURL string
+
+	// HTTPClient is the client used for
+	// requests. If nil, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+
+	refs map[string]*sam.Reference
+}
+
+// NewRefget returns a Refget retrieving the given references from the
+// refget service at baseURL. Each reference must have an MD5 checksum.
+func NewRefget(baseURL string, refs []*sam.Reference) (*Refget, error) {
+	r := &Refget{BaseURL: baseURL, refs: make(map[string]*sam.Reference, len(refs))}
+	for _, ref := range refs {
+		if ref.MD5() == nil {
+			return nil, fmt.Errorf("reference: no md5 for %q", ref.Name())
+		}
+		r.refs[ref.Name()] = ref
+	}
+	return r, nil
+}
+
+// GetSequence returns the bases of the named sequence in the zero-based
+// half-open interval [beg,end). If end is negative or beyond the end of
+// the sequence, the bases to the end of the sequence are returned.
+func (r *Refget) GetSequence(name string, beg, end int) ([]byte, error) {
+	ref, ok := r.refs[name]
+	if !ok {
+		return nil, fmt.Errorf("reference: no sequence %q", name)
+	}
+	if end < 0 || end > ref.Len() {
+		end = ref.Len()
+	}
+	if beg < 0 || beg > end {
+		return nil, fmt.Errorf("reference: invalid interval [%d,%d) for %q", beg, end, name)
+	}
+	if beg == end {
+		return []byte{}, nil
+	}
+	u := strings.TrimSuffix(r.BaseURL, "/") + "/sequence/" + hex.EncodeToString(ref.MD5())
+	if beg != 0 || end != ref.Len() {
+		u += "?start=" + strconv.Itoa(beg) + "&end=" + strconv.Itoa(end)
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/vnd.ga4gh.refget.v1.0.0+plain, text/plain")
+	c := r.HTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reference: refget request for %q failed: %s", name, resp.Status)
+	}
+	seq, err := io.ReadAll(io.LimitReader(resp.Body, int64(end-beg)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(seq) != end-beg {
+		return nil, fmt.Errorf("reference: unexpected sequence length for %q: got %d want %d", name, len(seq), end-beg)
+	}
+	return seq, nil
+}
+
+// MD5 returns the MD5 checksum of the named sequence from its header
+// reference.
+func (r *Refget) MD5(name string) ([]byte, error) {
+	ref, ok := r.refs[name]
+	if !ok {
+		return nil, fmt.Errorf("reference: no sequence %q", name)
+	}
+	return ref.MD5(), nil
+}
@@ -0,0 +1,329 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package remote implements random access to files served over HTTP(S)
+// using range requests, and indexed access to remote BAM files.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/sam"
+)
+
+const (
+	// DefaultParallel is the default number of
+	// concurrent range requests made by a read.
+	DefaultParallel = 4
+
+	// DefaultPartSize is the default minimum
+	// length of each range request made by a read.
+	DefaultPartSize = 1 << 20
+)
+
+var (
+	ErrNoRange = errors.New("remote: range requests not supported")
+	ErrNoSize  = errors.New("remote: file size not reported")
+)
+
+// Error is an unsuccessful HTTP response.
+type Error struct {
+	Status int
+	URL    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("remote: %s: %d %s", e.URL, e.Status, http.StatusText(e.Status))
+}
+
+// Options specifies the behaviour of remote file access.
+type Options struct {
+	// HTTPClient is the client used for
+	// requests. Redirects are followed as
+	// specified by the client. If nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Auth is called on each HTTP request
+	// to allow authentication headers to be
+	// added.
+	Auth func(*http.Request) error
+
+	// Retries is the number of times a
+	// request is retried after a network
+	// error or a 5xx or 429 response.
+	Retries int
+
+	// Backoff returns the delay before the
+	// given retry attempt, counting from one.
+	// If nil, an exponential backoff from
+	// 100ms is used.
+	Backoff func(attempt int) time.Duration
+
+	// Parallel is the maximum number of
+	// concurrent range requests made by a
+	// single read, and PartSize is the
+	// minimum length of each request. If
+	// zero, DefaultParallel and
+	// DefaultPartSize are used.
+	Parallel int
+	PartSize int
+}
+
+// File is an io.ReaderAt reading a file served over HTTP(S). Each read
+// is made using range requests.
+type File struct {
+	ctx  context.Context
+	url  string
+	size int64
+	opts Options
+}
+
+// Open returns a File reading the file at the given URL. The size of
+// the file is obtained by a request for its first byte. The context is
+// used for all requests made by the File.
+func Open(ctx context.Context, url string, opts Options) (*File, error) {
+	if opts.Parallel < 1 {
+		opts.Parallel = DefaultParallel
+	}
+	if opts.PartSize < 1 {
+		opts.PartSize = DefaultPartSize
+	}
+	f := &File{ctx: ctx, url: url, opts: opts}
+	resp, err := f.get(0, 1)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	cr := resp.Header.Get("Content-Range")
+	i := strings.LastIndexByte(cr, '/')
+	if i < 0 {
+		return nil, ErrNoSize
+	}
+	f.size, err = strconv.ParseInt(cr[i+1:], 10, 64)
+	if err != nil {
+		return nil, ErrNoSize
+	}
+	return f, nil
+}
+
+// Size returns the size of the file.
+func (f *File) Size() int64 { return f.size }
+
+// ReadAt implements the io.ReaderAt interface. Reads longer than twice
+// the part size are made using concurrent range requests.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("remote: negative offset")
+	}
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	var eof error
+	if int64(len(p)) > f.size-off {
+		p = p[:f.size-off]
+		eof = io.EOF
+	}
+
+	parts := len(p) / f.opts.PartSize
+	if parts > f.opts.Parallel {
+		parts = f.opts.Parallel
+	}
+	if parts < 1 {
+		parts = 1
+	}
+	size := (len(p) + parts - 1) / parts
+	errs := make(chan error, parts)
+	for beg := 0; beg < len(p); beg += size {
+		end := beg + size
+		if end > len(p) {
+			end = len(p)
+		}
+		go func(b []byte, off int64) {
+			errs <- f.readPart(b, off)
+		}(p[beg:end], off+int64(beg))
+	}
+	var err error
+	for beg := 0; beg < len(p); beg += size {
+		if e := <-errs; err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), eof
+}
+
+// readPart fills b with the data of the file at off using a single
+// range request.
+func (f *File) readPart(b []byte, off int64) error {
+	resp, err := f.get(off, len(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.ReadFull(resp.Body, b)
+	return err
+}
+
+// get performs a request for n bytes of the file at off, retrying
+// according to the File's retry policy. A successful response is
+// returned with its body open.
+func (f *File) get(off int64, n int) (*http.Response, error) {
+	client := f.opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt != 0 {
+			t := time.NewTimer(f.backoff(attempt))
+			select {
+			case <-f.ctx.Done():
+				t.Stop()
+				return nil, f.ctx.Err()
+			case <-t.C:
+			}
+		}
+		req, err := http.NewRequestWithContext(f.ctx, http.MethodGet, f.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(n)-1))
+		if f.opts.Auth != nil {
+			err = f.opts.Auth(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			if attempt < f.opts.Retries && f.ctx.Err() == nil {
+				continue
+			}
+			return nil, err
+		}
+		switch {
+		case resp.StatusCode == http.StatusPartialContent:
+			return resp, nil
+		case (resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests) && attempt < f.opts.Retries:
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return nil, ErrNoRange
+		}
+		return nil, &Error{Status: resp.StatusCode, URL: f.url}
+	}
+}
+
+func (f *File) backoff(attempt int) time.Duration {
+	if f.opts.Backoff == nil {
+		return 100 * time.Millisecond << uint(attempt-1)
+	}
+	return f.opts.Backoff(attempt)
+}
+
+// BAM is an indexed remote BAM file.
+type BAM struct {
+	*bam.Reader
+
+	// Index is the BAI index
+	// of the BAM file.
+	Index *bam.Index
+
+	ranges *bgzf.RangeReader
+}
+
+// OpenIndexed returns a BAM reading the BAM file at the given URL using
+// the BAI index at the URL with ".bai" appended to its path, reading
+// with rd goroutines for decompression. Reads of the BAM data are made
+// through a bgzf.RangeReader so that the chunks of each query are
+// fetched with few requests.
+func OpenIndexed(ctx context.Context, url string, rd int, opts Options) (*BAM, error) {
+	baiURL, err := indexURL(url, ".bai")
+	if err != nil {
+		return nil, err
+	}
+	bai, err := Open(ctx, baiURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, bai.Size())
+	_, err = bai.ReadAt(buf, 0)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := bam.ReadIndex(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	f, err := Open(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
+	ranges := bgzf.NewRangeReader(f, f.Size(), bgzf.RangeOptions{})
+	br, err := bam.NewReader(io.NewSectionReader(ranges, 0, f.Size()), rd)
+	if err != nil {
+		return nil, err
+	}
+	return &BAM{Reader: br, Index: idx, ranges: ranges}, nil
+}
+
+// Query returns an iterator over the records overlapping the interval
+// [beg,end) on ref. The chunks of the query are planned so that they
+// are fetched with few requests.
+func (b *BAM) Query(ref *sam.Reference, beg, end int) (*bam.Iterator, error) {
+	chunks, err := b.Index.Chunks(ref, beg, end)
+	if err != nil {
+		return nil, err
+	}
+	b.ranges.Plan(chunks)
+	return bam.NewIterator(b.Reader, chunks)
+}
+
+// QueryBED returns an iterator over the records overlapping any of the
+// features read from r, as bam.QueryBED does for a local file. The
+// chunks of all features are planned together so that they are fetched
+// with few requests.
+func (b *BAM) QueryBED(r *bed.Reader) (*bam.Iterator, error) {
+	regions, err := bam.ReadBEDRegions(r, b.Header())
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := b.Index.RegionChunks(regions)
+	if err != nil {
+		return nil, err
+	}
+	b.ranges.Plan(chunks)
+	return bam.NewRegionIterator(b.Reader, b.Index, regions)
+}
+
+// indexURL returns u with ext appended to its path.
+func indexURL(u, ext string) (string, error) {
+	p, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	p.Path += ext
+	if p.RawPath != "" {
+		p.RawPath += ext
+	}
+	return p.String(), nil
+}
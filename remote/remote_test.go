@@ -0,0 +1,208 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/bed"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestOpenIndexed(t *testing.T) {
+	chr1, err := sam.NewReference("chr1", "", "", 1e6, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	h, err := sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	h.SortOrder = sam.Coordinate
+	var data bytes.Buffer
+	w, err := bam.NewWriter(&data, h, 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	const n = 5000
+	for i := 0; i < n; i++ {
+		cigar := []sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 10)}
+		r, err := sam.NewRecord(fmt.Sprintf("r%d", i), chr1, nil, i*100, -1, 0, 60, cigar, []byte("ACGTACGTAC"), nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating record: %v", err)
+		}
+		err = w.Write(r)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+	br, err := bam.NewReader(bytes.NewReader(data.Bytes()), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var idx bam.Index
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		err = idx.Add(r, br.LastChunk())
+		if err != nil {
+			t.Fatalf("unexpected error indexing record: %v", err)
+		}
+	}
+	var bai bytes.Buffer
+	err = bam.WriteIndex(&bai, &idx)
+	if err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+
+	// The server redirects to the data,
+	// requires authentication and fails
+	// the first data request.
+	var (
+		mu       sync.Mutex
+		requests int
+		failed   bool
+	)
+	files := map[string][]byte{"/data/sample.bam": data.Bytes(), "/data/sample.bam.bai": bai.Bytes()}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/files/") {
+			http.Redirect(w, r, "/data/"+strings.TrimPrefix(r.URL.Path, "/files/"), http.StatusFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		requests++
+		fail := !failed
+		failed = true
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(b))
+	}))
+	defer srv.Close()
+
+	opts := Options{
+		Auth: func(r *http.Request) error {
+			r.Header.Set("Authorization", "Bearer token")
+			return nil
+		},
+		Retries:  1,
+		Backoff:  func(int) time.Duration { return 0 },
+		PartSize: 1 << 10,
+	}
+	ctx := context.Background()
+	f, err := OpenIndexed(ctx, srv.URL+"/files/sample.bam", 1, opts)
+	if err != nil {
+		t.Fatalf("unexpected error opening remote BAM: %v", err)
+	}
+	defer f.Close()
+	mu.Lock()
+	requests = 0
+	mu.Unlock()
+
+	ref := f.Header().Refs()[0]
+	it, err := f.Query(ref, 100000, 200000)
+	if err != nil {
+		t.Fatalf("unexpected error querying: %v", err)
+	}
+	var got int
+	for it.Next() {
+		r := it.Record()
+		if r.End() > 100000 && r.Pos < 200000 {
+			got++
+		}
+	}
+	err = it.Close()
+	if err != nil {
+		t.Fatalf("unexpected error iterating: %v", err)
+	}
+	if got != 1000 {
+		t.Errorf("unexpected number of records: got:%d want:1000", got)
+	}
+	mu.Lock()
+	if requests > DefaultParallel {
+		t.Errorf("unexpected number of requests for query: got:%d", requests)
+	}
+	mu.Unlock()
+
+	// Records are placed every 100 bases,
+	// so the last feature overlaps none.
+	const features = "chr1\t1000\t1005\nchr1\t1003\t2000\nchr2\t0\t10\nchr1\t50050\t50095\n"
+	it, err = f.QueryBED(bed.NewReader(strings.NewReader(features)))
+	if err != nil {
+		t.Fatalf("unexpected error querying BED: %v", err)
+	}
+	var names []string
+	for it.Next() {
+		names = append(names, it.Record().Name)
+	}
+	err = it.Close()
+	if err != nil {
+		t.Fatalf("unexpected error iterating BED query: %v", err)
+	}
+	var want []string
+	for i := 10; i < 20; i++ {
+		want = append(want, fmt.Sprintf("r%d", i))
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("unexpected BED query records:\ngot: %v\nwant:%v", names, want)
+	}
+
+	_, err = OpenIndexed(ctx, srv.URL+"/files/sample.bam", 1, Options{})
+	if err == nil {
+		t.Error("expected error without authentication")
+	}
+	_, err = Open(ctx, srv.URL+"/files/missing.bam", opts)
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusNotFound {
+		t.Errorf("unexpected error for missing file: %v", err)
+	}
+}
+
+func TestIndexURL(t *testing.T) {
+	for _, test := range []struct {
+		url, want string
+	}{
+		{url: "https://example.org/a/sample.bam", want: "https://example.org/a/sample.bam.bai"},
+		{url: "https://example.org/sample.bam?sig=x%2By", want: "https://example.org/sample.bam.bai?sig=x%2By"},
+	} {
+		got, err := indexURL(test.url, ".bai")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != test.want {
+			t.Errorf("unexpected index URL for %q: got:%q want:%q", test.url, got, test.want)
+		}
+	}
+}
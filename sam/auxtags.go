@@ -194,7 +194,7 @@ func ParseAux(text []byte) (Aux, error) {
 		}
 		value = Hex(b)
 	case 'B':
-		if txt[1] != ',' {
+		if len(txt) < 2 || txt[1] != ',' {
 			return nil, fmt.Errorf("sam: invalid aux tag field: %q", text)
 		}
 		nf := bytes.Split(txt[2:], []byte{','})
@@ -427,7 +427,13 @@ func (a Aux) Value() interface{} {
 	case 'H':
 		return []byte(a[3:])
 	case 'B':
+		if len(a) < 8 {
+			return fmt.Errorf("%%B!(SHORT ARRAY len=%d)", len(a))
+		}
 		length := int32(binary.LittleEndian.Uint32(a[4:8]))
+		if length < 0 || int(length) > len(a)-8 {
+			return fmt.Errorf("%%B!(BAD ARRAY length=%d)", length)
+		}
 		switch t := a[3]; t {
 		case 'c':
 			c := a[8:]
@@ -438,35 +444,35 @@ func (a Aux) Value() interface{} {
 			Bs := make([]int16, length)
 			err := binary.Read(bytes.NewBuffer(a[8:]), binary.LittleEndian, &Bs)
 			if err != nil {
-				panic(fmt.Sprintf("sam: binary.Read of s field failed: %v", err))
+				return fmt.Errorf("%%B!(BAD ARRAY type=%c: %v)", t, err)
 			}
 			return Bs
 		case 'S':
 			BS := make([]uint16, length)
 			err := binary.Read(bytes.NewBuffer(a[8:]), binary.LittleEndian, &BS)
 			if err != nil {
-				panic(fmt.Sprintf("sam: binary.Read of S field failed: %v", err))
+				return fmt.Errorf("%%B!(BAD ARRAY type=%c: %v)", t, err)
 			}
 			return BS
 		case 'i':
 			Bi := make([]int32, length)
 			err := binary.Read(bytes.NewBuffer(a[8:]), binary.LittleEndian, &Bi)
 			if err != nil {
-				panic(fmt.Sprintf("sam: binary.Read of i field failed: %v", err))
+				return fmt.Errorf("%%B!(BAD ARRAY type=%c: %v)", t, err)
 			}
 			return Bi
 		case 'I':
 			BI := make([]uint32, length)
 			err := binary.Read(bytes.NewBuffer(a[8:]), binary.LittleEndian, &BI)
 			if err != nil {
-				panic(fmt.Sprintf("sam: binary.Read of I field failed: %v", err))
+				return fmt.Errorf("%%B!(BAD ARRAY type=%c: %v)", t, err)
 			}
 			return BI
 		case 'f':
 			Bf := make([]float32, length)
 			err := binary.Read(bytes.NewBuffer(a[8:]), binary.LittleEndian, &Bf)
 			if err != nil {
-				panic(fmt.Sprintf("sam: binary.Read of f field failed: %v", err))
+				return fmt.Errorf("%%B!(BAD ARRAY type=%c: %v)", t, err)
 			}
 			return Bf
 		default:
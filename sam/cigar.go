@@ -122,7 +122,12 @@ var cigarOps = []string{"M", "I", "D", "N", "S", "H", "P", "=", "X", "B", "?"}
 //  CigarMismatch       1        1
 //  CigarBack           0       -1
 //
-func (ct CigarOpType) Consumes() Consume { return consume[ct] }
+func (ct CigarOpType) Consumes() Consume {
+	if ct > lastCigar {
+		ct = lastCigar
+	}
+	return consume[ct]
+}
 
 // String returns the string representation of a CigarOpType.
 func (ct CigarOpType) String() string {
@@ -233,6 +238,7 @@ func ParseCigar(b []byte) (Cigar, error) {
 		err error
 	)
 	for i := 0; i < len(b); i++ {
+		op = lastCigar
 		for j := i; j < len(b); j++ {
 			if b[j] < '0' || '9' < b[j] {
 				n, err = atoi(b[i:j])
@@ -0,0 +1,58 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzUnmarshalSAM(f *testing.F) {
+	for _, seed := range []string{
+		"r0\t99\tchr1\t11\t60\t4M\t=\t21\t14\tACGT\tIIII\tNM:i:0\tRG:Z:g0\tXB:B:s,1,-2,3\tXH:H:1AE3\tXF:f:1.5\tXA:A:a",
+		"r1\t4\t*\t0\t0\t*\t*\t0\t0\tACGT\t*",
+		"r2\t0x10\tchr1\t1\t0\t2S2M\tchr2\t5\t0\tACGT\t#$%&",
+
+		// Malformed records.
+		"r3\t0\tchr1\t1\t0\t4M\t*\t0\t0\tACGT\tIIII\tXB:B:c",
+		"r4\t0\tchr1\t1\t0\t4M2\t*\t0\t0\tACGTAC\t*",
+		"r5\t0\tchr1\t1\t0\t5M\t*\t0\t0\tACGT\t*",
+		"r6\t0\tchr1",
+	} {
+		f.Add([]byte(seed))
+	}
+	chr1, _ := NewReference("chr1", "", "", 1000, nil, nil)
+	chr2, _ := NewReference("chr2", "", "", 1000, nil, nil)
+	h, err := NewHeader(nil, []*Reference{chr1, chr2})
+	if err != nil {
+		f.Fatalf("unexpected error creating header: %v", err)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, h := range []*Header{nil, h} {
+			var r Record
+			err := r.UnmarshalSAM(h, data)
+			if err != nil {
+				continue
+			}
+			b, err := r.MarshalSAM(FlagDecimal)
+			if err != nil {
+				continue
+			}
+			var got Record
+			err = got.UnmarshalSAM(h, b)
+			if err != nil {
+				t.Fatalf("failed to unmarshal marshaled record %q: %v", b, err)
+			}
+			rb, err := got.MarshalSAM(FlagDecimal)
+			if err != nil {
+				t.Fatalf("failed to marshal round trip record %q: %v", b, err)
+			}
+			if !bytes.Equal(rb, b) {
+				t.Errorf("unexpected round trip:\ngot: %q\nwant:%q", rb, b)
+			}
+		}
+	})
+}
@@ -10,7 +10,7 @@ import (
 	gunsafe "github.com/Schaudge/grailbase/unsafe"
 )
 
-var recordPool    = NewRecordFreePool(func() *Record { return &Record{} }, 1<<20)
+var recordPool = NewRecordFreePool(func() *Record { return &Record{} }, DefaultPoolSize)
 
 // ResizeScratch makes *buf exactly n bytes long.
 func ResizeScratch(buf *[]byte, n int) {
@@ -24,17 +24,10 @@ func ResizeScratch(buf *[]byte, n int) {
 	}
 }
 
-// GetFromFreePool allocates a new empty Record object.
+// GetFromFreePool allocates a new empty Record object from the singleton
+// freepool. It is equivalent to calling Get on a nil *RecordPool.
 func GetFromFreePool() *Record {
-	rec := recordPool.Get()
-	rec.Name = ""
-	rec.Ref = nil
-	rec.MateRef = nil
-	rec.Cigar = nil
-	rec.Seq = Seq{}
-	rec.Qual = nil
-	rec.AuxFields = nil
-	return rec
+	return (*RecordPool)(nil).Get()
 }
 
 // PutInFreePool adds the record to the singleton freepool.  The caller must
@@ -57,7 +50,8 @@ func (r *Record) Equal(other *Record) bool {
 		r.TempLen == other.TempLen &&
 		r.Seq.Equal(other.Seq) &&
 		bytes.Equal(r.Qual, other.Qual) &&
-		r.AuxFields.Equal(other.AuxFields)
+		r.AuxFields.Equal(other.AuxFields) &&
+		bytes.Equal(r.RawAux, other.RawAux)
 }
 
 // Equal checks if the two values are identical.
@@ -321,7 +321,7 @@ func MergeHeaders(src []*Header) (h *Header, reflinks [][]*Reference, err error)
 				// r was not actually added, so use the ref
 				// that h owns.
 				for _, hr := range h.refs {
-					if equalRefs(r, hr) {
+					if r.EquivalentTo(hr) {
 						r = hr
 						break
 					}
@@ -425,7 +425,7 @@ func (w *errWriter) Write(p []byte) (int, error) {
 //  - a read group auxiliary field must refer to a read group listed in the
 //    header and these must agree on platform unit and library.
 func (bh *Header) Validate(r *Record) error {
-	rp := r.AuxFields.Get(programTag)
+	rp := r.AuxTag(programTag)
 	found := false
 	for _, hp := range bh.Progs() {
 		if hp.UID() == rp.Value() {
@@ -437,15 +437,15 @@ func (bh *Header) Validate(r *Record) error {
 		return fmt.Errorf("sam: program uid not found: %v", rp.Value())
 	}
 
-	rg := r.AuxFields.Get(readGroupTag)
+	rg := r.AuxTag(readGroupTag)
 	found = false
 	for _, hg := range bh.RGs() {
 		if hg.Name() == rg.Value() {
-			rPlatformUnit := r.AuxFields.Get(platformUnitTag).Value()
+			rPlatformUnit := r.AuxTag(platformUnitTag).Value()
 			if rPlatformUnit != hg.PlatformUnit() {
 				return fmt.Errorf("sam: mismatched platform for read group %s: %v != %v", hg.Name(), rPlatformUnit, hg.platformUnit)
 			}
-			rLibrary := r.AuxFields.Get(libraryTag).Value()
+			rLibrary := r.AuxTag(libraryTag).Value()
 			if rLibrary != hg.Library() {
 				return fmt.Errorf("sam: mismatched library for read group %s: %v != %v", hg.Name(), rLibrary, hg.library)
 			}
@@ -545,7 +545,7 @@ func (bh *Header) AddReadGroup(rg *ReadGroup) error {
 // RemoveReadGroup removes rg from the Header and makes it
 // available to add to another Header.
 func (bh *Header) RemoveReadGroup(rg *ReadGroup) error {
-	if rg.id < 0 || int(rg.id) >= len(bh.refs) || bh.rgs[rg.id] != rg {
+	if rg.id < 0 || int(rg.id) >= len(bh.rgs) || bh.rgs[rg.id] != rg {
 		return errInvalidReadGroup
 	}
 	bh.rgs = append(bh.rgs[:rg.id], bh.rgs[rg.id+1:]...)
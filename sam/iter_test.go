@@ -0,0 +1,81 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package sam
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReaderAll(t *testing.T) {
+	const data = "@SQ\tSN:chr1\tLN:1000\n" +
+		"r0\t0\tchr1\t11\t60\t4M\t*\t0\t0\tACGT\tIIII\n" +
+		"r1\t0\tchr1\t12\t60\t4M\t*\t0\t0\tACGT\tIIII\n" +
+		"r2\t0\tchr1\t13\t60\t4M\t*\t0\t0\tACGT\tIIII\n"
+
+	sr, err := NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var got string
+	for r, err := range sr.All() {
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		got += r.Name
+	}
+	if got != "r0r1r2" {
+		t.Errorf("unexpected records: got:%q want:%q", got, "r0r1r2")
+	}
+
+	// Stopping an iteration leaves the
+	// remaining records to be read.
+	sr, err = NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	for _, err := range sr.All() {
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		break
+	}
+	r, err := sr.Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading record: %v", err)
+	}
+	if r.Name != "r1" {
+		t.Errorf("unexpected record after stopped iteration: got:%s want:r1", r.Name)
+	}
+
+	// A read error is yielded with a nil
+	// record and ends the iteration.
+	sr, err = NewReader(strings.NewReader(data + "r3\t0\tchr1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	var (
+		n    int
+		errs []error
+	)
+	for r, err := range sr.All() {
+		if err != nil {
+			if r != nil {
+				t.Errorf("unexpected non-nil record with error: %v", r)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	if n != 3 {
+		t.Errorf("unexpected number of records before error: got:%d want:3", n)
+	}
+	if len(errs) != 1 {
+		t.Errorf("unexpected number of errors: got:%d want:1", len(errs))
+	}
+}
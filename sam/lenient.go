@@ -0,0 +1,120 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import "fmt"
+
+// WarningKind is a kind of SAM specification violation repaired by
+// lenient parsing.
+type WarningKind int
+
+const (
+	// UnknownReference is a reference or
+	// mate reference name absent from the
+	// header. The record or its mate is made
+	// unplaced and marked unmapped.
+	UnknownReference WarningKind = iota
+
+	// UnplacedMapped is a record without a
+	// reference that is not marked unmapped.
+	// The record is marked unmapped.
+	UnplacedMapped
+
+	// MapQOutOfRange is a mapping quality
+	// outside [0, 255]. The mapping quality
+	// is set to 255, unavailable.
+	MapQOutOfRange
+
+	// CigarLengthMismatch is a CIGAR that
+	// does not match the sequence length.
+	// The record is kept unchanged.
+	CigarLengthMismatch
+
+	// QualLengthMismatch is a quality string
+	// that does not match the sequence
+	// length. The qualities are removed.
+	QualLengthMismatch
+
+	// InvalidAux is an aux field that cannot
+	// be parsed. The field is removed.
+	InvalidAux
+)
+
+var warningKinds = [...]string{
+	UnknownReference:    "unknown reference",
+	UnplacedMapped:      "mapped record without reference",
+	MapQOutOfRange:      "mapping quality out of range",
+	CigarLengthMismatch: "CIGAR/sequence length mismatch",
+	QualLengthMismatch:  "quality/sequence length mismatch",
+	InvalidAux:          "invalid aux field",
+}
+
+// String returns a description of the kind.
+func (k WarningKind) String() string {
+	if 0 <= k && int(k) < len(warningKinds) {
+		return warningKinds[k]
+	}
+	return fmt.Sprintf("WarningKind(%d)", int(k))
+}
+
+// Warning describes a SAM specification violation repaired by lenient
+// parsing.
+type Warning struct {
+	// Line is the line number of the record
+	// in its SAM stream, or zero if the line
+	// number is not known.
+	Line int
+
+	// Name is the name of the record.
+	Name string
+
+	Kind    WarningKind
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Line == 0 {
+		return fmt.Sprintf("sam: record %q: %s", w.Name, w.Message)
+	}
+	return fmt.Sprintf("sam: line %d: record %q: %s", w.Line, w.Name, w.Message)
+}
+
+// UnmarshalSAMLenient parses a SAM format alignment line as UnmarshalSAM
+// does, but repairs common violations of the SAM specification instead
+// of returning an error, calling warn, if it is not nil, with a Warning
+// describing each repair. The violations repaired are described by the
+// WarningKind constants. Lines that cannot be repaired, such as lines
+// with missing fields, result in an error.
+func (r *Record) UnmarshalSAMLenient(h *Header, b []byte, warn func(Warning)) error {
+	return r.unmarshalSAM(h, b, func(k WarningKind, msg string) {
+		if warn != nil {
+			warn(Warning{Name: r.Name, Kind: k, Message: msg})
+		}
+	})
+}
+
+// SetWarnings sets the Reader to parse records leniently, as described
+// by Record.UnmarshalSAMLenient, sending a Warning for each repair on w.
+// Sends on w do not block; warnings that cannot be sent because w is
+// full are counted by DroppedWarnings. If w is nil, the Reader returns
+// to strict parsing.
+func (r *Reader) SetWarnings(w chan<- Warning) {
+	r.warnings = w
+}
+
+// DroppedWarnings returns the number of warnings that could not be sent
+// on the channel set by SetWarnings because it was full.
+func (r *Reader) DroppedWarnings() int {
+	return r.dropped
+}
+
+// warn sends a warning for the record at the current line.
+func (r *Reader) warn(name string, k WarningKind, msg string) {
+	select {
+	case r.warnings <- Warning{Line: r.line, Name: name, Kind: k, Message: msg}:
+	default:
+		r.dropped++
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalSAMLenient(t *testing.T) {
+	chr1, _ := NewReference("chr1", "", "", 1000, nil, nil)
+	h, err := NewHeader(nil, []*Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	for _, test := range []struct {
+		line  string
+		kinds []WarningKind
+		check func(r *Record) bool
+	}{
+		{
+			line: "r0\t0\tchr1\t11\t60\t4M\t*\t0\t0\tACGT\tIIII",
+			check: func(r *Record) bool {
+				return r.Ref == chr1 && r.MapQ == 60 && r.Flags == 0
+			},
+		},
+		{
+			line:  "r1\t0\t*\t0\t60\t4M\t*\t0\t0\tACGT\tIIII",
+			kinds: []WarningKind{UnplacedMapped},
+			check: func(r *Record) bool { return r.Flags&Unmapped != 0 },
+		},
+		{
+			line:  "r2\t0\tchrX\t11\t60\t4M\tchrY\t20\t0\tACGT\tIIII",
+			kinds: []WarningKind{UnknownReference, UnknownReference},
+			check: func(r *Record) bool {
+				return r.Ref == nil && r.MateRef == nil && r.Flags&(Unmapped|MateUnmapped) == Unmapped|MateUnmapped
+			},
+		},
+		{
+			line:  "r3\t0\tchr1\t11\t300\t4M\t*\t0\t0\tACGT\tIIII",
+			kinds: []WarningKind{MapQOutOfRange},
+			check: func(r *Record) bool { return r.MapQ == 255 },
+		},
+		{
+			line:  "r4\t0\tchr1\t11\t-1\t4M\t*\t0\t0\tACGT\tIII",
+			kinds: []WarningKind{MapQOutOfRange, QualLengthMismatch},
+			check: func(r *Record) bool {
+				return r.MapQ == 255 && reflect.DeepEqual(r.Qual, []byte{0xff, 0xff, 0xff, 0xff})
+			},
+		},
+		{
+			line:  "r5\t0\tchr1\t11\t60\t5M\t*\t0\t0\tACGT\tIIII\tNM:i:0\tXB:B:c\tRG:Z:g0",
+			kinds: []WarningKind{CigarLengthMismatch, InvalidAux},
+			check: func(r *Record) bool {
+				return len(r.AuxFields) == 2 && r.AuxFields[1].Tag() == NewTag("RG")
+			},
+		},
+	} {
+		var kinds []WarningKind
+		var r Record
+		err := r.UnmarshalSAMLenient(h, []byte(test.line), func(w Warning) {
+			if w.Name != r.Name {
+				t.Errorf("unexpected warning name for %q: got:%q want:%q", test.line, w.Name, r.Name)
+			}
+			kinds = append(kinds, w.Kind)
+		})
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(kinds, test.kinds) {
+			t.Errorf("unexpected warnings for %q:\ngot: %v\nwant:%v", test.line, kinds, test.kinds)
+		}
+		if !test.check(&r) {
+			t.Errorf("unexpected repaired record for %q: %v", test.line, &r)
+		}
+		if test.kinds != nil {
+			err = r.UnmarshalSAM(h, []byte(test.line))
+			if err == nil && test.kinds[0] != UnplacedMapped {
+				t.Errorf("expected strict error for %q", test.line)
+			}
+		}
+	}
+
+	var r Record
+	err = r.UnmarshalSAMLenient(h, []byte("r0\t0\tchr1"), nil)
+	if err == nil {
+		t.Error("expected error for missing fields")
+	}
+}
+
+func TestReaderWarnings(t *testing.T) {
+	const data = "@SQ\tSN:chr1\tLN:1000\n" +
+		"r0\t0\tchr1\t11\t60\t4M\t*\t0\t0\tACGT\tIIII\n" +
+		"r1\t0\t*\t0\t60\t4M\t*\t0\t0\tACGT\tIIII\n" +
+		"r2\t0\tchr1\t11\t300\t4M\t*\t0\t0\tACGT\tIIII\n"
+	sr, err := NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	warnings := make(chan Warning, 1)
+	sr.SetWarnings(warnings)
+	var n int
+	for {
+		_, err := sr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+		n++
+	}
+	if n != 3 {
+		t.Errorf("unexpected number of records: got:%d want:3", n)
+	}
+	w := <-warnings
+	want := Warning{Line: 3, Name: "r1", Kind: UnplacedMapped, Message: "mapped record without reference: unmapped flag set"}
+	if w != want {
+		t.Errorf("unexpected warning:\ngot: %+v\nwant:%+v", w, want)
+	}
+	if sr.DroppedWarnings() != 1 {
+		t.Errorf("unexpected dropped warning count: got:%d want:1", sr.DroppedWarnings())
+	}
+}
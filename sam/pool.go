@@ -0,0 +1,88 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+const (
+	// DefaultPoolSize is the default approximate
+	// maximum number of free Records held by a
+	// RecordPool.
+	DefaultPoolSize = 1 << 20
+
+	// PoolBatchSize is the number of Records a
+	// client obtaining many Records, such as a
+	// BAM reader, should get in each call to
+	// GetBatch.
+	PoolBatchSize = 64
+)
+
+// RecordPool is a pool of free Records. Records obtained from a
+// RecordPool may be returned to it with Put when no references to them
+// remain, so that their storage is reused by later calls to Get.
+//
+// A RecordPool isolates its Records from those of other pools and of
+// the global pool used by GetFromFreePool and PutInFreePool, and holds
+// a bounded number of free Records. A nil *RecordPool is valid and uses
+// the global pool.
+type RecordPool struct {
+	free *RecordFreePool
+}
+
+// NewRecordPool returns a RecordPool holding up to approximately size
+// free Records. If size is less than one, DefaultPoolSize is used.
+func NewRecordPool(size int) *RecordPool {
+	if size < 1 {
+		size = DefaultPoolSize
+	}
+	return &RecordPool{free: NewRecordFreePool(func() *Record { return &Record{} }, size)}
+}
+
+func (p *RecordPool) freePool() *RecordFreePool {
+	if p == nil {
+		return recordPool
+	}
+	return p.free
+}
+
+// Get returns an empty Record from the pool, allocating a new Record
+// if the pool is empty. The Scratch storage of a reused Record is
+// retained.
+func (p *RecordPool) Get() *Record {
+	rec := p.freePool().Get()
+	reset(rec)
+	return rec
+}
+
+// GetBatch fills dst with empty Records from the pool as Get does.
+// Getting Records in batches reduces contention on the pool when many
+// goroutines obtain Records concurrently.
+func (p *RecordPool) GetBatch(dst []*Record) {
+	p.freePool().GetN(dst)
+	for _, rec := range dst {
+		reset(rec)
+	}
+}
+
+func reset(rec *Record) {
+	rec.Name = ""
+	rec.Ref = nil
+	rec.MateRef = nil
+	rec.Cigar = nil
+	rec.Seq = Seq{}
+	rec.Qual = nil
+	rec.AuxFields = nil
+	rec.RawAux = nil
+}
+
+// Put returns r to the pool. The caller must guarantee that there are
+// no outstanding references to r or to its contents, which will be
+// overwritten by a later use.
+func (p *RecordPool) Put(r *Record) {
+	p.freePool().Put(r)
+}
+
+// PutBatch returns the Records in recs to the pool as Put does.
+func (p *RecordPool) PutBatch(recs []*Record) {
+	p.freePool().PutN(recs)
+}
@@ -0,0 +1,84 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import "testing"
+
+func TestRecordPool(t *testing.T) {
+	ref, err := NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	for _, p := range []*RecordPool{nil, NewRecordPool(0), NewRecordPool(4)} {
+		for i := 0; i < 16; i++ {
+			r := p.Get()
+			if r.Name != "" || r.Ref != nil || r.MateRef != nil || r.Cigar != nil ||
+				r.Seq.Length != 0 || r.Qual != nil || r.AuxFields != nil {
+				t.Fatalf("unexpected non-empty record from pool: %+v", r)
+			}
+			r.Name = "r"
+			r.Ref = ref
+			r.MateRef = ref
+			r.Cigar = Cigar{NewCigarOp(CigarMatch, 4)}
+			r.Seq = NewSeq([]byte("ACGT"))
+			r.Qual = []byte{30, 30, 30, 30}
+			r.AuxFields = AuxFields{Aux("NMC\x01")}
+			p.Put(r)
+		}
+	}
+}
+
+func TestRecordPoolBatch(t *testing.T) {
+	for _, p := range []*RecordPool{nil, NewRecordPool(8), NewRecordPool(0)} {
+		for _, n := range []int{1, 3, PoolBatchSize, 3 * PoolBatchSize} {
+			recs := make([]*Record, n)
+			for i := 0; i < 4; i++ {
+				p.GetBatch(recs)
+				seen := make(map[*Record]bool)
+				for _, r := range recs {
+					if r == nil {
+						t.Fatal("unexpected nil record from pool")
+					}
+					if seen[r] {
+						t.Fatal("unexpected duplicate record in batch")
+					}
+					seen[r] = true
+					if r.Name != "" || r.Cigar != nil || r.AuxFields != nil {
+						t.Fatalf("unexpected non-empty record from pool: %+v", r)
+					}
+					r.Name = "r"
+					r.Cigar = Cigar{NewCigarOp(CigarMatch, 4)}
+				}
+				p.PutBatch(recs)
+			}
+		}
+	}
+}
+
+func BenchmarkRecordPool(b *testing.B) {
+	p := NewRecordPool(0)
+	b.RunParallel(func(pb *testing.PB) {
+		var recs [PoolBatchSize]*Record
+		for pb.Next() {
+			for i := range recs {
+				recs[i] = p.Get()
+			}
+			for _, r := range recs {
+				p.Put(r)
+			}
+		}
+	})
+}
+
+func BenchmarkRecordPoolBatch(b *testing.B) {
+	p := NewRecordPool(0)
+	b.RunParallel(func(pb *testing.PB) {
+		var recs [PoolBatchSize]*Record
+		for pb.Next() {
+			p.GetBatch(recs[:])
+			p.PutBatch(recs[:])
+		}
+	})
+}
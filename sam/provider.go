@@ -0,0 +1,67 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"crypto/md5"
+	"hash"
+)
+
+// ReferenceProvider provides reference sequence and checksums for
+// the named reference sequences of a set of alignments.
+type ReferenceProvider interface {
+	// GetSequence returns the bases of the named reference
+	// sequence in the zero-based half-open interval [beg,end).
+	// The returned sequence may be shorter than requested
+	// if end is beyond the end of the reference sequence.
+	GetSequence(name string, beg, end int) ([]byte, error)
+
+	// MD5 returns the MD5 checksum of the named reference
+	// sequence as described for the @SQ M5 tag.
+	MD5(name string) ([]byte, error)
+}
+
+// SequenceMD5 returns the MD5 checksum of seq as described for the
+// @SQ M5 tag in the SAM specification.
+func SequenceMD5(seq []byte) []byte {
+	h := NewSequenceHash()
+	h.Write(seq)
+	return h.Sum(nil)
+}
+
+// NewSequenceHash returns a hash.Hash computing the MD5 checksum of
+// sequence as described for the @SQ M5 tag in the SAM specification.
+// The checksum is calculated over the written sequence with all
+// characters outside the range '!' to '~' removed and all lower case
+// characters converted to upper case, so sequence may be written in
+// pieces, including line breaks.
+func NewSequenceHash() hash.Hash {
+	return &seqHash{Hash: md5.New()}
+}
+
+type seqHash struct {
+	hash.Hash
+	buf [4096]byte
+}
+
+func (h *seqHash) Write(p []byte) (int, error) {
+	n := 0
+	for _, b := range p {
+		if b < '!' || b > '~' {
+			continue
+		}
+		if 'a' <= b && b <= 'z' {
+			b &^= ' '
+		}
+		h.buf[n] = b
+		n++
+		if n == len(h.buf) {
+			h.Hash.Write(h.buf[:])
+			n = 0
+		}
+	}
+	h.Hash.Write(h.buf[:n])
+	return len(p), nil
+}
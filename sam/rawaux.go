@@ -0,0 +1,165 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var errCorruptRawAux = errors.New("sam: corrupt raw aux data")
+
+// nextAux returns the first aux field held in the BAM encoding b and the
+// number of bytes of b it occupies. The returned Aux shares storage with
+// b and, for Z and H fields, excludes the terminating zero.
+func nextAux(b []byte) (a Aux, n int, err error) {
+	if len(b) < 4 {
+		return nil, 0, errCorruptRawAux
+	}
+	switch b[2] {
+	case 'A', 'c', 'C':
+		n = 4
+	case 's', 'S':
+		n = 5
+	case 'i', 'I', 'f':
+		n = 7
+	case 'Z', 'H':
+		i := bytes.IndexByte(b[3:], 0)
+		if i < 0 {
+			return nil, 0, errCorruptRawAux
+		}
+		return Aux(b[: 3+i : 3+i]), 3 + i + 1, nil
+	case 'B':
+		if len(b) < 8 {
+			return nil, 0, errCorruptRawAux
+		}
+		var width int64
+		switch b[3] {
+		case 'c', 'C':
+			width = 1
+		case 's', 'S':
+			width = 2
+		case 'i', 'I', 'f':
+			width = 4
+		default:
+			return nil, 0, errCorruptRawAux
+		}
+		size := 8 + width*int64(binary.LittleEndian.Uint32(b[4:8]))
+		if size > int64(len(b)) {
+			return nil, 0, errCorruptRawAux
+		}
+		n = int(size)
+	default:
+		return nil, 0, errCorruptRawAux
+	}
+	if n > len(b) {
+		return nil, 0, errCorruptRawAux
+	}
+	return Aux(b[:n:n]), n, nil
+}
+
+// rawAux calls fn for each aux field held in r.RawAux, stopping if fn
+// returns false.
+func (r *Record) rawAux(fn func(Aux) bool) error {
+	for b := r.RawAux; len(b) != 0; {
+		a, n, err := nextAux(b)
+		if err != nil {
+			return err
+		}
+		if !fn(a) {
+			return nil
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// DecodeAux decodes the aux fields held in r.RawAux, placing them ahead
+// of any fields already in r.AuxFields, and sets r.RawAux to nil. The
+// decoded fields share storage with the raw data. DecodeAux must be
+// called before r.AuxFields is examined or modified directly if the
+// record may hold raw aux data.
+func (r *Record) DecodeAux() error {
+	if r.RawAux == nil {
+		return nil
+	}
+	var aux AuxFields
+	err := r.rawAux(func(a Aux) bool {
+		aux = append(aux, a)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	r.AuxFields = append(aux, r.AuxFields...)
+	r.RawAux = nil
+	return nil
+}
+
+// auxFields returns the aux fields of r, decoding r.RawAux without
+// modifying r. Corrupt raw data is ignored.
+func (r *Record) auxFields() AuxFields {
+	if r.RawAux == nil {
+		return r.AuxFields
+	}
+	var aux AuxFields
+	r.rawAux(func(a Aux) bool {
+		aux = append(aux, a)
+		return true
+	})
+	return append(aux, r.AuxFields...)
+}
+
+// AuxTag returns the first aux field of r identified by tag, or nil if
+// no field matches, searching r.RawAux without decoding other fields
+// and then r.AuxFields.
+func (r *Record) AuxTag(tag Tag) Aux {
+	a, _ := r.auxGet(tag, false)
+	return a
+}
+
+// auxGet returns the first aux field identified by tag from r.RawAux
+// and r.AuxFields. If unique is true, an error is returned if the tag
+// appears more than once.
+func (r *Record) auxGet(tag Tag, unique bool) (Aux, error) {
+	if r.RawAux == nil {
+		if unique {
+			return r.AuxFields.GetUnique(tag)
+		}
+		return r.AuxFields.Get(tag), nil
+	}
+	var (
+		found Aux
+		dup   bool
+	)
+	match := func(a Aux) bool {
+		if a.Tag() != tag {
+			return true
+		}
+		if found != nil {
+			dup = true
+			return false
+		}
+		found = a
+		return unique
+	}
+	err := r.rawAux(match)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil || (unique && !dup) {
+		for _, a := range r.AuxFields {
+			if !match(a) {
+				break
+			}
+		}
+	}
+	if dup {
+		return nil, fmt.Errorf("sam.GetUnique: tag %v appears multiple times", tag)
+	}
+	return found, nil
+}
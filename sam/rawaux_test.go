@@ -0,0 +1,86 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRawAux(t *testing.T) {
+	var (
+		nm = NewTag("NM")
+		rg = NewTag("RG")
+		bc = NewTag("BC")
+		xb = NewTag("XB")
+	)
+	var aux AuxFields
+	for _, f := range []struct {
+		tag Tag
+		v   interface{}
+	}{
+		{nm, 3},
+		{rg, "grp1"},
+		{xb, []int16{1, -2, 3}},
+		{bc, 'A'},
+	} {
+		a, err := NewAux(f.tag, f.v)
+		if err != nil {
+			t.Fatalf("unexpected error creating aux: %v", err)
+		}
+		aux = append(aux, a)
+	}
+	var raw []byte
+	for _, a := range aux {
+		raw = append(raw, a...)
+		if a.Type() == 'Z' {
+			raw = append(raw, 0)
+		}
+	}
+	extra, err := NewAux(NewTag("XS"), 7)
+	if err != nil {
+		t.Fatalf("unexpected error creating aux: %v", err)
+	}
+
+	r := &Record{RawAux: raw, AuxFields: AuxFields{extra}}
+	for _, a := range append(aux, extra) {
+		got := r.AuxTag(a.Tag())
+		if !reflect.DeepEqual(got, a) {
+			t.Errorf("unexpected aux for %v: got:%v want:%v", a.Tag(), got, a)
+		}
+	}
+	if got := r.AuxTag(NewTag("ZZ")); got != nil {
+		t.Errorf("unexpected aux for absent tag: %v", got)
+	}
+	if got, ok := r.Tag([]byte("RG")); !ok || got.Value() != "grp1" {
+		t.Errorf("unexpected RG tag: got:%v", got)
+	}
+	n, found, err := r.auxIntValue(nm)
+	if err != nil || !found || n != 3 {
+		t.Errorf("unexpected NM value: got:%d found:%t err:%v", n, found, err)
+	}
+	err = r.DecodeAux()
+	if err != nil {
+		t.Fatalf("unexpected error decoding aux: %v", err)
+	}
+	if r.RawAux != nil {
+		t.Error("expected nil raw aux after decoding")
+	}
+	want := append(aux, extra)
+	if !reflect.DeepEqual(r.AuxFields, want) {
+		t.Errorf("unexpected decoded aux: got:%v want:%v", r.AuxFields, want)
+	}
+
+	dup := &Record{RawAux: raw, AuxFields: AuxFields{aux[0]}}
+	_, _, err = dup.auxIntValue(nm)
+	if err == nil {
+		t.Error("expected error for duplicated tag")
+	}
+
+	bad := &Record{RawAux: raw[:len(raw)-3]}
+	if bad.DecodeAux() == nil {
+		t.Error("expected error for truncated raw aux")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewReaderHeader(t *testing.T) {
+	dict, err := NewReader(strings.NewReader("@HD\tVN:1.6\n@SQ\tSN:chr1\tLN:1000\tM5:0123456789abcdef0123456789abcdef\n@SQ\tSN:chr2\tLN:2000\n"))
+	if err != nil {
+		t.Fatalf("unexpected error reading dictionary: %v", err)
+	}
+	h := dict.Header()
+
+	for _, test := range []struct {
+		name   string
+		stream string
+		ref    string
+		wantH  bool
+		err    bool
+	}{
+		{
+			name:   "headerless",
+			stream: "r0\t0\tchr2\t11\t60\t4M\t*\t0\t0\tACGT\tIIII\n",
+			ref:    "chr2",
+			wantH:  true,
+		},
+		{
+			name:   "headerless unknown",
+			stream: "r0\t0\tchrX\t11\t60\t4M\t*\t0\t0\tACGT\tIIII\n",
+			wantH:  true,
+			err:    true,
+		},
+		{
+			name:   "no @SQ",
+			stream: "@HD\tVN:1.6\tSO:unsorted\n@PG\tID:bwa\tPN:bwa\nr0\t0\tchr1\t11\t60\t4M\tchr2\t20\t0\tACGT\tIIII\n",
+			ref:    "chr1",
+		},
+		{
+			name:   "own @SQ",
+			stream: "@SQ\tSN:chr3\tLN:500\nr0\t0\tchr1\t11\t60\t4M\t*\t0\t0\tACGT\tIIII\n",
+			err:    true,
+		},
+	} {
+		r, err := NewReaderHeader(strings.NewReader(test.stream), h)
+		if err != nil {
+			t.Fatalf("unexpected error creating reader for %s: %v", test.name, err)
+		}
+		if got := r.Header() == h; got != test.wantH {
+			t.Errorf("unexpected header identity for %s: got:%t want:%t", test.name, got, test.wantH)
+		}
+		rec, err := r.Read()
+		if test.err {
+			if err == nil {
+				t.Errorf("expected error for %s", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record for %s: %v", test.name, err)
+		}
+		if rec.Ref == nil || rec.Ref.Name() != test.ref {
+			t.Errorf("unexpected reference for %s: got:%v want:%s", test.name, rec.Ref, test.ref)
+			continue
+		}
+		if rec.Ref.Len() != h.Refs()[rec.Ref.ID()].Len() {
+			t.Errorf("unexpected reference length for %s: got:%d", test.name, rec.Ref.Len())
+		}
+		if test.name == "no @SQ" {
+			if len(r.Header().Refs()) != len(h.Refs()) {
+				t.Errorf("unexpected reference count: got:%d want:%d", len(r.Header().Refs()), len(h.Refs()))
+			}
+			if rec.MateRef == nil || rec.MateRef.Name() != "chr2" {
+				t.Errorf("unexpected mate reference: got:%v", rec.MateRef)
+			}
+			if r.Header().Version != "1.6" || r.Header().Progs() == nil {
+				t.Errorf("lost stream header fields")
+			}
+		}
+	}
+
+	if _, err := NewReaderHeader(strings.NewReader(""), nil); err == nil {
+		t.Error("expected error for nil dictionary")
+	}
+}
@@ -9,10 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-	"unsafe"
 
-	"github.com/Schaudge/grailbase/simd"
-	"github.com/Schaudge/grailbio/biosimd"
 	"github.com/Schaudge/hts/internal"
 )
 
@@ -61,6 +58,16 @@ type Record struct {
 	Qual      []byte
 	AuxFields AuxFields
 
+	// RawAux holds undecoded BAM encoded aux
+	// fields, as left by readers that decode
+	// aux fields lazily. The fields held in
+	// RawAux precede those in AuxFields.
+	// Record methods examining aux fields
+	// search RawAux without decoding it, and
+	// DecodeAux moves its fields into
+	// AuxFields.
+	RawAux []byte
+
 	Scratch []byte
 }
 
@@ -143,6 +150,10 @@ func (r *Record) Tag(tag []byte) (v Aux, ok bool) {
 	if len(tag) < 2 {
 		panic("sam: tag too short")
 	}
+	if r.RawAux != nil {
+		aux := r.AuxTag(Tag{tag[0], tag[1]})
+		return aux, aux != nil
+	}
 	for _, aux := range r.AuxFields {
 		if aux.matches(tag) {
 			return aux, true
@@ -267,7 +278,7 @@ func (r *Record) BagSize() (int, error) {
 // then returns (DupTypeNone, nil). If the aux value is malformed,
 // then returns (DupTypeNone, err).
 func (r *Record) DupType() (DupType, error) {
-	aux, err := r.AuxFields.GetUnique(dupTypeTag)
+	aux, err := r.auxGet(dupTypeTag, true)
 	if err != nil || aux == nil {
 		return DupTypeNone, err
 	}
@@ -308,7 +319,7 @@ func (r *Record) LibraryBagSize() (int, error) {
 // nil) or (LinearDuplicate, nil) depending on the value of the
 // LD tag.
 func (r *Record) LinearDup() (LinearDupState, error) {
-	aux, err := r.AuxFields.GetUnique(linearDupTag)
+	aux, err := r.auxGet(linearDupTag, true)
 	if err != nil || aux == nil {
 		return LinearNone, err
 	}
@@ -354,7 +365,7 @@ func (r *Record) LinearBagSize() (int, error) {
 // false, nil). If the aux tag is found, and it is an integer type,
 // then return (value, true, nil).
 func (r *Record) auxIntValue(tag Tag) (val int, found bool, err error) {
-	aux, err := r.AuxFields.GetUnique(tag)
+	aux, err := r.auxGet(tag, true)
 	if err != nil || aux == nil {
 		return -1, false, err
 	}
@@ -385,7 +396,7 @@ func (r *Record) auxIntValue(tag Tag) (val int, found bool, err error) {
 // return (-1, false, nil). If the aux tag is found, and it is an
 // integer type, then return (value, true, nil).
 func (r *Record) auxInt64Value(tag Tag) (val int64, found bool, err error) {
-	aux, err := r.AuxFields.GetUnique(tag)
+	aux, err := r.auxGet(tag, true)
 	if err != nil || aux == nil {
 		return -1, false, err
 	}
@@ -430,7 +441,7 @@ func (r *Record) String() string {
 		r.TempLen,
 		r.Seq.Expand(),
 		r.Qual,
-		r.AuxFields,
+		r.auxFields(),
 	)
 }
 
@@ -446,6 +457,14 @@ func (r *Record) UnmarshalText(b []byte) error {
 // references with zero length and an ID of -1 are created to hold the reference
 // names.
 func (r *Record) UnmarshalSAM(h *Header, b []byte) error {
+	return r.unmarshalSAM(h, b, nil)
+}
+
+// unmarshalSAM parses a SAM format alignment line as UnmarshalSAM does.
+// If warn is not nil, violations of the SAM specification that can be
+// repaired are repaired and reported to warn rather than returned as
+// errors.
+func (r *Record) unmarshalSAM(h *Header, b []byte, warn func(WarningKind, string)) error {
 	f := bytes.Split(b, []byte{'\t'})
 	if len(f) < 11 {
 		return errors.New("sam: missing SAM fields")
@@ -459,7 +478,12 @@ func (r *Record) UnmarshalSAM(h *Header, b []byte) error {
 	r.Flags = Flags(flags)
 	r.Ref, err = referenceForName(h, string(f[2]))
 	if err != nil {
-		return fmt.Errorf("sam: failed to assign reference: %v", err)
+		if warn == nil {
+			return fmt.Errorf("sam: failed to assign reference: %v", err)
+		}
+		warn(UnknownReference, fmt.Sprintf("reference %q not in header: record made unplaced", f[2]))
+		r.Ref = nil
+		r.Flags |= Unmapped
 	}
 	r.Pos, err = strconv.Atoi(string(f[3]))
 	r.Pos--
@@ -468,7 +492,14 @@ func (r *Record) UnmarshalSAM(h *Header, b []byte) error {
 	}
 	mapQ, err := strconv.ParseUint(string(f[4]), 10, 8)
 	if err != nil {
-		return fmt.Errorf("sam: failed to parse map quality: %v", err)
+		if warn == nil {
+			return fmt.Errorf("sam: failed to parse map quality: %v", err)
+		}
+		if _, perr := strconv.ParseInt(string(f[4]), 10, 64); perr != nil && !errors.Is(perr, strconv.ErrRange) {
+			return fmt.Errorf("sam: failed to parse map quality: %v", err)
+		}
+		warn(MapQOutOfRange, fmt.Sprintf("mapping quality %s out of range: set to 255", f[4]))
+		mapQ = 255
 	}
 	r.MapQ = byte(mapQ)
 	r.Cigar, err = ParseCigar(f[5])
@@ -480,7 +511,12 @@ func (r *Record) UnmarshalSAM(h *Header, b []byte) error {
 	} else {
 		r.MateRef, err = referenceForName(h, string(f[6]))
 		if err != nil {
-			return fmt.Errorf("sam: failed to assign mate reference: %v", err)
+			if warn == nil {
+				return fmt.Errorf("sam: failed to assign mate reference: %v", err)
+			}
+			warn(UnknownReference, fmt.Sprintf("mate reference %q not in header: mate made unplaced", f[6]))
+			r.MateRef = nil
+			r.Flags |= MateUnmapped
 		}
 	}
 	r.MatePos, err = strconv.Atoi(string(f[7]))
@@ -495,27 +531,46 @@ func (r *Record) UnmarshalSAM(h *Header, b []byte) error {
 	if !bytes.Equal(f[9], []byte{'*'}) {
 		r.Seq = NewSeq(f[9])
 		if len(r.Cigar) != 0 && !r.Cigar.IsValid(r.Seq.Length) {
-			return errors.New("sam: sequence/CIGAR length mismatch")
+			if warn == nil {
+				return errors.New("sam: sequence/CIGAR length mismatch")
+			}
+			warn(CigarLengthMismatch, fmt.Sprintf("CIGAR %v inconsistent with sequence length %d", r.Cigar, r.Seq.Length))
 		}
 	}
 	if !bytes.Equal(f[10], []byte{'*'}) {
 		r.Qual = append(r.Qual, f[10]...)
-		simd.AddConst8Inplace(r.Qual, 256-33)
+		addConst8Inplace(r.Qual, 256-33)
 	} else if r.Seq.Length != 0 {
 		r.Qual = make([]byte, r.Seq.Length)
-		simd.Memset8(r.Qual, 0xff)
+		memset8(r.Qual, 0xff)
 	}
 	if len(r.Qual) != 0 && len(r.Qual) != r.Seq.Length {
-		return errors.New("sam: sequence/quality length mismatch")
+		if warn == nil {
+			return errors.New("sam: sequence/quality length mismatch")
+		}
+		warn(QualLengthMismatch, fmt.Sprintf("quality length %d does not match sequence length %d: qualities removed", len(r.Qual), r.Seq.Length))
+		r.Qual = nil
+		if r.Seq.Length != 0 {
+			r.Qual = make([]byte, r.Seq.Length)
+			memset8(r.Qual, 0xff)
+		}
+	}
+	if warn != nil && r.Ref == nil && r.Flags&Unmapped == 0 {
+		warn(UnplacedMapped, "mapped record without reference: unmapped flag set")
+		r.Flags |= Unmapped
 	}
 	if len(f) > 11 {
-		r.AuxFields = make([]Aux, len(f)-11)
-		for i, aux := range f[11:] {
+		r.AuxFields = make([]Aux, 0, len(f)-11)
+		for _, aux := range f[11:] {
 			a, err := ParseAux(aux)
 			if err != nil {
-				return err
+				if warn == nil {
+					return err
+				}
+				warn(InvalidAux, fmt.Sprintf("%v: field removed", err))
+				continue
 			}
-			r.AuxFields[i] = a
+			r.AuxFields = append(r.AuxFields, a)
 		}
 	}
 	return nil
@@ -569,6 +624,15 @@ func (r *Record) MarshalSAM(flags int) ([]byte, error) {
 		formatSeq(r.Seq),
 		formatQual(r.Qual),
 	)
+	if r.RawAux != nil {
+		err := r.rawAux(func(a Aux) bool {
+			fmt.Fprintf(&buf, "\t%v", samAux(a))
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 	for _, t := range r.AuxFields {
 		fmt.Fprintf(&buf, "\t%v", samAux(t))
 	}
@@ -628,7 +692,7 @@ func formatQual(q []byte) []byte {
 	for _, v := range q {
 		if v != 0xff {
 			a := make([]byte, len(q))
-			simd.AddConst8(a, q, 33)
+			addConst8(a, q, 33)
 			return a
 		}
 	}
@@ -645,8 +709,8 @@ type Seq struct {
 }
 
 var (
-	n16TableRev = simd.MakeNibbleLookupTable([16]byte{'=', 'A', 'C', 'M', 'G', 'R', 'S', 'V', 'T', 'W', 'Y', 'H', 'K', 'D', 'B', 'N'})
-	n16Table    = [256]Doublet{
+	n16Chars = [16]byte{'=', 'A', 'C', 'M', 'G', 'R', 'S', 'V', 'T', 'W', 'Y', 'H', 'K', 'D', 'B', 'N'}
+	n16Table = [256]Doublet{
 		0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf,
 		0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf,
 		0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf, 0xf,
@@ -701,10 +765,12 @@ func contract(s []byte) []Doublet {
 // UnpackAndReplaceSeq{Unsafe}, which populate preallocated buffers, are better
 // when you are iterating through many bases.  (The main advantage of the
 // Unsafe functions is great performance for length < 32.)
+//
+// Expand uses biosimd on amd64 unless the purego build tag is set, and a
+// portable implementation otherwise.
 func (ns Seq) Expand() []byte {
 	s := make([]byte, ns.Length)
-	nsSeqPtr := (*[]byte)(unsafe.Pointer(&ns.Seq))
-	biosimd.UnpackAndReplaceSeq(s, *nsSeqPtr, &n16TableRev)
+	unpackSeq(s, ns.Seq)
 	return s
 }
 
@@ -747,7 +813,7 @@ func (ns Seq) Base(pos int) SeqBase {
 //
 // REQUIRES: 0 <= pos < seq.Length
 func (ns Seq) BaseChar(pos int) byte {
-	return n16TableRev.Get(byte(ns.Base(pos)))
+	return n16Chars[ns.Base(pos)]
 }
 
 // Char converts a SeqBase to a human-readable character.  For example,
@@ -755,5 +821,5 @@ func (ns Seq) BaseChar(pos int) byte {
 //
 // REQUIRES: 0 <= b < NumSeqBaseTypes
 func (b SeqBase) Char() byte {
-	return n16TableRev.Get(byte(b))
+	return n16Chars[b]
 }
@@ -0,0 +1,72 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !race
+// +build !race
+
+package sam
+
+import "sync/atomic"
+
+// GetN fills dst with objects removed from the freepool, calling the
+// callback passed to NewRecordFreePool for each object beyond those held
+// by the pool. The local queue is locked at most once.
+func (p *RecordFreePool) GetN(dst []*Record) {
+	l := p.pin()
+	n := 0
+	for n < len(dst) && l.privateSize > 0 {
+		l.privateSize--
+		dst[n] = l.private[l.privateSize]
+		l.private[l.privateSize] = nil
+		n++
+	}
+	runtime_procUnpin()
+	if n < len(dst) && atomic.LoadInt64(&l.sharedSize) > 0 {
+		l.mu.Lock()
+		m := copy(dst[n:], l.shared[len(l.shared)-minInt(len(dst)-n, len(l.shared)):])
+		for i := len(l.shared) - m; i < len(l.shared); i++ {
+			l.shared[i] = nil
+		}
+		l.shared = l.shared[:len(l.shared)-m]
+		atomic.StoreInt64(&l.sharedSize, int64(len(l.shared)))
+		l.mu.Unlock()
+		n += m
+	}
+	for ; n < len(dst); n++ {
+		dst[n] = p.new()
+	}
+}
+
+// PutN adds the objects in xs to the freepool. Objects not held in the
+// private slots of the local queue are added to the shorter of the
+// local queue and a randomly chosen queue, which is locked once. The
+// caller shall not touch the objects after the call.
+func (p *RecordFreePool) PutN(xs []*Record) {
+	l := p.pin()
+	for len(xs) != 0 && l.privateSize < RecordMaxPrivateElems {
+		l.private[l.privateSize] = xs[0]
+		l.privateSize++
+		xs = xs[1:]
+	}
+	runtime_procUnpin()
+	if len(xs) == 0 {
+		return
+	}
+	l2 := &p.local[int(fastrand())%len(p.local)]
+	if atomic.LoadInt64(&l2.sharedSize) < atomic.LoadInt64(&l.sharedSize) {
+		l = l2
+	}
+	l.mu.Lock()
+	if p.maxLocalSize >= 0 {
+		n := int(p.maxLocalSize - l.sharedSize)
+		if n > len(xs) {
+			n = len(xs)
+		}
+		if n > 0 {
+			l.shared = append(l.shared, xs[:n]...)
+			atomic.StoreInt64(&l.sharedSize, int64(len(l.shared)))
+		}
+	}
+	l.mu.Unlock()
+}
@@ -0,0 +1,20 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build race
+// +build race
+
+package sam
+
+func (p *RecordFreePool) GetN(dst []*Record) {
+	for i := range dst {
+		dst[i] = p.Get()
+	}
+}
+
+func (p *RecordFreePool) PutN(xs []*Record) {
+	for _, x := range xs {
+		p.Put(x)
+	}
+}
@@ -313,6 +313,21 @@ func (r *Reference) Clone() *Reference {
 	return &cr
 }
 
+// EquivalentTo returns whether the receiver and other describe the same
+// reference sequence, independent of the Headers that hold them. References
+// are equivalent if they have the same name and length and, when both
+// have an MD5 sum, the same MD5 sum. Other fields, including the ID, are
+// not considered, so References read from different files may be compared.
+// A nil Reference is only equivalent to another nil Reference.
+func (r *Reference) EquivalentTo(other *Reference) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.name == other.name &&
+		r.lRef == other.lRef &&
+		(r.md5 == "" || other.md5 == "" || r.md5 == other.md5)
+}
+
 func equalRefs(a, b *Reference) bool {
 	if a == b {
 		return true
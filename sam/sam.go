@@ -20,10 +20,44 @@ type Reader struct {
 	h *Header
 
 	seenRefs map[string]*Reference
+
+	// line is the number of lines read.
+	line int
+
+	// warnings, if not nil, receives the
+	// warnings of lenient parsing, and
+	// dropped counts the warnings that
+	// could not be sent.
+	warnings chan<- Warning
+	dropped  int
 }
 
 // NewReader returns a new Reader, reading from the given io.Reader.
 func NewReader(r io.Reader) (*Reader, error) {
+	return newReader(r, nil)
+}
+
+// NewReaderHeader returns a new Reader, reading from the given io.Reader,
+// that resolves reference names against the reference dictionary of
+// dict when the stream has no @SQ header lines, as is common for aligner
+// output piped without a header. The dictionary may be loaded from a
+// sequence dictionary (.dict) file with NewReader.
+//
+// If the stream has no header, dict is used as the header of the Reader.
+// If the stream has a header without @SQ lines, the references of dict
+// are added to it. If the stream has @SQ lines, dict is not used. In all
+// cases, records with reference names absent from the header result in
+// an error, or a warning if SetWarnings has been called.
+func NewReaderHeader(r io.Reader, dict *Header) (*Reader, error) {
+	if dict == nil {
+		return nil, errors.New("sam: nil reference dictionary")
+	}
+	return newReader(r, dict)
+}
+
+// newReader returns a new Reader reading from r, resolving references
+// against dict if it is not nil and the stream has no @SQ lines.
+func newReader(r io.Reader, dict *Header) (*Reader, error) {
 	h, _ := NewHeader(nil, nil)
 	sr := &Reader{
 		r: bufio.NewReader(r),
@@ -36,6 +70,10 @@ func NewReader(r io.Reader) (*Reader, error) {
 		return nil, err
 	}
 	if p[0] != '@' {
+		if dict != nil {
+			sr.h = dict
+			return sr, nil
+		}
 		sr.seenRefs = make(map[string]*Reference)
 		return sr, nil
 	}
@@ -46,6 +84,7 @@ func NewReader(r io.Reader) (*Reader, error) {
 			return nil, io.ErrUnexpectedEOF
 		}
 		b = append(b, l...)
+		sr.line++
 		p, err := sr.r.Peek(1)
 		if err == io.EOF {
 			break
@@ -62,6 +101,14 @@ func NewReader(r io.Reader) (*Reader, error) {
 	if err != nil {
 		return nil, err
 	}
+	if dict != nil && len(sr.h.Refs()) == 0 {
+		for _, ref := range dict.Refs() {
+			err = sr.h.AddReference(ref.Clone())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	return sr, nil
 }
@@ -77,15 +124,22 @@ func (r *Reader) Read() (*Record, error) {
 	if err != nil {
 		return nil, err
 	}
+	r.line++
 	b = b[:len(b)-1]
-	if b[len(b)-1] == '\r' {
+	if len(b) != 0 && b[len(b)-1] == '\r' {
 		b = b[:len(b)-1]
 	}
-	var rec Record
+	var (
+		rec  Record
+		warn func(WarningKind, string)
+	)
+	if r.warnings != nil {
+		warn = func(k WarningKind, msg string) { r.warn(rec.Name, k, msg) }
+	}
 
 	// Handle cases where a header was present.
 	if r.seenRefs == nil {
-		err = rec.UnmarshalSAM(r.h, b)
+		err = rec.unmarshalSAM(r.h, b, warn)
 		if err != nil {
 			return nil, err
 		}
@@ -93,7 +147,7 @@ func (r *Reader) Read() (*Record, error) {
 	}
 
 	// Handle cases where no SAM header is present.
-	err = rec.UnmarshalSAM(nil, b)
+	err = rec.unmarshalSAM(nil, b, warn)
 	if err != nil {
 		return nil, err
 	}
@@ -808,6 +808,39 @@ func (s *S) TestEqualRefs(c *check.C) {
 	c.Assert(equalRefs(b, a), check.Equals, false)
 }
 
+func (s *S) TestEquivalentRefs(c *check.C) {
+	md5 := []byte("0123456789abcdef")
+	a, err := NewReference("aaa", "assem", "species", 1234, md5, nil)
+	c.Assert(err, check.IsNil)
+	h, err := NewHeader(nil, []*Reference{a})
+	c.Assert(err, check.IsNil)
+	c.Assert(a.ID(), check.Equals, 0)
+
+	for _, test := range []struct {
+		name   string
+		length int
+		md5    []byte
+		want   bool
+	}{
+		{name: "aaa", length: 1234, md5: md5, want: true},
+		{name: "aaa", length: 1234, md5: nil, want: true},
+		{name: "aaa", length: 1234, md5: []byte("fedcba9876543210"), want: false},
+		{name: "aaa", length: 1235, md5: md5, want: false},
+		{name: "bbb", length: 1234, md5: md5, want: false},
+	} {
+		b, err := NewReference(test.name, "", "", test.length, test.md5, nil)
+		c.Assert(err, check.IsNil)
+		c.Check(a.EquivalentTo(b), check.Equals, test.want)
+		c.Check(b.EquivalentTo(a), check.Equals, test.want)
+	}
+	c.Check(h.Refs()[0].EquivalentTo(a.Clone()), check.Equals, true)
+
+	var n *Reference
+	c.Check(n.EquivalentTo(nil), check.Equals, true)
+	c.Check(n.EquivalentTo(a), check.Equals, false)
+	c.Check(a.EquivalentTo(nil), check.Equals, false)
+}
+
 func (s *S) TestAddClonedRef(c *check.C) {
 	sr, err := NewReader(bytes.NewReader(specExamples.data))
 	c.Assert(err, check.Equals, nil)
@@ -914,6 +947,26 @@ func (s *S) TestRemoveReadGroup(c *check.C) {
 	c.Check(len(h.RGs()), check.Equals, len(headerHG00096_1000.RGs()))
 }
 
+// Read group removal must be bounded by the number of read groups, not
+// by the number of references.
+func (s *S) TestRemoveReadGroupIndex(c *check.C) {
+	h, err := NewHeader(nil, nil)
+	c.Assert(err, check.Equals, nil)
+	rg, err := NewReadGroup("rg", "", "", "", "", "", "", "", "", "", time.Time{}, 0)
+	c.Assert(err, check.Equals, nil)
+	c.Assert(h.AddReadGroup(rg), check.Equals, nil)
+	c.Check(h.RemoveReadGroup(rg), check.Equals, nil)
+	c.Check(len(h.RGs()), check.Equals, 0)
+
+	h = headerHG00096_1000.Clone()
+	c.Assert(len(h.Refs()) > len(h.RGs()), check.Equals, true)
+	rg, err = NewReadGroup("rg", "", "", "", "", "", "", "", "", "", time.Time{}, 0)
+	c.Assert(err, check.Equals, nil)
+	rg.id = int32(len(h.RGs()))
+	c.Check(h.RemoveReadGroup(rg), check.Equals, errInvalidReadGroup)
+	c.Check(len(h.RGs()), check.Equals, len(headerHG00096_1000.RGs()))
+}
+
 func (s *S) TestRemoveProgram(c *check.C) {
 	h := headerHG00096_1000.Clone()
 	h.RemoveProgram(h.Progs()[2])
@@ -0,0 +1,37 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build amd64 && !purego
+
+package sam
+
+import (
+	"unsafe"
+
+	"github.com/Schaudge/grailbase/simd"
+	"github.com/Schaudge/grailbio/biosimd"
+)
+
+// This file contains the SIMD implementations of the byte operations
+// used to encode and decode records. The portable implementations in
+// simd_generic.go are used on other architectures and when the purego
+// build tag is set.
+
+var n16TableRev = simd.MakeNibbleLookupTable(n16Chars)
+
+// addConst8Inplace adds c to each byte of b.
+func addConst8Inplace(b []byte, c byte) { simd.AddConst8Inplace(b, c) }
+
+// addConst8 sets each byte of dst to the corresponding byte of src
+// plus c. The slices must have the same length.
+func addConst8(dst, src []byte, c byte) { simd.AddConst8(dst, src, c) }
+
+// memset8 sets each byte of b to v.
+func memset8(b []byte, v byte) { simd.Memset8(b, v) }
+
+// unpackSeq fills dst with the base letters of the packed sequence
+// src, which must hold at least len(dst) bases.
+func unpackSeq(dst []byte, src []Doublet) {
+	biosimd.UnpackAndReplaceSeq(dst, *(*[]byte)(unsafe.Pointer(&src)), &n16TableRev)
+}
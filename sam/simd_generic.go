@@ -0,0 +1,46 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !amd64 || purego
+
+package sam
+
+// This file contains the portable implementations of the byte
+// operations used to encode and decode records, used where the SIMD
+// implementations in simd_amd64.go are unavailable.
+
+// addConst8Inplace adds c to each byte of b.
+func addConst8Inplace(b []byte, c byte) {
+	for i := range b {
+		b[i] += c
+	}
+}
+
+// addConst8 sets each byte of dst to the corresponding byte of src
+// plus c. The slices must have the same length.
+func addConst8(dst, src []byte, c byte) {
+	for i, v := range src {
+		dst[i] = v + c
+	}
+}
+
+// memset8 sets each byte of b to v.
+func memset8(b []byte, v byte) {
+	for i := range b {
+		b[i] = v
+	}
+}
+
+// unpackSeq fills dst with the base letters of the packed sequence
+// src, which must hold at least len(dst) bases.
+func unpackSeq(dst []byte, src []Doublet) {
+	for i := 0; i+1 < len(dst); i += 2 {
+		d := src[i>>1]
+		dst[i] = n16Chars[d>>4]
+		dst[i+1] = n16Chars[d&0xf]
+	}
+	if len(dst)&1 != 0 {
+		dst[len(dst)-1] = n16Chars[src[len(dst)>>1]>>4]
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Cycles collects mean base quality and base composition by sequencing
+// cycle, equivalent to the metrics reported by Picard
+// MeanQualityByCycle and CollectBaseDistributionByCycle. Secondary and
+// supplementary alignments are ignored. Bases of reverse strand
+// alignments are complemented and counted in reverse order.
+//
+// The zero value is ready to use. Cycles values collected from
+// disjoint sets of records may be combined with Merge.
+type Cycles struct {
+	// First and Last hold the counts for
+	// the first and last fragments of
+	// templates indexed by cycle. Unpaired
+	// reads are counted as first fragments.
+	First []CycleCounts `json:"first"`
+	Last  []CycleCounts `json:"last"`
+}
+
+// CycleCounts holds the base counts of a sequencing cycle.
+type CycleCounts struct {
+	// Bases holds counts of A, C, G, T
+	// and other bases.
+	Bases [5]uint64 `json:"bases"`
+
+	// QualitySum and QualityBases are the
+	// sum of base qualities and the number
+	// of bases with quality scores.
+	QualitySum   uint64 `json:"quality_sum"`
+	QualityBases uint64 `json:"quality_bases"`
+}
+
+// MeanQuality returns the mean base quality of the cycle.
+func (c *CycleCounts) MeanQuality() float64 {
+	return ratio(c.QualitySum, c.QualityBases)
+}
+
+// Add adds the record r to the counts.
+func (c *Cycles) Add(r *sam.Record) {
+	if r.Flags&(sam.Secondary|sam.Supplementary) != 0 {
+		return
+	}
+	counts := &c.First
+	if r.Flags&(sam.Paired|sam.Read2) == sam.Paired|sam.Read2 {
+		counts = &c.Last
+	}
+	n := r.Seq.Length
+	if len(*counts) < n {
+		*counts = append(*counts, make([]CycleCounts, n-len(*counts))...)
+	}
+	rev := r.Flags&sam.Reverse != 0
+	hasQual := len(r.Qual) == n && n != 0 && r.Qual[0] != 0xff
+	for i := 0; i < n; i++ {
+		cycle := i
+		b := r.Seq.BaseChar(i)
+		if rev {
+			cycle = n - 1 - i
+			b = samutil.Complement(b)
+		}
+		cc := &(*counts)[cycle]
+		switch b {
+		case 'A':
+			cc.Bases[0]++
+		case 'C':
+			cc.Bases[1]++
+		case 'G':
+			cc.Bases[2]++
+		case 'T':
+			cc.Bases[3]++
+		default:
+			cc.Bases[4]++
+		}
+		if hasQual {
+			cc.QualitySum += uint64(r.Qual[i])
+			cc.QualityBases++
+		}
+	}
+}
+
+// Merge adds the counts of o to c.
+func (c *Cycles) Merge(o *Cycles) {
+	c.First = mergeCycles(c.First, o.First)
+	c.Last = mergeCycles(c.Last, o.Last)
+}
+
+func mergeCycles(dst, src []CycleCounts) []CycleCounts {
+	if len(dst) < len(src) {
+		dst = append(dst, make([]CycleCounts, len(src)-len(dst))...)
+	}
+	for i, s := range src {
+		d := &dst[i]
+		for j, n := range s.Bases {
+			d.Bases[j] += n
+		}
+		d.QualitySum += s.QualitySum
+		d.QualityBases += s.QualityBases
+	}
+	return dst
+}
+
+// WriteQuality writes the mean base quality of each cycle to w in the
+// tab-separated format of Picard MeanQualityByCycle output. Cycles are
+// numbered from one, and last fragment cycles are numbered following
+// the first fragment cycles. Cycles without quality scores are omitted.
+func (c *Cycles) WriteQuality(w io.Writer) error {
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	cw.printf("CYCLE\tMEAN_QUALITY\n")
+	for i, counts := range [][]CycleCounts{c.First, c.Last} {
+		offset := 0
+		if i == 1 {
+			offset = len(c.First)
+		}
+		for j := range counts {
+			if counts[j].QualityBases == 0 {
+				continue
+			}
+			cw.printf("%d\t%f\n", offset+j+1, counts[j].MeanQuality())
+		}
+	}
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+// WriteBaseDistribution writes the base composition of each cycle to w
+// in the tab-separated format of Picard CollectBaseDistributionByCycle
+// output. Cycles are numbered from one for each read end.
+func (c *Cycles) WriteBaseDistribution(w io.Writer) error {
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	cw.printf("READ_END\tCYCLE\tPCT_A\tPCT_C\tPCT_G\tPCT_T\tPCT_N\n")
+	for i, counts := range [][]CycleCounts{c.First, c.Last} {
+		for j := range counts {
+			b := &counts[j].Bases
+			total := b[0] + b[1] + b[2] + b[3] + b[4]
+			if total == 0 {
+				continue
+			}
+			cw.printf("%d\t%d", i+1, j+1)
+			for _, n := range b {
+				cw.printf("\t%f", 100*ratio(n, total))
+			}
+			cw.printf("\n")
+		}
+	}
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
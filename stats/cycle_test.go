@@ -0,0 +1,85 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestCycles(t *testing.T) {
+	// Base qualities are 20, 21, 22 and 23.
+	builder := htstestutil.NewBAMBuilder().Ref("chr1", 1000)
+	builder.Record("r", "chr1", 10, "4M", "ACGT").Qual("5678").Flags(sam.Paired|sam.Read1).
+		Record("r", "chr1", 20, "4M", "AACG").Qual("5678").Flags(sam.Paired|sam.Read2|sam.Reverse).
+		Record("r", "*", -1, "*", "N").Qual("5").Flags(sam.Unmapped).
+		Record("r", "chr1", 10, "4M", "GGGG").Qual("5678").Flags(sam.Secondary)
+	h, err := builder.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	recs, err := builder.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+	var a, b Cycles
+	for i, r := range recs {
+		if i%2 == 0 {
+			a.Add(r)
+		} else {
+			b.Add(r)
+		}
+	}
+	a.Merge(&b)
+
+	want := Cycles{
+		First: []CycleCounts{
+			{Bases: [5]uint64{1, 0, 0, 0, 1}, QualitySum: 40, QualityBases: 2},
+			{Bases: [5]uint64{0, 1, 0, 0, 0}, QualitySum: 21, QualityBases: 1},
+			{Bases: [5]uint64{0, 0, 1, 0, 0}, QualitySum: 22, QualityBases: 1},
+			{Bases: [5]uint64{0, 0, 0, 1, 0}, QualitySum: 23, QualityBases: 1},
+		},
+		Last: []CycleCounts{
+			{Bases: [5]uint64{0, 1, 0, 0, 0}, QualitySum: 23, QualityBases: 1},
+			{Bases: [5]uint64{0, 0, 1, 0, 0}, QualitySum: 22, QualityBases: 1},
+			{Bases: [5]uint64{0, 0, 0, 1, 0}, QualitySum: 21, QualityBases: 1},
+			{Bases: [5]uint64{0, 0, 0, 1, 0}, QualitySum: 20, QualityBases: 1},
+		},
+	}
+	if !reflect.DeepEqual(a, want) {
+		t.Fatalf("unexpected counts:\ngot: %+v\nwant:%+v", a, want)
+	}
+
+	var buf bytes.Buffer
+	err = a.WriteQuality(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing quality: %v", err)
+	}
+	for _, line := range []string{"CYCLE\tMEAN_QUALITY\n", "\n1\t20.000000\n", "\n4\t23.000000\n", "\n5\t23.000000\n", "\n8\t20.000000\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("quality output missing %q:\n%s", line, &buf)
+		}
+	}
+
+	buf.Reset()
+	err = a.WriteBaseDistribution(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing base distribution: %v", err)
+	}
+	for _, line := range []string{
+		"READ_END\tCYCLE\tPCT_A\tPCT_C\tPCT_G\tPCT_T\tPCT_N\n",
+		"\n1\t1\t50.000000\t0.000000\t0.000000\t0.000000\t50.000000\n",
+		"\n2\t4\t0.000000\t0.000000\t0.000000\t100.000000\t0.000000\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("base distribution output missing %q:\n%s", line, &buf)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stats implements collection of summary statistics of
+// alignment records.
+package stats
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// Indexes into the counts of a FlagStat.
+const (
+	Pass = 0 // QC-passed records.
+	Fail = 1 // QC-failed records.
+)
+
+// FlagStat holds alignment record counts equivalent to those reported
+// by samtools flagstat. Each count holds the count for QC-passed records
+// at index Pass and for QC-failed records at index Fail.
+//
+// As with samtools flagstat, the pairing counts from Paired through
+// MateDiffChrHighMapQ include only primary alignments.
+//
+// The zero value is ready to use. FlagStat values collected from
+// disjoint sets of records may be combined with Merge.
+type FlagStat struct {
+	Total         [2]uint64 // All records.
+	Secondary     [2]uint64 // Secondary alignments.
+	Supplementary [2]uint64 // Supplementary alignments.
+	Duplicates    [2]uint64 // Records marked as duplicates.
+	Mapped        [2]uint64 // Mapped records.
+
+	Paired              [2]uint64 // Records paired in sequencing.
+	Read1               [2]uint64 // First reads of pairs.
+	Read2               [2]uint64 // Second reads of pairs.
+	ProperPair          [2]uint64 // Mapped records in proper pairs.
+	BothMapped          [2]uint64 // Records mapped with a mapped mate.
+	Singletons          [2]uint64 // Mapped records with an unmapped mate.
+	MateDiffChr         [2]uint64 // Records with a mate mapped to a different reference.
+	MateDiffChrHighMapQ [2]uint64 // MateDiffChr records with mapping quality at least 5.
+}
+
+// Add adds the record r to the counts.
+func (s *FlagStat) Add(r *sam.Record) {
+	qc := Pass
+	if r.Flags&sam.QCFail != 0 {
+		qc = Fail
+	}
+	s.Total[qc]++
+	switch {
+	case r.Flags&sam.Secondary != 0:
+		s.Secondary[qc]++
+	case r.Flags&sam.Supplementary != 0:
+		s.Supplementary[qc]++
+	case r.Flags&sam.Paired != 0:
+		s.Paired[qc]++
+		if r.Flags&(sam.ProperPair|sam.Unmapped) == sam.ProperPair {
+			s.ProperPair[qc]++
+		}
+		if r.Flags&sam.Read1 != 0 {
+			s.Read1[qc]++
+		}
+		if r.Flags&sam.Read2 != 0 {
+			s.Read2[qc]++
+		}
+		switch r.Flags & (sam.Unmapped | sam.MateUnmapped) {
+		case sam.MateUnmapped:
+			s.Singletons[qc]++
+		case 0:
+			s.BothMapped[qc]++
+			if r.MateRef.ID() != r.Ref.ID() {
+				s.MateDiffChr[qc]++
+				if r.MapQ >= 5 {
+					s.MateDiffChrHighMapQ[qc]++
+				}
+			}
+		}
+	}
+	if r.Flags&sam.Unmapped == 0 {
+		s.Mapped[qc]++
+	}
+	if r.Flags&sam.Duplicate != 0 {
+		s.Duplicates[qc]++
+	}
+}
+
+// Merge adds the counts in o to the receiver.
+func (s *FlagStat) Merge(o *FlagStat) {
+	for qc := range s.Total {
+		s.Total[qc] += o.Total[qc]
+		s.Secondary[qc] += o.Secondary[qc]
+		s.Supplementary[qc] += o.Supplementary[qc]
+		s.Duplicates[qc] += o.Duplicates[qc]
+		s.Mapped[qc] += o.Mapped[qc]
+		s.Paired[qc] += o.Paired[qc]
+		s.Read1[qc] += o.Read1[qc]
+		s.Read2[qc] += o.Read2[qc]
+		s.ProperPair[qc] += o.ProperPair[qc]
+		s.BothMapped[qc] += o.BothMapped[qc]
+		s.Singletons[qc] += o.Singletons[qc]
+		s.MateDiffChr[qc] += o.MateDiffChr[qc]
+		s.MateDiffChrHighMapQ[qc] += o.MateDiffChrHighMapQ[qc]
+	}
+}
+
+// WriteTo writes the counts to w in the text format of samtools
+// flagstat.
+func (s *FlagStat) WriteTo(w io.Writer) (int64, error) {
+	lines := []struct {
+		counts *[2]uint64
+		label  string
+		of     *[2]uint64
+	}{
+		{&s.Total, "in total (QC-passed reads + QC-failed reads)", nil},
+		{&s.Secondary, "secondary", nil},
+		{&s.Supplementary, "supplementary", nil},
+		{&s.Duplicates, "duplicates", nil},
+		{&s.Mapped, "mapped", &s.Total},
+		{&s.Paired, "paired in sequencing", nil},
+		{&s.Read1, "read1", nil},
+		{&s.Read2, "read2", nil},
+		{&s.ProperPair, "properly paired", &s.Paired},
+		{&s.BothMapped, "with itself and mate mapped", nil},
+		{&s.Singletons, "singletons", &s.Paired},
+		{&s.MateDiffChr, "with mate mapped to a different chr", nil},
+		{&s.MateDiffChrHighMapQ, "with mate mapped to a different chr (mapQ>=5)", nil},
+	}
+	var n int64
+	for _, l := range lines {
+		var (
+			c   int
+			err error
+		)
+		if l.of == nil {
+			c, err = fmt.Fprintf(w, "%d + %d %s\n", l.counts[Pass], l.counts[Fail], l.label)
+		} else {
+			c, err = fmt.Fprintf(w, "%d + %d %s (%s : %s)\n", l.counts[Pass], l.counts[Fail], l.label,
+				percent(l.counts[Pass], l.of[Pass]), percent(l.counts[Fail], l.of[Fail]))
+		}
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func percent(n, total uint64) string {
+	if total == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.2f%%", 100*float64(n)/float64(total))
+}
@@ -0,0 +1,75 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestFlagStat(t *testing.T) {
+	chr1, _ := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	chr2, _ := sam.NewReference("chr2", "", "", 1000, nil, nil)
+	_, err := sam.NewHeader(nil, []*sam.Reference{chr1, chr2})
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+
+	const pair = sam.Paired | sam.ProperPair
+	recs := []*sam.Record{
+		{Ref: chr1, MateRef: chr1, MapQ: 60, Flags: pair | sam.Read1},
+		{Ref: chr1, MateRef: chr1, MapQ: 60, Flags: pair | sam.Read2 | sam.Duplicate},
+		{Ref: chr1, MateRef: chr2, MapQ: 3, Flags: sam.Paired | sam.Read1},
+		{Ref: chr2, MateRef: chr1, MapQ: 30, Flags: sam.Paired | sam.Read2},
+		{Ref: chr1, MapQ: 60, Flags: sam.Paired | sam.Read1 | sam.MateUnmapped},
+		{Ref: chr1, Flags: sam.Paired | sam.Read2 | sam.Unmapped},
+		{Ref: chr1, MapQ: 60, Flags: sam.Secondary},
+		{Ref: chr2, MapQ: 60, Flags: sam.Paired | sam.Supplementary},
+		{Ref: chr2, MapQ: 60, Flags: sam.QCFail},
+		{Flags: sam.Unmapped | sam.QCFail},
+	}
+
+	var all FlagStat
+	for _, r := range recs {
+		all.Add(r)
+	}
+	var a, b FlagStat
+	for i, r := range recs {
+		if i%2 == 0 {
+			a.Add(r)
+		} else {
+			b.Add(r)
+		}
+	}
+	a.Merge(&b)
+	if a != all {
+		t.Errorf("unexpected merged counts:\ngot: %+v\nwant:%+v", a, all)
+	}
+
+	var buf bytes.Buffer
+	_, err = all.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing counts: %v", err)
+	}
+	const want = `8 + 2 in total (QC-passed reads + QC-failed reads)
+1 + 0 secondary
+1 + 0 supplementary
+1 + 0 duplicates
+7 + 1 mapped (87.50% : 50.00%)
+6 + 0 paired in sequencing
+3 + 0 read1
+3 + 0 read2
+2 + 0 properly paired (33.33% : N/A)
+4 + 0 with itself and mate mapped
+1 + 0 singletons (16.67% : N/A)
+2 + 0 with mate mapped to a different chr
+1 + 0 with mate mapped to a different chr (mapQ>=5)
+`
+	if buf.String() != want {
+		t.Errorf("unexpected flagstat output:\ngot:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
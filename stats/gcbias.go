@@ -0,0 +1,279 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bufio"
+	"io"
+	"math"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// DefaultGCWindow is the default width of GC content windows.
+const DefaultGCWindow = 100
+
+// GCBias collects read start counts by the GC content of the reference
+// window at the start of each read, equivalent to the metrics reported
+// by Picard CollectGcBiasMetrics.
+//
+// Each reference position starts a window of Window bases, and windows
+// with more than 4% N bases are ignored. Reads are assigned to the
+// window starting at their alignment start, or ending at their
+// alignment end for reverse strand reads. Unmapped, secondary,
+// supplementary, QC failed and duplicate records are ignored.
+//
+// GCBias values collected from disjoint sets of records may be
+// combined with Merge.
+type GCBias struct {
+	// Window is the width of windows.
+	Window int `json:"window"`
+
+	// Windows holds the number of windows
+	// of each reference indexed by GC
+	// percentage. The windows of references
+	// are counted when AddReference is called
+	// or when the first record aligned to the
+	// reference is added.
+	Windows map[string]*[101]uint64 `json:"windows"`
+
+	// ReadStarts holds the number of reads
+	// indexed by GC percentage.
+	ReadStarts [101]uint64 `json:"read_starts"`
+
+	// QualitySum and QualityBases hold the
+	// sum of base qualities and the number of
+	// bases with quality scores of reads
+	// indexed by GC percentage.
+	QualitySum   [101]uint64 `json:"quality_sum"`
+	QualityBases [101]uint64 `json:"quality_bases"`
+
+	ref sam.ReferenceProvider
+
+	// name and gc are the name and window
+	// GC percentages of the reference most
+	// recently used, with -1 for ignored
+	// windows.
+	name string
+	gc   []int8
+}
+
+// NewGCBias returns a new GCBias reading reference sequences from ref.
+// If window is less than one, DefaultGCWindow is used.
+func NewGCBias(ref sam.ReferenceProvider, window int) *GCBias {
+	if window < 1 {
+		window = DefaultGCWindow
+	}
+	return &GCBias{Window: window, Windows: make(map[string]*[101]uint64), ref: ref}
+}
+
+// AddReference counts the windows of the reference with the given name
+// and length if they have not already been counted. Calling
+// AddReference for every reference of a header gives genome wide window
+// counts as reported by Picard.
+func (g *GCBias) AddReference(name string, length int) error {
+	_, err := g.load(name, length)
+	return err
+}
+
+// load returns the window GC percentages of the named reference,
+// counting its windows if they have not already been counted.
+func (g *GCBias) load(name string, length int) ([]int8, error) {
+	if name == g.name && g.gc != nil {
+		return g.gc, nil
+	}
+	seq, err := g.ref.GetSequence(name, 0, length)
+	if err != nil {
+		return nil, err
+	}
+	g.name = name
+	g.gc = windowGC(g.gc[:0], seq, g.Window)
+	if _, ok := g.Windows[name]; !ok {
+		var w [101]uint64
+		for _, gc := range g.gc {
+			if gc >= 0 {
+				w[gc]++
+			}
+		}
+		g.Windows[name] = &w
+	}
+	return g.gc, nil
+}
+
+// windowGC appends the GC percentage of each window of seq to dst,
+// with -1 for windows with too many N bases.
+func windowGC(dst []int8, seq []byte, window int) []int8 {
+	if len(seq) < window {
+		return dst
+	}
+	maxN := window * 4 / 100
+	var gc, at, n int
+	count := func(b byte, d int) {
+		switch b &^ 0x20 {
+		case 'C', 'G', 'S':
+			gc += d
+		case 'A', 'T', 'W':
+			at += d
+		default:
+			n += d
+		}
+	}
+	for i := 0; i < window; i++ {
+		count(seq[i], 1)
+	}
+	for i := 0; ; i++ {
+		if n > maxN || gc+at == 0 {
+			dst = append(dst, -1)
+		} else {
+			dst = append(dst, int8(math.Round(100*float64(gc)/float64(gc+at))))
+		}
+		if i+window == len(seq) {
+			return dst
+		}
+		count(seq[i], -1)
+		count(seq[i+window], 1)
+	}
+}
+
+// Add adds the record r to the counts, reading the sequence of the
+// reference of r if it differs from that of the previously added
+// record.
+func (g *GCBias) Add(r *sam.Record) error {
+	if r.Flags&(sam.Unmapped|sam.Secondary|sam.Supplementary|sam.QCFail|sam.Duplicate) != 0 || r.Ref == nil {
+		return nil
+	}
+	gc, err := g.load(r.Ref.Name(), r.Ref.Len())
+	if err != nil {
+		return err
+	}
+	pos := r.Pos
+	if r.Flags&sam.Reverse != 0 {
+		pos = r.End() - g.Window
+	}
+	if pos < 0 || pos >= len(gc) || gc[pos] < 0 {
+		return nil
+	}
+	bin := gc[pos]
+	g.ReadStarts[bin]++
+	if n := r.Seq.Length; len(r.Qual) == n && n != 0 && r.Qual[0] != 0xff {
+		for _, q := range r.Qual {
+			g.QualitySum[bin] += uint64(q)
+		}
+		g.QualityBases[bin] += uint64(n)
+	}
+	return nil
+}
+
+// Merge adds the counts of o to g. The windows of references counted
+// by both g and o are counted once. Merge panics if the window widths
+// of g and o differ.
+func (g *GCBias) Merge(o *GCBias) {
+	if g.Window != o.Window {
+		panic("stats: mismatched GC window widths")
+	}
+	if g.Windows == nil {
+		g.Windows = make(map[string]*[101]uint64)
+	}
+	for name, w := range o.Windows {
+		if _, ok := g.Windows[name]; !ok {
+			c := *w
+			g.Windows[name] = &c
+		}
+	}
+	for i := range g.ReadStarts {
+		g.ReadStarts[i] += o.ReadStarts[i]
+		g.QualitySum[i] += o.QualitySum[i]
+		g.QualityBases[i] += o.QualityBases[i]
+	}
+}
+
+// GCBiasMetrics holds the GC bias metrics calculated from a GCBias.
+type GCBiasMetrics struct {
+	// Bins holds the metrics for each
+	// GC percentage.
+	Bins [101]GCBin `json:"bins"`
+
+	// ATDropout and GCDropout are the sums
+	// of the excess of the percentage of
+	// windows over the percentage of reads
+	// for GC percentages up to 50 and above
+	// 50 respectively.
+	ATDropout float64 `json:"at_dropout"`
+	GCDropout float64 `json:"gc_dropout"`
+}
+
+// GCBin holds the GC bias metrics of windows with a GC percentage.
+type GCBin struct {
+	Windows         uint64  `json:"windows"`
+	ReadStarts      uint64  `json:"read_starts"`
+	MeanBaseQuality float64 `json:"mean_base_quality"`
+
+	// NormalizedCoverage is the number of
+	// reads per window relative to the
+	// number of reads per window over all
+	// windows, and ErrorBarWidth is the
+	// Poisson error of NormalizedCoverage.
+	NormalizedCoverage float64 `json:"normalized_coverage"`
+	ErrorBarWidth      float64 `json:"error_bar_width"`
+}
+
+// Metrics returns the GC bias metrics of the counts.
+func (g *GCBias) Metrics() GCBiasMetrics {
+	var (
+		m       GCBiasMetrics
+		windows [101]uint64
+		total   struct{ windows, reads uint64 }
+	)
+	for _, w := range g.Windows {
+		for i, n := range w {
+			windows[i] += n
+			total.windows += n
+		}
+	}
+	for _, n := range g.ReadStarts {
+		total.reads += n
+	}
+	mean := ratio(total.reads, total.windows)
+	for i := range m.Bins {
+		b := &m.Bins[i]
+		b.Windows = windows[i]
+		b.ReadStarts = g.ReadStarts[i]
+		b.MeanBaseQuality = ratio(g.QualitySum[i], g.QualityBases[i])
+		if b.Windows != 0 && mean != 0 {
+			b.NormalizedCoverage = float64(b.ReadStarts) / float64(b.Windows) / mean
+			b.ErrorBarWidth = math.Sqrt(float64(b.ReadStarts)) / float64(b.Windows) / mean
+		}
+		dropout := 100 * (ratio(b.Windows, total.windows) - ratio(b.ReadStarts, total.reads))
+		if dropout > 0 {
+			if i <= 50 {
+				m.ATDropout += dropout
+			} else {
+				m.GCDropout += dropout
+			}
+		}
+	}
+	return m
+}
+
+// WriteTo writes the GC bias metrics to w in a tab-separated format
+// based on the output of Picard CollectGcBiasMetrics, with the summary
+// dropout metrics preceding the metrics of each GC percentage.
+func (g *GCBias) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	m := g.Metrics()
+
+	cw.printf("## GC BIAS SUMMARY\nWINDOW_SIZE\tAT_DROPOUT\tGC_DROPOUT\n")
+	cw.printf("%d\t%f\t%f\n\n", g.Window, m.ATDropout, m.GCDropout)
+
+	cw.printf("## GC BIAS DETAIL\nGC\tWINDOWS\tREAD_STARTS\tMEAN_BASE_QUALITY\tNORMALIZED_COVERAGE\tERROR_BAR_WIDTH\n")
+	for i, b := range m.Bins {
+		cw.printf("%d\t%d\t%d\t%f\t%f\t%f\n", i, b.Windows, b.ReadStarts, b.MeanBaseQuality, b.NormalizedCoverage, b.ErrorBarWidth)
+	}
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
@@ -0,0 +1,96 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/reference"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestGCBias(t *testing.T) {
+	ref := reference.NewMemory()
+	err := ref.Add("chr1", []byte("AAAAAAAAAAGGGGGGGGGGNAAAAAAAAA"))
+	if err != nil {
+		t.Fatalf("unexpected error adding reference: %v", err)
+	}
+	// Base qualities are 20 to 29.
+	const q = "56789:;<=>"
+	builder := htstestutil.NewBAMBuilder().Ref("chr1", 30)
+	builder.Record("r", "chr1", 0, "10M", "AAAAAAAAAA").Qual(q).
+		Record("r", "chr1", 5, "10M", "AAAAAGGGGG").Qual(q).
+		Record("r", "chr1", 10, "10M", "GGGGGGGGGG").Qual(q).Flags(sam.Reverse).
+		Record("r", "chr1", 5, "10M", "AAAAAGGGGG").Qual(q).Flags(sam.Duplicate).
+		// Windows including the N are ignored.
+		Record("r", "chr1", 15, "10M", "GGGGGNAAAA").Qual(q).
+		Record("r", "*", -1, "*", "NNNN").Qual("5678").Flags(sam.Unmapped)
+	h, err := builder.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	recs, err := builder.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+
+	a, b := NewGCBias(ref, 10), NewGCBias(ref, 10)
+	for i, r := range recs {
+		g := a
+		if i%2 != 0 {
+			g = b
+		}
+		err := g.Add(r)
+		if err != nil {
+			t.Fatalf("unexpected error adding record: %v", err)
+		}
+	}
+	a.Merge(b)
+
+	m := a.Metrics()
+	var windows, reads uint64
+	for i, bin := range m.Bins {
+		windows += bin.Windows
+		reads += bin.ReadStarts
+		want := uint64(0)
+		if i%10 == 0 {
+			want = 1
+		}
+		if bin.Windows != want {
+			t.Errorf("unexpected window count for GC %d: got:%d want:%d", i, bin.Windows, want)
+		}
+	}
+	if windows != 11 || reads != 3 {
+		t.Errorf("unexpected totals: got:%d windows %d reads want:11 windows 3 reads", windows, reads)
+	}
+	for _, i := range []int{0, 50, 100} {
+		bin := m.Bins[i]
+		if bin.ReadStarts != 1 || math.Abs(bin.NormalizedCoverage-11.0/3) > 1e-9 || math.Abs(bin.MeanBaseQuality-24.5) > 1e-9 {
+			t.Errorf("unexpected metrics for GC %d: %+v", i, bin)
+		}
+	}
+	if math.Abs(m.ATDropout-400.0/11) > 1e-9 || math.Abs(m.GCDropout-400.0/11) > 1e-9 {
+		t.Errorf("unexpected dropout: got:%v %v want:%v", m.ATDropout, m.GCDropout, 400.0/11)
+	}
+
+	var buf bytes.Buffer
+	_, err = a.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing metrics: %v", err)
+	}
+	for _, line := range []string{
+		"WINDOW_SIZE\tAT_DROPOUT\tGC_DROPOUT\n10\t36.363636\t36.363636\n",
+		"\n50\t1\t1\t24.500000\t3.666667\t3.666667\n",
+		"\n51\t0\t0\t0.000000\t0.000000\t0.000000\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("output missing %q:\n%s", line, &buf)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+// Default InsertSizeOptions values.
+const (
+	DefaultDeviations = 10
+	DefaultMinPercent = 0.05
+)
+
+// PairOrientation is the orientation of a read pair as defined by
+// Picard.
+type PairOrientation int
+
+const (
+	FR     PairOrientation = iota // The forward read 5' end precedes the reverse read 5' end.
+	RF                            // The reverse read 5' end precedes the forward read 5' end.
+	Tandem                        // Both reads are on the same strand.
+)
+
+func (o PairOrientation) String() string {
+	switch o {
+	case FR:
+		return "FR"
+	case RF:
+		return "RF"
+	case Tandem:
+		return "TANDEM"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// InsertSizeOptions specifies the behaviour of an InsertSizes.
+type InsertSizeOptions struct {
+	// Deviations is the number of median
+	// absolute deviations above the median
+	// insert size beyond which insert sizes
+	// are trimmed before calculating the
+	// mean and standard deviation. If
+	// Deviations is zero, DefaultDeviations
+	// is used.
+	Deviations float64
+
+	// MinPercent is the minimum fraction
+	// of read pairs that an orientation must
+	// hold to be reported. If MinPercent is
+	// zero, DefaultMinPercent is used. If it
+	// is negative, all orientations with
+	// pairs are reported.
+	MinPercent float64
+
+	// IncludeDuplicates specifies that
+	// duplicate records are counted.
+	IncludeDuplicates bool
+}
+
+// InsertSizes collects insert size distributions equivalent to those
+// reported by Picard CollectInsertSizeMetrics.
+//
+// A pair is counted once, for its first read, when both reads are
+// mapped to the same reference with a non-zero template length.
+// Secondary, supplementary, QC failed and, unless IncludeDuplicates is
+// set, duplicate records are ignored.
+//
+// The zero value is ready to use with the default options. InsertSizes
+// values collected from disjoint sets of records may be combined with
+// Merge.
+type InsertSizes struct {
+	// Histograms holds the counts of pairs
+	// indexed by orientation and then by
+	// insert size.
+	Histograms [3]map[int]uint64 `json:"histograms"`
+
+	opts InsertSizeOptions
+}
+
+// NewInsertSizes returns a new InsertSizes using the provided options.
+func NewInsertSizes(opts InsertSizeOptions) *InsertSizes {
+	return &InsertSizes{opts: opts}
+}
+
+// Add adds the record r to the distributions.
+func (s *InsertSizes) Add(r *sam.Record) {
+	exclude := sam.Unmapped | sam.MateUnmapped | sam.Secondary | sam.Supplementary | sam.QCFail
+	if !s.opts.IncludeDuplicates {
+		exclude |= sam.Duplicate
+	}
+	if r.Flags&(sam.Paired|sam.Read1|exclude) != sam.Paired|sam.Read1 {
+		return
+	}
+	if r.Ref == nil || r.MateRef != r.Ref || r.TempLen == 0 {
+		return
+	}
+	size := r.TempLen
+	if size < 0 {
+		size = -size
+	}
+	o := pairOrientation(r)
+	if s.Histograms[o] == nil {
+		s.Histograms[o] = make(map[int]uint64)
+	}
+	s.Histograms[o][size]++
+}
+
+// pairOrientation returns the Picard pair orientation of r, comparing
+// the one-based 5' positions of the forward and reverse reads.
+func pairOrientation(r *sam.Record) PairOrientation {
+	rev, mateRev := r.Flags&sam.Reverse != 0, r.Flags&sam.MateReverse != 0
+	if rev == mateRev {
+		return Tandem
+	}
+	var pos, neg int
+	if rev {
+		pos, neg = r.MatePos+1, r.End()
+	} else {
+		pos, neg = r.Pos+1, r.Pos+1+r.TempLen
+	}
+	if pos < neg {
+		return FR
+	}
+	return RF
+}
+
+// Merge adds the distributions of o to s.
+func (s *InsertSizes) Merge(o *InsertSizes) {
+	for i, h := range o.Histograms {
+		if len(h) == 0 {
+			continue
+		}
+		if s.Histograms[i] == nil {
+			s.Histograms[i] = make(map[int]uint64, len(h))
+		}
+		for size, n := range h {
+			s.Histograms[i][size] += n
+		}
+	}
+}
+
+// WidthPercents holds the fractions of pairs for which the widths of
+// InsertSizeMetrics are reported.
+var WidthPercents = [...]float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 0.95, 0.99}
+
+// InsertSizeMetrics holds the insert size metrics of read pairs with
+// one orientation.
+type InsertSizeMetrics struct {
+	Orientation PairOrientation `json:"pair_orientation"`
+
+	ReadPairs uint64 `json:"read_pairs"`
+
+	// Median, Mode, MedianAbsoluteDeviation,
+	// Min and Max are calculated from all
+	// insert sizes.
+	Median                  float64 `json:"median_insert_size"`
+	Mode                    int     `json:"mode_insert_size"`
+	MedianAbsoluteDeviation float64 `json:"median_absolute_deviation"`
+	Min                     int     `json:"min_insert_size"`
+	Max                     int     `json:"max_insert_size"`
+
+	// Mean and StandardDeviation are
+	// calculated after trimming insert
+	// sizes beyond the outlier threshold.
+	Mean              float64 `json:"mean_insert_size"`
+	StandardDeviation float64 `json:"standard_deviation"`
+
+	// Widths holds the widths of the
+	// smallest ranges centred on the median
+	// that include the fractions of pairs
+	// in WidthPercents.
+	Widths [len(WidthPercents)]int `json:"widths"`
+}
+
+// Metrics returns the metrics of each orientation holding at least the
+// minimum fraction of read pairs, in orientation order.
+func (s *InsertSizes) Metrics() []InsertSizeMetrics {
+	deviations := s.opts.Deviations
+	if deviations == 0 {
+		deviations = DefaultDeviations
+	}
+	minPercent := s.opts.MinPercent
+	if minPercent == 0 {
+		minPercent = DefaultMinPercent
+	}
+
+	var total uint64
+	for _, h := range s.Histograms {
+		for _, n := range h {
+			total += n
+		}
+	}
+	var metrics []InsertSizeMetrics
+	for o, h := range s.Histograms {
+		hist := newHistogram(h)
+		if hist.total == 0 || float64(hist.total) < minPercent*float64(total) {
+			continue
+		}
+		m := InsertSizeMetrics{
+			Orientation: PairOrientation(o),
+			ReadPairs:   hist.total,
+			Median:      hist.median(),
+			Mode:        hist.mode(),
+			Min:         hist.sizes[0],
+			Max:         hist.sizes[len(hist.sizes)-1],
+		}
+		m.MedianAbsoluteDeviation = hist.mad(m.Median)
+		m.Widths = hist.widths(m.Median)
+		m.Mean, m.StandardDeviation = hist.trim(int(m.Median + deviations*m.MedianAbsoluteDeviation)).meanStd()
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// histogram is a sorted insert size histogram.
+type histogram struct {
+	sizes  []int
+	counts []uint64
+	total  uint64
+}
+
+func newHistogram(h map[int]uint64) histogram {
+	var hist histogram
+	for size := range h {
+		hist.sizes = append(hist.sizes, size)
+	}
+	sort.Ints(hist.sizes)
+	hist.counts = make([]uint64, len(hist.sizes))
+	for i, size := range hist.sizes {
+		hist.counts[i] = h[size]
+		hist.total += h[size]
+	}
+	return hist
+}
+
+// median returns the median value of the histogram, averaging the two
+// central values when the total count is even.
+func (h histogram) median() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	lo, hi := (h.total+1)/2, h.total/2+1
+	var (
+		n      uint64
+		lv, hv = -1, -1
+	)
+	for i, c := range h.counts {
+		n += c
+		if lv < 0 && n >= lo {
+			lv = h.sizes[i]
+		}
+		if n >= hi {
+			hv = h.sizes[i]
+			break
+		}
+	}
+	return float64(lv+hv) / 2
+}
+
+// mode returns the most frequent value of the histogram, choosing the
+// smallest value when there are ties.
+func (h histogram) mode() int {
+	var mode int
+	var best uint64
+	for i, c := range h.counts {
+		if c > best {
+			mode, best = h.sizes[i], c
+		}
+	}
+	return mode
+}
+
+// mad returns the median absolute deviation of the histogram from
+// median.
+func (h histogram) mad(median float64) float64 {
+	// Deviations are doubled to hold
+	// half-integer values as integers.
+	dev := make(map[int]uint64, len(h.sizes))
+	for i, size := range h.sizes {
+		dev[int(math.Abs(2*(float64(size)-median)))] += h.counts[i]
+	}
+	return newHistogram(dev).median() / 2
+}
+
+// widths returns the widths of the smallest ranges centred on median
+// that hold the fractions of counts in WidthPercents.
+func (h histogram) widths(median float64) [len(WidthPercents)]int {
+	var widths [len(WidthPercents)]int
+	bin := make(map[int]uint64, len(h.sizes))
+	for i, size := range h.sizes {
+		bin[size] = h.counts[i]
+	}
+	min, max := h.sizes[0], h.sizes[len(h.sizes)-1]
+	var covered uint64
+	next := 0
+	for lo, hi := int(median), int(median); next < len(widths) && (lo >= min || hi <= max); lo, hi = lo-1, hi+1 {
+		covered += bin[lo]
+		if lo != hi {
+			covered += bin[hi]
+		}
+		frac := float64(covered) / float64(h.total)
+		for next < len(widths) && frac >= WidthPercents[next] {
+			widths[next] = hi - lo + 1
+			next++
+		}
+	}
+	return widths
+}
+
+// trim returns the histogram without values greater than max.
+func (h histogram) trim(max int) histogram {
+	n := sort.Search(len(h.sizes), func(i int) bool { return h.sizes[i] > max })
+	t := histogram{sizes: h.sizes[:n], counts: h.counts[:n]}
+	for _, c := range t.counts {
+		t.total += c
+	}
+	return t
+}
+
+// meanStd returns the mean and sample standard deviation of the
+// histogram.
+func (h histogram) meanStd() (mean, std float64) {
+	if h.total == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for i, c := range h.counts {
+		sum += float64(c) * float64(h.sizes[i])
+	}
+	n := float64(h.total)
+	mean = sum / n
+	if h.total < 2 {
+		return mean, 0
+	}
+	var ss float64
+	for i, c := range h.counts {
+		d := float64(h.sizes[i]) - mean
+		ss += float64(c) * d * d
+	}
+	return mean, math.Sqrt(ss / (n - 1))
+}
+
+// WriteTo writes the metrics and histograms to w in the tab-separated
+// format of Picard CollectInsertSizeMetrics output. Histogram columns
+// are written for the reported orientations.
+func (s *InsertSizes) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	metrics := s.Metrics()
+
+	cw.printf("## METRICS CLASS\tpicard.analysis.InsertSizeMetrics\n")
+	cw.printf("MEDIAN_INSERT_SIZE\tMODE_INSERT_SIZE\tMEDIAN_ABSOLUTE_DEVIATION\tMIN_INSERT_SIZE\tMAX_INSERT_SIZE\tMEAN_INSERT_SIZE\tSTANDARD_DEVIATION\tREAD_PAIRS\tPAIR_ORIENTATION")
+	for _, p := range WidthPercents {
+		cw.printf("\tWIDTH_OF_%d_PERCENT", int(math.Round(100*p)))
+	}
+	cw.printf("\n")
+	for _, m := range metrics {
+		cw.printf("%g\t%d\t%g\t%d\t%d\t%f\t%f\t%d\t%v", m.Median, m.Mode, m.MedianAbsoluteDeviation, m.Min, m.Max, m.Mean, m.StandardDeviation, m.ReadPairs, m.Orientation)
+		for _, w := range m.Widths {
+			cw.printf("\t%d", w)
+		}
+		cw.printf("\n")
+	}
+
+	if len(metrics) != 0 {
+		cw.printf("\n## HISTOGRAM\tjava.lang.Integer\ninsert_size")
+		seen := make(map[int]bool)
+		var sizes []int
+		for _, m := range metrics {
+			cw.printf("\tAll_Reads.%s_count", strings.ToLower(m.Orientation.String()))
+			for size := range s.Histograms[m.Orientation] {
+				if !seen[size] {
+					seen[size] = true
+					sizes = append(sizes, size)
+				}
+			}
+		}
+		cw.printf("\n")
+		sort.Ints(sizes)
+		for _, size := range sizes {
+			cw.printf("%d", size)
+			for _, m := range metrics {
+				cw.printf("\t%d", s.Histograms[m.Orientation][size])
+			}
+			cw.printf("\n")
+		}
+	}
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
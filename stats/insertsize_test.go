@@ -0,0 +1,114 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestInsertSizes(t *testing.T) {
+	builder := htstestutil.NewBAMBuilder().Ref("chr1", 100000).Ref("chr2", 100000)
+	pair := func(tlen int, flags sam.Flags) *htstestutil.RecordBuilder {
+		return builder.Record("r", "chr1", 1000, "4M", "ACGT").Flags(sam.Paired|sam.Read1|flags).Mate("=", 1000+tlen-4, tlen)
+	}
+	for _, tlen := range []int{100, 100, 101, 102, 103, 104, 105, 110, 120, 10000} {
+		pair(tlen, sam.MateReverse)
+	}
+	// A reverse first read with the mate 5' end preceding its own.
+	pair(-300, sam.Reverse).Mate("=", 704, -300)
+	// An RF pair with the forward read downstream.
+	pair(-50, sam.MateReverse).Mate("=", 954, -50)
+	pair(200, 0)
+	pair(200, sam.Duplicate|sam.MateReverse)
+	pair(200, sam.Secondary|sam.MateReverse)
+	pair(0, sam.MateReverse)
+	pair(200, sam.MateReverse).Mate("chr2", 1196, 200)
+	pair(200, sam.MateReverse).Flags(sam.Paired | sam.Read2 | sam.MateReverse)
+	h, err := builder.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	recs, err := builder.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+
+	var a, b InsertSizes
+	for i, r := range recs {
+		if i%2 == 0 {
+			a.Add(r)
+		} else {
+			b.Add(r)
+		}
+	}
+	a.Merge(&b)
+
+	wantHist := [3]map[int]uint64{
+		FR:     {100: 2, 101: 1, 102: 1, 103: 1, 104: 1, 105: 1, 110: 1, 120: 1, 10000: 1, 300: 1},
+		RF:     {50: 1},
+		Tandem: {200: 1},
+	}
+	if !reflect.DeepEqual(a.Histograms, wantHist) {
+		t.Fatalf("unexpected histograms:\ngot: %v\nwant:%v", a.Histograms, wantHist)
+	}
+
+	got := a.Metrics()
+	if len(got) != 3 || got[1].Orientation != RF || got[2].Orientation != Tandem {
+		t.Fatalf("unexpected orientations: %+v", got)
+	}
+	m := got[0]
+	if m.Orientation != FR || m.ReadPairs != 11 || m.Median != 104 || m.Mode != 100 || m.Min != 100 || m.Max != 10000 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+	if m.MedianAbsoluteDeviation != 4 {
+		t.Errorf("unexpected median absolute deviation: got:%v want:4", m.MedianAbsoluteDeviation)
+	}
+	// Insert sizes above 104+10*4 are trimmed.
+	wantMean := (2*100 + 101 + 102 + 103 + 104 + 105 + 110 + 120) / 9.0
+	if math.Abs(m.Mean-wantMean) > 1e-9 {
+		t.Errorf("unexpected mean: got:%v want:%v", m.Mean, wantMean)
+	}
+	wantWidths := [len(WidthPercents)]int{3, 3, 5, 7, 9, 9, 13, 33, 393, 19793, 19793}
+	if m.Widths != wantWidths {
+		t.Errorf("unexpected widths: got:%v want:%v", m.Widths, wantWidths)
+	}
+
+	// RF and tandem pairs are each less than
+	// 10% of pairs.
+	fr := NewInsertSizes(InsertSizeOptions{MinPercent: 0.1, Deviations: 1})
+	fr.Merge(&a)
+	got = fr.Metrics()
+	if len(got) != 1 || got[0].Orientation != FR {
+		t.Fatalf("unexpected orientations: %+v", got)
+	}
+	if want := (2*100 + 101 + 102 + 103 + 104 + 105) / 7.0; math.Abs(got[0].Mean-want) > 1e-9 {
+		t.Errorf("unexpected trimmed mean: got:%v want:%v", got[0].Mean, want)
+	}
+
+	var buf bytes.Buffer
+	_, err = a.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing metrics: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"104\t100\t4\t100\t10000\t",
+		"\t11\tFR\t3\t3\t5\t",
+		"insert_size\tAll_Reads.fr_count\tAll_Reads.rf_count\tAll_Reads.tandem_count\n",
+		"\n50\t0\t1\t0\n",
+		"\n100\t2\t0\t0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,665 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+const (
+	// MaxInsertSize is the largest insert size
+	// recorded. Larger insert sizes are recorded
+	// as MaxInsertSize.
+	MaxInsertSize = 8000
+
+	// MaxCoverage is the largest depth recorded
+	// in the coverage histogram. Greater depths
+	// are recorded as MaxCoverage.
+	MaxCoverage = 1000
+)
+
+// Stats holds alignment statistics equivalent to those reported by
+// samtools stats. Secondary and supplementary alignments are counted
+// in the summary and are otherwise ignored.
+//
+// The per-cycle statistics are kept separately for the first and last
+// fragments of templates, and are indexed by sequencing cycle, so
+// bases of reverse strand alignments are complemented and counted in
+// reverse order.
+//
+// The coverage histogram is only correct for coordinate sorted input.
+//
+// The zero value is ready to use. Stats values collected from disjoint
+// sets of records may be combined with Merge; for coverage, the sets
+// must not cover the same reference positions.
+type Stats struct {
+	Summary Summary `json:"summary"`
+
+	// FirstQuality and LastQuality are
+	// histograms of base quality indexed
+	// by cycle and then quality.
+	FirstQuality [][]uint64 `json:"first_quality"`
+	LastQuality  [][]uint64 `json:"last_quality"`
+
+	// FirstBases and LastBases are counts
+	// of A, C, G, T and other bases indexed
+	// by cycle.
+	FirstBases [][5]uint64 `json:"first_bases"`
+	LastBases  [][5]uint64 `json:"last_bases"`
+
+	// FirstGC and LastGC are histograms of
+	// read GC content indexed by percentage.
+	FirstGC [101]uint64 `json:"first_gc"`
+	LastGC  [101]uint64 `json:"last_gc"`
+
+	// InsertSize holds counts of inward,
+	// outward and other oriented pairs
+	// indexed by insert size.
+	InsertSize [][3]uint64 `json:"insert_size"`
+
+	// Insertions and Deletions are
+	// histograms of indel lengths.
+	Insertions []uint64 `json:"insertions"`
+	Deletions  []uint64 `json:"deletions"`
+
+	// Coverage is a histogram of the number
+	// of covered reference positions indexed
+	// by depth.
+	Coverage []uint64 `json:"coverage"`
+
+	// MapQ is a histogram of mapping quality
+	// of mapped records.
+	MapQ [256]uint64 `json:"mapq"`
+
+	// cov holds the depths of reference
+	// positions not yet added to Coverage.
+	cov struct {
+		ref    int
+		start  int
+		depths []uint32
+	}
+}
+
+// Summary holds the summary counts of a Stats.
+type Summary struct {
+	Sequences            uint64 `json:"sequences"`
+	FirstFragments       uint64 `json:"first_fragments"`
+	LastFragments        uint64 `json:"last_fragments"`
+	ReadsMapped          uint64 `json:"reads_mapped"`
+	ReadsMappedAndPaired uint64 `json:"reads_mapped_and_paired"`
+	ReadsUnmapped        uint64 `json:"reads_unmapped"`
+	ReadsProperlyPaired  uint64 `json:"reads_properly_paired"`
+	ReadsPaired          uint64 `json:"reads_paired"`
+	ReadsDuplicated      uint64 `json:"reads_duplicated"`
+	ReadsMQ0             uint64 `json:"reads_mq0"`
+	ReadsQCFailed        uint64 `json:"reads_qc_failed"`
+	NonPrimary           uint64 `json:"non_primary"`
+	TotalLength          uint64 `json:"total_length"`
+	BasesMapped          uint64 `json:"bases_mapped"`
+	BasesMappedCigar     uint64 `json:"bases_mapped_cigar"`
+	BasesDuplicated      uint64 `json:"bases_duplicated"`
+	Mismatches           uint64 `json:"mismatches"`
+	QualitySum           uint64 `json:"quality_sum"`
+	QualityBases         uint64 `json:"quality_bases"`
+}
+
+// ErrorRate returns the number of mismatches per aligned base.
+func (s *Summary) ErrorRate() float64 {
+	return ratio(s.Mismatches, s.BasesMappedCigar)
+}
+
+// AverageLength returns the mean read length.
+func (s *Summary) AverageLength() float64 {
+	return ratio(s.TotalLength, s.Sequences)
+}
+
+// AverageQuality returns the mean base quality.
+func (s *Summary) AverageQuality() float64 {
+	return ratio(s.QualitySum, s.QualityBases)
+}
+
+func ratio(n, d uint64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// Orientation indexes of Stats InsertSize counts.
+const (
+	Inward = iota
+	Outward
+	OtherOrientation
+)
+
+var (
+	nmTag = sam.NewTag("NM")
+	mdTag = sam.NewTag("MD")
+)
+
+// Add adds the record r to the statistics.
+func (s *Stats) Add(r *sam.Record) {
+	sum := &s.Summary
+	if r.Flags&(sam.Secondary|sam.Supplementary) != 0 {
+		sum.NonPrimary++
+		return
+	}
+	sum.Sequences++
+	if r.Flags&sam.QCFail != 0 {
+		sum.ReadsQCFailed++
+	}
+	n := r.Seq.Length
+	sum.TotalLength += uint64(n)
+	if r.Flags&sam.Duplicate != 0 {
+		sum.ReadsDuplicated++
+		sum.BasesDuplicated += uint64(n)
+	}
+	mapped := r.Flags&sam.Unmapped == 0
+	if r.Flags&sam.Paired != 0 {
+		sum.ReadsPaired++
+		if mapped && r.Flags&sam.ProperPair != 0 {
+			sum.ReadsProperlyPaired++
+		}
+		if mapped && r.Flags&sam.MateUnmapped == 0 {
+			sum.ReadsMappedAndPaired++
+		}
+	}
+
+	s.addCycles(r)
+
+	if !mapped {
+		sum.ReadsUnmapped++
+		return
+	}
+	sum.ReadsMapped++
+	sum.BasesMapped += uint64(n)
+	s.MapQ[r.MapQ]++
+	if r.MapQ == 0 {
+		sum.ReadsMQ0++
+	}
+	for _, co := range r.Cigar {
+		l := co.Len()
+		switch co.Type() {
+		case sam.CigarMatch, sam.CigarEqual, sam.CigarMismatch:
+			sum.BasesMappedCigar += uint64(l)
+		case sam.CigarInsertion:
+			sum.BasesMappedCigar += uint64(l)
+			s.Insertions = grow(s.Insertions, l+1)
+			s.Insertions[l]++
+		case sam.CigarDeletion:
+			s.Deletions = grow(s.Deletions, l+1)
+			s.Deletions[l]++
+		}
+	}
+	if nm, ok := auxInt(r.AuxTag(nmTag)); ok {
+		sum.Mismatches += uint64(nm)
+	} else if md := r.AuxTag(mdTag); md != nil {
+		if v, ok := md.Value().(string); ok {
+			sum.Mismatches += uint64(mdMismatches(v))
+		}
+	}
+
+	if r.Flags&(sam.Paired|sam.MateUnmapped) == sam.Paired && r.TempLen > 0 && r.MateRef.ID() == r.Ref.ID() {
+		size := r.TempLen
+		if size > MaxInsertSize {
+			size = MaxInsertSize
+		}
+		s.InsertSize = grow3(s.InsertSize, size+1)
+		s.InsertSize[size][orientation(r)]++
+	}
+
+	s.addCoverage(r)
+}
+
+// addCycles adds the per-cycle base and quality statistics of r.
+func (s *Stats) addCycles(r *sam.Record) {
+	quals, bases, gc := &s.FirstQuality, &s.FirstBases, &s.FirstGC
+	if r.Flags&sam.Read2 != 0 && r.Flags&sam.Read1 == 0 {
+		s.Summary.LastFragments++
+		quals, bases, gc = &s.LastQuality, &s.LastBases, &s.LastGC
+	} else {
+		s.Summary.FirstFragments++
+	}
+	n := r.Seq.Length
+	if n == 0 {
+		return
+	}
+	rev := r.Flags&sam.Reverse != 0
+	hasQual := len(r.Qual) == n && r.Qual[0] != 0xff
+	if len(*bases) < n {
+		*bases = append(*bases, make([][5]uint64, n-len(*bases))...)
+	}
+	if hasQual && len(*quals) < n {
+		*quals = append(*quals, make([][]uint64, n-len(*quals))...)
+	}
+	var nGC int
+	for i := 0; i < n; i++ {
+		cycle := i
+		b := r.Seq.BaseChar(i)
+		if rev {
+			cycle = n - 1 - i
+			b = samutil.Complement(b)
+		}
+		switch b {
+		case 'A':
+			(*bases)[cycle][0]++
+		case 'C':
+			(*bases)[cycle][1]++
+			nGC++
+		case 'G':
+			(*bases)[cycle][2]++
+			nGC++
+		case 'T':
+			(*bases)[cycle][3]++
+		default:
+			(*bases)[cycle][4]++
+		}
+		if hasQual {
+			q := int(r.Qual[i])
+			(*quals)[cycle] = grow((*quals)[cycle], q+1)
+			(*quals)[cycle][q]++
+			s.Summary.QualitySum += uint64(q)
+			s.Summary.QualityBases++
+		}
+	}
+	gc[int(math.Round(100*float64(nGC)/float64(n)))]++
+}
+
+// orientation returns the orientation index of the pair of r, which
+// must be the leftmost record of the pair.
+func orientation(r *sam.Record) int {
+	switch r.Flags & (sam.Reverse | sam.MateReverse) {
+	case sam.MateReverse:
+		return Inward
+	case sam.Reverse:
+		return Outward
+	default:
+		return OtherOrientation
+	}
+}
+
+// addCoverage adds the aligned bases of r to the pending depths, adding
+// depths of positions before the start of r to the Coverage histogram.
+func (s *Stats) addCoverage(r *sam.Record) {
+	c := &s.cov
+	if r.Ref.ID() != c.ref || r.Pos >= c.start+len(c.depths) {
+		s.flushCoverage(len(c.depths))
+		c.ref = r.Ref.ID()
+		c.start = r.Pos
+	} else if r.Pos > c.start {
+		s.flushCoverage(r.Pos - c.start)
+	}
+	pos := r.Pos
+	for _, co := range r.Cigar {
+		l := co.Len()
+		switch co.Type() {
+		case sam.CigarMatch, sam.CigarEqual, sam.CigarMismatch:
+			beg, end := pos-c.start, pos+l-c.start
+			if beg < 0 {
+				// Out of order input.
+				beg = 0
+			}
+			if end > len(c.depths) {
+				c.depths = append(c.depths, make([]uint32, end-len(c.depths))...)
+			}
+			for i := beg; i < end; i++ {
+				c.depths[i]++
+			}
+		}
+		pos += l * co.Type().Consumes().Reference
+	}
+}
+
+// flushCoverage adds the first n pending depths to the Coverage
+// histogram.
+func (s *Stats) flushCoverage(n int) {
+	c := &s.cov
+	for _, d := range c.depths[:n] {
+		if d == 0 {
+			continue
+		}
+		if d > MaxCoverage {
+			d = MaxCoverage
+		}
+		s.Coverage = grow(s.Coverage, int(d)+1)
+		s.Coverage[d]++
+	}
+	c.start += n
+	c.depths = c.depths[:copy(c.depths, c.depths[n:])]
+}
+
+// Flush completes the Coverage histogram for the records added so far.
+// It must be called after the last record has been added and before
+// the Coverage histogram is used.
+func (s *Stats) Flush() {
+	s.flushCoverage(len(s.cov.depths))
+}
+
+// Merge adds the statistics in o to the receiver. Both the receiver and
+// o are flushed.
+func (s *Stats) Merge(o *Stats) {
+	s.Flush()
+	o.Flush()
+
+	a, b := &s.Summary, &o.Summary
+	a.Sequences += b.Sequences
+	a.FirstFragments += b.FirstFragments
+	a.LastFragments += b.LastFragments
+	a.ReadsMapped += b.ReadsMapped
+	a.ReadsMappedAndPaired += b.ReadsMappedAndPaired
+	a.ReadsUnmapped += b.ReadsUnmapped
+	a.ReadsProperlyPaired += b.ReadsProperlyPaired
+	a.ReadsPaired += b.ReadsPaired
+	a.ReadsDuplicated += b.ReadsDuplicated
+	a.ReadsMQ0 += b.ReadsMQ0
+	a.ReadsQCFailed += b.ReadsQCFailed
+	a.NonPrimary += b.NonPrimary
+	a.TotalLength += b.TotalLength
+	a.BasesMapped += b.BasesMapped
+	a.BasesMappedCigar += b.BasesMappedCigar
+	a.BasesDuplicated += b.BasesDuplicated
+	a.Mismatches += b.Mismatches
+	a.QualitySum += b.QualitySum
+	a.QualityBases += b.QualityBases
+
+	s.FirstQuality = mergeQuality(s.FirstQuality, o.FirstQuality)
+	s.LastQuality = mergeQuality(s.LastQuality, o.LastQuality)
+	s.FirstBases = mergeBases(s.FirstBases, o.FirstBases)
+	s.LastBases = mergeBases(s.LastBases, o.LastBases)
+	for i := range s.FirstGC {
+		s.FirstGC[i] += o.FirstGC[i]
+		s.LastGC[i] += o.LastGC[i]
+	}
+	s.InsertSize = grow3(s.InsertSize, len(o.InsertSize))
+	for i, c := range o.InsertSize {
+		for j := range c {
+			s.InsertSize[i][j] += c[j]
+		}
+	}
+	s.Insertions = mergeCounts(s.Insertions, o.Insertions)
+	s.Deletions = mergeCounts(s.Deletions, o.Deletions)
+	s.Coverage = mergeCounts(s.Coverage, o.Coverage)
+	for i := range s.MapQ {
+		s.MapQ[i] += o.MapQ[i]
+	}
+}
+
+// InsertSizeMean returns the mean and standard deviation of insert
+// sizes.
+func (s *Stats) InsertSizeMean() (mean, std float64) {
+	var n, sum, sum2 float64
+	for size, c := range s.InsertSize {
+		k := float64(c[Inward] + c[Outward] + c[OtherOrientation])
+		n += k
+		sum += k * float64(size)
+		sum2 += k * float64(size) * float64(size)
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	mean = sum / n
+	return mean, math.Sqrt(sum2/n - mean*mean)
+}
+
+// WriteTo writes the statistics to w in a text format based on the
+// output of samtools stats. Each line starts with a section identifier
+// followed by tab-separated values.
+func (s *Stats) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	sum := &s.Summary
+
+	var pairs [3]uint64
+	for _, c := range s.InsertSize {
+		for j := range c {
+			pairs[j] += c[j]
+		}
+	}
+	mean, std := s.InsertSizeMean()
+
+	cw.printf("# Summary numbers.\n")
+	for _, f := range []struct {
+		label string
+		value interface{}
+	}{
+		{"raw total sequences", sum.Sequences},
+		{"sequences", sum.Sequences},
+		{"1st fragments", sum.FirstFragments},
+		{"last fragments", sum.LastFragments},
+		{"reads mapped", sum.ReadsMapped},
+		{"reads mapped and paired", sum.ReadsMappedAndPaired},
+		{"reads unmapped", sum.ReadsUnmapped},
+		{"reads properly paired", sum.ReadsProperlyPaired},
+		{"reads paired", sum.ReadsPaired},
+		{"reads duplicated", sum.ReadsDuplicated},
+		{"reads MQ0", sum.ReadsMQ0},
+		{"reads QC failed", sum.ReadsQCFailed},
+		{"non-primary alignments", sum.NonPrimary},
+		{"total length", sum.TotalLength},
+		{"bases mapped", sum.BasesMapped},
+		{"bases mapped (cigar)", sum.BasesMappedCigar},
+		{"bases duplicated", sum.BasesDuplicated},
+		{"mismatches", sum.Mismatches},
+		{"error rate", fmt.Sprintf("%e", sum.ErrorRate())},
+		{"average length", int(sum.AverageLength())},
+		{"average quality", fmt.Sprintf("%.1f", sum.AverageQuality())},
+		{"insert size average", fmt.Sprintf("%.1f", mean)},
+		{"insert size standard deviation", fmt.Sprintf("%.1f", std)},
+		{"inward oriented pairs", pairs[Inward]},
+		{"outward oriented pairs", pairs[Outward]},
+		{"pairs with other orientation", pairs[OtherOrientation]},
+	} {
+		cw.printf("SN\t%s:\t%v\n", f.label, f.value)
+	}
+
+	cw.printf("# First fragment qualities. Use `grep ^FFQ | cut -f 2-` to extract this part.\n")
+	writeQuality(cw, "FFQ", s.FirstQuality)
+	cw.printf("# Last fragment qualities. Use `grep ^LFQ | cut -f 2-` to extract this part.\n")
+	writeQuality(cw, "LFQ", s.LastQuality)
+
+	cw.printf("# GC content of first fragments. Use `grep ^GCF | cut -f 2-` to extract this part.\n")
+	writeGC(cw, "GCF", &s.FirstGC)
+	cw.printf("# GC content of last fragments. Use `grep ^GCL | cut -f 2-` to extract this part.\n")
+	writeGC(cw, "GCL", &s.LastGC)
+
+	cw.printf("# ACGT content per cycle for first fragments. Use `grep ^FBC | cut -f 2-` to extract this part. The columns are: cycle; A,C,G,T base counts [%%]; and N,O counts\n")
+	writeBases(cw, "FBC", s.FirstBases)
+	cw.printf("# ACGT content per cycle for last fragments. Use `grep ^LBC | cut -f 2-` to extract this part. The columns are: cycle; A,C,G,T base counts [%%]; and N,O counts\n")
+	writeBases(cw, "LBC", s.LastBases)
+
+	cw.printf("# Insert sizes. Use `grep ^IS | cut -f 2-` to extract this part. The columns are: insert size, pairs total, inward oriented pairs, outward oriented pairs, other pairs\n")
+	for size, c := range s.InsertSize {
+		total := c[Inward] + c[Outward] + c[OtherOrientation]
+		if total == 0 {
+			continue
+		}
+		cw.printf("IS\t%d\t%d\t%d\t%d\t%d\n", size, total, c[Inward], c[Outward], c[OtherOrientation])
+	}
+
+	cw.printf("# Indel distribution. Use `grep ^ID | cut -f 2-` to extract this part. The columns are: length, number of insertions, number of deletions\n")
+	for l := 1; l < len(s.Insertions) || l < len(s.Deletions); l++ {
+		var ins, del uint64
+		if l < len(s.Insertions) {
+			ins = s.Insertions[l]
+		}
+		if l < len(s.Deletions) {
+			del = s.Deletions[l]
+		}
+		if ins == 0 && del == 0 {
+			continue
+		}
+		cw.printf("ID\t%d\t%d\t%d\n", l, ins, del)
+	}
+
+	cw.printf("# Coverage distribution. Use `grep ^COV | cut -f 2-` to extract this part.\n")
+	for d, n := range s.Coverage {
+		if n == 0 {
+			continue
+		}
+		if d == MaxCoverage {
+			cw.printf("COV\t[%d<]\t%d\t%d\n", d, d, n)
+		} else {
+			cw.printf("COV\t[%d-%d]\t%d\t%d\n", d, d, d, n)
+		}
+	}
+
+	cw.printf("# Mapping qualities. Use `grep ^MAPQ | cut -f 2-` to extract this part.\n")
+	for q, n := range s.MapQ {
+		if n != 0 {
+			cw.printf("MAPQ\t%d\t%d\n", q, n)
+		}
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func writeQuality(cw *countWriter, id string, quals [][]uint64) {
+	var max int
+	for _, q := range quals {
+		if len(q) > max {
+			max = len(q)
+		}
+	}
+	for cycle, q := range quals {
+		cw.printf("%s\t%d", id, cycle+1)
+		for i := 0; i < max; i++ {
+			var n uint64
+			if i < len(q) {
+				n = q[i]
+			}
+			cw.printf("\t%d", n)
+		}
+		cw.printf("\n")
+	}
+}
+
+func writeGC(cw *countWriter, id string, gc *[101]uint64) {
+	for pc, n := range gc {
+		if n != 0 {
+			cw.printf("%s\t%d\t%d\n", id, pc, n)
+		}
+	}
+}
+
+func writeBases(cw *countWriter, id string, bases [][5]uint64) {
+	for cycle, b := range bases {
+		acgt := b[0] + b[1] + b[2] + b[3]
+		cw.printf("%s\t%d", id, cycle+1)
+		for _, n := range b[:4] {
+			cw.printf("\t%.2f", 100*ratio(n, acgt))
+		}
+		cw.printf("\t%d\t0\n", b[4])
+	}
+}
+
+// countWriter is a writer that retains the first write error and the
+// number of bytes written.
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) printf(format string, args ...interface{}) {
+	if cw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(cw.w, format, args...)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+// auxInt returns the integer value of a, and whether a holds an
+// integer.
+func auxInt(a sam.Aux) (int, bool) {
+	if a == nil {
+		return 0, false
+	}
+	switch v := a.Value().(type) {
+	case int8:
+		return int(v), true
+	case uint8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case uint16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case uint32:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// mdMismatches returns the number of mismatched bases described by
+// the MD tag value md.
+func mdMismatches(md string) int {
+	var n int
+	del := false
+	for i := 0; i < len(md); i++ {
+		switch c := md[i]; {
+		case '0' <= c && c <= '9':
+			del = false
+		case c == '^':
+			del = true
+		case !del:
+			n++
+		}
+	}
+	return n
+}
+
+func grow(c []uint64, n int) []uint64 {
+	if len(c) < n {
+		c = append(c, make([]uint64, n-len(c))...)
+	}
+	return c
+}
+
+func grow3(c [][3]uint64, n int) [][3]uint64 {
+	if len(c) < n {
+		c = append(c, make([][3]uint64, n-len(c))...)
+	}
+	return c
+}
+
+func mergeCounts(dst, src []uint64) []uint64 {
+	dst = grow(dst, len(src))
+	for i, n := range src {
+		dst[i] += n
+	}
+	return dst
+}
+
+func mergeQuality(dst, src [][]uint64) [][]uint64 {
+	if len(dst) < len(src) {
+		dst = append(dst, make([][]uint64, len(src)-len(dst))...)
+	}
+	for i, q := range src {
+		dst[i] = mergeCounts(dst[i], q)
+	}
+	return dst
+}
+
+func mergeBases(dst, src [][5]uint64) [][5]uint64 {
+	if len(dst) < len(src) {
+		dst = append(dst, make([][5]uint64, len(src)-len(dst))...)
+	}
+	for i, b := range src {
+		for j := range b {
+			dst[i][j] += b[j]
+		}
+	}
+	return dst
+}
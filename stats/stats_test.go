@@ -0,0 +1,147 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestStats(t *testing.T) {
+	// Base qualities are 20, 21, 22 and so on.
+	builder := htstestutil.NewBAMBuilder().Ref("chr1", 1000)
+	builder.Record("r", "chr1", 10, "4M", "ACGT").Qual("5678").Flags(sam.Paired|sam.ProperPair|sam.Read1|sam.MateReverse).Mate("=", 12, 6).MapQ(60).Tag("NM", 1).
+		Record("r", "chr1", 12, "2M1I1M2D1M", "GGTAC").Qual("56789").Flags(sam.Paired|sam.ProperPair|sam.Read2|sam.Reverse).Mate("=", 10, -6).MapQ(60).Tag("MD", "1A1^TT0C0").
+		Record("r", "chr1", 12, "4M", "AAAA").Qual("5678").Flags(sam.Secondary).MapQ(60).
+		Record("r", "*", -1, "*", "NNNN").Qual("5678").Flags(sam.Unmapped)
+	h, err := builder.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	recs, err := builder.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+
+	var all Stats
+	for _, r := range recs {
+		all.Add(r)
+	}
+	all.Flush()
+
+	wantSummary := Summary{
+		Sequences:            3,
+		FirstFragments:       2,
+		LastFragments:        1,
+		ReadsMapped:          2,
+		ReadsMappedAndPaired: 2,
+		ReadsUnmapped:        1,
+		ReadsProperlyPaired:  2,
+		ReadsPaired:          2,
+		NonPrimary:           1,
+		TotalLength:          13,
+		BasesMapped:          9,
+		BasesMappedCigar:     9,
+		Mismatches:           3,
+		QualitySum:           (20+21+22+23)*2 + (20 + 21 + 22 + 23 + 24),
+		QualityBases:         13,
+	}
+	if all.Summary != wantSummary {
+		t.Errorf("unexpected summary:\ngot: %+v\nwant:%+v", all.Summary, wantSummary)
+	}
+
+	// r2 is reverse so its bases by cycle are GTACC.
+	wantLast := [][5]uint64{{0, 0, 1, 0, 0}, {0, 0, 0, 1, 0}, {1, 0, 0, 0, 0}, {0, 1, 0, 0, 0}, {0, 1, 0, 0, 0}}
+	if !reflect.DeepEqual(all.LastBases, wantLast) {
+		t.Errorf("unexpected last fragment bases:\ngot: %v\nwant:%v", all.LastBases, wantLast)
+	}
+	if q := all.LastQuality[0]; len(q) != 25 || q[24] != 1 {
+		t.Errorf("unexpected first cycle last fragment qualities: %v", q)
+	}
+	if all.FirstGC[50] != 1 || all.FirstGC[0] != 1 || all.LastGC[60] != 1 {
+		t.Errorf("unexpected GC content: first:%v last:%v", all.FirstGC, all.LastGC)
+	}
+	if len(all.InsertSize) != 7 || all.InsertSize[6] != [3]uint64{Inward: 1} {
+		t.Errorf("unexpected insert sizes: %v", all.InsertSize)
+	}
+	if !reflect.DeepEqual(all.Insertions, []uint64{0, 1}) || !reflect.DeepEqual(all.Deletions, []uint64{0, 0, 1}) {
+		t.Errorf("unexpected indels: ins:%v del:%v", all.Insertions, all.Deletions)
+	}
+	// Positions 10 and 11 are covered by r1, 12
+	// and 13 by both, and 14 and 17 by r2 alone.
+	if !reflect.DeepEqual(all.Coverage, []uint64{0, 4, 2}) {
+		t.Errorf("unexpected coverage: %v", all.Coverage)
+	}
+
+	var a, b Stats
+	for i, r := range recs {
+		if i < 2 {
+			a.Add(r)
+		} else {
+			b.Add(r)
+		}
+	}
+	a.Merge(&b)
+	a.cov = all.cov
+	if !reflect.DeepEqual(a, all) {
+		t.Errorf("unexpected merged statistics:\ngot: %+v\nwant:%+v", a, all)
+	}
+
+	var buf bytes.Buffer
+	_, err = all.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing statistics: %v", err)
+	}
+	for _, want := range []string{
+		"SN\traw total sequences:\t3\n",
+		"SN\tmismatches:\t3\n",
+		"SN\tinward oriented pairs:\t1\n",
+		"IS\t6\t1\t1\t0\t0\n",
+		"ID\t2\t0\t1\n",
+		"COV\t[2-2]\t2\t2\n",
+		"LBC\t1\t0.00\t0.00\t100.00\t0.00\t0\t0\n",
+		"GCL\t60\t1\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing line %q in output:\n%s", want, buf.String())
+		}
+	}
+
+	j, err := json.Marshal(&all)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling statistics: %v", err)
+	}
+	var got Stats
+	err = json.Unmarshal(j, &got)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshaling statistics: %v", err)
+	}
+	got.cov = all.cov
+	if !reflect.DeepEqual(got, all) {
+		t.Error("statistics did not round trip through JSON")
+	}
+}
+
+func TestMDMismatches(t *testing.T) {
+	for _, test := range []struct {
+		md   string
+		want int
+	}{
+		{md: "10", want: 0},
+		{md: "3A2C0", want: 2},
+		{md: "2^AC3T1", want: 1},
+		{md: "0A^G0T", want: 2},
+	} {
+		if got := mdMismatches(test.md); got != test.want {
+			t.Errorf("unexpected mismatch count for %q: got:%d want:%d", test.md, got, test.want)
+		}
+	}
+}
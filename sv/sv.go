@@ -0,0 +1,416 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sv implements extraction of structural variant evidence from
+// coordinate sorted alignment records.
+//
+// Three kinds of evidence are reported: clusters of reads soft clipped
+// at the same reference position, split reads with supplementary
+// alignments described by SA aux tags, and read pairs with discordant
+// mate placement, orientation or insert size.
+package sv
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrUnsorted = errors.New("sv: records not coordinate sorted")
+)
+
+// Default option values.
+const (
+	DefaultExclude   = sam.Unmapped | sam.Secondary | sam.QCFail | sam.Duplicate
+	DefaultMinClip   = 5
+	DefaultMaxInsert = 1000
+)
+
+var saTag = sam.NewTag("SA")
+
+// Source is a source of coordinate sorted records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Options specifies the behaviour of a Scanner.
+type Options struct {
+	// Exclude specifies flags of records that
+	// are excluded. If Exclude is zero,
+	// DefaultExclude is used.
+	Exclude sam.Flags
+
+	// MinMapQ is the minimum mapping quality
+	// of records used as evidence.
+	MinMapQ byte
+
+	// MinClip is the minimum length of soft
+	// clips included in clusters. If MinClip
+	// is zero, DefaultMinClip is used.
+	MinClip int
+
+	// MinClipReads is the minimum number of
+	// reads in a reported soft clip cluster.
+	// If MinClipReads is less than one, all
+	// clusters are reported.
+	MinClipReads int
+
+	// MaxInsert is the maximum absolute
+	// template length of concordant pairs. If
+	// MaxInsert is zero, DefaultMaxInsert is
+	// used. If it is negative, insert sizes
+	// are not considered.
+	MaxInsert int
+}
+
+// Evidence is structural variant evidence. It is one of *Clip, *Split
+// or *Discordant.
+type Evidence interface {
+	// Position returns the reference and
+	// position of the evidence.
+	Position() (ref *sam.Reference, pos int)
+}
+
+// Side is the side of an alignment a soft clip is on.
+type Side int
+
+const (
+	Left  Side = iota // The clip precedes the aligned bases.
+	Right             // The clip follows the aligned bases.
+)
+
+func (s Side) String() string {
+	if s == Left {
+		return "left"
+	}
+	return "right"
+}
+
+// Clip is a cluster of reads soft clipped at the same position.
+type Clip struct {
+	Ref *sam.Reference
+
+	// Pos is the breakpoint position; the
+	// first aligned base for left clips and
+	// the base following the last aligned
+	// base for right clips.
+	Pos  int
+	Side Side
+
+	// Reads is the number of reads
+	// in the cluster.
+	Reads int
+
+	// Seq is the consensus of the clipped
+	// sequences in reference orientation.
+	// Left clipped sequences are aligned on
+	// their last base and right clipped
+	// sequences on their first base.
+	Seq []byte
+}
+
+// Position returns the reference and breakpoint of the cluster.
+func (c *Clip) Position() (*sam.Reference, int) { return c.Ref, c.Pos }
+
+// Split is a read with supplementary alignments.
+type Split struct {
+	Record *sam.Record
+
+	// Parts holds the supplementary
+	// alignments of the record.
+	Parts []Alignment
+}
+
+// Position returns the reference and position of the record.
+func (s *Split) Position() (*sam.Reference, int) { return s.Record.Ref, s.Record.Pos }
+
+// Alignment is an alignment described by an SA aux tag.
+type Alignment struct {
+	Ref     string
+	Pos     int
+	Reverse bool
+	Cigar   sam.Cigar
+	MapQ    byte
+	NM      int
+}
+
+// ParseSA returns the alignments described in the value of an SA aux
+// tag. Positions are converted to zero-based coordinates.
+func ParseSA(sa string) ([]Alignment, error) {
+	var parts []Alignment
+	for _, f := range strings.Split(strings.TrimSuffix(sa, ";"), ";") {
+		fields := strings.Split(f, ",")
+		if len(fields) != 6 {
+			return nil, errors.New("sv: invalid SA field: " + f)
+		}
+		pos, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, errors.New("sv: invalid SA position: " + fields[1])
+		}
+		if fields[2] != "+" && fields[2] != "-" {
+			return nil, errors.New("sv: invalid SA strand: " + fields[2])
+		}
+		co, err := sam.ParseCigar([]byte(fields[3]))
+		if err != nil {
+			return nil, err
+		}
+		q, err := strconv.ParseUint(fields[4], 10, 8)
+		if err != nil {
+			return nil, errors.New("sv: invalid SA mapping quality: " + fields[4])
+		}
+		nm, err := strconv.Atoi(fields[5])
+		if err != nil {
+			return nil, errors.New("sv: invalid SA edit distance: " + fields[5])
+		}
+		parts = append(parts, Alignment{
+			Ref:     fields[0],
+			Pos:     pos - 1,
+			Reverse: fields[2] == "-",
+			Cigar:   co,
+			MapQ:    byte(q),
+			NM:      nm,
+		})
+	}
+	return parts, nil
+}
+
+// Reason describes why a read pair is discordant.
+type Reason int
+
+const (
+	MateRef     Reason = 1 << iota // The mate is aligned to another reference.
+	Orientation                    // The reads are not in forward-reverse orientation.
+	InsertSize                     // The template length exceeds the maximum insert size.
+)
+
+// Discordant is a discordantly aligned read pair. It is reported for
+// the leftmost read of the pair.
+type Discordant struct {
+	Record *sam.Record
+	Reason Reason
+}
+
+// Position returns the reference and position of the record.
+func (d *Discordant) Position() (*sam.Reference, int) { return d.Record.Ref, d.Record.Pos }
+
+// Scanner reads records and returns structural variant evidence.
+// Split and discordant pair evidence is returned as each record is
+// read; soft clip clusters are returned once no later record can
+// contribute to them, so evidence is not strictly position sorted.
+type Scanner struct {
+	src  Source
+	opts Options
+
+	ref      *sam.Reference
+	pos      int
+	clusters map[clipKey]*cluster
+
+	queue []Evidence
+	curr  Evidence
+	done  bool
+	err   error
+}
+
+type clipKey struct {
+	pos  int
+	side Side
+}
+
+type cluster struct {
+	reads int
+	// counts holds base counts by offset
+	// from the breakpoint, outward.
+	counts [][4]int
+}
+
+// NewScanner returns a Scanner reading records from src.
+func NewScanner(src Source, opts Options) *Scanner {
+	if opts.Exclude == 0 {
+		opts.Exclude = DefaultExclude
+	}
+	if opts.MinClip == 0 {
+		opts.MinClip = DefaultMinClip
+	}
+	if opts.MaxInsert == 0 {
+		opts.MaxInsert = DefaultMaxInsert
+	}
+	return &Scanner{src: src, opts: opts, clusters: make(map[clipKey]*cluster)}
+}
+
+// Next advances the Scanner to the next evidence, returning false
+// when no evidence remains or an error has occurred.
+func (s *Scanner) Next() bool {
+	for len(s.queue) == 0 {
+		if s.done || s.err != nil {
+			s.curr = nil
+			return false
+		}
+		r, err := s.src.Read()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			s.done = true
+			s.flush(nil, 0)
+			continue
+		}
+		if r.Flags&s.opts.Exclude != 0 || r.MapQ < s.opts.MinMapQ || r.Ref == nil {
+			continue
+		}
+		if r.Ref == s.ref && r.Pos < s.pos || r.Ref != s.ref && s.ref != nil && r.Ref.ID() < s.ref.ID() {
+			s.err = ErrUnsorted
+			continue
+		}
+		s.flush(r.Ref, r.Pos)
+		s.ref, s.pos = r.Ref, r.Pos
+		err = s.add(r)
+		if err != nil {
+			s.err = err
+		}
+	}
+	s.curr, s.queue = s.queue[0], s.queue[1:]
+	return true
+}
+
+// Evidence returns the current evidence.
+func (s *Scanner) Evidence() Evidence { return s.curr }
+
+// Error returns the first non-EOF error encountered.
+func (s *Scanner) Error() error { return s.err }
+
+// flush queues the clusters that cannot be extended by records at or
+// after pos on ref.
+func (s *Scanner) flush(ref *sam.Reference, pos int) {
+	var keys []clipKey
+	for k := range s.clusters {
+		if ref != s.ref || k.pos < pos {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pos != keys[j].pos {
+			return keys[i].pos < keys[j].pos
+		}
+		return keys[i].side < keys[j].side
+	})
+	for _, k := range keys {
+		c := s.clusters[k]
+		delete(s.clusters, k)
+		if c.reads < s.opts.MinClipReads {
+			continue
+		}
+		seq := make([]byte, len(c.counts))
+		for i, n := range c.counts {
+			best := 0
+			for j := range n {
+				if n[j] > n[best] {
+					best = j
+				}
+			}
+			seq[i] = "ACGT"[best]
+			if n[best] == 0 {
+				seq[i] = 'N'
+			}
+		}
+		if k.side == Left {
+			for i, j := 0, len(seq)-1; i < j; i, j = i+1, j-1 {
+				seq[i], seq[j] = seq[j], seq[i]
+			}
+		}
+		s.queue = append(s.queue, &Clip{Ref: s.ref, Pos: k.pos, Side: k.side, Reads: c.reads, Seq: seq})
+	}
+}
+
+// add adds the evidence of r.
+func (s *Scanner) add(r *sam.Record) error {
+	if len(r.Cigar) != 0 {
+		seq := r.Seq.Expand()
+		first, last := 0, len(r.Cigar)-1
+		// Skip hard clips at the ends.
+		for first < last && r.Cigar[first].Type() == sam.CigarHardClipped {
+			first++
+		}
+		for last > first && r.Cigar[last].Type() == sam.CigarHardClipped {
+			last--
+		}
+		if co := r.Cigar[first]; co.Type() == sam.CigarSoftClipped && co.Len() >= s.opts.MinClip {
+			clip := seq[:co.Len()]
+			s.addClip(clipKey{pos: r.Pos, side: Left}, clip, true)
+		}
+		if co := r.Cigar[last]; last != first && co.Type() == sam.CigarSoftClipped && co.Len() >= s.opts.MinClip {
+			clip := seq[len(seq)-co.Len():]
+			s.addClip(clipKey{pos: r.End(), side: Right}, clip, false)
+		}
+	}
+
+	if r.Flags&sam.Supplementary == 0 {
+		if a := r.AuxTag(saTag); a != nil {
+			v, _ := a.Value().(string)
+			parts, err := ParseSA(v)
+			if err != nil {
+				return err
+			}
+			s.queue = append(s.queue, &Split{Record: r, Parts: parts})
+		}
+	}
+
+	if r.Flags&(sam.Paired|sam.MateUnmapped|sam.Supplementary) == sam.Paired && r.MateRef != nil {
+		var reason Reason
+		if r.MateRef != r.Ref {
+			reason |= MateRef
+		} else {
+			if s.opts.MaxInsert >= 0 && (r.TempLen > s.opts.MaxInsert || -r.TempLen > s.opts.MaxInsert) {
+				reason |= InsertSize
+			}
+			rev, mateRev := r.Flags&sam.Reverse != 0, r.Flags&sam.MateReverse != 0
+			switch {
+			case rev == mateRev,
+				r.Pos < r.MatePos && rev,
+				r.Pos > r.MatePos && !rev:
+				reason |= Orientation
+			}
+		}
+		if reason != 0 && reportPair(r) {
+			s.queue = append(s.queue, &Discordant{Record: r, Reason: reason})
+		}
+	}
+	return nil
+}
+
+// reportPair returns whether the pair evidence of r is reported for r
+// rather than its mate.
+func reportPair(r *sam.Record) bool {
+	if r.Ref != r.MateRef {
+		return r.Ref.ID() < r.MateRef.ID()
+	}
+	return r.Pos < r.MatePos || (r.Pos == r.MatePos && r.Flags&sam.Read1 != 0)
+}
+
+// addClip adds a clipped sequence to the cluster at k. If left is true
+// the sequence is counted from its end.
+func (s *Scanner) addClip(k clipKey, clip []byte, left bool) {
+	c, ok := s.clusters[k]
+	if !ok {
+		c = &cluster{}
+		s.clusters[k] = c
+	}
+	c.reads++
+	for len(c.counts) < len(clip) {
+		c.counts = append(c.counts, [4]int{})
+	}
+	for i := range clip {
+		b := clip[i]
+		if left {
+			b = clip[len(clip)-1-i]
+		}
+		if j := strings.IndexByte("ACGT", b&^0x20); j >= 0 {
+			c.counts[i][j]++
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sv
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+func TestScanner(t *testing.T) {
+	const (
+		fr = sam.Paired | sam.Read1 | sam.MateReverse
+		rf = sam.Paired | sam.Read2 | sam.Reverse
+	)
+	const seq = "AAAAAAAAAA"
+	b := htstestutil.NewBAMBuilder().Ref("chr1", 10000).Ref("chr2", 10000)
+	b.Record("c1", "chr1", 100, "5S5M", "TTGCAAAAAA").MapQ(60).
+		Record("c2", "chr1", 100, "2H6S5M", "GTTGCAAAAAA").MapQ(60).
+		Record("c3", "chr1", 100, "3S5M", "TGCAAAAA").MapQ(60).
+		Record("c4", "chr1", 102, "5M6S", "AAAAACCGGTA").MapQ(60).
+		Record("s1", "chr1", 200, "5M5S", "AAAAAGGGGG").MapQ(60).Tag("SA", "chr2,501,-,5S5M,30,1;").
+		Record("s1", "chr1", 200, "5M5H", "AAAAA").MapQ(60).Flags(sam.Supplementary).Tag("SA", "chr1,201,+,5M5S,60,0;").
+		Record("p1", "chr1", 300, "10M", seq).MapQ(60).Flags(fr).Mate("=", 5000, 4710).
+		Record("p2", "chr1", 310, "10M", seq).MapQ(60).Flags(rf|sam.MateReverse).Mate("=", 600, 300).
+		Record("p3", "chr1", 320, "10M", seq).MapQ(60).Flags(fr).Mate("chr2", 50, 0).
+		Record("p4", "chr1", 330, "10M", seq).MapQ(60).Flags(fr).Mate("=", 500, 180).
+		Record("p4", "chr1", 500, "10M", seq).MapQ(60).Flags(rf).Mate("=", 330, -180).
+		Record("d1", "chr1", 600, "5S5M", "CCCCCAAAAA").MapQ(60).Flags(sam.Duplicate).
+		Record("p1", "chr1", 5000, "10M", seq).MapQ(60).Flags(rf).Mate("=", 300, -4710).
+		Record("c5", "chr2", 100, "5S5M", "TTGCAAAAAA").MapQ(60)
+	h, err := b.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	chr1, chr2 := h.Refs()[0], h.Refs()[1]
+	in, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+
+	src := records(in)
+	s := NewScanner(&src, Options{})
+	var got []Evidence
+	for s.Next() {
+		got = append(got, s.Evidence())
+	}
+	if err := s.Error(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Evidence{
+		// c3 is clipped by fewer than DefaultMinClip bases.
+		&Clip{Ref: chr1, Pos: 100, Side: Left, Reads: 2, Seq: []byte("GTTGCA")},
+		&Clip{Ref: chr1, Pos: 107, Side: Right, Reads: 1, Seq: []byte("CCGGTA")},
+		&Split{Record: in[4], Parts: []Alignment{{Ref: "chr2", Pos: 500, Reverse: true, Cigar: mustCigar(t, "5S5M"), MapQ: 30, NM: 1}}},
+		// The supplementary s1 alignment is hard clipped.
+		&Clip{Ref: chr1, Pos: 205, Side: Right, Reads: 1, Seq: []byte("GGGGG")},
+		&Discordant{Record: in[6], Reason: InsertSize},
+		&Discordant{Record: in[7], Reason: Orientation},
+		&Discordant{Record: in[8], Reason: MateRef},
+		&Clip{Ref: chr2, Pos: 100, Side: Left, Reads: 1, Seq: []byte("TTGCA")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected evidence:")
+		for _, e := range got {
+			t.Logf("got:  %+v", e)
+		}
+		for _, e := range want {
+			t.Logf("want: %+v", e)
+		}
+	}
+}
+
+func mustCigar(t *testing.T, s string) sam.Cigar {
+	t.Helper()
+	co, err := sam.ParseCigar([]byte(s))
+	if err != nil {
+		t.Fatalf("unexpected error parsing cigar: %v", err)
+	}
+	return co
+}
+
+func TestParseSA(t *testing.T) {
+	got, err := ParseSA("chr1,100,+,10M5S,60,0;chr3,2000,-,5S10M,7,2;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Alignment{
+		{Ref: "chr1", Pos: 99, Cigar: mustCigar(t, "10M5S"), MapQ: 60},
+		{Ref: "chr3", Pos: 1999, Reverse: true, Cigar: mustCigar(t, "5S10M"), MapQ: 7, NM: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected alignments:\ngot: %+v\nwant:%+v", got, want)
+	}
+	for _, sa := range []string{"chr1,100,+,10M,60;", "chr1,x,+,10M,60,0", "chr1,1,*,10M,60,0", "chr1,1,+,10M,600,0"} {
+		if _, err := ParseSA(sa); err == nil {
+			t.Errorf("expected error for %q", sa)
+		}
+	}
+}
@@ -29,6 +29,11 @@ type Index struct {
 	MetaChar rune
 	Skip     int32
 
+	// CountBins specifies whether Add records
+	// per-bin record counts for use by
+	// EstimateRecordCount.
+	CountBins bool
+
 	refNames []string
 	nameMap  map[string]int
 
@@ -95,10 +100,15 @@ func (i *Index) Add(r Record, c bgzf.Chunk, placed, mapped bool) error {
 	refName := r.RefName()
 	rid, ok := i.nameMap[refName]
 	if !ok {
+		if i.nameMap == nil {
+			i.nameMap = make(map[string]int)
+		}
 		rid = len(i.refNames)
 		i.refNames = append(i.refNames, refName)
+		i.nameMap[refName] = rid
 	}
 	shim := tabixShim{id: rid, start: r.Start(), end: r.End()}
+	i.idx.CountBins = i.CountBins
 	return i.idx.Add(shim, internal.BinFor(r.Start(), r.End()), c, placed, mapped)
 }
 
@@ -117,6 +127,17 @@ func (i *Index) Chunks(ref string, beg, end int) ([]bgzf.Chunk, error) {
 
 var adjacent = index.Adjacent
 
+// EstimateRecordCount returns an estimate of the number of records
+// overlapping the interval [beg,end) on the named reference, calculated
+// from the per-bin record counts collected when CountBins is true.
+func (i *Index) EstimateRecordCount(ref string, beg, end int) (uint64, error) {
+	id, ok := i.nameMap[ref]
+	if !ok {
+		return 0, index.ErrNoReference
+	}
+	return i.idx.EstimateRecordCount(id, beg, end)
+}
+
 // MergeChunks applies the given MergeStrategy to all bins in the Index.
 func (i *Index) MergeChunks(s index.MergeStrategy) {
 	i.idx.MergeChunks(s)
@@ -215,7 +236,7 @@ func readTabixHeader(r io.Reader, idx *Index) error {
 	if names[len(names)-1] != 0 {
 		return errors.New("tabix: last name not zero-terminated")
 	}
-	idx.refNames = strings.Split(names[:len(names)-1], string(0))
+	idx.refNames = strings.Split(names[:len(names)-1], "\x00")
 
 	return nil
 }
@@ -2,8 +2,11 @@ package tabix
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
+	"github.com/Schaudge/hts/bgzf"
+
 	"gopkg.in/check.v1"
 )
 
@@ -35,3 +38,88 @@ func (s *S) TestIssue19(c *check.C) {
 	c.Assert(err, check.Equals, nil)
 	c.Check(len(chunks), check.Not(check.Equals), 0)
 }
+
+func (s *S) TestIndexText(c *check.C) {
+	var (
+		data  bytes.Buffer
+		lines []string
+	)
+	bg := bgzf.NewWriter(&data, 1)
+	_, err := bg.Write([]byte("#chrom\tstart\tend\n"))
+	c.Assert(err, check.Equals, nil)
+	for _, ref := range []string{"chr1", "chr2"} {
+		for p := 0; p < 20000; p += 10 {
+			l := fmt.Sprintf("%s\t%d\t%d\tfeature", ref, p*10, p*10+50)
+			lines = append(lines, l)
+			_, err = bg.Write([]byte(l + "\n"))
+			c.Assert(err, check.Equals, nil)
+		}
+	}
+	c.Assert(bg.Close(), check.Equals, nil)
+
+	br, err := bgzf.NewReader(bytes.NewReader(data.Bytes()), 1)
+	c.Assert(err, check.Equals, nil)
+	idx, err := IndexText(br, BEDConfig)
+	c.Assert(err, check.Equals, nil)
+	c.Check(idx.Names(), check.DeepEquals, []string{"chr1", "chr2"})
+	c.Check(idx.Config(), check.Equals, BEDConfig)
+
+	var buf bytes.Buffer
+	c.Assert(WriteIndex(&buf, idx), check.Equals, nil)
+	idx, err = ReadIndex(&buf)
+	c.Assert(err, check.Equals, nil)
+
+	for _, q := range []struct {
+		ref      string
+		beg, end int
+	}{
+		{ref: "chr1", beg: 0, end: 1},
+		{ref: "chr1", beg: 1000, end: 5000},
+		{ref: "chr2", beg: 150000, end: 170000},
+		{ref: "chr2", beg: 1999000, end: 3000000},
+	} {
+		var want []string
+		for _, l := range lines {
+			var (
+				ref      string
+				beg, end int
+			)
+			fmt.Sscanf(l, "%s\t%d\t%d", &ref, &beg, &end)
+			if ref == q.ref && beg < q.end && end > q.beg {
+				want = append(want, l)
+			}
+		}
+		br, err := bgzf.NewReader(bytes.NewReader(data.Bytes()), 1)
+		c.Assert(err, check.Equals, nil)
+		it, err := NewIterator(br, idx, q.ref, q.beg, q.end)
+		c.Assert(err, check.Equals, nil)
+		var got []string
+		for it.Next() {
+			got = append(got, string(it.Line()))
+		}
+		c.Check(it.Close(), check.Equals, nil)
+		c.Check(got, check.DeepEquals, want, check.Commentf("query %+v", q))
+	}
+}
+
+func (s *S) TestParseLine(c *check.C) {
+	for _, t := range []struct {
+		cfg      Config
+		line     string
+		ref      string
+		beg, end int
+	}{
+		{cfg: BEDConfig, line: "chr1\t10\t20", ref: "chr1", beg: 10, end: 20},
+		{cfg: GFFConfig, line: "chr1\tsrc\tgene\t10\t20\t.\t+\t.\tID=g", ref: "chr1", beg: 9, end: 20},
+		{cfg: VCFConfig, line: "chr2\t100\t.\tACG\tA\t.\tPASS\tDP=3", ref: "chr2", beg: 99, end: 102},
+		{cfg: VCFConfig, line: "chr2\t100\t.\tA\t<DEL>\t.\tPASS\tSVTYPE=DEL;END=200", ref: "chr2", beg: 99, end: 200},
+		{cfg: SAMConfig, line: "r\t0\tchr3\t5\t60\t3M2D4M\t*\t0\t0\tAAAAAAA\t*", ref: "chr3", beg: 4, end: 13},
+	} {
+		idx := NewWithConfig(t.cfg)
+		ref, beg, end, err := idx.ParseLine([]byte(t.line))
+		c.Assert(err, check.Equals, nil)
+		c.Check(string(ref), check.Equals, t.ref)
+		c.Check(beg, check.Equals, t.beg)
+		c.Check(end, check.Equals, t.end)
+	}
+}
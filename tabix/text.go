@@ -0,0 +1,405 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tabix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Schaudge/hts/bgzf"
+	"github.com/Schaudge/hts/bgzf/index"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Format values for the Index Format field.
+const (
+	Generic = 0 // Generic tab-delimited format.
+	SAM     = 1 // SAM format.
+	VCF     = 2 // VCF format.
+)
+
+// Config describes the column layout of a tab-delimited text file
+// indexed by tabix. Column numbers are 1-based.
+type Config struct {
+	Format    byte
+	ZeroBased bool
+
+	NameColumn  int32
+	BeginColumn int32
+	EndColumn   int32
+
+	MetaChar rune
+	Skip     int32
+}
+
+// Preset configurations matching the tabix -p presets.
+var (
+	GFFConfig = Config{Format: Generic, NameColumn: 1, BeginColumn: 4, EndColumn: 5, MetaChar: '#'}
+	BEDConfig = Config{Format: Generic, ZeroBased: true, NameColumn: 1, BeginColumn: 2, EndColumn: 3, MetaChar: '#'}
+	SAMConfig = Config{Format: SAM, NameColumn: 3, BeginColumn: 4, EndColumn: 0, MetaChar: '@'}
+	VCFConfig = Config{Format: VCF, NameColumn: 1, BeginColumn: 2, EndColumn: 0, MetaChar: '#'}
+)
+
+// NewWithConfig returns a new tabix index using the given column
+// configuration.
+func NewWithConfig(cfg Config) *Index {
+	idx := New()
+	idx.setConfig(cfg)
+	return idx
+}
+
+// Config returns the column configuration of the index.
+func (i *Index) Config() Config {
+	return Config{
+		Format:      i.Format,
+		ZeroBased:   i.ZeroBased,
+		NameColumn:  i.NameColumn,
+		BeginColumn: i.BeginColumn,
+		EndColumn:   i.EndColumn,
+		MetaChar:    i.MetaChar,
+		Skip:        i.Skip,
+	}
+}
+
+func (i *Index) setConfig(cfg Config) {
+	i.Format = cfg.Format
+	i.ZeroBased = cfg.ZeroBased
+	i.NameColumn = cfg.NameColumn
+	i.BeginColumn = cfg.BeginColumn
+	i.EndColumn = cfg.EndColumn
+	i.MetaChar = cfg.MetaChar
+	i.Skip = cfg.Skip
+}
+
+// IsMeta returns whether the line is a meta line according to the
+// index configuration.
+func (i *Index) IsMeta(line []byte) bool {
+	return len(line) == 0 || rune(line[0]) == i.MetaChar
+}
+
+// ParseLine returns the reference name and zero-based half-open
+// interval covered by the given data line according to the index
+// configuration. The returned name shares storage with line.
+func (i *Index) ParseLine(line []byte) (ref []byte, beg, end int, err error) {
+	line = bytes.TrimRight(line, "\r\n")
+	need := i.NameColumn
+	if i.BeginColumn > need {
+		need = i.BeginColumn
+	}
+	if i.EndColumn > need {
+		need = i.EndColumn
+	}
+	split := need
+	switch i.Format {
+	case SAM:
+		if need < 6 {
+			need = 6
+		}
+		split = need
+	case VCF:
+		if need < 4 {
+			need = 4
+		}
+		// Split out the INFO column if it is present.
+		split = 8
+	}
+	if i.NameColumn < 1 || i.BeginColumn < 1 {
+		return nil, 0, 0, errors.New("tabix: invalid column configuration")
+	}
+	f := bytes.SplitN(line, []byte{'\t'}, int(split)+1)
+	if len(f) < int(need) {
+		return nil, 0, 0, fmt.Errorf("tabix: too few columns: %d < %d", len(f), need)
+	}
+	ref = f[i.NameColumn-1]
+	beg, err = strconv.Atoi(string(f[i.BeginColumn-1]))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("tabix: failed to parse begin: %v", err)
+	}
+	if !i.ZeroBased {
+		beg--
+	}
+	end = beg + 1
+
+	switch i.Format {
+	case SAM:
+		cigar, err := sam.ParseCigar(f[5])
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("tabix: failed to parse cigar: %v", err)
+		}
+		if n, _ := cigar.Lengths(); n > 0 {
+			end = beg + n
+		}
+	case VCF:
+		end = beg + len(f[3])
+		if len(f) > 7 {
+			for _, kv := range bytes.Split(f[7], []byte{';'}) {
+				if !bytes.HasPrefix(kv, []byte("END=")) {
+					continue
+				}
+				e, err := strconv.Atoi(string(kv[4:]))
+				if err != nil {
+					return nil, 0, 0, fmt.Errorf("tabix: failed to parse END: %v", err)
+				}
+				end = e
+				break
+			}
+		}
+	default:
+		if i.EndColumn != 0 {
+			end, err = strconv.Atoi(string(f[i.EndColumn-1]))
+			if err != nil {
+				return nil, 0, 0, fmt.Errorf("tabix: failed to parse end: %v", err)
+			}
+		}
+	}
+	if end <= beg {
+		end = beg + 1
+	}
+	return ref, beg, end, nil
+}
+
+type textRecord struct {
+	ref        string
+	start, end int
+}
+
+func (r textRecord) RefName() string { return r.ref }
+func (r textRecord) Start() int      { return r.start }
+func (r textRecord) End() int        { return r.end }
+
+// lineReader reads lines from a bgzf.Reader, tracking the virtual
+// offset chunk of each line.
+type lineReader struct {
+	r *bgzf.Reader
+
+	buf  []byte
+	base bgzf.Offset
+
+	line []byte
+	err  error
+}
+
+func newLineReader(r *bgzf.Reader) *lineReader {
+	r.Blocked = true
+	return &lineReader{r: r}
+}
+
+// next returns the next line, including any terminating newline,
+// and the chunk it occupies.
+func (l *lineReader) next() ([]byte, bgzf.Chunk, error) {
+	l.line = l.line[:0]
+	var c bgzf.Chunk
+	first := true
+	for {
+		if len(l.buf) == 0 {
+			if l.err != nil {
+				if len(l.line) != 0 && l.err == io.EOF {
+					return l.line, c, nil
+				}
+				return nil, c, l.err
+			}
+			l.fill()
+			continue
+		}
+		if first {
+			c.Begin = l.base
+			first = false
+		}
+		n := bytes.IndexByte(l.buf, '\n') + 1
+		if n == 0 {
+			n = len(l.buf)
+		}
+		l.line = append(l.line, l.buf[:n]...)
+		l.buf = l.buf[n:]
+		l.base.Block += uint16(n)
+		c.End = l.base
+		if l.line[len(l.line)-1] == '\n' {
+			return l.line, c, nil
+		}
+	}
+}
+
+func (l *lineReader) fill() {
+	if cap(l.buf) < bgzf.MaxBlockSize {
+		l.buf = make([]byte, bgzf.MaxBlockSize)
+	}
+	l.buf = l.buf[:cap(l.buf)]
+	var n int
+	for n == 0 && l.err == nil {
+		n, l.err = l.r.Read(l.buf)
+		if l.err == io.EOF && n == 0 {
+			// A blocked read reaching the end of a block returns
+			// io.EOF; this is only terminal when no progress is made
+			// on the following read.
+			n, l.err = l.r.Read(l.buf)
+		}
+		if l.err == io.EOF && n != 0 {
+			l.err = nil
+		}
+	}
+	l.buf = l.buf[:n]
+	l.base = l.r.LastChunk().Begin
+}
+
+// IndexText reads the BGZF compressed tab-delimited text from r and returns
+// a tabix index using the given column configuration. Data lines must be
+// sorted by reference name grouping and position.
+func IndexText(r *bgzf.Reader, cfg Config) (*Index, error) {
+	idx := NewWithConfig(cfg)
+	lr := newLineReader(r)
+	var (
+		skipped int32
+		lastRef []byte
+		lastBeg int
+	)
+	for {
+		line, c, err := lr.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if skipped < idx.Skip {
+			skipped++
+			continue
+		}
+		if idx.IsMeta(line) || len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		ref, beg, end, err := idx.ParseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(ref, lastRef) {
+			if beg < lastBeg {
+				return nil, fmt.Errorf("tabix: unsorted positions on %s: %d < %d", ref, beg+1, lastBeg+1)
+			}
+		} else {
+			if _, ok := idx.nameMap[string(ref)]; ok {
+				return nil, fmt.Errorf("tabix: reference %s not contiguous", ref)
+			}
+			lastRef = append(lastRef[:0], ref...)
+		}
+		lastBeg = beg
+		err = idx.Add(textRecord{ref: string(ref), start: beg, end: end}, c, true, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// Iterator provides line-wise access to the data lines of a tabix
+// indexed file that overlap a query interval.
+type Iterator struct {
+	idx *Index
+	cr  *index.ChunkReader
+	br  *bufio.Reader
+
+	ref      string
+	beg, end int
+
+	line []byte
+	err  error
+}
+
+// NewIterator returns an Iterator over the lines in r overlapping the
+// zero-based half-open interval [beg, end) on the named reference.
+// The bgzf.Reader is used in blocked mode until the Iterator is closed.
+func NewIterator(r *bgzf.Reader, idx *Index, ref string, beg, end int) (*Iterator, error) {
+	chunks, err := idx.Chunks(ref, beg, end)
+	if err != nil && err != index.ErrInvalid {
+		return nil, err
+	}
+	cr, err := index.NewChunkReader(r, chunks)
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator{
+		idx: idx,
+		cr:  cr,
+		br:  bufio.NewReader(cr),
+		ref: ref,
+		beg: beg,
+		end: end,
+	}, nil
+}
+
+// Next advances the Iterator past the next overlapping line, which will then
+// be available through the Line method. It returns false when the iteration
+// stops, either by reaching the end of the input or an error.
+func (i *Iterator) Next() bool {
+	for i.err == nil {
+		i.line, i.err = i.br.ReadBytes('\n')
+		if len(i.line) == 0 || i.idx.IsMeta(i.line) {
+			continue
+		}
+		if i.err == io.EOF {
+			i.err = nil
+		}
+		ref, beg, end, err := i.idx.ParseLine(i.line)
+		if err != nil {
+			i.err = err
+			return false
+		}
+		if string(ref) != i.ref || beg >= i.end {
+			continue
+		}
+		if end > i.beg {
+			i.line = bytes.TrimRight(i.line, "\r\n")
+			return true
+		}
+	}
+	return false
+}
+
+// Line returns the most recent line read by a call to Next, without the
+// line terminator.
+func (i *Iterator) Line() []byte { return i.line }
+
+// Error returns the first non-EOF error that was encountered by the Iterator.
+func (i *Iterator) Error() error {
+	if i.err == io.EOF {
+		return nil
+	}
+	return i.err
+}
+
+// Close releases the underlying bgzf.Reader.
+func (i *Iterator) Close() error {
+	i.cr.Close()
+	return i.Error()
+}
+
+// ReadIndex reads a BGZF compressed tabix index from the given io.Reader.
+func ReadIndex(r io.Reader) (*Index, error) {
+	bg, err := bgzf.NewReader(r, 1)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := ReadFrom(bg)
+	if err != nil {
+		bg.Close()
+		return nil, err
+	}
+	return idx, bg.Close()
+}
+
+// WriteIndex writes the index to the given io.Writer as BGZF compressed
+// data as described in the tabix specification.
+func WriteIndex(w io.Writer, idx *Index) error {
+	bg := bgzf.NewWriter(w, 1)
+	err := WriteTo(bg, idx)
+	if err != nil {
+		bg.Close()
+		return err
+	}
+	return bg.Close()
+}
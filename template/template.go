@@ -0,0 +1,197 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package template implements iteration over the templates of name
+// grouped alignment records, as produced by a queryname sort or
+// collation.
+//
+// All records sharing a name are returned together as a Template, with
+// the primary records of the first and last fragments separated from
+// the supplementary and secondary records of the template. This is the
+// unit of work for duplicate marking, conversion of alignments to
+// FASTQ and the extraction of structural variant evidence from split
+// and discordant reads.
+package template
+
+import (
+	"errors"
+	"io"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+var ErrPrimary = errors.New("template: multiple primary records for fragment")
+
+// Source is a source of records with records of the same name adjacent,
+// as produced by a queryname sort or collation.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Template holds the records of a template.
+type Template struct {
+	// Name is the name shared by the
+	// records of the template.
+	Name string
+
+	// R1 and R2 are the primary records
+	// of the first and last fragments. The
+	// primary record of an unpaired read is
+	// held in R1. Either may be nil if the
+	// template has no such record.
+	R1, R2 *sam.Record
+
+	// Supplementary and Secondary hold the
+	// supplementary and secondary records of
+	// the template in the order they were
+	// read. Records flagged as both
+	// supplementary and secondary are held
+	// in Secondary.
+	Supplementary []*sam.Record
+	Secondary     []*sam.Record
+}
+
+// IsPaired returns whether the template holds the primary records of
+// both fragments of a read pair.
+func (t *Template) IsPaired() bool {
+	return t.R1 != nil && t.R2 != nil
+}
+
+// Records returns all the records of the template, primary records
+// first, followed by supplementary and then secondary records.
+func (t *Template) Records() []*sam.Record {
+	recs := make([]*sam.Record, 0, 2+len(t.Supplementary)+len(t.Secondary))
+	for _, r := range []*sam.Record{t.R1, t.R2} {
+		if r != nil {
+			recs = append(recs, r)
+		}
+	}
+	recs = append(recs, t.Supplementary...)
+	return append(recs, t.Secondary...)
+}
+
+// Fragment returns the records of the template belonging to the first
+// fragment when last is false, or the last fragment when last is true,
+// primary record first. Records of unpaired reads belong to the first
+// fragment.
+func (t *Template) Fragment(last bool) []*sam.Record {
+	var recs []*sam.Record
+	if p := t.primary(last); p != nil {
+		recs = append(recs, p)
+	}
+	for _, r := range t.Supplementary {
+		if isLast(r) == last {
+			recs = append(recs, r)
+		}
+	}
+	for _, r := range t.Secondary {
+		if isLast(r) == last {
+			recs = append(recs, r)
+		}
+	}
+	return recs
+}
+
+// primary returns the primary record of the first or last fragment.
+func (t *Template) primary(last bool) *sam.Record {
+	if last {
+		return t.R2
+	}
+	return t.R1
+}
+
+// isLast returns whether r is a record of the last fragment of a pair.
+func isLast(r *sam.Record) bool {
+	return r.Flags&(sam.Paired|sam.Read1|sam.Read2) == sam.Paired|sam.Read2
+}
+
+// add adds r to the template.
+func (t *Template) add(r *sam.Record) error {
+	switch {
+	case r.Flags&sam.Secondary != 0:
+		t.Secondary = append(t.Secondary, r)
+	case r.Flags&sam.Supplementary != 0:
+		t.Supplementary = append(t.Supplementary, r)
+	case isLast(r):
+		if t.R2 != nil {
+			return ErrPrimary
+		}
+		t.R2 = r
+	default:
+		if t.R1 != nil {
+			return ErrPrimary
+		}
+		t.R1 = r
+	}
+	return nil
+}
+
+// Iterator returns the templates of its Source in order.
+type Iterator struct {
+	src Source
+
+	next *sam.Record
+	curr *Template
+	done bool
+	err  error
+}
+
+// NewIterator returns an Iterator reading records from src. Records
+// sharing a name that are not adjacent in src are returned in separate
+// templates.
+func NewIterator(src Source) *Iterator {
+	return &Iterator{src: src}
+}
+
+// Next advances the Iterator to the next template, returning false
+// when no templates remain or an error has occurred.
+func (it *Iterator) Next() bool {
+	it.curr = nil
+	if it.err != nil {
+		return false
+	}
+	if it.next == nil {
+		if it.done {
+			return false
+		}
+		it.next, it.err = it.src.Read()
+		if it.err != nil {
+			if it.err == io.EOF {
+				it.err = nil
+				it.done = true
+			}
+			it.next = nil
+			return false
+		}
+	}
+	t := &Template{Name: it.next.Name}
+	it.err = t.add(it.next)
+	it.next = nil
+	for it.err == nil {
+		rec, err := it.src.Read()
+		if err != nil {
+			if err != io.EOF {
+				it.err = err
+			}
+			it.done = true
+			break
+		}
+		if rec.Name != t.Name {
+			it.next = rec
+			break
+		}
+		it.err = t.add(rec)
+	}
+	if it.err != nil {
+		return false
+	}
+	it.curr = t
+	return true
+}
+
+// Template returns the current template.
+func (it *Iterator) Template() *Template { return it.curr }
+
+// Error returns the first non-EOF error encountered.
+func (it *Iterator) Error() error { return it.err }
@@ -0,0 +1,143 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package template
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+func TestIterator(t *testing.T) {
+	var (
+		a1     = record(t, "a", sam.Paired|sam.Read1)
+		a2     = record(t, "a", sam.Paired|sam.Read2)
+		a1Supp = record(t, "a", sam.Paired|sam.Read1|sam.Supplementary)
+		a2Sec  = record(t, "a", sam.Paired|sam.Read2|sam.Secondary)
+		a2Both = record(t, "a", sam.Paired|sam.Read2|sam.Secondary|sam.Supplementary)
+		b      = record(t, "b", 0)
+		bSupp  = record(t, "b", sam.Supplementary)
+		c2     = record(t, "c", sam.Paired|sam.Read2)
+		c2Supp = record(t, "c", sam.Paired|sam.Read2|sam.Supplementary)
+	)
+	src := &records{a1Supp, a2, a2Sec, a1, a2Both, b, bSupp, c2Supp, c2}
+	want := []*Template{
+		{
+			Name:          "a",
+			R1:            a1,
+			R2:            a2,
+			Supplementary: []*sam.Record{a1Supp},
+			Secondary:     []*sam.Record{a2Sec, a2Both},
+		},
+		{
+			Name:          "b",
+			R1:            b,
+			Supplementary: []*sam.Record{bSupp},
+		},
+		{
+			Name:          "c",
+			R2:            c2,
+			Supplementary: []*sam.Record{c2Supp},
+		},
+	}
+
+	var got []*Template
+	it := NewIterator(src)
+	for it.Next() {
+		got = append(got, it.Template())
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("unexpected error iterating: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected templates:\ngot: %+v\nwant:%+v", got, want)
+	}
+	if it.Next() {
+		t.Error("unexpected template after end of iteration")
+	}
+
+	if !got[0].IsPaired() || got[1].IsPaired() || got[2].IsPaired() {
+		t.Error("unexpected paired state")
+	}
+	if recs := got[0].Records(); !reflect.DeepEqual(recs, []*sam.Record{a1, a2, a1Supp, a2Sec, a2Both}) {
+		t.Errorf("unexpected records: %v", recs)
+	}
+	if recs := got[0].Fragment(false); !reflect.DeepEqual(recs, []*sam.Record{a1, a1Supp}) {
+		t.Errorf("unexpected first fragment records: %v", recs)
+	}
+	if recs := got[0].Fragment(true); !reflect.DeepEqual(recs, []*sam.Record{a2, a2Sec, a2Both}) {
+		t.Errorf("unexpected last fragment records: %v", recs)
+	}
+	if recs := got[2].Fragment(false); len(recs) != 0 {
+		t.Errorf("unexpected first fragment records: %v", recs)
+	}
+}
+
+func TestIteratorErrors(t *testing.T) {
+	it := NewIterator(&records{
+		record(t, "a", sam.Paired|sam.Read1),
+		record(t, "b", sam.Paired|sam.Read1),
+		record(t, "b", sam.Paired|sam.Read1),
+	})
+	if !it.Next() || it.Template().Name != "a" {
+		t.Fatalf("failed to read first template: %v", it.Error())
+	}
+	if it.Next() {
+		t.Error("unexpected template with duplicate primary records")
+	}
+	if err := it.Error(); err != ErrPrimary {
+		t.Errorf("unexpected error: got:%v want:%v", err, ErrPrimary)
+	}
+
+	errRead := errors.New("read error")
+	it = NewIterator(&failing{recs: records{record(t, "a", 0)}, err: errRead})
+	if it.Next() {
+		t.Error("unexpected template before read error")
+	}
+	if err := it.Error(); err != errRead {
+		t.Errorf("unexpected error: got:%v want:%v", err, errRead)
+	}
+
+	it = NewIterator(&records{})
+	if it.Next() || it.Error() != nil {
+		t.Errorf("unexpected iteration of empty source: %v", it.Error())
+	}
+}
+
+func record(t *testing.T, name string, flags sam.Flags) *sam.Record {
+	r, err := sam.NewRecord(name, nil, nil, -1, -1, 0, 0, nil, []byte("ACGT"), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating record: %v", err)
+	}
+	r.Flags = flags | sam.Unmapped
+	return r
+}
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+// failing returns its records and then err.
+type failing struct {
+	recs records
+	err  error
+}
+
+func (f *failing) Read() (*sam.Record, error) {
+	if len(f.recs) == 0 {
+		return nil, f.err
+	}
+	return f.recs.Read()
+}
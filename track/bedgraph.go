@@ -0,0 +1,94 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package track
+
+import (
+	"fmt"
+	"io"
+)
+
+// BedGraphWriter implements bedGraph format writing. Adjacent intervals
+// on the same chromosome with equal values are merged into a single
+// line, so per-position values are written as runs.
+type BedGraphWriter struct {
+	w io.Writer
+
+	// Zero specifies whether intervals
+	// with zero value are written.
+	Zero bool
+
+	pending Interval
+	has     bool
+	last    string
+	lastEnd int
+}
+
+// NewBedGraphWriter returns a BedGraphWriter that writes to w. If name
+// is not empty, a track definition line with the given name is written.
+func NewBedGraphWriter(w io.Writer, name string) (*BedGraphWriter, error) {
+	err := writeTrack(w, "bedGraph", name)
+	if err != nil {
+		return nil, err
+	}
+	return &BedGraphWriter{w: w}, nil
+}
+
+// Write writes the interval iv. Intervals on a chromosome must be
+// written in order and must not overlap. Empty intervals are ignored.
+func (w *BedGraphWriter) Write(iv Interval) error {
+	if iv.Start < 0 || iv.End < iv.Start {
+		return ErrInterval
+	}
+	if iv.Start == iv.End {
+		return nil
+	}
+	if iv.Chrom == w.last && iv.Start < w.lastEnd {
+		return ErrOrder
+	}
+	w.last, w.lastEnd = iv.Chrom, iv.End
+	if w.has && iv.Chrom == w.pending.Chrom && iv.Start == w.pending.End && iv.Value == w.pending.Value {
+		w.pending.End = iv.End
+		return nil
+	}
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+	w.pending, w.has = iv, true
+	return nil
+}
+
+// WriteValues writes the values starting at the zero-based position
+// start on the named chromosome, each covering span positions. Per-base
+// depths are written with a span of one and windowed depths with the
+// window size as the span.
+func (w *BedGraphWriter) WriteValues(chrom string, start, span int, values []float64) error {
+	if span < 1 {
+		return ErrStep
+	}
+	for i, v := range values {
+		beg := start + i*span
+		err := w.Write(Interval{Chrom: chrom, Start: beg, End: beg + span, Value: v})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes any pending merged interval to the underlying writer. It
+// must be called after the last interval has been written.
+func (w *BedGraphWriter) Flush() error {
+	if !w.has {
+		return nil
+	}
+	w.has = false
+	iv := w.pending
+	if iv.Value == 0 && !w.Zero {
+		return nil
+	}
+	_, err := fmt.Fprintf(w.w, "%s\t%d\t%d\t%s\n", iv.Chrom, iv.Start, iv.End, formatValue(iv.Value))
+	return err
+}
@@ -0,0 +1,49 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package track implements writing of coverage tracks in the bedGraph
+// and wiggle formats for display in genome browsers.
+//
+// The formats are described in the UCSC Genome Browser documentation.
+//
+// https://genome.ucsc.edu/goldenPath/help/bedgraph.html
+//
+// https://genome.ucsc.edu/goldenPath/help/wiggle.html
+package track
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var (
+	ErrOrder    = errors.New("track: values out of order")
+	ErrInterval = errors.New("track: invalid interval")
+	ErrStep     = errors.New("track: invalid step or span")
+)
+
+// Interval is a run of positions sharing a single value. Start and End
+// are zero-based half-open coordinates.
+type Interval struct {
+	Chrom      string
+	Start, End int
+	Value      float64
+}
+
+// formatValue returns the shortest text representation of v.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// writeTrack writes a track definition line of the given type and name
+// to w. No line is written if name is empty.
+func writeTrack(w io.Writer, typ, name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "track type=%s name=%q\n", typ, name)
+	return err
+}
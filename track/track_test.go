@@ -0,0 +1,132 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package track
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBedGraphWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewBedGraphWriter(&buf, "depth")
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, v := range []struct {
+		chrom  string
+		start  int
+		span   int
+		values []float64
+	}{
+		{chrom: "chr1", start: 10, span: 1, values: []float64{0, 1, 1, 2, 2, 2, 0}},
+		{chrom: "chr1", start: 17, span: 1, values: []float64{0, 3}},
+		{chrom: "chr1", start: 100, span: 50, values: []float64{0.5, 0.5, 1.25}},
+		{chrom: "chr2", start: 0, span: 1, values: []float64{4, 4}},
+	} {
+		err = w.WriteValues(v.chrom, v.start, v.span, v.values)
+		if err != nil {
+			t.Fatalf("unexpected error writing values: %v", err)
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		t.Fatalf("unexpected error flushing: %v", err)
+	}
+	const want = `track type=bedGraph name="depth"
+chr1	11	13	1
+chr1	13	16	2
+chr1	18	19	3
+chr1	100	200	0.5
+chr1	200	250	1.25
+chr2	0	2	4
+`
+	if buf.String() != want {
+		t.Errorf("unexpected bedGraph:\ngot:\n%s\nwant:\n%s", &buf, want)
+	}
+
+	err = w.Write(Interval{Chrom: "chr2", Start: 1, End: 5, Value: 1})
+	if err != ErrOrder {
+		t.Errorf("unexpected error for out of order interval: %v", err)
+	}
+	err = w.WriteValues("chr2", 10, 0, []float64{1})
+	if err != ErrStep {
+		t.Errorf("unexpected error for zero span: %v", err)
+	}
+
+	buf.Reset()
+	w, _ = NewBedGraphWriter(&buf, "")
+	w.Zero = true
+	w.WriteValues("chr1", 0, 1, []float64{0, 0, 1})
+	w.Flush()
+	const wantZero = "chr1\t0\t2\t0\nchr1\t2\t3\t1\n"
+	if buf.String() != wantZero {
+		t.Errorf("unexpected bedGraph with zeros:\ngot:\n%s\nwant:\n%s", &buf, wantZero)
+	}
+}
+
+func TestWiggleWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWiggleWriter(&buf, "depth")
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, step := range []struct {
+		start  int
+		values []float64
+	}{
+		{start: 0, values: []float64{1, 2}},
+		{start: 200, values: []float64{3}},
+		{start: 400, values: []float64{4.5}},
+	} {
+		err = w.WriteFixedStep("chr1", step.start, 100, 50, step.values)
+		if err != nil {
+			t.Fatalf("unexpected error writing fixed step: %v", err)
+		}
+	}
+	for _, pos := range []int{1000, 1010} {
+		err = w.WriteVariableStep("chr1", pos, 5, float64(pos/10))
+		if err != nil {
+			t.Fatalf("unexpected error writing variable step: %v", err)
+		}
+	}
+	err = w.WriteVariableStep("chr2", 0, 5, 7)
+	if err != nil {
+		t.Fatalf("unexpected error writing variable step: %v", err)
+	}
+	const want = `track type=wiggle_0 name="depth"
+fixedStep chrom=chr1 start=1 step=100 span=50
+1
+2
+3
+fixedStep chrom=chr1 start=401 step=100 span=50
+4.5
+variableStep chrom=chr1 span=5
+1001	100
+1011	101
+variableStep chrom=chr2 span=5
+1	7
+`
+	if buf.String() != want {
+		t.Errorf("unexpected wiggle:\ngot:\n%s\nwant:\n%s", &buf, want)
+	}
+
+	for _, test := range []struct {
+		step, span int
+		start      int
+		want       error
+	}{
+		{step: 0, span: 1, start: 10, want: ErrStep},
+		{step: 10, span: 20, start: 10, want: ErrStep},
+		{step: 10, span: 10, start: -1, want: ErrInterval},
+		{step: 10, span: 10, start: 2, want: ErrOrder},
+	} {
+		err = w.WriteFixedStep("chr2", test.start, test.step, test.span, []float64{1})
+		if err != test.want {
+			t.Errorf("unexpected error for step=%d span=%d start=%d: got:%v want:%v",
+				test.step, test.span, test.start, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package track
+
+import (
+	"fmt"
+	"io"
+)
+
+// section is a wiggle data section type.
+type section int
+
+const (
+	noSection section = iota
+	fixedStep
+	variableStep
+)
+
+// WiggleWriter implements wiggle format writing with fixedStep and
+// variableStep data sections. A new section declaration is written only
+// when the chromosome, step or span changes, or when fixedStep values
+// are not contiguous with the preceding values.
+type WiggleWriter struct {
+	w io.Writer
+
+	mode       section
+	chrom      string
+	step, span int
+
+	// next is the zero-based position of the
+	// next contiguous fixedStep value, or the
+	// least valid next variableStep position.
+	next int
+}
+
+// NewWiggleWriter returns a WiggleWriter that writes to w. If name is
+// not empty, a track definition line with the given name is written.
+func NewWiggleWriter(w io.Writer, name string) (*WiggleWriter, error) {
+	err := writeTrack(w, "wiggle_0", name)
+	if err != nil {
+		return nil, err
+	}
+	return &WiggleWriter{w: w}, nil
+}
+
+// WriteFixedStep writes the values as fixedStep data. The first value
+// is at the zero-based position start on the named chromosome, and each
+// following value is step positions after the previous one. Each value
+// covers span positions, which must not be greater than step.
+func (w *WiggleWriter) WriteFixedStep(chrom string, start, step, span int, values []float64) error {
+	if step < 1 || span < 1 || span > step {
+		return ErrStep
+	}
+	if start < 0 {
+		return ErrInterval
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if chrom == w.chrom && start < w.next {
+		return ErrOrder
+	}
+	if w.mode != fixedStep || chrom != w.chrom || step != w.step || span != w.span || start != w.next {
+		_, err := fmt.Fprintf(w.w, "fixedStep chrom=%s start=%d step=%d span=%d\n", chrom, start+1, step, span)
+		if err != nil {
+			return err
+		}
+		w.mode, w.chrom, w.step, w.span = fixedStep, chrom, step, span
+	}
+	for _, v := range values {
+		_, err := fmt.Fprintln(w.w, formatValue(v))
+		if err != nil {
+			return err
+		}
+	}
+	w.next = start + len(values)*step
+	return nil
+}
+
+// WriteVariableStep writes a value as variableStep data at the
+// zero-based position pos on the named chromosome, covering span
+// positions. Values on a chromosome must be written in order and must
+// not overlap.
+func (w *WiggleWriter) WriteVariableStep(chrom string, pos, span int, value float64) error {
+	if span < 1 {
+		return ErrStep
+	}
+	if pos < 0 {
+		return ErrInterval
+	}
+	if chrom == w.chrom && pos < w.next {
+		return ErrOrder
+	}
+	if w.mode != variableStep || chrom != w.chrom || span != w.span {
+		_, err := fmt.Fprintf(w.w, "variableStep chrom=%s span=%d\n", chrom, span)
+		if err != nil {
+			return err
+		}
+		w.mode, w.chrom, w.step, w.span = variableStep, chrom, 0, span
+	}
+	_, err := fmt.Fprintf(w.w, "%d\t%s\n", pos+1, formatValue(value))
+	w.next = pos + span
+	return err
+}
@@ -0,0 +1,324 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package trim implements pre-alignment trimming of the 3' ends of
+// unaligned records, such as those of unaligned BAM files or those
+// imported from FASTQ.
+//
+// Trimming is performed in three steps: poly-G tails, left by two-color
+// sequencing chemistry when no signal is detected, are removed first,
+// then adapter sequence read through at the end of short inserts, and
+// finally low quality bases are removed using a sliding window. The
+// number of bases removed by each step is recorded in an aux field of
+// the record.
+package trim
+
+import (
+	"errors"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrAligned   = errors.New("trim: aligned record")
+	ErrErrorRate = errors.New("trim: adapter error rate out of range")
+)
+
+// Default trimming option values.
+const (
+	DefaultWindow     = 4
+	DefaultMinOverlap = 3
+)
+
+var (
+	// Tags recording the number of bases
+	// trimmed by each step.
+	tgTag = sam.NewTag("tg") // Poly-G tail.
+	taTag = sam.NewTag("ta") // Adapter.
+	tqTag = sam.NewTag("tq") // Quality.
+)
+
+// Options specifies the behaviour of a Trimmer.
+type Options struct {
+	// PolyG is the minimum length of a
+	// trailing run of G bases that is
+	// trimmed. If PolyG is zero, poly-G
+	// tails are not trimmed.
+	PolyG int
+
+	// Adapters are the adapter sequences
+	// trimmed from the 3' end of reads. A
+	// read is trimmed from the first
+	// position at which the remainder of
+	// the read matches the start of an
+	// adapter, or the whole adapter matches,
+	// over at least MinOverlap bases. N
+	// bases in the read match any base.
+	Adapters []string
+
+	// MinOverlap is the minimum number of
+	// bases of a read matching an adapter
+	// for the adapter to be trimmed. If
+	// MinOverlap is less than one,
+	// DefaultMinOverlap is used.
+	MinOverlap int
+
+	// ErrorRate is the maximum fraction of
+	// mismatched bases in an adapter match,
+	// rounded down to a number of bases.
+	ErrorRate float64
+
+	// MinQual is the minimum mean base
+	// quality of a window of Window bases
+	// ending at the 3' end of a trimmed
+	// read. Bases are trimmed from the 3'
+	// end until such a window is found. If
+	// MinQual is zero, or a record has no
+	// qualities, reads are not trimmed by
+	// quality.
+	MinQual byte
+
+	// Window is the length of the quality
+	// window. If Window is less than one,
+	// DefaultWindow is used.
+	Window int
+
+	// MinLength is the minimum length of a
+	// trimmed read. Records trimmed to
+	// fewer bases are marked as failing
+	// quality checks.
+	MinLength int
+}
+
+// Trimmed is the number of bases trimmed from a record by each step.
+type Trimmed struct {
+	PolyG   int
+	Adapter int
+	Quality int
+}
+
+// Total returns the total number of bases trimmed.
+func (t Trimmed) Total() int { return t.PolyG + t.Adapter + t.Quality }
+
+// Trimmer trims unaligned records.
+type Trimmer struct {
+	opts     Options
+	adapters [][]byte
+}
+
+// NewTrimmer returns a new Trimmer using the given options.
+func NewTrimmer(opts Options) (*Trimmer, error) {
+	if opts.ErrorRate < 0 || opts.ErrorRate >= 1 {
+		return nil, ErrErrorRate
+	}
+	if opts.MinOverlap < 1 {
+		opts.MinOverlap = DefaultMinOverlap
+	}
+	if opts.Window < 1 {
+		opts.Window = DefaultWindow
+	}
+	t := &Trimmer{opts: opts}
+	for _, a := range opts.Adapters {
+		b := []byte(a)
+		for i, c := range b {
+			b[i] = upper(c)
+		}
+		t.adapters = append(t.adapters, b)
+	}
+	return t, nil
+}
+
+// Trim trims r in place, updating its sequence and qualities, and
+// returns the number of bases trimmed by each step. The aux fields
+// recording the bases trimmed are set for each step that trimmed bases.
+// Records with the sam.Reverse flag set are trimmed from the start of
+// their stored sequence, which holds the 3' end of the read. Aligned
+// records are not trimmed and ErrAligned is returned.
+func (t *Trimmer) Trim(r *sam.Record) (Trimmed, error) {
+	if len(r.Cigar) != 0 {
+		return Trimmed{}, ErrAligned
+	}
+	seq := r.Seq.Expand()
+	qual := r.Qual
+	if len(qual) != len(seq) || (len(qual) != 0 && qual[0] == 0xff) {
+		qual = nil
+	}
+	reversed := r.Flags&sam.Reverse != 0
+	if reversed {
+		// Work in read orientation.
+		samutil.ReverseComplement(seq)
+		if qual != nil {
+			qual = append([]byte(nil), qual...)
+			samutil.Reverse(qual)
+		}
+	}
+
+	var trimmed Trimmed
+	n := len(seq)
+	if t.opts.PolyG > 0 {
+		g := polyG(seq[:n])
+		if g >= t.opts.PolyG {
+			trimmed.PolyG = g
+			n -= g
+		}
+	}
+	if len(t.adapters) != 0 {
+		p := t.adapter(seq[:n])
+		trimmed.Adapter = n - p
+		n = p
+	}
+	if t.opts.MinQual != 0 && qual != nil {
+		p := t.quality(qual[:n])
+		trimmed.Quality = n - p
+		n = p
+	}
+
+	if n != len(seq) {
+		if reversed {
+			off := len(seq) - n
+			seq = r.Seq.Expand()[off:]
+			r.Qual = trimQual(r.Qual, off, len(r.Qual))
+		} else {
+			seq = seq[:n]
+			r.Qual = trimQual(r.Qual, 0, n)
+		}
+		r.Seq = sam.NewSeq(seq)
+	}
+	for _, f := range []struct {
+		tag sam.Tag
+		n   int
+	}{
+		{tag: tgTag, n: trimmed.PolyG},
+		{tag: taTag, n: trimmed.Adapter},
+		{tag: tqTag, n: trimmed.Quality},
+	} {
+		if f.n == 0 {
+			continue
+		}
+		err := samutil.SetAux(r, f.tag, f.n)
+		if err != nil {
+			return trimmed, err
+		}
+	}
+	if n < t.opts.MinLength {
+		r.Flags |= sam.QCFail
+	}
+	return trimmed, nil
+}
+
+// trimQual returns q[beg:end], or q if it holds no qualities.
+func trimQual(q []byte, beg, end int) []byte {
+	if len(q) < end {
+		return q
+	}
+	return q[beg:end]
+}
+
+// polyG returns the length of the trailing run of G bases in seq.
+func polyG(seq []byte) int {
+	n := 0
+	for i := len(seq) - 1; i >= 0 && upper(seq[i]) == 'G'; i-- {
+		n++
+	}
+	return n
+}
+
+// adapter returns the position in seq of the first adapter match, or
+// len(seq) if there is none.
+func (t *Trimmer) adapter(seq []byte) int {
+	best := len(seq)
+	for _, a := range t.adapters {
+		for p := 0; p < best && len(seq)-p >= t.opts.MinOverlap; p++ {
+			overlap := minInt(len(a), len(seq)-p)
+			if overlap < t.opts.MinOverlap {
+				break
+			}
+			maxErr := int(float64(overlap) * t.opts.ErrorRate)
+			var errs int
+			for i, c := range a[:overlap] {
+				if b := upper(seq[p+i]); b != c && b != 'N' {
+					errs++
+					if errs > maxErr {
+						break
+					}
+				}
+			}
+			if errs <= maxErr {
+				best = p
+				break
+			}
+		}
+	}
+	return best
+}
+
+// quality returns the length of qual after trimming by quality.
+func (t *Trimmer) quality(qual []byte) int {
+	w := t.opts.Window
+	if w > len(qual) {
+		w = len(qual)
+	}
+	if w == 0 {
+		return 0
+	}
+	thresh := int(t.opts.MinQual) * w
+	var sum int
+	for _, q := range qual[len(qual)-w:] {
+		sum += int(q)
+	}
+	for end := len(qual); ; end-- {
+		if sum >= thresh {
+			return end
+		}
+		if end == w {
+			return 0
+		}
+		sum += int(qual[end-w-1]) - int(qual[end-1])
+	}
+}
+
+// Source is a source of records.
+type Source interface {
+	Read() (*sam.Record, error)
+}
+
+// Reader is a trimming record reader. It returns the records of its
+// Source in order, trimmed by a Trimmer.
+type Reader struct {
+	src Source
+	t   *Trimmer
+}
+
+// NewReader returns a Reader trimming the records read from src with t.
+func NewReader(src Source, t *Trimmer) *Reader {
+	return &Reader{src: src, t: t}
+}
+
+// Read returns the next trimmed record.
+func (r *Reader) Read() (*sam.Record, error) {
+	rec, err := r.src.Read()
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.t.Trim(rec)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func upper(b byte) byte {
+	if 'a' <= b && b <= 'z' {
+		return b - 'a' + 'A'
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,310 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trim
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Schaudge/hts/sam"
+)
+
+const adapter = "AGATCGGAAGAGC"
+
+func TestTrim(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		opts     Options
+		seq      string
+		qual     []byte
+		reverse  bool
+		wantSeq  string
+		wantQual []byte
+		want     Trimmed
+		wantFail bool
+	}{
+		{
+			name:     "none",
+			opts:     Options{PolyG: 5, Adapters: []string{adapter}, MinQual: 20},
+			seq:      "ACGTACGTAC",
+			qual:     quals(10, 30),
+			wantSeq:  "ACGTACGTAC",
+			wantQual: quals(10, 30),
+		},
+		{
+			name:     "poly-G",
+			opts:     Options{PolyG: 5},
+			seq:      "ACGTACGGGGGGG",
+			qual:     quals(13, 30),
+			wantSeq:  "ACGTAC",
+			wantQual: quals(6, 30),
+			want:     Trimmed{PolyG: 7},
+		},
+		{
+			name:     "short poly-G",
+			opts:     Options{PolyG: 5},
+			seq:      "ACGTACGGGG",
+			qual:     quals(10, 30),
+			wantSeq:  "ACGTACGGGG",
+			wantQual: quals(10, 30),
+		},
+		{
+			name:     "adapter",
+			opts:     Options{Adapters: []string{adapter}},
+			seq:      "ACGTACGTAGATCGGAAGAGCACAC",
+			qual:     quals(25, 30),
+			wantSeq:  "ACGTACGT",
+			wantQual: quals(8, 30),
+			want:     Trimmed{Adapter: 17},
+		},
+		{
+			name:     "partial adapter",
+			opts:     Options{Adapters: []string{adapter}},
+			seq:      "ACGTACGTAGATC",
+			qual:     quals(13, 30),
+			wantSeq:  "ACGTACGT",
+			wantQual: quals(8, 30),
+			want:     Trimmed{Adapter: 5},
+		},
+		{
+			name:     "short adapter overlap",
+			opts:     Options{Adapters: []string{adapter}, MinOverlap: 4},
+			seq:      "ACGTACGTCAGA",
+			qual:     quals(12, 30),
+			wantSeq:  "ACGTACGTCAGA",
+			wantQual: quals(12, 30),
+		},
+		{
+			name:     "adapter mismatch",
+			opts:     Options{Adapters: []string{adapter}, ErrorRate: 0.1},
+			seq:      "CCCCAGATCGGTAGAGC",
+			qual:     quals(17, 30),
+			wantSeq:  "CCCC",
+			wantQual: quals(4, 30),
+			want:     Trimmed{Adapter: 13},
+		},
+		{
+			name:     "adapter mismatch exact",
+			opts:     Options{Adapters: []string{adapter}},
+			seq:      "CCCCAGATCGGTAGAGC",
+			qual:     quals(17, 30),
+			wantSeq:  "CCCCAGATCGGTAGAGC",
+			wantQual: quals(17, 30),
+		},
+		{
+			name:     "adapter N",
+			opts:     Options{Adapters: []string{adapter}},
+			seq:      "CCCCAGATCNGAAGAGC",
+			qual:     quals(17, 30),
+			wantSeq:  "CCCC",
+			wantQual: quals(4, 30),
+			want:     Trimmed{Adapter: 13},
+		},
+		{
+			name:     "first adapter",
+			opts:     Options{Adapters: []string{adapter, "CTGTCTCTTATA"}},
+			seq:      "ACGTCTGTCTCTTATAAGATCGGAAGAGC",
+			qual:     quals(29, 30),
+			wantSeq:  "ACGT",
+			wantQual: quals(4, 30),
+			want:     Trimmed{Adapter: 25},
+		},
+		{
+			name:     "quality",
+			opts:     Options{MinQual: 20, Window: 2},
+			seq:      "ACGTACGTAC",
+			qual:     []byte{30, 30, 30, 30, 30, 25, 12, 30, 2, 2},
+			wantSeq:  "ACGTACGT",
+			wantQual: []byte{30, 30, 30, 30, 30, 25, 12, 30},
+			want:     Trimmed{Quality: 2},
+		},
+		{
+			name:     "quality all",
+			opts:     Options{MinQual: 20},
+			seq:      "ACGTAC",
+			qual:     quals(6, 10),
+			wantSeq:  "",
+			wantQual: []byte{},
+			want:     Trimmed{Quality: 6},
+		},
+		{
+			name:     "no quality",
+			opts:     Options{MinQual: 20},
+			seq:      "ACGTAC",
+			qual:     quals(6, 0xff),
+			wantSeq:  "ACGTAC",
+			wantQual: quals(6, 0xff),
+		},
+		{
+			name:     "all steps",
+			opts:     Options{PolyG: 5, Adapters: []string{adapter}, MinQual: 20, MinLength: 6},
+			seq:      "ACGTACGTAGATCGGAAGAGCGGGGGGGG",
+			qual:     append(append(quals(4, 30), quals(4, 5)...), quals(21, 30)...),
+			wantSeq:  "ACGTA",
+			wantQual: []byte{30, 30, 30, 30, 5},
+			want:     Trimmed{PolyG: 8, Adapter: 13, Quality: 3},
+			wantFail: true,
+		},
+		{
+			name:     "reverse",
+			opts:     Options{PolyG: 5, Adapters: []string{adapter}},
+			seq:      "CCCCCCGCTCTTCCGATCTACGTACGT",
+			qual:     append(quals(19, 10), quals(8, 30)...),
+			reverse:  true,
+			wantSeq:  "ACGTACGT",
+			wantQual: quals(8, 30),
+			want:     Trimmed{PolyG: 6, Adapter: 13},
+		},
+	} {
+		r := unaligned(t, test.seq, test.qual)
+		if test.reverse {
+			r.Flags |= sam.Reverse
+		}
+		tr, err := NewTrimmer(test.opts)
+		if err != nil {
+			t.Fatalf("unexpected error creating trimmer: %v", err)
+		}
+		got, err := tr.Trim(r)
+		if err != nil {
+			t.Fatalf("unexpected error trimming %s: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("unexpected trimmed lengths for %s: got:%+v want:%+v", test.name, got, test.want)
+		}
+		if seq := string(r.Seq.Expand()); seq != test.wantSeq {
+			t.Errorf("unexpected sequence for %s: got:%s want:%s", test.name, seq, test.wantSeq)
+		}
+		if !bytes.Equal(r.Qual, test.wantQual) {
+			t.Errorf("unexpected qualities for %s: got:%v want:%v", test.name, r.Qual, test.wantQual)
+		}
+		for _, tag := range []struct {
+			tag  sam.Tag
+			want int
+		}{
+			{tag: tgTag, want: test.want.PolyG},
+			{tag: taTag, want: test.want.Adapter},
+			{tag: tqTag, want: test.want.Quality},
+		} {
+			aux := r.AuxFields.Get(tag.tag)
+			switch {
+			case tag.want == 0 && aux != nil:
+				t.Errorf("unexpected %s tag for %s: %v", tag.tag, test.name, aux)
+			case tag.want != 0 && aux == nil:
+				t.Errorf("missing %s tag for %s", tag.tag, test.name)
+			case aux != nil && auxInt(aux) != tag.want:
+				t.Errorf("unexpected %s tag value for %s: got:%v want:%d", tag.tag, test.name, aux.Value(), tag.want)
+			}
+		}
+		if fail := r.Flags&sam.QCFail != 0; fail != test.wantFail {
+			t.Errorf("unexpected QC fail flag for %s: got:%t want:%t", test.name, fail, test.wantFail)
+		}
+	}
+}
+
+func TestTrimErrors(t *testing.T) {
+	for _, rate := range []float64{-0.1, 1} {
+		_, err := NewTrimmer(Options{ErrorRate: rate})
+		if err != ErrErrorRate {
+			t.Errorf("unexpected error for error rate %v: got:%v want:%v", rate, err, ErrErrorRate)
+		}
+	}
+
+	tr, err := NewTrimmer(Options{PolyG: 1})
+	if err != nil {
+		t.Fatalf("unexpected error creating trimmer: %v", err)
+	}
+	chr1, err := sam.NewReference("chr1", "", "", 1000, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating reference: %v", err)
+	}
+	_, err = sam.NewHeader(nil, []*sam.Reference{chr1})
+	if err != nil {
+		t.Fatalf("unexpected error creating header: %v", err)
+	}
+	r, err := sam.NewRecord("read", chr1, nil, 10, -1, 0, 60,
+		[]sam.CigarOp{sam.NewCigarOp(sam.CigarMatch, 4)}, []byte("ACGG"), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating record: %v", err)
+	}
+	_, err = tr.Trim(r)
+	if err != ErrAligned {
+		t.Errorf("unexpected error for aligned record: got:%v want:%v", err, ErrAligned)
+	}
+	if got := string(r.Seq.Expand()); got != "ACGG" {
+		t.Errorf("aligned record altered: %s", got)
+	}
+}
+
+func TestReader(t *testing.T) {
+	tr, err := NewTrimmer(Options{PolyG: 3})
+	if err != nil {
+		t.Fatalf("unexpected error creating trimmer: %v", err)
+	}
+	r := NewReader(&records{
+		unaligned(t, "ACGTGGG", quals(7, 30)),
+		unaligned(t, "ACGT", quals(4, 30)),
+	}, tr)
+	var got []string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+		got = append(got, string(rec.Seq.Expand()))
+	}
+	if len(got) != 2 || got[0] != "ACGT" || got[1] != "ACGT" {
+		t.Errorf("unexpected sequences: %v", got)
+	}
+}
+
+func unaligned(t *testing.T, seq string, qual []byte) *sam.Record {
+	r, err := sam.NewRecord("read", nil, nil, -1, -1, 0, 0, nil, []byte(seq), qual, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating record: %v", err)
+	}
+	r.Flags |= sam.Unmapped
+	return r
+}
+
+func quals(n int, q byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = q
+	}
+	return b
+}
+
+func auxInt(a sam.Aux) int {
+	switch v := a.Value().(type) {
+	case int8:
+		return int(v)
+	case uint8:
+		return int(v)
+	case int16:
+		return int(v)
+	case uint16:
+		return int(v)
+	case int32:
+		return int(v)
+	case uint32:
+		return int(v)
+	}
+	return -1
+}
+
+type records []*sam.Record
+
+func (r *records) Read() (*sam.Record, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
@@ -0,0 +1,457 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package umi
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrNoRecords = errors.New("umi: no records")
+	ErrMixedMI   = errors.New("umi: records from more than one molecule")
+)
+
+// Default consensus option values.
+const (
+	DefaultMaxQual = 90
+	DefaultPrefix  = "consensus"
+)
+
+var (
+	rgTag = sam.NewTag("RG")
+
+	// Per-read consensus tags.
+	cdTag = sam.NewTag("cD") // Maximum depth.
+	cmTag = sam.NewTag("cM") // Minimum depth.
+	ceTag = sam.NewTag("cE") // Error rate.
+
+	// Per-strand duplex consensus tags.
+	adTag = sam.NewTag("aD")
+	amTag = sam.NewTag("aM")
+	aeTag = sam.NewTag("aE")
+	bdTag = sam.NewTag("bD")
+	bmTag = sam.NewTag("bM")
+	beTag = sam.NewTag("bE")
+)
+
+// ConsensusOptions specifies the behaviour of a Caller.
+type ConsensusOptions struct {
+	// MinReads is the minimum number of reads
+	// supporting a consensus position on each
+	// strand. Consensus reads are truncated at
+	// the first position with fewer supporting
+	// reads. If MinReads is less than one, one
+	// read is required.
+	MinReads int
+
+	// MinBaseQ is the minimum quality of
+	// input bases used in the consensus.
+	MinBaseQ byte
+
+	// MaxQual is the maximum consensus base
+	// quality. If MaxQual is zero,
+	// DefaultMaxQual is used.
+	MaxQual byte
+
+	// Duplex specifies that duplex consensus
+	// reads are called from records grouped
+	// with duplex UMIs. Duplex consensus
+	// reads are only called for molecules
+	// with reads from both strands.
+	Duplex bool
+
+	// Prefix is the prefix of consensus read
+	// names, which are formed from the prefix
+	// and the molecule identifier separated by
+	// a colon. If Prefix is empty, DefaultPrefix
+	// is used.
+	Prefix string
+}
+
+// Caller calls consensus reads from the records of a molecule.
+type Caller struct {
+	opts ConsensusOptions
+}
+
+// NewCaller returns a Caller using the given options.
+func NewCaller(opts ConsensusOptions) *Caller {
+	if opts.MinReads < 1 {
+		opts.MinReads = 1
+	}
+	if opts.MaxQual == 0 {
+		opts.MaxQual = DefaultMaxQual
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = DefaultPrefix
+	}
+	return &Caller{opts: opts}
+}
+
+// Call returns the consensus reads of the records in recs, which must
+// all have the same MI tag value, ignoring any duplex strand suffix.
+// Secondary and supplementary records are ignored.
+//
+// Consensus reads are returned as unmapped records in sequencing
+// orientation, with the first and second reads of a pair returned in
+// that order. If there are insufficient reads to call a consensus,
+// Call returns no records and a nil error.
+//
+// Single-strand consensus reads hold the cD, cM and cE aux tags giving
+// the maximum and minimum number of reads supporting a consensus base,
+// and the fraction of input bases disagreeing with the consensus.
+// Duplex consensus reads additionally hold aD, aM, aE, bD, bM and bE
+// tags giving the same values for the A and B strands.
+func (c *Caller) Call(recs []*sam.Record) ([]*sam.Record, error) {
+	var (
+		mi     string
+		rg     interface{}
+		paired bool
+
+		// reads holds the oriented reads indexed
+		// by strand and then by read number.
+		reads [2][2][]read
+	)
+	for _, r := range recs {
+		if r.Flags&(sam.Secondary|sam.Supplementary) != 0 {
+			continue
+		}
+		a := r.AuxTag(miTag)
+		if a == nil {
+			return nil, errors.New("umi: record without molecule identifier")
+		}
+		id, _ := a.Value().(string)
+		base, strand := id, 0
+		if c.opts.Duplex {
+			switch {
+			case strings.HasSuffix(id, "/A"):
+				base = strings.TrimSuffix(id, "/A")
+			case strings.HasSuffix(id, "/B"):
+				base, strand = strings.TrimSuffix(id, "/B"), 1
+			default:
+				return nil, errors.New("umi: invalid duplex molecule identifier: " + id)
+			}
+		}
+		if mi == "" {
+			mi = base
+			if a := r.AuxTag(rgTag); a != nil {
+				rg = a.Value()
+			}
+		} else if base != mi {
+			return nil, ErrMixedMI
+		}
+		n := 0
+		if r.Flags&sam.Read2 != 0 {
+			n = 1
+		}
+		paired = paired || r.Flags&sam.Paired != 0
+		reads[strand][n] = append(reads[strand][n], orient(r))
+	}
+	if mi == "" {
+		return nil, ErrNoRecords
+	}
+
+	ends := 1
+	if paired {
+		ends = 2
+	}
+	var calls []*consensus
+	for n := 0; n < ends; n++ {
+		var cons *consensus
+		if c.opts.Duplex {
+			// The first read of an A strand template
+			// and the second read of a B strand
+			// template are sequenced from the same
+			// strand of the molecule.
+			a := c.singleStrand(reads[0][n])
+			b := c.singleStrand(reads[1][1-n])
+			if a == nil || b == nil {
+				return nil, nil
+			}
+			cons = c.duplex(a, b)
+		} else {
+			cons = c.singleStrand(reads[0][n])
+		}
+		if cons == nil {
+			return nil, nil
+		}
+		calls = append(calls, cons)
+	}
+
+	name := c.opts.Prefix + ":" + mi
+	out := make([]*sam.Record, len(calls))
+	for n, cons := range calls {
+		fields := []auxField{{miTag, mi}}
+		if rg != nil {
+			fields = append(fields, auxField{rgTag, rg})
+		}
+		fields = append(fields,
+			auxField{cdTag, cons.maxDepth()},
+			auxField{cmTag, cons.minDepth()},
+			auxField{ceTag, cons.errorRate()},
+		)
+		if cons.a != nil {
+			fields = append(fields,
+				auxField{adTag, cons.a.maxDepth()},
+				auxField{amTag, cons.a.minDepth()},
+				auxField{aeTag, cons.a.errorRate()},
+				auxField{bdTag, cons.b.maxDepth()},
+				auxField{bmTag, cons.b.minDepth()},
+				auxField{beTag, cons.b.errorRate()},
+			)
+		}
+		r, err := sam.NewRecord(name, nil, nil, -1, -1, 0, 0, nil, cons.bases, cons.quals, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			err = samutil.SetAux(r, f.tag, f.value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		r.Flags = sam.Unmapped
+		if paired {
+			r.Flags |= sam.Paired | sam.MateUnmapped | sam.Read1
+			if n == 1 {
+				r.Flags ^= sam.Read1 | sam.Read2
+			}
+		}
+		out[n] = r
+	}
+	return out, nil
+}
+
+// read is a read in sequencing orientation.
+type read struct {
+	bases, quals []byte
+}
+
+// orient returns the bases and qualities of r in sequencing orientation.
+func orient(r *sam.Record) read {
+	n := r.Seq.Length
+	rd := read{bases: r.Seq.Expand(), quals: make([]byte, n)}
+	if len(r.Qual) == n {
+		copy(rd.quals, r.Qual)
+	}
+	if r.Flags&sam.Reverse != 0 {
+		samutil.ReverseComplement(rd.bases)
+		samutil.Reverse(rd.quals)
+	}
+	return rd
+}
+
+// consensus is a consensus read.
+type consensus struct {
+	bases, quals []byte
+
+	// depth and errors are the number of
+	// input bases used at each position and
+	// the number disagreeing with the
+	// consensus base.
+	depth, errors []int
+
+	// a and b are the strand consensus reads
+	// of a duplex consensus.
+	a, b *consensus
+}
+
+func (c *consensus) maxDepth() int {
+	var max int
+	for _, d := range c.depth {
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+func (c *consensus) minDepth() int {
+	min := math.MaxInt32
+	for _, d := range c.depth {
+		if d < min {
+			min = d
+		}
+	}
+	return min
+}
+
+func (c *consensus) errorRate() float32 {
+	var n, e int
+	for i, d := range c.depth {
+		n += d
+		e += c.errors[i]
+	}
+	if n == 0 {
+		return 0
+	}
+	return float32(e) / float32(n)
+}
+
+// baseIndex maps bases to likelihood indexes; other bases are -1.
+var baseIndex = func() [256]int {
+	var idx [256]int
+	for i := range idx {
+		idx[i] = -1
+	}
+	for i, b := range "ACGT" {
+		idx[b] = i
+		idx[b+'a'-'A'] = i
+	}
+	return idx
+}()
+
+// singleStrand returns the consensus of reads, or nil if there are
+// insufficient reads.
+func (c *Caller) singleStrand(reads []read) *consensus {
+	if len(reads) < c.opts.MinReads {
+		return nil
+	}
+	var length int
+	for _, r := range reads {
+		if len(r.bases) > length {
+			length = len(r.bases)
+		}
+	}
+	cons := &consensus{}
+	for i := 0; i < length; i++ {
+		var (
+			ll    [4]float64
+			depth int
+		)
+		for _, r := range reads {
+			if i >= len(r.bases) {
+				continue
+			}
+			b := baseIndex[r.bases[i]]
+			q := r.quals[i]
+			if b < 0 || q < c.opts.MinBaseQ || q == 0xff {
+				continue
+			}
+			depth++
+			e := math.Min(math.Pow(10, -float64(q)/10), 0.75)
+			for j := range ll {
+				if j == b {
+					ll[j] += math.Log(1 - e)
+				} else {
+					ll[j] += math.Log(e / 3)
+				}
+			}
+		}
+		if depth < c.opts.MinReads {
+			break
+		}
+		best := 0
+		for j := range ll {
+			if ll[j] > ll[best] {
+				best = j
+			}
+		}
+		// Posterior probability of error of the
+		// best base under a uniform prior.
+		var other float64
+		for j := range ll {
+			if j != best {
+				other += math.Exp(ll[j] - ll[best])
+			}
+		}
+		perr := other / (1 + other)
+		base := "ACGT"[best]
+		var errs int
+		for _, r := range reads {
+			if i < len(r.bases) && baseIndex[r.bases[i]] >= 0 && r.quals[i] >= c.opts.MinBaseQ && r.quals[i] != 0xff &&
+				baseIndex[r.bases[i]] != best {
+				errs++
+			}
+		}
+		cons.bases = append(cons.bases, base)
+		cons.quals = append(cons.quals, c.phred(perr))
+		cons.depth = append(cons.depth, depth)
+		cons.errors = append(cons.errors, errs)
+	}
+	if len(cons.bases) == 0 {
+		return nil
+	}
+	return cons
+}
+
+// phred returns the phred scaled quality of the error probability p,
+// limited to the maximum consensus quality.
+func (c *Caller) phred(p float64) byte {
+	if p <= 0 {
+		return c.opts.MaxQual
+	}
+	q := -10 * math.Log10(p)
+	if q > float64(c.opts.MaxQual) {
+		return c.opts.MaxQual
+	}
+	if q < 2 {
+		return 2
+	}
+	return byte(math.Round(q))
+}
+
+// duplex returns the duplex consensus of the strand consensus reads a
+// and b.
+func (c *Caller) duplex(a, b *consensus) *consensus {
+	n := len(a.bases)
+	if len(b.bases) > n {
+		n = len(b.bases)
+	}
+	cons := &consensus{a: a, b: b}
+	for i := 0; i < n; i++ {
+		var (
+			base  byte
+			qual  byte
+			depth int
+			errs  int
+		)
+		switch {
+		case i >= len(a.bases):
+			base, qual = b.bases[i], b.quals[i]
+		case i >= len(b.bases):
+			base, qual = a.bases[i], a.quals[i]
+		case a.bases[i] == b.bases[i]:
+			base = a.bases[i]
+			sum := int(a.quals[i]) + int(b.quals[i])
+			if sum > int(c.opts.MaxQual) {
+				sum = int(c.opts.MaxQual)
+			}
+			qual = byte(sum)
+		case a.quals[i] > b.quals[i]:
+			base, qual = a.bases[i], a.quals[i]-b.quals[i]
+		case b.quals[i] > a.quals[i]:
+			base, qual = b.bases[i], b.quals[i]-a.quals[i]
+		default:
+			base = 'N'
+		}
+		if qual < 2 {
+			qual = 2
+		}
+		if i < len(a.bases) {
+			depth += a.depth[i]
+			errs += a.errors[i]
+		}
+		if i < len(b.bases) {
+			depth += b.depth[i]
+			errs += b.errors[i]
+		}
+		cons.bases = append(cons.bases, base)
+		cons.quals = append(cons.quals, qual)
+		cons.depth = append(cons.depth, depth)
+		cons.errors = append(cons.errors, errs)
+	}
+	return cons
+}
+
+// auxField is an aux tag and value to be set on a record.
+type auxField struct {
+	tag   sam.Tag
+	value interface{}
+}
@@ -0,0 +1,329 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package umi implements grouping of alignment records into source
+// molecules using unique molecular identifiers, and calling of
+// single-strand and duplex consensus reads from the grouped records.
+//
+// Grouping assigns an MI aux tag to each record, following the fgbio
+// convention: records from the same molecule share an MI value, and
+// for duplex UMIs the value is suffixed with /A or /B to identify the
+// strand of the molecule the template was derived from.
+package umi
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Schaudge/hts/internal/samutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+var (
+	ErrNoUMI = errors.New("umi: record without umi")
+)
+
+var (
+	rxTag = sam.NewTag("RX")
+	miTag = sam.NewTag("MI")
+)
+
+// GroupOptions specifies the behaviour of a Grouper.
+type GroupOptions struct {
+	// Tag is the aux tag holding the UMI
+	// of a record. If Tag is the zero value,
+	// RX is used.
+	Tag sam.Tag
+
+	// Edits is the maximum edit distance
+	// between UMIs that are collapsed into
+	// the same molecule. UMIs are collapsed
+	// using the directional method of
+	// UMI-tools: a UMI is merged into a group
+	// containing a UMI within the edit distance
+	// observed in at least 2n-1 templates,
+	// where n is the number of templates with
+	// the merged UMI.
+	Edits int
+
+	// Duplex specifies that UMIs are pairs
+	// of strand UMIs separated by a hyphen,
+	// and that templates with swapped UMI
+	// pairs from the two strands of the same
+	// molecule are grouped together.
+	Duplex bool
+
+	// Skip, if not nil, specifies records
+	// that are excluded from grouping. The
+	// MI tag of skipped records is not
+	// altered.
+	Skip func(*sam.Record) bool
+}
+
+// Grouper assigns molecule identifiers to records.
+type Grouper struct {
+	opts GroupOptions
+	next int
+}
+
+// NewGrouper returns a Grouper using the given options.
+func NewGrouper(opts GroupOptions) *Grouper {
+	if opts.Tag == (sam.Tag{}) {
+		opts.Tag = rxTag
+	}
+	return &Grouper{opts: opts}
+}
+
+// template holds the records of a read pair or unpaired read.
+type template struct {
+	recs []*sam.Record
+	umi  string
+	key  posKey
+
+	// strandB is whether the template is
+	// from the B strand of a duplex molecule.
+	strandB bool
+}
+
+// posKey is the position group of a template.
+type posKey struct {
+	bag      int64
+	ref, pos int
+	mateRef  int
+	matePos  int
+	rev      bool
+}
+
+// Group assigns an MI aux tag to each of the records in recs. Records
+// are grouped first by position: records with a DI bag ID, as written
+// by the markdup package, are grouped by bag, and other records are
+// grouped by the positions of the template's reads. Within each
+// position group, templates are grouped by UMI.
+//
+// All the records of each template must be present in recs, and
+// templates of a molecule must not be split between calls. Molecule
+// identifiers are unique over calls to Group.
+func (g *Grouper) Group(recs []*sam.Record) error {
+	var (
+		byName    = make(map[string]*template)
+		templates []*template
+	)
+	for _, r := range recs {
+		if g.opts.Skip != nil && g.opts.Skip(r) {
+			continue
+		}
+		t, ok := byName[r.Name]
+		if !ok {
+			t = &template{}
+			byName[r.Name] = t
+			templates = append(templates, t)
+		}
+		t.recs = append(t.recs, r)
+	}
+
+	var (
+		byPos = make(map[posKey][]*template)
+		keys  []posKey
+	)
+	for _, t := range templates {
+		err := g.describe(t)
+		if err != nil {
+			return err
+		}
+		if _, ok := byPos[t.key]; !ok {
+			keys = append(keys, t.key)
+		}
+		byPos[t.key] = append(byPos[t.key], t)
+	}
+	for _, k := range keys {
+		err := g.assign(byPos[k])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describe sets the UMI, strand and position key of t.
+func (g *Grouper) describe(t *template) error {
+	var (
+		primary *sam.Record
+		r1, r2  *sam.Record
+	)
+	for _, r := range t.recs {
+		if r.Flags&(sam.Secondary|sam.Supplementary) != 0 {
+			continue
+		}
+		if primary == nil {
+			primary = r
+		}
+		switch {
+		case r.Flags&sam.Read2 != 0:
+			r2 = r
+		default:
+			r1 = r
+		}
+	}
+	if primary == nil {
+		primary = t.recs[0]
+	}
+	aux := primary.AuxTag(g.opts.Tag)
+	if aux == nil {
+		return ErrNoUMI
+	}
+	umi, ok := aux.Value().(string)
+	if !ok || umi == "" {
+		return ErrNoUMI
+	}
+	t.umi = strings.ToUpper(umi)
+
+	if bag, err := primary.BagID(); err == nil && bag >= 0 {
+		t.key = posKey{bag: bag}
+	} else {
+		// Order the ends of the template so that
+		// both reads of a pair give the same key.
+		a := [2]int{primary.Ref.ID(), primary.Pos}
+		b := [2]int{primary.MateRef.ID(), primary.MatePos}
+		if primary.Flags&sam.Paired == 0 {
+			b = [2]int{-1, -1}
+		} else if b[0] < a[0] || (b[0] == a[0] && b[1] < a[1]) {
+			a, b = b, a
+		}
+		t.key = posKey{bag: -1, ref: a[0], pos: a[1], mateRef: b[0], matePos: b[1]}
+		if primary.Flags&sam.Paired == 0 {
+			t.key.rev = primary.Flags&sam.Reverse != 0
+		}
+	}
+
+	if g.opts.Duplex {
+		parts := strings.Split(t.umi, "-")
+		if len(parts) != 2 {
+			return errors.New("umi: invalid duplex umi: " + umi)
+		}
+		// The template is from the A strand if
+		// its first read is leftmost.
+		if r1 != nil && r2 != nil && less(r2, r1) {
+			t.strandB = true
+			t.umi = parts[1] + "-" + parts[0]
+		}
+	}
+	return nil
+}
+
+// less returns whether a is placed before b.
+func less(a, b *sam.Record) bool {
+	ai, bi := a.Ref.ID(), b.Ref.ID()
+	if ai < 0 {
+		return false
+	}
+	if bi < 0 {
+		return true
+	}
+	return ai < bi || (ai == bi && a.Pos < b.Pos)
+}
+
+// assign assigns molecule identifiers to the templates of a position
+// group.
+func (g *Grouper) assign(templates []*template) error {
+	counts := make(map[string]int)
+	for _, t := range templates {
+		counts[t.umi]++
+	}
+	umis := make([]string, 0, len(counts))
+	for u := range counts {
+		umis = append(umis, u)
+	}
+	sort.Slice(umis, func(i, j int) bool {
+		if counts[umis[i]] != counts[umis[j]] {
+			return counts[umis[i]] > counts[umis[j]]
+		}
+		return umis[i] < umis[j]
+	})
+
+	ids := make(map[string]int, len(umis))
+	for _, root := range umis {
+		if _, ok := ids[root]; ok {
+			continue
+		}
+		id := g.next
+		g.next++
+		ids[root] = id
+		queue := []string{root}
+		for len(queue) != 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, v := range umis {
+				if _, ok := ids[v]; ok {
+					continue
+				}
+				if counts[u] >= 2*counts[v]-1 && withinEdits(u, v, g.opts.Edits) {
+					ids[v] = id
+					queue = append(queue, v)
+				}
+			}
+		}
+	}
+
+	for _, t := range templates {
+		mi := strconv.Itoa(ids[t.umi])
+		if g.opts.Duplex {
+			if t.strandB {
+				mi += "/B"
+			} else {
+				mi += "/A"
+			}
+		}
+		for _, r := range t.recs {
+			err := samutil.SetAux(r, miTag, mi)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// withinEdits returns whether the edit distance between a and b is at
+// most n.
+func withinEdits(a, b string, n int) bool {
+	if n == 0 {
+		return a == b
+	}
+	if d := len(a) - len(b); d > n || -d > n {
+		return false
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		min := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j-1]+cost, minInt(prev[j], curr[j-1])+1)
+			if curr[j] < min {
+				min = curr[j]
+			}
+		}
+		if min > n {
+			return false
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)] <= n
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
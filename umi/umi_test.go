@@ -0,0 +1,244 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package umi
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+// addRead adds a read to b on chr1 with a CIGAR matching seq and mapping
+// quality 60. Paired reads have their mate on chr1 at matePos.
+func addRead(b *htstestutil.BAMBuilder, name string, pos int, flags sam.Flags, matePos int, seq, qual string) *htstestutil.RecordBuilder {
+	r := b.Record(name, "chr1", pos, strconv.Itoa(len(seq))+"M", seq).Qual(qual).MapQ(60).Flags(flags)
+	if flags&sam.Paired != 0 {
+		r.Mate("=", matePos, 0)
+	}
+	return r
+}
+
+// newRecords returns the records added to b.
+func newRecords(t *testing.T, b *htstestutil.BAMBuilder) []*sam.Record {
+	t.Helper()
+	h, err := b.Header()
+	if err != nil {
+		t.Fatalf("unexpected error making header: %v", err)
+	}
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error making records: %v", err)
+	}
+	return recs
+}
+
+func newAux(t *testing.T, tag sam.Tag, v interface{}) sam.Aux {
+	t.Helper()
+	a, err := sam.NewAux(tag, v)
+	if err != nil {
+		t.Fatalf("unexpected error making aux: %v", err)
+	}
+	return a
+}
+
+func mi(t *testing.T, r *sam.Record) string {
+	t.Helper()
+	a := r.AuxFields.Get(miTag)
+	if a == nil {
+		t.Fatalf("missing MI tag for %s", r.Name)
+	}
+	return a.Value().(string)
+}
+
+func TestGroup(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().Ref("chr1", 1000)
+	addRead(b, "a", 100, 0, -1, "ACGT", "IIII").Tag("RX", "AAAA")
+	addRead(b, "b", 100, 0, -1, "ACGT", "IIII").Tag("RX", "AAAA")
+	addRead(b, "c", 100, 0, -1, "ACGT", "IIII").Tag("RX", "AAAT")
+	addRead(b, "d", 100, 0, -1, "ACGT", "IIII").Tag("RX", "CCCC")
+	addRead(b, "e", 200, 0, -1, "ACGT", "IIII").Tag("RX", "AAAA")
+	addRead(b, "f", 100, sam.Reverse, -1, "ACGT", "IIII").Tag("RX", "AAAA")
+	recs := newRecords(t, b)
+	g := NewGrouper(GroupOptions{Edits: 1})
+	err := g.Group(recs)
+	if err != nil {
+		t.Fatalf("unexpected error grouping: %v", err)
+	}
+	ids := make(map[string]string)
+	for _, r := range recs {
+		ids[r.Name] = mi(t, r)
+	}
+	if ids["a"] != ids["b"] || ids["a"] != ids["c"] {
+		t.Errorf("expected a, b and c to be grouped: %v", ids)
+	}
+	if ids["d"] == ids["a"] || ids["e"] == ids["a"] || ids["f"] == ids["a"] || ids["e"] == ids["f"] {
+		t.Errorf("unexpected grouping of d, e or f: %v", ids)
+	}
+
+	b = htstestutil.NewBAMBuilder().Ref("chr1", 1000)
+	addRead(b, "x", 1, 0, -1, "A", "I")
+	err = NewGrouper(GroupOptions{}).Group(newRecords(t, b))
+	if err != ErrNoUMI {
+		t.Errorf("unexpected error for missing UMI: got:%v want:%v", err, ErrNoUMI)
+	}
+}
+
+func TestGroupDuplex(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().Ref("chr1", 1000)
+	const (
+		r1f = sam.Paired | sam.Read1 | sam.MateReverse
+		r2r = sam.Paired | sam.Read2 | sam.Reverse
+		r2f = sam.Paired | sam.Read2 | sam.MateReverse
+		r1r = sam.Paired | sam.Read1 | sam.Reverse
+	)
+	addRead(b, "a", 100, r1f, 300, "ACGT", "IIII").Tag("RX", "AAA-CCC")
+	addRead(b, "a", 300, r2r, 100, "ACGT", "IIII").Tag("RX", "AAA-CCC")
+	addRead(b, "b", 100, r2f, 300, "ACGT", "IIII").Tag("RX", "CCC-AAA")
+	addRead(b, "b", 300, r1r, 100, "ACGT", "IIII").Tag("RX", "CCC-AAA")
+	addRead(b, "c", 100, r1f, 300, "ACGT", "IIII").Tag("RX", "CCC-AAA")
+	addRead(b, "c", 300, r2r, 100, "ACGT", "IIII").Tag("RX", "CCC-AAA")
+	recs := newRecords(t, b)
+	err := NewGrouper(GroupOptions{Duplex: true}).Group(recs)
+	if err != nil {
+		t.Fatalf("unexpected error grouping: %v", err)
+	}
+	ma, mb, mc := mi(t, recs[0]), mi(t, recs[2]), mi(t, recs[4])
+	if mi(t, recs[1]) != ma || mi(t, recs[3]) != mb || mi(t, recs[5]) != mc {
+		t.Errorf("reads of a template have different MI values")
+	}
+	if ma[len(ma)-2:] != "/A" || mb[len(mb)-2:] != "/B" || ma[:len(ma)-2] != mb[:len(mb)-2] {
+		t.Errorf("unexpected duplex MI values for a and b: %q %q", ma, mb)
+	}
+	if mc[len(mc)-2:] != "/A" || mc == ma {
+		t.Errorf("unexpected duplex MI value for c: %q", mc)
+	}
+}
+
+func TestWithinEdits(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		n    int
+		want bool
+	}{
+		{a: "ACGT", b: "ACGT", n: 0, want: true},
+		{a: "ACGT", b: "ACGA", n: 0, want: false},
+		{a: "ACGT", b: "ACGA", n: 1, want: true},
+		{a: "ACGT", b: "AGT", n: 1, want: true},
+		{a: "ACGT", b: "TGCA", n: 2, want: false},
+		{a: "ACGT", b: "A", n: 2, want: false},
+	} {
+		if got := withinEdits(test.a, test.b, test.n); got != test.want {
+			t.Errorf("unexpected result for %q %q within %d: got:%t want:%t", test.a, test.b, test.n, got, test.want)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().Ref("chr1", 1000)
+	addRead(b, "a", 100, 0, -1, "ACGTA", "IIIII").Tag("MI", "7").Tag("RG", "rg1")
+	addRead(b, "b", 100, 0, -1, "ACGTA", "IIIII").Tag("MI", "7")
+	addRead(b, "c", 100, 0, -1, "ACCT", "IIII").Tag("MI", "7")
+	// Reverse complement of ACGT.
+	addRead(b, "d", 100, sam.Reverse, -1, "ACGT", "IIII").Tag("MI", "7")
+	recs := newRecords(t, b)
+	out, err := NewCaller(ConsensusOptions{MinReads: 2}).Call(recs)
+	if err != nil {
+		t.Fatalf("unexpected error calling consensus: %v", err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("unexpected number of consensus reads: got:%d want:1", len(out))
+	}
+	c := out[0]
+	if c.Name != "consensus:7" || c.Flags != sam.Unmapped {
+		t.Errorf("unexpected consensus record: name:%s flags:%v", c.Name, c.Flags)
+	}
+	// The fifth position only has two reads.
+	if got := string(c.Seq.Expand()); got != "ACGTA" {
+		t.Errorf("unexpected consensus sequence: got:%s want:ACGTA", got)
+	}
+	if c.Qual[0] != DefaultMaxQual || c.Qual[4] >= c.Qual[0] {
+		t.Errorf("unexpected consensus qualities: %v", c.Qual)
+	}
+	for _, test := range []struct {
+		tag  sam.Tag
+		want interface{}
+	}{
+		{tag: miTag, want: "7"},
+		{tag: rgTag, want: "rg1"},
+		{tag: cdTag, want: int8(4)},
+		{tag: cmTag, want: int8(2)},
+		{tag: ceTag, want: float32(1) / 18},
+	} {
+		a := c.AuxFields.Get(test.tag)
+		if a == nil {
+			t.Errorf("missing %s tag", test.tag)
+			continue
+		}
+		if got := a.Value(); got != test.want {
+			t.Errorf("unexpected %s value: got:%v (%T) want:%v (%T)", test.tag, got, got, test.want, test.want)
+		}
+	}
+
+	out, err = NewCaller(ConsensusOptions{MinReads: 5}).Call(recs)
+	if err != nil || out != nil {
+		t.Errorf("unexpected result for insufficient reads: %v %v", out, err)
+	}
+
+	recs[3].AuxFields = []sam.Aux{newAux(t, miTag, "8")}
+	_, err = NewCaller(ConsensusOptions{}).Call(recs)
+	if err != ErrMixedMI {
+		t.Errorf("unexpected error for mixed molecules: got:%v want:%v", err, ErrMixedMI)
+	}
+}
+
+func TestCallDuplex(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().Ref("chr1", 1000)
+	const (
+		r1f = sam.Paired | sam.Read1 | sam.MateReverse
+		r2r = sam.Paired | sam.Read2 | sam.Reverse
+		r2f = sam.Paired | sam.Read2 | sam.MateReverse
+		r1r = sam.Paired | sam.Read1 | sam.Reverse
+	)
+	addRead(b, "a", 100, r1f, 300, "ACGT", "5555").Tag("MI", "3/A")
+	addRead(b, "a", 300, r2r, 100, "GGCC", "5555").Tag("MI", "3/A")
+	addRead(b, "b", 100, r2f, 300, "ACGA", "555+").Tag("MI", "3/B")
+	addRead(b, "b", 300, r1r, 100, "GGCC", "5555").Tag("MI", "3/B")
+	recs := newRecords(t, b)
+	out, err := NewCaller(ConsensusOptions{Duplex: true}).Call(recs)
+	if err != nil {
+		t.Fatalf("unexpected error calling consensus: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("unexpected number of consensus reads: got:%d want:2", len(out))
+	}
+	if out[0].Flags&sam.Read1 == 0 || out[1].Flags&sam.Read2 == 0 {
+		t.Errorf("unexpected consensus read flags: %v %v", out[0].Flags, out[1].Flags)
+	}
+	if got := string(out[0].Seq.Expand()); got != "ACGT" {
+		t.Errorf("unexpected first read sequence: got:%s want:ACGT", got)
+	}
+	if got := string(out[1].Seq.Expand()); got != "GGCC" {
+		t.Errorf("unexpected second read sequence: got:%s want:GGCC", got)
+	}
+	// Agreeing strands sum qualities; the disagreeing
+	// last base takes the difference of the strand
+	// qualities.
+	if !bytes.Equal(out[0].Qual, []byte{40, 40, 40, 10}) {
+		t.Errorf("unexpected first read qualities: %v", out[0].Qual)
+	}
+	for _, tag := range []sam.Tag{adTag, amTag, aeTag, bdTag, bmTag, beTag} {
+		if out[0].AuxFields.Get(tag) == nil {
+			t.Errorf("missing %s tag", tag)
+		}
+	}
+
+	out, err = NewCaller(ConsensusOptions{Duplex: true}).Call(recs[:2])
+	if err != nil || out != nil {
+		t.Errorf("unexpected result for single strand molecule: %v %v", out, err)
+	}
+}
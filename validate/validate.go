@@ -0,0 +1,522 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package validate implements validation of SAM and BAM streams in the
+// manner of Picard ValidateSamFile.
+//
+// A Validator examines each record of a stream and the relationships
+// between records, such as the agreement of the mate fields of paired
+// records, and reports the problems it finds as Issues of a Kind and a
+// Severity.
+package validate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/sam"
+)
+
+// Severity is the severity of an issue.
+type Severity int
+
+const (
+	// Ignore specifies that issues of a kind
+	// are not reported.
+	Ignore Severity = iota
+
+	// Warning is the severity of issues that
+	// do not make a stream invalid.
+	Warning
+
+	// Error is the severity of issues that
+	// make a stream invalid.
+	Error
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case Ignore:
+		return "IGNORE"
+	case Warning:
+		return "WARNING"
+	case Error:
+		return "ERROR"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Kind is a kind of issue. Kinds are named as the corresponding
+// Picard ValidateSamFile error types.
+type Kind int
+
+const (
+	// OutOfOrder is a record out of the sort
+	// order declared by the header.
+	OutOfOrder Kind = iota
+
+	// InvalidBin is a BAM record with an
+	// index bin inconsistent with its
+	// position and alignment.
+	InvalidBin
+
+	// InvalidAlignmentStart is a placed
+	// record with a position outside its
+	// reference.
+	InvalidAlignmentStart
+
+	// InvalidCigar is a mapped record with a
+	// CIGAR inconsistent with its sequence.
+	InvalidCigar
+
+	// QualLengthMismatch is a record with
+	// base qualities of a different length
+	// to its sequence.
+	QualLengthMismatch
+
+	// MissingReadGroup is a record without
+	// an RG aux field in a stream with read
+	// groups in its header.
+	MissingReadGroup
+
+	// ReadGroupNotFound is a record with an
+	// RG aux field naming a read group not
+	// in the header.
+	ReadGroupNotFound
+
+	// MateNotFound is a primary paired
+	// record whose mate is not in the
+	// stream.
+	MateNotFound
+
+	// MatesAreSameEnd is a primary paired
+	// record with the same name and the
+	// same end as another unmatched primary
+	// record.
+	MatesAreSameEnd
+
+	// MismatchMateRef, MismatchMateStart,
+	// MismatchMateNegStrand and
+	// MismatchMateUnmapped are primary
+	// paired records whose mate fields
+	// disagree with the reference,
+	// position, strand or mapping of their
+	// mates.
+	MismatchMateRef
+	MismatchMateStart
+	MismatchMateNegStrand
+	MismatchMateUnmapped
+
+	numKinds
+)
+
+var kindNames = [numKinds]string{
+	OutOfOrder:            "RECORD_OUT_OF_ORDER",
+	InvalidBin:            "INVALID_INDEXING_BIN",
+	InvalidAlignmentStart: "INVALID_ALIGNMENT_START",
+	InvalidCigar:          "INVALID_CIGAR",
+	QualLengthMismatch:    "MISMATCH_READ_LENGTH_AND_QUALS_LENGTH",
+	MissingReadGroup:      "RECORD_MISSING_READ_GROUP",
+	ReadGroupNotFound:     "READ_GROUP_NOT_FOUND",
+	MateNotFound:          "MATE_NOT_FOUND",
+	MatesAreSameEnd:       "MATES_ARE_SAME_END",
+	MismatchMateRef:       "MISMATCH_MATE_REF_INDEX",
+	MismatchMateStart:     "MISMATCH_MATE_ALIGNMENT_START",
+	MismatchMateNegStrand: "MISMATCH_FLAG_MATE_NEG_STRAND",
+	MismatchMateUnmapped:  "MISMATCH_FLAG_MATE_UNMAPPED",
+}
+
+// String returns the Picard name of the kind.
+func (k Kind) String() string {
+	if 0 <= k && k < numKinds {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// ParseKind returns the kind with the given Picard name.
+func ParseKind(name string) (Kind, error) {
+	for k, n := range kindNames {
+		if n == name {
+			return Kind(k), nil
+		}
+	}
+	return 0, fmt.Errorf("validate: unknown issue kind %q", name)
+}
+
+// Kinds returns all kinds of issue.
+func Kinds() []Kind {
+	kinds := make([]Kind, numKinds)
+	for i := range kinds {
+		kinds[i] = Kind(i)
+	}
+	return kinds
+}
+
+// defaultSeverity is the severity of each kind of issue unless set by
+// Options.
+var defaultSeverity = [numKinds]Severity{
+	OutOfOrder:            Error,
+	InvalidBin:            Error,
+	InvalidAlignmentStart: Error,
+	InvalidCigar:          Error,
+	QualLengthMismatch:    Error,
+	MissingReadGroup:      Warning,
+	ReadGroupNotFound:     Error,
+	MateNotFound:          Error,
+	MatesAreSameEnd:       Error,
+	MismatchMateRef:       Error,
+	MismatchMateStart:     Error,
+	MismatchMateNegStrand: Error,
+	MismatchMateUnmapped:  Error,
+}
+
+// Issue is a problem found by a Validator.
+type Issue struct {
+	Kind     Kind
+	Severity Severity
+
+	// Name is the name of the record with
+	// the issue, and Record is its index in
+	// the stream. Record is -1 for issues
+	// found at the end of the stream.
+	Name   string
+	Record int64
+
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Record < 0 {
+		return fmt.Sprintf("%v: %v, Read name %s, %s", i.Severity, i.Kind, i.Name, i.Message)
+	}
+	return fmt.Sprintf("%v: Record %d, Read name %s, %v: %s", i.Severity, i.Record+1, i.Name, i.Kind, i.Message)
+}
+
+// DefaultMaxIssues is the default maximum number of issues held by a
+// Report.
+const DefaultMaxIssues = 100
+
+// Options specifies the behaviour of a Validator.
+type Options struct {
+	// Severity specifies the severity of
+	// kinds of issue, overriding their
+	// default severity. Kinds with a
+	// severity of Ignore are not reported.
+	Severity map[Kind]Severity
+
+	// MaxIssues is the maximum number of
+	// issues held by a Report. Issues beyond
+	// the maximum are counted but not held.
+	// If MaxIssues is zero, DefaultMaxIssues
+	// is used, and if it is negative, all
+	// issues are held.
+	MaxIssues int
+}
+
+// Report is the result of validation.
+type Report struct {
+	// Records is the number of records
+	// validated.
+	Records int64
+
+	// Issues holds the reported issues up
+	// to the maximum number of issues.
+	Issues []Issue
+
+	// Counts holds the number of issues of
+	// each kind, including those not held
+	// in Issues.
+	Counts map[Kind]int64
+
+	// Truncated is whether issues were
+	// counted but not held in Issues.
+	Truncated bool
+
+	severity [numKinds]Severity
+}
+
+// Errors returns the number of issues with Error severity.
+func (r *Report) Errors() int64 { return r.count(Error) }
+
+// Warnings returns the number of issues with Warning severity.
+func (r *Report) Warnings() int64 { return r.count(Warning) }
+
+func (r *Report) count(s Severity) int64 {
+	var n int64
+	for k, c := range r.Counts {
+		if r.severity[k] == s {
+			n += c
+		}
+	}
+	return n
+}
+
+// WriteSummary writes a table of the number of issues of each kind to
+// w in the format of the Picard ValidateSamFile summary mode.
+func (r *Report) WriteSummary(w io.Writer) error {
+	if len(r.Counts) == 0 {
+		_, err := fmt.Fprintln(w, "No errors found")
+		return err
+	}
+	kinds := make([]Kind, 0, len(r.Counts))
+	for k := range r.Counts {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	_, err := fmt.Fprintln(w, "Error Type\tCount")
+	if err != nil {
+		return err
+	}
+	for _, k := range kinds {
+		_, err = fmt.Fprintf(w, "%v:%v\t%d\n", r.severity[k], k, r.Counts[k])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reader is a source of records to validate. It is satisfied by
+// *bam.Reader and *sam.Reader.
+type Reader interface {
+	Header() *sam.Header
+	Read() (*sam.Record, error)
+}
+
+// binner is implemented by readers that report the index bin stored
+// in the last record read, such as *bam.Reader.
+type binner interface {
+	LastBin() int
+}
+
+// Validate validates the records read from r, returning a Report of
+// the issues found. The error returned is any error reading from r
+// other than io.EOF.
+func Validate(r Reader, opts Options) (*Report, error) {
+	v := NewValidator(r.Header(), opts)
+	b, _ := r.(binner)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return v.Finish(), err
+		}
+		v.Add(rec)
+		if b != nil {
+			v.CheckBin(rec, b.LastBin())
+		}
+	}
+	return v.Finish(), nil
+}
+
+// Validator validates a stream of records.
+type Validator struct {
+	h        *sam.Header
+	severity [numKinds]Severity
+	max      int
+
+	less   func(a, b *sam.Record) bool
+	groups map[string]bool
+
+	last    *sam.Record
+	n       int64
+	pending map[mateKey]mateInfo
+	report  Report
+}
+
+// mateKey identifies a primary paired record awaiting its mate.
+type mateKey struct {
+	name string
+	end  sam.Flags
+}
+
+// mateInfo holds the fields of a primary paired record used to check
+// agreement with its mate.
+type mateInfo struct {
+	record  int64
+	ref     int
+	pos     int
+	flags   sam.Flags
+	mateRef int
+	matePos int
+}
+
+// NewValidator returns a Validator for records with the header h.
+func NewValidator(h *sam.Header, opts Options) *Validator {
+	v := &Validator{
+		h:        h,
+		severity: defaultSeverity,
+		max:      opts.MaxIssues,
+		pending:  make(map[mateKey]mateInfo),
+		report:   Report{Counts: make(map[Kind]int64)},
+	}
+	if v.max == 0 {
+		v.max = DefaultMaxIssues
+	}
+	for k, s := range opts.Severity {
+		if 0 <= k && k < numKinds {
+			v.severity[k] = s
+		}
+	}
+	switch h.SortOrder {
+	case sam.Coordinate:
+		v.less = func(a, b *sam.Record) bool {
+			ai, bi := uint32(a.Ref.ID()), uint32(b.Ref.ID())
+			if ai != bi {
+				return ai < bi
+			}
+			return a.Pos < b.Pos
+		}
+	case sam.QueryName:
+		order, _ := bam.HeaderNameOrder(h)
+		v.less = func(a, b *sam.Record) bool { return order.Less(a.Name, b.Name) }
+	}
+	if rgs := h.RGs(); len(rgs) != 0 {
+		v.groups = make(map[string]bool)
+		for _, rg := range rgs {
+			v.groups[rg.Name()] = true
+		}
+	}
+	return v
+}
+
+var rgTag = sam.NewTag("RG")
+
+// Add validates the record r, which must not be modified until the
+// Validator is finished.
+func (v *Validator) Add(r *sam.Record) {
+	if v.less != nil && v.last != nil && v.less(r, v.last) {
+		v.issue(OutOfOrder, r.Name, v.n, "record out of %v order after %s", v.h.SortOrder, v.last.Name)
+	}
+	v.last = r
+
+	if r.Ref != nil && (r.Pos < 0 || r.Pos >= r.Ref.Len()) {
+		v.issue(InvalidAlignmentStart, r.Name, v.n, "position %d outside %s of length %d", r.Pos+1, r.Ref.Name(), r.Ref.Len())
+	}
+	if r.Flags&sam.Unmapped == 0 && len(r.Cigar) != 0 && r.Seq.Length != 0 && !r.Cigar.IsValid(r.Seq.Length) {
+		v.issue(InvalidCigar, r.Name, v.n, "CIGAR %v inconsistent with read length %d", r.Cigar, r.Seq.Length)
+	}
+	if r.Qual != nil && len(r.Qual) != r.Seq.Length {
+		v.issue(QualLengthMismatch, r.Name, v.n, "read length %d does not match quality length %d", r.Seq.Length, len(r.Qual))
+	}
+	if v.groups != nil {
+		rg := r.AuxTag(rgTag)
+		switch {
+		case rg == nil:
+			v.issue(MissingReadGroup, r.Name, v.n, "RG tag not found")
+		case !v.groups[fmt.Sprint(rg.Value())]:
+			v.issue(ReadGroupNotFound, r.Name, v.n, "read group %v not found in header", rg.Value())
+		}
+	}
+	if r.Flags&sam.Paired != 0 && r.Flags&(sam.Secondary|sam.Supplementary) == 0 {
+		v.checkMate(r)
+	}
+	v.n++
+	v.report.Records = v.n
+}
+
+// CheckBin validates the index bin stored in the BAM encoding of r, the
+// record most recently added.
+func (v *Validator) CheckBin(r *sam.Record, bin int) {
+	if want := int(uint16(r.Bin())); bin != want {
+		v.issue(InvalidBin, r.Name, v.n-1, "bin field %d does not match computed bin %d", bin, want)
+	}
+}
+
+// checkMate checks the mate fields of the primary paired record r
+// against its mate if the mate has been seen, and otherwise holds r
+// until its mate is added.
+func (v *Validator) checkMate(r *sam.Record) {
+	end := r.Flags & (sam.Read1 | sam.Read2)
+	info := mateInfo{
+		record:  v.n,
+		ref:     r.Ref.ID(),
+		pos:     r.Pos,
+		flags:   r.Flags,
+		mateRef: r.MateRef.ID(),
+		matePos: r.MatePos,
+	}
+	self := mateKey{name: r.Name, end: end}
+	if _, ok := v.pending[self]; ok {
+		v.issue(MatesAreSameEnd, r.Name, v.n, "another unmatched primary record has the same name and end")
+		return
+	}
+	mate := mateKey{name: r.Name, end: end ^ (sam.Read1 | sam.Read2)}
+	if end == 0 || end == sam.Read1|sam.Read2 {
+		// Records that are neither or both
+		// first and last are matched with
+		// records of the same end.
+		mate.end = end
+	}
+	m, ok := v.pending[mate]
+	if !ok {
+		v.pending[self] = info
+		return
+	}
+	delete(v.pending, mate)
+	v.compareMates(r.Name, info, m)
+	v.compareMates(r.Name, m, info)
+}
+
+// compareMates checks the mate fields of a against the fields of its
+// mate b.
+func (v *Validator) compareMates(name string, a, b mateInfo) {
+	if a.flags&sam.MateUnmapped != 0 != (b.flags&sam.Unmapped != 0) {
+		v.issue(MismatchMateUnmapped, name, a.record, "mate unmapped flag does not match unmapped flag of mate")
+	}
+	if a.mateRef != b.ref {
+		v.issue(MismatchMateRef, name, a.record, "mate reference %d does not match reference %d of mate", a.mateRef, b.ref)
+	}
+	if a.matePos != b.pos {
+		v.issue(MismatchMateStart, name, a.record, "mate start %d does not match start %d of mate", a.matePos+1, b.pos+1)
+	}
+	if a.flags&sam.MateReverse != 0 != (b.flags&sam.Reverse != 0) {
+		v.issue(MismatchMateNegStrand, name, a.record, "mate negative strand flag does not match read negative strand flag of mate")
+	}
+}
+
+// issue records an issue of kind k for the named record with the given
+// index.
+func (v *Validator) issue(k Kind, name string, record int64, format string, args ...interface{}) {
+	s := v.severity[k]
+	if s == Ignore {
+		return
+	}
+	v.report.Counts[k]++
+	if v.max >= 0 && len(v.report.Issues) >= v.max {
+		v.report.Truncated = true
+		return
+	}
+	v.report.Issues = append(v.report.Issues, Issue{
+		Kind:     k,
+		Severity: s,
+		Name:     name,
+		Record:   record,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Finish reports the primary paired records whose mates were not found
+// and returns the Report of the validation. Records must not be added
+// after Finish is called.
+func (v *Validator) Finish() *Report {
+	keys := make([]mateKey, 0, len(v.pending))
+	for k := range v.pending {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return v.pending[keys[i]].record < v.pending[keys[j]].record })
+	for _, k := range keys {
+		v.issue(MateNotFound, k.name, -1, "mate not found for paired read")
+	}
+	v.pending = nil
+	r := v.report
+	r.severity = v.severity
+	return &r
+}
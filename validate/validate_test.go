@@ -0,0 +1,183 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package validate
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Schaudge/hts/bam"
+	"github.com/Schaudge/hts/htstestutil"
+	"github.com/Schaudge/hts/sam"
+)
+
+func validateBAM(t *testing.T, b *htstestutil.BAMBuilder, opts Options) *Report {
+	t.Helper()
+	data, err := b.Build()
+	if err != nil {
+		t.Fatalf("unexpected error building BAM: %v", err)
+	}
+	br, err := bam.NewReader(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	defer br.Close()
+	rep, err := Validate(br, opts)
+	if err != nil {
+		t.Fatalf("unexpected error validating: %v", err)
+	}
+	return rep
+}
+
+func TestValidateClean(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().
+		Ref("chr1", 1000).
+		ReadGroup("g0", "s0").
+		SortOrder(sam.Coordinate)
+	b.Record("p0", "chr1", 10, "4M", "ACGT").Flags(sam.Paired|sam.Read1|sam.MateReverse).Mate("=", 100, 94).Tag("RG", "g0").
+		Record("p0", "chr1", 100, "4M", "ACGT").Flags(sam.Paired|sam.Read2|sam.Reverse).Mate("=", 10, -94).Tag("RG", "g0").
+		Record("u0", "*", -1, "*", "ACGT").Flags(sam.Unmapped).Tag("RG", "g0")
+	rep := validateBAM(t, b, Options{})
+	if len(rep.Issues) != 0 {
+		t.Errorf("unexpected issues: %v", rep.Issues)
+	}
+	if rep.Records != 3 {
+		t.Errorf("unexpected record count: got:%d want:3", rep.Records)
+	}
+	var buf bytes.Buffer
+	err := rep.WriteSummary(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing summary: %v", err)
+	}
+	if got, want := buf.String(), "No errors found\n"; got != want {
+		t.Errorf("unexpected summary: got:%q want:%q", got, want)
+	}
+}
+
+func TestValidateIssues(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().
+		Ref("chr1", 1000).
+		ReadGroup("g0", "s0").
+		SortOrder(sam.Coordinate)
+	b.Record("p0", "chr1", 100, "4M", "ACGT").Flags(sam.Paired|sam.Read1).Mate("=", 50, 0).Tag("RG", "g0").
+		Record("p0", "chr1", 10, "4M", "ACGT").Flags(sam.Paired|sam.Read2).Mate("=", 100, 0).Tag("RG", "g0").
+		Record("p1", "chr1", 20, "4M", "ACGT").Flags(sam.Paired|sam.Read1).Mate("=", 30, 0).
+		Record("p2", "chr1", 30, "4M", "ACGT").Flags(sam.Paired|sam.Read1).Mate("=", 30, 0).Tag("RG", "g1").
+		Record("p2", "chr1", 30, "4M", "ACGT").Flags(sam.Paired|sam.Read1).Mate("=", 30, 0).Tag("RG", "g0")
+	rep := validateBAM(t, b, Options{MaxIssues: -1})
+
+	want := map[Kind]int64{
+		OutOfOrder:        1,
+		MismatchMateStart: 1,
+		MissingReadGroup:  1,
+		ReadGroupNotFound: 1,
+		MatesAreSameEnd:   1,
+		MateNotFound:      2,
+	}
+	if !reflect.DeepEqual(rep.Counts, want) {
+		t.Errorf("unexpected counts:\ngot: %v\nwant:%v", rep.Counts, want)
+	}
+	if rep.Warnings() != 1 {
+		t.Errorf("unexpected warning count: got:%d want:1", rep.Warnings())
+	}
+	if rep.Errors() != 6 {
+		t.Errorf("unexpected error count: got:%d want:6", rep.Errors())
+	}
+	for _, i := range rep.Issues {
+		if i.Kind == MismatchMateStart && (i.Name != "p0" || i.Record != 0) {
+			t.Errorf("unexpected mate start issue: %v", i)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := rep.WriteSummary(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing summary: %v", err)
+	}
+	if !strings.Contains(buf.String(), "ERROR:MATE_NOT_FOUND\t2\n") {
+		t.Errorf("unexpected summary:\n%s", buf.String())
+	}
+}
+
+func TestValidatorRecords(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().
+		Ref("chr1", 1000)
+	b.Record("r0", "chr1", 10, "4M", "ACGT").Qual("IIII").
+		Record("r1", "chr1", 20, "4M", "ACGT").
+		Record("r2", "chr1", 30, "4M", "ACGT")
+	h, err := b.Header()
+	if err != nil {
+		t.Fatalf("unexpected error building header: %v", err)
+	}
+	recs, err := b.Records(h)
+	if err != nil {
+		t.Fatalf("unexpected error building records: %v", err)
+	}
+	recs[0].Qual = recs[0].Qual[:2]
+	recs[1].Cigar = sam.Cigar{sam.NewCigarOp(sam.CigarMatch, 5)}
+	recs[2].Pos = 1000
+
+	v := NewValidator(h, Options{})
+	for i, r := range recs {
+		v.Add(r)
+		v.CheckBin(r, int(uint16(r.Bin())))
+		if i == 1 {
+			v.CheckBin(r, 0)
+		}
+	}
+	rep := v.Finish()
+	want := []Kind{QualLengthMismatch, InvalidCigar, InvalidBin, InvalidAlignmentStart}
+	var got []Kind
+	for _, i := range rep.Issues {
+		got = append(got, i.Kind)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected issues:\ngot: %v\nwant:%v", got, want)
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	b := htstestutil.NewBAMBuilder().
+		Ref("chr1", 1000).
+		SortOrder(sam.Coordinate)
+	b.Record("r0", "chr1", 30, "4M", "ACGT").
+		Record("r1", "chr1", 20, "4M", "ACGT").
+		Record("r2", "chr1", 10, "4M", "ACGT").
+		Record("r3", "chr1", 10, "4M", "ACGT").Flags(sam.Paired | sam.Read1)
+	rep := validateBAM(t, b, Options{
+		Severity:  map[Kind]Severity{OutOfOrder: Warning, MateNotFound: Ignore},
+		MaxIssues: 1,
+	})
+	if len(rep.Issues) != 1 || !rep.Truncated {
+		t.Errorf("unexpected truncation: issues:%d truncated:%t", len(rep.Issues), rep.Truncated)
+	}
+	if got := rep.Counts[OutOfOrder]; got != 2 {
+		t.Errorf("unexpected out of order count: got:%d want:2", got)
+	}
+	if _, ok := rep.Counts[MateNotFound]; ok {
+		t.Error("unexpected count of ignored kind")
+	}
+	if rep.Warnings() != 2 || rep.Errors() != 0 {
+		t.Errorf("unexpected severity counts: warnings:%d errors:%d", rep.Warnings(), rep.Errors())
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for _, k := range Kinds() {
+		got, err := ParseKind(k.String())
+		if err != nil {
+			t.Errorf("unexpected error parsing %v: %v", k, err)
+		}
+		if got != k {
+			t.Errorf("unexpected kind: got:%v want:%v", got, k)
+		}
+	}
+	_, err := ParseKind("NOT_A_KIND")
+	if err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
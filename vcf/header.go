@@ -0,0 +1,520 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vcf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// errMalformed is wrapped with the line number by Header.UnmarshalText.
+var errMalformed = errors.New("malformed line")
+
+// DefaultVersion is the file format version written for headers without
+// a version.
+const DefaultVersion = "VCFv4.3"
+
+// Type is the type of an INFO or FORMAT field value.
+type Type byte
+
+const (
+	String Type = iota
+	Integer
+	Float
+	Flag
+	Character
+)
+
+var typeNames = [...]string{
+	String:    "String",
+	Integer:   "Integer",
+	Float:     "Float",
+	Flag:      "Flag",
+	Character: "Character",
+}
+
+// String returns the VCF name of the type.
+func (t Type) String() string {
+	if int(t) >= len(typeNames) {
+		return fmt.Sprintf("Type(%d)", t)
+	}
+	return typeNames[t]
+}
+
+func parseType(s string) (Type, error) {
+	for t, n := range typeNames {
+		if n == s {
+			return Type(t), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown type: %q", s)
+}
+
+// Special values of the Number of an INFO or FORMAT field.
+const (
+	// NumberA indicates one value per alternate allele.
+	NumberA = -1 - iota
+
+	// NumberR indicates one value per allele,
+	// including the reference.
+	NumberR
+
+	// NumberG indicates one value per genotype.
+	NumberG
+
+	// NumberUnknown indicates an unknown or
+	// unbounded number of values.
+	NumberUnknown
+)
+
+func formatNumber(n int) string {
+	switch n {
+	case NumberA:
+		return "A"
+	case NumberR:
+		return "R"
+	case NumberG:
+		return "G"
+	case NumberUnknown:
+		return "."
+	}
+	return strconv.Itoa(n)
+}
+
+func parseNumber(s string) (int, error) {
+	switch s {
+	case "A":
+		return NumberA, nil
+	case "R":
+		return NumberR, nil
+	case "G":
+		return NumberG, nil
+	case ".":
+		return NumberUnknown, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number: %q", s)
+	}
+	return n, nil
+}
+
+// Attr is a key-value attribute of a structured header line.
+type Attr struct {
+	Key, Value string
+}
+
+// Definition is the definition of an INFO or FORMAT field.
+type Definition struct {
+	ID          string
+	Number      int
+	Type        Type
+	Description string
+
+	// Extra holds any additional attributes
+	// of the definition, in order.
+	Extra []Attr
+}
+
+// Filter is the definition of a FILTER value.
+type Filter struct {
+	ID          string
+	Description string
+	Extra       []Attr
+}
+
+// Contig is a reference sequence described by the header.
+type Contig struct {
+	ID string
+
+	// Length is the length of the
+	// contig, or -1 if unknown.
+	Length int
+
+	Extra []Attr
+}
+
+// Meta is a header meta-information line that is not a fileformat,
+// INFO, FORMAT, FILTER or contig line. Structured values, such as the
+// values of ALT lines, are held in their text form including the
+// enclosing angle brackets.
+type Meta struct {
+	Key, Value string
+}
+
+// Header is a VCF header.
+type Header struct {
+	// Version is the file format version,
+	// for example "VCFv4.3".
+	Version string
+
+	Infos   []*Definition
+	Formats []*Definition
+	Filters []*Filter
+	Contigs []*Contig
+	Meta    []Meta
+
+	// Samples holds the names of the samples
+	// in the order of the sample columns.
+	Samples []string
+}
+
+// Info returns the INFO definition with the given ID, or nil.
+func (h *Header) Info(id string) *Definition { return findDefinition(h.Infos, id) }
+
+// Format returns the FORMAT definition with the given ID, or nil.
+func (h *Header) Format(id string) *Definition { return findDefinition(h.Formats, id) }
+
+func findDefinition(defs []*Definition, id string) *Definition {
+	for _, d := range defs {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// Filter returns the FILTER definition with the given ID, or nil.
+func (h *Header) Filter(id string) *Filter {
+	for _, f := range h.Filters {
+		if f.ID == id {
+			return f
+		}
+	}
+	return nil
+}
+
+// Contig returns the contig with the given ID, or nil.
+func (h *Header) Contig(id string) *Contig {
+	for _, c := range h.Contigs {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// columns are the fixed columns of the VCF header line.
+var columns = []string{"#CHROM", "POS", "ID", "REF", "ALT", "QUAL", "FILTER", "INFO"}
+
+// UnmarshalText parses the VCF header text in text, including the
+// column header line, into the receiver.
+func (h *Header) UnmarshalText(text []byte) error {
+	*h = Header{}
+	sc := bufio.NewScanner(bytes.NewReader(text))
+	sc.Buffer(nil, len(text)+1)
+	seenColumns := false
+	for n := 1; sc.Scan(); n++ {
+		l := strings.TrimSuffix(sc.Text(), "\r")
+		if l == "" {
+			continue
+		}
+		if seenColumns {
+			return fmt.Errorf("vcf: unexpected header line %d after column header", n)
+		}
+		var err error
+		switch {
+		case strings.HasPrefix(l, "##"):
+			err = h.metaLine(l[2:])
+		case strings.HasPrefix(l, "#"):
+			err = h.columnLine(l)
+			seenColumns = true
+		default:
+			err = errMalformed
+		}
+		if err != nil {
+			return fmt.Errorf("vcf: header line %d: %v", n, err)
+		}
+	}
+	err := sc.Err()
+	if err != nil {
+		return err
+	}
+	if h.Version == "" {
+		return ErrNoFileFormat
+	}
+	if !seenColumns {
+		return ErrNoColumnHeader
+	}
+	return nil
+}
+
+// metaLine parses the meta-information line l with the leading "##"
+// removed.
+func (h *Header) metaLine(l string) error {
+	eq := strings.IndexByte(l, '=')
+	if eq < 1 {
+		return errMalformed
+	}
+	key, value := l[:eq], l[eq+1:]
+	switch key {
+	case "fileformat":
+		if h.Version != "" {
+			return errors.New("duplicate fileformat line")
+		}
+		h.Version = value
+		return nil
+	case "INFO", "FORMAT", "FILTER", "contig":
+	default:
+		h.Meta = append(h.Meta, Meta{Key: key, Value: value})
+		return nil
+	}
+
+	attrs, err := parseAttrs(value)
+	if err != nil {
+		return err
+	}
+	get := func(k string) (string, bool) {
+		for i, a := range attrs {
+			if a.Key == k {
+				attrs = append(attrs[:i:i], attrs[i+1:]...)
+				return a.Value, true
+			}
+		}
+		return "", false
+	}
+	rest := func() []Attr {
+		if len(attrs) == 0 {
+			return nil
+		}
+		return attrs
+	}
+	id, ok := get("ID")
+	if !ok || id == "" {
+		return fmt.Errorf("missing %s ID", key)
+	}
+
+	switch key {
+	case "INFO", "FORMAT":
+		d := Definition{ID: id}
+		num, ok := get("Number")
+		if !ok {
+			return fmt.Errorf("missing %s Number", key)
+		}
+		d.Number, err = parseNumber(num)
+		if err != nil {
+			return err
+		}
+		typ, ok := get("Type")
+		if !ok {
+			return fmt.Errorf("missing %s Type", key)
+		}
+		d.Type, err = parseType(typ)
+		if err != nil {
+			return err
+		}
+		d.Description, _ = get("Description")
+		d.Extra = rest()
+		if key == "INFO" {
+			if h.Info(id) != nil {
+				return fmt.Errorf("duplicate INFO ID: %q", id)
+			}
+			h.Infos = append(h.Infos, &d)
+		} else {
+			if h.Format(id) != nil {
+				return fmt.Errorf("duplicate FORMAT ID: %q", id)
+			}
+			h.Formats = append(h.Formats, &d)
+		}
+	case "FILTER":
+		if h.Filter(id) != nil {
+			return fmt.Errorf("duplicate FILTER ID: %q", id)
+		}
+		f := Filter{ID: id}
+		f.Description, _ = get("Description")
+		f.Extra = rest()
+		h.Filters = append(h.Filters, &f)
+	case "contig":
+		if h.Contig(id) != nil {
+			return fmt.Errorf("duplicate contig ID: %q", id)
+		}
+		c := Contig{ID: id, Length: -1}
+		if length, ok := get("length"); ok {
+			c.Length, err = strconv.Atoi(length)
+			if err != nil || c.Length < 0 {
+				return fmt.Errorf("invalid contig length: %q", length)
+			}
+		}
+		c.Extra = rest()
+		h.Contigs = append(h.Contigs, &c)
+	}
+	return nil
+}
+
+// parseAttrs parses the structured value s, which must be enclosed in
+// angle brackets.
+func parseAttrs(s string) ([]Attr, error) {
+	if len(s) < 2 || s[0] != '<' || s[len(s)-1] != '>' {
+		return nil, errMalformed
+	}
+	s = s[1 : len(s)-1]
+	var attrs []Attr
+	for len(s) != 0 {
+		eq := strings.IndexByte(s, '=')
+		if eq < 1 {
+			return nil, errMalformed
+		}
+		a := Attr{Key: s[:eq]}
+		s = s[eq+1:]
+		if strings.HasPrefix(s, `"`) {
+			var (
+				b   strings.Builder
+				end = -1
+			)
+			for i := 1; i < len(s); i++ {
+				switch s[i] {
+				case '\\':
+					i++
+					if i < len(s) {
+						b.WriteByte(s[i])
+					}
+					continue
+				case '"':
+					end = i
+				}
+				if end >= 0 {
+					break
+				}
+				b.WriteByte(s[i])
+			}
+			if end < 0 {
+				return nil, errors.New("unterminated quoted value")
+			}
+			a.Value = b.String()
+			s = s[end+1:]
+		} else {
+			i := strings.IndexByte(s, ',')
+			if i < 0 {
+				i = len(s)
+			}
+			a.Value = s[:i]
+			s = s[i:]
+		}
+		if s != "" {
+			if s[0] != ',' {
+				return nil, errMalformed
+			}
+			s = s[1:]
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs, nil
+}
+
+// columnLine parses the column header line l.
+func (h *Header) columnLine(l string) error {
+	f := strings.Split(l, "\t")
+	if len(f) < len(columns) {
+		return errMalformed
+	}
+	for i, c := range columns {
+		if f[i] != c {
+			return fmt.Errorf("unexpected column %q", f[i])
+		}
+	}
+	if len(f) == len(columns) {
+		return nil
+	}
+	if f[len(columns)] != "FORMAT" {
+		return fmt.Errorf("unexpected column %q", f[len(columns)])
+	}
+	h.Samples = append([]string(nil), f[len(columns)+1:]...)
+	return nil
+}
+
+// MarshalText returns the text of the header, including the column
+// header line. Meta-information lines are written in the order
+// fileformat, other meta lines, FILTER, INFO, FORMAT and contig.
+func (h *Header) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	version := h.Version
+	if version == "" {
+		version = DefaultVersion
+	}
+	fmt.Fprintf(&buf, "##fileformat=%s\n", version)
+	for _, m := range h.Meta {
+		if strings.ContainsAny(m.Key, "=\n") || strings.Contains(m.Value, "\n") {
+			return nil, fmt.Errorf("vcf: invalid meta line: %s=%s", m.Key, m.Value)
+		}
+		fmt.Fprintf(&buf, "##%s=%s\n", m.Key, m.Value)
+	}
+	for _, f := range h.Filters {
+		writeStructured(&buf, "FILTER", []Attr{
+			{"ID", f.ID},
+			{"Description", f.Description},
+		}, f.Extra)
+	}
+	for _, d := range h.Infos {
+		writeDefinition(&buf, "INFO", d)
+	}
+	for _, d := range h.Formats {
+		writeDefinition(&buf, "FORMAT", d)
+	}
+	for _, c := range h.Contigs {
+		attrs := []Attr{{"ID", c.ID}}
+		if c.Length >= 0 {
+			attrs = append(attrs, Attr{"length", strconv.Itoa(c.Length)})
+		}
+		writeStructured(&buf, "contig", attrs, c.Extra)
+	}
+	buf.WriteString(strings.Join(columns, "\t"))
+	if len(h.Samples) != 0 {
+		buf.WriteString("\tFORMAT")
+		for _, s := range h.Samples {
+			buf.WriteByte('\t')
+			buf.WriteString(s)
+		}
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeDefinition(buf *bytes.Buffer, key string, d *Definition) {
+	writeStructured(buf, key, []Attr{
+		{"ID", d.ID},
+		{"Number", formatNumber(d.Number)},
+		{"Type", d.Type.String()},
+		{"Description", d.Description},
+	}, d.Extra)
+}
+
+// writeStructured writes a structured meta-information line holding
+// the given attributes.
+func writeStructured(buf *bytes.Buffer, key string, attrs, extra []Attr) {
+	fmt.Fprintf(buf, "##%s=<", key)
+	for i, a := range append(attrs, extra...) {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(a.Key)
+		buf.WriteByte('=')
+		if needsQuote(a) {
+			buf.WriteByte('"')
+			for j := 0; j < len(a.Value); j++ {
+				if c := a.Value[j]; c == '"' || c == '\\' {
+					buf.WriteByte('\\')
+				}
+				buf.WriteByte(a.Value[j])
+			}
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(a.Value)
+		}
+	}
+	buf.WriteString(">\n")
+}
+
+// needsQuote returns whether the value of a must be quoted.
+func needsQuote(a Attr) bool {
+	switch a.Key {
+	case "Description", "Source", "Version":
+		return true
+	}
+	return a.Value == "" || strings.ContainsAny(a.Value, ",\"<>= \t\\")
+}
@@ -0,0 +1,528 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vcf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MissingInt is the value of missing elements of integer lists.
+const MissingInt = math.MinInt32
+
+// Info is an INFO field of a Record.
+type Info struct {
+	Key   string
+	Value interface{}
+}
+
+// Record is a VCF record.
+type Record struct {
+	Chrom string
+
+	// Pos is the zero-based position
+	// of the record.
+	Pos int
+
+	ID  []string
+	Ref string
+	Alt []string
+
+	// Qual is the quality of the
+	// record, or NaN if missing.
+	Qual float64
+
+	// Filter holds the filters that the record
+	// has failed, or "PASS". A nil Filter
+	// indicates that filters were not applied.
+	Filter []string
+
+	Info []Info
+
+	// Format holds the keys of the sample
+	// fields of the record and Samples holds
+	// the values for each sample in the order
+	// of Format. Trailing values of a sample
+	// may be omitted.
+	Format  []string
+	Samples [][]interface{}
+}
+
+// UnmarshalVCF parses the VCF line b into the receiver, using h to
+// determine the types of INFO and FORMAT values.
+func (r *Record) UnmarshalVCF(h *Header, b []byte) error {
+	f := strings.Split(string(b), "\t")
+	if len(f) < len(columns) {
+		return errors.New("vcf: missing VCF fields")
+	}
+	*r = Record{Chrom: f[0]}
+	var err error
+	r.Pos, err = strconv.Atoi(f[1])
+	if err != nil {
+		return fmt.Errorf("vcf: failed to parse position: %v", err)
+	}
+	r.Pos--
+	r.ID = splitMissing(f[2], ";")
+	r.Ref = f[3]
+	r.Alt = splitMissing(f[4], ",")
+	r.Qual = math.NaN()
+	if f[5] != "." {
+		r.Qual, err = strconv.ParseFloat(f[5], 64)
+		if err != nil {
+			return fmt.Errorf("vcf: failed to parse quality: %v", err)
+		}
+	}
+	r.Filter = splitMissing(f[6], ";")
+	if f[7] != "." {
+		for _, field := range strings.Split(f[7], ";") {
+			key, value, ok := strings.Cut(field, "=")
+			var v interface{} = true
+			if ok {
+				v, err = parseValue(h.Info(key), value)
+				if err != nil {
+					return fmt.Errorf("vcf: failed to parse INFO %s: %v", key, err)
+				}
+			}
+			r.Info = append(r.Info, Info{Key: key, Value: v})
+		}
+	}
+	if len(f) == len(columns) {
+		return nil
+	}
+
+	r.Format = strings.Split(f[8], ":")
+	samples := f[9:]
+	if len(samples) != len(h.Samples) {
+		return fmt.Errorf("vcf: record has %d samples, header has %d", len(samples), len(h.Samples))
+	}
+	defs := make([]*Definition, len(r.Format))
+	for i, key := range r.Format {
+		defs[i] = h.Format(key)
+	}
+	r.Samples = make([][]interface{}, len(samples))
+	for i, s := range samples {
+		values := strings.Split(s, ":")
+		if len(values) > len(r.Format) {
+			return fmt.Errorf("vcf: sample %d has more values than FORMAT keys", i+1)
+		}
+		r.Samples[i] = make([]interface{}, len(values))
+		for j, value := range values {
+			r.Samples[i][j], err = parseValue(defs[j], value)
+			if err != nil {
+				return fmt.Errorf("vcf: failed to parse sample %d %s: %v", i+1, r.Format[j], err)
+			}
+		}
+	}
+	return nil
+}
+
+// splitMissing splits s by sep, returning nil if s is ".".
+func splitMissing(s, sep string) []string {
+	if s == "." {
+		return nil
+	}
+	return strings.Split(s, sep)
+}
+
+// parseValue parses the INFO or FORMAT value s using the definition d.
+// If d is nil, the value is held as a string.
+func parseValue(d *Definition, s string) (interface{}, error) {
+	if s == "." {
+		return nil, nil
+	}
+	if d == nil {
+		return s, nil
+	}
+	if d.Number == 1 || d.Type == Flag {
+		return parseElement(d.Type, s)
+	}
+	parts := strings.Split(s, ",")
+	switch d.Type {
+	case Integer:
+		v := make([]int, len(parts))
+		for i, p := range parts {
+			if p == "." {
+				v[i] = MissingInt
+				continue
+			}
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = n
+		}
+		return v, nil
+	case Float:
+		v := make([]float64, len(parts))
+		for i, p := range parts {
+			if p == "." {
+				v[i] = math.NaN()
+				continue
+			}
+			f, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = f
+		}
+		return v, nil
+	}
+	return parts, nil
+}
+
+// parseElement parses a single value of type t.
+func parseElement(t Type, s string) (interface{}, error) {
+	switch t {
+	case Integer:
+		return strconv.Atoi(s)
+	case Float:
+		return strconv.ParseFloat(s, 64)
+	case Flag:
+		return true, nil
+	}
+	return s, nil
+}
+
+// MarshalText formats the Record as a VCF line without the trailing
+// newline.
+func (r *Record) MarshalText() ([]byte, error) {
+	if r.Chrom == "" || strings.ContainsAny(r.Chrom, "\t\n") {
+		return nil, fmt.Errorf("vcf: invalid chromosome name: %q", r.Chrom)
+	}
+	if r.Ref == "" {
+		return nil, errors.New("vcf: missing reference allele")
+	}
+	var buf bytes.Buffer
+	buf.WriteString(r.Chrom)
+	buf.WriteByte('\t')
+	buf.WriteString(strconv.Itoa(r.Pos + 1))
+	buf.WriteByte('\t')
+	writeList(&buf, r.ID, ";")
+	buf.WriteByte('\t')
+	buf.WriteString(r.Ref)
+	buf.WriteByte('\t')
+	writeList(&buf, r.Alt, ",")
+	buf.WriteByte('\t')
+	formatFloat(&buf, r.Qual)
+	buf.WriteByte('\t')
+	writeList(&buf, r.Filter, ";")
+	buf.WriteByte('\t')
+	if len(r.Info) == 0 {
+		buf.WriteByte('.')
+	}
+	for i, f := range r.Info {
+		if i != 0 {
+			buf.WriteByte(';')
+		}
+		buf.WriteString(f.Key)
+		if f.Value == true {
+			continue
+		}
+		buf.WriteByte('=')
+		err := formatValue(&buf, f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("vcf: INFO %s: %v", f.Key, err)
+		}
+	}
+	if len(r.Format) == 0 {
+		if len(r.Samples) != 0 {
+			return nil, errors.New("vcf: sample values without FORMAT keys")
+		}
+		return buf.Bytes(), nil
+	}
+	buf.WriteByte('\t')
+	buf.WriteString(strings.Join(r.Format, ":"))
+	for i, s := range r.Samples {
+		if len(s) > len(r.Format) {
+			return nil, fmt.Errorf("vcf: sample %d has more values than FORMAT keys", i+1)
+		}
+		buf.WriteByte('\t')
+		if len(s) == 0 {
+			buf.WriteByte('.')
+		}
+		for j, v := range s {
+			if j != 0 {
+				buf.WriteByte(':')
+			}
+			err := formatValue(&buf, v)
+			if err != nil {
+				return nil, fmt.Errorf("vcf: sample %d %s: %v", i+1, r.Format[j], err)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeList writes the elements of s separated by sep, or "." if s
+// is empty.
+func writeList(buf *bytes.Buffer, s []string, sep string) {
+	if len(s) == 0 {
+		buf.WriteByte('.')
+		return
+	}
+	buf.WriteString(strings.Join(s, sep))
+}
+
+func formatFloat(buf *bytes.Buffer, f float64) {
+	if math.IsNaN(f) {
+		buf.WriteByte('.')
+		return
+	}
+	buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func formatInt(buf *bytes.Buffer, n int) {
+	if n == MissingInt {
+		buf.WriteByte('.')
+		return
+	}
+	buf.WriteString(strconv.Itoa(n))
+}
+
+func formatString(buf *bytes.Buffer, s string) {
+	if s == "" {
+		s = "."
+	}
+	buf.WriteString(s)
+}
+
+// formatValue writes the INFO or FORMAT value v.
+func formatValue(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte('.')
+	case int:
+		formatInt(buf, v)
+	case float64:
+		formatFloat(buf, v)
+	case string:
+		formatString(buf, v)
+	case []int:
+		for i, e := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			formatInt(buf, e)
+		}
+	case []float64:
+		for i, e := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			formatFloat(buf, e)
+		}
+	case []string:
+		for i, e := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			formatString(buf, e)
+		}
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+	return nil
+}
+
+// End returns the zero-based, half-open end of the record. The END
+// INFO field is used if it is present.
+func (r *Record) End() int {
+	if end, ok := r.InfoInt("END"); ok {
+		return end
+	}
+	return r.Pos + len(r.Ref)
+}
+
+// InfoValue returns the value of the INFO field with the given key and
+// whether the field is present.
+func (r *Record) InfoValue(key string) (interface{}, bool) {
+	for _, f := range r.Info {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// SetInfo sets the value of the INFO field with the given key, adding
+// the field if it is not present.
+func (r *Record) SetInfo(key string, v interface{}) {
+	for i, f := range r.Info {
+		if f.Key == key {
+			r.Info[i].Value = v
+			return
+		}
+	}
+	r.Info = append(r.Info, Info{Key: key, Value: v})
+}
+
+// InfoFlag returns whether the INFO flag with the given key is set.
+func (r *Record) InfoFlag(key string) bool {
+	v, _ := r.InfoValue(key)
+	return v == true
+}
+
+// InfoInt returns the integer value of the INFO field with the given
+// key. It returns false if the field is absent, missing or not a single
+// integer.
+func (r *Record) InfoInt(key string) (int, bool) {
+	v, _ := r.InfoValue(key)
+	n, ok := v.(int)
+	return n, ok
+}
+
+// InfoFloat returns the floating point value of the INFO field with the
+// given key. It returns false if the field is absent, missing or not a
+// single floating point value.
+func (r *Record) InfoFloat(key string) (float64, bool) {
+	v, _ := r.InfoValue(key)
+	f, ok := v.(float64)
+	return f, ok
+}
+
+// InfoString returns the string value of the INFO field with the given
+// key. It returns false if the field is absent, missing or not a single
+// string.
+func (r *Record) InfoString(key string) (string, bool) {
+	v, _ := r.InfoValue(key)
+	s, ok := v.(string)
+	return s, ok
+}
+
+// InfoInts returns the integer values of the INFO field with the given
+// key. A single integer value is returned as a slice of length one.
+func (r *Record) InfoInts(key string) ([]int, bool) {
+	switch v, _ := r.InfoValue(key); v := v.(type) {
+	case int:
+		return []int{v}, true
+	case []int:
+		return v, true
+	}
+	return nil, false
+}
+
+// InfoFloats returns the floating point values of the INFO field with
+// the given key. A single value is returned as a slice of length one.
+func (r *Record) InfoFloats(key string) ([]float64, bool) {
+	switch v, _ := r.InfoValue(key); v := v.(type) {
+	case float64:
+		return []float64{v}, true
+	case []float64:
+		return v, true
+	}
+	return nil, false
+}
+
+// InfoStrings returns the string values of the INFO field with the
+// given key. A single value is returned as a slice of length one.
+func (r *Record) InfoStrings(key string) ([]string, bool) {
+	switch v, _ := r.InfoValue(key); v := v.(type) {
+	case string:
+		return []string{v}, true
+	case []string:
+		return v, true
+	}
+	return nil, false
+}
+
+// SampleValue returns the value of the FORMAT field with the given key
+// for the sample with index i, and whether the value is present.
+func (r *Record) SampleValue(i int, key string) (interface{}, bool) {
+	if i < 0 || i >= len(r.Samples) {
+		return nil, false
+	}
+	for j, k := range r.Format {
+		if k == key {
+			if j >= len(r.Samples[i]) {
+				return nil, false
+			}
+			return r.Samples[i][j], true
+		}
+	}
+	return nil, false
+}
+
+// Genotype returns the parsed GT value of the sample with index i. It
+// returns false if the sample has no valid genotype.
+func (r *Record) Genotype(i int) (Genotype, bool) {
+	v, _ := r.SampleValue(i, "GT")
+	s, ok := v.(string)
+	if !ok {
+		return Genotype{}, false
+	}
+	g, err := ParseGenotype(s)
+	return g, err == nil
+}
+
+// Genotype is a sample genotype.
+type Genotype struct {
+	// Alleles holds the allele index of each
+	// haplotype, or -1 for missing alleles.
+	Alleles []int
+
+	// Phased indicates whether each allele is
+	// phased with respect to the preceding
+	// allele. Phased[0] is true only if the
+	// genotype has an explicit leading phasing
+	// indicator.
+	Phased []bool
+}
+
+// ParseGenotype parses the GT value s.
+func ParseGenotype(s string) (Genotype, error) {
+	var g Genotype
+	phased := false
+	switch {
+	case strings.HasPrefix(s, "|"):
+		phased = true
+		s = s[1:]
+	case strings.HasPrefix(s, "/"):
+		s = s[1:]
+	}
+	for {
+		i := strings.IndexAny(s, "/|")
+		if i < 0 {
+			i = len(s)
+		}
+		a := -1
+		if s[:i] != "." {
+			var err error
+			a, err = strconv.Atoi(s[:i])
+			if err != nil || a < 0 {
+				return Genotype{}, fmt.Errorf("vcf: invalid genotype allele: %q", s[:i])
+			}
+		}
+		g.Alleles = append(g.Alleles, a)
+		g.Phased = append(g.Phased, phased)
+		if i == len(s) {
+			return g, nil
+		}
+		phased = s[i] == '|'
+		s = s[i+1:]
+	}
+}
+
+// String returns the GT text of the genotype.
+func (g Genotype) String() string {
+	var buf strings.Builder
+	for i, a := range g.Alleles {
+		switch {
+		case i < len(g.Phased) && g.Phased[i]:
+			buf.WriteByte('|')
+		case i != 0:
+			buf.WriteByte('/')
+		}
+		if a < 0 {
+			buf.WriteByte('.')
+		} else {
+			buf.WriteString(strconv.Itoa(a))
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,134 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vcf implements VCF file format reading and writing. The VCF
+// format is described in the VCF specification.
+//
+// https://samtools.github.io/hts-specs/VCFv4.3.pdf
+//
+// INFO and FORMAT values are typed according to the header definitions.
+// Values of fields with a Number of 1 are held as int, float64 or string
+// values, and values of other fields are held as []int, []float64 or
+// []string. Flag values are held as true. Fields without a header
+// definition are held as strings. A missing value is held as nil, and
+// missing elements of a list are held as MissingInt, NaN or ".".
+package vcf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrNoFileFormat   = errors.New("vcf: missing fileformat line")
+	ErrNoColumnHeader = errors.New("vcf: missing column header line")
+)
+
+// Reader implements VCF format reading.
+type Reader struct {
+	r *bufio.Reader
+	h *Header
+
+	line int
+}
+
+// NewReader returns a new Reader, reading from the given io.Reader.
+// The VCF header is read from r during construction.
+func NewReader(r io.Reader) (*Reader, error) {
+	vr := &Reader{r: bufio.NewReader(r), h: &Header{}}
+	var b []byte
+	for {
+		p, err := vr.r.Peek(1)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p[0] != '#' {
+			break
+		}
+		l, err := vr.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		vr.line++
+		b = append(b, l...)
+		if !bytes.HasPrefix(l, []byte("##")) {
+			break
+		}
+	}
+	err := vr.h.UnmarshalText(b)
+	if err != nil {
+		return nil, err
+	}
+	return vr, nil
+}
+
+// Header returns the VCF Header held by the Reader.
+func (r *Reader) Header() *Header { return r.h }
+
+// Read returns the next Record in the VCF stream.
+func (r *Reader) Read() (*Record, error) {
+	for {
+		b, err := r.r.ReadBytes('\n')
+		if len(b) == 0 {
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		r.line++
+		b = bytes.TrimSuffix(b, []byte{'\n'})
+		b = bytes.TrimSuffix(b, []byte{'\r'})
+		if len(b) == 0 {
+			continue
+		}
+		var rec Record
+		err = rec.UnmarshalVCF(r.h, b)
+		if err != nil {
+			return nil, fmt.Errorf("%v at line %d", err, r.line)
+		}
+		return &rec, nil
+	}
+}
+
+// Writer implements VCF format writing.
+type Writer struct {
+	w io.Writer
+	h *Header
+}
+
+// NewWriter returns a Writer to the given io.Writer using h for the
+// VCF header.
+func NewWriter(w io.Writer, h *Header) (*Writer, error) {
+	text, err := h.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, h: h}, nil
+}
+
+// Write writes r to the VCF stream.
+func (w *Writer) Write(r *Record) error {
+	if len(r.Samples) != len(w.h.Samples) {
+		return fmt.Errorf("vcf: record has %d samples, header has %d", len(r.Samples), len(w.h.Samples))
+	}
+	b, err := r.MarshalText()
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,338 @@
+// Copyright ©2026 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vcf
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testVCF = `##fileformat=VCFv4.3
+##fileDate=20090805
+##source=myImputationProgramV3.1
+##reference=file:///seq/references/1000GenomesPilot-NCBI36.fasta
+##contig=<ID=20,length=62435964,assembly=B36,md5=f126cdf8a6e0c7f379d618ff66beb2da,species="Homo sapiens",taxonomy=x>
+##phasing=partial
+##INFO=<ID=NS,Number=1,Type=Integer,Description="Number of Samples With Data">
+##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth">
+##INFO=<ID=AF,Number=A,Type=Float,Description="Allele Frequency">
+##INFO=<ID=AA,Number=1,Type=String,Description="Ancestral Allele">
+##INFO=<ID=DB,Number=0,Type=Flag,Description="dbSNP membership, build 129">
+##INFO=<ID=H2,Number=0,Type=Flag,Description="HapMap2 membership">
+##FILTER=<ID=q10,Description="Quality below 10">
+##FILTER=<ID=s50,Description="Less than 50% of samples have data">
+##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">
+##FORMAT=<ID=GQ,Number=1,Type=Integer,Description="Genotype Quality">
+##FORMAT=<ID=DP,Number=1,Type=Integer,Description="Read Depth">
+##FORMAT=<ID=HQ,Number=2,Type=Integer,Description="Haplotype Quality">
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	NA00001	NA00002	NA00003
+20	14370	rs6054257	G	A	29	PASS	NS=3;DP=14;AF=0.5;DB;H2	GT:GQ:DP:HQ	0|0:48:1:51,51	1|0:48:8:51,51	1/1:43:5:.,.
+20	17330	.	T	A	3	q10	NS=3;DP=11;AF=0.017	GT:GQ:DP:HQ	0|0:49:3:58,50	0|1:3:5:65,3	0/0:41:3
+20	1110696	rs6040355	A	G,T	67	PASS	NS=2;DP=10;AF=0.333,0.667;AA=T;DB	GT:GQ:DP:HQ	1|2:21:6:23,27	2|1:2:0:18,2	2/2:35:4
+20	1230237	.	T	.	47	PASS	NS=3;DP=13;AA=T	GT:GQ:DP:HQ	0|0:54:7:56,60	0|0:48:4:51,51	0/0:61:2
+20	1234567	microsat1	GTC	G,GTCT	.	.	NS=3;DP=9;AA=G;XX=undeclared	GT:GQ:DP	0/1:.:4	0/2:17:2	./.:40:3
+`
+
+// wantHeader is the header of testVCF as written.
+const wantHeader = `##fileformat=VCFv4.3
+##fileDate=20090805
+##source=myImputationProgramV3.1
+##reference=file:///seq/references/1000GenomesPilot-NCBI36.fasta
+##phasing=partial
+##FILTER=<ID=q10,Description="Quality below 10">
+##FILTER=<ID=s50,Description="Less than 50% of samples have data">
+##INFO=<ID=NS,Number=1,Type=Integer,Description="Number of Samples With Data">
+##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth">
+##INFO=<ID=AF,Number=A,Type=Float,Description="Allele Frequency">
+##INFO=<ID=AA,Number=1,Type=String,Description="Ancestral Allele">
+##INFO=<ID=DB,Number=0,Type=Flag,Description="dbSNP membership, build 129">
+##INFO=<ID=H2,Number=0,Type=Flag,Description="HapMap2 membership">
+##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">
+##FORMAT=<ID=GQ,Number=1,Type=Integer,Description="Genotype Quality">
+##FORMAT=<ID=DP,Number=1,Type=Integer,Description="Read Depth">
+##FORMAT=<ID=HQ,Number=2,Type=Integer,Description="Haplotype Quality">
+##contig=<ID=20,length=62435964,assembly=B36,md5=f126cdf8a6e0c7f379d618ff66beb2da,species="Homo sapiens",taxonomy=x>
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	NA00001	NA00002	NA00003
+`
+
+func readAll(t *testing.T, r *Reader) []*Record {
+	var recs []*Record
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return recs
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading record: %v", err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	r, err := NewReader(strings.NewReader(testVCF))
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	h := r.Header()
+	if h.Version != "VCFv4.3" {
+		t.Errorf("unexpected version: %q", h.Version)
+	}
+	if !reflect.DeepEqual(h.Samples, []string{"NA00001", "NA00002", "NA00003"}) {
+		t.Errorf("unexpected samples: %q", h.Samples)
+	}
+	c := h.Contig("20")
+	if c == nil {
+		t.Fatal("missing contig")
+	}
+	wantContig := &Contig{ID: "20", Length: 62435964, Extra: []Attr{
+		{"assembly", "B36"},
+		{"md5", "f126cdf8a6e0c7f379d618ff66beb2da"},
+		{"species", "Homo sapiens"},
+		{"taxonomy", "x"},
+	}}
+	if !reflect.DeepEqual(c, wantContig) {
+		t.Errorf("unexpected contig: got:%+v want:%+v", c, wantContig)
+	}
+	if d := h.Info("AF"); d == nil || d.Number != NumberA || d.Type != Float || d.Description != "Allele Frequency" {
+		t.Errorf("unexpected AF definition: %+v", d)
+	}
+	if d := h.Format("HQ"); d == nil || d.Number != 2 || d.Type != Integer {
+		t.Errorf("unexpected HQ definition: %+v", d)
+	}
+	if f := h.Filter("q10"); f == nil || f.Description != "Quality below 10" {
+		t.Errorf("unexpected q10 filter: %+v", f)
+	}
+	if h.Info("XX") != nil {
+		t.Error("unexpected definition for undeclared INFO field")
+	}
+
+	got, err := h.MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected error marshaling header: %v", err)
+	}
+	if string(got) != wantHeader {
+		t.Errorf("unexpected header text:\ngot:\n%s\nwant:\n%s", got, wantHeader)
+	}
+	var h2 Header
+	err = h2.UnmarshalText(got)
+	if err != nil {
+		t.Fatalf("unexpected error parsing written header: %v", err)
+	}
+	if !reflect.DeepEqual(&h2, h) {
+		t.Errorf("header did not round trip:\ngot: %+v\nwant:%+v", &h2, h)
+	}
+}
+
+func TestHeaderQuoting(t *testing.T) {
+	h := &Header{Infos: []*Definition{{
+		ID:          "Q",
+		Number:      NumberUnknown,
+		Type:        Character,
+		Description: `a "quoted" \ value`,
+		Extra:       []Attr{{"Source", "tool"}, {"Version", "1"}},
+	}}}
+	text, err := h.MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected error marshaling header: %v", err)
+	}
+	want := "##fileformat=VCFv4.3\n" +
+		`##INFO=<ID=Q,Number=.,Type=Character,Description="a \"quoted\" \\ value",Source="tool",Version="1">` + "\n" +
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n"
+	if string(text) != want {
+		t.Errorf("unexpected header text:\ngot: %q\nwant:%q", text, want)
+	}
+	var got Header
+	err = got.UnmarshalText(text)
+	if err != nil {
+		t.Fatalf("unexpected error parsing header: %v", err)
+	}
+	h.Version = DefaultVersion
+	if !reflect.DeepEqual(&got, h) {
+		t.Errorf("header did not round trip:\ngot: %+v\nwant:%+v", got.Infos[0], h.Infos[0])
+	}
+}
+
+func TestHeaderErrors(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		err  string
+	}{
+		{text: "#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n", err: ErrNoFileFormat.Error()},
+		{text: "##fileformat=VCFv4.3\n", err: ErrNoColumnHeader.Error()},
+		{text: "##fileformat=VCFv4.3\n##INFO=<ID=X,Number=1>\n", err: "vcf: header line 2: missing INFO Type"},
+		{text: "##fileformat=VCFv4.3\n##INFO=<ID=X,Number=Z,Type=Integer>\n", err: `vcf: header line 2: invalid number: "Z"`},
+		{text: "##fileformat=VCFv4.3\n##INFO=<ID=X,Number=1,Type=Long>\n", err: `vcf: header line 2: unknown type: "Long"`},
+		{text: "##fileformat=VCFv4.3\n##FILTER=<ID=X,Description=\"open>\n", err: "vcf: header line 2: unterminated quoted value"},
+		{text: "##fileformat=VCFv4.3\n##contig=<ID=1>\n##contig=<ID=1>\n", err: `vcf: header line 3: duplicate contig ID: "1"`},
+		{text: "##fileformat=VCFv4.3\n#CHROM\tPOS\n", err: "vcf: header line 2: malformed line"},
+	} {
+		var h Header
+		err := h.UnmarshalText([]byte(test.text))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("unexpected error for %q: got:%v want:%s", test.text, err, test.err)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	r, err := NewReader(strings.NewReader(testVCF))
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	recs := readAll(t, r)
+	if len(recs) != 5 {
+		t.Fatalf("unexpected number of records: got:%d want:5", len(recs))
+	}
+
+	rec := recs[0]
+	if rec.Chrom != "20" || rec.Pos != 14369 || rec.Ref != "G" || rec.Qual != 29 {
+		t.Errorf("unexpected fixed fields: %+v", rec)
+	}
+	if !reflect.DeepEqual(rec.ID, []string{"rs6054257"}) || !reflect.DeepEqual(rec.Filter, []string{"PASS"}) {
+		t.Errorf("unexpected ID or FILTER: %q %q", rec.ID, rec.Filter)
+	}
+	if v, ok := rec.InfoInt("DP"); !ok || v != 14 {
+		t.Errorf("unexpected DP: %v %t", v, ok)
+	}
+	if v, ok := rec.InfoFloats("AF"); !ok || !reflect.DeepEqual(v, []float64{0.5}) {
+		t.Errorf("unexpected AF: %v %t", v, ok)
+	}
+	if !rec.InfoFlag("DB") || !rec.InfoFlag("H2") || rec.InfoFlag("AA") {
+		t.Error("unexpected flags")
+	}
+	if _, ok := rec.InfoString("AA"); ok {
+		t.Error("unexpected AA")
+	}
+	if v, ok := rec.SampleValue(1, "HQ"); !ok || !reflect.DeepEqual(v, []int{51, 51}) {
+		t.Errorf("unexpected HQ: %v %t", v, ok)
+	}
+	if v, ok := rec.SampleValue(2, "HQ"); !ok || !reflect.DeepEqual(v, []int{MissingInt, MissingInt}) {
+		t.Errorf("unexpected missing HQ: %v %t", v, ok)
+	}
+	g, ok := rec.Genotype(1)
+	if !ok || !reflect.DeepEqual(g, Genotype{Alleles: []int{1, 0}, Phased: []bool{false, true}}) {
+		t.Errorf("unexpected genotype: %+v %t", g, ok)
+	}
+
+	rec = recs[1]
+	if _, ok := rec.SampleValue(2, "HQ"); ok {
+		t.Error("unexpected value for omitted trailing field")
+	}
+
+	rec = recs[2]
+	if !reflect.DeepEqual(rec.Alt, []string{"G", "T"}) {
+		t.Errorf("unexpected ALT: %q", rec.Alt)
+	}
+	if v, ok := rec.InfoFloats("AF"); !ok || !reflect.DeepEqual(v, []float64{0.333, 0.667}) {
+		t.Errorf("unexpected AF: %v %t", v, ok)
+	}
+	if v, ok := rec.InfoString("AA"); !ok || v != "T" {
+		t.Errorf("unexpected AA: %q %t", v, ok)
+	}
+
+	rec = recs[3]
+	if rec.Alt != nil || rec.End() != 1230237 {
+		t.Errorf("unexpected ALT or end: %q %d", rec.Alt, rec.End())
+	}
+
+	rec = recs[4]
+	if !math.IsNaN(rec.Qual) || rec.Filter != nil || rec.End() != 1234569 {
+		t.Errorf("unexpected QUAL, FILTER or end: %v %q %d", rec.Qual, rec.Filter, rec.End())
+	}
+	if v, ok := rec.InfoString("XX"); !ok || v != "undeclared" {
+		t.Errorf("unexpected undeclared value: %q %t", v, ok)
+	}
+	if v, ok := rec.SampleValue(0, "GQ"); !ok || v != nil {
+		t.Errorf("unexpected missing GQ: %v %t", v, ok)
+	}
+	g, ok = rec.Genotype(2)
+	if !ok || !reflect.DeepEqual(g, Genotype{Alleles: []int{-1, -1}, Phased: []bool{false, false}}) {
+		t.Errorf("unexpected missing genotype: %+v %t", g, ok)
+	}
+	rec.SetInfo("END", 1234570)
+	rec.SetInfo("DP", 10)
+	if rec.End() != 1234570 {
+		t.Errorf("unexpected end with END: %d", rec.End())
+	}
+	if v, _ := rec.InfoInt("DP"); v != 10 || len(rec.Info) != 5 {
+		t.Errorf("unexpected INFO after setting: %+v", rec.Info)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	r, err := NewReader(strings.NewReader(testVCF))
+	if err != nil {
+		t.Fatalf("unexpected error creating reader: %v", err)
+	}
+	recs := readAll(t, r)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, r.Header())
+	if err != nil {
+		t.Fatalf("unexpected error creating writer: %v", err)
+	}
+	for _, rec := range recs {
+		err = w.Write(rec)
+		if err != nil {
+			t.Fatalf("unexpected error writing record: %v", err)
+		}
+	}
+	want := wantHeader + testVCF[strings.Index(testVCF, "\n20\t")+1:]
+	if buf.String() != want {
+		t.Errorf("unexpected output:\ngot:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	err = w.Write(&Record{Chrom: "20", Ref: "A"})
+	if err == nil {
+		t.Error("expected error writing record with missing samples")
+	}
+	for _, rec := range []*Record{
+		{Ref: "A"},
+		{Chrom: "20"},
+		{Chrom: "20", Ref: "A", Info: []Info{{"X", int32(1)}}},
+		{Chrom: "20", Ref: "A", Format: []string{"GT"}, Samples: [][]interface{}{{"0", "1"}}},
+	} {
+		_, err = rec.MarshalText()
+		if err == nil {
+			t.Errorf("expected error marshaling %+v", rec)
+		}
+	}
+}
+
+func TestGenotype(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want Genotype
+		err  bool
+	}{
+		{text: "0", want: Genotype{Alleles: []int{0}, Phased: []bool{false}}},
+		{text: "0/1", want: Genotype{Alleles: []int{0, 1}, Phased: []bool{false, false}}},
+		{text: "1|2", want: Genotype{Alleles: []int{1, 2}, Phased: []bool{false, true}}},
+		{text: "|0|1", want: Genotype{Alleles: []int{0, 1}, Phased: []bool{true, true}}},
+		{text: "./.", want: Genotype{Alleles: []int{-1, -1}, Phased: []bool{false, false}}},
+		{text: "0/1|2", want: Genotype{Alleles: []int{0, 1, 2}, Phased: []bool{false, false, true}}},
+		{text: "a/1", err: true},
+		{text: "0/", err: true},
+	} {
+		got, err := ParseGenotype(test.text)
+		if (err != nil) != test.err {
+			t.Errorf("unexpected error for %q: %v", test.text, err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected genotype for %q: got:%+v want:%+v", test.text, got, test.want)
+		}
+		if got.String() != test.text {
+			t.Errorf("unexpected genotype text: got:%q want:%q", got.String(), test.text)
+		}
+	}
+}